@@ -12,6 +12,7 @@ package cli
 import (
 	"os"
 
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 )
 
@@ -20,6 +21,8 @@ var (
 	appName           string
 	bindPaths         []string
 	mounts            []string
+	rootfsPropagation string
+	personality       string
 	homePath          string
 	overlayPath       []string
 	scratchPath       []string
@@ -31,33 +34,44 @@ var (
 	networkArgs       []string
 	dns               string
 	security          []string
+	seccompAuditSys   []string
 	cgroupsTOMLFile   string
+	cgroupParent      string
+	coreLimit         string
+	coreDir           string
+	cdiDevices        []string
 	containLibsPath   []string
 	fuseMount         []string
 	apptainerEnv      map[string]string
 	apptainerEnvFiles []string
+	apptainerEnvJSON  string
+	envFileLiteral    bool
 	noMount           []string
 	dmtcpLaunch       string
 	dmtcpRestart      string
 
-	isBoot          bool
-	isFakeroot      bool
-	isCleanEnv      bool
-	isCompat        bool
-	isContained     bool
-	isContainAll    bool
-	isWritable      bool
-	isWritableTmpfs bool
-	nvidia          bool
-	nvCCLI          bool
-	rocm            bool
-	noEval          bool
-	noHome          bool
-	noInit          bool
-	noNvidia        bool
-	noRocm          bool
-	noUmask         bool
-	disableCache    bool
+	isBoot            bool
+	isFakeroot        bool
+	isCleanEnv        bool
+	isCompat          bool
+	isContained       bool
+	isContainAll      bool
+	isWritable        bool
+	isWritableTmpfs   bool
+	nvidia            bool
+	nvCCLI            bool
+	rocm              bool
+	noEval            bool
+	noHome            bool
+	noInit            bool
+	subreaper         bool
+	allowArchMismatch bool
+	runnerSpec        string
+	seccompAudit      bool
+	noNvidia          bool
+	noRocm            bool
+	noUmask           bool
+	disableCache      bool
 
 	netNamespace   bool
 	netnsPath      string
@@ -67,11 +81,14 @@ var (
 	noPidNamespace bool
 	ipcNamespace   bool
 
-	allowSUID bool
-	keepPrivs bool
-	noPrivs   bool
-	addCaps   string
-	dropCaps  string
+	allowSUID     bool
+	keepPrivs     bool
+	noPrivs       bool
+	noNewPrivs    bool
+	allowNewPrivs bool
+	addCaps       string
+	dropCaps      string
+	ambientCaps   bool
 
 	blkioWeight       int
 	blkioWeightDevice []string
@@ -84,6 +101,7 @@ var (
 	memorySwap        string // bytes
 	oomKillDisable    bool
 	pidsLimit         int
+	deviceCgroupRules []string
 	unsquash          bool
 
 	ignoreSubuid      bool
@@ -94,7 +112,15 @@ var (
 
 	shareNS bool // mode for launching container using shared namespace
 
+	lazyImage bool // mount http(s) images lazily instead of downloading them
+
 	runscriptTimeout string // runscript timeout
+
+	profileStartup bool   // record the duration of the startup phases
+	profileFormat  string // format of the startup phases report
+	presetNames    []string
+	useOCI         bool
+	noOCI          bool
 )
 
 // --app
@@ -132,6 +158,28 @@ var actionMountFlag = cmdline.Flag{
 	EnvHandler:   cmdline.EnvAppendValue,
 }
 
+// --rootfs-propagation
+var actionRootfsPropagationFlag = cmdline.Flag{
+	ID:           "actionRootfsPropagationFlag",
+	Value:        &rootfsPropagation,
+	DefaultValue: "",
+	Name:         "rootfs-propagation",
+	Usage:        "mount propagation of the container root filesystem (rprivate, rslave or rshared)",
+	EnvKeys:      []string{"ROOTFS_PROPAGATION"},
+	Tag:          "<mode>",
+}
+
+// --personality
+var actionPersonalityFlag = cmdline.Flag{
+	ID:           "actionPersonalityFlag",
+	Value:        &personality,
+	DefaultValue: "",
+	Name:         "personality",
+	Usage:        "execution domain of the container process (linux or linux32, reporting a 32-bit architecture for legacy images)",
+	EnvKeys:      []string{"PERSONALITY"},
+	Tag:          "<domain>",
+}
+
 // -H|--home
 var actionHomeFlag = cmdline.Flag{
 	ID:           "actionHomeFlag",
@@ -274,10 +322,30 @@ var actionSecurityFlag = cmdline.Flag{
 	Value:        &security,
 	DefaultValue: []string{},
 	Name:         "security",
-	Usage:        "enable security features (SELinux, Apparmor, Seccomp)",
+	Usage:        "enable security features (SELinux, Apparmor, Seccomp, Landlock)",
 	EnvKeys:      []string{"SECURITY"},
 }
 
+// --seccomp-audit
+var actionSeccompAuditFlag = cmdline.Flag{
+	ID:           "actionSeccompAuditFlag",
+	Value:        &seccompAudit,
+	DefaultValue: false,
+	Name:         "seccomp-audit",
+	Usage:        "log the sensitive syscalls made by the container processes without blocking them (sets no new privileges when not run with CAP_SYS_ADMIN)",
+	EnvKeys:      []string{"SECCOMP_AUDIT"},
+}
+
+// --seccomp-audit-syscalls
+var actionSeccompAuditSyscallsFlag = cmdline.Flag{
+	ID:           "actionSeccompAuditSyscallsFlag",
+	Value:        &seccompAuditSys,
+	DefaultValue: []string{},
+	Name:         "seccomp-audit-syscalls",
+	Usage:        "comma separated list of syscalls logged with --seccomp-audit instead of the default ones",
+	EnvKeys:      []string{"SECCOMP_AUDIT_SYSCALLS"},
+}
+
 // --apply-cgroups
 var actionApplyCgroupsFlag = cmdline.Flag{
 	ID:           "actionApplyCgroupsFlag",
@@ -288,6 +356,50 @@ var actionApplyCgroupsFlag = cmdline.Flag{
 	EnvKeys:      []string{"APPLY_CGROUPS"},
 }
 
+// --cgroup-parent
+var actionCgroupParentFlag = cmdline.Flag{
+	ID:           "actionCgroupParentFlag",
+	Value:        &cgroupParent,
+	DefaultValue: "",
+	Name:         "cgroup-parent",
+	Usage:        "create the container cgroup under the specified cgroup path, or slice with systemd cgroups (root only, or a cgroup containing the current process)",
+	EnvKeys:      []string{"CGROUP_PARENT"},
+	Tag:          "<path|slice>",
+}
+
+// --core-limit
+var actionCoreLimitFlag = cmdline.Flag{
+	ID:           "actionCoreLimitFlag",
+	Value:        &coreLimit,
+	DefaultValue: "",
+	Name:         "core-limit",
+	Usage:        "set the core dump size limit of the container process, 'unlimited' or a size like 512M",
+	EnvKeys:      []string{"CORE_LIMIT"},
+	Tag:          "<size|unlimited>",
+}
+
+// --core-dir
+var actionCoreDirFlag = cmdline.Flag{
+	ID:           "actionCoreDirFlag",
+	Value:        &coreDir,
+	DefaultValue: "",
+	Name:         "core-dir",
+	Usage:        "bind a host directory where the kernel writes core dumps of the container processes, raising the core dump size limit to the hard limit unless --core-limit is set",
+	EnvKeys:      []string{"CORE_DIR"},
+	Tag:          "<path>",
+}
+
+// --device
+var actionDeviceFlag = cmdline.Flag{
+	ID:           "actionDeviceFlag",
+	Value:        &cdiDevices,
+	DefaultValue: []string{},
+	Name:         "device",
+	Usage:        "add a CDI device to the container, by its fully qualified name as vendor/class=name (use 'apptainer cdi list' to list the available devices)",
+	EnvKeys:      []string{"DEVICE"},
+	Tag:          "<vendor/class=name>",
+}
+
 // hidden flag to handle APPTAINER_CONTAINLIBS environment variable
 var actionContainLibsFlag = cmdline.Flag{
 	ID:           "actionContainLibsFlag",
@@ -464,6 +576,37 @@ var actionNoInitFlag = cmdline.Flag{
 	EnvKeys:      []string{"NOSHIMINIT"},
 }
 
+// --subreaper
+var actionSubreaperFlag = cmdline.Flag{
+	ID:           "actionSubreaperFlag",
+	Value:        &subreaper,
+	DefaultValue: false,
+	Name:         "subreaper",
+	Usage:        "start shim process reaping orphaned processes of the container without --pid",
+	EnvKeys:      []string{"SUBREAPER"},
+}
+
+// --runner
+var actionRunnerFlag = cmdline.Flag{
+	ID:           "actionRunnerFlag",
+	Value:        &runnerSpec,
+	DefaultValue: "",
+	Name:         "runner",
+	Usage:        "run the container on a remote Linux runner reached over SSH (user@host or ssh://[user@]host[:port]), streaming its input and outputs",
+	EnvKeys:      []string{"RUNNER"},
+	Tag:          "<host>",
+}
+
+// --allow-arch-mismatch
+var actionAllowArchMismatchFlag = cmdline.Flag{
+	ID:           "actionAllowArchMismatchFlag",
+	Value:        &allowArchMismatch,
+	DefaultValue: false,
+	Name:         "allow-arch-mismatch",
+	Usage:        "run images targeting another architecture than the host's, when an emulator is known to be registered",
+	EnvKeys:      []string{"ALLOW_ARCH_MISMATCH"},
+}
+
 // hidden flag to disable nvidia bindings when 'always use nv = yes'
 var actionNoNvidiaFlag = cmdline.Flag{
 	ID:           "actionNoNvidiaFlag",
@@ -568,6 +711,26 @@ var actionNoPrivsFlag = cmdline.Flag{
 	EnvKeys:      []string{"NO_PRIVS"},
 }
 
+// --no-new-privs
+var actionNoNewPrivsFlag = cmdline.Flag{
+	ID:           "actionNoNewPrivsFlag",
+	Value:        &noNewPrivs,
+	DefaultValue: false,
+	Name:         "no-new-privs",
+	Usage:        "prevent container processes from gaining new privileges, e.g. with setuid binaries",
+	EnvKeys:      []string{"NO_NEW_PRIVS"},
+}
+
+// --allow-new-privs
+var actionAllowNewPrivsFlag = cmdline.Flag{
+	ID:           "actionAllowNewPrivsFlag",
+	Value:        &allowNewPrivs,
+	DefaultValue: false,
+	Name:         "allow-new-privs",
+	Usage:        "allow container processes to gain new privileges (root only)",
+	EnvKeys:      []string{"ALLOW_NEW_PRIVS"},
+}
+
 // --add-caps
 var actionAddCapsFlag = cmdline.Flag{
 	ID:           "actionAddCapsFlag",
@@ -588,6 +751,16 @@ var actionDropCapsFlag = cmdline.Flag{
 	EnvKeys:      []string{"DROP_CAPS"},
 }
 
+// --ambient-caps
+var actionAmbientCapsFlag = cmdline.Flag{
+	ID:           "actionAmbientCapsFlag",
+	Value:        &ambientCaps,
+	DefaultValue: false,
+	Name:         "ambient-caps",
+	Usage:        "place the capabilities granted to the user in the ambient set, to keep them across exec without file capabilities",
+	EnvKeys:      []string{"AMBIENT_CAPS"},
+}
+
 // --allow-setuid
 var actionAllowSetuidFlag = cmdline.Flag{
 	ID:           "actionAllowSetuidFlag",
@@ -617,6 +790,27 @@ var actionEnvFileFlag = cmdline.Flag{
 	EnvKeys:      []string{"ENV_FILE"},
 }
 
+// --env-file-literal
+var actionEnvFileLiteralFlag = cmdline.Flag{
+	ID:           "actionEnvFileLiteralFlag",
+	Value:        &envFileLiteral,
+	DefaultValue: false,
+	Name:         "env-file-literal",
+	Usage:        "read --env-file files as docker env files, with literal KEY=VALUE lines and only ${VAR} references expanded, instead of evaluating them as shell scripts",
+	EnvKeys:      []string{"ENV_FILE_LITERAL"},
+}
+
+// --env-json
+var actionEnvJSONFlag = cmdline.Flag{
+	ID:           "actionEnvJSONFlag",
+	Value:        &apptainerEnvJSON,
+	DefaultValue: "",
+	Name:         "env-json",
+	Usage:        "pass environment variables from a JSON object in file, or standard input with '-', to contained process",
+	Tag:          "<file|->",
+	EnvKeys:      []string{"ENV_JSON"},
+}
+
 // --no-umask
 var actionNoUmaskFlag = cmdline.Flag{
 	ID:           "actionNoUmask",
@@ -767,6 +961,16 @@ var actionPidsLimitFlag = cmdline.Flag{
 	EnvKeys:      []string{"PIDS_LIMIT"},
 }
 
+// --device-cgroup-rule
+var actionDeviceCgroupRuleFlag = cmdline.Flag{
+	ID:           "actionDeviceCgroupRule",
+	Value:        &deviceCgroupRules,
+	DefaultValue: []string{},
+	Name:         "device-cgroup-rule",
+	Usage:        "Add a rule to the device cgroup, as 'c 189:* rwm' to allow or 'deny c 189:* rwm' to deny access to devices",
+	EnvKeys:      []string{"DEVICE_CGROUP_RULE"},
+}
+
 // --unsquash
 var actionUnsquashFlag = cmdline.Flag{
 	ID:           "actionUnsquashFlag",
@@ -833,6 +1037,16 @@ var actionShareNSFlag = cmdline.Flag{
 	Hidden:       false,
 }
 
+// --lazy
+var actionLazyFlag = cmdline.Flag{
+	ID:           "actionLazyFlag",
+	Value:        &lazyImage,
+	DefaultValue: false,
+	Name:         "lazy",
+	Usage:        "mount http(s) images lazily, fetching their content with range requests when read instead of downloading them",
+	EnvKeys:      []string{"LAZY"},
+}
+
 // --runscript-timeout
 var actionRunscriptTimeoutFlag = cmdline.Flag{
 	ID:           "runscriptTimeoutFlag",
@@ -844,14 +1058,76 @@ var actionRunscriptTimeoutFlag = cmdline.Flag{
 	Hidden:       false,
 }
 
+// --profile
+var actionProfileFlag = cmdline.Flag{
+	ID:           "actionProfileFlag",
+	Value:        &profileStartup,
+	DefaultValue: false,
+	Name:         "profile",
+	Usage:        "record the duration of the startup phases of the container, and report them to stderr at exit",
+	EnvKeys:      []string{"PROFILE"},
+}
+
+// --preset
+var actionPresetFlag = cmdline.Flag{
+	ID:           "actionPresetFlag",
+	Value:        &presetNames,
+	DefaultValue: []string{},
+	Name:         "preset",
+	Usage:        "apply the named flag presets, a comma separated list, defined in apptainer.conf or ~/.apptainer/presets.conf",
+	EnvKeys:      []string{"PRESET"},
+}
+
+// --oci
+var actionOCIFlag = cmdline.Flag{
+	ID:           "actionOCIFlag",
+	Value:        &useOCI,
+	DefaultValue: false,
+	Name:         "oci",
+	Usage:        "run the image with the OCI runtime (root only), whatever the default runtime",
+	EnvKeys:      []string{"OCI"},
+}
+
+// --no-oci
+var actionNoOCIFlag = cmdline.Flag{
+	ID:           "actionNoOCIFlag",
+	Value:        &noOCI,
+	DefaultValue: false,
+	Name:         "no-oci",
+	Usage:        "run the image with the native runtime, whatever the default runtime",
+	EnvKeys:      []string{"NO_OCI"},
+}
+
+// --profile-format
+var actionProfileFormatFlag = cmdline.Flag{
+	ID:           "actionProfileFormatFlag",
+	Value:        &profileFormat,
+	DefaultValue: profile.FormatText,
+	Name:         "profile-format",
+	Usage:        "format of the --profile report (text or json)",
+	EnvKeys:      []string{"PROFILE_FORMAT"},
+}
+
 // --netns-path
 var actionNetnsPathFlag = cmdline.Flag{
 	ID:           "actionNetnsPathFlag",
 	Value:        &netnsPath,
 	DefaultValue: "",
 	Name:         "netns-path",
-	Usage:        "join the network namespace at the specified path (as root, or if permitted in apptainer.conf)",
+	Usage:        "join the network namespace at the specified path, or created by 'ip netns add' with the specified name (as root, or if permitted in apptainer.conf)",
 	EnvKeys:      []string{"NETNS_PATH"},
+	Tag:          "<path|name>",
+}
+
+// --netns
+var actionNetnsFlag = cmdline.Flag{
+	ID:           "actionNetnsFlag",
+	Value:        &netnsPath,
+	DefaultValue: "",
+	Name:         "netns",
+	Usage:        "join the network namespace at the specified path, or created by 'ip netns add' with the specified name (synonym for --netns-path)",
+	EnvKeys:      []string{"NETNS"},
+	Tag:          "<path|name>",
 }
 
 func init() {
@@ -876,9 +1152,14 @@ func init() {
 		actionsRunscriptCmd := cmdManager.GetCmdGroup("actions_runscript")
 
 		cmdManager.RegisterFlagForCmd(&actionAddCapsFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionAmbientCapsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionAllowSetuidFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionAppFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionApplyCgroupsFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionCgroupParentFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionCoreLimitFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionCoreDirFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionDeviceFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionBindFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionCleanEnvFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionCompatFlag, actionsInstanceCmd...)
@@ -887,6 +1168,9 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&actionContainLibsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionDisableCacheFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionDNSFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonLockfileFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonLockedFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonRequireDigestFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionDropCapsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionFakerootFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionFuseMountFlag, actionsInstanceCmd...)
@@ -895,28 +1179,40 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&actionIpcNamespaceFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionKeepPrivsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionMountFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionRootfsPropagationFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionPersonalityFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNetNamespaceFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNetnsPathFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionNetnsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNetworkArgsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNetworkFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoHomeFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoMountFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoInitFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionSubreaperFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionAllowArchMismatchFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionRunnerFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoNvidiaFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoRocmFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoPrivsFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionNoNewPrivsFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionAllowNewPrivsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNvidiaFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNvCCLIFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionRocmFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionOverlayFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&commonPromptForPassphraseFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&commonPEMFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonAgeFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonPKCS11Flag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionPidNamespaceFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoPidNamespaceFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&actionCwdFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&actionPwdFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&actionScratchFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionSecurityFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionSeccompAuditFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionSeccompAuditSyscallsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionShellFlag, ShellCmd)
 		cmdManager.RegisterFlagForCmd(&actionTmpDirFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionUserNamespaceFlag, actionsInstanceCmd...)
@@ -932,6 +1228,8 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionEnvFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionEnvFileFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionEnvFileLiteralFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionEnvJSONFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoUmaskFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionNoEvalFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionBlkioWeightFlag, actionsInstanceCmd...)
@@ -945,13 +1243,22 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&actionMemorySwapFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionOomKillDisableFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionPidsLimitFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionDeviceCgroupRuleFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionUnsquashFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionIgnoreSubuidFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionIgnoreFakerootCommand, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionIgnoreUsernsFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionUnderlayFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionShareNSFlag, actionsCmd...)
+		cmdManager.RegisterFlagForCmd(&actionLazyFlag, actionsCmd...)
+		cmdManager.RegisterFlagForCmd(&actionProfileFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionProfileFormatFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionPresetFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&actionOCIFlag, actionsCmd...)
+		cmdManager.RegisterFlagForCmd(&actionNoOCIFlag, actionsCmd...)
 		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonHTTPHeaderFlag, actionsInstanceCmd...)
+		cmdManager.RegisterFlagForCmd(&commonHTTPTokenFlag, actionsInstanceCmd...)
 		cmdManager.RegisterFlagForCmd(&actionRunscriptTimeoutFlag, actionsRunscriptCmd...)
 	})
 }
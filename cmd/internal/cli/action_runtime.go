@@ -0,0 +1,104 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// defaultRuntimeDirective is the directive selecting the runtime of the
+// action commands in apptainer.conf and in the user settings file.
+const defaultRuntimeDirective = "default runtime"
+
+// actionRuntime returns the runtime running image with the action commands,
+// apptainer.RuntimeNative or apptainer.RuntimeOCI, selected with --oci or
+// --no-oci, or else by the default runtime directive of the user settings
+// file at userPath, or else of the configuration.
+func actionRuntime(image, userPath string) (string, error) {
+	setting := apptainer.RuntimeAuto
+	switch {
+	case useOCI && noOCI:
+		return "", fmt.Errorf("--oci and --no-oci are mutually exclusive")
+	case useOCI:
+		setting = apptainer.RuntimeOCI
+	case noOCI:
+		setting = apptainer.RuntimeNative
+	default:
+		if cfg := apptainerconf.GetCurrentConfig(); cfg != nil && cfg.DefaultRuntime != "" {
+			setting = cfg.DefaultRuntime
+		}
+		user, err := userDefaultRuntime(userPath)
+		if err != nil {
+			return "", err
+		}
+		if user != "" {
+			setting = user
+		}
+	}
+	return apptainer.ActionRuntime(setting, image)
+}
+
+// userDefaultRuntime returns the value of the last default runtime
+// directive of the user settings file at path, if any.
+func userDefaultRuntime(path string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	directives, err := apptainerconf.GetDirectives(f)
+	if err != nil {
+		return "", fmt.Errorf("while parsing %s: %w", path, err)
+	}
+	values := directives[defaultRuntimeDirective]
+	if len(values) == 0 {
+		return "", nil
+	}
+	return values[len(values)-1], nil
+}
+
+// ociLaunchFlags are the action flags applying to containers run with the
+// OCI runtime.
+var ociLaunchFlags = map[string]bool{
+	actionOCIFlag.Name:    true,
+	actionNoOCIFlag.Name:  true,
+	actionPresetFlag.Name: true,
+}
+
+// ociLaunch runs the action given by args, the action script followed by its
+// arguments, in a container created by the OCI runtime from image, exiting
+// with the exit status of the container.
+func ociLaunch(cmd *cobra.Command, image string, args []string, instanceName string) error {
+	if instanceName != "" {
+		return fmt.Errorf("instances can't be started with the OCI runtime, use --no-oci or 'apptainer oci run'")
+	}
+	cmd.LocalNonPersistentFlags().Visit(func(f *pflag.Flag) {
+		if !ociLaunchFlags[f.Name] {
+			sylog.Warningf("Ignoring --%s, not supported with the OCI runtime", f.Name)
+		}
+	})
+
+	imgCache := getCacheHandle(cache.Config{Disable: disableCache})
+	err := apptainer.OciAction(cmd.Context(), image, args, imgCache)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	return err
+}
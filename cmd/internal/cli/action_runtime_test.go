@@ -0,0 +1,55 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+)
+
+func TestActionRuntime(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.sif")
+	if err := os.WriteFile(image, []byte("native image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(dir, "settings.conf")
+	missing := filepath.Join(dir, "missing.conf")
+	if err := os.WriteFile(settings, []byte("# user settings\ndefault runtime = native\ndefault runtime = oci\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { useOCI, noOCI = false, false }()
+
+	tests := []struct {
+		name     string
+		oci      bool
+		noOCI    bool
+		settings string
+		want     string
+		wantErr  bool
+	}{
+		{name: "Default", settings: missing, want: apptainer.RuntimeNative},
+		{name: "User", settings: settings, want: apptainer.RuntimeOCI},
+		{name: "NoOCI", noOCI: true, settings: settings, want: apptainer.RuntimeNative},
+		{name: "OCI", oci: true, settings: missing, want: apptainer.RuntimeOCI},
+		{name: "Conflict", oci: true, noOCI: true, settings: missing, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useOCI, noOCI = tt.oci, tt.noOCI
+			got, err := actionRuntime(image, tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got runtime %q, expected %q", got, tt.want)
+			}
+		})
+	}
+}
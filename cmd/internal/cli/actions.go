@@ -20,18 +20,27 @@ import (
 	"syscall"
 
 	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
 	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client/azblob"
+	"github.com/apptainer/apptainer/internal/pkg/client/gcs"
+	"github.com/apptainer/apptainer/internal/pkg/client/ipfs"
 	"github.com/apptainer/apptainer/internal/pkg/client/library"
 	"github.com/apptainer/apptainer/internal/pkg/client/net"
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
 	"github.com/apptainer/apptainer/internal/pkg/client/oci"
 	"github.com/apptainer/apptainer/internal/pkg/client/oras"
+	"github.com/apptainer/apptainer/internal/pkg/client/s3"
 	"github.com/apptainer/apptainer/internal/pkg/client/shub"
 	"github.com/apptainer/apptainer/internal/pkg/instance"
 	"github.com/apptainer/apptainer/internal/pkg/ociimage"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/launch"
 	"github.com/apptainer/apptainer/internal/pkg/util/env"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/syfs"
 	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 	"github.com/apptainer/apptainer/pkg/util/fs/lock"
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
@@ -57,6 +66,32 @@ func getCacheHandle(cfg cache.Config) *cache.Handle {
 
 // actionPreRun will run replaceURIWithImage and will also do the proper path unsetting
 func actionPreRun(cmd *cobra.Command, args []string) {
+	// apply the flag presets first, they may set any other flag
+	if len(presetNames) > 0 {
+		presets, err := loadPresets(syfs.Presets())
+		if err != nil {
+			sylog.Fatalf("While loading flag presets: %s", err)
+		}
+		if err := applyPresets(cmd, presetNames, presets); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	}
+
+	// proxy the action to a remote runner, the container is not run locally
+	if runnerSpec != "" {
+		code, err := runOnRunner(cmd, args)
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		os.Exit(code)
+	}
+
+	if profileStartup {
+		if err := profile.Enable(profileFormat); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	}
+
 	// For compatibility - we still set USER_PATH so it will be visible in the
 	// container, and can be used there if needed. USER_PATH is not used by
 	// apptainer itself in 1.0.0+
@@ -65,7 +100,9 @@ func actionPreRun(cmd *cobra.Command, args []string) {
 
 	os.Setenv("IMAGE_ARG", args[0])
 
+	endResolve := profile.Begin("image resolution")
 	replaceURIWithImage(cmd.Context(), cmd, args)
+	endResolve()
 
 	// --compat infers other options that give increased OCI / Docker compatibility
 	// Excludes uts/user/net namespaces as these are restrictive for many Apptainer
@@ -82,7 +119,7 @@ func actionPreRun(cmd *cobra.Command, args []string) {
 func handleOCI(ctx context.Context, imgCache *cache.Handle, cmd *cobra.Command, pullFrom string) (string, error) {
 	ociAuth, err := makeOCICredentials(cmd)
 	if err != nil {
-		sylog.Fatalf("While creating Docker credentials: %v", err)
+		return "", fmt.Errorf("while creating docker credentials: %v", err)
 	}
 
 	pullOpts := oci.PullOptions{
@@ -104,12 +141,23 @@ func handleOras(ctx context.Context, imgCache *cache.Handle, cmd *cobra.Command,
 	return oras.Pull(ctx, imgCache, pullFrom, tmpDir, ociAuth, noHTTPS, reqAuthFile)
 }
 
-func handleLibrary(ctx context.Context, imgCache *cache.Handle, pullFrom string) (string, error) {
+func handleLibrary(ctx context.Context, imgCache *cache.Handle, cmd *cobra.Command, pullFrom string) (string, error) {
 	r, err := library.NormalizeLibraryRef(pullFrom)
 	if err != nil {
 		return "", err
 	}
 
+	orasURI, err := getLibraryOrasURI(r)
+	if err != nil {
+		return "", err
+	}
+	if orasURI != "" {
+		if err := checkRegistryPolicy(orasURI); err != nil {
+			return "", err
+		}
+		return handleOras(ctx, imgCache, cmd, orasURI)
+	}
+
 	// Default "" = use current remote endpoint
 	var libraryURI string
 	if r.Host != "" {
@@ -132,7 +180,59 @@ func handleShub(ctx context.Context, imgCache *cache.Handle, pullFrom string) (s
 }
 
 func handleNet(ctx context.Context, imgCache *cache.Handle, pullFrom string) (string, error) {
-	return net.Pull(ctx, imgCache, pullFrom, tmpDir)
+	headers, err := net.ParseHeaders(httpHeaders, httpToken)
+	if err != nil {
+		return "", err
+	}
+	return net.Pull(ctx, imgCache, pullFrom, tmpDir, net.PullOptions{Headers: headers})
+}
+
+// mountLazy mounts the http(s) image at netURL lazily, and returns the path
+// of the mounted image.
+func mountLazy(netURL string) (string, error) {
+	headers, err := net.ParseHeaders(httpHeaders, httpToken)
+	if err != nil {
+		return "", err
+	}
+	return net.MountLazy(netURL, net.LazyOptions{Headers: headers, TmpDir: tmpDir})
+}
+
+// getObjectBackend returns the object storage backend of the transport,
+// configured from the environment of the cloud provider.
+func getObjectBackend(transport string) (objstore.Backend, error) {
+	switch transport {
+	case objstore.S3:
+		cfg, err := s3.DefaultConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get S3 configuration: %v", err)
+		}
+		return s3.NewClient(cfg), nil
+	case objstore.GS:
+		cfg, err := gcs.DefaultConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get Google Cloud Storage configuration: %v", err)
+		}
+		return gcs.NewClient(cfg), nil
+	case objstore.AzBlob:
+		cfg, err := azblob.DefaultConfig()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get Azure Blob Storage configuration: %v", err)
+		}
+		return azblob.NewClient(cfg), nil
+	}
+	return nil, fmt.Errorf("unsupported object storage transport: %s", transport)
+}
+
+func handleObject(ctx context.Context, imgCache *cache.Handle, transport, pullFrom string) (string, error) {
+	b, err := getObjectBackend(transport)
+	if err != nil {
+		return "", err
+	}
+	return objstore.Pull(ctx, b, imgCache, pullFrom, tmpDir)
+}
+
+func handleIPFS(ctx context.Context, imgCache *cache.Handle, pullFrom string) (string, error) {
+	return ipfs.Pull(ctx, imgCache, pullFrom, tmpDir)
 }
 
 func replaceURIWithImage(ctx context.Context, cmd *cobra.Command, args []string) {
@@ -142,8 +242,18 @@ func replaceURIWithImage(ctx context.Context, cmd *cobra.Command, args []string)
 		return
 	}
 
-	var image string
-	var err error
+	enforceRegistryPolicy(args[0])
+	enforceImageDigest(args[0])
+
+	// the lazy mount is released with the process, not shared between containers
+	if lazyImage && !shareNS && (t == uri.HTTP || t == uri.HTTPS) {
+		image, err := mountLazy(args[0])
+		if err == nil {
+			args[0] = image
+			return
+		}
+		sylog.Warningf("Unable to mount %s lazily, downloading it: %s", args[0], err)
+	}
 
 	// Create a cache handle only when we know we are using a URI
 	imgCache := getCacheHandle(cache.Config{Disable: disableCache})
@@ -151,28 +261,79 @@ func replaceURIWithImage(ctx context.Context, cmd *cobra.Command, args []string)
 		sylog.Fatalf("failed to create a new image cache handle")
 	}
 
+	image, err := pullToCache(ctx, imgCache, cmd, args[0])
+	if err != nil {
+		sylog.Fatalf("Unable to handle %s uri: %v", args[0], err)
+	}
+
+	args[0] = image
+}
+
+// pullToCache pulls the image at imageURI into the cache, converting it to
+// SIF if needed, and returns the path of the cached image.
+func pullToCache(ctx context.Context, imgCache *cache.Handle, cmd *cobra.Command, imageURI string) (string, error) {
+	imageURI, err := lockReference(ctx, cmd, imageURI)
+	if err != nil {
+		return "", err
+	}
+
+	t, _ := uri.Split(imageURI)
+	useTransportTmpDir(cmd, t)
 	switch t {
 	case uri.Library:
-		image, err = handleLibrary(ctx, imgCache, args[0])
+		return handleLibrary(ctx, imgCache, cmd, imageURI)
 	case uri.Oras:
-		image, err = handleOras(ctx, imgCache, cmd, args[0])
+		return handleOras(ctx, imgCache, cmd, imageURI)
 	case uri.Shub:
-		image, err = handleShub(ctx, imgCache, args[0])
+		return handleShub(ctx, imgCache, imageURI)
 	case ociimage.SupportedTransport(t):
-		image, err = handleOCI(ctx, imgCache, cmd, args[0])
+		return handleOCI(ctx, imgCache, cmd, imageURI)
 	case uri.HTTP:
-		image, err = handleNet(ctx, imgCache, args[0])
+		return handleNet(ctx, imgCache, imageURI)
 	case uri.HTTPS:
-		image, err = handleNet(ctx, imgCache, args[0])
-	default:
-		sylog.Fatalf("Unsupported transport type: %s", t)
+		return handleNet(ctx, imgCache, imageURI)
+	case uri.S3, uri.GS, uri.AzBlob:
+		return handleObject(ctx, imgCache, t, imageURI)
+	case uri.IPFS:
+		return handleIPFS(ctx, imgCache, imageURI)
 	}
+	return handlePluginTransport(ctx, imgCache, t, imageURI)
+}
 
-	if err != nil {
-		sylog.Fatalf("Unable to handle %s uri: %v", args[0], err)
+// checkRegistryPolicy returns an error if the image URI refers to a registry
+// not satisfying the allowed / denied registries lists of apptainer.conf.
+func checkRegistryPolicy(imageURI string) error {
+	cfg := apptainerconf.GetCurrentConfig()
+	if cfg == nil {
+		return nil
+	}
+	return ociimage.CheckRegistryPolicy(imageURI, cfg.AllowedRegistries, cfg.DeniedRegistries)
+}
+
+// checkImageDigest returns an error if the image URI doesn't include a digest
+// while one is required by --require-digest or apptainer.conf.
+func checkImageDigest(imageURI string) error {
+	cfg := apptainerconf.GetCurrentConfig()
+	if !requireDigest && (cfg == nil || !cfg.RequireImageDigest) {
+		return nil
 	}
+	return ociimage.CheckImageDigest(imageURI)
+}
 
-	args[0] = image
+// enforceRegistryPolicy aborts if the image URI refers to a registry not
+// satisfying the allowed / denied registries lists of apptainer.conf.
+func enforceRegistryPolicy(imageURI string) {
+	if err := checkRegistryPolicy(imageURI); err != nil {
+		sylog.Fatalf("%s", err)
+	}
+}
+
+// enforceImageDigest aborts if the image URI doesn't include a digest while
+// one is required.
+func enforceImageDigest(imageURI string) {
+	if err := checkImageDigest(imageURI); err != nil {
+		sylog.Fatalf("%s", err)
+	}
 }
 
 // ExecCmd represents the exec command
@@ -280,6 +441,19 @@ var TestCmd = &cobra.Command{
 }
 
 func launchContainer(cmd *cobra.Command, image string, args []string, instanceName string, fd int) error {
+	selected, err := actionRuntime(image, syfs.Settings())
+	if err != nil {
+		return err
+	}
+	if selected == apptainer.RuntimeOCI {
+		return ociLaunch(cmd, image, args, instanceName)
+	}
+	// OCI-SIF images hold an OCI image layout instead of a root filesystem
+	// partition, which only the OCI runtime can run
+	if apptainer.IsOCISIF(image) {
+		return fmt.Errorf("%s is an OCI-SIF image, which the native runtime can't run: run it with --oci as root, or convert it to a native SIF image with 'apptainer convert'", image)
+	}
+
 	ns := launch.Namespaces{
 		User:  userNamespace,
 		UTS:   utsNamespace,
@@ -311,7 +485,7 @@ func launchContainer(cmd *cobra.Command, image string, args []string, instanceNa
 		launch.OptWorkDir(workdirPath),
 		launch.OptHome(
 			homePath,
-			cmd.Flag(actionHomeFlag.Name).Changed,
+			cmd.Flags().Changed(actionHomeFlag.Name),
 			noHome,
 		),
 		launch.OptMounts(bindPaths, mounts, fuseMount),
@@ -322,25 +496,39 @@ func launchContainer(cmd *cobra.Command, image string, args []string, instanceNa
 		launch.OptNoRocm(noRocm),
 		launch.OptContainLibs(containLibsPath),
 		launch.OptEnv(apptainerEnv, apptainerEnvFiles, isCleanEnv),
+		launch.OptEnvFileLiteral(envFileLiteral),
+		launch.OptEnvJSON(apptainerEnvJSON),
 		launch.OptNoEval(noEval),
 		launch.OptNamespaces(ns),
 		launch.OptNetnsPath(netnsPath),
 		launch.OptNetwork(network, networkArgs),
+		launch.OptStaticAddress(instanceStartIP, instanceStartMAC),
+		launch.OptLogDriver(instanceStartLogDriver),
 		launch.OptHostname(hostname),
 		launch.OptDNS(dns),
 		launch.OptCaps(addCaps, dropCaps),
+		launch.OptAmbientCaps(ambientCaps),
 		launch.OptAllowSUID(allowSUID),
 		launch.OptKeepPrivs(keepPrivs),
 		launch.OptNoPrivs(noPrivs),
+		launch.OptNewPrivs(noNewPrivs, allowNewPrivs),
 		launch.OptSecurity(security),
+		launch.OptSeccompAudit(seccompAudit, seccompAuditSys),
 		launch.OptNoUmask(noUmask),
+		launch.OptRootfsPropagation(rootfsPropagation),
+		launch.OptPersonality(personality),
 		launch.OptCgroupsJSON(cgJSON),
+		launch.OptCgroupParent(cgroupParent),
+		launch.OptCoreDump(coreLimit, coreDir),
+		launch.OptDevices(cdiDevices),
 		launch.OptConfigFile(configurationFile),
 		launch.OptShellPath(shellPath),
 		launch.OptCwdPath(cwdPath),
 		launch.OptFakeroot(isFakeroot),
 		launch.OptBoot(isBoot),
 		launch.OptNoInit(noInit),
+		launch.OptSubreaper(subreaper),
+		launch.OptAllowArchMismatch(allowArchMismatch),
 		launch.OptContain(isContained),
 		launch.OptContainAll(isContainAll),
 		launch.OptAppName(appName),
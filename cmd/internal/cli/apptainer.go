@@ -27,6 +27,7 @@ import (
 
 	"github.com/apptainer/apptainer/docs"
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/client/library"
 	"github.com/apptainer/apptainer/internal/pkg/plugin"
 	"github.com/apptainer/apptainer/internal/pkg/remote"
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
@@ -61,6 +62,8 @@ var (
 	dockerHost  string
 
 	encryptionPEMPath   string
+	encryptionAgePath   string
+	encryptionPKCS11URI string
 	promptForPassphrase bool
 	forceOverwrite      bool
 	noHTTPS             bool
@@ -68,6 +71,14 @@ var (
 	tmpDir              string
 	// Optional user requested authentication file for writing/reading OCI registry credentials
 	reqAuthFile string
+	// Additional headers and bearer token for http(s) image sources
+	httpHeaders []string
+	httpToken   string
+	// Lockfile of the digests of tag-based docker:// and oras:// references
+	lockfilePath string
+	lockedMode   bool
+	// Reject docker:// and oras:// references without a digest
+	requireDigest bool
 )
 
 // apptainer command flags
@@ -78,6 +89,7 @@ var (
 	verbose bool
 	quiet   bool
 
+	logFormat         string
 	configurationFile string
 )
 
@@ -102,6 +114,16 @@ var singNoColorFlag = cmdline.Flag{
 	EnvKeys:      []string{"NOCOLOR"},
 }
 
+// --log-format
+var singLogFormatFlag = cmdline.Flag{
+	ID:           "singLogFormatFlag",
+	Value:        &logFormat,
+	DefaultValue: "",
+	Name:         "log-format",
+	Usage:        "format of log messages: text or json (default from apptainer.conf)",
+	EnvKeys:      []string{"MESSAGEFORMAT"},
+}
+
 // -s|--silent
 var singSilentFlag = cmdline.Flag{
 	ID:           "singSilentFlag",
@@ -198,6 +220,24 @@ var commonPEMFlag = cmdline.Flag{
 	Usage:        "enter an path to a PEM formatted RSA key for an encrypted container",
 }
 
+// --age-path
+var commonAgeFlag = cmdline.Flag{
+	ID:           "actionEncryptionAgePath",
+	Value:        &encryptionAgePath,
+	DefaultValue: "",
+	Name:         "age-path",
+	Usage:        "enter a path to an age recipients file (build) or identity file (run) for an encrypted container",
+}
+
+// --pkcs11-uri
+var commonPKCS11Flag = cmdline.Flag{
+	ID:           "actionEncryptionPKCS11URI",
+	Value:        &encryptionPKCS11URI,
+	DefaultValue: "",
+	Name:         "pkcs11-uri",
+	Usage:        "enter a PKCS#11 URI of an RSA key held by a hardware token for an encrypted container",
+}
+
 // -F|--force
 var commonForceFlag = cmdline.Flag{
 	ID:           "commonForceFlag",
@@ -270,6 +310,81 @@ var commonAuthFileFlag = cmdline.Flag{
 	EnvKeys:      []string{"AUTH_FILE"},
 }
 
+// --http-header
+var commonHTTPHeaderFlag = cmdline.Flag{
+	ID:           "commonHTTPHeaderFlag",
+	Value:        &httpHeaders,
+	DefaultValue: cmdline.StringArray{},
+	Name:         "http-header",
+	Usage:        "add a header to requests made to http(s) image sources (can be specified multiple times)",
+	Tag:          "<name: value>",
+}
+
+// --http-token
+var commonHTTPTokenFlag = cmdline.Flag{
+	ID:           "commonHTTPTokenFlag",
+	Value:        &httpToken,
+	DefaultValue: "",
+	Name:         "http-token",
+	Usage:        "bearer token used to authenticate requests made to http(s) image sources",
+	EnvKeys:      []string{"HTTP_TOKEN"},
+}
+
+// --lockfile
+var commonLockfileFlag = cmdline.Flag{
+	ID:           "commonLockfileFlag",
+	Value:        &lockfilePath,
+	DefaultValue: "",
+	Name:         "lockfile",
+	Usage:        "record the digests of tag-based docker:// and oras:// images in this lockfile (apptainer.lock with --locked)",
+	EnvKeys:      []string{"LOCKFILE"},
+}
+
+// --locked
+var commonLockedFlag = cmdline.Flag{
+	ID:           "commonLockedFlag",
+	Value:        &lockedMode,
+	DefaultValue: false,
+	Name:         "locked",
+	Usage:        "refuse images that don't match the digests recorded in the lockfile",
+	EnvKeys:      []string{"LOCKED"},
+}
+
+// --require-digest
+var commonRequireDigestFlag = cmdline.Flag{
+	ID:           "commonRequireDigestFlag",
+	Value:        &requireDigest,
+	DefaultValue: false,
+	Name:         "require-digest",
+	Usage:        "reject docker:// and oras:// images referenced by a tag without an explicit @sha256: digest",
+	EnvKeys:      []string{"REQUIRE_DIGEST"},
+}
+
+// useTransportTmpDir sets the temporary directory used to pull and convert
+// the images of transport to the directory set in the configuration, unless
+// a temporary directory is set with --tmpdir or APPTAINER_TMPDIR.
+func useTransportTmpDir(cmd *cobra.Command, transport string) {
+	if f := cmd.Flag(commonTmpDirFlag.Name); f != nil && f.Changed {
+		return
+	}
+	cfg := apptainerconf.GetCurrentConfig()
+	if cfg == nil {
+		return
+	}
+	for _, e := range cfg.TransportTmpDirs {
+		t, dir, ok := strings.Cut(e, ":")
+		if !ok || dir == "" {
+			sylog.Warningf("Ignoring invalid transport tmp dirs entry %q, expected <transport>:<directory>", e)
+			continue
+		}
+		if strings.TrimSpace(t) == transport {
+			tmpDir = strings.TrimSpace(dir)
+			sylog.Debugf("Using temporary directory %s for %s images", tmpDir, transport)
+			return
+		}
+	}
+}
+
 func getCurrentUser() *user.User {
 	usr, err := user.Current()
 	if err != nil {
@@ -313,6 +428,19 @@ func setSylogMessageLevel() {
 	}
 
 	sylog.SetLevel(level, color)
+
+	if logFormat != "" {
+		setSylogFormat(logFormat)
+	}
+}
+
+// setSylogFormat sets the log messages format and propagates it to
+// nested apptainer calls and child processes.
+func setSylogFormat(format string) {
+	if err := sylog.SetFormat(format); err != nil {
+		sylog.Fatalf("While setting log format: %s", err)
+	}
+	os.Setenv(sylog.FormatEnv, format)
 }
 
 // handleRemoteConf will make sure your 'remote.yaml' config file
@@ -527,12 +655,15 @@ func persistentPreRun(cmd *cobra.Command, _ []string) error {
 		}
 
 		sylog.Debugf("Parsing configuration file %s", configurationFile)
-		config, err = apptainerconf.Parse(configurationFile)
+		config, err = apptainerconf.ParseWithOverrides(configurationFile, os.Getuid())
 		if err != nil {
 			return fmt.Errorf("couldn't parse configuration file %s: %s", configurationFile, err)
 		}
 	}
 	apptainerconf.SetCurrentConfig(config)
+	if logFormat == "" && config.LogFormat != "" {
+		setSylogFormat(config.LogFormat)
+	}
 	// Include the user's PATH for now.
 	// It will be overridden later if using setuid flow.
 	apptainerconf.SetBinaryPath(buildcfg.LIBEXECDIR, true)
@@ -590,6 +721,7 @@ func Init(loadPlugins bool) {
 
 	cmdManager.RegisterFlagForCmd(&singDebugFlag, apptainerCmd)
 	cmdManager.RegisterFlagForCmd(&singNoColorFlag, apptainerCmd)
+	cmdManager.RegisterFlagForCmd(&singLogFormatFlag, apptainerCmd)
 	cmdManager.RegisterFlagForCmd(&singSilentFlag, apptainerCmd)
 	cmdManager.RegisterFlagForCmd(&singQuietFlag, apptainerCmd)
 	cmdManager.RegisterFlagForCmd(&singVerboseFlag, apptainerCmd)
@@ -789,9 +921,16 @@ func getRemote() (*endpoint.Config, error) {
 		}
 		help += strings.Join(endpoints, ", ")
 		return nil, fmt.Errorf("no default endpoint set: %s", help)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// use the access token obtained with 'remote login --oidc', if any
+	if err := ep.LoadOAuthToken(context.Background()); err != nil {
+		sylog.Warningf("Unable to refresh OIDC access token, please login again: %v", err)
 	}
 
-	return ep, err
+	return ep, nil
 }
 
 func apptainerExec(image string, args []string) (string, error) {
@@ -892,3 +1031,30 @@ func getLibraryClientConfig(uri string) (*libClient.Config, error) {
 	}
 	return libClientConfig, nil
 }
+
+// getLibraryOrasURI returns the oras:// URI serving the library reference
+// ref when the current remote endpoint declares an OCI registry as its
+// library backend, or an empty string otherwise. References containing a
+// host name are always resolved against that library server.
+func getLibraryOrasURI(ref *libClient.Ref) (string, error) {
+	if ref.Host != "" {
+		return "", nil
+	}
+	if currentRemoteEndpoint == nil {
+		var err error
+
+		currentRemoteEndpoint, err = getRemote()
+		if err != nil {
+			return "", fmt.Errorf("unable to load remote configuration: %v", err)
+		}
+	}
+	if currentRemoteEndpoint.LibraryRegistry == "" {
+		return "", nil
+	}
+	orasURI, err := library.OrasURI(currentRemoteEndpoint.LibraryRegistry, ref)
+	if err != nil {
+		return "", err
+	}
+	sylog.Debugf("Resolved library reference %s to %s", ref.String(), orasURI)
+	return orasURI, nil
+}
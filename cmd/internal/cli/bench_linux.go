@@ -0,0 +1,90 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchIterations int
+	benchIOSize     int
+)
+
+// --iterations
+var benchIterationsFlag = cmdline.Flag{
+	ID:           "benchIterationsFlag",
+	Value:        &benchIterations,
+	DefaultValue: 5,
+	Name:         "iterations",
+	Usage:        "number of runs of each workload, whose median is reported",
+}
+
+// --io-size
+var benchIOSizeFlag = cmdline.Flag{
+	ID:           "benchIOSizeFlag",
+	Value:        &benchIOSize,
+	DefaultValue: 64,
+	Name:         "io-size",
+	Usage:        "size in MiB of the file written and read by the I/O workloads",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(BenchCmd)
+
+		cmdManager.RegisterFlagForCmd(&benchIterationsFlag, BenchCmd)
+		cmdManager.RegisterFlagForCmd(&benchIOSizeFlag, BenchCmd)
+		cmdManager.RegisterFlagForCmd(&commonTmpDirFlag, BenchCmd)
+
+		// the options following the image are passed to 'apptainer exec'
+		BenchCmd.Flags().SetInterspersed(false)
+	})
+}
+
+// BenchCmd is the 'bench' command measuring the overhead of containers
+// over native runs.
+var BenchCmd = &cobra.Command{
+	Args:                  cobra.MinimumNArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := apptainer.BenchOptions{
+			Iterations:  benchIterations,
+			IOSize:      benchIOSize,
+			ExecOptions: args[1:],
+			TmpDir:      tmpDir,
+		}
+		results, err := apptainer.Bench(cmd.Context(), args[0], opts)
+		if err != nil {
+			sylog.Fatalf("Unable to run benchmark: %s", err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", "WORKLOAD", "NATIVE", "CONTAINER", "OVERHEAD")
+		for _, r := range results {
+			if r.Err != nil {
+				sylog.Warningf("Unable to measure %s in the container: %s", r.Workload, r.Err)
+				fmt.Fprintf(tw, "%s\t%.1f %s\t%s\t%s\n", r.Workload, r.Native, r.Unit, "failed", "-")
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%.1f %s\t%.1f %s\t%+.1f%%\n", r.Workload, r.Native, r.Unit, r.Container, r.Unit, r.Delta())
+		}
+		tw.Flush()
+	},
+
+	Use:     docs.BenchUse,
+	Short:   docs.BenchShort,
+	Long:    docs.BenchLong,
+	Example: docs.BenchExample,
+}
@@ -11,7 +11,6 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"syscall"
@@ -51,10 +50,16 @@ var buildArgs struct {
 	ignoreSubuid        bool     // Ignore /etc/subuid entries (hidden)
 	ignoreFakerootCmd   bool     // Ignore fakeroot command (hidden)
 	ignoreUserns        bool     // Ignore user namespace(hidden)
-	remote              bool     // Remote flag(hidden, only for helpful error message)
+	remote              bool     // Build on a runner
+	runner              string   // Runner for remote builds
+	oci                 bool     // Build an OCI-SIF image
 	buildVarArgs        []string // Variables passed to build procedure.
 	buildVarArgFile     string   // Variables file passed to build procedure.
 	buildArgsUnusedWarn bool     // Variables passed to build procedure to turn fatal error to warn.
+	mksquashfsProcs     int
+	mksquashfsMem       string
+	mksquashfsBlockSize string
+	mksquashfsComp      string
 }
 
 // -s|--sandbox
@@ -279,14 +284,35 @@ var buildIgnoreUsernsFlag = cmdline.Flag{
 	Hidden:       true,
 }
 
+// --remote
 var buildRemoteFlag = cmdline.Flag{
 	ID:           "remoteFlag",
 	Value:        &buildArgs.remote,
 	DefaultValue: false,
 	Name:         "remote",
-	Usage:        "--remote is no longer supported, try building locally without it",
-	EnvKeys:      []string{},
-	Hidden:       true,
+	Usage:        "build on the remote Linux runner set with --runner, downloading the image or pushing it to an oras:// or library:// destination",
+	EnvKeys:      []string{"REMOTE"},
+}
+
+// --runner
+var buildRunnerFlag = cmdline.Flag{
+	ID:           "buildRunnerFlag",
+	Value:        &buildArgs.runner,
+	DefaultValue: "",
+	Name:         "runner",
+	Usage:        "remote Linux runner reached over SSH (user@host or ssh://[user@]host[:port]) for remote builds, implies --remote",
+	EnvKeys:      []string{"RUNNER"},
+	Tag:          "<host>",
+}
+
+// --oci
+var buildOCIFlag = cmdline.Flag{
+	ID:           "buildOCIFlag",
+	Value:        &buildArgs.oci,
+	DefaultValue: false,
+	Name:         "oci",
+	Usage:        "build an OCI-SIF image, holding the container as a single layer OCI image",
+	EnvKeys:      []string{"OCI"},
 }
 
 // --build-arg
@@ -316,6 +342,42 @@ var buildArgUnusedWarn = cmdline.Flag{
 	Usage:        "shows warning instead of fatal message when build args are not exact matched",
 }
 
+// --mksquashfs-procs
+var buildMksquashfsProcsFlag = cmdline.Flag{
+	ID:           "buildMksquashfsProcsFlag",
+	Value:        &buildArgs.mksquashfsProcs,
+	DefaultValue: 0,
+	Name:         "mksquashfs-procs",
+	Usage:        "number of processors used by mksquashfs, unsquashfs and OCI layers decompression, overriding the configuration when not 0",
+}
+
+// --mksquashfs-mem
+var buildMksquashfsMemFlag = cmdline.Flag{
+	ID:           "buildMksquashfsMemFlag",
+	Value:        &buildArgs.mksquashfsMem,
+	DefaultValue: "",
+	Name:         "mksquashfs-mem",
+	Usage:        "memory used by mksquashfs (e.g. 1G or 500M), overriding the configuration",
+}
+
+// --mksquashfs-block-size
+var buildMksquashfsBlockSizeFlag = cmdline.Flag{
+	ID:           "buildMksquashfsBlockSizeFlag",
+	Value:        &buildArgs.mksquashfsBlockSize,
+	DefaultValue: "",
+	Name:         "mksquashfs-block-size",
+	Usage:        "block size of the SIF image squashfs filesystem, a power of two between 4K and 1M",
+}
+
+// --mksquashfs-comp
+var buildMksquashfsCompFlag = cmdline.Flag{
+	ID:           "buildMksquashfsCompFlag",
+	Value:        &buildArgs.mksquashfsComp,
+	DefaultValue: "",
+	Name:         "mksquashfs-comp",
+	Usage:        "compressor of the SIF image squashfs filesystem: gzip (default), lz4, lzo, xz or zstd",
+}
+
 func init() {
 	addCmdInit(func(cmdManager *cmdline.CommandManager) {
 		cmdManager.RegisterCmd(buildCmd)
@@ -342,6 +404,8 @@ func init() {
 
 		cmdManager.RegisterFlagForCmd(&commonPromptForPassphraseFlag, buildCmd)
 		cmdManager.RegisterFlagForCmd(&commonPEMFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&commonAgeFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&commonPKCS11Flag, buildCmd)
 
 		cmdManager.RegisterFlagForCmd(&buildNvFlag, buildCmd)
 		cmdManager.RegisterFlagForCmd(&buildNvCCLIFlag, buildCmd)
@@ -355,11 +419,18 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&buildIgnoreFakerootCommand, buildCmd)
 		cmdManager.RegisterFlagForCmd(&buildIgnoreUsernsFlag, buildCmd)
 		cmdManager.RegisterFlagForCmd(&buildRemoteFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&buildRunnerFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&buildOCIFlag, buildCmd)
 
 		cmdManager.RegisterFlagForCmd(&buildVarArgsFlag, buildCmd)
 		cmdManager.RegisterFlagForCmd(&buildVarArgFileFlag, buildCmd)
 		cmdManager.RegisterFlagForCmd(&buildArgUnusedWarn, buildCmd)
 		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, buildCmd)
+
+		cmdManager.RegisterFlagForCmd(&buildMksquashfsProcsFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&buildMksquashfsMemFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&buildMksquashfsBlockSizeFlag, buildCmd)
+		cmdManager.RegisterFlagForCmd(&buildMksquashfsCompFlag, buildCmd)
 	})
 }
 
@@ -378,7 +449,17 @@ var buildCmd = &cobra.Command{
 }
 
 func preRun(cmd *cobra.Command, args []string) {
-	if promptForPassphrase || cmd.Flags().Lookup("pem-path").Changed {
+	// the image is built on a runner, not locally
+	if buildArgs.remote || buildArgs.runner != "" {
+		if err := runBuildRemote(cmd, args[0], args[1]); err != nil {
+			sylog.Fatalf("While performing remote build: %v", err)
+		}
+		sylog.Infof("Build complete: %s", args[0])
+		os.Exit(0)
+	}
+
+	if promptForPassphrase || cmd.Flags().Lookup("pem-path").Changed ||
+		cmd.Flags().Lookup("age-path").Changed || cmd.Flags().Lookup("pkcs11-uri").Changed {
 		// these imply --encrypt
 		buildArgs.encrypt = true
 	}
@@ -397,11 +478,24 @@ func preRun(cmd *cobra.Command, args []string) {
 			}
 		}
 	}
+}
 
-	if buildArgs.remote {
-		err := errors.New("--remote is no longer supported, try building locally without it")
-		cobra.CheckErr(err)
+// checkOCIBuild checks that the options set for the build are supported when
+// building an OCI-SIF image.
+func checkOCIBuild() error {
+	if !buildArgs.oci {
+		return nil
+	}
+	if buildArgs.sandbox {
+		return fmt.Errorf("--oci can't be used with --sandbox")
 	}
+	if buildArgs.encrypt {
+		return fmt.Errorf("--oci can't be used with encryption")
+	}
+	if buildArgs.update {
+		return fmt.Errorf("--oci can't be used with --update")
+	}
+	return nil
 }
 
 // checkBuildTarget makes sure output target doesn't exist, or is ok to overwrite.
@@ -500,6 +594,8 @@ func makeOCICredentials(cmd *cobra.Command) (*authn.AuthConfig, error) {
 		}
 		passwordFlag.Value.Set(authConfig.Password)
 		passwordFlag.Changed = true
+		// only prompt once when credentials are needed several times
+		dockerLogin = false
 	}
 
 	if usernameFlag.Changed || passwordFlag.Changed {
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/apptainer/apptainer/internal/app/apptainer"
 	"github.com/apptainer/apptainer/internal/pkg/build"
 	"github.com/apptainer/apptainer/internal/pkg/build/args"
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
@@ -219,8 +220,8 @@ func runBuild(cmd *cobra.Command, args []string) {
 		os.Setenv("APPTAINER_WRITABLE_TMPFS", "1")
 	}
 
-	if cmd.Flags().Lookup("authfile").Changed && buildArgs.remote {
-		sylog.Fatalf("Custom authfile is not supported for remote build")
+	if err := checkOCIBuild(); err != nil {
+		sylog.Fatalf("%s", err)
 	}
 
 	// check if target collides with existing file
@@ -229,9 +230,29 @@ func runBuild(cmd *cobra.Command, args []string) {
 	}
 
 	runBuildLocal(cmd.Context(), cmd, dest, spec, fakerootPath)
+	if buildArgs.oci {
+		if err := convertToOCISIF(dest); err != nil {
+			sylog.Fatalf("While converting to OCI-SIF image: %v", err)
+		}
+	}
 	sylog.Infof("Build complete: %s", dest)
 }
 
+// convertToOCISIF converts the native SIF image built at dest to an OCI-SIF
+// image in place.
+func convertToOCISIF(dest string) error {
+	native := dest + ".native"
+	if err := os.Rename(dest, native); err != nil {
+		return err
+	}
+	if err := apptainer.Convert(native, dest, apptainer.ConvertOptions{TmpDir: tmpDir}); err != nil {
+		os.Remove(dest)
+		os.Rename(native, dest)
+		return err
+	}
+	return os.Remove(native)
+}
+
 func runBuildLocal(ctx context.Context, cmd *cobra.Command, dst, spec string, fakerootPath string) {
 	var keyInfo *cryptkey.KeyInfo
 	unprivilege := false
@@ -247,14 +268,16 @@ func runBuildLocal(ctx context.Context, cmd *cobra.Command, dst, spec string, fa
 		keyInfo = k
 
 		if keyInfo == nil && unprivilege {
-			sylog.Errorf("Missing encryption info, please add `--passphrase` or `--pem-path` or corresponding environment variable")
+			sylog.Errorf("Missing encryption info, please add `--passphrase`, `--pem-path`, `--age-path` or `--pkcs11-uri` or corresponding environment variable")
 			return
 		}
 	} else {
 		_, passphraseEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PASSPHRASE")
 		_, pemPathEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PEM_PATH")
 		_, pemDataEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PEM_DATA")
-		if passphraseEnvOK || pemPathEnvOK || pemDataEnvOK {
+		_, agePathEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_AGE_PATH")
+		_, pkcs11URIEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PKCS11_URI")
+		if passphraseEnvOK || pemPathEnvOK || pemDataEnvOK || agePathEnvOK || pkcs11URIEnvOK {
 			sylog.Warningf("Encryption related env vars found, but --encrypt was not specified. NOT encrypting container.")
 		}
 	}
@@ -269,6 +292,10 @@ func runBuildLocal(ctx context.Context, cmd *cobra.Command, dst, spec string, fa
 		sylog.Fatalf("Could not check build sections: %v", err)
 	}
 
+	if buildArgs.mksquashfsProcs < 0 {
+		sylog.Fatalf("Invalid --mksquashfs-procs value %d, must be positive", buildArgs.mksquashfsProcs)
+	}
+
 	authConf, err := makeOCICredentials(cmd)
 	if err != nil {
 		sylog.Fatalf("While creating Docker credentials: %v", err)
@@ -357,26 +384,30 @@ func runBuildLocal(ctx context.Context, cmd *cobra.Command, dst, spec string, fa
 			Format:    buildFormat,
 			NoCleanUp: buildArgs.noCleanUp,
 			Opts: types.Options{
-				ImgCache:          imgCache,
-				TmpDir:            tmpDir,
-				NoCache:           disableCache,
-				Update:            buildArgs.update,
-				Force:             forceOverwrite,
-				Sections:          buildArgs.sections,
-				NoTest:            buildArgs.noTest,
-				NoHTTPS:           noHTTPS,
-				LibraryURL:        buildArgs.libraryURL,
-				LibraryAuthToken:  authToken,
-				FakerootPath:      fakerootPath,
-				KeyServerOpts:     ko,
-				OCIAuthConfig:     authConf,
-				DockerDaemonHost:  dockerHost,
-				EncryptionKeyInfo: keyInfo,
-				FixPerms:          buildArgs.fixPerms,
-				SandboxTarget:     sandboxTarget,
-				Binds:             buildArgs.bindPaths,
-				Unprivilege:       unprivilege,
-				ReqAuthFile:       reqAuthFile,
+				ImgCache:            imgCache,
+				TmpDir:              tmpDir,
+				NoCache:             disableCache,
+				Update:              buildArgs.update,
+				Force:               forceOverwrite,
+				Sections:            buildArgs.sections,
+				NoTest:              buildArgs.noTest,
+				NoHTTPS:             noHTTPS,
+				LibraryURL:          buildArgs.libraryURL,
+				LibraryAuthToken:    authToken,
+				FakerootPath:        fakerootPath,
+				KeyServerOpts:       ko,
+				OCIAuthConfig:       authConf,
+				DockerDaemonHost:    dockerHost,
+				EncryptionKeyInfo:   keyInfo,
+				FixPerms:            buildArgs.fixPerms,
+				SandboxTarget:       sandboxTarget,
+				Binds:               buildArgs.bindPaths,
+				Unprivilege:         unprivilege,
+				ReqAuthFile:         reqAuthFile,
+				MksquashfsProcs:     uint(buildArgs.mksquashfsProcs),
+				MksquashfsMem:       buildArgs.mksquashfsMem,
+				MksquashfsBlockSize: buildArgs.mksquashfsBlockSize,
+				MksquashfsComp:      buildArgs.mksquashfsComp,
 			},
 		})
 	if err != nil {
@@ -424,30 +455,39 @@ func isImage(spec string) bool {
 
 // getEncryptionMaterial handles the setting of encryption environment and flag parameters to eventually be
 // passed to the crypt package for handling.
-// This handles the APPTAINER_ENCRYPTION_PASSPHRASE/PEM_PATH envvars outside of cobra in order to
-// enforce the unique flag/env precedence for the encryption flow
+// This handles the APPTAINER_ENCRYPTION_PASSPHRASE/PEM_PATH/AGE_PATH/PKCS11_URI envvars outside of
+// cobra in order to enforce the unique flag/env precedence for the encryption flow
 func getEncryptionMaterial(cmd *cobra.Command) (*cryptkey.KeyInfo, error) {
 	passphraseFlag := cmd.Flags().Lookup("passphrase")
 	PEMFlag := cmd.Flags().Lookup("pem-path")
+	ageFlag := cmd.Flags().Lookup("age-path")
+	PKCS11Flag := cmd.Flags().Lookup("pkcs11-uri")
 	passphraseEnv, passphraseEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PASSPHRASE")
 	pemPathEnv, pemPathEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PEM_PATH")
 	pemDataEnv, pemDataEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PEM_DATA")
+	agePathEnv, agePathEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_AGE_PATH")
+	pkcs11URIEnv, pkcs11URIEnvOK := os.LookupEnv("APPTAINER_ENCRYPTION_PKCS11_URI")
 
-	if PEMFlag == nil || passphraseFlag == nil {
+	if PEMFlag == nil || passphraseFlag == nil || ageFlag == nil || PKCS11Flag == nil {
 		return nil, nil
 	}
 
 	// checks for no flags/envvars being set
-	if !(PEMFlag.Changed || pemPathEnvOK || pemDataEnvOK || passphraseFlag.Changed || passphraseEnvOK) {
+	if !(PEMFlag.Changed || ageFlag.Changed || PKCS11Flag.Changed || passphraseFlag.Changed ||
+		pemPathEnvOK || pemDataEnvOK || agePathEnvOK || pkcs11URIEnvOK || passphraseEnvOK) {
 		return nil, nil
 	}
 
 	// order of precedence:
 	// 1. PEM flag
-	// 2. Passphrase flag
-	// 3. PEM PATH envvar
-	// 4. PEM DATA envvar
-	// 5. Passphrase envvar
+	// 2. age flag
+	// 3. PKCS#11 flag
+	// 4. Passphrase flag
+	// 5. PEM PATH envvar
+	// 6. PEM DATA envvar
+	// 7. age PATH envvar
+	// 8. PKCS#11 URI envvar
+	// 9. Passphrase envvar
 
 	if PEMFlag.Changed {
 		exists, err := fs.PathExists(encryptionPEMPath)
@@ -476,6 +516,16 @@ func getEncryptionMaterial(cmd *cobra.Command) (*cryptkey.KeyInfo, error) {
 		return &cryptkey.KeyInfo{Format: cryptkey.PEM, Path: encryptionPEMPath}, nil
 	}
 
+	if ageFlag.Changed {
+		sylog.Verbosef("Using age path flag for encrypted container")
+		return getAgeKeyInfo(cmd, encryptionAgePath), nil
+	}
+
+	if PKCS11Flag.Changed {
+		sylog.Verbosef("Using PKCS#11 URI flag for encrypted container")
+		return getPKCS11KeyInfo(encryptionPKCS11URI), nil
+	}
+
 	if passphraseFlag.Changed {
 		sylog.Verbosef("Using interactive passphrase entry for encrypted container")
 		passphrase, err := interactive.AskQuestionNoEcho("Enter encryption passphrase: ")
@@ -507,6 +557,16 @@ func getEncryptionMaterial(cmd *cobra.Command) (*cryptkey.KeyInfo, error) {
 		return &cryptkey.KeyInfo{Format: cryptkey.ENV, Material: pemDataEnv}, nil
 	}
 
+	if agePathEnvOK {
+		sylog.Verbosef("Using age path environment variable for encrypted container")
+		return getAgeKeyInfo(cmd, agePathEnv), nil
+	}
+
+	if pkcs11URIEnvOK {
+		sylog.Verbosef("Using PKCS#11 URI environment variable for encrypted container")
+		return getPKCS11KeyInfo(pkcs11URIEnv), nil
+	}
+
 	if passphraseEnvOK {
 		sylog.Verbosef("Using passphrase environment variable for encrypted container")
 		return &cryptkey.KeyInfo{Format: cryptkey.Passphrase, Material: passphraseEnv}, nil
@@ -514,3 +574,37 @@ func getEncryptionMaterial(cmd *cobra.Command) (*cryptkey.KeyInfo, error) {
 
 	return nil, nil
 }
+
+// getAgeKeyInfo checks that path holds age recipients when building, or age
+// identities when launching a container, before returning its key info.
+func getAgeKeyInfo(cmd *cobra.Command, path string) *cryptkey.KeyInfo {
+	exists, err := fs.PathExists(path)
+	if err != nil {
+		sylog.Fatalf("Unable to verify existence of %s: %v", path, err)
+	}
+
+	if !exists {
+		sylog.Fatalf("Specified age file %s: does not exist.", path)
+	}
+
+	if cmd.Name() == "build" {
+		if _, err := cryptkey.LoadAgeRecipientsFile(path); err != nil {
+			sylog.Fatalf("Invalid encryption age recipients: %v", err)
+		}
+	} else {
+		if _, err := cryptkey.LoadAgeIdentitiesFile(path); err != nil {
+			sylog.Fatalf("Invalid encryption age identities: %v", err)
+		}
+	}
+
+	return &cryptkey.KeyInfo{Format: cryptkey.Age, Path: path}
+}
+
+// getPKCS11KeyInfo checks that uri is a usable PKCS#11 URI before returning
+// its key info. The token itself is only accessed when the key is used.
+func getPKCS11KeyInfo(uri string) *cryptkey.KeyInfo {
+	if _, err := cryptkey.ParsePKCS11URI(uri); err != nil {
+		sylog.Fatalf("Invalid encryption PKCS#11 URI: %v", err)
+	}
+	return &cryptkey.KeyInfo{Format: cryptkey.PKCS11, Material: uri}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/spf13/cobra"
+)
+
+// remoteBuildLocalFlags are the build flags not passed to the build run on a
+// runner.
+var remoteBuildLocalFlags = []string{"remote", "oci", "force"}
+
+// runBuildRemote builds the image dest from spec on the runner set with
+// --runner. The image built is downloaded to dest, or pushed from the runner
+// when dest is an oras:// or library:// URI, with the registry credentials
+// given locally forwarded to the runner.
+func runBuildRemote(cmd *cobra.Command, dest, spec string) error {
+	if buildArgs.runner == "" {
+		return fmt.Errorf("remote builds require a runner, set with --runner")
+	}
+	if buildArgs.sandbox {
+		return fmt.Errorf("sandbox images can't be built remotely")
+	}
+	if buildArgs.update {
+		return fmt.Errorf("--update can't be used for remote builds")
+	}
+	if err := checkOCIBuild(); err != nil {
+		return err
+	}
+
+	destTransport, _ := uri.Split(dest)
+	switch destTransport {
+	case "":
+		if err := checkBuildTarget(dest); err != nil {
+			return fmt.Errorf("while checking build target: %v", err)
+		}
+	case OrasProtocol, LibraryProtocol:
+	default:
+		return fmt.Errorf("unsupported destination %s, only local files, oras:// and library:// URIs are supported for remote builds", dest)
+	}
+
+	r, err := newRunner(buildArgs.runner)
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	creds, err := getRunnerCredentials(cmd)
+	if err != nil {
+		return err
+	}
+	dir, err := r.TempDir(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Remove(context.Background(), dir)
+	if err := creds.forward(ctx, r, dir); err != nil {
+		return err
+	}
+
+	if transport, _ := uri.Split(spec); transport == "" {
+		switch {
+		case fs.IsDir(spec):
+			return fmt.Errorf("sandbox %s can't be built from remotely, build a SIF image from it first", spec)
+		case fs.IsFile(spec):
+			spec, err = r.Upload(ctx, spec)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	image := path.Join(dir, "image.sif")
+	remote := append([]string{"build"}, runnerFlags(cmd, remoteBuildLocalFlags...)...)
+	if err := runOn(ctx, r, append(remote, image, spec)); err != nil {
+		return err
+	}
+	if buildArgs.oci {
+		ociImage := path.Join(dir, "image.oci.sif")
+		if err := runOn(ctx, r, []string{"convert", image, ociImage}); err != nil {
+			return err
+		}
+		image = ociImage
+	}
+
+	if destTransport != "" {
+		return runOn(ctx, r, []string{"push", image, dest})
+	}
+	return r.Download(ctx, image, dest)
+}
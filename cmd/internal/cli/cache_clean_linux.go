@@ -45,7 +45,7 @@ var (
 		DefaultValue: []string{"all"},
 		Name:         "type",
 		ShortHand:    "T",
-		Usage:        "a list of cache types to clean (possible values: library, oci, shub, blob, net, oras, all)",
+		Usage:        "a list of cache types to clean (possible values: library, oci, shub, blob, net, oras, object, ipfs, referrers, records, oci-spec, oci-rootfs, all)",
 	}
 
 	// -D|--days
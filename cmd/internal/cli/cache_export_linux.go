@@ -0,0 +1,63 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterFlagForCmd(&cacheExportTypesFlag, cacheExportCmd)
+		cmdManager.RegisterFlagForCmd(&cacheExportDirFlag, cacheExportCmd)
+	})
+}
+
+var (
+	cacheExportTypes []string
+	cacheExportDir   bool
+
+	// -T|--type
+	cacheExportTypesFlag = cmdline.Flag{
+		ID:           "cacheExportTypes",
+		Value:        &cacheExportTypes,
+		DefaultValue: []string{"all"},
+		Name:         "type",
+		ShortHand:    "T",
+		Usage:        "a list of cache types to export (possible values: library, oci-tmp, shub, blob, net, oras, object, ipfs, referrers, records, oci-spec, all)",
+	}
+
+	// --dir
+	cacheExportDirFlag = cmdline.Flag{
+		ID:           "cacheExportDirFlag",
+		Value:        &cacheExportDir,
+		DefaultValue: false,
+		Name:         "dir",
+		Usage:        "export to a directory with the layout of the cache instead of a tar archive",
+	}
+
+	// cacheExportCmd is 'apptainer cache export' and will export your local apptainer cache
+	cacheExportCmd = &cobra.Command{
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			imgCache := getCacheHandle(cache.Config{})
+			if err := apptainer.ExportApptainerCache(imgCache, args[0], cacheExportTypes, cacheExportDir); err != nil {
+				sylog.Fatalf("Could not export cache: %v", err)
+			}
+		},
+
+		Use:     docs.CacheExportUse,
+		Short:   docs.CacheExportShort,
+		Long:    docs.CacheExportLong,
+		Example: docs.CacheExportExample,
+	}
+)
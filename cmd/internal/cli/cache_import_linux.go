@@ -0,0 +1,31 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+// cacheImportCmd is 'apptainer cache import' and will import entries into your local apptainer cache
+var cacheImportCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		imgCache := getCacheHandle(cache.Config{})
+		if err := apptainer.ImportApptainerCache(imgCache, args[0]); err != nil {
+			sylog.Fatalf("Could not import cache: %v", err)
+		}
+	},
+
+	Use:     docs.CacheImportUse,
+	Short:   docs.CacheImportShort,
+	Long:    docs.CacheImportLong,
+	Example: docs.CacheImportExample,
+}
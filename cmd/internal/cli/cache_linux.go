@@ -22,6 +22,9 @@ func init() {
 		cmdManager.RegisterCmd(CacheCmd)
 		cmdManager.RegisterSubCmd(CacheCmd, cacheCleanCmd)
 		cmdManager.RegisterSubCmd(CacheCmd, CacheListCmd)
+		cmdManager.RegisterSubCmd(CacheCmd, cacheExportCmd)
+		cmdManager.RegisterSubCmd(CacheCmd, cacheImportCmd)
+		cmdManager.RegisterSubCmd(CacheCmd, cacheVerifyCmd)
 	})
 }
 
@@ -0,0 +1,85 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client/oci"
+	"github.com/apptainer/apptainer/internal/pkg/client/oras"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterFlagForCmd(&cacheVerifyTypesFlag, cacheVerifyCmd)
+		cmdManager.RegisterFlagForCmd(&cacheVerifyRepairFlag, cacheVerifyCmd)
+	})
+}
+
+var (
+	cacheVerifyTypes  []string
+	cacheVerifyRepair bool
+
+	// -T|--type
+	cacheVerifyTypesFlag = cmdline.Flag{
+		ID:           "cacheVerifyTypes",
+		Value:        &cacheVerifyTypes,
+		DefaultValue: []string{"all"},
+		Name:         "type",
+		ShortHand:    "T",
+		Usage:        "a list of cache types to verify (possible values: library, oci-tmp, shub, blob, net, oras, object, ipfs, referrers, records, oci-spec, all)",
+	}
+
+	// --repair
+	cacheVerifyRepairFlag = cmdline.Flag{
+		ID:           "cacheVerifyRepairFlag",
+		Value:        &cacheVerifyRepair,
+		DefaultValue: false,
+		Name:         "repair",
+		Usage:        "remove corrupted entries, pulling the images recorded in them again",
+	}
+
+	// cacheVerifyCmd is 'apptainer cache verify' and will verify the entries of your local apptainer cache
+	cacheVerifyCmd = &cobra.Command{
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			imgCache := getCacheHandle(cache.Config{})
+			pull := func(r *cache.ImageRecord) error {
+				return cachePullAgain(cmd, imgCache, r)
+			}
+			if err := apptainer.VerifyApptainerCache(imgCache, cacheVerifyTypes, cacheVerifyRepair, pull); err != nil {
+				sylog.Fatalf("Cache verification failed: %v", err)
+			}
+		},
+
+		Use:     docs.CacheVerifyUse,
+		Short:   docs.CacheVerifyShort,
+		Long:    docs.CacheVerifyLong,
+		Example: docs.CacheVerifyExample,
+	}
+)
+
+// cachePullAgain pulls the image of the record r into the cache, with the
+// default credentials.
+func cachePullAgain(cmd *cobra.Command, imgCache *cache.Handle, r *cache.ImageRecord) error {
+	transport, _ := uri.Split(r.URI)
+	if transport == OrasProtocol {
+		_, err := oras.Pull(cmd.Context(), imgCache, r.URI, tmpDir, nil, false, "")
+		return err
+	}
+	pullOpts := oci.PullOptions{
+		TmpDir:   tmpDir,
+		Pullarch: r.Arch,
+	}
+	_, err := oci.Pull(cmd.Context(), imgCache, r.URI, pullOpts)
+	return err
+}
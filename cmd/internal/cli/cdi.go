@@ -0,0 +1,98 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"errors"
+	"os"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cdi"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(CDICmd)
+		cmdManager.RegisterSubCmd(CDICmd, cdiListCmd)
+		cmdManager.RegisterSubCmd(CDICmd, cdiValidateCmd)
+		cmdManager.RegisterFlagForCmd(&cdiDirFlag, cdiListCmd, cdiValidateCmd)
+	})
+}
+
+var cdiDirs []string
+
+// --dir
+var cdiDirFlag = cmdline.Flag{
+	ID:           "cdiDirFlag",
+	Value:        &cdiDirs,
+	DefaultValue: []string{},
+	Name:         "dir",
+	Usage:        "directory of the CDI spec files, by increasing priority, instead of the 'cdi dirs' of apptainer.conf",
+	Tag:          "<path>",
+}
+
+// getCDIDirs returns the directories of the CDI spec files, given by --dir
+// or apptainer.conf.
+func getCDIDirs() []string {
+	if len(cdiDirs) > 0 {
+		return cdiDirs
+	}
+	var dirs []string
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		dirs = cfg.CDIDirs
+	}
+	return cdi.DirsOrDefault(dirs)
+}
+
+// CDICmd is the 'cdi' command that allows management of CDI devices.
+var CDICmd = &cobra.Command{
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return errors.New("invalid command")
+	},
+	DisableFlagsInUseLine: true,
+
+	Use:           docs.CDIUse,
+	Short:         docs.CDIShort,
+	Long:          docs.CDILong,
+	Example:       docs.CDIExample,
+	SilenceErrors: true,
+}
+
+// cdiListCmd is 'apptainer cdi list'.
+var cdiListCmd = &cobra.Command{
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := apptainer.CDIList(os.Stdout, getCDIDirs()); err != nil {
+			sylog.Fatalf("Failed to list CDI devices: %s", err)
+		}
+	},
+
+	Use:     docs.CDIListUse,
+	Short:   docs.CDIListShort,
+	Long:    docs.CDIListLong,
+	Example: docs.CDIListExample,
+}
+
+// cdiValidateCmd is 'apptainer cdi validate'.
+var cdiValidateCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		if err := apptainer.CDIValidate(getCDIDirs(), args); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	},
+
+	Use:     docs.CDIValidateUse,
+	Short:   docs.CDIValidateShort,
+	Long:    docs.CDIValidateLong,
+	Example: docs.CDIValidateExample,
+}
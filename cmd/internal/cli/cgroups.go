@@ -89,6 +89,15 @@ func getFlagLimits() (*cgroups.Config, error) {
 		configured = true
 	}
 
+	devices, err := getDeviceRules()
+	if err != nil {
+		return nil, err
+	}
+	if devices != nil {
+		config.Devices = devices
+		configured = true
+	}
+
 	if configured {
 		return &config, nil
 	}
@@ -289,6 +298,111 @@ func getPidsLimits() (*cgroups.LinuxPids, error) {
 	return nil, nil
 }
 
+// defaultDeviceRules allow access to the standard devices of a container,
+// as /dev/null, /dev/tty and pseudo-terminals.
+var defaultDeviceRules = []string{
+	"c 1:3 rwm",   // /dev/null
+	"c 1:5 rwm",   // /dev/zero
+	"c 1:7 rwm",   // /dev/full
+	"c 1:8 rwm",   // /dev/random
+	"c 1:9 rwm",   // /dev/urandom
+	"c 5:0 rwm",   // /dev/tty
+	"c 5:1 rwm",   // /dev/console
+	"c 5:2 rwm",   // /dev/ptmx
+	"c 136:* rwm", // /dev/pts/*
+}
+
+// getDeviceRules handles --device-cgroup-rule flags, converting values into
+// device cgroup rules applied in order. Apptainer allows access to all
+// devices by default, so deny rules are applied on top of this default.
+// When the first rule allows access, only the standard devices are allowed
+// before applying the rules instead, so that devices are allowed by major
+// number, as hot-plugged devices appearing while the container runs.
+func getDeviceRules() ([]cgroups.LinuxDeviceCgroup, error) {
+	if len(deviceCgroupRules) == 0 {
+		return nil, nil
+	}
+
+	rules := []cgroups.LinuxDeviceCgroup{}
+	first, err := parseDeviceRule(deviceCgroupRules[0])
+	if err != nil {
+		return nil, err
+	}
+	if first.Allow {
+		rules = append(rules, cgroups.LinuxDeviceCgroup{Allow: false, Access: "rwm"})
+		for _, r := range defaultDeviceRules {
+			rule, err := parseDeviceRule(r)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	} else {
+		rules = append(rules, cgroups.LinuxDeviceCgroup{Allow: true, Access: "rwm"})
+	}
+
+	for _, r := range deviceCgroupRules {
+		rule, err := parseDeviceRule(r)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseDeviceRule parses a device cgroup rule of the form...
+//
+//	[deny] <type> <major>:<minor> <access>
+//	c 189:* rwm
+//
+// where type is a (all), b (block) or c (char), major and minor are numbers
+// or * for any, and access is a combination of r (read), w (write) and
+// m (mknod).
+func parseDeviceRule(val string) (cgroups.LinuxDeviceCgroup, error) {
+	rule := cgroups.LinuxDeviceCgroup{Allow: true}
+	fields := strings.Fields(val)
+	if len(fields) == 4 && fields[0] == "deny" {
+		rule.Allow = false
+		fields = fields[1:]
+	}
+	if len(fields) != 3 {
+		return rule, fmt.Errorf("device-cgroup-rule %q must be in [deny] <type> <major>:<minor> <access> format", val)
+	}
+
+	switch fields[0] {
+	case "a", "b", "c":
+		rule.Type = fields[0]
+	default:
+		return rule, fmt.Errorf("invalid device type %q in device-cgroup-rule %q, must be a, b or c", fields[0], val)
+	}
+
+	major, minor, ok := strings.Cut(fields[1], ":")
+	if !ok {
+		return rule, fmt.Errorf("device-cgroup-rule %q must have <major>:<minor> device numbers", val)
+	}
+	for _, n := range []struct {
+		s string
+		v **int64
+	}{{major, &rule.Major}, {minor, &rule.Minor}} {
+		if n.s == "*" {
+			continue
+		}
+		i, err := strconv.ParseInt(n.s, 10, 64)
+		if err != nil || i < 0 {
+			return rule, fmt.Errorf("invalid device number %q in device-cgroup-rule %q", n.s, val)
+		}
+		*n.v = &i
+	}
+
+	if fields[2] == "" || strings.Trim(fields[2], "rwm") != "" {
+		return rule, fmt.Errorf("invalid access %q in device-cgroup-rule %q, must be a combination of r, w and m", fields[2], val)
+	}
+	rule.Access = fields[2]
+
+	return rule, nil
+}
+
 // deviceMajorMinor returns major and minor numbers for the device at path
 func deviceMajorMinor(path string) (major, minor int64, err error) {
 	var stat unix.Stat_t
@@ -522,3 +522,105 @@ func Test_getPidsLimits(t *testing.T) {
 		})
 	}
 }
+
+func Test_getDeviceRules(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        []string
+		wantDevices  bool
+		wantError    bool
+		devicesCheck func(t *testing.T, d []cgroups.LinuxDeviceCgroup)
+	}{
+		{
+			name:        "None",
+			wantDevices: false,
+			wantError:   false,
+		},
+		{
+			name:        "Allow",
+			rules:       []string{"c 189:* rwm"},
+			wantDevices: true,
+			wantError:   false,
+			devicesCheck: func(t *testing.T, d []cgroups.LinuxDeviceCgroup) {
+				if len(d) != len(defaultDeviceRules)+2 {
+					t.Fatalf("expected %d rules, got %d", len(defaultDeviceRules)+2, len(d))
+				}
+				if d[0].Allow || d[0].Type != "" {
+					t.Errorf("expected deny all first, got %+v", d[0])
+				}
+				last := d[len(d)-1]
+				if !last.Allow || last.Type != "c" || last.Major == nil || *last.Major != 189 || last.Minor != nil || last.Access != "rwm" {
+					t.Errorf("unexpected rule %+v", last)
+				}
+			},
+		},
+		{
+			name:        "Deny",
+			rules:       []string{"deny b 8:0 w", "a *:* m"},
+			wantDevices: true,
+			wantError:   false,
+			devicesCheck: func(t *testing.T, d []cgroups.LinuxDeviceCgroup) {
+				if len(d) != 3 {
+					t.Fatalf("expected 3 rules, got %d", len(d))
+				}
+				if !d[0].Allow {
+					t.Errorf("expected allow all first, got %+v", d[0])
+				}
+				if d[1].Allow || d[1].Type != "b" || *d[1].Major != 8 || *d[1].Minor != 0 || d[1].Access != "w" {
+					t.Errorf("unexpected rule %+v", d[1])
+				}
+				if !d[2].Allow || d[2].Type != "a" || d[2].Major != nil || d[2].Minor != nil {
+					t.Errorf("unexpected rule %+v", d[2])
+				}
+			},
+		},
+		{
+			name:      "BadFormat",
+			rules:     []string{"c 189 rwm"},
+			wantError: true,
+		},
+		{
+			name:      "BadType",
+			rules:     []string{"x 189:* rwm"},
+			wantError: true,
+		},
+		{
+			name:      "BadNumber",
+			rules:     []string{"c 189:-1 rwm"},
+			wantError: true,
+		},
+		{
+			name:      "BadAccess",
+			rules:     []string{"c 189:* rwx"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviceCgroupRules = tt.rules
+
+			devices, err := getDeviceRules()
+
+			if err != nil && !tt.wantError {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if err == nil && tt.wantError {
+				t.Errorf("unexpected success: %s", err)
+			}
+
+			if tt.wantDevices && devices == nil {
+				t.Errorf("expected devices, got nil")
+			}
+
+			if !tt.wantDevices && devices != nil {
+				t.Errorf("expected nil, got %v", devices)
+			}
+
+			if tt.devicesCheck != nil && devices != nil {
+				tt.devicesCheck(t, devices)
+			}
+		})
+	}
+}
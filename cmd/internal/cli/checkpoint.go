@@ -12,10 +12,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/apptainer/apptainer/docs"
 	"github.com/apptainer/apptainer/internal/pkg/checkpoint/dmtcp"
+	"github.com/apptainer/apptainer/internal/pkg/client/oras"
 	"github.com/apptainer/apptainer/internal/pkg/instance"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -31,8 +33,17 @@ func init() {
 		cmdManager.RegisterSubCmd(CheckpointCmd, CheckpointInstanceCmd)
 		cmdManager.RegisterSubCmd(CheckpointCmd, CheckpointCreateCmd)
 		cmdManager.RegisterSubCmd(CheckpointCmd, CheckpointDeleteCmd)
+		cmdManager.RegisterSubCmd(CheckpointCmd, CheckpointPushCmd)
+		cmdManager.RegisterSubCmd(CheckpointCmd, CheckpointPullCmd)
 
 		cmdManager.RegisterFlagForCmd(&actionHomeFlag, CheckpointInstanceCmd)
+
+		checkpointRegistryCmds := []*cobra.Command{CheckpointPushCmd, CheckpointPullCmd}
+		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, checkpointRegistryCmds...)
+		cmdManager.RegisterFlagForCmd(&dockerLoginFlag, checkpointRegistryCmds...)
+		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, checkpointRegistryCmds...)
+		cmdManager.RegisterFlagForCmd(&dockerPasswordFlag, checkpointRegistryCmds...)
+		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, checkpointRegistryCmds...)
 	})
 }
 
@@ -134,6 +145,126 @@ var CheckpointDeleteCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
 }
 
+// checkpointRef returns the registry reference of an oras:// URI.
+func checkpointRef(uri string) string {
+	ref, ok := strings.CutPrefix(uri, OrasProtocol+"://")
+	if !ok {
+		sylog.Fatalf("Checkpoints are stored in OCI registries, %q must be an %s:// URI", uri, OrasProtocol)
+	}
+	return ref
+}
+
+// CheckpointPushCmd apptainer checkpoint push
+var CheckpointPushCmd = &cobra.Command{
+	Args:   cobra.ExactArgs(2),
+	PreRun: checkpointPreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		ref := checkpointRef(args[1])
+
+		e, err := dmtcp.NewManager().Get(name)
+		if err != nil {
+			sylog.Fatalf("Failed to get checkpoint entry: %v", err)
+		}
+
+		f, err := os.CreateTemp("", "checkpoint-*.tar.gz")
+		if err != nil {
+			sylog.Fatalf("Failed to create temporary file: %v", err)
+		}
+		defer os.Remove(f.Name())
+		err = e.Pack(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			sylog.Fatalf("Failed to archive checkpoint: %v", err)
+		}
+
+		ociAuth, err := makeOCICredentials(cmd)
+		if err != nil {
+			sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+		}
+		if err := oras.UploadCheckpoint(cmd.Context(), f.Name(), ref, ociAuth, noHTTPS, reqAuthFile); err != nil {
+			sylog.Fatalf("Unable to push checkpoint to oci registry: %v", err)
+		}
+
+		sylog.Infof("Checkpoint %q pushed to %s", name, args[1])
+	},
+
+	Use:     docs.CheckpointPushUse,
+	Short:   docs.CheckpointPushShort,
+	Long:    docs.CheckpointPushLong,
+	Example: docs.CheckpointPushExample,
+
+	DisableFlagsInUseLine: true,
+}
+
+// CheckpointPullCmd apptainer checkpoint pull
+var CheckpointPullCmd = &cobra.Command{
+	Args:   cobra.RangeArgs(1, 2),
+	PreRun: checkpointPreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		ref := checkpointRef(args[0])
+
+		// default to the repository name, without tag or digest
+		name := filepath.Base(ref)
+		if i := strings.IndexAny(name, ":@"); i > 0 {
+			name = name[:i]
+		}
+		if len(args) > 1 {
+			name = args[1]
+		}
+
+		m := dmtcp.NewManager()
+		if _, err := m.Get(name); err == nil {
+			sylog.Fatalf("Checkpoint %q already exists.", name)
+		}
+
+		f, err := os.CreateTemp("", "checkpoint-*.tar.gz")
+		if err != nil {
+			sylog.Fatalf("Failed to create temporary file: %v", err)
+		}
+		f.Close()
+		defer os.Remove(f.Name())
+
+		ociAuth, err := makeOCICredentials(cmd)
+		if err != nil {
+			sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+		}
+		if err := oras.DownloadCheckpoint(cmd.Context(), f.Name(), ref, ociAuth, noHTTPS, reqAuthFile); err != nil {
+			sylog.Fatalf("Unable to pull checkpoint from oci registry: %v", err)
+		}
+
+		e, err := m.Create(name)
+		if err != nil {
+			sylog.Fatalf("Failed to create checkpoint: %s", err)
+		}
+		if err := unpackCheckpoint(e, f.Name()); err != nil {
+			m.Delete(name)
+			sylog.Fatalf("Failed to restore checkpoint: %v", err)
+		}
+
+		sylog.Infof("Checkpoint %q pulled from %s", name, args[0])
+	},
+
+	Use:     docs.CheckpointPullUse,
+	Short:   docs.CheckpointPullShort,
+	Long:    docs.CheckpointPullLong,
+	Example: docs.CheckpointPullExample,
+
+	DisableFlagsInUseLine: true,
+}
+
+// unpackCheckpoint extracts the checkpoint archive at path into e.
+func unpackCheckpoint(e *dmtcp.Entry, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return e.Unpack(f)
+}
+
 var CheckpointInstanceCmd = &cobra.Command{
 	Args: cobra.ExactArgs(1),
 	PreRun: func(cmd *cobra.Command, args []string) {
@@ -0,0 +1,37 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(CleanupCmd)
+	})
+}
+
+// CleanupCmd is the 'cleanup' command that completes the deferred teardowns
+// of containers and OCI bundles.
+var CleanupCmd = &cobra.Command{
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := apptainer.Cleanup(); err != nil {
+			sylog.Fatalf("Unable to complete deferred teardowns: %s", err)
+		}
+	},
+
+	Use:     docs.CleanupUse,
+	Short:   docs.CleanupShort,
+	Long:    docs.CleanupLong,
+	Example: docs.CleanupExample,
+}
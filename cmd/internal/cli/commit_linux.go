@@ -0,0 +1,38 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(CommitCmd)
+	})
+}
+
+// CommitCmd is the 'commit' command that saves the changes recorded in an
+// overlay directory or an OCI bundle into a new image.
+var CommitCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(3),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		if err := apptainer.Commit(args[0], args[1], args[2]); err != nil {
+			sylog.Fatalf("Unable to commit changes: %s", err)
+		}
+		sylog.Infof("Changes committed to %s", args[2])
+	},
+
+	Use:     docs.CommitUse,
+	Short:   docs.CommitShort,
+	Long:    docs.CommitLong,
+	Example: docs.CommitExample,
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/cdi"
+	ociclient "github.com/apptainer/apptainer/internal/pkg/client/oci"
+	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// completionTagsMaxAge is how long the tags of a repository listed to
+	// complete image references are reused.
+	completionTagsMaxAge = time.Hour
+	// completionTimeout bounds the time spent listing tags to complete an
+	// image reference.
+	completionTimeout = 5 * time.Second
+)
+
+// imageExtensions are the extensions of the image files completed for image
+// arguments.
+var imageExtensions = []string{"sif", "simg", "img", "sqsh", "squashfs", "ext3"}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		for _, cmd := range []*cobra.Command{ExecCmd, ShellCmd, RunCmd, TestCmd, instanceStartCmd, instanceRunCmd} {
+			cmd.ValidArgsFunction = completeImage
+			if err := cmd.RegisterFlagCompletionFunc(actionDeviceFlag.Name, completeDevice); err != nil {
+				sylog.Debugf("Could not register completion of --%s: %v", actionDeviceFlag.Name, err)
+			}
+		}
+		for _, cmd := range []*cobra.Command{instanceStopCmd, instanceStatsCmd, instanceTopCmd, instanceExecCmd, instanceListCmd, instanceMigrateCmd} {
+			cmd.ValidArgsFunction = completeInstance
+		}
+	})
+}
+
+// completeImage completes the image argument of the action commands, with
+// the tags of docker:// and oras:// references, the names of the running
+// instances for instance:// URIs, or else the image files and directories.
+func completeImage(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		// the command and its arguments, or the instance name
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	switch transport, _ := uri.Split(toComplete); transport {
+	case "":
+		return imageExtensions, cobra.ShellCompDirectiveFilterFileExt
+	case DockerProtocol, OrasProtocol:
+		return completeTags(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	case "instance":
+		var refs []string
+		for _, name := range instanceNames() {
+			refs = append(refs, "instance://"+name)
+		}
+		return refs, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeTags returns the references of the tags of the repository of the
+// docker:// or oras:// reference ref, once the tag is being typed. The tags
+// are listed at most once per completionTagsMaxAge, and cached.
+func completeTags(cmd *cobra.Command, ref string) []string {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.Contains(ref[i:], "/") || strings.HasPrefix(ref[i:], "://") {
+		return nil
+	}
+	repo := ref[:i]
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+
+	imgCache := getCacheHandle(cache.Config{Disable: disableCache})
+	tags, err := ociclient.ListTagsCached(ctx, imgCache, repo, completionTagsMaxAge, nil, noHTTPS, reqAuthFile)
+	if err != nil {
+		sylog.Debugf("Could not list tags of %s: %v", repo, err)
+		return nil
+	}
+	refs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		refs = append(refs, repo+":"+tag)
+	}
+	return refs
+}
+
+// completeInstance completes the instance name argument of the instance
+// commands.
+func completeInstance(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return instanceNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// instanceNames returns the names of the instances of the current user.
+func instanceNames() []string {
+	ii, err := instance.List("", "*", instance.AppSubDir, false)
+	if err != nil {
+		sylog.Debugf("Could not list instances: %v", err)
+		return nil
+	}
+	names := make([]string, 0, len(ii))
+	for _, i := range ii {
+		names = append(names, i.Name)
+	}
+	return names
+}
+
+// completeDevice completes --device with the names of the CDI devices.
+func completeDevice(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return cdi.Scan(getCDIDirs()).Names(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageConfigFile string
+	imageEntrypoint string
+	imageEnv        []string
+	imageLabels     []string
+)
+
+// --config
+var imageConfigFileFlag = cmdline.Flag{
+	ID:           "imageConfigFileFlag",
+	Value:        &imageConfigFile,
+	DefaultValue: "",
+	Name:         "config",
+	Usage:        "JSON file holding the OCI image configuration (entrypoint, cmd, env, labels...) of the image",
+}
+
+// --entrypoint
+var imageEntrypointFlag = cmdline.Flag{
+	ID:           "imageEntrypointFlag",
+	Value:        &imageEntrypoint,
+	DefaultValue: "",
+	Name:         "entrypoint",
+	Usage:        "entrypoint of the image, a command path or a JSON array of arguments",
+}
+
+// --env
+var imageEnvFlag = cmdline.Flag{
+	ID:           "imageEnvFlag",
+	Value:        &imageEnv,
+	DefaultValue: cmdline.StringArray{},
+	Name:         "env",
+	Usage:        "add an environment variable (KEY=VALUE) to the image configuration",
+}
+
+// --label
+var imageLabelFlag = cmdline.Flag{
+	ID:           "imageLabelFlag",
+	Value:        &imageLabels,
+	DefaultValue: cmdline.StringArray{},
+	Name:         "label",
+	Usage:        "add a label (KEY=VALUE) to the image configuration",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(ConvertCmd)
+
+		cmdManager.RegisterFlagForCmd(&actionTmpDirFlag, ConvertCmd)
+		cmdManager.RegisterFlagForCmd(&imageConfigFileFlag, ConvertCmd, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&imageEntrypointFlag, ConvertCmd, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&imageEnvFlag, ConvertCmd, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&imageLabelFlag, ConvertCmd, SaveCmd)
+	})
+}
+
+// imageConfigOptions returns the image configuration overrides set by the
+// --config, --entrypoint, --env and --label options.
+func imageConfigOptions() (apptainer.ImageConfigOptions, error) {
+	co := apptainer.ImageConfigOptions{
+		ConfigFile: imageConfigFile,
+		Env:        imageEnv,
+	}
+	if strings.HasPrefix(imageEntrypoint, "[") {
+		if err := json.Unmarshal([]byte(imageEntrypoint), &co.Entrypoint); err != nil {
+			return co, fmt.Errorf("invalid entrypoint JSON array: %w", err)
+		}
+	} else if imageEntrypoint != "" {
+		co.Entrypoint = []string{imageEntrypoint}
+	}
+	for _, l := range imageLabels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return co, fmt.Errorf("invalid label %q, must be KEY=VALUE", l)
+		}
+		if co.Labels == nil {
+			co.Labels = make(map[string]string)
+		}
+		co.Labels[k] = v
+	}
+	return co, nil
+}
+
+// ConvertCmd is the 'convert' command that converts a native SIF image to
+// an OCI-SIF image and vice versa.
+var ConvertCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		co, err := imageConfigOptions()
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		err = apptainer.Convert(args[0], args[1], apptainer.ConvertOptions{
+			TmpDir: tmpDir,
+			Config: co,
+		})
+		if err != nil {
+			sylog.Fatalf("Unable to convert %s: %s", args[0], err)
+		}
+		sylog.Infof("Image converted to %s", args[1])
+	},
+
+	Use:     docs.ConvertUse,
+	Short:   docs.ConvertShort,
+	Long:    docs.ConvertLong,
+	Example: docs.ConvertExample,
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"io"
+	"os"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var exportOCILayer bool
+
+// --oci-layer
+var exportOCILayerFlag = cmdline.Flag{
+	ID:           "exportOCILayerFlag",
+	Value:        &exportOCILayer,
+	DefaultValue: false,
+	Name:         "oci-layer",
+	Usage:        "write a gzip compressed OCI layer and report its digests",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(ExportCmd)
+
+		cmdManager.RegisterFlagForCmd(&exportOCILayerFlag, ExportCmd)
+		cmdManager.RegisterFlagForCmd(&actionTmpDirFlag, ExportCmd)
+	})
+}
+
+// ExportCmd is the 'export' command that writes a tarball of the filesystem
+// of an image or a running instance.
+var ExportCmd = &cobra.Command{
+	Args:                  cobra.RangeArgs(1, 2),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		var w io.Writer = os.Stdout
+		var out string
+		if len(args) == 2 && args[1] != "-" {
+			f, err := os.OpenFile(args[1], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				sylog.Fatalf("Unable to create %s: %s", args[1], err)
+			}
+			defer f.Close()
+			w, out = f, args[1]
+		} else if term.IsTerminal(int(os.Stdout.Fd())) {
+			sylog.Fatalf("Refusing to write a tarball to a terminal, redirect the output or specify a file")
+		}
+
+		d, err := apptainer.Export(args[0], w, apptainer.ExportOptions{
+			OCILayer: exportOCILayer,
+			TmpDir:   tmpDir,
+		})
+		if err != nil {
+			if out != "" {
+				os.Remove(out)
+			}
+			sylog.Fatalf("Unable to export %s: %s", args[0], err)
+		}
+		if d != nil {
+			sylog.Infof("Layer digest: %s", d.Digest)
+			sylog.Infof("Layer diff ID: %s", d.DiffID)
+		}
+	},
+
+	Use:     docs.ExportUse,
+	Short:   docs.ExportShort,
+	Long:    docs.ExportLong,
+	Example: docs.ExportExample,
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client/oci"
+	"github.com/apptainer/apptainer/internal/pkg/client/oras"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(ImagesCmd)
+		cmdManager.RegisterSubCmd(ImagesCmd, ImagesListCmd)
+		cmdManager.RegisterSubCmd(ImagesCmd, ImagesOutdatedCmd)
+		cmdManager.RegisterSubCmd(ImagesCmd, ImagesRegisterCmd)
+		cmdManager.RegisterSubCmd(ImagesCmd, ImagesUnregisterCmd)
+
+		cmdManager.RegisterFlagForCmd(&imagesListJSONFlag, ImagesListCmd)
+
+		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, ImagesOutdatedCmd)
+		cmdManager.RegisterFlagForCmd(&commonTmpDirFlag, ImagesOutdatedCmd)
+
+		cmdManager.RegisterFlagForCmd(&dockerHostFlag, ImagesOutdatedCmd)
+		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, ImagesOutdatedCmd)
+		cmdManager.RegisterFlagForCmd(&dockerPasswordFlag, ImagesOutdatedCmd)
+		cmdManager.RegisterFlagForCmd(&dockerLoginFlag, ImagesOutdatedCmd)
+		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, ImagesOutdatedCmd)
+	})
+}
+
+// ImagesCmd apptainer images
+var ImagesCmd = &cobra.Command{
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return errors.New("invalid command")
+	},
+	DisableFlagsInUseLine: true,
+
+	Use:           docs.ImagesUse,
+	Short:         docs.ImagesShort,
+	Long:          docs.ImagesLong,
+	Example:       docs.ImagesExample,
+	SilenceErrors: true,
+}
+
+// -j|--json
+var imagesListJSON bool
+
+var imagesListJSONFlag = cmdline.Flag{
+	ID:           "imagesListJSONFlag",
+	Value:        &imagesListJSON,
+	DefaultValue: false,
+	Name:         "json",
+	ShortHand:    "j",
+	Usage:        "print structured json instead of list",
+	EnvKeys:      []string{"JSON"},
+}
+
+// ImagesListCmd apptainer images list
+var ImagesListCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(_ *cobra.Command, _ []string) {
+		dirs, err := apptainer.ImageDirs(syfs.ImageDirs())
+		if err != nil {
+			sylog.Fatalf("While reading registered image directories: %v", err)
+		}
+		images, err := apptainer.LocalImages(getCacheHandle(cache.Config{}), dirs)
+		if err != nil {
+			sylog.Fatalf("While listing images: %v", err)
+		}
+		if err := apptainer.PrintLocalImages(os.Stdout, images, imagesListJSON); err != nil {
+			sylog.Fatalf("While printing images: %v", err)
+		}
+	},
+
+	Use:     docs.ImagesListUse,
+	Short:   docs.ImagesListShort,
+	Long:    docs.ImagesListLong,
+	Example: docs.ImagesListExample,
+}
+
+// ImagesRegisterCmd apptainer images register
+var ImagesRegisterCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := apptainer.RegisterImageDir(syfs.ImageDirs(), args[0]); err != nil {
+			sylog.Fatalf("While registering image directory: %v", err)
+		}
+	},
+
+	Use:     docs.ImagesRegisterUse,
+	Short:   docs.ImagesRegisterShort,
+	Long:    docs.ImagesRegisterLong,
+	Example: docs.ImagesRegisterExample,
+}
+
+// ImagesUnregisterCmd apptainer images unregister
+var ImagesUnregisterCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := apptainer.UnregisterImageDir(syfs.ImageDirs(), args[0]); err != nil {
+			sylog.Fatalf("While unregistering image directory: %v", err)
+		}
+	},
+
+	Use:     docs.ImagesUnregisterUse,
+	Short:   docs.ImagesUnregisterShort,
+	Long:    docs.ImagesUnregisterLong,
+	Example: docs.ImagesUnregisterExample,
+}
+
+// ImagesOutdatedCmd apptainer images outdated
+var ImagesOutdatedCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, _ []string) {
+		imgCache := getCacheHandle(cache.Config{})
+		if imgCache.IsDisabled() {
+			sylog.Fatalf("The cache is disabled, no image digests are recorded")
+		}
+		records, err := imgCache.ImageRecords()
+		if err != nil {
+			sylog.Fatalf("While reading image records: %v", err)
+		}
+		if len(records) == 0 {
+			fmt.Println("No pulled docker:// or oras:// images recorded in the cache.")
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", "URI", "LOCATION", "PULLED", "STATUS")
+		for _, r := range records {
+			location := r.Path
+			if location == "" {
+				location = "cache"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.URI, location, r.Pulled.Local().Format(time.DateTime), imageStatus(cmd, r))
+		}
+		tw.Flush()
+	},
+
+	Use:     docs.ImagesOutdatedUse,
+	Short:   docs.ImagesOutdatedShort,
+	Long:    docs.ImagesOutdatedLong,
+	Example: docs.ImagesOutdatedExample,
+}
+
+// imageStatus returns whether the image of the record changed in the
+// registry since it was pulled.
+func imageStatus(cmd *cobra.Command, r *cache.ImageRecord) string {
+	if r.Path != "" {
+		if _, err := os.Stat(r.Path); os.IsNotExist(err) {
+			return "missing"
+		}
+	}
+	digest, err := remoteDigest(cmd, r.URI, r.Arch)
+	if err != nil {
+		sylog.Warningf("Unable to check %s: %v", r.URI, err)
+		return "unknown"
+	}
+	if digest != r.Digest {
+		return "outdated"
+	}
+	return "up to date"
+}
+
+// remoteDigest returns the digest the docker:// or oras:// image at
+// imageURI currently resolves to for arch, as recorded in the cache when it
+// is pulled.
+func remoteDigest(cmd *cobra.Command, imageURI, arch string) (string, error) {
+	ociAuth, err := makeOCICredentials(cmd)
+	if err != nil {
+		return "", fmt.Errorf("while creating docker credentials: %v", err)
+	}
+
+	if t, _ := uri.Split(imageURI); t == OrasProtocol {
+		hash, err := oras.RefHash(cmd.Context(), imageURI, ociAuth, noHTTPS, reqAuthFile)
+		if err != nil {
+			return "", err
+		}
+		return hash.String(), nil
+	}
+
+	opts := oci.PullOptions{
+		TmpDir:      tmpDir,
+		OciAuth:     ociAuth,
+		DockerHost:  dockerHost,
+		NoHTTPS:     noHTTPS,
+		Pullarch:    arch,
+		ReqAuthFile: reqAuthFile,
+	}
+	return oci.RemoteDigest(cmd.Context(), imageURI, opts)
+}
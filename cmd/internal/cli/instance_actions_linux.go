@@ -22,6 +22,9 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&instanceStartPidFileFlag, instanceStartCmd, instanceRunCmd)
 		cmdManager.RegisterFlagForCmd(&actionDMTCPLaunchFlag, instanceStartCmd, instanceRunCmd)
 		cmdManager.RegisterFlagForCmd(&actionDMTCPRestartFlag, instanceStartCmd, instanceRunCmd)
+		cmdManager.RegisterFlagForCmd(&instanceStartIPFlag, instanceStartCmd, instanceRunCmd)
+		cmdManager.RegisterFlagForCmd(&instanceStartMACFlag, instanceStartCmd, instanceRunCmd)
+		cmdManager.RegisterFlagForCmd(&instanceStartLogDriverFlag, instanceStartCmd, instanceRunCmd)
 	})
 }
 
@@ -37,6 +40,45 @@ var instanceStartPidFileFlag = cmdline.Flag{
 	EnvKeys:      []string{"PID_FILE"},
 }
 
+// --ip
+var instanceStartIP string
+
+var instanceStartIPFlag = cmdline.Flag{
+	ID:           "instanceStartIPFlag",
+	Value:        &instanceStartIP,
+	DefaultValue: "",
+	Name:         "ip",
+	Usage:        "request a static IP address for the instance on its first network (implies --net)",
+	EnvKeys:      []string{"IP"},
+	Tag:          "<address>",
+}
+
+// --mac
+var instanceStartMAC string
+
+var instanceStartMACFlag = cmdline.Flag{
+	ID:           "instanceStartMACFlag",
+	Value:        &instanceStartMAC,
+	DefaultValue: "",
+	Name:         "mac",
+	Usage:        "request a static MAC address for the instance on its first network, supported by bridge and macvlan networks (implies --net)",
+	EnvKeys:      []string{"MAC"},
+	Tag:          "<address>",
+}
+
+// --log-driver
+var instanceStartLogDriver string
+
+var instanceStartLogDriverFlag = cmdline.Flag{
+	ID:           "instanceStartLogDriverFlag",
+	Value:        &instanceStartLogDriver,
+	DefaultValue: "",
+	Name:         "log-driver",
+	Usage:        "send instance output to log files, syslog, the systemd journal, or discard it (default set by apptainer.conf)",
+	EnvKeys:      []string{"LOG_DRIVER"},
+	Tag:          "<file|syslog|journald|none>",
+}
+
 // execute either the instance start or run command
 func instanceAction(cmd *cobra.Command, args []string) {
 	image := args[0]
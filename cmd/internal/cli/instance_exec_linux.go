@@ -0,0 +1,53 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"os"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+// apptainer instance exec
+var instanceExecCmd = &cobra.Command{
+	Args:                  cobra.MinimumNArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		name := instance.ExtractName(args[0])
+
+		if _, err := instance.Get(name, instance.AppSubDir); err == nil {
+			// like with OCI containers, the command runs with the
+			// container environment instead of the host environment
+			isCleanEnv = true
+			a := append([]string{"/.singularity.d/actions/exec"}, args[1:]...)
+			if err := launchContainer(cmd, "instance://"+name, a, "", -1); err != nil {
+				sylog.Fatalf("%s", err)
+			}
+			return
+		}
+
+		if _, err := instance.Get(name, instance.OciSubDir); err == nil {
+			if os.Geteuid() != 0 {
+				sylog.Fatalf("Executing a command in the OCI container %s requires root privileges", name)
+			}
+			if err := apptainer.OciExec(name, args[1:]); err != nil { //nolint:staticcheck
+				sylog.Fatalf("%s", err)
+			}
+			return
+		}
+
+		sylog.Fatalf("No instance or OCI container found with name %s", name)
+	},
+
+	Use:     docs.InstanceExecUse,
+	Short:   docs.InstanceExecShort,
+	Long:    docs.InstanceExecLong,
+	Example: docs.InstanceExecExample,
+}
@@ -25,6 +25,9 @@ func init() {
 		cmdManager.RegisterSubCmd(instanceCmd, instanceStopCmd)
 		cmdManager.RegisterSubCmd(instanceCmd, instanceListCmd)
 		cmdManager.RegisterSubCmd(instanceCmd, instanceStatsCmd)
+		cmdManager.RegisterSubCmd(instanceCmd, instanceTopCmd)
+		cmdManager.RegisterSubCmd(instanceCmd, instanceExecCmd)
+		cmdManager.RegisterSubCmd(instanceCmd, instanceMigrateCmd)
 	})
 }
 
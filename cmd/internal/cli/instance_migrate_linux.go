@@ -0,0 +1,60 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"time"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/checkpoint/dmtcp"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterFlagForCmd(&instanceMigrateSharedFlag, instanceMigrateCmd)
+		cmdManager.RegisterFlagForCmd(&instanceStopTimeoutFlag, instanceMigrateCmd)
+	})
+}
+
+// --shared
+var instanceMigrateShared bool
+
+var instanceMigrateSharedFlag = cmdline.Flag{
+	ID:           "instanceMigrateSharedFlag",
+	Value:        &instanceMigrateShared,
+	DefaultValue: false,
+	Name:         "shared",
+	Usage:        "the image and the checkpoint are on a file system shared with the destination host, don't copy them",
+	EnvKeys:      []string{"MIGRATE_SHARED"},
+}
+
+// apptainer instance migrate
+var instanceMigrateCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	PreRun: func(_ *cobra.Command, _ []string) {
+		dmtcp.QuickInstallationCheck()
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		opts := apptainer.MigrateOptions{
+			Shared:      instanceMigrateShared,
+			StopTimeout: time.Duration(instanceStopTimeout) * time.Second,
+		}
+		if err := apptainer.MigrateInstance(args[0], args[1], opts); err != nil {
+			sylog.Fatalf("Failed to migrate instance %s: %s", args[0], err)
+		}
+		sylog.Infof("Instance %s migrated to %s", args[0], args[1])
+	},
+
+	Use:     docs.InstanceMigrateUse,
+	Short:   docs.InstanceMigrateShort,
+	Long:    docs.InstanceMigrateLong,
+	Example: docs.InstanceMigrateExample,
+}
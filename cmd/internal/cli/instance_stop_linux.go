@@ -82,7 +82,7 @@ var instanceStopSignalFlag = cmdline.Flag{
 	DefaultValue: "",
 	Name:         "signal",
 	ShortHand:    "s",
-	Usage:        "signal sent to the instance",
+	Usage:        "signal sent to the instance (default: the stop signal of the image, or SIGINT)",
 	Tag:          "<signal>",
 	EnvKeys:      []string{"SIGNAL"},
 }
@@ -97,6 +97,7 @@ var instanceStopTimeoutFlag = cmdline.Flag{
 	Name:         "timeout",
 	ShortHand:    "t",
 	Usage:        "force kill non stopped instances after X seconds",
+	EnvKeys:      []string{"STOP_TIMEOUT"},
 }
 
 // apptainer instance stop
@@ -113,7 +114,8 @@ var instanceStopCmd = &cobra.Command{
 			sylog.Fatalf("Only root user can stop user's instances")
 		}
 
-		sig := syscall.SIGINT
+		// the stop signal of the instance images, or SIGINT, by default
+		var sig syscall.Signal
 		if instanceStopSignal != "" {
 			var err error
 			sig, err = signal.Convert(instanceStopSignal)
@@ -104,6 +104,9 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&keyserverLoginUsernameFlag, KeyserverLoginCmd)
 		cmdManager.RegisterFlagForCmd(&keyserverLoginPasswordFlag, KeyserverLoginCmd)
 		cmdManager.RegisterFlagForCmd(&keyserverLoginPasswordStdinFlag, KeyserverLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCFlag, KeyserverLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCIssuerFlag, KeyserverLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCClientIDFlag, KeyserverLoginCmd)
 	})
 }
 
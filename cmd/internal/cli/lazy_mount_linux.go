@@ -0,0 +1,38 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"os"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/net"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(LazyMountCmd)
+	})
+}
+
+// LazyMountCmd is the hidden 'lazy-mount' command serving the FUSE
+// filesystem of an image mounted with --lazy, configured from its standard
+// input.
+var LazyMountCmd = &cobra.Command{
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	Hidden:                true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := net.ServeLazy(os.Stdin, os.Stdout); err != nil {
+			sylog.Fatalf("Lazy mount failed: %s", err)
+		}
+	},
+
+	Use:   "lazy-mount",
+	Short: "Serve a lazily mounted image (internal use)",
+}
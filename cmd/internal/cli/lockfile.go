@@ -0,0 +1,76 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ociclient "github.com/apptainer/apptainer/internal/pkg/client/oci"
+	"github.com/apptainer/apptainer/internal/pkg/lockfile"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+// lockReference applies the lockfile to imageURI. When --lockfile is set,
+// the digest a tag-based docker:// or oras:// reference resolves to is
+// recorded in the lockfile. With --locked, images whose digest doesn't match
+// the lockfile are refused instead. The returned URI is pinned to the digest,
+// so that the image pulled is the one checked or recorded.
+func lockReference(ctx context.Context, cmd *cobra.Command, imageURI string) (string, error) {
+	if lockfilePath == "" && !lockedMode {
+		return imageURI, nil
+	}
+
+	t, _ := uri.Split(imageURI)
+	if t != DockerProtocol && t != OrasProtocol {
+		if lockedMode {
+			return "", fmt.Errorf("%s can't be checked against the lockfile, only docker:// and oras:// images are allowed with --locked", imageURI)
+		}
+		return imageURI, nil
+	}
+
+	ociAuth, err := makeOCICredentials(cmd)
+	if err != nil {
+		return "", fmt.Errorf("while creating docker credentials: %v", err)
+	}
+	ref, digest, err := ociclient.ResolveDigest(ctx, imageURI, ociAuth, noHTTPS, reqAuthFile)
+	if err != nil {
+		return "", err
+	}
+	// digest references are reproducible already
+	if strings.Contains(ref, "@") {
+		return imageURI, nil
+	}
+	key := t + "://" + ref
+
+	path := lockfilePath
+	if path == "" {
+		path = lockfile.DefaultName
+	}
+	if lockedMode {
+		l, err := lockfile.Load(path)
+		if err != nil {
+			return "", fmt.Errorf("while reading lockfile: %v", err)
+		}
+		locked, ok := l.Digest(key)
+		if !ok {
+			return "", fmt.Errorf("%s is not recorded in lockfile %s", key, path)
+		}
+		if locked != digest {
+			return "", fmt.Errorf("%s resolves to %s, not to %s as recorded in lockfile %s", key, digest, locked, path)
+		}
+	} else {
+		if err := lockfile.Record(path, key, digest); err != nil {
+			return "", fmt.Errorf("while recording %s in lockfile: %v", key, err)
+		}
+		sylog.Verbosef("Recorded %s as %s in lockfile %s", key, digest, path)
+	}
+
+	return ociclient.PinDigest(imageURI, digest, noHTTPS)
+}
@@ -29,6 +29,9 @@ func ObtainLoginArgs(name string) *apptainer.LoginArgs {
 	loginArgs.Tokenfile = loginTokenFile
 	loginArgs.Insecure = loginInsecure
 	loginArgs.ReqAuthFile = reqAuthFile
+	loginArgs.OIDC = loginOIDC
+	loginArgs.OIDCIssuer = loginOIDCIssuer
+	loginArgs.OIDCClientID = loginOIDCClientID
 
 	if loginPasswordStdin {
 		p, err := io.ReadAll(os.Stdin)
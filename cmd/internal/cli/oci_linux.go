@@ -12,12 +12,16 @@ package cli
 import (
 	"github.com/apptainer/apptainer/docs"
 	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	"github.com/spf13/cobra"
 )
 
-var ociArgs apptainer.OciArgs
+var (
+	ociArgs            apptainer.OciArgs
+	ociMountConfigPath string
+)
 
 // -b|--bundle
 var ociBundleFlag = cmdline.Flag{
@@ -32,6 +36,27 @@ var ociBundleFlag = cmdline.Flag{
 	EnvKeys:      []string{"BUNDLE"},
 }
 
+// --config
+var ociConfigFlag = cmdline.Flag{
+	ID:           "ociConfigFlag",
+	Value:        &ociArgs.ConfigPath,
+	DefaultValue: "",
+	Name:         "config",
+	Usage:        "read the OCI configuration from this file instead of the bundle config.json, - for standard input",
+	Tag:          "<path>",
+	EnvKeys:      []string{"OCI_CONFIG"},
+}
+
+// --config (mount)
+var ociMountConfigFlag = cmdline.Flag{
+	ID:           "ociMountConfigFlag",
+	Value:        &ociMountConfigPath,
+	DefaultValue: "",
+	Name:         "config",
+	Usage:        "write the OCI configuration to this file instead of the bundle config.json, - for standard output",
+	Tag:          "<path>",
+}
+
 // -s|--sync-socket
 var ociSyncSocketFlag = cmdline.Flag{
 	ID:           "ociSyncSocketFlag",
@@ -92,10 +117,10 @@ var ociPidFileFlag = cmdline.Flag{
 var ociKillSignalFlag = cmdline.Flag{
 	ID:           "ociKillSignalFlag",
 	Value:        &ociArgs.KillSignal,
-	DefaultValue: "SIGTERM",
+	DefaultValue: "",
 	Name:         "signal",
 	ShortHand:    "s",
-	Usage:        "signal sent to the container",
+	Usage:        "signal sent to the container (default: the stop signal of the image, or SIGTERM)",
 	Tag:          "<signal>",
 	EnvKeys:      []string{"SIGNAL"},
 }
@@ -118,7 +143,7 @@ var ociKillTimeoutFlag = cmdline.Flag{
 	DefaultValue: uint32(0),
 	Name:         "timeout",
 	ShortHand:    "t",
-	Usage:        "timeout in second before killing container",
+	Usage:        "grace period in seconds before killing the container with SIGKILL, if not stopped by the signal",
 }
 
 // -f|--from-file
@@ -132,6 +157,292 @@ var ociUpdateFromFileFlag = cmdline.Flag{
 	EnvKeys:      []string{"FROM_FILE"},
 }
 
+// -j|--json
+var ociTopJSONFlag = cmdline.Flag{
+	ID:           "ociTopJSONFlag",
+	Value:        &ociArgs.FormatJSON,
+	DefaultValue: false,
+	Name:         "json",
+	ShortHand:    "j",
+	Usage:        "output processes in json",
+}
+
+// --read-only-root
+var ociReadOnlyRootFlag = cmdline.Flag{
+	ID:           "ociReadOnlyRootFlag",
+	Value:        &ociArgs.ReadOnlyRoot,
+	DefaultValue: false,
+	Name:         "read-only-root",
+	Usage:        "mount the container root filesystem read-only",
+	EnvKeys:      []string{"READ_ONLY_ROOT"},
+}
+
+// --nv
+var ociNvidiaFlag = cmdline.Flag{
+	ID:           "ociNvidiaFlag",
+	Value:        &ociArgs.Nvidia,
+	DefaultValue: false,
+	Name:         "nv",
+	Usage:        "enable Nvidia support, using the nvidia.com/gpu=all CDI device if found, or the libraries listed in nvliblist.conf",
+	EnvKeys:      []string{"NV"},
+}
+
+// --no-passwd-group
+var ociNoPasswdGroupFlag = cmdline.Flag{
+	ID:           "ociNoPasswdGroupFlag",
+	Value:        &ociArgs.NoPasswdGroup,
+	DefaultValue: false,
+	Name:         "no-passwd-group",
+	Usage:        "don't synthesize /etc/passwd and /etc/group entries for the container user",
+	EnvKeys:      []string{"NO_PASSWD_GROUP"},
+}
+
+// --passwd-template
+var ociPasswdTemplateFlag = cmdline.Flag{
+	ID:           "ociPasswdTemplateFlag",
+	Value:        &ociArgs.PasswdTemplate,
+	DefaultValue: "",
+	Name:         "passwd-template",
+	Tag:          "<path>",
+	Usage:        "synthesize /etc/passwd from this file instead of the container /etc/passwd",
+	EnvKeys:      []string{"PASSWD_TEMPLATE"},
+}
+
+// --group-template
+var ociGroupTemplateFlag = cmdline.Flag{
+	ID:           "ociGroupTemplateFlag",
+	Value:        &ociArgs.GroupTemplate,
+	DefaultValue: "",
+	Name:         "group-template",
+	Tag:          "<path>",
+	Usage:        "synthesize /etc/group from this file instead of the container /etc/group",
+	EnvKeys:      []string{"GROUP_TEMPLATE"},
+}
+
+// -H|--home
+var ociHomeFlag = cmdline.Flag{
+	ID:           "ociHomeFlag",
+	Value:        &ociArgs.Home,
+	DefaultValue: "",
+	Name:         "home",
+	ShortHand:    "H",
+	Usage:        "a home directory specification.  spec can either be a src path or src:dest pair.  src is the source path of the home directory outside the container and dest overrides the home directory within the container.",
+	Tag:          "<spec>",
+}
+
+// --no-home
+var ociNoHomeFlag = cmdline.Flag{
+	ID:           "ociNoHomeFlag",
+	Value:        &ociArgs.NoHome,
+	DefaultValue: false,
+	Name:         "no-home",
+	Usage:        "do NOT mount the home directory, starting in / when it would start in it",
+	EnvKeys:      []string{"NO_HOME"},
+}
+
+// --contain
+var ociContainFlag = cmdline.Flag{
+	ID:           "ociContainFlag",
+	Value:        &ociArgs.ContainHome,
+	DefaultValue: false,
+	Name:         "contain",
+	Usage:        "use a tmpfs home directory instead of binding it from the host",
+	EnvKeys:      []string{"CONTAIN"},
+}
+
+// -S|--scratch
+var ociScratchFlag = cmdline.Flag{
+	ID:           "ociScratchFlag",
+	Value:        &ociArgs.ScratchDirs,
+	DefaultValue: []string{},
+	Name:         "scratch",
+	ShortHand:    "S",
+	Usage:        "include a scratch directory within the container, a tmpfs or a directory removed when the container exits (use -W to force location)",
+	EnvKeys:      []string{"SCRATCH", "SCRATCHDIR"},
+	Tag:          "<path>",
+}
+
+// -W|--workdir
+var ociWorkdirFlag = cmdline.Flag{
+	ID:           "ociWorkdirFlag",
+	Value:        &ociArgs.WorkDir,
+	DefaultValue: "",
+	Name:         "workdir",
+	ShortHand:    "W",
+	Usage:        "working directory holding the scratch directories",
+	EnvKeys:      []string{"WORKDIR"},
+	Tag:          "<path>",
+}
+
+// --cwd
+var ociCwdFlag = cmdline.Flag{
+	ID:           "ociCwdFlag",
+	Value:        &ociArgs.Cwd,
+	DefaultValue: "",
+	Name:         "cwd",
+	Usage:        "initial working directory for payload process inside the container (synonym for --pwd)",
+	EnvKeys:      []string{"CWD", "TARGET_CWD"},
+	Tag:          "<path>",
+}
+
+// --pwd
+var ociPwdFlag = cmdline.Flag{
+	ID:           "ociPwdFlag",
+	Value:        &ociArgs.Cwd,
+	DefaultValue: "",
+	Name:         "pwd",
+	Usage:        "initial working directory for payload process inside the container (synonym for --cwd)",
+	Hidden:       true,
+	EnvKeys:      []string{"PWD", "TARGET_PWD"},
+	Tag:          "<path>",
+}
+
+// --keep-cwd
+var ociKeepCwdFlag = cmdline.Flag{
+	ID:           "ociKeepCwdFlag",
+	Value:        &ociArgs.KeepCwd,
+	DefaultValue: false,
+	Name:         "keep-cwd",
+	Usage:        "start the payload process in the current directory, when it exists inside the container",
+	EnvKeys:      []string{"KEEP_CWD"},
+}
+
+// --debug-keep-bundle
+var ociDebugKeepBundleFlag = cmdline.Flag{
+	ID:           "ociDebugKeepBundleFlag",
+	Value:        &ociArgs.DebugKeepBundle,
+	DefaultValue: false,
+	Name:         "debug-keep-bundle",
+	Usage:        "keep the bundle and configuration of the container when it fails, to debug it with 'oci debug'",
+	EnvKeys:      []string{"DEBUG_KEEP_BUNDLE"},
+}
+
+// --shell
+var ociDebugShellFlag = cmdline.Flag{
+	ID:           "ociDebugShellFlag",
+	Value:        &ociArgs.DebugShell,
+	DefaultValue: "",
+	Name:         "shell",
+	Usage:        "path to the shell to run in the container (default /bin/sh)",
+	Tag:          "<path>",
+}
+
+// --tmpfs
+var ociTmpfsFlag = cmdline.Flag{
+	ID:           "ociTmpfsFlag",
+	Value:        &ociArgs.Tmpfs,
+	DefaultValue: cmdline.StringArray{},
+	Name:         "tmpfs",
+	Usage:        "mount a writable tmpfs on a container directory, with optional mount options (e.g. --tmpfs /run:size=64m,mode=755)",
+	Tag:          "<path[:options]>",
+	EnvKeys:      []string{"TMPFS"},
+}
+
+// --no-mount
+var ociNoMountFlag = cmdline.Flag{
+	ID:           "ociNoMountFlag",
+	Value:        &ociArgs.NoMount,
+	DefaultValue: []string{},
+	Name:         "no-mount",
+	Usage:        "disable one or more default mounts of the bundle configuration (proc, sys, dev, devpts, tmp) and/or specify an absolute destination path to disable a mount",
+	EnvKeys:      []string{"NO_MOUNT"},
+}
+
+// --net
+var ociNetFlag = cmdline.Flag{
+	ID:           "ociNetFlag",
+	Value:        &ociArgs.Network,
+	DefaultValue: "",
+	Name:         "net",
+	Usage:        "network of the container: 'host' to share the host network, 'none' for a loopback-only network namespace, 'container:<id>' to join the network namespace of a container or instance://<name>, or a comma separated list of CNI network configurations",
+	Tag:          "<none|host|container:id|network>",
+	EnvKeys:      []string{"NET"},
+}
+
+// --netns
+var ociNetnsFlag = cmdline.Flag{
+	ID:           "ociNetnsFlag",
+	Value:        &ociArgs.Netns,
+	DefaultValue: "",
+	Name:         "netns",
+	Usage:        "join the network namespace at the specified path, or created by 'ip netns add' with the specified name",
+	Tag:          "<path|name>",
+	EnvKeys:      []string{"NETNS"},
+}
+
+// --ipc
+var ociIPCFlag = cmdline.Flag{
+	ID:           "ociIPCFlag",
+	Value:        &ociArgs.IPC,
+	DefaultValue: "",
+	Name:         "ipc",
+	Usage:        "IPC namespace of the container: 'host' to share the host IPC namespace, or 'container:<id>' to join the IPC namespace of a container or instance://<name>",
+	Tag:          "<host|container:id>",
+	EnvKeys:      []string{"IPC"},
+}
+
+// --pid
+var ociPIDFlag = cmdline.Flag{
+	ID:           "ociPIDFlag",
+	Value:        &ociArgs.PID,
+	DefaultValue: "",
+	Name:         "pid",
+	Usage:        "PID namespace of the container: 'host' to share the host PID namespace, or 'container:<id>' to join the PID namespace of a container or instance://<name>",
+	Tag:          "<host|container:id>",
+	EnvKeys:      []string{"PID"},
+}
+
+// --init
+var ociInitFlag = cmdline.Flag{
+	ID:           "ociInitFlag",
+	Value:        &ociArgs.Init,
+	DefaultValue: false,
+	Name:         "init",
+	Usage:        "run an init process reaping zombie processes and forwarding signals to the container process",
+	EnvKeys:      []string{"INIT"},
+}
+
+// --cgroup-parent
+var ociCgroupParentFlag = cmdline.Flag{
+	ID:           "ociCgroupParentFlag",
+	Value:        &ociArgs.CgroupParent,
+	DefaultValue: "",
+	Name:         "cgroup-parent",
+	Usage:        "parent cgroup path, or systemd slice with systemd cgroups, under which the container cgroup is created when the bundle doesn't set one",
+	Tag:          "<path|slice>",
+	EnvKeys:      []string{"CGROUP_PARENT"},
+}
+
+// --security
+var ociSecurityFlag = cmdline.Flag{
+	ID:           "ociSecurityFlag",
+	Value:        &ociArgs.Security,
+	DefaultValue: []string{},
+	Name:         "security",
+	Usage:        "enable security features, only 'landlock:<ruleset.json>' is supported to restrict filesystem accesses with a Landlock ruleset",
+	EnvKeys:      []string{"SECURITY"},
+}
+
+// --no-new-privs
+var ociNoNewPrivsFlag = cmdline.Flag{
+	ID:           "ociNoNewPrivsFlag",
+	Value:        &ociArgs.NoNewPrivs,
+	DefaultValue: false,
+	Name:         "no-new-privs",
+	Usage:        "prevent the container processes from gaining new privileges, whatever the bundle configuration",
+	EnvKeys:      []string{"NO_NEW_PRIVS"},
+}
+
+// --allow-new-privs
+var ociAllowNewPrivsFlag = cmdline.Flag{
+	ID:           "ociAllowNewPrivsFlag",
+	Value:        &ociArgs.AllowNewPrivs,
+	DefaultValue: false,
+	Name:         "allow-new-privs",
+	Usage:        "allow the container processes to gain new privileges, whatever the bundle configuration and apptainer.conf default",
+	EnvKeys:      []string{"ALLOW_NEW_PRIVS"},
+}
+
 func init() {
 	addCmdInit(func(cmdManager *cmdline.CommandManager) {
 		cmdManager.RegisterCmd(OciCmd)
@@ -146,23 +457,54 @@ func init() {
 		cmdManager.RegisterSubCmd(OciCmd, OciUpdateCmd)
 		cmdManager.RegisterSubCmd(OciCmd, OciPauseCmd)
 		cmdManager.RegisterSubCmd(OciCmd, OciResumeCmd)
+		cmdManager.RegisterSubCmd(OciCmd, OciTopCmd)
 		cmdManager.RegisterSubCmd(OciCmd, OciMountCmd)
 		cmdManager.RegisterSubCmd(OciCmd, OciUmountCmd)
+		cmdManager.RegisterSubCmd(OciCmd, OciDebugCmd)
 
 		cmdManager.SetCmdGroup("create_run", OciCreateCmd, OciRunCmd)
 		createRunCmd := cmdManager.GetCmdGroup("create_run")
 
 		cmdManager.RegisterFlagForCmd(&ociBundleFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociConfigFlag, createRunCmd...)
 		cmdManager.RegisterFlagForCmd(&ociSyncSocketFlag, createRunCmd...)
 		cmdManager.RegisterFlagForCmd(&ociLogPathFlag, createRunCmd...)
 		cmdManager.RegisterFlagForCmd(&ociLogFormatFlag, createRunCmd...)
 		cmdManager.RegisterFlagForCmd(&ociPidFileFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociReadOnlyRootFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNvidiaFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNoPasswdGroupFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociPasswdTemplateFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociGroupTemplateFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociHomeFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNoHomeFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociContainFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociScratchFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociWorkdirFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociCwdFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociPwdFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociKeepCwdFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociTmpfsFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNoMountFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNetFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNetnsFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociIPCFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociPIDFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociCgroupParentFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociInitFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociSecurityFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociNoNewPrivsFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociAllowNewPrivsFlag, createRunCmd...)
+		cmdManager.RegisterFlagForCmd(&ociDebugKeepBundleFlag, createRunCmd...)
 		cmdManager.RegisterFlagForCmd(&ociCreateEmptyProcessFlag, OciCreateCmd)
 		cmdManager.RegisterFlagForCmd(&ociKillForceFlag, OciKillCmd)
 		cmdManager.RegisterFlagForCmd(&ociKillSignalFlag, OciKillCmd)
 		cmdManager.RegisterFlagForCmd(&ociKillTimeoutFlag, OciKillCmd)
 		cmdManager.RegisterFlagForCmd(&ociUpdateFromFileFlag, OciUpdateCmd)
 		cmdManager.RegisterFlagForCmd(&ociSyncSocketFlag, OciStateCmd)
+		cmdManager.RegisterFlagForCmd(&ociTopJSONFlag, OciTopCmd)
+		cmdManager.RegisterFlagForCmd(&ociMountConfigFlag, OciMountCmd)
+		cmdManager.RegisterFlagForCmd(&ociDebugShellFlag, OciDebugCmd)
 	})
 }
 
@@ -352,13 +694,45 @@ var OciResumeCmd = &cobra.Command{
 	Example: docs.OciResumeExample,
 }
 
+// OciTopCmd represents oci top command.
+var OciTopCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	PreRun:                CheckRoot,
+	Run: func(_ *cobra.Command, args []string) {
+		if err := apptainer.OciTop(args[0], ociArgs.FormatJSON); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	},
+	Use:     docs.OciTopUse,
+	Short:   docs.OciTopShort,
+	Long:    docs.OciTopLong,
+	Example: docs.OciTopExample,
+}
+
+// OciDebugCmd represents oci debug command.
+var OciDebugCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	PreRun:                CheckRoot,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := apptainer.OciDebug(cmd.Context(), args[0], ociArgs.DebugShell); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	},
+	Use:     docs.OciDebugUse,
+	Short:   docs.OciDebugShort,
+	Long:    docs.OciDebugLong,
+	Example: docs.OciDebugExample,
+}
+
 // OciMountCmd represents oci mount command.
 var OciMountCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(2),
 	DisableFlagsInUseLine: true,
 	PreRun:                CheckRoot,
 	Run: func(_ *cobra.Command, args []string) {
-		if err := apptainer.OciMount(args[0], args[1]); err != nil {
+		if err := apptainer.OciMount(args[0], args[1], getCacheHandle(cache.Config{}), ociMountConfigPath); err != nil {
 			sylog.Fatalf("%s", err)
 		}
 	},
@@ -0,0 +1,245 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// prefetchFile is a file listing the images to prefetch
+	prefetchFile string
+	// prefetchJobs is the number of images prefetched concurrently
+	prefetchJobs int
+	// prefetchReport is the file the JSON report is written to
+	prefetchReport string
+)
+
+// -f|--file
+var prefetchFileFlag = cmdline.Flag{
+	ID:           "prefetchFileFlag",
+	Value:        &prefetchFile,
+	DefaultValue: "",
+	Name:         "file",
+	ShortHand:    "f",
+	Usage:        "read image URIs from a file, one per line, or from standard input with '-'",
+}
+
+// -j|--jobs
+var prefetchJobsFlag = cmdline.Flag{
+	ID:           "prefetchJobsFlag",
+	Value:        &prefetchJobs,
+	DefaultValue: 2,
+	Name:         "jobs",
+	ShortHand:    "j",
+	Usage:        "number of images to pull concurrently",
+	EnvKeys:      []string{"PREFETCH_JOBS"},
+}
+
+// --report
+var prefetchReportFlag = cmdline.Flag{
+	ID:           "prefetchReportFlag",
+	Value:        &prefetchReport,
+	DefaultValue: "",
+	Name:         "report",
+	Usage:        "write the JSON report to a file instead of standard output",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(PrefetchCmd)
+
+		cmdManager.RegisterFlagForCmd(&prefetchFileFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&prefetchJobsFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&prefetchReportFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonTmpDirFlag, PrefetchCmd)
+
+		cmdManager.RegisterFlagForCmd(&dockerHostFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&dockerPasswordFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonHTTPHeaderFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonHTTPTokenFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonLockfileFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonLockedFlag, PrefetchCmd)
+		cmdManager.RegisterFlagForCmd(&commonRequireDigestFlag, PrefetchCmd)
+	})
+}
+
+// PrefetchCmd apptainer prefetch
+var PrefetchCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ArbitraryArgs,
+	Run:                   prefetchRun,
+	Use:                   docs.PrefetchUse,
+	Short:                 docs.PrefetchShort,
+	Long:                  docs.PrefetchLong,
+	Example:               docs.PrefetchExample,
+}
+
+// prefetchResult is the outcome of the prefetch of an image.
+type prefetchResult struct {
+	URI      string  `json:"uri"`
+	Status   string  `json:"status"`
+	Path     string  `json:"path,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"`
+}
+
+// prefetchReportData is the machine-readable report of a prefetch.
+type prefetchReportData struct {
+	Images    []prefetchResult `json:"images"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
+
+// readPrefetchList returns the image URIs listed in r, ignoring empty lines
+// and comments.
+func readPrefetchList(r io.Reader) ([]string, error) {
+	var uris []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		uris = append(uris, line)
+	}
+	return uris, scanner.Err()
+}
+
+func prefetchRun(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+
+	uris := args
+	if prefetchFile != "" {
+		var r io.Reader = os.Stdin
+		if prefetchFile != "-" {
+			f, err := os.Open(prefetchFile)
+			if err != nil {
+				sylog.Fatalf("While opening image list: %v", err)
+			}
+			defer f.Close()
+			r = f
+		}
+		list, err := readPrefetchList(r)
+		if err != nil {
+			sylog.Fatalf("While reading image list: %v", err)
+		}
+		uris = append(uris, list...)
+	}
+	if len(uris) == 0 {
+		sylog.Fatalf("No image URI supplied")
+	}
+	if prefetchJobs < 1 {
+		sylog.Fatalf("Invalid number of jobs (%d)", prefetchJobs)
+	}
+
+	// Check all references before pulling anything, and drop duplicates
+	// so that the same image is not pulled concurrently.
+	seen := make(map[string]bool)
+	var images []string
+	for _, u := range uris {
+		t, ref := uri.Split(u)
+		if t == "" || t == "instance" || ref == "" {
+			sylog.Fatalf("Bad URI %s", u)
+		}
+		if !seen[u] {
+			seen[u] = true
+			images = append(images, u)
+		}
+	}
+
+	imgCache := getCacheHandle(cache.Config{})
+	if imgCache.IsDisabled() {
+		sylog.Fatalf("The cache is disabled, images can't be prefetched")
+	}
+
+	report := prefetchReportData{Images: make([]prefetchResult, len(images))}
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for i := 0; i < prefetchJobs && i < len(images); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				start := time.Now()
+				res := prefetchResult{URI: images[idx], Status: "ok"}
+				var path string
+				err := checkRegistryPolicy(images[idx])
+				if err == nil {
+					err = checkImageDigest(images[idx])
+				}
+				if err == nil {
+					path, err = pullToCache(ctx, imgCache, cmd, images[idx])
+				}
+				if err != nil {
+					sylog.Errorf("While prefetching %s: %v", images[idx], err)
+					res.Status = "failed"
+					res.Error = err.Error()
+				} else {
+					sylog.Infof("Prefetched %s", images[idx])
+					res.Path = path
+				}
+				res.Duration = time.Since(start).Seconds()
+				report.Images[idx] = res
+			}
+		}()
+	}
+	for idx := range images {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, res := range report.Images {
+		if res.Status == "ok" {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	if err := writePrefetchReport(report); err != nil {
+		sylog.Fatalf("While writing report: %v", err)
+	}
+
+	if report.Failed > 0 {
+		sylog.Fatalf("Failed to prefetch %d of %d images", report.Failed, len(images))
+	}
+}
+
+// writePrefetchReport writes the JSON report to standard output, or to the
+// file set with --report.
+func writePrefetchReport(report prefetchReportData) error {
+	out := os.Stdout
+	if prefetchReport != "" {
+		f, err := os.Create(prefetchReport)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
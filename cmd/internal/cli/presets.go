@@ -0,0 +1,148 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"mvdan.cc/sh/v3/shell"
+)
+
+// presetDirective is the directive defining a flag preset in apptainer.conf
+// and in the user presets file.
+const presetDirective = "preset"
+
+// loadPresets returns the flag presets defined by the preset directives of
+// the configuration, overridden by those of the user presets file at
+// userPath.
+func loadPresets(userPath string) (map[string][]string, error) {
+	presets := make(map[string][]string)
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		if err := parsePresets(presets, cfg.Presets); err != nil {
+			return nil, fmt.Errorf("in configuration file: %w", err)
+		}
+	}
+
+	f, err := os.Open(userPath)
+	if os.IsNotExist(err) {
+		return presets, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	directives, err := apptainerconf.GetDirectives(f)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing %s: %w", userPath, err)
+	}
+	if err := parsePresets(presets, directives[presetDirective]); err != nil {
+		return nil, fmt.Errorf("in %s: %w", userPath, err)
+	}
+	return presets, nil
+}
+
+// parsePresets adds to presets the presets defined by the values of preset
+// directives, a name followed by flags split into words as a shell would.
+func parsePresets(presets map[string][]string, values []string) error {
+	for _, v := range values {
+		name, flags, _ := strings.Cut(strings.TrimSpace(v), " ")
+		args, err := shell.Fields(flags, nil)
+		if err != nil {
+			return fmt.Errorf("invalid preset %q: %w", name, err)
+		}
+		presets[name] = args
+	}
+	return nil
+}
+
+// presetValue records the values set for a flag by a preset.
+type presetValue struct {
+	flag *pflag.Flag
+	set  *[]presetFlag
+}
+
+type presetFlag struct {
+	flag  *pflag.Flag
+	value string
+}
+
+func (v presetValue) String() string { return "" }
+func (v presetValue) Type() string   { return v.flag.Value.Type() }
+
+func (v presetValue) Set(s string) error {
+	*v.set = append(*v.set, presetFlag{flag: v.flag, value: s})
+	return nil
+}
+
+// presetFlags parses args, the flags of a preset, against the flags of cmd,
+// and returns the flags they set with their values, in order.
+func presetFlags(cmd *cobra.Command, args []string) ([]presetFlag, error) {
+	var set []presetFlag
+	fs := pflag.NewFlagSet("preset", pflag.ContinueOnError)
+	fs.SetOutput(&strings.Builder{})
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		pf := fs.VarPF(presetValue{flag: f, set: &set}, f.Name, f.Shorthand, f.Usage)
+		pf.NoOptDefVal = f.NoOptDefVal
+	})
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return set, nil
+}
+
+// applyPresets sets the flags of cmd from the presets named names, in order.
+// The flags set on the command line or by environment variables take
+// precedence, except the flags holding multiple values to which the preset
+// values are added.
+func applyPresets(cmd *cobra.Command, names []string, presets map[string][]string) error {
+	changed := make(map[string]bool)
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		changed[f.Name] = true
+	})
+
+	for _, name := range names {
+		args, ok := presets[name]
+		if !ok {
+			return fmt.Errorf("preset %q is not defined", name)
+		}
+		set, err := presetFlags(cmd, args)
+		if err != nil {
+			return fmt.Errorf("while applying preset %q: %w", name, err)
+		}
+		for _, pf := range set {
+			f := pf.flag
+			if f.Name == actionPresetFlag.Name {
+				return fmt.Errorf("preset %q can't use --%s", name, f.Name)
+			}
+			if changed[f.Name] && !multiValued(f) {
+				sylog.Debugf("Ignoring --%s of preset %s: set on the command line", f.Name, name)
+				continue
+			}
+			if err := f.Value.Set(pf.value); err != nil {
+				return fmt.Errorf("while applying preset %q: invalid value %q for --%s: %w", name, pf.value, f.Name, err)
+			}
+			f.Changed = true
+			sylog.Debugf("Preset %s set --%s to %s", name, f.Name, pf.value)
+		}
+	}
+	return nil
+}
+
+// multiValued returns whether the flag f accumulates the values it is set
+// to, like --bind or --env.
+func multiValued(f *pflag.Flag) bool {
+	t := f.Value.Type()
+	return strings.HasSuffix(t, "Slice") || strings.HasSuffix(t, "Array") || t == "stringToString"
+}
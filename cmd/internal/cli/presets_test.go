@@ -0,0 +1,63 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApplyPresets(t *testing.T) {
+	presets := make(map[string][]string)
+	err := parsePresets(presets, []string{
+		"gpu --nv --bind '/scratch,/data' --pwd /scratch",
+		"tmp --writable-tmpfs -B /tmp",
+		"bad --pwd /a extra",
+		"loop --preset gpu",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(presets["tmp"], []string{"--writable-tmpfs", "-B", "/tmp"}) {
+		t.Errorf("got preset %q", presets["tmp"])
+	}
+
+	var nv, writableTmpfs bool
+	var binds, names []string
+	var pwd string
+	newCmd := func(args ...string) *cobra.Command {
+		nv, writableTmpfs, binds, names, pwd = false, false, nil, nil, ""
+		cmd := &cobra.Command{}
+		cmd.Flags().BoolVar(&nv, "nv", false, "")
+		cmd.Flags().BoolVar(&writableTmpfs, "writable-tmpfs", false, "")
+		cmd.Flags().StringSliceVarP(&binds, "bind", "B", nil, "")
+		cmd.Flags().StringVar(&pwd, "pwd", "", "")
+		cmd.Flags().StringSliceVar(&names, "preset", nil, "")
+		if err := cmd.Flags().Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	cmd := newCmd("--pwd", "/home", "--bind", "/opt")
+	if err := applyPresets(cmd, []string{"gpu", "tmp"}, presets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !nv || !writableTmpfs || pwd != "/home" {
+		t.Errorf("got nv=%v writable-tmpfs=%v pwd=%q", nv, writableTmpfs, pwd)
+	}
+	if expected := []string{"/opt", "/scratch", "/data", "/tmp"}; !reflect.DeepEqual(binds, expected) {
+		t.Errorf("got binds %q, expected %q", binds, expected)
+	}
+
+	for _, name := range []string{"bad", "loop", "missing"} {
+		if err := applyPresets(newCmd(), []string{name}, presets); err == nil {
+			t.Errorf("unexpected success applying preset %s", name)
+		}
+	}
+}
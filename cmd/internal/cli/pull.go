@@ -11,20 +11,25 @@
 package cli
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/apptainer/apptainer/docs"
 	build_oci "github.com/apptainer/apptainer/internal/pkg/build/oci"
 	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client/ipfs"
 	"github.com/apptainer/apptainer/internal/pkg/client/library"
 	"github.com/apptainer/apptainer/internal/pkg/client/net"
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
 	"github.com/apptainer/apptainer/internal/pkg/client/oci"
 	"github.com/apptainer/apptainer/internal/pkg/client/oras"
 	"github.com/apptainer/apptainer/internal/pkg/client/shub"
 	"github.com/apptainer/apptainer/internal/pkg/ociimage"
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/internal/pkg/util/uri"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -42,6 +47,16 @@ const (
 	HTTPSProtocol = "https"
 	// OrasProtocol holds the oras URI.
 	OrasProtocol = "oras"
+	// DockerProtocol holds the docker registry URI.
+	DockerProtocol = "docker"
+	// S3Protocol holds the s3 object storage URI.
+	S3Protocol = "s3"
+	// GSProtocol holds the Google Cloud Storage URI.
+	GSProtocol = "gs"
+	// AzBlobProtocol holds the Azure Blob Storage URI.
+	AzBlobProtocol = "azblob"
+	// IPFSProtocol holds the content-addressed IPFS URI.
+	IPFSProtocol = "ipfs"
 )
 
 var (
@@ -60,6 +75,12 @@ var (
 	pullArchVariant string
 	// pullSandbox indicates whether pulling images as sandbox format
 	pullSandbox bool
+	// pullDownloadConcurrency is the number of concurrent library download requests
+	pullDownloadConcurrency int
+	// pullDigest is the expected sha256 digest of an http(s) image
+	pullDigest string
+	// pullCheckUpdate only pulls the image again when the remote image changed
+	pullCheckUpdate bool
 )
 
 // --arch
@@ -82,6 +103,34 @@ var pullArchVariantFlag = cmdline.Flag{
 	EnvKeys:      []string{"PULL_ARCH_VARIANT"},
 }
 
+// --download-concurrency
+var pullDownloadConcurrencyFlag = cmdline.Flag{
+	ID:           "pullDownloadConcurrencyFlag",
+	Value:        &pullDownloadConcurrency,
+	DefaultValue: 0,
+	Name:         "download-concurrency",
+	Usage:        "number of concurrent requests used to download library images (default from apptainer.conf)",
+}
+
+// --digest
+var pullDigestFlag = cmdline.Flag{
+	ID:           "pullDigestFlag",
+	Value:        &pullDigest,
+	DefaultValue: "",
+	Name:         "digest",
+	Usage:        "expected sha256 digest of an http(s) image, the pull fails if the downloaded content does not match",
+}
+
+// --check-update
+var pullCheckUpdateFlag = cmdline.Flag{
+	ID:           "pullCheckUpdateFlag",
+	Value:        &pullCheckUpdate,
+	DefaultValue: false,
+	Name:         "check-update",
+	Usage:        "pull a docker:// or oras:// image again only if it changed in the registry since it was pulled",
+	EnvKeys:      []string{"PULL_CHECK_UPDATE"},
+}
+
 // --library
 var pullLibraryURIFlag = cmdline.Flag{
 	ID:           "pullLibraryURIFlag",
@@ -164,6 +213,9 @@ func init() {
 
 		cmdManager.RegisterFlagForCmd(&commonForceFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&pullLibraryURIFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&pullDownloadConcurrencyFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&pullDigestFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&pullCheckUpdateFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&pullNameFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&commonTmpDirFlag, PullCmd)
@@ -181,6 +233,11 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&pullArchFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&pullArchVariantFlag, PullCmd)
 		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&commonHTTPHeaderFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&commonHTTPTokenFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&commonLockfileFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&commonLockedFlag, PullCmd)
+		cmdManager.RegisterFlagForCmd(&commonRequireDigestFlag, PullCmd)
 
 		cmdManager.RegisterFlagForCmd(&pullSandboxFlag, PullCmd)
 	})
@@ -207,10 +264,18 @@ func pullRun(cmd *cobra.Command, args []string) {
 
 	pullFrom := args[len(args)-1]
 	transport, ref := uri.Split(pullFrom)
+	useTransportTmpDir(cmd, transport)
 	if ref == "" {
 		sylog.Fatalf("Bad URI %s", pullFrom)
 	}
 
+	enforceRegistryPolicy(pullFrom)
+	enforceImageDigest(pullFrom)
+
+	if pullDigest != "" && transport != HTTPProtocol && transport != HTTPSProtocol {
+		sylog.Fatalf("The --digest option is only supported for http(s) images")
+	}
+
 	pullTo := pullImageName
 	if pullTo == "" {
 		pullTo = args[0]
@@ -226,6 +291,16 @@ func pullRun(cmd *cobra.Command, args []string) {
 		pullTo = filepath.Join(pullDir, pullTo)
 	}
 
+	refresh := false
+	if pullCheckUpdate {
+		upToDate, exists := checkPullUpdate(cmd, imgCache, transport, pullFrom, pullTo)
+		if upToDate {
+			return
+		}
+		refresh = exists
+		forceOverwrite = true
+	}
+
 	_, err := os.Stat(pullTo)
 	if !os.IsNotExist(err) {
 		// image already exists
@@ -234,6 +309,11 @@ func pullRun(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	pullFrom, err = lockReference(ctx, cmd, pullFrom)
+	if err != nil {
+		sylog.Fatalf("%v", err)
+	}
+
 	switch transport {
 	case LibraryProtocol:
 		ref, err := library.NormalizeLibraryRef(pullFrom)
@@ -245,6 +325,25 @@ func pullRun(cmd *cobra.Command, args []string) {
 			sylog.Fatalf("Conflicting arguments; do not use --library with a library URI containing host name")
 		}
 
+		if pullLibraryURI == "" {
+			orasURI, err := getLibraryOrasURI(ref)
+			if err != nil {
+				sylog.Fatalf("Unable to resolve library reference: %v", err)
+			}
+			if orasURI != "" {
+				enforceRegistryPolicy(orasURI)
+				pullOras(cmd, imgCache, pullTo, orasURI)
+				return
+			}
+		}
+
+		if cmd.Flag(pullDownloadConcurrencyFlag.Name).Changed {
+			if pullDownloadConcurrency < 1 {
+				sylog.Fatalf("Invalid download concurrency value (%d)", pullDownloadConcurrency)
+			}
+			library.SetDownloadConcurrency(pullDownloadConcurrency)
+		}
+
 		var libraryURI string
 		if pullLibraryURI != "" {
 			libraryURI = pullLibraryURI
@@ -279,17 +378,31 @@ func pullRun(cmd *cobra.Command, args []string) {
 			sylog.Fatalf("While pulling shub image: %v\n", err)
 		}
 	case OrasProtocol:
-		ociAuth, err := makeOCICredentials(cmd)
+		pullOras(cmd, imgCache, pullTo, pullFrom)
+	case S3Protocol, GSProtocol, AzBlobProtocol:
+		b, err := getObjectBackend(transport)
 		if err != nil {
-			sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+			sylog.Fatalf("%v", err)
 		}
-
-		_, err = oras.PullToFile(ctx, imgCache, pullTo, pullFrom, ociAuth, noHTTPS, reqAuthFile, pullSandbox)
+		_, err = objstore.PullToFile(ctx, b, imgCache, pullTo, pullFrom, pullSandbox)
 		if err != nil {
-			sylog.Fatalf("While pulling image from oci registry: %v", err)
+			sylog.Fatalf("While pulling image from %s: %v\n", transport, err)
+		}
+	case IPFSProtocol:
+		_, err := ipfs.PullToFile(ctx, imgCache, pullTo, pullFrom, pullSandbox)
+		if err != nil {
+			sylog.Fatalf("While pulling image from ipfs: %v\n", err)
 		}
 	case HTTPProtocol, HTTPSProtocol:
-		_, err := net.PullToFile(ctx, imgCache, pullTo, pullFrom, pullSandbox)
+		headers, err := net.ParseHeaders(httpHeaders, httpToken)
+		if err != nil {
+			sylog.Fatalf("While parsing http headers: %v", err)
+		}
+		opts := net.PullOptions{
+			Digest:  pullDigest,
+			Headers: headers,
+		}
+		_, err = net.PullToFile(ctx, imgCache, pullTo, pullFrom, pullSandbox, opts)
 		if err != nil {
 			sylog.Fatalf("While pulling from image from http(s): %v\n", err)
 		}
@@ -321,6 +434,85 @@ func pullRun(cmd *cobra.Command, args []string) {
 	case "":
 		sylog.Fatalf("No transport type URI supplied")
 	default:
-		sylog.Fatalf("Unsupported transport type: %s", transport)
+		if pullSandbox {
+			sylog.Fatalf("Pulling a sandbox is not supported for %s images", transport)
+		}
+		image, err := handlePluginTransport(ctx, imgCache, transport, pullFrom)
+		if errors.Is(err, errUnsupportedTransport) {
+			sylog.Fatalf("Unsupported transport type: %s", transport)
+		} else if err != nil {
+			sylog.Fatalf("%v", err)
+		}
+		if err := fs.CopyFileAtomic(image, pullTo, 0o755); err != nil {
+			sylog.Fatalf("While copying %s to %s: %v", image, pullTo, err)
+		}
+	}
+
+	if refresh {
+		sylog.Infof("Refreshed %s from %s", pullTo, pullFrom)
+	}
+}
+
+// checkPullUpdate returns whether the image at pullTo is up to date with the
+// docker:// or oras:// image at pullFrom, comparing the digest the image
+// resolves to with the digest recorded in the cache when it was pulled. It
+// also returns whether pullTo exists.
+func checkPullUpdate(cmd *cobra.Command, imgCache *cache.Handle, transport, pullFrom, pullTo string) (upToDate, exists bool) {
+	if transport != DockerProtocol && transport != OrasProtocol {
+		sylog.Fatalf("--check-update is only supported for docker:// and oras:// images")
+	}
+	if lockfilePath != "" || lockedMode {
+		sylog.Fatalf("--check-update can't be used with --lockfile or --locked")
+	}
+	if imgCache.IsDisabled() {
+		sylog.Fatalf("--check-update requires the cache, where the digests of pulled images are recorded")
+	}
+
+	if _, err := os.Stat(pullTo); os.IsNotExist(err) {
+		return false, false
+	}
+	path, err := filepath.Abs(pullTo)
+	if err != nil {
+		sylog.Fatalf("While getting absolute path of %s: %v", pullTo, err)
+	}
+
+	arch := ""
+	if transport == DockerProtocol {
+		arch, err = build_oci.ConvertArch(pullArch, pullArchVariant)
+		if err != nil {
+			sylog.Fatalf("While processing the arch and arch variant: %v", err)
+		}
+	}
+	record, err := imgCache.GetImageRecord(pullFrom, path, arch)
+	if err != nil {
+		sylog.Fatalf("While reading image record: %v", err)
+	}
+	if record == nil {
+		sylog.Infof("No digest recorded for %s, pulling it again", pullTo)
+		return false, true
+	}
+
+	digest, err := remoteDigest(cmd, pullFrom, arch)
+	if err != nil {
+		sylog.Fatalf("While checking for an update of %s: %v", pullFrom, err)
+	}
+	if digest == record.Digest {
+		sylog.Infof("%s is up to date with %s", pullTo, pullFrom)
+		return true, true
+	}
+	sylog.Infof("%s has changed since %s was pulled on %s", pullFrom, pullTo, record.Pulled.Local().Format(time.RFC1123))
+	return false, true
+}
+
+// pullOras pulls the image at the oras:// URI pullFrom to pullTo.
+func pullOras(cmd *cobra.Command, imgCache *cache.Handle, pullTo, pullFrom string) {
+	ociAuth, err := makeOCICredentials(cmd)
+	if err != nil {
+		sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+	}
+
+	_, err = oras.PullToFile(cmd.Context(), imgCache, pullTo, pullFrom, ociAuth, noHTTPS, reqAuthFile, pullSandbox)
+	if err != nil {
+		sylog.Fatalf("While pulling image from oci registry: %v", err)
 	}
 }
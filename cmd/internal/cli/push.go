@@ -16,6 +16,7 @@ import (
 
 	"github.com/apptainer/apptainer/docs"
 	"github.com/apptainer/apptainer/internal/pkg/client/library"
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
 	"github.com/apptainer/apptainer/internal/pkg/client/oras"
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
 	"github.com/apptainer/apptainer/internal/pkg/signature"
@@ -23,6 +24,7 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/util/uri"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
+	libClient "github.com/apptainer/container-library-client/client"
 	"github.com/spf13/cobra"
 )
 
@@ -35,6 +37,18 @@ var (
 
 	// pushDescription holds a description to be set against a library container
 	pushDescription string
+
+	// pushArtifactType holds the artifactType of the manifest pushed to an OCI registry
+	pushArtifactType string
+
+	// pushConfigMediaType holds the config media type of the manifest pushed to an OCI registry
+	pushConfigMediaType string
+
+	// pushAnnotations holds the annotations of the manifest pushed to an OCI registry
+	pushAnnotations map[string]string
+
+	// pushAttach holds the artifacts attached as referrers of the manifest pushed to an OCI registry
+	pushAttach []string
 )
 
 // --library
@@ -68,6 +82,44 @@ var pushDescriptionFlag = cmdline.Flag{
 	Usage:        "description for container image (library:// only)",
 }
 
+// --artifact-type
+var pushArtifactTypeFlag = cmdline.Flag{
+	ID:           "pushArtifactTypeFlag",
+	Value:        &pushArtifactType,
+	DefaultValue: "",
+	Name:         "artifact-type",
+	Usage:        "artifactType of the pushed manifest (oras:// only)",
+	EnvKeys:      []string{"PUSH_ARTIFACT_TYPE"},
+}
+
+// --config-media-type
+var pushConfigMediaTypeFlag = cmdline.Flag{
+	ID:           "pushConfigMediaTypeFlag",
+	Value:        &pushConfigMediaType,
+	DefaultValue: "",
+	Name:         "config-media-type",
+	Usage:        "media type of the config of the pushed manifest (oras:// only)",
+	EnvKeys:      []string{"PUSH_CONFIG_MEDIA_TYPE"},
+}
+
+// --annotation
+var pushAnnotationFlag = cmdline.Flag{
+	ID:           "pushAnnotationFlag",
+	Value:        &pushAnnotations,
+	DefaultValue: map[string]string{},
+	Name:         "annotation",
+	Usage:        "add an annotation to the pushed manifest, in the form key=value, overriding the org.opencontainers.image.* labels of the image set by default (oras:// only)",
+}
+
+// --attach
+var pushAttachFlag = cmdline.Flag{
+	ID:           "pushAttachFlag",
+	Value:        &pushAttach,
+	DefaultValue: cmdline.StringArray{},
+	Name:         "attach",
+	Usage:        "attach an artifact as a referrer of the pushed manifest, in the form type=path, where type is sbom, cyclonedx, signature, provenance or an artifact type (oras:// only)",
+}
+
 func init() {
 	addCmdInit(func(cmdManager *cmdline.CommandManager) {
 		cmdManager.RegisterCmd(PushCmd)
@@ -75,6 +127,10 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&pushLibraryURIFlag, PushCmd)
 		cmdManager.RegisterFlagForCmd(&pushAllowUnsignedFlag, PushCmd)
 		cmdManager.RegisterFlagForCmd(&pushDescriptionFlag, PushCmd)
+		cmdManager.RegisterFlagForCmd(&pushArtifactTypeFlag, PushCmd)
+		cmdManager.RegisterFlagForCmd(&pushConfigMediaTypeFlag, PushCmd)
+		cmdManager.RegisterFlagForCmd(&pushAnnotationFlag, PushCmd)
+		cmdManager.RegisterFlagForCmd(&pushAttachFlag, PushCmd)
 		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, PushCmd)
 
 		cmdManager.RegisterFlagForCmd(&dockerHostFlag, PushCmd)
@@ -106,14 +162,25 @@ var PushCmd = &cobra.Command{
 				sylog.Fatalf("Conflicting arguments; do not use --library with a library URI containing host name")
 			}
 
-			lc, err := getLibraryClientConfig(PushLibraryURI)
-			if err != nil {
-				sylog.Fatalf("Unable to get library client configuration: %v", err)
+			var orasURI string
+			if PushLibraryURI == "" {
+				orasURI, err = getLibraryOrasURI(destRef)
+				if err != nil {
+					sylog.Fatalf("Unable to resolve library reference: %v", err)
+				}
 			}
 
-			// Push to library requires a valid authToken
-			if lc.AuthToken == "" {
-				sylog.Fatalf("Cannot push image to library: %v", remoteWarning)
+			var lc *libClient.Config
+			if orasURI == "" {
+				lc, err = getLibraryClientConfig(PushLibraryURI)
+				if err != nil {
+					sylog.Fatalf("Unable to get library client configuration: %v", err)
+				}
+
+				// Push to library requires a valid authToken
+				if lc.AuthToken == "" {
+					sylog.Fatalf("Cannot push image to library: %v", remoteWarning)
+				}
 			}
 
 			if unsignedPush {
@@ -131,6 +198,25 @@ var PushCmd = &cobra.Command{
 				}
 			}
 
+			// The remote endpoint serves library references from an OCI
+			// registry, push the image there with ORAS.
+			if orasURI != "" {
+				if cmd.Flag(pushDescriptionFlag.Name).Changed {
+					sylog.Warningf("Description is not supported for push to a library registry. Ignoring it.")
+				}
+				enforceRegistryPolicy(orasURI)
+				ociAuth, err := makeOCICredentials(cmd)
+				if err != nil {
+					sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+				}
+				if err := oras.UploadImage(cmd.Context(), file, strings.TrimPrefix(orasURI, OrasProtocol+":"), ociAuth, noHTTPS, reqAuthFile, orasImageOpts()...); err != nil {
+					sylog.Fatalf("Unable to push image to library registry: %v", err)
+				}
+				sylog.Infof("Upload complete")
+				return
+			}
+
+			warnORASFlags(cmd, "library")
 			resp, err := library.Push(cmd.Context(), file, destRef, pushDescription, lc)
 			if err != nil {
 				sylog.Fatalf("Unable to push image to library: %v", err)
@@ -168,10 +254,23 @@ var PushCmd = &cobra.Command{
 				sylog.Fatalf("Unable to make docker oci credentials: %s", err)
 			}
 
-			if err := oras.UploadImage(cmd.Context(), file, ref, ociAuth, noHTTPS, reqAuthFile); err != nil {
+			if err := oras.UploadImage(cmd.Context(), file, ref, ociAuth, noHTTPS, reqAuthFile, orasImageOpts()...); err != nil {
 				sylog.Fatalf("Unable to push image to oci registry: %v", err)
 			}
 			sylog.Infof("Upload complete")
+		case S3Protocol, GSProtocol, AzBlobProtocol:
+			if cmd.Flag(pushDescriptionFlag.Name).Changed {
+				sylog.Warningf("Description is not supported for push to %s. Ignoring it.", transport)
+			}
+			warnORASFlags(cmd, transport)
+			b, err := getObjectBackend(transport)
+			if err != nil {
+				sylog.Fatalf("%v", err)
+			}
+			if err := objstore.Push(cmd.Context(), b, file, dest); err != nil {
+				sylog.Fatalf("Unable to push image to %s: %v", transport, err)
+			}
+			sylog.Infof("Upload complete")
 		case "":
 			sylog.Fatalf("Transport type URI required but not supplied")
 		default:
@@ -184,3 +283,31 @@ var PushCmd = &cobra.Command{
 	Long:    docs.PushLong,
 	Example: docs.PushExample,
 }
+
+// orasImageOpts returns the options setting the manifest pushed to an OCI
+// registry, and the artifacts attached to it, from the command line.
+func orasImageOpts() []oras.ImageOpt {
+	opts := []oras.ImageOpt{
+		oras.OptImageArtifactType(pushArtifactType),
+		oras.OptImageConfigMediaType(pushConfigMediaType),
+		oras.OptImageAnnotations(pushAnnotations),
+	}
+	for _, a := range pushAttach {
+		r, err := oras.ParseReferrer(a)
+		if err != nil {
+			sylog.Fatalf("Unable to attach artifact: %v", err)
+		}
+		opts = append(opts, oras.OptImageReferrers(r))
+	}
+	return opts
+}
+
+// warnORASFlags warns about the flags setting the manifest pushed to an OCI
+// registry, when pushing to another transport.
+func warnORASFlags(cmd *cobra.Command, transport string) {
+	for _, f := range []*cmdline.Flag{&pushArtifactTypeFlag, &pushConfigMediaTypeFlag, &pushAnnotationFlag, &pushAttachFlag} {
+		if cmd.Flag(f.Name).Changed {
+			sylog.Warningf("--%s is not supported for push to %s. Ignoring it.", f.Name, transport)
+		}
+	}
+}
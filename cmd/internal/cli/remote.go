@@ -31,19 +31,23 @@ const (
 )
 
 var (
-	loginTokenFile          string
-	loginUsername           string
-	loginPassword           string
-	remoteConfig            string
-	remoteKeyserverOrder    uint32
-	remoteKeyserverInsecure bool
-	loginPasswordStdin      bool
-	loginInsecure           bool
-	remoteNoLogin           bool
-	global                  bool
-	remoteUseExclusive      bool
-	remoteAddInsecure       bool
-	remoteAddNotDefault     bool
+	loginTokenFile           string
+	loginUsername            string
+	loginPassword            string
+	remoteConfig             string
+	remoteKeyserverOrder     uint32
+	remoteKeyserverInsecure  bool
+	loginPasswordStdin       bool
+	loginInsecure            bool
+	remoteNoLogin            bool
+	global                   bool
+	remoteUseExclusive       bool
+	remoteAddInsecure        bool
+	remoteAddNotDefault      bool
+	remoteAddLibraryRegistry string
+	loginOIDC                bool
+	loginOIDCIssuer          string
+	loginOIDCClientID        string
 )
 
 // assemble values of remoteConfig for user/sys locations
@@ -129,6 +133,35 @@ var remoteLoginInsecureFlag = cmdline.Flag{
 	EnvKeys:      []string{"LOGIN_INSECURE"},
 }
 
+// --oidc
+var loginOIDCFlag = cmdline.Flag{
+	ID:           "loginOIDCFlag",
+	Value:        &loginOIDC,
+	DefaultValue: false,
+	Name:         "oidc",
+	Usage:        "log in with single sign-on, using the OAuth2 device flow of an OIDC provider",
+}
+
+// --oidc-issuer
+var loginOIDCIssuerFlag = cmdline.Flag{
+	ID:           "loginOIDCIssuerFlag",
+	Value:        &loginOIDCIssuer,
+	DefaultValue: "",
+	Name:         "oidc-issuer",
+	Usage:        "URL of the OIDC provider used with --oidc (default: the provider advertised by the remote)",
+	EnvKeys:      []string{"OIDC_ISSUER"},
+}
+
+// --oidc-client-id
+var loginOIDCClientIDFlag = cmdline.Flag{
+	ID:           "loginOIDCClientIDFlag",
+	Value:        &loginOIDCClientID,
+	DefaultValue: "",
+	Name:         "oidc-client-id",
+	Usage:        "client ID registered with the OIDC provider used with --oidc (default: the client advertised by the remote)",
+	EnvKeys:      []string{"OIDC_CLIENT_ID"},
+}
+
 // -e|--exclusive
 var remoteUseExclusiveFlag = cmdline.Flag{
 	ID:           "remoteUseExclusiveFlag",
@@ -180,6 +213,15 @@ var remoteAddNotDefaultFlag = cmdline.Flag{
 	Usage:        "do not designate the newly-added remote endpoint as the default",
 }
 
+// --library-registry
+var remoteAddLibraryRegistryFlag = cmdline.Flag{
+	ID:           "remoteAddLibraryRegistryFlag",
+	Value:        &remoteAddLibraryRegistry,
+	DefaultValue: "",
+	Name:         "library-registry",
+	Usage:        "OCI registry used to serve library:// references for this remote (e.g. registry.example.com/library)",
+}
+
 func init() {
 	addCmdInit(func(cmdManager *cmdline.CommandManager) {
 		cmdManager.RegisterCmd(RemoteCmd)
@@ -205,11 +247,15 @@ func init() {
 		// add --insecure, --no-login flags to add command
 		cmdManager.RegisterFlagForCmd(&remoteAddInsecureFlag, RemoteAddCmd)
 		cmdManager.RegisterFlagForCmd(&remoteAddNotDefaultFlag, RemoteAddCmd)
+		cmdManager.RegisterFlagForCmd(&remoteAddLibraryRegistryFlag, RemoteAddCmd)
 
 		cmdManager.RegisterFlagForCmd(&remoteLoginUsernameFlag, RemoteLoginCmd)
 		cmdManager.RegisterFlagForCmd(&remoteLoginPasswordFlag, RemoteLoginCmd)
 		cmdManager.RegisterFlagForCmd(&remoteLoginPasswordStdinFlag, RemoteLoginCmd)
 		cmdManager.RegisterFlagForCmd(&remoteLoginInsecureFlag, RemoteLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCFlag, RemoteLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCIssuerFlag, RemoteLoginCmd)
+		cmdManager.RegisterFlagForCmd(&loginOIDCClientIDFlag, RemoteLoginCmd)
 
 		cmdManager.RegisterFlagForCmd(&remoteUseExclusiveFlag, RemoteUseCmd)
 
@@ -292,7 +338,7 @@ var RemoteAddCmd = &cobra.Command{
 		}
 
 		makeDefault := !remoteAddNotDefault
-		if err := apptainer.RemoteAdd(remoteConfig, name, uri, global, localInsecure, makeDefault); err != nil {
+		if err := apptainer.RemoteAdd(remoteConfig, name, uri, global, localInsecure, makeDefault, remoteAddLibraryRegistry); err != nil {
 			sylog.Fatalf("%s", err)
 		}
 		sylog.Infof("Remote %q added.", name)
@@ -394,6 +440,9 @@ var RemoteLoginCmd = &cobra.Command{
 		loginArgs.Tokenfile = loginTokenFile
 		loginArgs.Insecure = loginInsecure
 		loginArgs.ReqAuthFile = reqAuthFile
+		loginArgs.OIDC = loginOIDC
+		loginArgs.OIDCIssuer = loginOIDCIssuer
+		loginArgs.OIDCClientID = loginOIDCClientID
 
 		if loginPasswordStdin {
 			p, err := io.ReadAll(os.Stdin)
@@ -0,0 +1,180 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/apptainer/apptainer/internal/pkg/runner"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/internal/pkg/util/ociauth"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// runnerLocalFlags are the flags not passed to the commands run on a
+// runner, the credentials being forwarded by runnerCredentials instead.
+var runnerLocalFlags = map[string]bool{
+	"runner":          true,
+	"preset":          true,
+	"authfile":        true,
+	"docker-login":    true,
+	"docker-username": true,
+	"docker-password": true,
+}
+
+// runnerFlags returns the flags set for cmd, to be passed to the command run
+// on a runner, except the local ones and the ones in skip.
+func runnerFlags(cmd *cobra.Command, skip ...string) []string {
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if runnerLocalFlags[f.Name] || slices.Contains(skip, f.Name) {
+			return
+		}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range s.GetSlice() {
+				flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, v))
+			}
+			return
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+	})
+	return flags
+}
+
+// runnerArgs returns the arguments of the action command cmd run with args
+// on a runner, where imagePath replaces the image argument.
+func runnerArgs(cmd *cobra.Command, args []string, imagePath string) []string {
+	remote := append([]string{cmd.Name()}, runnerFlags(cmd)...)
+	remote = append(remote, imagePath)
+	return append(remote, args[1:]...)
+}
+
+// newRunner returns the runner given by spec, with the command run on it
+// set by APPTAINER_RUNNER_COMMAND.
+func newRunner(spec string) (*runner.Runner, error) {
+	r, err := runner.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	if command := os.Getenv("APPTAINER_RUNNER_COMMAND"); command != "" {
+		r.Command = command
+	}
+	return r, nil
+}
+
+// runnerCredentials holds the registry credentials given locally, to be
+// forwarded to a runner.
+type runnerCredentials struct {
+	env      map[string]string
+	authFile []byte
+}
+
+// getRunnerCredentials returns the registry credentials given locally for
+// cmd, from the docker flags and the auth file.
+func getRunnerCredentials(cmd *cobra.Command) (*runnerCredentials, error) {
+	c := &runnerCredentials{env: make(map[string]string)}
+	if cmd.Flags().Lookup("docker-username") != nil {
+		auth, err := makeOCICredentials(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("while making docker credentials: %v", err)
+		}
+		if auth != nil {
+			c.env["APPTAINER_DOCKER_USERNAME"] = auth.Username
+			c.env["APPTAINER_DOCKER_PASSWORD"] = auth.Password
+		}
+	}
+	if authFile := ociauth.ChooseAuthFile(reqAuthFile); fs.IsFile(authFile) {
+		b, err := os.ReadFile(authFile)
+		if err != nil {
+			return nil, fmt.Errorf("while reading auth file: %v", err)
+		}
+		c.authFile = b
+	}
+	return c, nil
+}
+
+// empty returns whether there are no credentials to forward.
+func (c *runnerCredentials) empty() bool {
+	return len(c.env) == 0 && c.authFile == nil
+}
+
+// forward forwards the credentials to the runner r, in the temporary
+// directory dir on it. They are written to files readable by the user only,
+// exported to the environment of the commands run on the runner, rather than
+// passed on their command line.
+func (c *runnerCredentials) forward(ctx context.Context, r *runner.Runner, dir string) error {
+	if c.empty() {
+		return nil
+	}
+	if c.authFile != nil {
+		remoteAuthFile := path.Join(dir, "auth.json")
+		if err := r.WriteFile(ctx, remoteAuthFile, c.authFile); err != nil {
+			return err
+		}
+		c.env["APPTAINER_AUTH_FILE"] = remoteAuthFile
+	}
+	return r.SetEnv(ctx, dir, c.env)
+}
+
+// runOnRunner runs the action command cmd with args on the runner, uploading
+// the image first when it's a local file, and returns its exit code.
+func runOnRunner(cmd *cobra.Command, args []string) (int, error) {
+	r, err := newRunner(runnerSpec)
+	if err != nil {
+		return 0, err
+	}
+
+	imagePath := args[0]
+	if transport, _ := uri.Split(imagePath); transport == "" {
+		switch {
+		case fs.IsDir(imagePath):
+			return 0, fmt.Errorf("sandbox %s can't be run on a runner, build a SIF image from it first", imagePath)
+		case fs.IsFile(imagePath):
+			imagePath, err = r.Upload(cmd.Context(), imagePath)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	creds, err := getRunnerCredentials(cmd)
+	if err != nil {
+		return 0, err
+	}
+	if !creds.empty() {
+		dir, err := r.TempDir(cmd.Context())
+		if err != nil {
+			return 0, err
+		}
+		defer r.Remove(context.Background(), dir)
+		if err := creds.forward(cmd.Context(), r, dir); err != nil {
+			return 0, err
+		}
+	}
+
+	tty := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+	return r.Run(cmd.Context(), runnerArgs(cmd, args, imagePath), tty, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// runOn runs Apptainer with args on the runner r, without terminal, and
+// returns an error if it fails.
+func runOn(ctx context.Context, r *runner.Runner, args []string) error {
+	code, err := r.Run(ctx, args, false, nil, os.Stdout, os.Stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("%s %s failed on %s with exit code %d", r.Command, args[0], r.Host, code)
+	}
+	return nil
+}
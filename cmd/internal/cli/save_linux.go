@@ -0,0 +1,88 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	saveOutput string
+	saveFormat string
+	saveTag    string
+)
+
+// -o|--output
+var saveOutputFlag = cmdline.Flag{
+	ID:           "saveOutputFlag",
+	Value:        &saveOutput,
+	DefaultValue: "",
+	Name:         "output",
+	ShortHand:    "o",
+	Usage:        "path of the archive to create",
+	Required:     true,
+}
+
+// --format
+var saveFormatFlag = cmdline.Flag{
+	ID:           "saveFormatFlag",
+	Value:        &saveFormat,
+	DefaultValue: apptainer.DockerArchiveFormat,
+	Name:         "format",
+	Usage:        "archive format: docker-archive or oci-archive",
+}
+
+// --tag
+var saveTagFlag = cmdline.Flag{
+	ID:           "saveTagFlag",
+	Value:        &saveTag,
+	DefaultValue: "",
+	Name:         "tag",
+	Usage:        "image reference recorded in the archive, defaults to the image name with the latest tag",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(SaveCmd)
+
+		cmdManager.RegisterFlagForCmd(&saveOutputFlag, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&saveFormatFlag, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&saveTagFlag, SaveCmd)
+		cmdManager.RegisterFlagForCmd(&actionTmpDirFlag, SaveCmd)
+	})
+}
+
+// SaveCmd is the 'save' command that writes an image to a docker-archive or
+// oci-archive file.
+var SaveCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		co, err := imageConfigOptions()
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		err = apptainer.Save(args[0], saveOutput, apptainer.SaveOptions{
+			Format: saveFormat,
+			Tag:    saveTag,
+			TmpDir: tmpDir,
+			Config: co,
+		})
+		if err != nil {
+			sylog.Fatalf("Unable to save %s: %s", args[0], err)
+		}
+		sylog.Infof("Image saved to %s", saveOutput)
+	},
+
+	Use:     docs.SaveUse,
+	Short:   docs.SaveShort,
+	Long:    docs.SaveLong,
+	Example: docs.SaveExample,
+}
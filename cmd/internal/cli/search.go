@@ -11,10 +11,12 @@
 package cli
 
 import (
+	"fmt"
 	"runtime"
 
 	"github.com/apptainer/apptainer/docs"
 	"github.com/apptainer/apptainer/internal/pkg/client/library"
+	ociclient "github.com/apptainer/apptainer/internal/pkg/client/oci"
 	"github.com/apptainer/apptainer/internal/pkg/util/uri"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -68,6 +70,12 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&searchLibraryFlag, SearchCmd)
 		cmdManager.RegisterFlagForCmd(&searchArchFlag, SearchCmd)
 		cmdManager.RegisterFlagForCmd(&searchSignedFlag, SearchCmd)
+		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, SearchCmd)
+
+		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, SearchCmd)
+		cmdManager.RegisterFlagForCmd(&dockerPasswordFlag, SearchCmd)
+		cmdManager.RegisterFlagForCmd(&dockerLoginFlag, SearchCmd)
+		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, SearchCmd)
 	})
 }
 
@@ -77,8 +85,13 @@ var SearchCmd = &cobra.Command{
 	Args:                  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		proto, _ := uri.Split(args[0])
-		if proto != "" {
-			sylog.Fatalf("URI protocols not supported in search query")
+		switch proto {
+		case "":
+		case DockerProtocol, OrasProtocol:
+			searchRegistry(cmd, proto, args[0])
+			return
+		default:
+			sylog.Fatalf("Only docker:// and oras:// URI protocols are supported in search query")
 		}
 
 		config, err := getLibraryClientConfig(SearchLibraryURI)
@@ -101,3 +114,28 @@ var SearchCmd = &cobra.Command{
 	Long:    docs.SearchLong,
 	Example: docs.SearchExample,
 }
+
+// searchRegistry searches the catalog of the OCI registry targeted by query.
+func searchRegistry(cmd *cobra.Command, proto, query string) {
+	if cmd.Flag(searchSignedFlag.Name).Changed || cmd.Flag(searchArchFlag.Name).Changed {
+		sylog.Warningf("--signed and --arch are not supported when searching a registry. Ignoring them.")
+	}
+	ociAuth, err := makeOCICredentials(cmd)
+	if err != nil {
+		sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+	}
+
+	registry, repos, err := ociclient.SearchRegistry(cmd.Context(), query, ociAuth, noHTTPS, reqAuthFile)
+	if err != nil {
+		sylog.Fatalf("Couldn't search registry: %v", err)
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No repositories found in %s matching %q.\n\n", registry, query)
+		return
+	}
+	fmt.Printf("Found %d repositories in %s matching %q:\n\n", len(repos), registry, query)
+	for _, r := range repos {
+		fmt.Printf("\t%s://%s/%s\n", proto, registry, r)
+	}
+	fmt.Printf("\n")
+}
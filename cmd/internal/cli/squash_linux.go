@@ -0,0 +1,58 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"compress/gzip"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/app/apptainer"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var squashCompressionLevel int
+
+// --compression-level
+var squashCompressionLevelFlag = cmdline.Flag{
+	ID:           "squashCompressionLevelFlag",
+	Value:        &squashCompressionLevel,
+	DefaultValue: gzip.DefaultCompression,
+	Name:         "compression-level",
+	Usage:        "gzip compression level (0-9) of the squashed layer, recompresses images with a single layer",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(SquashCmd)
+
+		cmdManager.RegisterFlagForCmd(&squashCompressionLevelFlag, SquashCmd)
+		cmdManager.RegisterFlagForCmd(&actionTmpDirFlag, SquashCmd)
+	})
+}
+
+// SquashCmd is the 'squash' command that flattens the layers of an OCI-SIF
+// image into a single layer.
+var SquashCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := apptainer.Squash(args[0], apptainer.SquashOptions{
+			Recompress:       cmd.Flags().Changed(squashCompressionLevelFlag.Name),
+			CompressionLevel: squashCompressionLevel,
+			TmpDir:           tmpDir,
+		})
+		if err != nil {
+			sylog.Fatalf("Unable to squash %s: %s", args[0], err)
+		}
+	},
+
+	Use:     docs.SquashUse,
+	Short:   docs.SquashShort,
+	Long:    docs.SquashLong,
+	Example: docs.SquashExample,
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	ociclient "github.com/apptainer/apptainer/internal/pkg/client/oci"
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(TagsCmd)
+
+		cmdManager.RegisterFlagForCmd(&commonNoHTTPSFlag, TagsCmd)
+
+		cmdManager.RegisterFlagForCmd(&dockerUsernameFlag, TagsCmd)
+		cmdManager.RegisterFlagForCmd(&dockerPasswordFlag, TagsCmd)
+		cmdManager.RegisterFlagForCmd(&dockerLoginFlag, TagsCmd)
+		cmdManager.RegisterFlagForCmd(&commonAuthFileFlag, TagsCmd)
+	})
+}
+
+// TagsCmd apptainer tags
+var TagsCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		proto, _ := uri.Split(args[0])
+		if proto != DockerProtocol && proto != OrasProtocol {
+			sylog.Fatalf("Only docker:// and oras:// URIs are supported")
+		}
+
+		ociAuth, err := makeOCICredentials(cmd)
+		if err != nil {
+			sylog.Fatalf("Unable to make docker oci credentials: %s", err)
+		}
+
+		// the tags are always listed, and cached for the completion of
+		// image references
+		imgCache := getCacheHandle(cache.Config{})
+		tags, err := ociclient.ListTagsCached(cmd.Context(), imgCache, args[0], 0, ociAuth, noHTTPS, reqAuthFile)
+		if err != nil {
+			sylog.Fatalf("Couldn't list tags: %v", err)
+		}
+		for _, t := range tags {
+			fmt.Println(t)
+		}
+	},
+
+	Use:     docs.TagsUse,
+	Short:   docs.TagsShort,
+	Long:    docs.TagsLong,
+	Example: docs.TagsExample,
+}
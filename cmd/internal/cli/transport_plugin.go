@@ -0,0 +1,40 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/plugin"
+	clicallback "github.com/apptainer/apptainer/pkg/plugin/callback/cli"
+)
+
+// errUnsupportedTransport is returned when no plugin handles a transport.
+var errUnsupportedTransport = errors.New("unsupported transport type")
+
+// handlePluginTransport retrieves the image at imageURI with the plugin
+// handling its transport, and returns the path of the local image.
+func handlePluginTransport(ctx context.Context, imgCache *cache.Handle, transport, imageURI string) (string, error) {
+	callbackType := (clicallback.ImageTransport)(nil)
+	callbacks, err := plugin.LoadCallbacks(callbackType)
+	if err != nil {
+		return "", fmt.Errorf("while loading plugin callbacks '%T': %w", callbackType, err)
+	}
+	for _, c := range callbacks {
+		image, handled, err := c.(clicallback.ImageTransport)(ctx, imgCache, transport, imageURI)
+		if !handled {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("while retrieving %s: %w", imageURI, err)
+		}
+		return image, nil
+	}
+	return "", fmt.Errorf("%w: %s", errUnsupportedTransport, transport)
+}
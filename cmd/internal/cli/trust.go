@@ -0,0 +1,194 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/trust"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trustSite bool   // --site option to manage the site trust store
+	trustName string // --name option to name a trust anchor
+	trustJSON bool   // --json option to list trust anchors in JSON
+)
+
+// --site
+var trustSiteFlag = cmdline.Flag{
+	ID:           "trustSiteFlag",
+	Value:        &trustSite,
+	DefaultValue: false,
+	Name:         "site",
+	ShortHand:    "s",
+	Usage:        "manage the site trust store (restricted to root user or unprivileged installation only)",
+}
+
+// --name
+var trustNameFlag = cmdline.Flag{
+	ID:           "trustNameFlag",
+	Value:        &trustName,
+	DefaultValue: "",
+	Name:         "name",
+	ShortHand:    "n",
+	Usage:        "name of the trust anchor, defaults to the file name without extension",
+}
+
+// -j|--json
+var trustJSONFlag = cmdline.Flag{
+	ID:           "trustJSONFlag",
+	Value:        &trustJSON,
+	DefaultValue: false,
+	Name:         "json",
+	ShortHand:    "j",
+	Usage:        "output trust anchors in json",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(TrustCmd)
+		cmdManager.RegisterSubCmd(TrustCmd, TrustAddCmd)
+		cmdManager.RegisterSubCmd(TrustCmd, TrustRemoveCmd)
+		cmdManager.RegisterSubCmd(TrustCmd, TrustListCmd)
+
+		cmdManager.RegisterFlagForCmd(&trustSiteFlag, TrustAddCmd, TrustRemoveCmd)
+		cmdManager.RegisterFlagForCmd(&trustNameFlag, TrustAddCmd)
+		cmdManager.RegisterFlagForCmd(&trustJSONFlag, TrustListCmd)
+	})
+}
+
+// checkTrustSite ensures that the site trust store is managed with root
+// privileges or by an unprivileged installation.
+func checkTrustSite(cmd *cobra.Command, _ []string) {
+	if !trustSite || os.Geteuid() == 0 || buildcfg.APPTAINER_SUID_INSTALL == 0 {
+		return
+	}
+	sylog.Fatalf("%q command with --site requires root privileges or an unprivileged installation", cmd.CommandPath())
+}
+
+// trustStore returns the trust store selected by the --site option.
+func trustStore() *trust.Store {
+	if trustSite {
+		return trust.SiteStore()
+	}
+	return trust.UserStore()
+}
+
+// TrustCmd is the 'trust' command that manages the image verification
+// trust anchors.
+var TrustCmd = &cobra.Command{
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return errors.New("invalid command")
+	},
+	DisableFlagsInUseLine: true,
+
+	Use:     docs.TrustUse,
+	Short:   docs.TrustShort,
+	Long:    docs.TrustLong,
+	Example: docs.TrustExample,
+}
+
+// TrustAddCmd is 'apptainer trust add <kind> <file>'.
+var TrustAddCmd = &cobra.Command{
+	PreRun:                checkTrustSite,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		kind, err := trust.ParseKind(args[0])
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		s := trustStore()
+		a, err := s.Add(kind, trustName, args[1])
+		if err != nil {
+			sylog.Fatalf("Unable to add trust anchor: %s", err)
+		}
+		sylog.Infof("Added %s trust anchor %s (%s) to the %s trust store", a.Kind, a.Name, a.ID, s.Name)
+	},
+
+	Use:     docs.TrustAddUse,
+	Short:   docs.TrustAddShort,
+	Long:    docs.TrustAddLong,
+	Example: docs.TrustAddExample,
+}
+
+// TrustRemoveCmd is 'apptainer trust remove <kind> <name>'.
+var TrustRemoveCmd = &cobra.Command{
+	PreRun:                checkTrustSite,
+	Args:                  cobra.ExactArgs(2),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		kind, err := trust.ParseKind(args[0])
+		if err != nil {
+			sylog.Fatalf("%s", err)
+		}
+		s := trustStore()
+		if err := s.Remove(kind, args[1]); err != nil {
+			sylog.Fatalf("Unable to remove trust anchor from the %s trust store: %s", s.Name, err)
+		}
+		sylog.Infof("Removed %s trust anchor %s from the %s trust store", kind, args[1], s.Name)
+	},
+
+	Use:     docs.TrustRemoveUse,
+	Short:   docs.TrustRemoveShort,
+	Long:    docs.TrustRemoveLong,
+	Example: docs.TrustRemoveExample,
+}
+
+// TrustListCmd is 'apptainer trust list [kind]'.
+var TrustListCmd = &cobra.Command{
+	Args:                  cobra.RangeArgs(0, 1),
+	DisableFlagsInUseLine: true,
+	Run: func(_ *cobra.Command, args []string) {
+		var kind trust.Kind
+		if len(args) > 0 {
+			k, err := trust.ParseKind(args[0])
+			if err != nil {
+				sylog.Fatalf("%s", err)
+			}
+			kind = k
+		}
+
+		anchors := []trust.Anchor{}
+		for _, s := range trust.Stores() {
+			a, err := s.List(kind)
+			if err != nil {
+				sylog.Fatalf("Unable to list the %s trust store: %s", s.Name, err)
+			}
+			anchors = append(anchors, a...)
+		}
+
+		if trustJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "\t")
+			if err := enc.Encode(map[string][]trust.Anchor{"anchors": anchors}); err != nil {
+				sylog.Fatalf("Could not encode trust anchors: %s", err)
+			}
+			return
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		defer tw.Flush()
+		fmt.Fprintln(tw, "STORE\tKIND\tNAME\tID")
+		for _, a := range anchors {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Store, a.Kind, a.Name, a.ID)
+		}
+	},
+
+	Use:     docs.TrustListUse,
+	Short:   docs.TrustListShort,
+	Long:    docs.TrustListLong,
+	Example: docs.TrustListExample,
+}
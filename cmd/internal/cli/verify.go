@@ -19,6 +19,7 @@ import (
 	sifsignature "github.com/apptainer/apptainer/internal/pkg/signature"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/spf13/cobra"
 )
@@ -31,6 +32,7 @@ var (
 	certificateRootsPath         string // --certificate-roots flag
 	ocspVerify                   bool   // --ocsp-verify flag
 	pubKeyPath                   string // --key flag
+	bundlePath                   string // --bundle flag
 	localVerify                  bool   // -l flag
 	jsonVerify                   bool   // -j flag
 	verifyAll                    bool
@@ -138,6 +140,16 @@ var verifyPublicKeyFlag = cmdline.Flag{
 	EnvKeys:      []string{"VERIFY_KEY"},
 }
 
+// --bundle
+var verifyBundleFlag = cmdline.Flag{
+	ID:           "bundleFlag",
+	Value:        &bundlePath,
+	DefaultValue: "",
+	Name:         "bundle",
+	Usage:        "path to a sigstore bundle for keyless verification",
+	EnvKeys:      []string{"VERIFY_BUNDLE"},
+}
+
 // -l|--local
 var verifyLocalFlag = cmdline.Flag{
 	ID:           "verifyLocalFlag",
@@ -192,6 +204,7 @@ func init() {
 		cmdManager.RegisterFlagForCmd(&verifyCertificateRootsFlag, VerifyCmd)
 		cmdManager.RegisterFlagForCmd(&verifyOCSPFlag, VerifyCmd)
 		cmdManager.RegisterFlagForCmd(&verifyPublicKeyFlag, VerifyCmd)
+		cmdManager.RegisterFlagForCmd(&verifyBundleFlag, VerifyCmd)
 		cmdManager.RegisterFlagForCmd(&verifyLocalFlag, VerifyCmd)
 		cmdManager.RegisterFlagForCmd(&verifyJSONFlag, VerifyCmd)
 		cmdManager.RegisterFlagForCmd(&verifyAllFlag, VerifyCmd)
@@ -257,8 +270,27 @@ func doVerifyCmd(cmd *cobra.Command, cpath string) {
 		}
 		opts = append(opts, sifsignature.OptVerifyWithVerifier(v))
 
+	case cmd.Flag(verifyBundleFlag.Name).Changed:
+		sylog.Infof("Verifying image with keyless key material from bundle '%v'", bundlePath)
+
+		b, err := os.ReadFile(bundlePath)
+		if err != nil {
+			sylog.Fatalf("Failed to load sigstore bundle: %v", err)
+		}
+		cfg := apptainerconf.GetCurrentConfig()
+		if cfg == nil {
+			sylog.Fatalf("Keyless verification requires the apptainer configuration")
+		}
+		t, err := sifsignature.LoadConfiguredKeylessTrust(cfg)
+		if err != nil {
+			sylog.Fatalf("Failed to load keyless trust configuration: %v", err)
+		}
+		opts = append(opts, sifsignature.OptVerifyWithKeylessBundle(b, t))
+
 	default:
-		sylog.Infof("Verifying image with PGP key material")
+		sylog.Infof("Verifying image with PGP key material and trusted keys")
+
+		opts = append(opts, sifsignature.OptVerifyWithTrustedKeys())
 
 		// Set keyserver option, if applicable.
 		if localVerify {
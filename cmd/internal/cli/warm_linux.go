@@ -0,0 +1,143 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apptainer/apptainer/docs"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/warm"
+	"github.com/apptainer/apptainer/pkg/cmdline"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/spf13/cobra"
+)
+
+var warmIdleTimeout string
+
+// --idle-timeout
+var warmIdleTimeoutFlag = cmdline.Flag{
+	ID:           "warmIdleTimeoutFlag",
+	Value:        &warmIdleTimeout,
+	DefaultValue: "1h",
+	Name:         "idle-timeout",
+	Usage:        "unmount the image once unused for this duration (e.g. 30m, 0 to keep it mounted until stopped)",
+}
+
+func init() {
+	addCmdInit(func(cmdManager *cmdline.CommandManager) {
+		cmdManager.RegisterCmd(WarmCmd)
+		cmdManager.RegisterSubCmd(WarmCmd, WarmStartCmd)
+		cmdManager.RegisterSubCmd(WarmCmd, WarmStopCmd)
+		cmdManager.RegisterSubCmd(WarmCmd, WarmListCmd)
+		cmdManager.RegisterSubCmd(WarmCmd, warmServeCmd)
+
+		cmdManager.RegisterFlagForCmd(&warmIdleTimeoutFlag, WarmStartCmd)
+	})
+}
+
+// WarmCmd is the 'warm' command keeping images mounted by per-user helper
+// processes.
+var WarmCmd = &cobra.Command{
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return errors.New("invalid command")
+	},
+	DisableFlagsInUseLine: true,
+
+	Use:           docs.WarmUse,
+	Short:         docs.WarmShort,
+	Long:          docs.WarmLong,
+	Example:       docs.WarmExample,
+	SilenceErrors: true,
+}
+
+// WarmStartCmd is the 'warm start' command.
+var WarmStartCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		idleTimeout, err := time.ParseDuration(warmIdleTimeout)
+		if err != nil || idleTimeout < 0 {
+			sylog.Fatalf("Invalid idle timeout %q", warmIdleTimeout)
+		}
+		s, err := warm.Start(args[0], idleTimeout)
+		if err != nil {
+			sylog.Fatalf("Unable to warm %s: %s", args[0], err)
+		}
+		sylog.Infof("%s is kept mounted at %s by process %d", s.Image, s.Rootfs, s.PID)
+	},
+
+	Use:     docs.WarmStartUse,
+	Short:   docs.WarmStartShort,
+	Long:    docs.WarmStartLong,
+	Example: docs.WarmStartExample,
+}
+
+// WarmStopCmd is the 'warm stop' command.
+var WarmStopCmd = &cobra.Command{
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := warm.Stop(args[0]); err != nil {
+			sylog.Fatalf("Unable to stop warm %s: %s", args[0], err)
+		}
+	},
+
+	Use:     docs.WarmStopUse,
+	Short:   docs.WarmStopShort,
+	Long:    docs.WarmStopLong,
+	Example: docs.WarmStopExample,
+}
+
+// WarmListCmd is the 'warm list' command.
+var WarmListCmd = &cobra.Command{
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		states, err := warm.List()
+		if err != nil {
+			sylog.Fatalf("Unable to list warm images: %s", err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 4, ' ', 0)
+		fmt.Fprintln(tw, "PID\tIDLE TIMEOUT\tLAST USED\tIMAGE")
+		for _, s := range states {
+			idle := "none"
+			if s.IdleTimeout > 0 {
+				idle = s.IdleTimeout.String()
+			}
+			lastUsed := "never"
+			if !s.LastUsed.IsZero() {
+				lastUsed = s.LastUsed.Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.PID, idle, lastUsed, s.Image)
+		}
+		tw.Flush()
+	},
+
+	Use:     docs.WarmListUse,
+	Short:   docs.WarmListShort,
+	Long:    docs.WarmListLong,
+	Example: docs.WarmListExample,
+}
+
+// warmServeCmd is the hidden 'warm serve' command run by the helper process
+// keeping an image mounted, configured from its standard input.
+var warmServeCmd = &cobra.Command{
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	Hidden:                true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := warm.Serve(os.Stdin, os.Stdout); err != nil {
+			sylog.Fatalf("Warm image helper failed: %s", err)
+		}
+	},
+
+	Use:   "serve",
+	Short: "Keep an image mounted (internal use)",
+}
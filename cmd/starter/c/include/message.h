@@ -53,6 +53,7 @@
 #define ANSI_COLOR_RESET        "\x1b[0m"
 
 #define MSGLVL_ENV              "APPTAINER_MESSAGELEVEL"
+#define MSGFMT_ENV              "APPTAINER_MESSAGEFORMAT"
 
 void _print(int level, const char *function, const char *file, char *format, ...) __attribute__ ((__format__(printf, 4, 5)));
 
@@ -1143,11 +1143,13 @@ static void cleanenv(void) {
     }
 
     /*
-     * keep only APPTAINER_MESSAGELEVEL for GO runtime, set others to empty
-     * string and not NULL (see issue #3703 for why)
+     * keep only APPTAINER_MESSAGELEVEL and APPTAINER_MESSAGEFORMAT for GO
+     * runtime, set others to empty string and not NULL (see issue #3703
+     * for why)
      */
     for (e = environ; *e != NULL; e++) {
-        if ( strncmp(MSGLVL_ENV "=", *e, sizeof(MSGLVL_ENV)) != 0 ) {
+        if ( strncmp(MSGLVL_ENV "=", *e, sizeof(MSGLVL_ENV)) != 0 &&
+             strncmp(MSGFMT_ENV "=", *e, sizeof(MSGFMT_ENV)) != 0 ) {
             *e = "";
         }
     }
@@ -127,6 +127,11 @@ Enterprise Performance Computing (EPC)`
   The following sections are presented in the order of processing, with the exception
   that labels and environment can also be manipulated in %post.
 
+      %arguments
+          # default values of the {{ VARIABLE }} templates of the definition file,
+          # replaced in the header and all sections, overridden with --build-arg
+          VERSION=3.19
+
       %pre
           echo "This is a scriptlet that will be executed on the host, as root before"
           echo "the container has been bootstrapped. This section is not commonly used."
@@ -140,6 +145,9 @@ Enterprise Performance Computing (EPC)`
           /path/on/host/file.txt /path/on/container/file.txt
           relative_file.txt /path/on/container/relative_file.txt
 
+      %files from docker://busybox:latest
+          /bin/busybox /usr/local/bin/busybox
+
       %post
           echo "This scriptlet section will be executed from within the container after"
           echo "the bootstrap/base has been created and setup."
@@ -182,7 +190,14 @@ Enterprise Performance Computing (EPC)`
       Build a base sandbox from DockerHub, make changes to it, then build sif
           $ apptainer build --sandbox /tmp/debian docker://debian:latest
           $ apptainer exec --writable /tmp/debian apt-get install python
-          $ apptainer build /tmp/debian2.sif /tmp/debian`
+          $ apptainer build /tmp/debian2.sif /tmp/debian
+
+      Build variants of a definition file using "From: alpine:{{ VERSION }}"
+          $ apptainer build alpine-3.19.sif alpine.def
+          $ apptainer build --build-arg VERSION=3.20 alpine-3.20.sif alpine.def
+
+      Build an OCI-SIF image on a remote Linux runner, and push it to a registry
+          $ apptainer build --runner user@builder --oci oras://registry.example.com/debian:latest /path/to/debian.def`
 
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// Cache
@@ -191,7 +206,7 @@ Enterprise Performance Computing (EPC)`
 	CacheShort string = `Manage the local cache`
 	CacheLong  string = `
   Manage your local Apptainer cache. You can list/clean using the specific
-  types.`
+  types, and export/import cache entries to replicate a cache to other nodes.`
 	CacheExample string = `
   All group commands have their own help output:
 
@@ -231,6 +246,56 @@ Enterprise Performance Computing (EPC)`
   $ apptainer help cache list --type=library,oci
   $ apptainer cache list --help`
 
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// Cache Export
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CacheExportUse   string = `export [export options...] <file|directory|->`
+	CacheExportShort string = `Export your local Apptainer cache`
+	CacheExportLong  string = `
+  This will export the entries of your local cache, to be imported into the
+  cache of other nodes with 'cache import'. By default the cache is exported
+  as a tar archive, written to standard output if '-' is given. With --dir the
+  entries are copied to a directory with the layout of the cache instead,
+  skipping entries already present, so that it can be updated incrementally
+  and synchronized to other nodes or to an air-gapped system with tools like
+  rsync. Use --type to export only some types of entries.`
+	CacheExportExample string = `
+  $ apptainer cache export cache.tar
+  $ apptainer cache export --type=oci-tmp,blob - | ssh node apptainer cache import -
+  $ apptainer cache export --dir /shared/apptainer-cache`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// Cache Import
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CacheImportUse   string = `import <file|directory|->`
+	CacheImportShort string = `Import entries into your local Apptainer cache`
+	CacheImportLong  string = `
+  This will import the cache entries of a tar archive, read from standard
+  input if '-' is given, or of a directory created by 'cache export'. Entries
+  already in the cache are kept, OCI blobs are verified against their digest,
+  and the images of the OCI blob cache are added to its index.`
+	CacheImportExample string = `
+  $ apptainer cache import cache.tar
+  $ apptainer cache import /shared/apptainer-cache`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// Cache Verify
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CacheVerifyUse   string = `verify [verify options...]`
+	CacheVerifyShort string = `Verify the entries of your local Apptainer cache`
+	CacheVerifyLong  string = `
+  This will verify the entries of your local cache, reporting the corrupted
+  ones, as can be left by flaky or parallel filesystems hosting the cache. OCI
+  blobs and images named after their digest are checked against it, other SIF
+  images are checked not to be truncated, and metadata entries to be valid.
+  With --repair corrupted entries are removed, and the docker:// and oras://
+  images recorded as pulled into them are pulled again into the cache. Other
+  entries are downloaded again when next used. Use --type to verify only some
+  types of entries.`
+	CacheVerifyExample string = `
+  $ apptainer cache verify
+  $ apptainer cache verify --type=blob,oci-tmp --repair`
+
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// key
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -362,6 +427,61 @@ Enterprise Performance Computing (EPC)`
 	KeyRemoveExample string = `
   $ apptainer key remove D87FE3AF5C1F063FCBCC9B02F812842B5EEE5934`
 
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// trust
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	TrustUse   string = `trust [subcommand options...] <subcommand>`
+	TrustShort string = `Manage the trust anchors used to verify images`
+	TrustLong  string = `
+  The trust command manages the trust anchors used to verify images, in the
+  site trust store managed by root, and in your user trust store. There are
+  three kinds of trust anchors:
+
+    pgp:  PGP public keys, trusted in addition to the keyrings by verify and
+          by image verification at runtime
+    key:  PEM public keys, like cosign keys, trusted by verify when no key
+          material is given on the command line
+    root: PEM certificates of Fulcio certificate authorities, trusted in
+          addition to the 'keyless fulcio roots' of apptainer.conf to verify
+          keyless signatures with 'verify --bundle' and the sigstore bundle
+          referrers of oras pulls`
+	TrustExample string = `
+  All group commands have their own help output:
+
+  $ apptainer help trust add
+  $ apptainer trust add --help`
+
+	TrustAddUse   string = `add [add options...] <kind> <file>`
+	TrustAddShort string = `Add a trust anchor to your or the site trust store`
+	TrustAddLong  string = `
+  The 'trust add' command adds a trust anchor of kind pgp, key or root read
+  from a file to your trust store, or to the site trust store with --site.
+  The anchor is named after the file, unless a name is given with --name.`
+	TrustAddExample string = `
+  $ apptainer trust add key cosign.pub
+  $ sudo apptainer trust add --site --name sigstore root fulcio.pem
+  $ apptainer trust add --name team pgp team-keys.asc`
+
+	TrustRemoveUse   string = `remove [remove options...] <kind> <name>`
+	TrustRemoveShort string = `Remove a trust anchor from your or the site trust store`
+	TrustRemoveLong  string = `
+  The 'trust remove' command removes a named trust anchor from your trust
+  store, or from the site trust store with --site.`
+	TrustRemoveExample string = `
+  $ apptainer trust remove key cosign
+  $ sudo apptainer trust remove --site root sigstore`
+
+	TrustListUse   string = `list [list options...] [kind]`
+	TrustListShort string = `List the trust anchors of the site and your trust stores`
+	TrustListLong  string = `
+  The 'trust list' command lists the trust anchors of the site and your trust
+  stores, optionally only those of a kind, with their identifier: the
+  fingerprints of PGP keys, the sha256 digest of public keys and the subjects
+  of certificates.`
+	TrustListExample string = `
+  $ apptainer trust list
+  $ apptainer trust list --json key`
+
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// delete
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -372,6 +492,52 @@ Enterprise Performance Computing (EPC)`
 	DeleteExample string = `
   $ apptainer delete --arch=amd64 library://username/project/image:1.0`
 
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// cdi
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CDIUse   string = `cdi`
+	CDIShort string = `Manage the Container Device Interface (CDI) devices`
+	CDILong  string = `
+  The Container Device Interface (CDI) describes, in JSON or YAML spec files,
+  the edits of a container required to use devices: environment variables,
+  device nodes and mounts. Devices are added to a container with --device and
+  their fully qualified name, as vendor/class=name. Spec files are read from
+  the directories set by the 'cdi dirs' directive of apptainer.conf, by
+  increasing priority.`
+	CDIExample string = `
+  All group commands have their own help output:
+
+  $ apptainer help cdi list
+  $ apptainer cdi list --help`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// cdi list
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CDIListUse   string = `list [list options...]`
+	CDIListShort string = `List the CDI devices`
+	CDIListLong  string = `
+  List the CDI devices described by the spec files, with the spec file
+  describing each of them. Invalid spec files, and devices described by more
+  than one spec file of the same directory, are reported and ignored.`
+	CDIListExample string = `
+  $ apptainer cdi list
+  $ apptainer cdi list --dir /etc/cdi
+  $ apptainer exec --device nvidia.com/gpu=0 image.sif nvidia-smi`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// cdi validate
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	CDIValidateUse   string = `validate [validate options...] [<spec file>...]`
+	CDIValidateShort string = `Validate CDI spec files`
+	CDIValidateLong  string = `
+  Validate the given CDI spec files, or all the spec files of the CDI
+  directories, reporting the invalid ones and the devices described by more
+  than one spec file of the same directory. The command fails if any is
+  found.`
+	CDIValidateExample string = `
+  $ apptainer cdi validate
+  $ apptainer cdi validate /etc/cdi/vendor.yaml`
+
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// capability
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -497,7 +663,17 @@ Enterprise Performance Computing (EPC)`
   shub://*            A container hosted on Singularity Hub.
 
   oras://*            A SIF container hosted on an OCI registry that supports
-                      the OCI Registry As Storage (ORAS) specification.`
+                      the OCI Registry As Storage (ORAS) specification.
+
+  s3://*              A SIF container stored in an S3 compatible object
+                      storage bucket.
+
+  gs://*              A SIF container stored in a Google Cloud Storage bucket.
+
+  azblob://*          A SIF container stored in an Azure Blob Storage
+                      container.
+
+  ipfs://*            A SIF container stored in IPFS, identified by its CID.`
 	ExecUse   string = `exec [exec options...] <container> <command>`
 	ExecShort string = `Run a command within a container`
 	ExecLong  string = `
@@ -531,7 +707,13 @@ Enterprise Performance Computing (EPC)`
 	InstanceListShort string = `List all running and named Apptainer instances`
 	InstanceListLong  string = `
   The instance list command allows you to view the Apptainer container
-  instances that are currently running in the background.`
+  instances that are currently running in the background.
+
+  With --json, the list also includes the containers created with the
+  apptainer oci commands, the type field telling them apart. Each entry
+  reports the instance PID, image and image digest, IP address, creation
+  time, cgroup path and state (running or paused, or the OCI container
+  state).`
 	InstanceListExample string = `
   $ apptainer instance list
   INSTANCE NAME      PID       IMAGE
@@ -561,6 +743,12 @@ Enterprise Performance Computing (EPC)`
   will be executed with the instance start command as well. You can optionally
   pass arguments to startscript.
 
+  The output of the instance is written to log files in the instances
+  directory of your home by default. With --log-driver, it is sent to syslog
+  or to the systemd journal with the apptainer-<instance name> identifier
+  instead, or discarded with 'none'. The default driver is set by the
+  'instance log driver' directive of apptainer.conf.
+
   apptainer instance start accepts the following container formats` + formats
 	InstanceStartExample string = `
   $ apptainer instance start /tmp/my-sql.sif mysql
@@ -628,6 +816,69 @@ Enterprise Performance Computing (EPC)`
   $ apptainer instance stats --no-stream mysql
   $ sudo apptainer instance stats --user <username> user-mysql`
 
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// instance top
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	InstanceTopUse   string = `top [top options...] <instance name>`
+	InstanceTopShort string = `Display the processes running in a named instance`
+	InstanceTopLong  string = `
+  The instance top command lists the processes running in a named instance,
+  with their PID on the host and in the instance, their user, their CPU and
+  memory usage and their command. When the instance has its own PID namespace,
+  all the processes of the namespace are listed, including those started with
+  'apptainer exec instance://', otherwise the processes descending from the
+  instance process. If you are root, you can optionally list the processes of
+  an instance belonging to a specific user.`
+	InstanceTopExample string = `
+  $ apptainer instance top mysql
+  $ apptainer instance top --json mysql
+  $ sudo apptainer instance top --user <username> user-mysql`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// instance exec
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	InstanceExecUse   string = `exec <instance name> <command> [args...]`
+	InstanceExecShort string = `Run a command within a named instance`
+	InstanceExecLong  string = `
+  The instance exec command runs a command within a running instance, joining
+  its namespaces. It works both with instances started by Apptainer and with
+  containers created with the apptainer oci commands, which requires root
+  privileges.
+
+  The command runs with the container environment, not with the host
+  environment: for instances started by Apptainer it is like running
+  'apptainer exec --cleanenv instance://<instance name>', for OCI containers
+  the environment of the container process is used.`
+	InstanceExecExample string = `
+  $ apptainer instance start /tmp/my-sql.sif mysql
+  $ apptainer instance exec mysql ps aux
+  $ sudo apptainer instance exec my-oci-container cat /etc/os-release`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// instance migrate
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	InstanceMigrateUse   string = `migrate [migrate options...] <instance name> <destination host>`
+	InstanceMigrateShort string = `Migrate a named instance to another host (experimental)`
+	InstanceMigrateLong  string = `
+  The command apptainer instance migrate moves a running instance started with
+  --dmtcp-launch or --dmtcp-restart to another host. The instance is
+  checkpointed, its image and checkpoint are copied to the destination host with
+  scp, the local instance is stopped, and the instance is restarted on the
+  destination host from the checkpoint with the same name, through ssh.
+
+  The image is copied to the same path on the destination host, and the
+  checkpoint to the same location relative to the home directory. With
+  --shared, the image and the checkpoint are expected to be on a file system
+  shared by both hosts and are not copied. Apptainer and DMTCP must be
+  installed on the destination host, which must be reachable with ssh without
+  password. Options given to the instance when it was started are not carried
+  over.`
+	InstanceMigrateExample string = `
+  $ apptainer checkpoint create job-checkpoint
+  $ apptainer instance start --dmtcp-launch job-checkpoint job.sif job
+  $ apptainer instance migrate job node02
+  $ apptainer instance migrate --shared job node03`
+
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// instance stop
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -635,7 +886,13 @@ Enterprise Performance Computing (EPC)`
 	InstanceStopShort string = `Stop a named instance of a given container image`
 	InstanceStopLong  string = `
   The command apptainer instance stop allows you to stop and clean up a named,
-  running instance of a given container image.`
+  running instance of a given container image.
+
+  Instances are sent the stop signal set in the OCI configuration of their
+  image, or SIGINT by default, and are killed if still running after the
+  timeout. When run as root, the OCI containers created by the oci commands
+  whose names match are stopped the same way and deleted, a paused container
+  being resumed first so that it receives the signal.`
 	InstanceStopExample string = `
   $ apptainer instance start my-sql.sif mysql1
   $ apptainer instance start my-sql.sif mysql2
@@ -651,7 +908,10 @@ Enterprise Performance Computing (EPC)`
   Send SIGTERM to the instance
   $ apptainer instance stop -s SIGTERM mysql1
   $ apptainer instance stop -s TERM mysql1
-  $ apptainer instance stop -s 15 mysql1`
+  $ apptainer instance stop -s 15 mysql1
+
+  Give instances 60 seconds to shut down before killing them
+  $ apptainer instance stop -t 60 --all`
 
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// pull
@@ -675,7 +935,33 @@ Enterprise Performance Computing (EPC)`
       oras://registry/namespace/image:tag
 
   http, https: Pull an image using the http(s?) protocol
-      https://example.com/alpine.sif`
+      https://example.com/alpine.sif
+
+  s3: Pull an image from S3 compatible object storage, using the standard
+  AWS credentials. Set AWS_ENDPOINT_URL for MinIO, Ceph or other endpoints.
+      s3://bucket/path/to/image.sif
+
+  gs: Pull an image from Google Cloud Storage, using the application default
+  credentials or GOOGLE_OAUTH_ACCESS_TOKEN.
+      gs://bucket/path/to/image.sif
+
+  azblob: Pull an image from Azure Blob Storage, using the storage account set
+  by AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING.
+      azblob://container/path/to/image.sif
+
+  ipfs: Pull an image from IPFS through the gateway set by IPFS_GATEWAY, or
+  the gateway of the local node. The content is verified against the CID.
+      ipfs://bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi
+
+  With --lockfile, the digests that tag-based docker:// and oras:// references
+  resolve to are recorded in the given lockfile. With --locked, images not
+  matching the digests recorded in the lockfile, apptainer.lock by default,
+  are refused. The same flags are supported by 'run', 'exec', 'shell',
+  'instance start' and 'prefetch' to make workflows reproducible.
+
+  With --check-update, an existing docker:// or oras:// image is only pulled
+  again when the digest it resolves to in the registry differs from the one
+  recorded in the cache when it was pulled.`
 	PullExample string = `
   From a library
   $ apptainer pull alpine.sif library://alpine:latest
@@ -684,12 +970,104 @@ Enterprise Performance Computing (EPC)`
   $ apptainer pull tensorflow.sif docker://tensorflow/tensorflow:latest
   $ apptainer pull --arch arm --arch-variant 6 alpine.sif docker://alpine:latest
 
+  Record the digest of an image, and refuse it later if the tag has moved
+  $ apptainer pull --lockfile apptainer.lock alpine.sif docker://alpine:3.20
+  $ apptainer run --locked docker://alpine:3.20
+
+  Pull an image again only if it was updated in the registry
+  $ apptainer pull --check-update alpine.sif docker://alpine:3.20
+
   From Shub
   $ apptainer pull apptainer-images.sif shub://vsoch/apptainer-images
 
   From supporting OCI registry (e.g. Azure Container Registry)
   $ apptainer pull image.sif oras://<username>.azurecr.io/namespace/image:tag`
 
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// images
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	ImagesUse   string = `images`
+	ImagesShort string = `Manage the local images and the images pulled from OCI registries`
+	ImagesLong  string = `
+  The digests that docker:// and oras:// images resolve to when they are
+  pulled, into the cache or to a file, are recorded in the cache. The images
+  commands use these records to list the local images, and to check for
+  updated images in the registries.`
+	ImagesExample string = `
+  All group commands have their own help output:
+
+  $ apptainer help images list
+  $ apptainer images outdated --help`
+
+	ImagesListUse   string = `list [list options...]`
+	ImagesListShort string = `List the local SIF and OCI-SIF images`
+	ImagesListLong  string = `
+  The 'images list' command lists the SIF and OCI-SIF images held in the
+  cache, the images pulled to files recorded in the cache, and the images
+  found in the directories registered with 'apptainer images register' and
+  their subdirectories.
+
+  For every image, the reference it was pulled from, its format, digest,
+  size, architecture and age are shown, when known. Use --json for
+  structured output.`
+	ImagesListExample string = `
+  $ apptainer images list
+
+  $ apptainer images list --json`
+
+	ImagesRegisterUse   string = `register <directory>`
+	ImagesRegisterShort string = `Register a directory holding images`
+	ImagesRegisterLong  string = `
+  The 'images register' command registers a directory, so that the images
+  it holds are listed by 'apptainer images list'. The registered directories
+  are recorded in the image-dirs file of the apptainer user configuration
+  directory.`
+	ImagesRegisterExample string = `
+  $ apptainer images register ~/containers`
+
+	ImagesUnregisterUse   string = `unregister <directory>`
+	ImagesUnregisterShort string = `Unregister a directory holding images`
+	ImagesUnregisterLong  string = `
+  The 'images unregister' command removes a directory registered with
+  'apptainer images register'. The images it holds are not removed.`
+	ImagesUnregisterExample string = `
+  $ apptainer images unregister ~/containers`
+
+	ImagesOutdatedUse   string = `outdated [outdated options...]`
+	ImagesOutdatedShort string = `Report the pulled images that changed in their registry`
+	ImagesOutdatedLong  string = `
+  The 'images outdated' command compares the digest every recorded
+  docker:// and oras:// image currently resolves to in its registry with the
+  digest it was pulled at. The images that changed are reported as outdated,
+  and can be refreshed with 'apptainer pull --check-update'. Images pulled to
+  files that no longer exist are reported as missing.`
+	ImagesOutdatedExample string = `
+  $ apptainer images outdated`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// prefetch
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	PrefetchUse   string = `prefetch [prefetch options...] [URI...]`
+	PrefetchShort string = `Pull images into the cache without running them`
+	PrefetchLong  string = `
+  The 'prefetch' command pulls the images given as arguments, or listed in a
+  file with --file, into the cache, converting OCI images to SIF, exactly as
+  'run' or 'exec' would do before starting a container. Several images are
+  pulled concurrently, as set by --jobs. It is intended to stage images in
+  job prologs, or to warm the cache of nodes ahead of time.
+
+  Image lists contain one URI per line, empty lines and lines starting with
+  '#' are ignored. Any of the URIs supported by 'pull' can be used.
+
+  A JSON report is written to standard output, or to the file set with
+  --report, giving for every image its status, its path in the cache or the
+  error that occurred, and the duration of the pull. The command exits with
+  an error if any image could not be prefetched.`
+	PrefetchExample string = `
+  $ apptainer prefetch docker://alpine:3.20 oras://registry/namespace/image:tag
+
+  $ apptainer prefetch --jobs 4 --file images.txt --report /tmp/prefetch.json`
+
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// push
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -705,30 +1083,84 @@ Enterprise Performance Computing (EPC)`
   oras:
       oras://registry/namespace/image:tag
 
+  s3, gs, azblob:
+      s3://bucket/path/to/image.sif
+      gs://bucket/path/to/image.sif
+      azblob://container/path/to/image.sif
+
 
   NOTE: It's always good practice to sign your containers before
   pushing them to the library. An auth token is required to push to the library,
-  so you may need to configure it first with 'apptainer remote'.`
+  so you may need to configure it first with 'apptainer remote'.
+
+  When pushing to an OCI registry, the --artifact-type, --config-media-type
+  and --annotation flags set the artifactType, the media type of the config
+  and the annotations of the pushed manifest, which registry UIs and policy
+  engines use to identify the artifact.
+
+  The --attach flag attaches an artifact associated with the image, such as an
+  SBOM, a sigstore signature bundle or a provenance attestation, as an OCI
+  referrer of the pushed manifest. It takes the form type=path, where type is
+  one of sbom (SPDX), cyclonedx, signature, provenance, or an artifact type.
+  Registries that don't support the referrers API are handled with the
+  fallback tag scheme.`
 	PushExample string = `
   To Library
   $ apptainer push /home/user/my.sif library://user/collection/my.sif:latest
 
   To supported OCI registry
-  $ apptainer push /home/user/my.sif oras://registry/namespace/image:tag`
+  $ apptainer push /home/user/my.sif oras://registry/namespace/image:tag
+
+  To supported OCI registry, with an artifact type and annotations
+  $ apptainer push --artifact-type application/vnd.example.sif \
+      --annotation org.opencontainers.image.source=https://github.com/example/repo \
+      --annotation org.opencontainers.image.version=1.0 \
+      /home/user/my.sif oras://registry/namespace/image:1.0
+
+  To supported OCI registry, with an SBOM and a signature attached
+  $ apptainer push --attach sbom=my.spdx.json --attach signature=my.sigstore.json \
+      /home/user/my.sif oras://registry/namespace/image:1.0
+
+  To S3 object storage
+  $ apptainer push /home/user/my.sif s3://bucket/images/my.sif`
 
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// search
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	SearchUse   string = `search [search options...] <search_query>`
-	SearchShort string = `Search a Container Library for images`
+	SearchShort string = `Search a Container Library or an OCI registry for images`
 	SearchLong  string = `
   Search a Container Library for container images matching the search query.
   You can specify an alternate architecture, and/or limit
-  the results to only signed images.`
+  the results to only signed images.
+
+  When the search query is a docker:// or oras:// URI, the catalog of the
+  OCI registry it targets is searched instead for repositories containing
+  the query. The registry is the first component of the URI when it looks
+  like a hostname, Docker Hub otherwise. Not all registries allow listing
+  their catalog. The --docker-username / --docker-password or --docker-login
+  flags, or the credentials from 'apptainer registry login', are used to
+  authenticate.`
 	SearchExample string = `
   $ apptainer search lolcow
   $ apptainer search --arch arm64 alpine
-  $ apptainer search --signed tensorflow`
+  $ apptainer search --signed tensorflow
+  $ apptainer search docker://quay.io/biocontainers/samtools
+  $ apptainer search oras://localhost:5000/lolcow`
+
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	// tags
+	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
+	TagsUse   string = `tags [tags options...] <docker:// or oras:// URI>`
+	TagsShort string = `List the tags of a repository in an OCI registry`
+	TagsLong  string = `
+  The tags command lists the tags of a repository in an OCI registry, one per
+  line in lexical order. Any tag or digest in the URI is ignored. The
+  --docker-username / --docker-password or --docker-login flags, or the
+  credentials from 'apptainer registry login', are used to authenticate.`
+	TagsExample string = `
+  $ apptainer tags docker://quay.io/biocontainers/samtools
+  $ apptainer tags oras://ghcr.io/apptainer/lolcow`
 
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// run
@@ -819,11 +1251,20 @@ Enterprise Performance Computing (EPC)`
   within a SIF image.
 
   Key material can be provided via PEM-encoded file, or via the PGP keyring. To
-  manage the PGP keyring, see 'apptainer help key'.`
+  manage the PGP keyring, see 'apptainer help key'.
+
+  Keyless signatures are verified with the certificate of a sigstore bundle,
+  which must be issued by the Fulcio certificate authority to one of the
+  identities trusted in apptainer.conf, and be recorded in the Rekor
+  transparency log while it was valid. See the 'keyless' directives of
+  apptainer.conf.`
 	VerifyExample string = `
   Verify with a public key:
   $ apptainer verify --key public.pem container.sif
 
+  Verify a keyless signature with a sigstore bundle:
+  $ apptainer verify --bundle container.sigstore.json container.sif
+
   Verify with PGP:
   $ apptainer verify container.sif`
 
@@ -968,9 +1409,81 @@ Enterprise Performance Computing (EPC)`
 	OciCreateShort string = `Create a container from a bundle directory (root user only)`
 	OciCreateLong  string = `
   Create invoke create operation to create a container instance from an OCI 
-  bundle directory`
+  bundle directory
+
+  With --read-only-root, the root filesystem of the container is mounted
+  read-only, whatever the bundle configuration. Each --tmpfs option mounts a
+  writable tmpfs on a container directory, replacing any mount of the bundle
+  configuration on it, so that services only write where they need to. The
+  tmpfs are mounted nosuid, nodev, noexec and with mode 1777 by default, the
+  mount options given after the path replace all but nosuid and nodev.
+
+  The --config option reads the OCI configuration from a file instead of the
+  config.json of the bundle, or from standard input for -, so that it can be
+  handed over through a pipe, a memfd (/proc/<pid>/fd/<fd>) or a tmpfs file
+  without going through the bundle filesystem. Hooks reading the config.json
+  of the bundle don't see it.
+
+  The --no-mount option removes default mounts of the bundle configuration,
+  given by name like for the action commands (proc, sys, dev, devpts, tmp)
+  or by absolute destination path, along with the mounts below them.
+
+  The --net option sets the network namespace of the container, whatever the
+  bundle configuration: 'host' shares the network of the host, 'none' creates
+  a network namespace with only a loopback interface, and any other value is
+  a comma separated list of CNI network configurations set up in a new
+  network namespace. The 'allow oci host network' directive of apptainer.conf
+  may forbid containers sharing the network of the host.
+
+  The --netns option joins an existing network namespace instead, given by
+  path like /proc/<pid>/ns/net, or by the name it was created with by
+  'ip netns add'.
+
+  The --ipc and --pid options share the IPC and PID namespaces of the host
+  with 'host'. With 'container:<id>', the --ipc, --pid and --net options join
+  the namespace of another running container, given by its ID, or of an
+  instance given by instance://<name>, to run sidecar or debugging containers
+  next to it.
+
+  The --cgroup-parent option creates the cgroup of the container under the
+  given cgroup path, or systemd slice when cgroups are managed with systemd,
+  unless the bundle configuration sets its own cgroups path.
+
+  The --init option runs the container process as the child of a small init
+  process, which reaps the zombie processes left by the container payload,
+  forwards the signals it receives to the container process and exits with
+  its exit status.
+
+  The --security landlock:<ruleset.json> option restricts the filesystem
+  accesses of the container processes with a Landlock ruleset, on kernels
+  5.13 or later. The ruleset lists the handled access rights, all by
+  default, which are denied except beneath the container paths of its rules:
+
+    {
+      "rules": [
+        {"paths": ["/usr", "/etc"], "access": ["execute", "read_file", "read_dir"]},
+        {"paths": ["/tmp"], "access": ["read_file", "read_dir", "write_file", "make_reg"]}
+      ]
+    }
+
+  The --no-new-privs and --allow-new-privs options set or clear the
+  noNewPrivileges flag of the bundle process configuration. Without them,
+  no new privileges is set when the 'root no new privs' directive of
+  apptainer.conf is enabled.
+
+  The --debug-keep-bundle option keeps the bundle and the final OCI
+  configuration of the container when it fails to start or exits with an
+  error, to run a shell in it with 'apptainer oci debug'.`
 	OciCreateExample string = `
-  $ apptainer oci create -b ~/bundle mycontainer`
+  $ apptainer oci create -b ~/bundle mycontainer
+  $ apptainer oci create -b ~/bundle --config /dev/shm/mycontainer.json mycontainer
+  $ apptainer oci create -b ~/bundle --read-only-root --tmpfs /run --tmpfs /var/cache:size=64m mycontainer
+  $ apptainer oci create -b ~/bundle --no-mount sys,tmp mycontainer
+  $ apptainer oci create -b ~/bundle --net bridge mycontainer
+  $ apptainer oci create -b ~/bundle --netns sdn0 mycontainer
+  $ apptainer oci create -b ~/debug --pid container:mycontainer --net container:mycontainer debug
+  $ apptainer oci create -b ~/bundle --cgroup-parent batch.slice mycontainer
+  $ apptainer oci create -b ~/bundle --init mycontainer`
 
 	OciStartUse   string = `start <container_ID>`
 	OciStartShort string = `Start container process (root user only)`
@@ -1024,7 +1537,10 @@ Enterprise Performance Computing (EPC)`
 	OciRunUse   string = `run -b <bundle_path> [run options...] <container_ID>`
 	OciRunShort string = `Create/start/attach/delete a container from a bundle directory (root user only)`
 	OciRunLong  string = `
-  Run will invoke equivalent of create/start/attach/delete commands in a row.`
+  Run will invoke equivalent of create/start/attach/delete commands in a row.
+  The --config, --read-only-root, --tmpfs, --no-mount, --net, --netns, --ipc,
+  --pid, --cgroup-parent, --init, --security, --no-new-privs and
+  --allow-new-privs options are the same as for create.`
 	OciRunExample string = `
   $ apptainer oci run -b ~/bundle mycontainer
 
@@ -1062,12 +1578,49 @@ Enterprise Performance Computing (EPC)`
 	OciResumeExample string = `
   $ apptainer oci resume mycontainer`
 
-	OciMountUse   string = `mount <sif_image> <bundle_path>`
-	OciMountShort string = `Mount create an OCI bundle from SIF image (root user only)`
+	OciTopUse   string = `top [top options...] <container_ID>`
+	OciTopShort string = `Display the processes running in a container (root user only)`
+	OciTopLong  string = `
+  Top will list the processes running in the specified container ID, with their
+  PID on the host and in the container, their user, their CPU and memory usage
+  and their command.`
+	OciTopExample string = `
+  $ apptainer oci top mycontainer
+  $ apptainer oci top --json mycontainer`
+
+	OciDebugUse   string = `debug [debug options...] <container_ID>`
+	OciDebugShort string = `Run a shell in the bundle of a failed container (root user only)`
+	OciDebugLong  string = `
+  Debug runs a shell in a new container created from the bundle and the final
+  OCI configuration of the container with the specified ID, created or run
+  with --debug-keep-bundle and failed, to diagnose missing libraries or
+  entrypoint issues. The shell runs with the same mounts, user and environment
+  as the failed container, in its working directory when it exists.
+
+  The bundle and configuration of a container created with --debug-keep-bundle
+  are kept unless it exits successfully.`
+	OciDebugExample string = `
+  $ apptainer oci run --debug-keep-bundle -b /var/lib/apptainer/bundles/example example
+  $ apptainer oci debug example
+  $ apptainer oci debug --shell /bin/bash example`
+
+	OciMountUse   string = `mount [mount options...] <sif_image|directory> <bundle_path>`
+	OciMountShort string = `Mount create an OCI bundle from SIF image or directory (root user only)`
 	OciMountLong  string = `
-  Mount will mount and create an OCI bundle from a SIF image.`
+  Mount will mount and create an OCI bundle from a SIF image, or from an
+  unpacked directory tree like the images published on CVMFS. A directory is
+  used in place, without being copied, as the read-only lower layer of the
+  writable bundle root filesystem.
+
+  With --config, the OCI configuration of the bundle is written to a file,
+  or to standard output for -, instead of the bundle config.json, to be read
+  by 'apptainer oci create --config' or 'apptainer oci run --config' without
+  going through the bundle filesystem.`
 	OciMountExample string = `
-  $ apptainer oci mount /tmp/example.sif /var/lib/apptainer/bundles/example`
+  $ apptainer oci mount /tmp/example.sif /var/lib/apptainer/bundles/example
+  $ apptainer oci mount --config - /tmp/example.sif /shared/bundles/example | \
+      apptainer oci run --config - -b /shared/bundles/example example
+  $ apptainer oci mount /cvmfs/unpacked.cern.ch/registry.hub.docker.com/library/alpine:latest /var/lib/apptainer/bundles/alpine`
 
 	OciUmountUse   string = `umount <bundle_path>`
 	OciUmountShort string = `Umount delete bundle (root user only)`
@@ -1155,6 +1708,190 @@ Enterprise Performance Computing (EPC)`
   To create an EXT3 writable overlay image for use with --fakeroot actions:
   $ apptainer overlay create --fakeroot --size 1024 /tmp/my_overlay.img`
 
+	CommitUse   string = `commit <base image> <overlay> <new image>`
+	CommitShort string = `Save the changes recorded in an overlay into a new image`
+	CommitLong  string = `
+  The commit command creates a new SIF image from a base SIF image and the changes
+  made to it in an overlay directory, given with --overlay to the action commands,
+  or in a stopped OCI bundle created from the base image with 'oci mount'.
+
+  The changes are added to the new image as a read-only squashfs layer, stacked
+  over the root filesystem of the base image when the new image runs, so that
+  the modifications made interactively in a container can be persisted. Running
+  commit again on the new image adds another layer. EXT3 overlay images are not
+  supported, and the signatures of the base image are not kept in the new image.`
+	CommitExample string = `
+  To persist packages installed in a container:
+  $ mkdir /tmp/my_overlay
+  $ apptainer exec --fakeroot --overlay /tmp/my_overlay image.sif apt-get install -y vim
+  $ apptainer commit image.sif /tmp/my_overlay image-vim.sif`
+
+	ExportUse   string = `export [export options...] <image|instance://name> [file|-]`
+	ExportShort string = `Export the filesystem of a container as a tarball`
+	ExportLong  string = `
+  The export command writes a tarball of the root filesystem of a SIF, squashfs
+  or sandbox image, or of a running instance, to a file or to the standard
+  output when no file or '-' is given. The filesystem of an instance includes
+  the changes made by the running container, but not the paths mounted in it
+  like /proc, /sys or the bind paths.
+
+  With --oci-layer, the tarball is gzip compressed as an OCI image layer, and
+  the digest of the layer and its uncompressed diff ID are reported.`
+	ExportExample string = `
+  $ apptainer export image.sif fs.tar
+  $ apptainer export instance://mysql > fs.tar
+  $ apptainer export --oci-layer image.sif layer.tar.gz`
+
+	SaveUse   string = `save [save options...] <image|instance://name>`
+	SaveShort string = `Save an image to a docker-archive or oci-archive file`
+	SaveLong  string = `
+  The save command writes the root filesystem of a SIF, squashfs or sandbox
+  image, or of a running instance, as a single layer OCI image to an archive
+  which can be loaded by Docker or Podman without registry access.
+
+  The image configuration is synthesized from the image metadata: the labels
+  are kept, and the entrypoint runs the image runscript with the image
+  environment.
+
+  The configuration can be overridden like with the convert command, with the
+  --config, --entrypoint, --env and --label options.
+
+  The supported formats are:
+    docker-archive: archive loaded by 'docker load' and 'podman load' (default)
+    oci-archive:    tar archive of an OCI image layout`
+	SaveExample string = `
+  $ apptainer save -o image.tar image.sif
+  $ docker load -i image.tar
+
+  $ apptainer save --format oci-archive --tag myimage:1.0 -o image.oci.tar image.sif
+  $ podman load -i image.oci.tar`
+
+	ConvertUse   string = `convert <source image> <destination image>`
+	ConvertShort string = `Convert between native SIF and OCI-SIF images`
+	ConvertLong  string = `
+  The convert command converts a native SIF image, or a sandbox, to an OCI-SIF
+  image, a SIF image holding a single layer OCI image, and an OCI-SIF image to
+  a native SIF image. The direction of the conversion is given by the source
+  image format.
+
+  The OCI image configuration of an OCI-SIF image is synthesized from the native
+  image metadata: the labels are kept, and the entrypoint runs the image
+  runscript with the image environment. A native SIF image is built from an
+  OCI-SIF image like from any other OCI image.
+
+  A sandbox directory or a standalone squashfs image can also be converted to an
+  OCI-SIF image. The synthesized configuration can be overridden with the
+  execution parameters of an OCI image configuration read from a JSON file with
+  --config, then with the --entrypoint, --env and --label options.`
+	ConvertExample string = `
+  $ apptainer convert image.sif image.oci.sif
+  $ apptainer convert image.oci.sif image.sif
+
+  $ apptainer convert --entrypoint '["/usr/bin/python3", "-m", "http.server"]' \
+      --env PORT=8000 --label maintainer=me ./sandbox/ server.oci.sif
+  $ apptainer convert --config config.json rootfs.sqfs image.oci.sif`
+
+	SquashUse   string = `squash [squash options...] <image.oci.sif>`
+	SquashShort string = `Flatten the layers of an OCI-SIF image into a single layer`
+	SquashLong  string = `
+  The squash command replaces, in place, the layers of an OCI-SIF image with a
+  single layer holding the flattened filesystem of the image. The provenance
+  of the layers and their history are lost, in exchange for smaller metadata
+  and faster mounts.
+
+  An image which already has a single layer is left unchanged, unless a
+  compression level is given with --compression-level to recompress it.`
+	SquashExample string = `
+  $ apptainer squash image.oci.sif
+  $ apptainer squash --compression-level 9 image.oci.sif`
+
+	BenchUse   string = `bench [bench options...] <image path> [exec options...]`
+	BenchShort string = `Measure the overhead of running workloads in a container`
+	BenchLong  string = `
+  The bench command runs standard workloads natively and in the container
+  image, and reports the overhead of the container for each of them:
+
+    startup latency   the time to run a command
+    fork/exec rate    the number of processes executed per second
+    bind write/read   the throughput of a file in a directory bind mounted
+                      from the host
+    overlay write/read
+                      the throughput of a file in an overlay directory
+
+  Each workload is run several times and its median is reported, the startup
+  latency being subtracted from the other workloads. The image must provide
+  /bin/sh, mkdir and dd.
+
+  The options following the image are passed to 'apptainer exec' running the
+  workloads in the container, so that the overhead of launcher configurations,
+  such as --userns, --fakeroot or --writable-tmpfs, can be compared.`
+	BenchExample string = `
+  $ apptainer bench ubuntu.sif
+  $ apptainer bench --iterations 10 ubuntu.sif --userns
+  $ apptainer bench --io-size 256 ubuntu.sif --fakeroot`
+
+	WarmUse   string = `warm <subcommand>`
+	WarmShort string = `Keep images mounted to speed up the start of their containers`
+	WarmLong  string = `
+  The warm command keeps SIF images mounted by per-user helper processes, so
+  that launching a container from a warm image only sets up its namespaces
+  and starts its process, instead of mounting the image each time. This
+  amortizes the startup cost of workloads launching the same image many
+  times.
+
+  A warm image is used by the containers run in a user namespace, with
+  --userns, --fakeroot or an unprivileged installation, and without
+  --writable. The image must have a squashfs root filesystem, be unencrypted
+  and have no overlay partition, and squashfuse must be installed. A warm
+  image modified after it was mounted is not used anymore, and its helper
+  exits.`
+	WarmExample string = `
+  All group commands have their own help output:
+
+  $ apptainer help warm start
+  $ apptainer warm start --help`
+
+	WarmStartUse   string = `start [start options...] <image path>`
+	WarmStartShort string = `Keep an image mounted`
+	WarmStartLong  string = `
+  The warm start command starts a helper process keeping the image mounted,
+  until it's stopped or unused for the idle timeout.`
+	WarmStartExample string = `
+  $ apptainer warm start ubuntu.sif
+  $ apptainer warm start --idle-timeout 0 ubuntu.sif`
+
+	WarmStopUse   string = `stop <image path>`
+	WarmStopShort string = `Unmount a warm image`
+	WarmStopLong  string = `
+  The warm stop command stops the helper process keeping the image mounted.
+  Running containers keep using the mounted image until they exit.`
+	WarmStopExample string = `
+  $ apptainer warm stop ubuntu.sif`
+
+	WarmListUse   string = `list`
+	WarmListShort string = `List the warm images of the current user`
+	WarmListLong  string = `
+  The warm list command lists the images kept mounted by the helper
+  processes of the current user, with their idle timeout and last use.`
+	WarmListExample string = `
+  $ apptainer warm list`
+
+	CleanupUse   string = `cleanup`
+	CleanupShort string = `Complete the deferred teardowns of containers and OCI bundles`
+	CleanupLong  string = `
+  The cleanup command completes the teardowns which couldn't be completed when
+  a container exited, because of busy mounts, or because the container crashed
+  or was killed: the temporary sandboxes of extracted images, and the OCI
+  bundles of 'apptainer oci umount' with busy mounts, are removed.
+
+  The pending teardowns are also processed by the next container launched, or
+  by the next 'apptainer oci mount' or 'apptainer oci umount' for OCI bundles,
+  so this command is typically run at the end of batch jobs. A teardown is
+  only processed by the user who queued it.`
+	CleanupExample string = `
+  $ apptainer cleanup
+  $ sudo apptainer cleanup`
+
 	CheckpointUse   string = `checkpoint`
 	CheckpointShort string = `Manage container checkpoint state (experimental)`
 	CheckpointLong  string = `
@@ -1199,6 +1936,27 @@ Enterprise Performance Computing (EPC)`
 	CheckpointInstanceExample string = `
   To checkpoint an instance:
   $ apptainer checkpoint instance example-instance`
+
+	CheckpointPushUse   string = `push <name> oras://<registry>/<repository>[:<tag>]`
+	CheckpointPushShort string = `Push a checkpoint to an OCI registry (experimental)`
+	CheckpointPushLong  string = `
+  The checkpoint push command archives the state of a checkpoint and pushes it to an
+  OCI registry as an ORAS artifact, so that it can be pulled and restored on another
+  host with the checkpoint pull command.`
+	CheckpointPushExample string = `
+  To push a checkpoint:
+  $ apptainer checkpoint push example-checkpoint oras://registry.example.com/checkpoints/example:v1`
+
+	CheckpointPullUse   string = `pull oras://<registry>/<repository>[:<tag>] [name]`
+	CheckpointPullShort string = `Pull a checkpoint from an OCI registry (experimental)`
+	CheckpointPullLong  string = `
+  The checkpoint pull command pulls a checkpoint pushed with the checkpoint push command
+  and restores it locally, under the given name or the name of the repository. The
+  checkpoint can then be used to restart an instance with --dmtcp-restart.`
+	CheckpointPullExample string = `
+  To pull a checkpoint and restart an instance from it:
+  $ apptainer checkpoint pull oras://registry.example.com/checkpoints/example:v1 example-checkpoint
+  $ apptainer instance start --dmtcp-restart example-checkpoint image.sif example-instance`
 )
 
 // Documentation for sif/siftool command.
@@ -61,10 +61,19 @@ const (
 	KeyserverLoginUse   string = `login [login options...] <keyserver>`
 	KeyserverLoginShort string = `Login to a keyserver`
 	KeyserverLoginLong  string = `
-  The 'keyserver login' command allows you to login to a specific keyserver.`
+  The 'keyserver login' command allows you to login to a specific keyserver.
+
+  With --oidc, you log in with the single sign-on of the OIDC provider set
+  with --oidc-issuer and --oidc-client-id, authorizing the login in a
+  browser, possibly on another machine. The access token is refreshed
+  transparently until you log out.`
 	KeyserverLoginExample string = `
   To login in to a keyserver:
-  $ apptainer keyserver login --username foo https://mykeyserver.example.com`
+  $ apptainer keyserver login --username foo https://mykeyserver.example.com
+
+  To login in to a keyserver with single sign-on:
+  $ apptainer keyserver login --oidc --oidc-issuer https://sso.example.com \
+      --oidc-client-id apptainer https://mykeyserver.example.com`
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// keyserver logout command
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
@@ -100,10 +100,20 @@ const (
   endpoint.
 
   If no endpoint or registry is specified, the command will login to the currently
-  active remote endpoint.`
+  active remote endpoint.
+
+  With --oidc, you log in with the single sign-on of the OIDC provider
+  advertised by the endpoint, or set with --oidc-issuer and --oidc-client-id,
+  authorizing the login in a browser, possibly on another machine. Instead of
+  a long-lived token, an access token is stored with its refresh token in
+  ~/.apptainer/oauth-tokens.json, and refreshed transparently until you log
+  out.`
 	RemoteLoginExample string = `
   To log in to an endpoint:
-  $ apptainer remote login SylabsCloud`
+  $ apptainer remote login SylabsCloud
+
+  To log in to an endpoint with single sign-on:
+  $ apptainer remote login --oidc MyRemote`
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
 	// remote logout command
 	// ~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~
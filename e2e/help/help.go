@@ -36,6 +36,7 @@ var helpOciContentTests = []struct {
 	{"HelpOci", []string{"oci"}},
 	{"HelpOciAttach", []string{"oci", "attach"}},
 	{"HelpOciCreate", []string{"oci", "create"}},
+	{"HelpOciDebug", []string{"oci", "debug"}},
 	{"HelpOciDelete", []string{"oci", "delete"}},
 	{"HelpOciExec", []string{"oci", "exec"}},
 	{"HelpOciKill", []string{"oci", "kill"}},
@@ -45,6 +46,7 @@ var helpOciContentTests = []struct {
 	{"HelpOciRun", []string{"oci", "run"}},
 	{"HelpOciStart", []string{"oci", "start"}},
 	{"HelpOciState", []string{"oci", "state"}},
+	{"HelpOciTop", []string{"oci", "top"}},
 	{"HelpOciUmount", []string{"oci", "umount"}},
 	{"HelpOciUpdate", []string{"oci", "update"}},
 }
@@ -90,6 +92,7 @@ func (c ctx) testCommands(t *testing.T) {
 		{"OCI", "oci"},
 		{"Plugin", "plugin"},
 		{"Inspect", "inspect"},
+		{"Prefetch", "prefetch"},
 		{"Pull", "pull"},
 		{"Push", "push"},
 		{"Run", "run"},
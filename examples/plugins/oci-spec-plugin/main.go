@@ -0,0 +1,43 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package main
+
+import (
+	pluginapi "github.com/apptainer/apptainer/pkg/plugin"
+	clicallback "github.com/apptainer/apptainer/pkg/plugin/callback/cli"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Plugin is the only variable which a plugin MUST export.
+// This symbol is accessed by the plugin framework to initialize the plugin
+var Plugin = pluginapi.Plugin{
+	Manifest: pluginapi.Manifest{
+		Name:        "example.com/oci-spec-plugin",
+		Author:      "Apptainer Team",
+		Version:     "0.1.0",
+		Description: "This is a short example OCI specification plugin for Apptainer",
+	},
+	Callbacks: []pluginapi.Callback{
+		(clicallback.OCISpec)(callbackSpec),
+	},
+}
+
+// callbackSpec points the containers to the site license server, and
+// provides them a scratch directory.
+func callbackSpec(containerID string, spec *specs.Spec) error {
+	sylog.Infof("Adding site configuration to container %s", containerID)
+	if spec.Process != nil {
+		spec.Process.Env = append(spec.Process.Env, "LM_LICENSE_FILE=27000@license.example.com")
+	}
+	spec.Mounts = append(spec.Mounts, specs.Mount{
+		Destination: "/scratch",
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     []string{"nosuid", "nodev", "mode=1777"},
+	})
+	return nil
+}
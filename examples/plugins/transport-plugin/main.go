@@ -0,0 +1,52 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	pluginapi "github.com/apptainer/apptainer/pkg/plugin"
+	clicallback "github.com/apptainer/apptainer/pkg/plugin/callback/cli"
+)
+
+// storeDir is the directory of the site image store.
+const storeDir = "/srv/images"
+
+// Plugin is the only variable which a plugin MUST export.
+// This symbol is accessed by the plugin framework to initialize the plugin
+var Plugin = pluginapi.Plugin{
+	Manifest: pluginapi.Manifest{
+		Name:        "example.com/transport-plugin",
+		Author:      "Apptainer Team",
+		Version:     "0.1.0",
+		Description: "This is a short example image transport plugin for Apptainer",
+	},
+	Callbacks: []pluginapi.Callback{
+		(clicallback.ImageTransport)(callbackStore),
+	},
+}
+
+// callbackStore handles the store://<name> URIs, referencing the images
+// of the site image store.
+func callbackStore(_ context.Context, _ *cache.Handle, transport, imageURI string) (string, bool, error) {
+	if transport != "store" {
+		return "", false, nil
+	}
+	name := strings.TrimPrefix(imageURI, "store://")
+	if name == "" || strings.Contains(name, "/") {
+		return "", true, fmt.Errorf("invalid image name %q", name)
+	}
+	image := filepath.Join(storeDir, name+".sif")
+	if _, err := os.Stat(image); err != nil {
+		return "", true, err
+	}
+	return image, true, nil
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+const (
+	// benchDir is the directory of the I/O workloads in the container, bind
+	// mounted from the host.
+	benchDir = "/apptainer-bench"
+	// benchOverlayDir is the directory of the I/O workloads created in the
+	// overlay of the container.
+	benchOverlayDir = "/apptainer-bench-overlay"
+	// benchForks is the number of processes executed by the fork/exec
+	// workload.
+	benchForks = 500
+)
+
+// BenchOptions are the options of Bench.
+type BenchOptions struct {
+	// Iterations is the number of runs of each workload, whose median
+	// duration is reported.
+	Iterations int
+	// IOSize is the size, in MiB, of the file written and read by the I/O
+	// workloads.
+	IOSize int
+	// ExecOptions are the options of 'apptainer exec' running the workloads
+	// in the container, e.g. --userns or --fakeroot, to compare launcher
+	// configurations.
+	ExecOptions []string
+	// TmpDir is the directory holding the files of the I/O workloads.
+	TmpDir string
+}
+
+// BenchResult is the result of a workload run natively and in the
+// container.
+type BenchResult struct {
+	// Workload is the name of the workload.
+	Workload string
+	// Unit is the unit of the native and container values.
+	Unit string
+	// HigherIsBetter is true for rates, false for durations.
+	HigherIsBetter bool
+	// Native is the value measured natively.
+	Native float64
+	// Container is the value measured in the container.
+	Container float64
+	// Err is the error of a workload which failed.
+	Err error
+}
+
+// Delta returns the relative difference of the container value, in percent
+// of the native value, positive when the container is slower.
+func (r BenchResult) Delta() float64 {
+	if r.Native == 0 {
+		return 0
+	}
+	d := (r.Container - r.Native) / r.Native * 100
+	if r.HigherIsBetter {
+		return -d
+	}
+	return d
+}
+
+// benchRunner runs a shell script natively, or in the container with
+// additional 'apptainer exec' options, and returns its duration.
+type benchRunner func(ctx context.Context, script string, opts ...string) (time.Duration, error)
+
+// benchWorkload is a workload measured natively and in the container.
+type benchWorkload struct {
+	name           string
+	unit           string
+	higherIsBetter bool
+	// script returns the shell script of the workload, with its files in
+	// dir.
+	script func(dir string) string
+	// overlay runs the workload in the container with an overlay, holding
+	// its files, instead of a bind mount.
+	overlay bool
+	// value returns the measured value from the duration of the script,
+	// startup excluded.
+	value func(d time.Duration) float64
+}
+
+// Bench runs standard workloads natively and in the container image, and
+// returns their results: the startup latency, the fork/exec rate and the
+// throughput of the I/O through a bind mount and through an overlay.
+func Bench(ctx context.Context, image string, opts BenchOptions) ([]BenchResult, error) {
+	if opts.Iterations < 1 {
+		return nil, fmt.Errorf("the number of iterations must be positive")
+	}
+	if opts.IOSize < 1 {
+		return nil, fmt.Errorf("the I/O size must be positive")
+	}
+	image, err := filepath.Abs(image)
+	if err != nil {
+		return nil, fmt.Errorf("while determining absolute path for %s: %w", image, err)
+	}
+
+	tmp, err := os.MkdirTemp(opts.TmpDir, "bench-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	dataDir := filepath.Join(tmp, "data")
+	overlayDir := filepath.Join(tmp, "overlay")
+	for _, d := range []string{dataDir, overlayDir} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	native := nativeRunner()
+	container := containerRunner(image, opts.ExecOptions, dataDir)
+
+	sylog.Infof("Measuring startup latency")
+	nativeStart, err := median(ctx, native, opts.Iterations, "true")
+	if err != nil {
+		return nil, fmt.Errorf("while running natively: %w", err)
+	}
+	containerStart, err := median(ctx, container, opts.Iterations, "true")
+	if err != nil {
+		return nil, fmt.Errorf("while running in the container: %w", err)
+	}
+	results := []BenchResult{{
+		Workload:  "startup latency",
+		Unit:      "ms",
+		Native:    milliseconds(nativeStart),
+		Container: milliseconds(containerStart),
+	}}
+
+	size := float64(opts.IOSize)
+	mibPerSecond := func(d time.Duration) float64 { return size / d.Seconds() }
+	write := func(dir string) string {
+		return fmt.Sprintf("mkdir -p %[1]s && dd if=/dev/zero of=%[1]s/bench bs=1M count=%d conv=fsync 2>/dev/null", dir, opts.IOSize)
+	}
+	read := func(dir string) string {
+		return fmt.Sprintf("dd if=%s/bench of=/dev/null bs=1M 2>/dev/null", dir)
+	}
+	fork := func(string) string {
+		return fmt.Sprintf("i=0; while [ $i -lt %d ]; do /bin/sh -c :; i=$((i+1)); done", benchForks)
+	}
+	workloads := []benchWorkload{
+		{
+			name:           "fork/exec rate",
+			unit:           "exec/s",
+			higherIsBetter: true,
+			script:         fork,
+			value:          func(d time.Duration) float64 { return benchForks / d.Seconds() },
+		},
+		{
+			name:           "bind write",
+			unit:           "MiB/s",
+			higherIsBetter: true,
+			script:         write,
+			value:          mibPerSecond,
+		},
+		{
+			name:           "bind read",
+			unit:           "MiB/s",
+			higherIsBetter: true,
+			script:         read,
+			value:          mibPerSecond,
+		},
+		{
+			name:           "overlay write",
+			unit:           "MiB/s",
+			higherIsBetter: true,
+			script:         write,
+			overlay:        true,
+			value:          mibPerSecond,
+		},
+		{
+			name:           "overlay read",
+			unit:           "MiB/s",
+			higherIsBetter: true,
+			script:         read,
+			overlay:        true,
+			value:          mibPerSecond,
+		},
+	}
+
+	for _, w := range workloads {
+		sylog.Infof("Measuring %s", w.name)
+		r := BenchResult{Workload: w.name, Unit: w.unit, HigherIsBetter: w.higherIsBetter}
+		d, err := median(ctx, native, opts.Iterations, w.script(dataDir))
+		if err != nil {
+			return nil, fmt.Errorf("while running %s natively: %w", w.name, err)
+		}
+		r.Native = w.value(max(d-nativeStart, time.Microsecond))
+		script, execOpts := w.script(benchDir), []string(nil)
+		if w.overlay {
+			script, execOpts = w.script(benchOverlayDir), []string{"--overlay", overlayDir}
+		}
+		d, err = median(ctx, container, opts.Iterations, script, execOpts...)
+		if err != nil {
+			r.Err = err
+		} else {
+			r.Container = w.value(max(d-containerStart, time.Microsecond))
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// nativeRunner returns a runner of the scripts on the host.
+func nativeRunner() benchRunner {
+	return func(ctx context.Context, script string, _ ...string) (time.Duration, error) {
+		return timeCommand(exec.CommandContext(ctx, "/bin/sh", "-c", script))
+	}
+}
+
+// containerRunner returns a runner of the scripts in image, executed with
+// the options execOpts, with the I/O workloads in dir bind mounted in the
+// container.
+func containerRunner(image string, execOpts []string, dir string) benchRunner {
+	return func(ctx context.Context, script string, opts ...string) (time.Duration, error) {
+		args := []string{"exec", "--bind", dir + ":" + benchDir}
+		args = append(args, execOpts...)
+		args = append(args, opts...)
+		args = append(args, image, "/bin/sh", "-c", script)
+		cmd := exec.CommandContext(ctx, filepath.Join(buildcfg.BINDIR, "apptainer"), args...)
+		return timeCommand(cmd)
+	}
+}
+
+// timeCommand runs cmd and returns its duration.
+func timeCommand(cmd *exec.Cmd) (time.Duration, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("%s: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return time.Since(start), nil
+}
+
+// median runs script n times and returns its median duration.
+func median(ctx context.Context, run benchRunner, n int, script string, opts ...string) (time.Duration, error) {
+	durations := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		d, err := run(ctx, script, opts...)
+		if err != nil {
+			return 0, err
+		}
+		durations = append(durations, d)
+	}
+	slices.Sort(durations)
+	return durations[n/2], nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
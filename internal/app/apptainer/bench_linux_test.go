@@ -0,0 +1,51 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBenchResultDelta(t *testing.T) {
+	tests := []struct {
+		name   string
+		result BenchResult
+		expect float64
+	}{
+		{"slower startup", BenchResult{Native: 10, Container: 15}, 50},
+		{"faster startup", BenchResult{Native: 10, Container: 5}, -50},
+		{"lower rate", BenchResult{Native: 100, Container: 75, HigherIsBetter: true}, 25},
+		{"no native value", BenchResult{Container: 10}, 0},
+	}
+	for _, tt := range tests {
+		if d := tt.result.Delta(); d != tt.expect {
+			t.Errorf("%s: got %v, expected %v", tt.name, d, tt.expect)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	durations := []time.Duration{3, 1, 2}
+	i := 0
+	run := func(context.Context, string, ...string) (time.Duration, error) {
+		d := durations[i]
+		i++
+		return d, nil
+	}
+	d, err := median(context.Background(), run, len(durations), "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if d != 2 {
+		t.Errorf("got median %d, expected 2", d)
+	}
+
+	if _, err := median(context.Background(), nativeRunner(), 1, "exit 1"); err == nil {
+		t.Errorf("unexpected success of a failing script")
+	}
+}
@@ -41,7 +41,7 @@ func CleanApptainerCache(imgCache *cache.Handle, dryRun bool, cacheCleanTypes []
 	}
 
 	// Default is all caches
-	cachesToClean := append(cache.OciCacheTypes, cache.FileCacheTypes...)
+	cachesToClean := append(append(cache.OciCacheTypes, cache.FileCacheTypes...), cache.DirCacheTypes...)
 
 	// If specified caches, and we don't have 'all' specified then clean the specified
 	// ones only.
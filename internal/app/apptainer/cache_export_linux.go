@@ -0,0 +1,56 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// ExportApptainerCache exports the entries of the given cache types to dest,
+// as a tar archive, or as a directory with the layout of the cache if asDir
+// is true. A dest of "-" writes the tar archive to standard output. The
+// special type "all" is interpreted as all types of entries.
+func ExportApptainerCache(imgCache *cache.Handle, dest string, cacheTypes []string, asDir bool) error {
+	if imgCache == nil {
+		return errInvalidCacheHandle
+	}
+
+	types, err := cache.TransferTypes(cacheTypes)
+	if err != nil {
+		return err
+	}
+
+	var n int
+	switch {
+	case asDir:
+		if dest == "-" {
+			return fmt.Errorf("a directory is required to export the cache with --dir")
+		}
+		n, err = imgCache.ExportDir(dest, types)
+	case dest == "-":
+		n, err = imgCache.ExportTar(os.Stdout, types)
+	default:
+		var f *os.File
+		f, err = os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		n, err = imgCache.ExportTar(f, types)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	sylog.Infof("Exported %d cache entries", n)
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"os"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// ImportApptainerCache imports the cache entries exported to src, either a
+// tar archive or a directory. A src of "-" reads the tar archive from
+// standard input. Entries already in the cache are kept.
+func ImportApptainerCache(imgCache *cache.Handle, src string) error {
+	if imgCache == nil {
+		return errInvalidCacheHandle
+	}
+
+	var (
+		n   int
+		err error
+	)
+	switch {
+	case src == "-":
+		n, err = imgCache.ImportTar(os.Stdin)
+	case fs.IsDir(src):
+		n, err = imgCache.ImportDir(src)
+	default:
+		var f *os.File
+		f, err = os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		n, err = imgCache.ImportTar(f)
+	}
+	if err != nil {
+		return err
+	}
+
+	sylog.Infof("Imported %d cache entries", n)
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// VerifyApptainerCache verifies the entries of the given cache types,
+// reporting the corrupted ones. The special type "all" is interpreted as all
+// types of entries. If repair is true, corrupted entries are removed, and
+// the images recorded as pulled into them are pulled again with pull when
+// it's not nil. An error is returned if corrupted entries remain.
+func VerifyApptainerCache(imgCache *cache.Handle, cacheTypes []string, repair bool, pull func(*cache.ImageRecord) error) error {
+	if imgCache == nil {
+		return errInvalidCacheHandle
+	}
+
+	types, err := cache.TransferTypes(cacheTypes)
+	if err != nil {
+		return err
+	}
+	n, corrupt, err := imgCache.Verify(types)
+	if err != nil {
+		return err
+	}
+	for _, c := range corrupt {
+		sylog.Warningf("Corrupted %s cache entry %s: %s", c.Type, c.Name, c.Reason)
+	}
+	sylog.Infof("Verified %d cache entries, %d corrupted", n, len(corrupt))
+	if len(corrupt) == 0 {
+		return nil
+	}
+	if !repair {
+		return fmt.Errorf("found %d corrupted cache entries, use --repair to remove them", len(corrupt))
+	}
+
+	records, err := imgCache.ImageRecords()
+	if err != nil {
+		return fmt.Errorf("while reading image records: %v", err)
+	}
+
+	failed := 0
+	for _, c := range corrupt {
+		if err := imgCache.RemoveEntry(c); err != nil {
+			sylog.Errorf("Unable to remove %s: %v", c.Path, err)
+			failed++
+			continue
+		}
+		r := pulledInto(records, c)
+		if r == nil || pull == nil {
+			sylog.Infof("Removed %s cache entry %s, it will be downloaded again when next used", c.Type, c.Name)
+			continue
+		}
+		sylog.Infof("Pulling %s again into the cache", r.URI)
+		if err := pull(r); err != nil {
+			sylog.Errorf("Unable to pull %s again: %v", r.URI, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to repair %d cache entries", failed)
+	}
+	return nil
+}
+
+// pulledInto returns the record of the image pulled into the cache entry c,
+// or nil when there is none.
+func pulledInto(records []*cache.ImageRecord, c cache.CorruptEntry) *cache.ImageRecord {
+	if c.Type != cache.OciTempCacheType && c.Type != cache.OrasCacheType {
+		return nil
+	}
+	for _, r := range records {
+		if r.Path == "" && r.Digest == c.Name {
+			return r
+		}
+	}
+	return nil
+}
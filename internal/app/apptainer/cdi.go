@@ -0,0 +1,75 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apptainer/apptainer/internal/pkg/cdi"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// CDIList lists to w the CDI devices described by the spec files in dirs,
+// warning about the invalid spec files, which are ignored.
+func CDIList(w io.Writer, dirs []string) error {
+	registry := cdi.Scan(dirs)
+	for _, err := range registry.Errors {
+		sylog.Warningf("Ignoring invalid CDI spec: %s", err)
+	}
+
+	names := registry.Names()
+	if len(names) == 0 {
+		sylog.Infof("No CDI devices found in %v", dirs)
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 4, ' ', 0)
+	fmt.Fprintln(tw, "DEVICE\tSPEC")
+	for _, name := range names {
+		d, err := registry.Device(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, d.Spec.Path)
+	}
+	return tw.Flush()
+}
+
+// CDIValidate validates the CDI spec files, or all the spec files in dirs
+// when none is given, reporting the invalid and conflicting ones. An error
+// is returned if any is invalid.
+func CDIValidate(dirs []string, files []string) error {
+	var errs []error
+	n := 0
+	if len(files) > 0 {
+		for _, f := range files {
+			n++
+			if _, err := cdi.LoadSpec(f); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	} else {
+		for _, dir := range dirs {
+			dirFiles, err := cdi.SpecFiles(dir)
+			if err != nil {
+				return err
+			}
+			n += len(dirFiles)
+		}
+		errs = cdi.Scan(dirs).Errors
+	}
+
+	for _, err := range errs {
+		sylog.Errorf("%s", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d errors in %d CDI spec files", len(errs), n)
+	}
+	sylog.Infof("Validated %d CDI spec files", n)
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/teardown"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// Cleanup completes the teardowns queued by the containers and OCI bundles
+// of the current user which couldn't be torn down when they exited.
+func Cleanup() error {
+	done, pending, err := teardown.Process()
+	if err != nil {
+		return err
+	}
+	if done == 0 && pending == 0 {
+		sylog.Infof("No deferred teardown")
+		return nil
+	}
+	sylog.Infof("Completed %d deferred teardowns, %d pending", done, pending)
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/internal/pkg/image/packer"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/apptainer/sif/v2/pkg/sif"
+)
+
+// overlayUpperDir returns the directory holding the changes recorded by the
+// overlay at path: the upper directory of a stopped OCI bundle, or of an
+// overlay directory.
+func overlayUpperDir(path string) (string, error) {
+	if !fs.IsDir(path) {
+		return "", fmt.Errorf("%s is not an overlay directory or an OCI bundle, EXT3 overlay images can't be committed", path)
+	}
+	for _, upper := range []string{
+		filepath.Join(path, "overlay", "upper"),
+		filepath.Join(path, "upper"),
+	} {
+		if fs.IsDir(upper) {
+			return upper, nil
+		}
+	}
+	return path, nil
+}
+
+// Commit creates the SIF image dest from the SIF image base and the changes
+// recorded in overlay, an overlay directory or a stopped OCI bundle created
+// from base. The changes are added as a squashfs overlay partition, stacked
+// over the root filesystem of base when the image runs. As the signatures of
+// base don't cover the new partition, they are not kept.
+func Commit(base, overlay, dest string) error {
+	upper, err := overlayUpperDir(overlay)
+	if err != nil {
+		return err
+	}
+
+	img, err := image.Init(base, false)
+	if err != nil {
+		return fmt.Errorf("while opening image %s: %w", base, err)
+	}
+	defer img.File.Close()
+	if img.Type != image.SIF {
+		return fmt.Errorf("base image %s must be a SIF image", base)
+	}
+	part, err := img.GetRootFsPartition()
+	if err != nil {
+		return fmt.Errorf("while getting root FS partition: %w", err)
+	}
+	if part.Type != image.SQUASHFS {
+		return fmt.Errorf("root FS partition of %s must be an unencrypted squashfs partition", base)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(dest), ".commit-layer-")
+	if err != nil {
+		return fmt.Errorf("while creating temporary file for squashfs: %w", err)
+	}
+	layer := f.Name()
+	f.Close()
+	defer os.Remove(layer)
+
+	sylog.Infof("Creating layer from %s", upper)
+	flags := []string{"-noappend"}
+	if os.Getuid() != 0 {
+		flags = append(flags, "-all-root")
+	}
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		if cfg.MksquashfsMem != "" {
+			flags = append(flags, "-mem", cfg.MksquashfsMem)
+		}
+		if cfg.MksquashfsProcs != 0 {
+			flags = append(flags, "-processors", fmt.Sprint(cfg.MksquashfsProcs))
+		}
+	}
+	if err := packer.NewSquashfs().Create([]string{upper}, layer, flags); err != nil {
+		return fmt.Errorf("while creating squashfs layer: %w", err)
+	}
+
+	if err := fs.CopyFile(base, dest, 0o755); err != nil {
+		return err
+	}
+	if err := addLayerToImage(dest, layer); err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("while adding layer to %s: %w", dest, err)
+	}
+	return nil
+}
+
+// addLayerToImage adds the squashfs file at layerPath to the SIF image at
+// imagePath, as an overlay partition of the root filesystem group.
+func addLayerToImage(imagePath, layerPath string) error {
+	f, err := sif.LoadContainerFromPath(imagePath)
+	if err != nil {
+		return err
+	}
+	defer f.UnloadContainer()
+
+	rootfs, err := f.GetDescriptor(sif.WithPartitionType(sif.PartPrimSys))
+	if err != nil {
+		return err
+	}
+
+	sigs, err := f.GetDescriptors(sif.WithDataType(sif.DataSignature))
+	if err != nil {
+		return err
+	}
+	if len(sigs) > 0 {
+		if err := f.DeleteObjects(sif.WithDataType(sif.DataSignature)); err != nil {
+			return err
+		}
+		sylog.Warningf("Signatures of the base image were removed, %s should be signed again", imagePath)
+	}
+
+	lf, err := os.Open(layerPath)
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+
+	_, _, arch, err := rootfs.PartitionMetadata()
+	if err != nil {
+		return err
+	}
+	di, err := sif.NewDescriptorInput(sif.DataPartition, lf,
+		sif.OptPartitionMetadata(sif.FsSquash, sif.PartOverlay, arch),
+		sif.OptGroupID(rootfs.GroupID()),
+	)
+	if err != nil {
+		return err
+	}
+	return f.AddObject(di)
+}
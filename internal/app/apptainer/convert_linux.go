@@ -0,0 +1,200 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	"github.com/google/go-containerregistry/pkg/name"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// IsOCISIF returns whether the image at path is an OCI-SIF image, a SIF
+// image holding an OCI image layout.
+func IsOCISIF(path string) bool {
+	f, err := sif.LoadContainerFromPath(path, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		return false
+	}
+	defer f.UnloadContainer()
+	_, err = f.GetDescriptor(sif.WithDataType(sif.DataOCIRootIndex))
+	return err == nil
+}
+
+// ConvertOptions are the options of Convert.
+type ConvertOptions struct {
+	// TmpDir is the directory holding the temporary files.
+	TmpDir string
+	// Config overrides the image configuration synthesized when converting
+	// to an OCI-SIF image.
+	Config ImageConfigOptions
+}
+
+// Convert converts the native SIF image source, or a sandbox or squashfs
+// image, to the OCI-SIF image dest, or the OCI-SIF image source to the native
+// SIF image dest. The OCI image configuration of a native image is
+// synthesized from its metadata like with Save, and a native image is built
+// from an OCI-SIF image like from any OCI image.
+func Convert(source, dest string, opts ConvertOptions) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+
+	dir, err := os.MkdirTemp(opts.TmpDir, "convert-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if IsOCISIF(source) {
+		if !opts.Config.empty() {
+			return fmt.Errorf("image configuration options are only supported when converting to an OCI-SIF image")
+		}
+		sylog.Infof("Converting OCI-SIF image %s to native SIF image %s", source, dest)
+		if err := sifToLayout(source, dir); err != nil {
+			return fmt.Errorf("while extracting OCI image from %s: %w", source, err)
+		}
+		return buildFromLayout(dir, dest)
+	}
+
+	sylog.Infof("Converting native image %s to OCI-SIF image %s", source, dest)
+	ref, err := name.NewTag(defaultTag(source))
+	if err != nil {
+		return err
+	}
+	img, cleanup, err := ociImage(source, opts.TmpDir, opts.Config)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if err := writeOCILayout(ref, img, dir); err != nil {
+		return fmt.Errorf("while writing OCI image: %w", err)
+	}
+	return layoutToSIF(dir, dest)
+}
+
+// layoutToSIF creates the OCI-SIF image dest from the blobs and the index of
+// the OCI image layout directory dir.
+func layoutToSIF(dir, dest string) error {
+	blobDir := filepath.Join(dir, "blobs", "sha256")
+	entries, err := os.ReadDir(blobDir)
+	if err != nil {
+		return err
+	}
+
+	dis := make([]sif.DescriptorInput, 0, len(entries)+1)
+	for _, e := range entries {
+		f, err := os.Open(filepath.Join(blobDir, e.Name()))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		di, err := sif.NewDescriptorInput(sif.DataOCIBlob, f)
+		if err != nil {
+			return err
+		}
+		dis = append(dis, di)
+	}
+
+	idx, err := os.Open(filepath.Join(dir, "index.json"))
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	di, err := sif.NewDescriptorInput(sif.DataOCIRootIndex, idx)
+	if err != nil {
+		return err
+	}
+	dis = append(dis, di)
+
+	f, err := sif.CreateContainerAtPath(dest, sif.OptCreateWithDescriptors(dis...))
+	if err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("while creating %s: %w", dest, err)
+	}
+	return f.UnloadContainer()
+}
+
+// sifToLayout writes the OCI image held by the OCI-SIF image src to the OCI
+// image layout directory dir.
+func sifToLayout(src, dir string) error {
+	f, err := sif.LoadContainerFromPath(src, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		return err
+	}
+	defer f.UnloadContainer()
+
+	blobDir := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobDir, 0o755); err != nil {
+		return err
+	}
+	blobs, err := f.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return err
+	}
+	for _, d := range blobs {
+		h, err := d.OCIBlobDigest()
+		if err != nil {
+			return err
+		}
+		if h.Algorithm != "sha256" {
+			return fmt.Errorf("unsupported blob digest algorithm %s", h.Algorithm)
+		}
+		if err := writeBlob(filepath.Join(blobDir, h.Hex), d.GetReader()); err != nil {
+			return err
+		}
+	}
+
+	root, err := f.GetDescriptor(sif.WithDataType(sif.DataOCIRootIndex))
+	if err != nil {
+		return err
+	}
+	index, err := root.GetData()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), index, 0o644); err != nil {
+		return err
+	}
+	ociLayout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), ociLayout, 0o644)
+}
+
+// writeBlob writes the content of r to the file at path.
+func writeBlob(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// buildFromLayout builds the native SIF image dest from the OCI image layout
+// directory dir.
+func buildFromLayout(dir, dest string) error {
+	cmd := exec.Command(filepath.Join(buildcfg.BINDIR, "apptainer"), "build", dest, "oci:"+dir)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("while building %s: %w", dest, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestLayoutSIFRoundTrip(t *testing.T) {
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag("test:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := writeOCILayout(ref, img, src); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(dir, "image.sif")
+	if err := layoutToSIF(src, image); err != nil {
+		t.Fatalf("layoutToSIF: %v", err)
+	}
+	if !IsOCISIF(image) {
+		t.Fatalf("%s is not an OCI-SIF image", image)
+	}
+	if IsOCISIF(src) {
+		t.Fatalf("%s detected as an OCI-SIF image", src)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := sifToLayout(image, dst); err != nil {
+		t.Fatalf("sifToLayout: %v", err)
+	}
+	for _, f := range []string{"index.json", "blobs/sha256"} {
+		want := readTree(t, filepath.Join(src, f))
+		got := readTree(t, filepath.Join(dst, f))
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d files, want %d", f, len(got), len(want))
+		}
+		for p, b := range want {
+			if !bytes.Equal(got[p], b) {
+				t.Errorf("%s: content of %s differs", f, p)
+			}
+		}
+	}
+}
+
+// readTree returns the content of the file at path, or of the files of the
+// directory at path.
+func readTree(t *testing.T, path string) map[string][]byte {
+	files := make(map[string][]byte)
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		files[filepath.Base(p)] = b
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/image/unpacker"
+	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/build/types"
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+	da "github.com/docker/docker/pkg/archive"
+	"github.com/opencontainers/go-digest"
+)
+
+// ExportOptions are the options of Export.
+type ExportOptions struct {
+	// OCILayer compresses the tarball with gzip, as an OCI layer.
+	OCILayer bool
+	// TmpDir is the directory where images are extracted.
+	TmpDir string
+}
+
+// ExportDigests are the digests of an exported OCI layer.
+type ExportDigests struct {
+	// Digest is the digest of the compressed layer.
+	Digest digest.Digest
+	// DiffID is the digest of the uncompressed layer.
+	DiffID digest.Digest
+}
+
+// Export writes a tarball of the filesystem of source, a running instance
+// (instance://name), a sandbox or a SIF or squashfs image, to w. With the
+// OCILayer option, the tarball is gzip compressed and its digests are returned.
+func Export(source string, w io.Writer, opts ExportOptions) (*ExportDigests, error) {
+	root, excludes, cleanup, err := exportRoot(source, opts.TmpDir)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return writeLayer(root, excludes, w, opts.OCILayer)
+}
+
+// writeLayer writes a tarball of root, without the paths matching excludes,
+// to w. When compress is true, the tarball is gzip compressed and its digests
+// are returned.
+func writeLayer(root string, excludes []string, w io.Writer, compress bool) (*ExportDigests, error) {
+	rc, err := da.TarWithOptions(root, &da.TarOptions{ExcludePatterns: excludes})
+	if err != nil {
+		return nil, fmt.Errorf("while creating tarball of %s: %w", root, err)
+	}
+	defer rc.Close()
+
+	if !compress {
+		if _, err := io.Copy(w, rc); err != nil {
+			return nil, fmt.Errorf("while writing tarball: %w", err)
+		}
+		return nil, nil
+	}
+
+	return compressLayer(rc, w, gzip.DefaultCompression)
+}
+
+// compressLayer writes the tarball read from r to w, gzip compressed with
+// the compression level, and returns its digests.
+func compressLayer(r io.Reader, w io.Writer, level int) (*ExportDigests, error) {
+	diffID := digest.Canonical.Digester()
+	dgst := digest.Canonical.Digester()
+	gz, err := gzip.NewWriterLevel(io.MultiWriter(w, dgst.Hash()), level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.MultiWriter(gz, diffID.Hash()), r); err != nil {
+		return nil, fmt.Errorf("while writing layer: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("while writing layer: %w", err)
+	}
+	return &ExportDigests{Digest: dgst.Digest(), DiffID: diffID.Digest()}, nil
+}
+
+// exportRoot returns the root filesystem directory of source, the patterns
+// of the paths to exclude from the tarball, and a function cleaning up the
+// extracted image if any.
+func exportRoot(source, tmpDir string) (string, []string, func(), error) {
+	noop := func() {}
+
+	if strings.HasPrefix(source, "instance://") {
+		name := instance.ExtractName(source)
+		file, err := instance.Get(name, instance.AppSubDir)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		// the instance mounts, like /proc or the bind paths, are not part
+		// of the container filesystem
+		entries, err := proc.GetMountInfoEntry(fmt.Sprintf("/proc/%d/mountinfo", file.Pid))
+		if err != nil {
+			return "", nil, nil, err
+		}
+		var excludes []string
+		for _, e := range entries {
+			if e.Point != "/" {
+				excludes = append(excludes, strings.TrimPrefix(e.Point, "/"))
+			}
+		}
+		return fmt.Sprintf("/proc/%d/root", file.Pid), excludes, noop, nil
+	}
+
+	if fs.IsDir(source) {
+		return source, nil, noop, nil
+	}
+
+	img, err := image.Init(source, false)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("while opening image %s: %w", source, err)
+	}
+	defer img.File.Close()
+
+	part, err := img.GetRootFsPartition()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("while getting root filesystem in %s: %w", source, err)
+	}
+	if part.Type != image.SQUASHFS {
+		return "", nil, nil, fmt.Errorf("only the unencrypted squashfs root filesystem of an image can be exported")
+	}
+	reader, err := image.NewPartitionReader(img, "", 0)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("could not read root filesystem: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(tmpDir, "export-")
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	cleanup := func() {
+		if err := types.FixPerms(dir); err != nil {
+			sylog.Debugf("FixPerms had a problem: %v", err)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			sylog.Warningf("Unable to remove %s: %s", dir, err)
+		}
+	}
+
+	// extract to an inner directory to keep the permissions of the
+	// temporary directory
+	root := filepath.Join(dir, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		cleanup()
+		return "", nil, nil, err
+	}
+	sylog.Verbosef("Extracting %s to %s", source, root)
+	if err := unpacker.NewSquashfs().ExtractAll(reader, root); err != nil {
+		cleanup()
+		return "", nil, nil, fmt.Errorf("root filesystem extraction failed: %w", err)
+	}
+	return root, nil, cleanup, nil
+}
@@ -0,0 +1,378 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	"github.com/docker/go-units"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Image formats of LocalImage.
+const (
+	FormatSIF    = "sif"
+	FormatOCISIF = "oci-sif"
+)
+
+// imageCacheTypes are the cache types holding SIF images.
+var imageCacheTypes = []string{
+	cache.LibraryCacheType,
+	cache.OciTempCacheType,
+	cache.ShubCacheType,
+	cache.OrasCacheType,
+	cache.NetCacheType,
+	cache.ObjectCacheType,
+	cache.IpfsCacheType,
+}
+
+// LocalImage is a SIF or OCI-SIF image found in the cache, pulled to a file
+// recorded in the cache, or found in a directory registered by the user.
+type LocalImage struct {
+	// Reference is the URI the image was pulled from, or the reference
+	// name of an OCI-SIF image, when known.
+	Reference string `json:"reference,omitempty"`
+	// Path is the path of the image file.
+	Path string `json:"path"`
+	// Format is the format of the image, FormatSIF or FormatOCISIF.
+	Format string `json:"format"`
+	// Digest is the digest of the image, when known.
+	Digest string `json:"digest,omitempty"`
+	// Size is the size of the image file.
+	Size int64 `json:"size"`
+	// Arch is the architecture of the image.
+	Arch string `json:"arch,omitempty"`
+	// Modified is the time the image file was last modified.
+	Modified time.Time `json:"modified"`
+}
+
+// ImageDirs returns the directories registered in the file at path.
+func ImageDirs(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if dir := strings.TrimSpace(scanner.Text()); dir != "" && !strings.HasPrefix(dir, "#") {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, scanner.Err()
+}
+
+// RegisterImageDir registers the directory dir in the file at path.
+func RegisterImageDir(path, dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	dirs, err := ImageDirs(path)
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		if d == dir {
+			return fmt.Errorf("%s is already registered", dir)
+		}
+	}
+	return writeImageDirs(path, append(dirs, dir))
+}
+
+// UnregisterImageDir removes the directory dir from the file at path.
+func UnregisterImageDir(path, dir string) error {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	dirs, err := ImageDirs(path)
+	if err != nil {
+		return err
+	}
+	for i, d := range dirs {
+		if d == dir {
+			return writeImageDirs(path, append(dirs[:i], dirs[i+1:]...))
+		}
+	}
+	return fmt.Errorf("%s is not registered", dir)
+}
+
+func writeImageDirs(path string, dirs []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	for _, d := range dirs {
+		fmt.Fprintln(&b, d)
+	}
+	return os.WriteFile(path, b.Bytes(), 0o600)
+}
+
+// LocalImages returns the images found in the cache of imgCache, the images
+// pulled to files recorded in the cache, and the images found in the
+// directories dirs and their subdirectories, sorted by reference and path.
+func LocalImages(imgCache *cache.Handle, dirs []string) ([]LocalImage, error) {
+	var records []*cache.ImageRecord
+	if imgCache != nil && !imgCache.IsDisabled() {
+		var err error
+		records, err = imgCache.ImageRecords()
+		if err != nil {
+			return nil, fmt.Errorf("while reading image records: %w", err)
+		}
+	}
+
+	var images []LocalImage
+	seen := make(map[string]bool)
+	add := func(path string, r *cache.ImageRecord) {
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		img, err := inspectLocalImage(path)
+		if err != nil {
+			sylog.Debugf("Skipping %s: %v", path, err)
+			return
+		}
+		if r != nil {
+			img.Reference = r.URI
+			img.Digest = r.Digest
+		}
+		images = append(images, *img)
+	}
+
+	if imgCache != nil && !imgCache.IsDisabled() {
+		for _, cacheType := range imageCacheTypes {
+			dir, err := imgCache.GetFileCacheDir(cacheType)
+			if err != nil {
+				return nil, err
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			for _, e := range entries {
+				if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), "tmp_") {
+					continue
+				}
+				add(filepath.Join(dir, e.Name()), cachedImageRecord(records, e.Name()))
+			}
+		}
+	}
+
+	for _, r := range records {
+		if r.Path != "" {
+			add(r.Path, r)
+		}
+	}
+
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				sylog.Warningf("While looking for images in %s: %v", dir, err)
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				add(path, nil)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].Reference != images[j].Reference {
+			return images[i].Reference < images[j].Reference
+		}
+		return images[i].Path < images[j].Path
+	})
+	return images, nil
+}
+
+// cachedImageRecord returns the record of the image pulled into the cache
+// entry named name, as cache entries are named after the image digest.
+func cachedImageRecord(records []*cache.ImageRecord, name string) *cache.ImageRecord {
+	for _, r := range records {
+		if r.Path == "" && (r.Digest == name || r.Digest == "sha256:"+name) {
+			return r
+		}
+	}
+	return nil
+}
+
+// inspectLocalImage returns the description of the SIF or OCI-SIF image at
+// path.
+func inspectLocalImage(path string) (*LocalImage, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isSIF(path) {
+		return nil, fmt.Errorf("not a SIF image")
+	}
+	f, err := sif.LoadContainerFromPath(path, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		return nil, err
+	}
+	defer f.UnloadContainer()
+
+	img := &LocalImage{
+		Path:     path,
+		Format:   FormatSIF,
+		Size:     fi.Size(),
+		Modified: fi.ModTime(),
+	}
+
+	if root, err := f.GetDescriptor(sif.WithDataType(sif.DataOCIRootIndex)); err == nil {
+		img.Format = FormatOCISIF
+		if err := inspectOCISIF(f, root, img); err != nil {
+			sylog.Debugf("While inspecting OCI image in %s: %v", path, err)
+		}
+		return img, nil
+	}
+
+	if desc, err := f.GetDescriptor(sif.WithPartitionType(sif.PartPrimSys)); err == nil {
+		if _, _, arch, err := desc.PartitionMetadata(); err == nil && arch != "unknown" {
+			img.Arch = arch
+		}
+	}
+	return img, nil
+}
+
+// isSIF returns whether the file at path starts with a SIF header.
+func isSIF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	b := make([]byte, 64)
+	n, _ := io.ReadFull(f, b)
+	return bytes.Contains(b[:n], []byte("SIF_MAGIC"))
+}
+
+// inspectOCISIF sets the reference, digest and architecture of img from the
+// first image of the root index of the OCI-SIF image f.
+func inspectOCISIF(f *sif.FileImage, root sif.Descriptor, img *LocalImage) error {
+	var index ocispec.Index
+	if err := readSIFJSON(root, &index); err != nil {
+		return err
+	}
+	if len(index.Manifests) == 0 {
+		return errors.New("no image in root index")
+	}
+	desc := index.Manifests[0]
+	img.Reference = desc.Annotations[ocispec.AnnotationRefName]
+	img.Digest = desc.Digest.String()
+	if desc.Platform != nil {
+		img.Arch = desc.Platform.Architecture
+		return nil
+	}
+
+	var manifest ocispec.Manifest
+	if err := readOCIBlob(f, desc.Digest.String(), &manifest); err != nil {
+		return err
+	}
+	var config ocispec.Image
+	if err := readOCIBlob(f, manifest.Config.Digest.String(), &config); err != nil {
+		return err
+	}
+	img.Arch = config.Architecture
+	return nil
+}
+
+// readOCIBlob decodes the JSON blob with digest from the OCI-SIF image f
+// into v.
+func readOCIBlob(f *sif.FileImage, digest string, v any) error {
+	blobs, err := f.GetDescriptors(sif.WithDataType(sif.DataOCIBlob))
+	if err != nil {
+		return err
+	}
+	for _, d := range blobs {
+		if h, err := d.OCIBlobDigest(); err == nil && h.String() == digest {
+			return readSIFJSON(d, v)
+		}
+	}
+	return fmt.Errorf("blob %s not found", digest)
+}
+
+func readSIFJSON(d sif.Descriptor, v any) error {
+	b, err := d.GetData()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// PrintLocalImages prints images to w, as JSON when jsonOutput is true.
+func PrintLocalImages(w io.Writer, images []LocalImage, jsonOutput bool) error {
+	if jsonOutput {
+		if images == nil {
+			images = []LocalImage{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(images)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REFERENCE\tFORMAT\tDIGEST\tSIZE\tARCH\tAGE\tPATH")
+	for _, img := range images {
+		digest := img.Digest
+		if _, hex, ok := strings.Cut(digest, ":"); ok {
+			digest = hex
+		}
+		if len(digest) > 12 {
+			digest = digest[:12]
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			orDash(img.Reference),
+			img.Format,
+			orDash(digest),
+			units.HumanSize(float64(img.Size)),
+			orDash(img.Arch),
+			units.HumanDuration(time.Since(img.Modified))+" ago",
+			img.Path,
+		)
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
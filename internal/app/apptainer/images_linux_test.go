@@ -0,0 +1,93 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apptainer/sif/v2/pkg/sif"
+)
+
+func TestImageDirs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config", "image-dirs")
+	dir := t.TempDir()
+
+	if err := RegisterImageDir(file, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := RegisterImageDir(file, dir); err == nil {
+		t.Errorf("unexpected success registering a directory twice")
+	}
+	if err := RegisterImageDir(file, filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("unexpected success registering a missing directory")
+	}
+	dirs, err := ImageDirs(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(dirs, []string{dir}) {
+		t.Errorf("got directories %v, expected %v", dirs, []string{dir})
+	}
+
+	if err := UnregisterImageDir(file, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := UnregisterImageDir(file, dir); err == nil {
+		t.Errorf("unexpected success unregistering a directory twice")
+	}
+	if dirs, err := ImageDirs(file); err != nil || len(dirs) != 0 {
+		t.Errorf("got directories %v (%v), expected none", dirs, err)
+	}
+}
+
+func TestLocalImages(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(sub, "image.sif")
+	di, err := sif.NewDescriptorInput(sif.DataPartition, bytes.NewReader([]byte("hsqs")),
+		sif.OptPartitionMetadata(sif.FsSquash, sif.PartPrimSys, "arm64"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := sif.CreateContainerAtPath(image, sif.OptCreateWithDescriptors(di))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.UnloadContainer(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notsif"), []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	images, err := LocalImages(nil, []string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("got images %+v, expected one", images)
+	}
+	img := images[0]
+	if img.Path != image || img.Format != FormatSIF || img.Arch != "arm64" || img.Size == 0 {
+		t.Errorf("unexpected image %+v", img)
+	}
+
+	var out bytes.Buffer
+	if err := PrintLocalImages(&out, nil, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("got %q, expected an empty JSON list", out.String())
+	}
+}
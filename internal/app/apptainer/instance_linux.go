@@ -19,6 +19,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"sort"
 	"strings"
 	"syscall"
 	"text/tabwriter"
@@ -26,20 +27,96 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/cgroups"
 	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/oci"
+	"github.com/apptainer/apptainer/internal/pkg/util/signal"
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/ociruntime"
+	"github.com/apptainer/apptainer/pkg/runtime/engine/config"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	"github.com/apptainer/apptainer/pkg/util/fs/proc"
 	"github.com/buger/goterm"
 	units "github.com/docker/go-units"
+	imageSpecs "github.com/opencontainers/image-spec/specs-go/v1"
 	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+	"golang.org/x/sys/unix"
 )
 
 type instanceInfo struct {
-	Instance   string `json:"instance"`
-	Pid        int    `json:"pid"`
-	Image      string `json:"img"`
-	IP         string `json:"ip"`
-	LogErrPath string `json:"logErrPath"`
-	LogOutPath string `json:"logOutPath"`
+	Instance    string   `json:"instance"`
+	Type        string   `json:"type"`
+	Pid         int      `json:"pid"`
+	Image       string   `json:"img"`
+	ImageDigest string   `json:"imageDigest,omitempty"`
+	IP          string   `json:"ip"`
+	CreatedAt   string   `json:"createdAt,omitempty"`
+	CgroupPath  string   `json:"cgroupPath,omitempty"`
+	State       string   `json:"state"`
+	LogErrPath  string   `json:"logErrPath"`
+	LogOutPath  string   `json:"logOutPath"`
+	LogDriver   string   `json:"logDriver,omitempty"`
+	Ports       []string `json:"ports,omitempty"`
+}
+
+const (
+	// appInstanceType is the type of instances started by Apptainer.
+	appInstanceType = "apptainer"
+	// ociInstanceType is the type of containers created by the OCI
+	// commands.
+	ociInstanceType = "oci"
+)
+
+// getInstanceInfo returns the information about an instance reported by
+// the JSON instance list.
+func getInstanceInfo(i *instance.File, instanceType string) instanceInfo {
+	info := instanceInfo{
+		Instance:    i.Name,
+		Type:        instanceType,
+		Pid:         i.Pid,
+		Image:       i.Image,
+		ImageDigest: i.ImageDigest,
+		IP:          i.IP,
+		State:       ociruntime.Running,
+		LogErrPath:  i.LogErrPath,
+		LogOutPath:  i.LogOutPath,
+		LogDriver:   i.LogDriver,
+	}
+	if instanceType == appInstanceType {
+		info.Ports = imagePorts(i.Image)
+	}
+	if i.CreatedAt != 0 {
+		info.CreatedAt = time.Unix(0, i.CreatedAt).Format(time.RFC3339)
+	}
+
+	// OCI containers are always placed in a cgroup
+	hasCgroup := i.Cgroup || instanceType == ociInstanceType
+	if instanceType == ociInstanceType {
+		engineConfig := &oci.EngineConfig{}
+		if err := json.Unmarshal(i.Config, &config.Common{EngineConfig: engineConfig}); err != nil {
+			sylog.Debugf("Could not read configuration of container %s: %s", i.Name, err)
+		} else {
+			state := engineConfig.State
+			info.State = string(state.Status)
+			if state.CreatedAt != nil {
+				info.CreatedAt = time.Unix(0, *state.CreatedAt).Format(time.RFC3339)
+			}
+			hasCgroup = hasCgroup && state.Status != ociruntime.Stopped
+		}
+	}
+
+	if hasCgroup {
+		manager, err := cgroups.GetManagerForPid(i.Pid)
+		if err != nil {
+			sylog.Debugf("Could not get cgroup of instance %s: %s", i.Name, err)
+			return info
+		}
+		if path, err := manager.GetCgroupRelPath(); err == nil {
+			info.CgroupPath = path
+		}
+		if frozen, err := manager.IsFrozen(); err == nil && frozen && instanceType == appInstanceType {
+			info.State = ociruntime.Paused
+		}
+	}
+	return info
 }
 
 // PrintInstanceList fetches instance list, applying name and
@@ -66,6 +143,13 @@ func PrintInstanceList(w io.Writer, name, user string, formatJSON bool, showLogs
 		}
 
 		for _, i := range ii {
+			if i.LogDriver != "" && i.LogDriver != instance.FileLogDriver {
+				_, err = fmt.Fprintf(tabWriter, "%s\t%d\t%s\n", i.Name, i.Pid, i.LogDriver)
+				if err != nil {
+					return fmt.Errorf("could not write instance info: %v", err)
+				}
+				continue
+			}
 			_, err = fmt.Fprintf(tabWriter, "%s\t%d\t%s\n\t\t%s\n", i.Name, i.Pid, i.LogErrPath, i.LogOutPath)
 			if err != nil {
 				return fmt.Errorf("could not write instance info: %v", err)
@@ -89,14 +173,19 @@ func PrintInstanceList(w io.Writer, name, user string, formatJSON bool, showLogs
 		return nil
 	}
 
-	instances := make([]instanceInfo, len(ii))
-	for i := range instances {
-		instances[i].Image = ii[i].Image
-		instances[i].Pid = ii[i].Pid
-		instances[i].Instance = ii[i].Name
-		instances[i].IP = ii[i].IP
-		instances[i].LogErrPath = ii[i].LogErrPath
-		instances[i].LogOutPath = ii[i].LogOutPath
+	// the JSON output also reports the containers created by the OCI
+	// commands
+	oi, err := instance.List(user, name, instance.OciSubDir, all)
+	if err != nil {
+		return fmt.Errorf("could not retrieve OCI container list: %v", err)
+	}
+
+	instances := make([]instanceInfo, 0, len(ii)+len(oi))
+	for _, i := range ii {
+		instances = append(instances, getInstanceInfo(i, appInstanceType))
+	}
+	for _, i := range oi {
+		instances = append(instances, getInstanceInfo(i, ociInstanceType))
 	}
 
 	enc := json.NewEncoder(w)
@@ -299,15 +388,67 @@ func InstanceStats(ctx context.Context, name, instanceUser string, formatJSON bo
 	}
 }
 
+// InstanceTop prints the processes running in a named instance.
+func InstanceTop(name, instanceUser string, formatJSON bool) error {
+	ii, err := instanceListOrError(instanceUser, name)
+	if err != nil {
+		return err
+	}
+	if len(ii) != 1 {
+		return fmt.Errorf("query returned more than one instance (%d)", len(ii))
+	}
+	return printTop(os.Stdout, ii[0].Pid, formatJSON)
+}
+
 // StopInstance fetches instance list, applying name and
-// user filters, and stops them by sending a signal sig. If an instance
-// is still running after a grace period defined by timeout is expired,
-// it will be forcibly killed.
+// user filters, and stops them by sending a signal sig, or when sig is 0,
+// the stop signal set in the OCI configuration of their image, SIGINT by
+// default. If an instance is still running after a grace period defined by
+// timeout is expired, it will be forcibly killed. When run as root, the OCI
+// containers matching the filters, created by the OCI commands, are stopped
+// the same way and deleted.
 func StopInstance(name, user string, sig syscall.Signal, timeout time.Duration) error {
-	ii, err := instanceListOrError(user, name)
+	ii, err := instance.List(user, name, instance.AppSubDir, true)
 	if err != nil {
-		return err
+		return fmt.Errorf("could not retrieve instance list: %w", err)
 	}
+	var oi []*instance.File
+	if os.Geteuid() == 0 {
+		oi, err = instance.List(user, name, instance.OciSubDir, true)
+		if err != nil {
+			return fmt.Errorf("could not retrieve OCI container list: %w", err)
+		}
+	}
+	if len(ii) == 0 && len(oi) == 0 {
+		return fmt.Errorf("no instance found")
+	}
+
+	errs := make(chan error, len(oi))
+	for _, i := range oi {
+		go func(id string) {
+			errs <- stopOciContainer(id, sig, timeout)
+		}(i.Name)
+	}
+	if len(ii) > 0 {
+		stopInstances(ii, sig, timeout)
+	}
+
+	failed := 0
+	for range oi {
+		if err := <-errs; err != nil {
+			sylog.Errorf("%s", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not stop %d OCI containers", failed)
+	}
+	return nil
+}
+
+// stopInstances stops the instances ii with sig, killing them after
+// timeout.
+func stopInstances(ii []*instance.File, sig syscall.Signal, timeout time.Duration) {
 	stoppedPID := make(chan int, 1)
 	stopped := make([]int, 0)
 
@@ -320,7 +461,7 @@ func StopInstance(name, user string, sig syscall.Signal, timeout time.Duration)
 		case pid := <-stoppedPID:
 			stopped = append(stopped, pid)
 			if len(stopped) == len(ii) {
-				return nil
+				return
 			}
 		case <-time.After(timeout):
 		killNext:
@@ -334,12 +475,124 @@ func StopInstance(name, user string, sig syscall.Signal, timeout time.Duration)
 				sylog.Infof("Killing %s instance of %s (PID=%d) (Timeout)\n", i.Name, i.Image, i.Pid)
 				syscall.Kill(i.Pid, syscall.SIGKILL)
 			}
+			return
+		}
+	}
+}
+
+// stopOciContainer stops the OCI container id with sig, or the stop signal
+// of its image, SIGTERM by default, when sig is 0, killing it after timeout,
+// and deletes it. A paused container is resumed first, so that it gets the
+// signal, a created container is killed on deletion, its process not having
+// started.
+func stopOciContainer(id string, sig syscall.Signal, timeout time.Duration) error {
+	state, err := getState(id)
+	if err != nil {
+		return err
+	}
+
+	switch state.Status {
+	case ociruntime.Paused:
+		sylog.Infof("Resuming paused OCI container %s to stop it", id)
+		if err := OciPauseResume(id, false); err != nil {
+			return fmt.Errorf("while resuming OCI container %s: %w", id, err)
+		}
+		fallthrough
+	case ociruntime.Running:
+		killSignal := ""
+		if sig != 0 {
+			killSignal = unix.SignalName(sig)
+		}
+		sylog.Infof("Stopping OCI container %s (PID=%d)", id, state.Pid)
+		if err := OciKill(id, killSignal, int(math.Max(1, math.Ceil(timeout.Seconds())))); err != nil {
+			return fmt.Errorf("while stopping OCI container %s: %w", id, err)
+		}
+		if err := waitOciStopped(id, 5*time.Second); err != nil {
+			return err
+		}
+	}
+
+	if err := OciDelete(context.Background(), id); err != nil {
+		return fmt.Errorf("while deleting OCI container %s: %w", id, err)
+	}
+	return nil
+}
+
+// waitOciStopped waits for the state of the OCI container id to be updated
+// once its process exited, up to timeout.
+func waitOciStopped(id string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := getState(id)
+		if err != nil || state.Status == ociruntime.Stopped {
 			return nil
 		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("OCI container %s is still %s", id, state.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
 	}
 }
 
+// imageOCIConfig returns the OCI configuration stored in the SIF image at
+// path, or nil when there is none.
+func imageOCIConfig(path string) *imageSpecs.ImageConfig {
+	img, err := image.Init(path, false)
+	if err != nil {
+		return nil
+	}
+	defer img.File.Close()
+
+	r, err := image.NewSectionReader(img, image.SIFDescOCIConfigJSON, -1)
+	if err != nil {
+		return nil
+	}
+	var c imageSpecs.ImageConfig
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		sylog.Debugf("Could not decode OCI configuration of %s: %s", path, err)
+		return nil
+	}
+	return &c
+}
+
+// imageStopSignal returns the stop signal set in the OCI configuration of
+// the SIF image at path, or 0 when there is none.
+func imageStopSignal(path string) syscall.Signal {
+	c := imageOCIConfig(path)
+	if c == nil || c.StopSignal == "" {
+		return 0
+	}
+	sig, err := signal.Convert(c.StopSignal)
+	if err != nil {
+		sylog.Warningf("Ignoring stop signal of %s: %s", path, err)
+		return 0
+	}
+	return sig
+}
+
+// imagePorts returns the sorted ports exposed by the OCI configuration of
+// the SIF image at path.
+func imagePorts(path string) []string {
+	c := imageOCIConfig(path)
+	if c == nil || len(c.ExposedPorts) == 0 {
+		return nil
+	}
+	ports := make([]string, 0, len(c.ExposedPorts))
+	for p := range c.ExposedPorts {
+		ports = append(ports, p)
+	}
+	sort.Strings(ports)
+	return ports
+}
+
 func killInstance(i *instance.File, sig syscall.Signal, stoppedPID chan<- int) {
+	if sig == 0 {
+		sig = syscall.SIGINT
+		if s := imageStopSignal(i.Image); s != 0 {
+			sylog.Debugf("Using stop signal %s of %s", unix.SignalName(s), i.Image)
+			sig = s
+		}
+	}
 	sylog.Infof("Stopping %s instance of %s (PID=%d)\n", i.Name, i.Image, i.Pid)
 	syscall.Kill(i.Pid, sig)
 
@@ -0,0 +1,212 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/checkpoint/dmtcp"
+	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/internal/pkg/util/shell"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// MigrateOptions are the options of an instance migration.
+type MigrateOptions struct {
+	// Shared is set when the image and the checkpoint are on a file
+	// system shared with the destination host, so they aren't copied.
+	Shared bool
+	// StopTimeout is the grace period given to the local instance to stop
+	// once checkpointed, before it is killed.
+	StopTimeout time.Duration
+}
+
+// MigrateInstance migrates the instance name, started with DMTCP
+// checkpointing, to the host dest: the instance is checkpointed, its image
+// and checkpoint are copied to dest with scp unless they are shared, the
+// local instance is stopped and the instance is restarted on dest from the
+// checkpoint with the same name, through ssh.
+func MigrateInstance(name, dest string, opts MigrateOptions) error {
+	if strings.HasPrefix(dest, "-") {
+		return fmt.Errorf("invalid destination host %q", dest)
+	}
+	file, err := instance.Get(name, instance.AppSubDir)
+	if err != nil {
+		return fmt.Errorf("could not retrieve instance %s: %w", name, err)
+	}
+	if file.Checkpoint == "" {
+		return fmt.Errorf("instance %s was not started with --dmtcp-launch or --dmtcp-restart", name)
+	}
+	e, err := dmtcp.NewManager().Get(file.Checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get checkpoint entry: %w", err)
+	}
+	port, err := e.CoordinatorPort()
+	if err != nil {
+		return fmt.Errorf("failed to parse port file for coordinator port: %w", err)
+	}
+
+	remotePath, err := remoteCheckpointPath(e.Path())
+	if err != nil {
+		return err
+	}
+	copyImage := false
+	if !opts.Shared {
+		if exists, err := remoteExists(dest, remotePath); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("checkpoint %s already exists on %s", e.Name(), dest)
+		}
+		if copyImage, err = imageNeedsCopy(dest, file.Image); err != nil {
+			return err
+		}
+	}
+
+	sylog.Infof("Checkpointing instance %s with checkpoint %q", name, e.Name())
+	exe := filepath.Join(buildcfg.BINDIR, "apptainer")
+	args := append([]string{"exec", "instance://" + name}, dmtcp.BlockingCheckpointArgs(port)...)
+	if err := runCommand(exe, args...); err != nil {
+		return fmt.Errorf("while checkpointing instance %s: %w", name, err)
+	}
+
+	if !opts.Shared {
+		if copyImage {
+			sylog.Infof("Copying image %s to %s", file.Image, dest)
+			if err := copyToHost(dest, file.Image, file.Image); err != nil {
+				return fmt.Errorf("while copying image: %w", err)
+			}
+		}
+		sylog.Infof("Copying checkpoint %q to %s", e.Name(), dest)
+		if err := copyToHost(dest, e.Path(), remotePath); err != nil {
+			return fmt.Errorf("while copying checkpoint: %w", err)
+		}
+	}
+
+	if err := StopInstance(name, "", syscall.SIGINT, opts.StopTimeout); err != nil {
+		return fmt.Errorf("while stopping instance %s: %w", name, err)
+	}
+
+	sylog.Infof("Restarting instance %s on %s", name, dest)
+	start := []string{"apptainer", "instance", "start", "--dmtcp-restart", e.Name(), file.Image, name}
+	if err := runCommand("ssh", "--", dest, shell.ArgsQuoted(start)); err != nil {
+		return fmt.Errorf("while restarting instance %s on %s, it can be restarted locally from checkpoint %q: %w", name, dest, e.Name(), err)
+	}
+	return nil
+}
+
+// remoteCheckpointPath returns the path of the checkpoint directory on the
+// destination host, relative to the home directory of the user when the
+// checkpoint is stored in the local home directory, as scp and ssh resolve
+// relative paths from it.
+func remoteCheckpointPath(path string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(home, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return path, nil
+	}
+	return rel, nil
+}
+
+// imageNeedsCopy returns whether the image at path must be copied to host,
+// to the same path. An image file already there is not copied when it has
+// the same digest, and any other file is never overwritten.
+func imageNeedsCopy(host, path string) (bool, error) {
+	exists, err := remoteExists(host, path)
+	if err != nil || !exists {
+		return !exists, err
+	}
+	if fi, err := os.Stat(path); err != nil {
+		return false, err
+	} else if !fi.Mode().IsRegular() {
+		return false, fmt.Errorf("%s already exists on %s", path, host)
+	}
+
+	local, err := fileDigest(path)
+	if err != nil {
+		return false, err
+	}
+	out, err := sshOutput(host, "sha256sum "+shell.ArgsQuoted([]string{path}))
+	if err != nil {
+		return false, fmt.Errorf("while computing digest of %s on %s: %w", path, host, err)
+	}
+	if remote, _, _ := strings.Cut(string(out), " "); remote != local {
+		return false, fmt.Errorf("a different file already exists at %s on %s", path, host)
+	}
+	sylog.Infof("Image %s already on %s, not copying it", path, host)
+	return false, nil
+}
+
+// fileDigest returns the hex encoded SHA-256 digest of the file at path.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("while computing digest of %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// remoteExists returns whether path exists on host. The failures of ssh,
+// which exits with status 255 when the host can't be reached, are reported
+// as errors.
+func remoteExists(host, path string) (bool, error) {
+	err := runCommand("ssh", "--", host, "test -e "+shell.ArgsQuoted([]string{path}))
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("while checking %s on %s: %w", path, host, err)
+	}
+}
+
+// copyToHost recursively copies the local file src to dst on host.
+func copyToHost(host, src, dst string) error {
+	dir := shell.ArgsQuoted([]string{filepath.Dir(dst)})
+	if err := runCommand("ssh", "--", host, "mkdir -p "+dir); err != nil {
+		return err
+	}
+	return runCommand("scp", "-r", "-p", "-q", "--", src, host+":"+dst)
+}
+
+// sshOutput runs command on host with ssh and returns its output.
+func sshOutput(host, command string) ([]byte, error) {
+	sylog.Debugf("Running ssh -- %s %s", host, command)
+	cmd := exec.Command("ssh", "--", host, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// runCommand runs name with args, with the output of the command displayed.
+func runCommand(name string, args ...string) error {
+	sylog.Debugf("Running %s %s", name, strings.Join(args, " "))
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
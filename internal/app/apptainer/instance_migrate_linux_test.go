@@ -0,0 +1,57 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSSH installs an ssh command in PATH running the commands locally,
+// and failing like ssh for the host "unreachable".
+func fakeSSH(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+[ "$1" = "--" ] || exit 254
+[ "$2" = "unreachable" ] && exit 255
+exec sh -c "$3"
+`
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+}
+
+func TestImageNeedsCopy(t *testing.T) {
+	fakeSSH(t)
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.sif")
+	if err := os.WriteFile(image, []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := remoteExists("host", filepath.Join(dir, "missing")); err != nil || exists {
+		t.Errorf("got exists %v, error %v for a missing path", exists, err)
+	}
+	if _, err := remoteExists("unreachable", image); err == nil {
+		t.Errorf("unexpected success with an unreachable host")
+	}
+
+	// the image is the file on the destination host, with the same digest
+	if needed, err := imageNeedsCopy("host", image); err != nil || needed {
+		t.Errorf("got copy %v, error %v for an image already on the host", needed, err)
+	}
+	if needed, err := imageNeedsCopy("host", filepath.Join(dir, "missing")); err != nil || !needed {
+		t.Errorf("got copy %v, error %v for an image missing on the host", needed, err)
+	}
+	if _, err := imageNeedsCopy("host", dir); err == nil {
+		t.Errorf("unexpected success with an existing directory")
+	}
+	if _, err := imageNeedsCopy("unreachable", image); err == nil {
+		t.Errorf("unexpected success with an unreachable host")
+	}
+}
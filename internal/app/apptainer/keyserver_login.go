@@ -11,11 +11,14 @@
 package apptainer
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/apptainer/apptainer/internal/pkg/remote"
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
+	"github.com/apptainer/apptainer/pkg/syfs"
 	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
@@ -38,7 +41,11 @@ func KeyserverLogin(usrConfigFile string, args *LoginArgs) (err error) {
 		return err
 	}
 
-	if err := c.Login(args.Name, args.Username, args.Password, args.Insecure, ""); err != nil {
+	if args.OIDC {
+		if err := keyserverOIDCLogin(c, args); err != nil {
+			return fmt.Errorf("while login to %s: %s", args.Name, err)
+		}
+	} else if err := c.Login(args.Name, args.Username, args.Password, args.Insecure, ""); err != nil {
 		return fmt.Errorf("while login to %s: %s", args.Name, err)
 	}
 
@@ -59,6 +66,38 @@ func KeyserverLogin(usrConfigFile string, args *LoginArgs) (err error) {
 		return fmt.Errorf("failed to flush remote config file %s: %s", file.Name(), err)
 	}
 
-	sylog.Infof("Token stored in %s", file.Name())
+	if args.OIDC {
+		sylog.Infof("Token stored in %s", syfs.OAuthTokens())
+	} else {
+		sylog.Infof("Token stored in %s", file.Name())
+	}
 	return nil
 }
+
+// keyserverOIDCLogin logs in to a keyserver with the device authorization
+// grant of the OIDC provider set in args. The access token is verified
+// against the keyserver, then kept with its refresh token in the OAuth token
+// store, the keyserver credentials recorded in the remote configuration
+// holding no token.
+func keyserverOIDCLogin(c *remote.Config, args *LoginArgs) error {
+	if args.Username != "" || args.Password != "" {
+		return fmt.Errorf("--username and --password can't be used with --oidc")
+	}
+	if args.OIDCIssuer == "" || args.OIDCClientID == "" {
+		return fmt.Errorf("--oidc-issuer and --oidc-client-id are required to log in to a keyserver with --oidc")
+	}
+
+	ctx := context.Background()
+	p, t, err := oidcDeviceLogin(ctx, args.OIDCIssuer, args.OIDCClientID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Login(args.Name, "", t.AccessToken, args.Insecure, ""); err != nil {
+		return err
+	}
+	// Login appends the verified credentials last.
+	cred := c.Credentials[len(c.Credentials)-1]
+	cred.Auth = ""
+	return oauth.DefaultStore().Set(cred.URI, p, t)
+}
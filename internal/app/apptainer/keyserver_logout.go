@@ -10,7 +10,16 @@
 
 package apptainer
 
+import (
+	"fmt"
+
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
+)
+
 // KeyserverLogout logs out from a keyserver.
 func KeyserverLogout(usrConfigFile, name string, reqAuthFile string) (err error) {
+	if _, err := oauth.DefaultStore().Delete(name); err != nil {
+		return fmt.Errorf("while removing OIDC token: %v", err)
+	}
 	return CommonLoggout(usrConfigFile, name, reqAuthFile)
 }
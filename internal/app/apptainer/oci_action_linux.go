@@ -0,0 +1,272 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/apptainer/apptainer/internal/pkg/build/sources"
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/syecl"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/ocibundle"
+	dirbundle "github.com/apptainer/apptainer/pkg/ocibundle/dir"
+	sifbundle "github.com/apptainer/apptainer/pkg/ocibundle/sif"
+	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/term"
+)
+
+// Runtimes running images with the action commands.
+const (
+	RuntimeAuto   = "auto"
+	RuntimeNative = "native"
+	RuntimeOCI    = "oci"
+)
+
+// ActionRuntime returns the runtime, RuntimeNative or RuntimeOCI, running
+// image with the action commands for the default runtime setting, 'auto'
+// selecting the OCI runtime for OCI-SIF images and the native runtime
+// otherwise. Running instances are always joined with the native runtime.
+func ActionRuntime(setting, image string) (string, error) {
+	if strings.HasPrefix(image, "instance://") {
+		return RuntimeNative, nil
+	}
+	switch setting {
+	case RuntimeNative, RuntimeOCI:
+		return setting, nil
+	case RuntimeAuto, "":
+		if IsOCISIF(image) {
+			return RuntimeOCI, nil
+		}
+		return RuntimeNative, nil
+	}
+	return "", fmt.Errorf("invalid runtime %q, must be %s, %s or %s", setting, RuntimeAuto, RuntimeNative, RuntimeOCI)
+}
+
+// OciAction runs the action given by args, the action script of the native
+// runtime followed by its arguments, in a container created by the OCI
+// runtime from image, a SIF or OCI-SIF image or a directory. The image is
+// mounted in a temporary bundle like with oci mount, the layers of an
+// OCI-SIF image being extracted first, and the container is run with oci
+// run. The exit status of the container is returned as an *exec.ExitError.
+func OciAction(ctx context.Context, image string, args []string, imgCache *cache.Handle) error {
+	ociSIF := IsOCISIF(image)
+	if os.Geteuid() != 0 {
+		if ociSIF {
+			return fmt.Errorf("the OCI runtime can only be used by root, convert %s to a native SIF image with 'apptainer convert' to run it", image)
+		}
+		return fmt.Errorf("the OCI runtime can only be used by root, use --no-oci to run %s with the native runtime", image)
+	}
+	processTeardowns()
+
+	dir, err := os.MkdirTemp("", "oci-action-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	removeDir := true
+	defer func() {
+		if removeDir {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	bundle := filepath.Join(dir, "bundle")
+	var d ocibundle.Bundle
+	var imgConfig *v1.Config
+	switch {
+	case ociSIF:
+		f, err := os.Open(image)
+		if err != nil {
+			return err
+		}
+		err = syecl.Enforce(ctx, buildcfg.ECL_FILE, buildcfg.APPTAINER_CONFDIR, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		rootfs := filepath.Join(dir, "rootfs")
+		if imgConfig, err = unpackOCISIF(ctx, image, rootfs, dir); err != nil {
+			return fmt.Errorf("while extracting OCI image from %s: %w", image, err)
+		}
+		d, err = dirbundle.FromDir(rootfs, bundle, true)
+		if err != nil {
+			return err
+		}
+	case fs.IsDir(image):
+		if cfg := apptainerconf.GetCurrentConfig(); cfg != nil && !cfg.AllowContainerDir {
+			return fmt.Errorf("configuration disallows users from running sandbox containers")
+		}
+		d, err = dirbundle.FromDir(image, bundle, true)
+		if err != nil {
+			return err
+		}
+	default:
+		d, err = sifbundle.FromSif(image, bundle, true,
+			sifbundle.OptVerifyImage(checkECL),
+			sifbundle.OptSpecCache(imgCache),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err := d.Create(nil); err != nil {
+		return err
+	}
+	defer func() {
+		if err := d.Delete(); err != nil {
+			sylog.Warningf("Could not delete bundle %s: %s", bundle, err)
+			removeDir = false
+		}
+	}()
+
+	data, err := os.ReadFile(tools.Config(bundle).Path())
+	if err != nil {
+		return err
+	}
+	var spec specs.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse OCI configuration of bundle %s: %s", bundle, err)
+	}
+	g := generate.New(&spec)
+	if err := ociActionSpec(g, args, imgConfig, term.IsTerminal(int(os.Stdin.Fd()))); err != nil {
+		return err
+	}
+	if err := tools.SaveBundleConfig(bundle, g); err != nil {
+		return err
+	}
+
+	containerID := fmt.Sprintf("action-%d", os.Getpid())
+	cmd := exec.Command(filepath.Join(buildcfg.BINDIR, "apptainer"), "oci", "run", "-b", bundle, containerID)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// the bundle is deleted once the container exits, the signals are
+	// forwarded to oci run instead of terminating this process
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("while running oci run: %w", err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return cmd.Wait()
+}
+
+// unpackOCISIF extracts the layers of the first image of the OCI-SIF image
+// path to the directory rootfs, using tmpDir for temporary files, and
+// returns the image configuration.
+func unpackOCISIF(ctx context.Context, path, rootfs, tmpDir string) (*v1.Config, error) {
+	src := filepath.Join(tmpDir, "layout")
+	if err := sifToLayout(path, src); err != nil {
+		return nil, err
+	}
+	p, err := layout.FromPath(src)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := p.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	if len(im.Manifests) == 0 {
+		return nil, fmt.Errorf("no image in root index")
+	}
+	img, err := idx.Image(im.Manifests[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(rootfs, 0o755); err != nil {
+		return nil, err
+	}
+	if err := sources.UnpackRootfs(ctx, img, rootfs, sources.UnpackOptions{TmpDir: tmpDir}); err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Config, nil
+}
+
+// ociActionSpec sets the process of the OCI configuration of g from args,
+// the action script of the native runtime followed by its arguments, and
+// attaches it to a terminal when terminal is true. Native images hold the
+// action scripts, which are run as is. For OCI images, with the image
+// configuration imgConfig, exec runs its arguments, run the entrypoint with
+// the arguments or the image command, and shell /bin/sh, in the environment
+// and working directory of the image.
+func ociActionSpec(g *generate.Generator, args []string, imgConfig *v1.Config, terminal bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no action to run")
+	}
+	g.SetProcessTerminal(terminal)
+	if imgConfig == nil {
+		g.SetProcessArgs(args)
+		return nil
+	}
+
+	action, rest := filepath.Base(args[0]), args[1:]
+	var process []string
+	switch action {
+	case "exec":
+		process = rest
+	case "run":
+		process = append(process, imgConfig.Entrypoint...)
+		if len(rest) > 0 {
+			process = append(process, rest...)
+		} else {
+			process = append(process, imgConfig.Cmd...)
+		}
+		if len(process) == 0 {
+			return fmt.Errorf("the image has no entrypoint or command to run")
+		}
+	case "shell":
+		process = []string{"/bin/sh"}
+	default:
+		return fmt.Errorf("the %s action is not supported by the OCI runtime for OCI images", action)
+	}
+	g.SetProcessArgs(process)
+
+	for _, e := range imgConfig.Env {
+		k, v, _ := strings.Cut(e, "=")
+		g.SetProcessEnv(k, v)
+	}
+	if imgConfig.WorkingDir != "" {
+		g.SetProcessCwd(imgConfig.WorkingDir)
+	}
+	return nil
+}
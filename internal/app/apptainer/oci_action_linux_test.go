@@ -0,0 +1,117 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestActionRuntime(t *testing.T) {
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag("test:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := writeOCILayout(ref, img, filepath.Join(dir, "layout")); err != nil {
+		t.Fatal(err)
+	}
+	ociSIF := filepath.Join(dir, "oci.sif")
+	if err := layoutToSIF(filepath.Join(dir, "layout"), ociSIF); err != nil {
+		t.Fatal(err)
+	}
+	native := filepath.Join(dir, "native.sif")
+	if err := os.WriteFile(native, []byte("not an OCI-SIF image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		setting string
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{setting: RuntimeAuto, image: ociSIF, want: RuntimeOCI},
+		{setting: RuntimeAuto, image: native, want: RuntimeNative},
+		{setting: "", image: ociSIF, want: RuntimeOCI},
+		{setting: RuntimeNative, image: ociSIF, want: RuntimeNative},
+		{setting: RuntimeOCI, image: native, want: RuntimeOCI},
+		{setting: RuntimeOCI, image: "instance://test", want: RuntimeNative},
+		{setting: "docker", image: native, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ActionRuntime(tt.setting, tt.image)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ActionRuntime(%q, %s): got error %v, expected error: %v", tt.setting, tt.image, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ActionRuntime(%q, %s) = %s, want %s", tt.setting, tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestOciActionSpec(t *testing.T) {
+	imgConfig := &v1.Config{
+		Entrypoint: []string{"/entrypoint"},
+		Cmd:        []string{"serve"},
+		Env:        []string{"PATH=/app/bin:/bin", "MODE=test"},
+		WorkingDir: "/app",
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		imgConfig *v1.Config
+		process   []string
+		wantErr   bool
+	}{
+		{name: "Native", args: []string{"/.singularity.d/actions/run", "a"}, process: []string{"/.singularity.d/actions/run", "a"}},
+		{name: "Exec", args: []string{"/.singularity.d/actions/exec", "ls", "/"}, imgConfig: imgConfig, process: []string{"ls", "/"}},
+		{name: "Run", args: []string{"/.singularity.d/actions/run"}, imgConfig: imgConfig, process: []string{"/entrypoint", "serve"}},
+		{name: "RunArgs", args: []string{"/.singularity.d/actions/run", "check"}, imgConfig: imgConfig, process: []string{"/entrypoint", "check"}},
+		{name: "RunNothing", args: []string{"/.singularity.d/actions/run"}, imgConfig: &v1.Config{}, wantErr: true},
+		{name: "Shell", args: []string{"/.singularity.d/actions/shell"}, imgConfig: imgConfig, process: []string{"/bin/sh"}},
+		{name: "Test", args: []string{"/.singularity.d/actions/test"}, imgConfig: imgConfig, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := generate.New(&specs.Spec{Process: &specs.Process{Cwd: "/", Env: []string{"PATH=/bin", "TERM=xterm"}}})
+			err := ociActionSpec(g, tt.args, tt.imgConfig, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(g.Config.Process.Args, tt.process) || !g.Config.Process.Terminal {
+				t.Errorf("got process %v, terminal %v, expected %v with a terminal", g.Config.Process.Args, g.Config.Process.Terminal, tt.process)
+			}
+			if tt.imgConfig == nil {
+				return
+			}
+			wantEnv := []string{"PATH=/app/bin:/bin", "TERM=xterm", "MODE=test"}
+			if !reflect.DeepEqual(g.Config.Process.Env, wantEnv) {
+				t.Errorf("got env %v, expected %v", g.Config.Process.Env, wantEnv)
+			}
+			if g.Config.Process.Cwd != "/app" {
+				t.Errorf("got cwd %s, expected /app", g.Config.Process.Cwd)
+			}
+		})
+	}
+}
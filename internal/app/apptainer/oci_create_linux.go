@@ -15,11 +15,20 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/apptainer/apptainer/internal/pkg/instance"
+	"github.com/apptainer/apptainer/internal/pkg/plugin"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/oci"
+	"github.com/apptainer/apptainer/internal/pkg/security/landlock"
 	"github.com/apptainer/apptainer/internal/pkg/util/starter"
+	"github.com/apptainer/apptainer/pkg/network"
+	"github.com/apptainer/apptainer/pkg/ociruntime"
+	clicallback "github.com/apptainer/apptainer/pkg/plugin/callback/cli"
 	"github.com/apptainer/apptainer/pkg/runtime/engine/config"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // OciCreate creates a container from an OCI bundle
@@ -35,6 +44,51 @@ func OciCreate(containerID string, args *OciArgs) error {
 	if err != nil {
 		return fmt.Errorf("failed to determine bundle absolute path: %s", err)
 	}
+	// the current directory is kept for --keep-cwd
+	hostCwd, err := os.Getwd()
+	if err != nil {
+		sylog.Debugf("Could not get current directory: %s", err)
+	}
+	// the work directory is relative to the current directory
+	if args.WorkDir != "" {
+		if args.WorkDir, err = filepath.Abs(args.WorkDir); err != nil {
+			return fmt.Errorf("failed to determine work directory absolute path: %s", err)
+		}
+	}
+
+	// the landlock ruleset path is relative to the current directory
+	var landlockRuleset []byte
+	for _, param := range args.Security {
+		feature, path, _ := strings.Cut(param, ":")
+		if feature != "landlock" || path == "" {
+			return fmt.Errorf("unsupported security option %q, only landlock:<ruleset.json> is supported", param)
+		}
+		landlockRuleset, err = os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("while reading landlock ruleset: %s", err)
+		}
+		if _, err := landlock.ParseRuleset(landlockRuleset); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	// the configuration path is relative to the current directory, read
+	// from standard input for "-"
+	configJSON := filepath.Join(absBundle, "config.json")
+	if args.ConfigPath != "" {
+		configJSON = args.ConfigPath
+	}
+	var data []byte
+	if configJSON == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(configJSON)
+	}
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		return fmt.Errorf("oci specification file %q is missing or cannot be read", configJSON)
+	} else if err != nil {
+		return fmt.Errorf("failed to read OCI specification file %s: %s", configJSON, err)
+	}
 
 	if err := os.Chdir(absBundle); err != nil {
 		return fmt.Errorf("failed to change directory to %s: %s", absBundle, err)
@@ -47,39 +101,274 @@ func OciCreate(containerID string, args *OciArgs) error {
 	engineConfig.SetLogFormat(args.LogFormat)
 	engineConfig.SetPidFile(args.PidFile)
 
-	// load config.json from bundle path
-	configJSON := filepath.Join(absBundle, "config.json")
-	fb, err := os.Open(configJSON)
-	if err != nil {
-		return fmt.Errorf("oci specification file %q is missing or cannot be read", configJSON)
+	if err := json.Unmarshal(data, generator.Config); err != nil {
+		return fmt.Errorf("failed to parse OCI specification file %s: %s", configJSON, err)
 	}
 
-	data, err := io.ReadAll(fb)
-	if err != nil {
-		return fmt.Errorf("failed to read OCI specification file %s: %s", configJSON, err)
+	if landlockRuleset != nil {
+		generator.AddAnnotation(landlock.Annotation, string(landlockRuleset))
 	}
 
-	fb.Close()
+	if args.Netns != "" {
+		if args.Network != "" {
+			return fmt.Errorf("--net and --netns are mutually exclusive")
+		}
+		path, err := network.NamespacePath(args.Netns)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("while checking network namespace: %w", err)
+		}
+		generator.AddOrReplaceLinuxNamespace(specs.NetworkNamespace, path)
+	}
 
-	if err := json.Unmarshal(data, generator.Config); err != nil {
-		return fmt.Errorf("failed to parse OCI specification file %s: %s", configJSON, err)
+	switch {
+	case args.Network == "":
+	case args.Network == "host":
+		generator.RemoveLinuxNamespace(specs.NetworkNamespace)
+	case strings.HasPrefix(args.Network, containerNsPrefix):
+		if err := joinContainerNamespace(generator, specs.NetworkNamespace, args.Network); err != nil {
+			return fmt.Errorf("--net: %s", err)
+		}
+		args.Network = ""
+	default:
+		generator.AddOrReplaceLinuxNamespace(specs.NetworkNamespace, "")
+	}
+	engineConfig.SetNetwork(args.Network)
+
+	if err := setNamespace(generator, specs.IPCNamespace, args.IPC); err != nil {
+		return fmt.Errorf("--ipc: %s", err)
+	}
+	if err := setNamespace(generator, specs.PIDNamespace, args.PID); err != nil {
+		return fmt.Errorf("--pid: %s", err)
 	}
 
+	removeMounts(generator, args.NoMount)
+	if args.ReadOnlyRoot {
+		generator.SetRootReadonly(true)
+	}
+	for _, t := range args.Tmpfs {
+		dest, options, err := parseTmpfs(t)
+		if err != nil {
+			return err
+		}
+		generator.AddTmpfsMount(dest, options)
+	}
+
+	if args.Nvidia {
+		if err := addNvidiaGPUs(generator); err != nil {
+			return fmt.Errorf("--nv: %s", err)
+		}
+	}
+
+	if err := setHome(generator, args); err != nil {
+		return err
+	}
+	if err := setCwd(generator, absBundle, hostCwd, args); err != nil {
+		return err
+	}
+	if err := addEtcFiles(generator, absBundle, args); err != nil {
+		return err
+	}
+
+	switch {
+	case args.NoNewPrivs && args.AllowNewPrivs:
+		return fmt.Errorf("--no-new-privs and --allow-new-privs are mutually exclusive")
+	case args.NoNewPrivs:
+		generator.SetProcessNoNewPrivileges(true)
+	case args.AllowNewPrivs:
+		generator.SetProcessNoNewPrivileges(false)
+	}
+	engineConfig.SetAllowNewPrivs(args.AllowNewPrivs)
+
+	engineConfig.SetCgroupParent(args.CgroupParent)
 	engineConfig.EmptyProcess = args.EmptyProcess
+	engineConfig.SetInit(args.Init)
 	engineConfig.SyncSocket = args.SyncSocketPath
 
+	// allow any plugins with callbacks to modify the OCI specification
+	if err := runOCISpecCallbacks(containerID, generator.Config); err != nil {
+		return err
+	}
+
+	// scratch directories are created last, not to leave them behind when
+	// the container creation is aborted
+	if err := addScratchDirs(generator, engineConfig, containerID, args); err != nil {
+		return err
+	}
+
 	commonConfig := &config.Common{
 		ContainerID:  containerID,
 		EngineName:   oci.Name,
 		EngineConfig: engineConfig,
 	}
 
+	// the final configuration is recorded for oci debug, and removed by
+	// oci delete when the container exits successfully
+	if args.DebugKeepBundle {
+		if err := saveDebugRecord(containerID, absBundle, generator.Config); err != nil {
+			return fmt.Errorf("while recording container for debugging: %s", err)
+		}
+	} else {
+		removeDebugRecord(containerID)
+	}
+
 	procName := fmt.Sprintf("Apptainer OCI %s", containerID)
-	return starter.Run(
+	err = starter.Run(
 		procName,
 		commonConfig,
 		starter.WithStdin(os.Stdin),
 		starter.WithStderr(os.Stderr),
 		starter.WithStdout(os.Stdout),
 	)
+	if err != nil {
+		debugHint(containerID)
+	}
+	return err
+}
+
+// runOCISpecCallbacks runs the plugin callbacks modifying the OCI
+// specification of the container.
+func runOCISpecCallbacks(containerID string, spec *specs.Spec) error {
+	callbackType := (clicallback.OCISpec)(nil)
+	callbacks, err := plugin.LoadCallbacks(callbackType)
+	if err != nil {
+		return fmt.Errorf("while loading plugin callbacks '%T': %w", callbackType, err)
+	}
+	for _, c := range callbacks {
+		if err := c.(clicallback.OCISpec)(containerID, spec); err != nil {
+			return fmt.Errorf("plugin callback refused container %s: %w", containerID, err)
+		}
+	}
+	return nil
+}
+
+// defaultTmpfsOptions are the mount options of tmpfs mounts requested
+// without options.
+var defaultTmpfsOptions = []string{"nosuid", "nodev", "noexec", "mode=1777"}
+
+// parseTmpfs parses a tmpfs mount request of the form path[:options], with
+// comma separated tmpfs mount options, which are always mounted nosuid and
+// nodev.
+func parseTmpfs(s string) (string, []string, error) {
+	dest, opts, ok := strings.Cut(s, ":")
+	if !filepath.IsAbs(dest) {
+		return "", nil, fmt.Errorf("tmpfs destination %q is not an absolute path", dest)
+	}
+	dest = filepath.Clean(dest)
+	if dest == "/" {
+		return "", nil, fmt.Errorf("tmpfs can't be mounted on the container root")
+	}
+	if !ok || opts == "" {
+		return dest, defaultTmpfsOptions, nil
+	}
+	options := []string{"nosuid", "nodev"}
+	for _, o := range strings.Split(opts, ",") {
+		if o = strings.TrimSpace(o); o != "" && o != "suid" && o != "dev" {
+			options = append(options, o)
+		}
+	}
+	return dest, options, nil
+}
+
+// containerNsPrefix prefixes the namespace options joining the namespace of
+// another container, given by its ID or by an instance:// reference.
+const containerNsPrefix = "container:"
+
+// setNamespace sets a namespace of the OCI configuration from the value of
+// the --ipc or --pid option: 'host' shares the namespace of the host, and
+// container:<id> joins the namespace of another container.
+func setNamespace(g *generate.Generator, nsType specs.LinuxNamespaceType, value string) error {
+	switch {
+	case value == "":
+	case value == "host":
+		g.RemoveLinuxNamespace(nsType)
+	case strings.HasPrefix(value, containerNsPrefix):
+		return joinContainerNamespace(g, nsType, value)
+	default:
+		return fmt.Errorf("invalid value %q, must be 'host' or '%s<id>'", value, containerNsPrefix)
+	}
+	return nil
+}
+
+// joinContainerNamespace sets the namespace of the OCI configuration to the
+// namespace of a running OCI container, or of a running instance when
+// referenced with instance://<name>.
+func joinContainerNamespace(g *generate.Generator, nsType specs.LinuxNamespaceType, value string) error {
+	ref := strings.TrimPrefix(value, containerNsPrefix)
+	if ref == "" {
+		return fmt.Errorf("no container ID in %q", value)
+	}
+
+	var pid int
+	if strings.HasPrefix(ref, "instance://") {
+		file, err := instance.Get(instance.ExtractName(ref), instance.AppSubDir)
+		if err != nil {
+			return err
+		}
+		pid = file.Pid
+	} else {
+		state, err := getState(ref)
+		if err != nil {
+			return err
+		}
+		switch state.Status {
+		case ociruntime.Created, ociruntime.Running, ociruntime.Paused:
+			pid = state.Pid
+		default:
+			return fmt.Errorf("container %s is not running", ref)
+		}
+	}
+
+	nsName, ok := nsProcName[nsType]
+	if !ok {
+		return fmt.Errorf("joining %s namespace is not supported", nsType)
+	}
+	path := fmt.Sprintf("/proc/%d/ns/%s", pid, nsName)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("while checking %s namespace of %s: %w", nsType, ref, err)
+	}
+	g.AddOrReplaceLinuxNamespace(nsType, path)
+	return nil
+}
+
+// nsProcName maps the namespaces that can be joined to their name in
+// /proc/<pid>/ns.
+var nsProcName = map[specs.LinuxNamespaceType]string{
+	specs.IPCNamespace:     "ipc",
+	specs.PIDNamespace:     "pid",
+	specs.NetworkNamespace: "net",
+}
+
+// noMountDests are the destinations of the default mounts disabled by
+// --no-mount.
+var noMountDests = map[string][]string{
+	"proc":   {"/proc"},
+	"sys":    {"/sys"},
+	"dev":    {"/dev"},
+	"devpts": {"/dev/pts"},
+	"tmp":    {"/tmp", "/var/tmp"},
+}
+
+// removeMounts removes the mounts of the OCI configuration disabled with
+// --no-mount, given by name like the native runtime or by absolute
+// destination path.
+func removeMounts(g *generate.Generator, noMount []string) {
+	for _, v := range noMount {
+		if dests, ok := noMountDests[v]; ok {
+			for _, dest := range dests {
+				g.RemoveMounts(dest)
+			}
+			continue
+		}
+		switch {
+		case filepath.IsAbs(v):
+			g.RemoveMounts(filepath.Clean(v))
+		case v == "home", v == "cwd", v == "hostfs", v == "bind-paths":
+			sylog.Debugf("Ignoring '%s' mount type, not mounted in OCI containers", v)
+		default:
+			sylog.Warningf("Ignoring unknown mount type '%s'", v)
+		}
+	}
 }
@@ -0,0 +1,364 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/oci"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestParseTmpfs(t *testing.T) {
+	tests := []struct {
+		spec    string
+		dest    string
+		options []string
+		wantErr bool
+	}{
+		{spec: "/run", dest: "/run", options: defaultTmpfsOptions},
+		{spec: "/var/cache/:", dest: "/var/cache", options: defaultTmpfsOptions},
+		{spec: "/run:size=64m,mode=755", dest: "/run", options: []string{"nosuid", "nodev", "size=64m", "mode=755"}},
+		{spec: "/data:exec,suid,dev", dest: "/data", options: []string{"nosuid", "nodev", "exec"}},
+		{spec: "run", wantErr: true},
+		{spec: "/", wantErr: true},
+		{spec: "/../:size=1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		dest, options, err := parseTmpfs(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expected error for %q", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.spec, err)
+			continue
+		}
+		if dest != tt.dest || !reflect.DeepEqual(options, tt.options) {
+			t.Errorf("parseTmpfs(%q) = %s %v, want %s %v", tt.spec, dest, options, tt.dest, tt.options)
+		}
+	}
+}
+
+func TestRemoveMounts(t *testing.T) {
+	mounts := func(dests ...string) []specs.Mount {
+		m := make([]specs.Mount, len(dests))
+		for i, d := range dests {
+			m[i] = specs.Mount{Destination: d}
+		}
+		return m
+	}
+
+	g := generate.New(&specs.Spec{
+		Mounts: mounts("/proc", "/dev", "/dev/pts", "/dev/shm", "/sys", "/sys/fs/cgroup", "/tmp", "/data"),
+	})
+	removeMounts(g, []string{"devpts", "sys", "home", "/data/", "unknown"})
+
+	want := mounts("/proc", "/dev", "/dev/shm", "/tmp")
+	if !reflect.DeepEqual(g.Config.Mounts, want) {
+		t.Errorf("got mounts %v, want %v", g.Config.Mounts, want)
+	}
+}
+
+func TestAddGPUBinds(t *testing.T) {
+	g := generate.New(&specs.Spec{Process: &specs.Process{Env: []string{"LD_LIBRARY_PATH=/opt/lib"}}})
+	addGPUBinds(g, []string{"/usr/lib64/libcuda.so.1"}, []string{"/usr/local/bin/nvidia-smi"}, []string{"/var/run/nvidia-persistenced/socket"})
+
+	var dests []string
+	for _, m := range g.Config.Mounts {
+		dests = append(dests, m.Destination)
+	}
+	expected := []string{"/.singularity.d/libs/libcuda.so.1", "/usr/bin/nvidia-smi", "/var/run/nvidia-persistenced/socket"}
+	if !reflect.DeepEqual(dests, expected) {
+		t.Errorf("got mounts %v, expected %v", dests, expected)
+	}
+	expected = []string{"LD_LIBRARY_PATH=/opt/lib:/.singularity.d/libs"}
+	if !reflect.DeepEqual(g.Config.Process.Env, expected) {
+		t.Errorf("got environment %v, expected %v", g.Config.Process.Env, expected)
+	}
+}
+
+func TestAddEtcFiles(t *testing.T) {
+	bundle := t.TempDir()
+	etc := filepath.Join(bundle, "rootfs", "etc")
+	if err := os.MkdirAll(etc, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(etc, "passwd"), []byte("container:x:4242:4242::/:/bin/sh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	uid := uint32(os.Getuid())
+	g := generate.New(&specs.Spec{
+		Root: &specs.Root{Path: "rootfs"},
+		Process: &specs.Process{
+			User: specs.User{UID: uid, GID: uint32(os.Getgid())},
+			Env:  []string{"HOME=/home/test"},
+		},
+	})
+	if err := addEtcFiles(g, bundle, &OciArgs{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// there is no /etc/group in the container, only /etc/passwd is bound
+	if len(g.Config.Mounts) != 1 || g.Config.Mounts[0].Destination != "/etc/passwd" {
+		t.Fatalf("got mounts %+v, expected /etc/passwd", g.Config.Mounts)
+	}
+	b, err := os.ReadFile(g.Config.Mounts[0].Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || lines[0] != "container:x:4242:4242::/:/bin/sh" || !strings.Contains(lines[1], ":/home/test:") {
+		t.Errorf("unexpected passwd content %q", b)
+	}
+
+	g = generate.New(&specs.Spec{Root: &specs.Root{Path: "rootfs"}, Process: &specs.Process{User: specs.User{UID: uid}}})
+	if err := addEtcFiles(g, bundle, &OciArgs{NoPasswdGroup: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(g.Config.Mounts) != 0 {
+		t.Errorf("got mounts %+v, expected none", g.Config.Mounts)
+	}
+}
+
+func TestSetHome(t *testing.T) {
+	spec := func() *generate.Generator {
+		return generate.New(&specs.Spec{
+			Process: &specs.Process{Cwd: "/home/user", Env: []string{"HOME=/home/user"}},
+			Mounts: []specs.Mount{
+				{Destination: "/home/user", Type: "bind", Source: "/srv/user"},
+				{Destination: "/home/user/data", Type: "bind", Source: "/srv/data"},
+			},
+		})
+	}
+	dests := func(g *generate.Generator) []string {
+		var d []string
+		for _, m := range g.Config.Mounts {
+			d = append(d, m.Type+":"+m.Source+":"+m.Destination)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		args    OciArgs
+		mounts  []string
+		home    string
+		cwd     string
+		wantErr bool
+	}{
+		{
+			name:   "Unchanged",
+			mounts: []string{"bind:/srv/user:/home/user", "bind:/srv/data:/home/user/data"},
+			home:   "HOME=/home/user",
+			cwd:    "/home/user",
+		},
+		{
+			name:   "Home",
+			args:   OciArgs{Home: "/tmp/home:/home/test"},
+			mounts: []string{"bind:/srv/user:/home/user", "bind:/srv/data:/home/user/data", "bind:/tmp/home:/home/test"},
+			home:   "HOME=/home/test",
+			cwd:    "/home/user",
+		},
+		{
+			name:   "HomeSource",
+			args:   OciArgs{Home: "/home/user"},
+			mounts: []string{"bind:/home/user:/home/user"},
+			home:   "HOME=/home/user",
+			cwd:    "/home/user",
+		},
+		{
+			name:   "NoHome",
+			args:   OciArgs{NoHome: true},
+			mounts: []string{"bind:/srv/data:/home/user/data"},
+			home:   "HOME=/home/user",
+			cwd:    "/",
+		},
+		{
+			name:   "Contain",
+			args:   OciArgs{ContainHome: true},
+			mounts: []string{"tmpfs:tmpfs:/home/user"},
+			home:   "HOME=/home/user",
+			cwd:    "/home/user",
+		},
+		{name: "Relative", args: OciArgs{Home: "home"}, wantErr: true},
+		{name: "Conflict", args: OciArgs{Home: "/home/user", NoHome: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := spec()
+			err := setHome(g, &tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := dests(g); !reflect.DeepEqual(got, tt.mounts) {
+				t.Errorf("got mounts %v, expected %v", got, tt.mounts)
+			}
+			if !reflect.DeepEqual(g.Config.Process.Env, []string{tt.home}) {
+				t.Errorf("got environment %v, expected %v", g.Config.Process.Env, tt.home)
+			}
+			if g.Config.Process.Cwd != tt.cwd {
+				t.Errorf("got cwd %s, expected %s", g.Config.Process.Cwd, tt.cwd)
+			}
+		})
+	}
+}
+
+func TestAddScratchDirs(t *testing.T) {
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	newSpec := func() *generate.Generator {
+		return generate.New(&specs.Spec{Process: &specs.Process{User: specs.User{UID: uid, GID: gid}}})
+	}
+
+	g := newSpec()
+	engineConfig := oci.NewConfig()
+	if err := addScratchDirs(g, engineConfig, "test", &OciArgs{ScratchDirs: []string{"/scratch", "/data/tmp/"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(g.Config.Mounts) != 2 || g.Config.Mounts[0].Type != "tmpfs" || g.Config.Mounts[1].Destination != "/data/tmp" {
+		t.Errorf("got mounts %+v, expected tmpfs scratch directories", g.Config.Mounts)
+	}
+	if len(engineConfig.ScratchDirs) != 0 {
+		t.Errorf("got scratch directories %v to remove, expected none", engineConfig.ScratchDirs)
+	}
+
+	workdir := t.TempDir()
+	g = newSpec()
+	if err := addScratchDirs(g, engineConfig, "test", &OciArgs{ScratchDirs: []string{"/scratch"}, WorkDir: workdir}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(engineConfig.ScratchDirs) != 1 || filepath.Dir(engineConfig.ScratchDirs[0]) != workdir {
+		t.Fatalf("got scratch directories %v to remove, expected one in %s", engineConfig.ScratchDirs, workdir)
+	}
+	src := filepath.Join(engineConfig.ScratchDirs[0], "scratch")
+	if len(g.Config.Mounts) != 1 || g.Config.Mounts[0].Type != "bind" || g.Config.Mounts[0].Source != src {
+		t.Errorf("got mounts %+v, expected bind of %s", g.Config.Mounts, src)
+	}
+	if fi, err := os.Stat(src); err != nil || !fi.IsDir() {
+		t.Errorf("scratch directory %s not created: %v", src, err)
+	}
+
+	if err := addScratchDirs(newSpec(), engineConfig, "test", &OciArgs{ScratchDirs: []string{"scratch"}}); err == nil {
+		t.Errorf("unexpected success with a relative scratch directory")
+	}
+}
+
+func TestSetCwd(t *testing.T) {
+	bundle := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(bundle, "rootfs", "work", "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	if err := os.Mkdir(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	newSpec := func() *generate.Generator {
+		return generate.New(&specs.Spec{
+			Root:    &specs.Root{Path: "rootfs"},
+			Process: &specs.Process{Cwd: "/app"},
+			Mounts: []specs.Mount{
+				{Destination: "/data", Type: "bind", Source: src},
+				{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs"},
+			},
+		})
+	}
+
+	tests := []struct {
+		name    string
+		args    OciArgs
+		hostCwd string
+		cwd     string
+		wantErr bool
+	}{
+		{name: "Default", hostCwd: "/work/dir", cwd: "/app"},
+		{name: "Cwd", args: OciArgs{Cwd: "/opt/"}, cwd: "/opt"},
+		{name: "RelativeCwd", args: OciArgs{Cwd: "opt"}, wantErr: true},
+		{name: "KeepCwdRootfs", args: OciArgs{KeepCwd: true}, hostCwd: "/work/dir", cwd: "/work/dir"},
+		{name: "KeepCwdBind", args: OciArgs{KeepCwd: true}, hostCwd: "/data/sub", cwd: "/data/sub"},
+		{name: "KeepCwdTmpfs", args: OciArgs{KeepCwd: true}, hostCwd: "/tmp", cwd: "/tmp"},
+		{name: "KeepCwdTmpfsSub", args: OciArgs{KeepCwd: true}, hostCwd: "/tmp/sub", cwd: "/app"},
+		{name: "KeepCwdMissing", args: OciArgs{KeepCwd: true}, hostCwd: "/work/missing", cwd: "/app"},
+		{name: "Conflict", args: OciArgs{Cwd: "/opt", KeepCwd: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newSpec()
+			err := setCwd(g, bundle, tt.hostCwd, &tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if err == nil && g.Config.Process.Cwd != tt.cwd {
+				t.Errorf("got cwd %s, expected %s", g.Config.Process.Cwd, tt.cwd)
+			}
+		})
+	}
+}
+
+func TestDebugSpec(t *testing.T) {
+	bundle := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(bundle, "rootfs", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	newSpec := func(cwd string) *generate.Generator {
+		return generate.New(&specs.Spec{
+			Root:    &specs.Root{Path: "rootfs"},
+			Process: &specs.Process{Cwd: cwd, Args: []string{"/entrypoint"}, Env: []string{"PATH=/bin"}},
+			Mounts: []specs.Mount{
+				{Destination: "/data", Type: "bind", Source: src},
+				{Destination: "/scratch", Type: "bind", Source: filepath.Join(src, "removed")},
+				{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs"},
+			},
+			Linux: &specs.Linux{
+				Namespaces: []specs.LinuxNamespace{
+					{Type: specs.MountNamespace},
+					{Type: specs.PIDNamespace, Path: "/proc/0/ns/pid"},
+				},
+			},
+		})
+	}
+
+	g := newSpec("/app")
+	debugSpec(g, bundle, "/bin/bash", true)
+	if !reflect.DeepEqual(g.Config.Process.Args, []string{"/bin/bash"}) || !g.Config.Process.Terminal {
+		t.Errorf("got process args %v and terminal %v, expected /bin/bash with a terminal", g.Config.Process.Args, g.Config.Process.Terminal)
+	}
+	if g.Config.Process.Cwd != "/app" {
+		t.Errorf("got cwd %s, expected /app", g.Config.Process.Cwd)
+	}
+	if !reflect.DeepEqual(g.Config.Process.Env, []string{"PATH=/bin"}) {
+		t.Errorf("got env %v, expected the container env", g.Config.Process.Env)
+	}
+	var dests []string
+	for _, m := range g.Config.Mounts {
+		dests = append(dests, m.Destination)
+	}
+	if !reflect.DeepEqual(dests, []string{"/data", "/tmp"}) {
+		t.Errorf("got mounts %v, expected /data and /tmp", dests)
+	}
+	for _, ns := range g.Config.Linux.Namespaces {
+		if ns.Path != "" {
+			t.Errorf("got %s namespace path %s, expected a new namespace", ns.Type, ns.Path)
+		}
+	}
+
+	g = newSpec("/missing")
+	debugSpec(g, bundle, "/bin/sh", false)
+	if g.Config.Process.Cwd != "/" || g.Config.Process.Terminal {
+		t.Errorf("got cwd %s and terminal %v, expected / without terminal", g.Config.Process.Cwd, g.Config.Process.Terminal)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// setCwd sets the working directory of the container process to the
+// directory requested with --cwd, or to the host current directory hostCwd
+// with --keep-cwd when it exists in the container. Otherwise the working
+// directory of the OCI configuration is kept, the WorkingDir of the image
+// configuration for bundles created from images.
+func setCwd(g *generate.Generator, bundle, hostCwd string, args *OciArgs) error {
+	if args.Cwd != "" && args.KeepCwd {
+		return fmt.Errorf("--cwd and --keep-cwd are mutually exclusive")
+	}
+	switch {
+	case args.Cwd != "":
+		if !filepath.IsAbs(args.Cwd) {
+			return fmt.Errorf("--cwd: %s is not an absolute path", args.Cwd)
+		}
+		g.SetProcessCwd(filepath.Clean(args.Cwd))
+	case args.KeepCwd:
+		if hostCwd == "" || !containerDirExists(g, bundle, hostCwd) {
+			sylog.Warningf("Current directory %s doesn't exist in the container, not changing working directory", hostCwd)
+			return nil
+		}
+		g.SetProcessCwd(hostCwd)
+	}
+	return nil
+}
+
+// containerDirExists returns whether the directory dir exists in the
+// container, in its root filesystem or in a bind mount of the OCI
+// configuration.
+func containerDirExists(g *generate.Generator, bundle, dir string) bool {
+	dir = filepath.Clean(dir)
+	for i := len(g.Config.Mounts) - 1; i >= 0; i-- {
+		m := g.Config.Mounts[i]
+		dest := filepath.Clean(m.Destination)
+		rel, err := filepath.Rel(dest, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		if m.Type != "bind" && m.Type != "none" {
+			// the content of other mounts is unknown
+			return rel == "."
+		}
+		return fs.IsDir(filepath.Join(m.Source, rel))
+	}
+
+	if g.Config.Root == nil {
+		return false
+	}
+	rootfs := g.Config.Root.Path
+	if !filepath.IsAbs(rootfs) {
+		rootfs = filepath.Join(bundle, rootfs)
+	}
+	return fs.IsDir(filepath.Join(rootfs, dir))
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/term"
+)
+
+// defaultDebugShell is the shell started by oci debug without --shell.
+const defaultDebugShell = "/bin/sh"
+
+// ociDebugRecord is the bundle and final OCI configuration of a container
+// created with --debug-keep-bundle, kept after a failure to debug it.
+type ociDebugRecord struct {
+	Bundle string      `json:"bundle"`
+	Spec   *specs.Spec `json:"spec"`
+}
+
+// ociDebugPath returns the path of the debug record of the container
+// containerID.
+func ociDebugPath(containerID string) string {
+	return filepath.Join(syfs.ConfigDir(), "oci-debug", containerID+".json")
+}
+
+// saveDebugRecord records the bundle and OCI configuration spec of the
+// container containerID.
+func saveDebugRecord(containerID, bundle string, spec *specs.Spec) error {
+	data, err := json.Marshal(&ociDebugRecord{Bundle: bundle, Spec: spec})
+	if err != nil {
+		return err
+	}
+	path := ociDebugPath(containerID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// removeDebugRecord removes the debug record of the container containerID,
+// if any.
+func removeDebugRecord(containerID string) {
+	if err := os.Remove(ociDebugPath(containerID)); err != nil && !os.IsNotExist(err) {
+		sylog.Warningf("Could not remove debug record of container %s: %s", containerID, err)
+	}
+}
+
+// debugHint tells how to debug the container containerID when its debug
+// record was kept.
+func debugHint(containerID string) {
+	if _, err := os.Stat(ociDebugPath(containerID)); err == nil {
+		sylog.Infof("Bundle of container %s kept, run 'apptainer oci debug %s' to debug it", containerID, containerID)
+	}
+}
+
+// OciDebug runs shell, /bin/sh by default, in a new container created from
+// the bundle and OCI configuration of the container containerID, created
+// with --debug-keep-bundle and failed, with the same mounts, user and
+// environment.
+func OciDebug(ctx context.Context, containerID, shell string) error {
+	data, err := os.ReadFile(ociDebugPath(containerID))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no kept bundle for container %s, it must be created with --debug-keep-bundle", containerID)
+	} else if err != nil {
+		return fmt.Errorf("while reading debug record of container %s: %s", containerID, err)
+	}
+	var rec ociDebugRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return fmt.Errorf("while parsing debug record of container %s: %s", containerID, err)
+	}
+	if rec.Spec == nil {
+		return fmt.Errorf("no OCI configuration in debug record of container %s", containerID)
+	}
+	if shell == "" {
+		shell = defaultDebugShell
+	}
+
+	g := generate.New(rec.Spec)
+	debugSpec(g, rec.Bundle, shell, term.IsTerminal(int(os.Stdin.Fd())))
+
+	f, err := os.CreateTemp("", "oci-debug-"+containerID+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := json.NewEncoder(f).Encode(g.Config); err != nil {
+		f.Close()
+		return fmt.Errorf("while writing OCI configuration: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	sylog.Infof("Running %s in bundle %s of container %s", shell, rec.Bundle, containerID)
+	return OciRun(ctx, containerID+"-debug", &OciArgs{
+		BundlePath: rec.Bundle,
+		ConfigPath: f.Name(),
+		// /etc/passwd and /etc/group were already bound by the container
+		NoPasswdGroup: true,
+	})
+}
+
+// debugSpec replaces the process of the OCI configuration of g by shell,
+// attached to a terminal when terminal is true, and removes what the failed
+// container left unusable: its working directory when missing, the bind
+// mounts of removed directories like scratch directories, and the joined
+// namespaces of containers gone.
+func debugSpec(g *generate.Generator, bundle, shell string, terminal bool) {
+	g.SetProcessArgs([]string{shell})
+	g.SetProcessTerminal(terminal)
+	if cwd := g.Config.Process.Cwd; cwd == "" || !containerDirExists(g, bundle, cwd) {
+		g.SetProcessCwd("/")
+	}
+
+	mounts := g.Config.Mounts[:0]
+	for _, m := range g.Config.Mounts {
+		if m.Type == "bind" || m.Type == "none" {
+			src := m.Source
+			if !filepath.IsAbs(src) {
+				src = filepath.Join(bundle, src)
+			}
+			if _, err := os.Stat(src); err != nil {
+				sylog.Warningf("Not mounting %s on %s: %s", m.Source, m.Destination, err)
+				continue
+			}
+		}
+		mounts = append(mounts, m)
+	}
+	g.Config.Mounts = mounts
+
+	if g.Config.Linux != nil {
+		for _, ns := range g.Config.Linux.Namespaces {
+			if ns.Path == "" {
+				continue
+			}
+			if _, err := os.Stat(ns.Path); err != nil {
+				sylog.Warningf("Not joining %s namespace %s: %s", ns.Type, ns.Path, err)
+				g.AddOrReplaceLinuxNamespace(ns.Type, "")
+			}
+		}
+	}
+}
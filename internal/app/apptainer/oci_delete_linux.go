@@ -49,6 +49,13 @@ func OciDelete(ctx context.Context, containerID string) error {
 		}
 	}
 
+	// keep the debug record of failed containers only
+	if state := engineConfig.State; state.Status == ociruntime.Stopped && state.ExitCode != nil && *state.ExitCode == 0 {
+		removeDebugRecord(containerID)
+	} else {
+		debugHint(containerID)
+	}
+
 	// remove instance files
 	file, err := instance.Get(containerID, instance.OciSubDir)
 	if err != nil {
@@ -0,0 +1,112 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/files"
+	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// addEtcFiles binds into the container /etc/passwd and /etc/group files
+// holding the entries of the user and groups of the container process, as
+// done by the native runtime. They are synthesized from the templates set
+// in args, or from the container files, and written in the bundle volumes
+// directory. Missing templates are skipped.
+func addEtcFiles(g *generate.Generator, bundle string, args *OciArgs) error {
+	if args.NoPasswdGroup || g.Config.Process == nil {
+		return nil
+	}
+	configPasswd, configGroup := true, true
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		configPasswd, configGroup = cfg.ConfigPasswd, cfg.ConfigGroup
+	}
+
+	rootfs := ""
+	if g.Config.Root != nil {
+		rootfs = g.Config.Root.Path
+		if !filepath.IsAbs(rootfs) {
+			rootfs = filepath.Join(bundle, rootfs)
+		}
+	}
+	dir := tools.Volumes(bundle).Path()
+	u := g.Config.Process.User
+	uid := int(u.UID)
+
+	if configPasswd {
+		template := args.PasswdTemplate
+		if template == "" {
+			template = filepath.Join(rootfs, "etc", "passwd")
+		}
+		content, err := files.Passwd(template, processHome(g.Config.Process), uid, nil)
+		if err != nil {
+			sylog.Verbosef("Not synthesizing /etc/passwd: %s", err)
+		} else if err := bindEtcFile(g, dir, "passwd", content); err != nil {
+			return err
+		}
+	}
+
+	if configGroup {
+		template := args.GroupTemplate
+		if template == "" {
+			template = filepath.Join(rootfs, "etc", "group")
+		}
+		gids := []int{int(u.GID)}
+		for _, gid := range u.AdditionalGids {
+			gids = append(gids, int(gid))
+		}
+		content, err := files.Group(template, uid, gids, nil)
+		if err != nil {
+			sylog.Verbosef("Not synthesizing /etc/group: %s", err)
+		} else if content != nil {
+			if err := bindEtcFile(g, dir, "group", content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// processHome returns the value of HOME in the environment of process,
+// or an empty string to use the home directory of the user.
+func processHome(process *specs.Process) string {
+	for _, env := range process.Env {
+		if home, ok := strings.CutPrefix(env, "HOME="); ok {
+			return home
+		}
+	}
+	return ""
+}
+
+// bindEtcFile writes content to the file .<name> in dir, and binds it
+// read-only on /etc/<name> in the container.
+func bindEtcFile(g *generate.Generator, dir, name string, content []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("while creating %s: %s", dir, err)
+	}
+	path := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("while writing %s: %s", path, err)
+	}
+	dest := filepath.Join("/etc", name)
+	g.RemoveMounts(dest)
+	g.AddMount(specs.Mount{
+		Destination: dest,
+		Type:        "bind",
+		Source:      path,
+		Options:     []string{"bind", "nosuid", "nodev", "ro"},
+	})
+	sylog.Verbosef("Default mount: %s:%s", dest, dest)
+	return nil
+}
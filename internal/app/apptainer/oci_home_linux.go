@@ -0,0 +1,100 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/util/user"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// homeTmpfsOptions are the mount options of the tmpfs home directory
+// requested with --contain.
+var homeTmpfsOptions = []string{"nosuid", "nodev", "mode=755"}
+
+// setHome sets up the home directory of the container process as requested
+// by --home, --no-home and --contain, with the same semantics as the
+// native runtime:
+//
+//   - --home src[:dest] binds the host directory src on dest, src by
+//     default, in the container;
+//   - --contain mounts a tmpfs on the home directory instead;
+//   - --no-home mounts nothing on the home directory, removing the mount
+//     of the OCI configuration on it, and starts the process in / when it
+//     would start in the home directory.
+//
+// The mounts of the OCI configuration on and below the home directory are
+// replaced by the --home and --contain mounts.
+//
+// The home directory in the container is dest, HOME from the process
+// environment, or the home directory of the process user on the host, in
+// this order, and HOME is set to it, the mount point being created by the
+// runtime if needed.
+func setHome(g *generate.Generator, args *OciArgs) error {
+	if args.Home == "" && !args.NoHome && !args.ContainHome {
+		return nil
+	}
+	if args.NoHome && (args.Home != "" || args.ContainHome) {
+		return fmt.Errorf("--no-home is mutually exclusive with --home and --contain")
+	}
+	if g.Config.Process == nil {
+		return fmt.Errorf("no process in OCI configuration")
+	}
+
+	src, dest, _ := strings.Cut(args.Home, ":")
+	if dest == "" {
+		dest = src
+	}
+	if dest == "" {
+		dest = processHome(g.Config.Process)
+	}
+	if dest == "" {
+		pw, err := user.GetPwUID(g.Config.Process.User.UID)
+		if err != nil {
+			return fmt.Errorf("while getting home directory of user %d: %s", g.Config.Process.User.UID, err)
+		}
+		dest = pw.Dir
+	}
+	if !filepath.IsAbs(dest) || (src != "" && !filepath.IsAbs(src)) {
+		return fmt.Errorf("home directory specification %q must use absolute paths", args.Home)
+	}
+	dest = filepath.Clean(dest)
+	g.SetProcessEnv("HOME", dest)
+
+	switch {
+	case args.NoHome:
+		mounts := g.Config.Mounts[:0]
+		for _, m := range g.Config.Mounts {
+			if filepath.Clean(m.Destination) != dest {
+				mounts = append(mounts, m)
+			}
+		}
+		g.Config.Mounts = mounts
+		if filepath.Clean(g.Config.Process.Cwd) == dest {
+			g.SetProcessCwd("/")
+		}
+		sylog.Verbosef("Not mounting home directory %s", dest)
+	case args.ContainHome:
+		g.RemoveMounts(dest)
+		g.AddTmpfsMount(dest, homeTmpfsOptions)
+		sylog.Verbosef("Mounting tmpfs home directory on %s", dest)
+	default:
+		g.RemoveMounts(dest)
+		g.AddMount(specs.Mount{
+			Destination: dest,
+			Type:        "bind",
+			Source:      filepath.Clean(src),
+			Options:     []string{"rbind", "nosuid", "nodev"},
+		})
+		sylog.Verbosef("Mounting home directory %s on %s", src, dest)
+	}
+	return nil
+}
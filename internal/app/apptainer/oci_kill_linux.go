@@ -20,7 +20,9 @@ import (
 	"github.com/apptainer/apptainer/pkg/util/unix"
 )
 
-// OciKill kills container process
+// OciKill kills container process with killSignal, the stop signal of the
+// image or SIGTERM when empty, and with SIGKILL if it's not stopped after
+// killTimeout seconds when positive.
 func OciKill(containerID string, killSignal string, killTimeout int) error {
 	// send signal to the instance
 	state, err := getState(containerID)
@@ -34,6 +36,10 @@ func OciKill(containerID string, killSignal string, killTimeout int) error {
 
 	sig := syscall.SIGTERM
 
+	// default to the stop signal of the image
+	if killSignal == "" {
+		killSignal = state.Annotations[ociruntime.AnnotationStopSignal]
+	}
 	if killSignal != "" {
 		sig, err = signal.Convert(killSignal)
 		if err != nil {
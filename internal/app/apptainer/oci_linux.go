@@ -24,16 +24,43 @@ import (
 
 // OciArgs contains CLI arguments
 type OciArgs struct {
-	BundlePath     string
-	LogPath        string
-	LogFormat      string
-	SyncSocketPath string
-	PidFile        string
-	FromFile       string
-	KillSignal     string
-	KillTimeout    uint32
-	EmptyProcess   bool
-	ForceKill      bool
+	BundlePath      string
+	ConfigPath      string
+	LogPath         string
+	LogFormat       string
+	SyncSocketPath  string
+	PidFile         string
+	FromFile        string
+	KillSignal      string
+	KillTimeout     uint32
+	EmptyProcess    bool
+	Init            bool
+	ForceKill       bool
+	FormatJSON      bool
+	ReadOnlyRoot    bool
+	Tmpfs           []string
+	NoMount         []string
+	Network         string
+	Netns           string
+	IPC             string
+	PID             string
+	CgroupParent    string
+	Security        []string
+	NoNewPrivs      bool
+	AllowNewPrivs   bool
+	Nvidia          bool
+	NoPasswdGroup   bool
+	PasswdTemplate  string
+	GroupTemplate   string
+	Home            string
+	NoHome          bool
+	ContainHome     bool
+	ScratchDirs     []string
+	WorkDir         string
+	Cwd             string
+	KeepCwd         bool
+	DebugKeepBundle bool
+	DebugShell      string
 }
 
 func getCommonConfig(containerID string) (*config.Common, error) {
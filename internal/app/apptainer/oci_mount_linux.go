@@ -10,23 +10,98 @@
 package apptainer
 
 import (
-	ocibundle "github.com/apptainer/apptainer/pkg/ocibundle/sif"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/syecl"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/teardown"
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/ocibundle"
+	dirbundle "github.com/apptainer/apptainer/pkg/ocibundle/dir"
+	sifbundle "github.com/apptainer/apptainer/pkg/ocibundle/sif"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 )
 
-// OciMount mount a SIF image to create an OCI bundle
-func OciMount(image string, bundle string) error {
-	d, err := ocibundle.FromSif(image, bundle, true)
+// OciMount mount a SIF image, or a directory tree used in place, to
+// create an OCI bundle. The OCI configuration generated for a SIF image
+// is cached in imgCache and reused while the image configuration doesn't
+// change. It is written to configPath instead of the bundle config.json
+// when set, to standard output for "-".
+func OciMount(image string, bundle string, imgCache *cache.Handle, configPath string) (err error) {
+	var d ocibundle.Bundle
+	var configOut io.Writer
+
+	processTeardowns()
+
+	switch configPath {
+	case "":
+	case "-":
+		configOut = os.Stdout
+	default:
+		f, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return fmt.Errorf("while creating OCI configuration file: %w", err)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("while writing OCI configuration file: %w", cerr)
+			}
+			if err != nil {
+				os.Remove(configPath)
+			}
+		}()
+		configOut = f
+	}
+
+	if fs.IsDir(image) {
+		if cfg := apptainerconf.GetCurrentConfig(); cfg != nil && !cfg.AllowContainerDir {
+			return fmt.Errorf("configuration disallows users from running sandbox containers")
+		}
+		d, err = dirbundle.FromDir(image, bundle, true, dirbundle.OptConfigOutput(configOut))
+	} else {
+		d, err = sifbundle.FromSif(image, bundle, true,
+			sifbundle.OptVerifyImage(checkECL),
+			sifbundle.OptSpecCache(imgCache),
+			sifbundle.OptConfigOutput(configOut),
+		)
+	}
 	if err != nil {
 		return err
 	}
 	return d.Create(nil)
 }
 
-// OciUmount umount SIF and delete OCI bundle
+// OciUmount umount SIF and delete OCI bundle, the bundle deletion
+// being the same for bundles created from directories
 func OciUmount(bundle string) error {
-	d, err := ocibundle.FromSif("", bundle, true)
+	processTeardowns()
+
+	d, err := sifbundle.FromSif("", bundle, true)
 	if err != nil {
 		return err
 	}
 	return d.Delete()
 }
+
+// processTeardowns completes the deletion of the bundles with busy mounts
+// left by previous invocations.
+func processTeardowns() {
+	if _, pending, err := teardown.Process(); err != nil {
+		sylog.Debugf("While processing deferred teardowns: %s", err)
+	} else if pending > 0 {
+		sylog.Debugf("%d deferred teardowns still pending", pending)
+	}
+}
+
+// checkECL enforces the execution control list on the SIF image before
+// it is mounted in the bundle, so that administrator policies apply the
+// same way as with the native runtime.
+func checkECL(img *image.Image) error {
+	return syecl.Enforce(context.TODO(), buildcfg.ECL_FILE, buildcfg.APPTAINER_CONFDIR, img.File)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/cdi"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/util/gpu"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// nvidiaCDIDevice is the CDI device requested by --nv when NVIDIA CDI
+// specifications are present on the host.
+const nvidiaCDIDevice = "nvidia.com/gpu=all"
+
+// containerLibsDir is the directory in the container where GPU libraries
+// are bound, and added to LD_LIBRARY_PATH.
+const containerLibsDir = "/.singularity.d/libs"
+
+// addNvidiaGPUs sets up the NVIDIA GPUs of the host in the OCI
+// specification of g. The nvidia.com/gpu=all CDI device is used when found,
+// otherwise the devices are added and the libraries and binaries listed in
+// nvliblist.conf are bound into the container, as done by the native runtime.
+func addNvidiaGPUs(g *generate.Generator) error {
+	var dirs []string
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		dirs = cfg.CDIDirs
+	}
+	if d, err := cdi.Scan(cdi.DirsOrDefault(dirs)).Device(nvidiaCDIDevice); err == nil {
+		sylog.Debugf("Using CDI device %s for nv GPU setup", nvidiaCDIDevice)
+		edits := d.Edits()
+		return edits.ApplyToSpec(g)
+	}
+	return addNvidiaLegacy(g)
+}
+
+// addNvidiaLegacy sets up the NVIDIA GPUs of the host via direct binds of
+// the configured libraries and binaries.
+func addNvidiaLegacy(g *generate.Generator) error {
+	sylog.Debugf("Using legacy binds for nv GPU setup")
+	gpuConfFile := filepath.Join(buildcfg.APPTAINER_CONFDIR, "nvliblist.conf")
+	// bind persistenced socket if found
+	ipcs, err := gpu.NvidiaIpcsPath()
+	if err != nil {
+		sylog.Warningf("While finding nv ipcs: %v", err)
+	}
+	libs, bins, files, err := gpu.NvidiaPaths(gpuConfFile)
+	if err != nil {
+		sylog.Warningf("While finding nv bind points: %v", err)
+	}
+	devs, err := gpu.NvidiaDevices(true)
+	if err != nil {
+		sylog.Warningf("While finding nv devices: %v", err)
+	}
+	if len(libs)+len(bins)+len(files)+len(devs) == 0 {
+		sylog.Warningf("Could not find any nv files on this host!")
+		return nil
+	}
+	addGPUBinds(g, libs, bins, append(ipcs, files...))
+
+	for _, dev := range devs {
+		d, err := cdi.HostDevice(dev, dev)
+		if err != nil {
+			sylog.Warningf("While adding nv device: %v", err)
+			continue
+		}
+		cdi.AddDevice(g, d, "")
+	}
+	return nil
+}
+
+// addGPUBinds adds read-only bind mounts of the GPU libraries into the
+// container libraries directory, of the binaries into /usr/bin and of
+// the other files at the same location, and adds the libraries directory
+// to LD_LIBRARY_PATH.
+func addGPUBinds(g *generate.Generator, libs, bins, files []string) {
+	bind := func(src, dest string) {
+		g.AddMount(specs.Mount{
+			Destination: dest,
+			Type:        "bind",
+			Source:      src,
+			Options:     []string{"rbind", "nosuid", "nodev", "ro"},
+		})
+	}
+	for _, lib := range libs {
+		bind(lib, filepath.Join(containerLibsDir, filepath.Base(lib)))
+	}
+	for _, bin := range bins {
+		bind(bin, filepath.Join("/usr/bin", filepath.Base(bin)))
+	}
+	for _, file := range files {
+		bind(file, file)
+	}
+	if len(libs) == 0 {
+		return
+	}
+
+	ldLibraryPath := containerLibsDir
+	if g.Config.Process != nil {
+		for _, env := range g.Config.Process.Env {
+			if v, ok := strings.CutPrefix(env, "LD_LIBRARY_PATH="); ok && v != "" {
+				ldLibraryPath = v + ":" + containerLibsDir
+			}
+		}
+	}
+	g.SetProcessEnv("LD_LIBRARY_PATH", ldLibraryPath)
+}
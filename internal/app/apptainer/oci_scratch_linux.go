@@ -0,0 +1,78 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/oci"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// scratchTmpfsOptions are the mount options of the tmpfs scratch
+// directories.
+var scratchTmpfsOptions = []string{"nosuid", "nodev", "mode=1777"}
+
+// addScratchDirs adds the scratch directories requested with --scratch to
+// the OCI specification of g. They are tmpfs mounts, or bind mounts of
+// directories created for the container in the --workdir directory, which
+// are removed by the engine when the container exits.
+func addScratchDirs(g *generate.Generator, engineConfig *oci.EngineConfig, containerID string, args *OciArgs) error {
+	dests := make([]string, 0, len(args.ScratchDirs))
+	for _, dir := range args.ScratchDirs {
+		dest := filepath.Clean(dir)
+		if !filepath.IsAbs(dest) || dest == "/" {
+			return fmt.Errorf("--scratch: %s is not an absolute path to a container directory", dir)
+		}
+		dests = append(dests, dest)
+	}
+	if len(dests) == 0 {
+		return nil
+	}
+
+	if args.WorkDir == "" {
+		for _, dest := range dests {
+			g.AddTmpfsMount(dest, scratchTmpfsOptions)
+			sylog.Verbosef("Mounting tmpfs scratch directory on %s", dest)
+		}
+		return nil
+	}
+
+	base, err := os.MkdirTemp(args.WorkDir, "scratch-"+containerID+"-")
+	if err != nil {
+		return fmt.Errorf("--scratch: while creating scratch directory: %s", err)
+	}
+	engineConfig.ScratchDirs = append(engineConfig.ScratchDirs, base)
+
+	var uid, gid int
+	if g.Config.Process != nil {
+		uid, gid = int(g.Config.Process.User.UID), int(g.Config.Process.User.GID)
+	}
+	for _, dest := range dests {
+		src := filepath.Join(base, dest)
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			os.RemoveAll(base)
+			return fmt.Errorf("--scratch: while creating scratch directory: %s", err)
+		}
+		if err := os.Lchown(src, uid, gid); err != nil {
+			os.RemoveAll(base)
+			return fmt.Errorf("--scratch: while changing owner of scratch directory: %s", err)
+		}
+		g.RemoveMounts(dest)
+		g.AddMount(specs.Mount{
+			Destination: dest,
+			Type:        "bind",
+			Source:      src,
+			Options:     []string{"rbind", "nosuid", "nodev"},
+		})
+		sylog.Verbosef("Mounting scratch directory %s on %s", src, dest)
+	}
+	return nil
+}
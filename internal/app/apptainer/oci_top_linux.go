@@ -0,0 +1,27 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apptainer/apptainer/pkg/ociruntime"
+)
+
+// OciTop prints the processes running in a container.
+func OciTop(containerID string, formatJSON bool) error {
+	state, err := getState(containerID)
+	if err != nil {
+		return err
+	}
+	switch state.Status {
+	case ociruntime.Created, ociruntime.Running, ociruntime.Paused:
+	default:
+		return fmt.Errorf("container %s is %s", containerID, state.Status)
+	}
+	return printTop(os.Stdout, state.Pid, formatJSON)
+}
@@ -0,0 +1,41 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// oidcDeviceLogin runs the device authorization grant against the OIDC
+// issuer, asking the user to authorize the login in a browser, which may be
+// on another machine.
+func oidcDeviceLogin(ctx context.Context, issuer, clientID string) (*oauth.Provider, *oauth.Token, error) {
+	p, err := oauth.Discover(ctx, issuer, clientID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := p.DeviceLogin(ctx, func(da *oauth.DeviceAuthorization) {
+		if da.VerificationURIComplete != "" {
+			fmt.Printf("Open %s in a browser to log in,\nand check that it shows the code %s.\n", da.VerificationURIComplete, da.UserCode)
+		} else {
+			fmt.Printf("Open %s in a browser to log in,\nand enter the code %s.\n", da.VerificationURI, da.UserCode)
+		}
+		fmt.Println("Waiting for authorization...")
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if t.RefreshToken == "" {
+		sylog.Warningf("No refresh token was issued by %s, you will need to log in again when the access token expires", p.Issuer)
+	}
+	return p, t, nil
+}
@@ -21,8 +21,9 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
 )
 
-// RemoteAdd adds remote to configuration
-func RemoteAdd(configFile, name, uri string, global bool, insecure bool, makeDefault bool) (err error) {
+// RemoteAdd adds remote to configuration. A non-empty libraryRegistry
+// declares an OCI registry serving library:// references for the remote.
+func RemoteAdd(configFile, name, uri string, global bool, insecure bool, makeDefault bool, libraryRegistry string) (err error) {
 	// Explicit handling of corner cases: name and uri must be valid strings
 	if strings.TrimSpace(name) == "" {
 		return fmt.Errorf("invalid name: cannot have empty name")
@@ -54,7 +55,7 @@ func RemoteAdd(configFile, name, uri string, global bool, insecure bool, makeDef
 	if err != nil {
 		return err
 	}
-	e := endpoint.Config{URI: path.Join(u.Host + u.Path), System: global, Insecure: insecure}
+	e := endpoint.Config{URI: path.Join(u.Host + u.Path), System: global, Insecure: insecure, LibraryRegistry: libraryRegistry}
 
 	if err := c.Add(name, &e); err != nil {
 		return err
@@ -11,6 +11,7 @@
 package apptainer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -18,8 +19,10 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/remote"
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
 	"github.com/apptainer/apptainer/internal/pkg/util/auth"
 	"github.com/apptainer/apptainer/internal/pkg/util/interactive"
+	"github.com/apptainer/apptainer/pkg/syfs"
 	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
@@ -30,6 +33,11 @@ type LoginArgs struct {
 	Tokenfile   string
 	Insecure    bool
 	ReqAuthFile string
+	// OIDC logs in with the OAuth2 device authorization grant of the
+	// OIDC provider, instead of a static token.
+	OIDC         bool
+	OIDCIssuer   string
+	OIDCClientID string
 }
 
 // ErrLoginAborted is raised when the login process has been aborted by the user
@@ -65,7 +73,11 @@ func RemoteLogin(usrConfigFile string, args *LoginArgs) (err error) {
 
 	if r != nil {
 		// endpoints (sylabs cloud, Singularity enterprise etc.)
-		err := endPointLogin(r, args)
+		login := endPointLogin
+		if args.OIDC {
+			login = endPointOIDCLogin
+		}
+		err := login(r, args)
 		if err == ErrLoginAborted {
 			return nil
 		}
@@ -74,6 +86,9 @@ func RemoteLogin(usrConfigFile string, args *LoginArgs) (err error) {
 		}
 	} else {
 		// services (oci registry, single keyserver etc.)
+		if args.OIDC {
+			return fmt.Errorf("--oidc is only supported for login to a remote endpoint or with 'keyserver login'")
+		}
 		if args.Tokenfile != "" {
 			return fmt.Errorf("--tokenfile is only supported for login to a remote endpoint, not OCI (docker/oras) or keyservers")
 		}
@@ -98,7 +113,11 @@ func RemoteLogin(usrConfigFile string, args *LoginArgs) (err error) {
 		return fmt.Errorf("failed to flush remote config file %s: %s", file.Name(), err)
 	}
 
-	sylog.Infof("Token stored in %s", file.Name())
+	if args.OIDC {
+		sylog.Infof("Token stored in %s", syfs.OAuthTokens())
+	} else {
+		sylog.Infof("Token stored in %s", file.Name())
+	}
 	return nil
 }
 
@@ -154,3 +173,46 @@ func endPointLogin(ep *endpoint.Config, args *LoginArgs) error {
 	ep.Token = token
 	return nil
 }
+
+// endPointOIDCLogin implements the flow to log in to a remote endpoint with
+// the device authorization grant. The OIDC provider is the one advertised by
+// the endpoint, unless set in args. The tokens obtained are kept in the
+// OAuth token store, so that the access token is refreshed transparently,
+// and any static token of the endpoint is removed.
+func endPointOIDCLogin(ep *endpoint.Config, args *LoginArgs) error {
+	if args.Tokenfile != "" {
+		return fmt.Errorf("--tokenfile can't be used with --oidc")
+	}
+
+	issuer, clientID := args.OIDCIssuer, args.OIDCClientID
+	if issuer == "" || clientID == "" {
+		epIssuer, epClientID, err := ep.OIDCProvider()
+		if err != nil {
+			return fmt.Errorf("while getting OIDC provider of remote: %v", err)
+		}
+		if issuer == "" {
+			issuer = epIssuer
+		}
+		if clientID == "" {
+			clientID = epClientID
+		}
+	}
+	if issuer == "" || clientID == "" {
+		return fmt.Errorf("remote doesn't advertise an OIDC provider, use --oidc-issuer and --oidc-client-id")
+	}
+
+	ctx := context.Background()
+	p, t, err := oidcDeviceLogin(ctx, issuer, clientID)
+	if err != nil {
+		return err
+	}
+
+	if err := ep.VerifyToken(t.AccessToken); err != nil {
+		return fmt.Errorf("while verifying token: %v", err)
+	}
+	if err := oauth.DefaultStore().Set(ep.URI, p, t); err != nil {
+		return fmt.Errorf("while storing token: %v", err)
+	}
+	ep.Token = ""
+	return nil
+}
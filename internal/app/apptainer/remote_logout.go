@@ -17,6 +17,7 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/remote"
 	"github.com/apptainer/apptainer/internal/pkg/remote/endpoint"
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
 	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
@@ -49,6 +50,9 @@ func RemoteLogout(usrConfigFile, name string) (err error) {
 	if r != nil {
 		// endpoint
 		r.Token = ""
+		if _, err := oauth.DefaultStore().Delete(r.URI); err != nil {
+			return fmt.Errorf("while removing OIDC token: %v", err)
+		}
 	} else {
 		// services
 		sylog.Warningf("'remote logout' is deprecated for registries or keyservers and will be removed in a future release; running 'registry logout'")
@@ -11,6 +11,7 @@
 package apptainer
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sort"
@@ -150,6 +151,10 @@ func printLoggedInIdentity(config *containerclient.Config) {
 }
 
 func doTokenCheck(e *endpoint.Config) error {
+	if err := e.LoadOAuthToken(context.Background()); err != nil {
+		fmt.Println("\nOIDC access token can't be refreshed (please login again).")
+		return err
+	}
 	if e.Token == "" {
 		fmt.Println("\nNo authentication token set (logged out).")
 		return nil
@@ -0,0 +1,306 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/ociimage"
+	"github.com/apptainer/apptainer/internal/pkg/util/env"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	da "github.com/docker/docker/pkg/archive"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DockerArchiveFormat is the format of the archives loaded by docker
+	// load and podman load.
+	DockerArchiveFormat = "docker-archive"
+	// OCIArchiveFormat is the format of an OCI image layout archive.
+	OCIArchiveFormat = "oci-archive"
+)
+
+// SaveOptions are the options of Save.
+type SaveOptions struct {
+	// Format is DockerArchiveFormat or OCIArchiveFormat.
+	Format string
+	// Tag is the image reference recorded in the archive, derived from the
+	// source name when empty.
+	Tag string
+	// TmpDir is the directory holding the temporary files.
+	TmpDir string
+	// Config overrides the synthesized image configuration.
+	Config ImageConfigOptions
+}
+
+// ImageConfigOptions override the OCI image configuration synthesized from
+// the image metadata.
+type ImageConfigOptions struct {
+	// ConfigFile is the path of a JSON file holding the execution parameters
+	// of an OCI image configuration, the "config" object, applied first.
+	ConfigFile string
+	// Entrypoint replaces the image entrypoint when not empty.
+	Entrypoint []string
+	// Env holds KEY=VALUE variables added to the image environment.
+	Env []string
+	// Labels are added to the image labels.
+	Labels map[string]string
+}
+
+// Save writes the image source, as accepted by Export, to the archive dest
+// as a single layer OCI image.
+func Save(source, dest string, opts SaveOptions) error {
+	if opts.Format != DockerArchiveFormat && opts.Format != OCIArchiveFormat {
+		return fmt.Errorf("unsupported archive format %q, must be %s or %s", opts.Format, DockerArchiveFormat, OCIArchiveFormat)
+	}
+	tag := opts.Tag
+	if tag == "" {
+		tag = defaultTag(source)
+	}
+	ref, err := name.NewTag(tag)
+	if err != nil {
+		return fmt.Errorf("invalid image tag %q: %w", tag, err)
+	}
+
+	img, cleanup, err := ociImage(source, opts.TmpDir, opts.Config)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if opts.Format == DockerArchiveFormat {
+		err = tarball.Write(ref, img, f)
+	} else {
+		err = writeOCIArchive(ref, img, f, opts.TmpDir)
+	}
+	if err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("while writing %s: %w", dest, err)
+	}
+	return f.Close()
+}
+
+// ociImage returns a single layer OCI image holding the filesystem of
+// source, with a configuration synthesized from its metadata and overridden
+// by co. The returned function removes the temporary layer file.
+func ociImage(source, tmpDir string, co ImageConfigOptions) (v1.Image, func(), error) {
+	root, excludes, cleanupRoot, err := exportRoot(source, tmpDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanupRoot()
+
+	cfg, err := imageConfig(source, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := co.apply(&cfg.Config); err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.CreateTemp(tmpDir, "layer-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("while creating temporary layer file: %w", err)
+	}
+	layerPath := f.Name()
+	cleanup := func() { os.Remove(layerPath) }
+	_, err = writeLayer(root, excludes, f, true)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	img, err := singleLayerImage(cfg, layerPath)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	// the provenance labels of the image are annotations of its manifest too
+	if annotations := ociimage.ImageAnnotations(cfg.Config.Labels); len(annotations) > 0 {
+		img = mutate.Annotations(img, annotations).(v1.Image)
+	}
+	return img, cleanup, nil
+}
+
+// singleLayerImage returns an OCI image with the configuration cfg and the
+// gzip compressed layer at layerPath.
+func singleLayerImage(cfg *v1.ConfigFile, layerPath string) (v1.Image, error) {
+	layer, err := tarball.LayerFromFile(layerPath, tarball.WithMediaType(types.OCILayer))
+	if err != nil {
+		return nil, err
+	}
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, types.OCIConfigJSON)
+	img, err = mutate.ConfigFile(img, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return mutate.AppendLayers(img, layer)
+}
+
+// imageConfig returns an OCI image configuration for the root filesystem
+// root of source. The labels are read from the image metadata, and the
+// entrypoint runs the image runscript with the image environment. The
+// working directory, exposed ports and stop signal are preserved from the
+// OCI configuration stored in source when it was built from an OCI image.
+func imageConfig(source, root string) (*v1.ConfigFile, error) {
+	cfg := &v1.ConfigFile{
+		Architecture: runtime.GOARCH,
+		OS:           "linux",
+		Created:      v1.Time{Time: time.Now().UTC()},
+		Config: v1.Config{
+			Env: []string{"PATH=" + env.DefaultPath},
+		},
+	}
+	if f, err := sif.LoadContainerFromPath(source, sif.OptLoadWithFlag(os.O_RDONLY)); err == nil {
+		cfg.Architecture = f.PrimaryArch()
+		f.UnloadContainer()
+	}
+
+	b, err := os.ReadFile(filepath.Join(root, ".singularity.d", "labels.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("while reading image labels: %w", err)
+	} else if err == nil {
+		if err := json.Unmarshal(b, &cfg.Config.Labels); err != nil {
+			return nil, fmt.Errorf("while decoding image labels: %w", err)
+		}
+	}
+
+	if fs.IsFile(filepath.Join(root, ".singularity.d", "actions", "run")) {
+		cfg.Config.Entrypoint = []string{"/.singularity.d/actions/run"}
+	}
+	if oc := imageOCIConfig(source); oc != nil {
+		preserveImageConfig(&cfg.Config, oc)
+	}
+	return cfg, nil
+}
+
+// preserveImageConfig copies to c the settings of the OCI configuration oc
+// which don't depend on the image environment and runscript.
+func preserveImageConfig(c *v1.Config, oc *ocispec.ImageConfig) {
+	c.WorkingDir = oc.WorkingDir
+	c.StopSignal = oc.StopSignal
+	if len(oc.ExposedPorts) > 0 {
+		c.ExposedPorts = make(map[string]struct{}, len(oc.ExposedPorts))
+		for p := range oc.ExposedPorts {
+			c.ExposedPorts[p] = struct{}{}
+		}
+	}
+}
+
+// empty returns whether co doesn't override the image configuration.
+func (co ImageConfigOptions) empty() bool {
+	return co.ConfigFile == "" && len(co.Entrypoint) == 0 && len(co.Env) == 0 && len(co.Labels) == 0
+}
+
+// apply applies the overrides of co to the image configuration c.
+func (co ImageConfigOptions) apply(c *v1.Config) error {
+	if co.ConfigFile != "" {
+		b, err := os.ReadFile(co.ConfigFile)
+		if err != nil {
+			return fmt.Errorf("while reading image configuration: %w", err)
+		}
+		if err := json.Unmarshal(b, c); err != nil {
+			return fmt.Errorf("while decoding image configuration %s: %w", co.ConfigFile, err)
+		}
+	}
+	if len(co.Entrypoint) > 0 {
+		c.Entrypoint = co.Entrypoint
+	}
+	for _, e := range co.Env {
+		k, _, ok := strings.Cut(e, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("invalid environment variable %q, must be KEY=VALUE", e)
+		}
+		c.Env = slices.DeleteFunc(c.Env, func(v string) bool {
+			return strings.HasPrefix(v, k+"=")
+		})
+		c.Env = append(c.Env, e)
+	}
+	if len(co.Labels) > 0 && c.Labels == nil {
+		c.Labels = make(map[string]string, len(co.Labels))
+	}
+	for k, v := range co.Labels {
+		c.Labels[k] = v
+	}
+	return nil
+}
+
+var tagInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// defaultTag returns an image tag derived from the name of source.
+func defaultTag(source string) string {
+	n := strings.TrimPrefix(source, "instance://")
+	n = filepath.Base(filepath.Clean(n))
+	if i := strings.Index(n, "."); i > 0 {
+		n = n[:i]
+	}
+	n = strings.Trim(tagInvalidChars.ReplaceAllString(strings.ToLower(n), "-"), "._-")
+	if n == "" {
+		n = "image"
+	}
+	return n + ":latest"
+}
+
+// writeOCIArchive writes img to w as a tar archive of an OCI image layout,
+// the image being annotated with the tag of ref.
+func writeOCIArchive(ref name.Tag, img v1.Image, w io.Writer, tmpDir string) error {
+	dir, err := os.MkdirTemp(tmpDir, "oci-layout-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeOCILayout(ref, img, dir); err != nil {
+		return err
+	}
+
+	rc, err := da.TarWithOptions(dir, &da.TarOptions{})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// writeOCILayout writes img to the OCI image layout directory dir, the image
+// being annotated with the tag of ref.
+func writeOCILayout(ref name.Tag, img v1.Image, dir string) error {
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return err
+	}
+	return p.AppendImage(img, layout.WithAnnotations(map[string]string{
+		ocispec.AnnotationRefName: ref.String(),
+	}))
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestDefaultTag(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"image.sif", "image:latest"},
+		{"/data/My_Image.oci.sif", "my_image:latest"},
+		{"sandbox/", "sandbox:latest"},
+		{"instance://web", "web:latest"},
+		{"/tmp/.hidden", "hidden:latest"},
+		{"@@@", "image:latest"},
+	}
+	for _, tt := range tests {
+		if got := defaultTag(tt.source); got != tt.want {
+			t.Errorf("defaultTag(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestImageConfigOptionsApply(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configFile, []byte(`{"Cmd":["--help"],"Labels":{"a":"file","b":"file"}}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := v1.Config{
+		Entrypoint: []string{"/.singularity.d/actions/run"},
+		Env:        []string{"PATH=/bin", "HOME=/root"},
+	}
+	co := ImageConfigOptions{
+		ConfigFile: configFile,
+		Entrypoint: []string{"/app", "serve"},
+		Env:        []string{"PATH=/usr/bin", "LANG=C"},
+		Labels:     map[string]string{"b": "flag"},
+	}
+	if err := co.apply(&c); err != nil {
+		t.Fatal(err)
+	}
+	want := v1.Config{
+		Entrypoint: []string{"/app", "serve"},
+		Cmd:        []string{"--help"},
+		Env:        []string{"HOME=/root", "PATH=/usr/bin", "LANG=C"},
+		Labels:     map[string]string{"a": "file", "b": "flag"},
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got config %+v, want %+v", c, want)
+	}
+
+	if err := (ImageConfigOptions{Env: []string{"=x"}}).apply(&c); err == nil {
+		t.Errorf("unexpected success with invalid environment variable")
+	}
+	if !(ImageConfigOptions{Env: []string{}}).empty() {
+		t.Errorf("options with an empty environment are not empty")
+	}
+}
+
+func TestPreserveImageConfig(t *testing.T) {
+	c := v1.Config{Entrypoint: []string{"/.singularity.d/actions/run"}}
+	preserveImageConfig(&c, &ocispec.ImageConfig{
+		Entrypoint:   []string{"/bin/server"},
+		WorkingDir:   "/srv",
+		StopSignal:   "SIGTERM",
+		ExposedPorts: map[string]struct{}{"80/tcp": {}, "53/udp": {}},
+	})
+	expected := v1.Config{
+		Entrypoint:   []string{"/.singularity.d/actions/run"},
+		WorkingDir:   "/srv",
+		StopSignal:   "SIGTERM",
+		ExposedPorts: map[string]struct{}{"80/tcp": {}, "53/udp": {}},
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("got configuration %+v, expected %+v", c, expected)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// SquashOptions are the options of Squash.
+type SquashOptions struct {
+	// Recompress recompresses an image which already has a single layer.
+	Recompress bool
+	// CompressionLevel is the gzip compression level of the squashed layer.
+	CompressionLevel int
+	// TmpDir is the directory holding the temporary files.
+	TmpDir string
+}
+
+// Squash replaces, in place, the layers of the OCI-SIF image at path with a
+// single layer holding the flattened filesystem of the image. The history of
+// the layers is dropped from the image configuration.
+func Squash(path string, opts SquashOptions) error {
+	if opts.CompressionLevel < gzip.HuffmanOnly || opts.CompressionLevel > gzip.BestCompression {
+		return fmt.Errorf("invalid compression level %d", opts.CompressionLevel)
+	}
+	if !IsOCISIF(path) {
+		return fmt.Errorf("%s is not an OCI-SIF image", path)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp(opts.TmpDir, "squash-")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	if err := sifToLayout(path, src); err != nil {
+		return fmt.Errorf("while extracting OCI image from %s: %w", path, err)
+	}
+	p, err := layout.FromPath(src)
+	if err != nil {
+		return err
+	}
+	idx, err := p.ImageIndex()
+	if err != nil {
+		return err
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+	if len(im.Manifests) != 1 {
+		return fmt.Errorf("%s holds %d images, only OCI-SIF images holding a single image can be squashed", path, len(im.Manifests))
+	}
+	desc := im.Manifests[0]
+	img, err := idx.Image(desc.Digest)
+	if err != nil {
+		return err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	if len(layers) == 1 && !opts.Recompress {
+		sylog.Infof("%s already has a single layer", path)
+		return nil
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return err
+	}
+	cfg = cfg.DeepCopy()
+	cfg.RootFS.DiffIDs = nil
+	cfg.History = nil
+
+	sylog.Infof("Squashing %d layers of %s", len(layers), path)
+	layerPath := filepath.Join(dir, "layer.tar.gz")
+	f, err := os.Create(layerPath)
+	if err != nil {
+		return err
+	}
+	rc := mutate.Extract(img)
+	_, err = compressLayer(rc, f, opts.CompressionLevel)
+	rc.Close()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("while squashing layers: %w", err)
+	}
+
+	squashed, err := singleLayerImage(cfg, layerPath)
+	if err != nil {
+		return err
+	}
+	ref, err := name.NewTag(desc.Annotations[ocispec.AnnotationRefName])
+	if err != nil {
+		ref, err = name.NewTag(defaultTag(path))
+		if err != nil {
+			return err
+		}
+	}
+	dst := filepath.Join(dir, "dst")
+	if err := writeOCILayout(ref, squashed, dst); err != nil {
+		return fmt.Errorf("while writing OCI image: %w", err)
+	}
+
+	// create the new image next to the original one, and replace it once
+	// complete
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".squash-")
+	if err != nil {
+		return err
+	}
+	tmpImage := tmp.Name()
+	tmp.Close()
+	os.Remove(tmpImage)
+	if err := layoutToSIF(dst, tmpImage); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpImage, fi.Mode().Perm()); err != nil {
+		os.Remove(tmpImage)
+		return err
+	}
+	if err := os.Rename(tmpImage, path); err != nil {
+		os.Remove(tmpImage)
+		return err
+	}
+	return nil
+}
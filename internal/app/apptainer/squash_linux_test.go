@@ -0,0 +1,111 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+// ociSIFImage returns the single image held by the OCI-SIF image at path.
+func ociSIFImage(t *testing.T, path string) v1.Image {
+	dir := filepath.Join(t.TempDir(), "layout")
+	if err := sifToLayout(path, dir); err != nil {
+		t.Fatal(err)
+	}
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := p.ImageIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := idx.Image(im.Manifests[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// fileCount returns the number of entries of the flattened filesystem of img.
+func fileCount(t *testing.T, img v1.Image) int {
+	rc := mutate.Extract(img)
+	defer rc.Close()
+	n := 0
+	tr := tar.NewReader(rc)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			return n
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+}
+
+func TestSquash(t *testing.T) {
+	img, err := random.Image(512, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag("test:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := writeOCILayout(ref, img, filepath.Join(dir, "layout")); err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(dir, "image.sif")
+	if err := layoutToSIF(filepath.Join(dir, "layout"), image); err != nil {
+		t.Fatal(err)
+	}
+	want := fileCount(t, img)
+
+	if err := Squash(image, SquashOptions{CompressionLevel: 100}); err == nil {
+		t.Errorf("unexpected success with invalid compression level")
+	}
+	if err := Squash(dir, SquashOptions{CompressionLevel: gzip.DefaultCompression}); err == nil {
+		t.Errorf("unexpected success with a directory")
+	}
+	if err := Squash(image, SquashOptions{CompressionLevel: gzip.BestSpeed}); err != nil {
+		t.Fatalf("squash failed: %v", err)
+	}
+
+	squashed := ociSIFImage(t, image)
+	layers, err := squashed.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 1 {
+		t.Errorf("got %d layers, want 1", len(layers))
+	}
+	if got := fileCount(t, squashed); got != want {
+		t.Errorf("got %d files, want %d", got, want)
+	}
+	cfg, err := squashed.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.RootFS.DiffIDs) != 1 {
+		t.Errorf("got %d diff IDs, want 1", len(cfg.RootFS.DiffIDs))
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package apptainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/user"
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+	units "github.com/docker/go-units"
+	"golang.org/x/sys/unix"
+)
+
+// topProcess is a process running in a container, as reported by the top
+// commands.
+type topProcess struct {
+	HostPid int     `json:"hostPid"`
+	Pid     int     `json:"pid"`
+	PPid    int     `json:"ppid"`
+	User    string  `json:"user"`
+	CPU     float64 `json:"cpu"`
+	Mem     float64 `json:"mem"`
+	RSS     uint64  `json:"rss"`
+	Time    string  `json:"time"`
+	Command string  `json:"command"`
+}
+
+// containerProcesses returns the processes of the container whose init
+// process is pid. When the container has its own PID namespace, these are
+// all the processes of the namespace, including those joining it with
+// exec, otherwise the processes descending from pid.
+func containerProcesses(pid int) ([]*proc.Process, error) {
+	hasPidNs, err := proc.HasNamespace(pid, "pid")
+	if err != nil {
+		return nil, fmt.Errorf("while checking PID namespace of process %d: %s", pid, err)
+	}
+	var st syscall.Stat_t
+	if err := syscall.Stat(fmt.Sprintf("/proc/%d/ns/pid", pid), &st); err != nil {
+		return nil, fmt.Errorf("while checking PID namespace of process %d: %s", pid, err)
+	}
+
+	matches, _ := filepath.Glob("/proc/[0-9]*")
+	all := make(map[int]*proc.Process)
+	var ps []*proc.Process
+	for _, path := range matches {
+		p, err := strconv.Atoi(filepath.Base(path))
+		if err != nil {
+			continue
+		}
+		// processes may exit while listing them
+		process, err := proc.GetProcess(p)
+		if err != nil {
+			continue
+		}
+		if !hasPidNs {
+			all[p] = process
+			continue
+		}
+		var pst syscall.Stat_t
+		if err := syscall.Stat(filepath.Join(path, "ns", "pid"), &pst); err == nil && pst.Ino == st.Ino {
+			ps = append(ps, process)
+		}
+	}
+
+	if !hasPidNs {
+		// a process is in the container if one of its ancestors is pid
+		for _, process := range all {
+			for ancestor := process; ancestor != nil; ancestor = all[ancestor.PPid] {
+				if ancestor.Pid == pid {
+					ps = append(ps, process)
+					break
+				}
+			}
+		}
+	}
+	if len(ps) == 0 {
+		return nil, fmt.Errorf("no process found for container process %d", pid)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Pid < ps[j].Pid })
+	return ps, nil
+}
+
+// printTop prints the processes of the container whose init process is pid,
+// with their PID on the host and in the container, their user, their CPU and
+// memory usage, in a table or in JSON format.
+func printTop(w io.Writer, pid int, formatJSON bool) error {
+	ps, err := containerProcesses(pid)
+	if err != nil {
+		return err
+	}
+	uptime, err := proc.Uptime()
+	if err != nil {
+		return fmt.Errorf("while reading uptime: %s", err)
+	}
+	var info unix.Sysinfo_t
+	if err := unix.Sysinfo(&info); err != nil {
+		return fmt.Errorf("while reading system information: %s", err)
+	}
+	totalRAM := float64(info.Totalram) * float64(info.Unit)
+
+	// host PID to container PID of the container processes, the parent
+	// of processes whose parent is outside the container is reported as 0
+	nsPids := make(map[int]int, len(ps))
+	for _, p := range ps {
+		nsPids[p.Pid] = p.NSpid[len(p.NSpid)-1]
+	}
+
+	users := make(map[int]string)
+	processes := make([]topProcess, 0, len(ps))
+	for _, p := range ps {
+		name, ok := users[p.UID]
+		if !ok {
+			name = strconv.Itoa(p.UID)
+			if u, err := user.GetPwUID(uint32(p.UID)); err == nil {
+				name = u.Name
+			}
+			users[p.UID] = name
+		}
+		tp := topProcess{
+			HostPid: p.Pid,
+			Pid:     nsPids[p.Pid],
+			PPid:    nsPids[p.PPid],
+			User:    name,
+			RSS:     p.RSS,
+			Time:    formatCPUTime(p.CPUTime),
+			Command: p.Command,
+		}
+		// like ps, the CPU usage is averaged over the process lifetime
+		if elapsed := uptime - p.StartTime; elapsed > 0 {
+			tp.CPU = 100 * float64(p.CPUTime) / float64(elapsed)
+		}
+		if totalRAM > 0 {
+			tp.Mem = 100 * float64(p.RSS) / totalRAM
+		}
+		processes = append(processes, tp)
+	}
+
+	if formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		if err := enc.Encode(map[string][]topProcess{"processes": processes}); err != nil {
+			return fmt.Errorf("could not encode process list: %v", err)
+		}
+		return nil
+	}
+
+	tabWriter := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	_, err = fmt.Fprintln(tabWriter, "HOST PID\tPID\tPPID\tUSER\t%CPU\t%MEM\tRSS\tTIME\tCOMMAND")
+	if err != nil {
+		return fmt.Errorf("could not write process list header: %v", err)
+	}
+	for _, p := range processes {
+		_, err = fmt.Fprintf(tabWriter, "%d\t%d\t%d\t%s\t%.1f\t%.1f\t%s\t%s\t%s\n",
+			p.HostPid, p.Pid, p.PPid, p.User, p.CPU, p.Mem, units.BytesSize(float64(p.RSS)), p.Time, p.Command)
+		if err != nil {
+			return fmt.Errorf("could not write process info: %v", err)
+		}
+	}
+	return nil
+}
+
+// formatCPUTime formats a CPU time like ps, as [DD-]HH:MM:SS.
+func formatCPUTime(d time.Duration) string {
+	s := int64(d / time.Second)
+	days, s := s/86400, s%86400
+	t := fmt.Sprintf("%02d:%02d:%02d", s/3600, s%3600/60, s%60)
+	if days > 0 {
+		return fmt.Sprintf("%d-%s", days, t)
+	}
+	return t
+}
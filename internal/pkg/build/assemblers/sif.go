@@ -25,16 +25,19 @@ import (
 	"github.com/apptainer/apptainer/pkg/build/types"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	"github.com/apptainer/apptainer/pkg/util/cryptkey"
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/apptainer/sif/v2/pkg/sif"
 	"github.com/google/uuid"
 )
 
 // SIFAssembler doesn't store anything.
 type SIFAssembler struct {
-	GzipFlag        bool
-	MksquashfsProcs uint
-	MksquashfsMem   string
-	MksquashfsPath  string
+	GzipFlag            bool
+	MksquashfsProcs     uint
+	MksquashfsMem       string
+	MksquashfsPath      string
+	MksquashfsBlockSize uint
+	MksquashfsComp      string
 }
 
 type encryptionOptions struct {
@@ -113,7 +116,7 @@ func createSIF(path string, b *types.Bundle, squashfile string, encOpts *encrypt
 			syspartID := uint32(len(dis))
 			part, err := sif.NewDescriptorInput(sif.DataCryptoMessage, bytes.NewReader(data),
 				sif.OptLinkedID(syspartID),
-				sif.OptCryptoMessageMetadata(sif.FormatPEM, sif.MessageRSAOAEP),
+				sif.OptCryptoMessageMetadata(sif.FormatPEM, cryptkey.MessageType(encOpts.keyInfo)),
 			)
 			if err != nil {
 				return err
@@ -174,9 +177,14 @@ func (a *SIFAssembler) Assemble(b *types.Bundle, path string) error {
 		flags = append(flags, "-all-root")
 	}
 	// specify compression if needed
-	if a.GzipFlag {
+	if a.MksquashfsComp != "" {
+		flags = append(flags, "-comp", a.MksquashfsComp)
+	} else if a.GzipFlag {
 		flags = append(flags, "-comp", "gzip")
 	}
+	if a.MksquashfsBlockSize != 0 {
+		flags = append(flags, "-b", fmt.Sprint(a.MksquashfsBlockSize))
+	}
 	if a.MksquashfsMem != "" {
 		flags = append(flags, "-mem", a.MksquashfsMem)
 	}
@@ -197,6 +205,10 @@ func (a *SIFAssembler) Assemble(b *types.Bundle, path string) error {
 			return fmt.Errorf("no encryption key environment variable or --passphrase provided")
 		}
 
+		if err := fips.Forbid("gocryptfs encryption"); err != nil {
+			return fmt.Errorf("unable to encrypt image without privileges: %w", err)
+		}
+
 		g := packer.NewGocryptfs(b.Opts.EncryptionKeyInfo)
 		g.MksquashfsPath = a.MksquashfsPath
 
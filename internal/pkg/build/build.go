@@ -78,15 +78,19 @@ func New(defs []types.Definition, conf Config) (*Build, error) {
 }
 
 func newBuild(defs []types.Definition, conf Config) (*Build, error) {
+	defs, err := imageStages(defs)
+	if err != nil {
+		return nil, err
+	}
+
 	sandboxCopy := false
 	oldumask := syscall.Umask(0o002)
 	defer syscall.Umask(oldumask)
 
-	dest, err := fs.Abs(conf.Dest)
+	conf.Dest, err = fs.Abs(conf.Dest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine absolute path for %q: %v", conf.Dest, err)
 	}
-	conf.Dest = dest
 
 	// always build a sandbox if updating an existing sandbox
 	if conf.Opts.Update {
@@ -197,23 +201,48 @@ func newBuild(defs []types.Definition, conf Config) (*Build, error) {
 			return nil, fmt.Errorf("while searching for mksquashfs: %v", err)
 		}
 
-		flag, err := ensureGzipComp(b.stages[lastStageIndex].b.TmpDir, mksquashfsPath)
-		if err != nil {
-			return nil, fmt.Errorf("while ensuring correct compression algorithm: %v", err)
+		var flag bool
+		comp := conf.Opts.MksquashfsComp
+		if comp != "" && comp != "gzip" {
+			if err := squashfs.CheckComp(comp); err != nil {
+				return nil, err
+			}
+			sylog.Warningf("Images with %s compression can't be used on hosts whose kernel or squashfuse doesn't support it", comp)
+		} else {
+			flag, err = ensureGzipComp(b.stages[lastStageIndex].b.TmpDir, mksquashfsPath)
+			if err != nil {
+				return nil, fmt.Errorf("while ensuring correct compression algorithm: %v", err)
+			}
+			comp = ""
+		}
+		var blockSize uint
+		if conf.Opts.MksquashfsBlockSize != "" {
+			blockSize, err = squashfs.ParseBlockSize(conf.Opts.MksquashfsBlockSize)
+			if err != nil {
+				return nil, err
+			}
 		}
 		mksquashfsProcs, err := squashfs.GetProcs()
 		if err != nil {
 			return nil, fmt.Errorf("while searching for mksquashfs processor limits: %v", err)
 		}
+		if conf.Opts.MksquashfsProcs != 0 {
+			mksquashfsProcs = conf.Opts.MksquashfsProcs
+		}
 		mksquashfsMem, err := squashfs.GetMem()
 		if err != nil {
 			return nil, fmt.Errorf("while searching for mksquashfs mem limits: %v", err)
 		}
+		if conf.Opts.MksquashfsMem != "" {
+			mksquashfsMem = conf.Opts.MksquashfsMem
+		}
 		b.stages[lastStageIndex].a = &assemblers.SIFAssembler{
-			GzipFlag:        flag,
-			MksquashfsProcs: mksquashfsProcs,
-			MksquashfsMem:   mksquashfsMem,
-			MksquashfsPath:  mksquashfsPath,
+			GzipFlag:            flag,
+			MksquashfsProcs:     mksquashfsProcs,
+			MksquashfsMem:       mksquashfsMem,
+			MksquashfsPath:      mksquashfsPath,
+			MksquashfsBlockSize: blockSize,
+			MksquashfsComp:      comp,
 		}
 	default:
 		return nil, fmt.Errorf("unrecognized output format %s", conf.Format)
@@ -584,6 +613,41 @@ func MakeAllDefs(spec string, buildArgsMap map[string]string) ([]types.Definitio
 	return revisedDefs, unusedArgs, nil
 }
 
+// imageStages returns defs preceded by a stage for each image URI files are
+// copied from with a "%files from <URI>" section, named after the URI, so
+// that files can be copied from existing images like from build stages.
+func imageStages(defs []types.Definition) ([]types.Definition, error) {
+	stageNames := make(map[string]bool)
+	for _, d := range defs {
+		stageNames[d.Header["stage"]] = true
+	}
+
+	var images []types.Definition
+	for _, d := range defs {
+		for _, f := range d.BuildData.Files {
+			args := strings.Fields(strings.Split(f.Args, "#")[0])
+			if len(args) != 2 || stageNames[args[1]] {
+				continue
+			}
+			if ok, err := uri.IsValid(args[1]); !ok || err != nil {
+				continue
+			}
+			image, err := types.NewDefinitionFromURI(args[1])
+			if err != nil {
+				return nil, fmt.Errorf("while copying files from %s: %v", args[1], err)
+			}
+			image.Header["stage"] = args[1]
+			stageNames[args[1]] = true
+			images = append(images, image)
+		}
+	}
+	if len(images) == 0 {
+		return defs, nil
+	}
+	sylog.Debugf("Adding stages for images files are copied from")
+	return append(images, defs...), nil
+}
+
 func (b *Build) findStageIndex(name string) (int, error) {
 	for i, s := range b.stages {
 		if name == s.name {
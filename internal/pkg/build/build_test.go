@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/apptainer/apptainer/pkg/build/types"
 	"gotest.tools/v3/assert"
 )
 
@@ -58,3 +59,37 @@ func TestProcessWithAdditionalArgs(t *testing.T) {
 	assert.Equal(t, len(unusedArgs), 1)
 	assert.Equal(t, "ADDITION", unusedArgs[0])
 }
+
+func TestImageStages(t *testing.T) {
+	defs := []types.Definition{
+		{
+			Header: map[string]string{"bootstrap": "docker", "from": "golang", "stage": "devel"},
+		},
+		{
+			Header: map[string]string{"bootstrap": "docker", "from": "alpine"},
+			BuildData: types.Data{
+				Files: []types.Files{
+					{Args: "from devel"},
+					{Args: "from docker://busybox:latest # tools"},
+					{Args: "from docker://busybox:latest"},
+					{Args: "from oras://registry/data:1"},
+					{Args: ""},
+				},
+			},
+		},
+	}
+
+	d, err := imageStages(defs)
+	assert.NilError(t, err)
+	assert.Equal(t, len(d), 4)
+	assert.Equal(t, d[0].Header["stage"], "docker://busybox:latest")
+	assert.Equal(t, d[0].Header["bootstrap"], "docker")
+	assert.Equal(t, d[0].Header["from"], "busybox:latest")
+	assert.Equal(t, d[1].Header["stage"], "oras://registry/data:1")
+	assert.Equal(t, d[2].Header["stage"], "devel")
+	assert.Equal(t, d[3].Header["from"], "alpine")
+
+	d, err = imageStages(defs[:1])
+	assert.NilError(t, err)
+	assert.Equal(t, len(d), 1)
+}
@@ -229,6 +229,16 @@ func (cp *OCIConveyorPacker) Pack(ctx context.Context) (*sytypes.Bundle, error)
 	return cp.b, nil
 }
 
+// rootfsReadOnly returns whether the files of the unpacked root filesystem
+// are not modified in place by the build, apart from the files setting up the
+// container environment, so they can be hardlinked from the cache.
+func (cp *OCIConveyorPacker) rootfsReadOnly() bool {
+	r := cp.b.Recipe
+	return !cp.b.Opts.SandboxTarget && !cp.b.Opts.FixPerms && !cp.b.Opts.Update &&
+		r.BuildData.Setup.Script == "" && r.BuildData.Post.Script == "" &&
+		r.BuildData.Test.Script == "" && len(r.BuildData.Files) == 0 && len(r.AppOrder) == 0
+}
+
 func (cp *OCIConveyorPacker) insertOCIConfig() error {
 	conf, err := json.Marshal(cp.imgConfig)
 	if err != nil {
@@ -240,9 +250,23 @@ func (cp *OCIConveyorPacker) insertOCIConfig() error {
 }
 
 func (cp *OCIConveyorPacker) unpackRootfs(ctx context.Context) error {
-	if err := UnpackRootfs(ctx, cp.srcImg, cp.b.RootfsPath); err != nil {
+	var imgCache *cache.Handle
+	if !cp.b.Opts.NoCache {
+		imgCache = cp.b.Opts.ImgCache
+	}
+	opts := UnpackOptions{
+		TmpDir: cp.b.TmpDir,
+		Procs:  squashfsProcs(cp.b.Opts),
+	}
+	cached, err := unpackCachedRootfs(ctx, cp.srcImg, cp.b.RootfsPath, imgCache, cp.rootfsReadOnly(), opts)
+	if err != nil {
 		return err
 	}
+	if !cached {
+		if err := UnpackRootfs(ctx, cp.srcImg, cp.b.RootfsPath, opts); err != nil {
+			return err
+		}
+	}
 
 	// If the `--fix-perms` flag was used, then modify the permissions so that
 	// content has owner rwX and we're done
@@ -403,6 +427,21 @@ func (cp *OCIConveyorPacker) insertOCILabels() (err error) {
 	labels := cp.imgConfig.Labels
 	var text []byte
 
+	// keep the provenance annotations of the image manifest as labels, the
+	// labels of the image configuration taking precedence
+	m, err := cp.srcImg.Manifest()
+	if err != nil {
+		return fmt.Errorf("while reading image manifest: %v", err)
+	}
+	for k, v := range ociimage.ImageAnnotations(m.Annotations) {
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+
 	// make new map into json
 	text, err = json.MarshalIndent(labels, "", "\t")
 	if err != nil {
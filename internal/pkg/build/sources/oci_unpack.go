@@ -17,16 +17,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
 
 	apexlog "github.com/apex/log"
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/ociimage"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	"github.com/apptainer/apptainer/pkg/util/namespaces"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/opencontainers/go-digest"
 	umocilayer "github.com/opencontainers/umoci/oci/layer"
 	"github.com/opencontainers/umoci/pkg/idtools"
+	"golang.org/x/sys/unix"
 )
 
 // isExtractable checks if we have extractable layers in the image. Shouldn't be
@@ -49,8 +59,18 @@ func isExtractable(img v1.Image) (bool, error) {
 	return false, nil
 }
 
+// UnpackOptions are the options of UnpackRootfs.
+type UnpackOptions struct {
+	// TmpDir is the directory holding the layers decompressed ahead of their
+	// extraction.
+	TmpDir string
+	// Procs is the number of layers decompressed concurrently, the number of
+	// CPUs when 0. Layers are decompressed while being extracted when 1.
+	Procs uint
+}
+
 // UnpackRootfs extracts all of the layers of the given srcImage into destDir.
-func UnpackRootfs(_ context.Context, srcImage v1.Image, destDir string) (err error) {
+func UnpackRootfs(ctx context.Context, srcImage v1.Image, destDir string, opts UnpackOptions) (err error) {
 	extractable, err := isExtractable(srcImage)
 	if err != nil {
 		return err
@@ -59,7 +79,29 @@ func UnpackRootfs(_ context.Context, srcImage v1.Image, destDir string) (err err
 		return fmt.Errorf("no extractable OCI/Docker tar layers found in this image")
 	}
 
+	limits, err := ociimage.CurrentLimits()
+	if err != nil {
+		return err
+	}
+	if err := limits.CheckImage(srcImage); err != nil {
+		return err
+	}
+
+	procs := int(opts.Procs)
+	if procs == 0 {
+		procs = runtime.NumCPU()
+	}
+	if procs > 1 {
+		img, cleanup, err := prefetchLayers(ctx, srcImage, opts.TmpDir, procs, limits)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		srcImage = img
+	}
+
 	flatTar := mutate.Extract(srcImage)
+	defer flatTar.Close()
 
 	var mapOptions umocilayer.MapOptions
 
@@ -100,7 +142,7 @@ func UnpackRootfs(_ context.Context, srcImage v1.Image, destDir string) (err err
 
 	// Unpack root filesystem
 	unpackOptions := umocilayer.UnpackOptions{MapOptions: mapOptions}
-	err = umocilayer.UnpackLayer(destDir, flatTar, &unpackOptions)
+	err = umocilayer.UnpackLayer(destDir, limits.LimitReader(flatTar), &unpackOptions)
 	if err != nil {
 		return fmt.Errorf("error unpacking rootfs: %s", err)
 	}
@@ -109,6 +151,214 @@ func UnpackRootfs(_ context.Context, srcImage v1.Image, destDir string) (err err
 	return err
 }
 
+// prefetchedImage is an image whose layers are decompressed concurrently to
+// temporary files ahead of their extraction.
+type prefetchedImage struct {
+	v1.Image
+	layers []v1.Layer
+}
+
+func (i *prefetchedImage) Layers() ([]v1.Layer, error) {
+	return i.layers, nil
+}
+
+// prefetchedLayer is a layer of a prefetchedImage, whose uncompressed content
+// is read from the temporary file path once decompressed.
+type prefetchedLayer struct {
+	v1.Layer
+	path string
+	done chan struct{}
+	err  error
+}
+
+func (l *prefetchedLayer) Uncompressed() (io.ReadCloser, error) {
+	<-l.done
+	if l.err != nil {
+		return nil, l.err
+	}
+	return os.Open(l.path)
+}
+
+// prefetchLayers returns img with its layers decompressed by up to procs
+// goroutines to temporary files in tmpDir, starting with the upper layers
+// which are extracted first, or img itself when it has a single layer. The
+// returned function stops the decompression and removes the temporary files.
+func prefetchLayers(ctx context.Context, img v1.Image, tmpDir string, procs int, limits ociimage.ImageLimits) (v1.Image, func(), error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(layers) < 2 {
+		return img, func() {}, nil
+	}
+	dir, err := os.MkdirTemp(tmpDir, "layers-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("while creating temporary layers directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, procs)
+	pls := make([]v1.Layer, len(layers))
+	for i := len(layers) - 1; i >= 0; i-- {
+		pl := &prefetchedLayer{
+			Layer: layers[i],
+			path:  filepath.Join(dir, strconv.Itoa(i)),
+			done:  make(chan struct{}),
+		}
+		pls[i] = pl
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(pl.done)
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				pl.err = ctx.Err()
+				return
+			}
+			pl.err = decompressLayer(ctx, pl.Layer, pl.path, limits)
+		}()
+	}
+	sylog.Debugf("Decompressing %d layers with %d concurrent jobs", len(layers), procs)
+
+	cleanup := func() {
+		cancel()
+		wg.Wait()
+		os.RemoveAll(dir)
+	}
+	return &prefetchedImage{Image: img, layers: pls}, cleanup, nil
+}
+
+// decompressLayer writes the uncompressed content of layer to the file path.
+func decompressLayer(ctx context.Context, layer v1.Layer, path string, limits ociimage.ImageLimits) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, limits.LimitReader(&ctxReader{ctx: ctx, r: rc})); err != nil {
+		f.Close()
+		return fmt.Errorf("while decompressing layer: %w", err)
+	}
+	return f.Close()
+}
+
+// ctxReader is a reader failing once its context is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// rootfsCopyPaths are the paths of a root filesystem modified in place by the
+// build, which are copied instead of being hardlinked from the cache.
+var rootfsCopyPaths = []string{".singularity.d", "etc/hosts", "etc/resolv.conf"}
+
+// unpackCachedRootfs clones the root filesystem of srcImage from the root
+// filesystem cache of imgCache to destDir, unpacking it in the cache first
+// when needed. Files are reflinked from the cache when supported by the
+// filesystem, or hardlinked when allowed by hardlink. It returns false,
+// without doing anything, when neither is possible.
+func unpackCachedRootfs(ctx context.Context, srcImage v1.Image, destDir string, imgCache *cache.Handle, hardlink bool, opts UnpackOptions) (bool, error) {
+	if imgCache == nil || imgCache.IsDisabled() {
+		return false, nil
+	}
+	cacheDir, err := imgCache.GetDirCacheDir(cache.OciRootfsCacheType)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(cacheDir, 0o700); err != nil {
+		return false, err
+	}
+	if !fs.Reflinkable(cacheDir, destDir) && (!hardlink || !sameDevice(cacheDir, destDir)) {
+		sylog.Debugf("Root filesystem cache %s can't be reflinked or hardlinked to %s", cacheDir, destDir)
+		return false, nil
+	}
+
+	id, err := chainID(srcImage)
+	if err != nil {
+		return false, err
+	}
+	entry := filepath.Join(cacheDir, id.Encoded())
+	if !fs.IsDir(entry) {
+		tmp, err := os.MkdirTemp(cacheDir, ".tmp-")
+		if err != nil {
+			return false, err
+		}
+		if err := os.Chmod(tmp, 0o755); err != nil {
+			return false, err
+		}
+		sylog.Debugf("Unpacking root filesystem in cache entry %s", entry)
+		if err := UnpackRootfs(ctx, srcImage, tmp, opts); err != nil {
+			fs.ForceRemoveAll(tmp)
+			return false, err
+		}
+		// a concurrent build may have created the entry
+		if err := os.Rename(tmp, entry); err != nil {
+			fs.ForceRemoveAll(tmp)
+			if !fs.IsDir(entry) {
+				return false, err
+			}
+		}
+	}
+	now := time.Now()
+	if err := os.Chtimes(entry, now, now); err != nil {
+		sylog.Debugf("Could not update root filesystem cache entry time: %s", err)
+	}
+
+	mode, err := fs.CloneTree(entry, destDir, fs.CloneOptions{
+		Hardlink:  hardlink,
+		CopyPaths: rootfsCopyPaths,
+	})
+	if err != nil {
+		return true, fmt.Errorf("while cloning cached root filesystem: %w", err)
+	}
+	sylog.Debugf("Root filesystem cloned from cache entry %s with %s", entry, mode)
+	return true, nil
+}
+
+// chainID returns the chain ID of the layers of img, identifying the root
+// filesystem resulting from their extraction.
+func chainID(img v1.Image) (digest.Digest, error) {
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return "", err
+	}
+	var id digest.Digest
+	for _, diffID := range cfg.RootFS.DiffIDs {
+		if id == "" {
+			id = digest.Digest(diffID.String())
+			continue
+		}
+		id = digest.FromString(id.String() + " " + diffID.String())
+	}
+	if err := id.Validate(); err != nil {
+		return "", fmt.Errorf("invalid image layers chain ID: %w", err)
+	}
+	return id, nil
+}
+
+// sameDevice returns whether the paths a and b are on the same device.
+func sameDevice(a, b string) bool {
+	var sa, sb unix.Stat_t
+	if unix.Stat(a, &sa) != nil || unix.Stat(b, &sb) != nil {
+		return false
+	}
+	return sa.Dev == sb.Dev
+}
+
 // FixPerms will work through the rootfs of this bundle, making sure that all
 // files and directories have permissions set such that the owner can read,
 // modify, delete. This brings us to the situation of <=3.4
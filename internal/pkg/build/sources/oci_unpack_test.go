@@ -0,0 +1,109 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package sources
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/ociimage"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/opencontainers/go-digest"
+)
+
+func TestChainID(t *testing.T) {
+	img, err := random.Image(64, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := cfg.RootFS.DiffIDs
+
+	// chain IDs as defined by the OCI image specification
+	want := digest.Digest(ids[0].String())
+	for _, id := range ids[1:] {
+		want = digest.FromString(want.String() + " " + id.String())
+	}
+	got, err := chainID(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got chain ID %s, want %s", got, want)
+	}
+
+	// the chain ID only depends on the layers
+	other, err := mutate.Config(img, v1.Config{Env: []string{"A=B"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := chainID(other); err != nil || id != want {
+		t.Errorf("got chain ID %s (%v) for image with another configuration, want %s", id, err, want)
+	}
+
+	if _, err := chainID(empty.Image); err == nil {
+		t.Errorf("unexpected success for an image without layers")
+	}
+}
+
+func TestPrefetchLayers(t *testing.T) {
+	img, err := random.Image(1024, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := t.TempDir()
+
+	pImg, cleanup, err := prefetchLayers(context.Background(), img, tmpDir, 2, ociimage.ImageLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pLayers, err := pImg.Layers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pLayers) != len(layers) {
+		t.Fatalf("got %d layers, want %d", len(pLayers), len(layers))
+	}
+	for i := range layers {
+		want := readUncompressed(t, layers[i])
+		got := readUncompressed(t, pLayers[i])
+		if !bytes.Equal(got, want) {
+			t.Errorf("layer %d content differs", i)
+		}
+	}
+
+	cleanup()
+	if entries, err := os.ReadDir(tmpDir); err != nil || len(entries) != 0 {
+		t.Errorf("temporary files not removed: %v (%v)", entries, err)
+	}
+}
+
+func readUncompressed(t *testing.T, l v1.Layer) []byte {
+	t.Helper()
+	rc, err := l.Uncompressed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
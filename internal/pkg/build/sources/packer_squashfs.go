@@ -16,6 +16,7 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/image/unpacker"
 	"github.com/apptainer/apptainer/pkg/build/types"
 	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 )
 
 // SquashfsPacker holds the locations of where to pack from and to, as well as image offset info
@@ -34,6 +35,7 @@ func (p *SquashfsPacker) Pack(context.Context) (*types.Bundle, error) {
 	}
 
 	s := unpacker.NewSquashfs()
+	s.Processors = squashfsProcs(p.b.Opts)
 
 	// extract root filesystem
 	if err := s.ExtractAll(reader, p.b.RootfsPath); err != nil {
@@ -42,3 +44,15 @@ func (p *SquashfsPacker) Pack(context.Context) (*types.Bundle, error) {
 
 	return p.b, nil
 }
+
+// squashfsProcs returns the number of processors used by the squashfs tools
+// from the build options, or from the configuration, all of them when 0.
+func squashfsProcs(opts types.Options) uint {
+	if opts.MksquashfsProcs != 0 {
+		return opts.MksquashfsProcs
+	}
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		return cfg.MksquashfsProcs
+	}
+	return 0
+}
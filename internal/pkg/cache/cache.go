@@ -51,6 +51,20 @@ const (
 	OrasCacheType = "oras"
 	// NetCacheType specifies the cache holds images pulled from http(s) internet sources
 	NetCacheType = "net"
+	// ObjectCacheType specifies the cache holds images pulled from object storage
+	ObjectCacheType = "object"
+	// IpfsCacheType specifies the cache holds images pulled from IPFS
+	IpfsCacheType = "ipfs"
+	// ReferrersCacheType specifies the cache holds the referrers of images pulled from Oras sources
+	ReferrersCacheType = "referrers"
+	// RecordsCacheType specifies the cache holds records of the digests images were pulled at
+	RecordsCacheType = "records"
+	// OciSpecCacheType specifies the cache holds OCI runtime specs generated for bundles
+	OciSpecCacheType = "oci-spec"
+	// OciRootfsCacheType specifies the cache holds unpacked root filesystems of OCI images
+	OciRootfsCacheType = "oci-rootfs"
+	// TagsCacheType specifies the cache holds the tags listed for repositories of OCI registries
+	TagsCacheType = "tags"
 )
 
 var (
@@ -61,11 +75,21 @@ var (
 		ShubCacheType,
 		OrasCacheType,
 		NetCacheType,
+		ObjectCacheType,
+		IpfsCacheType,
+		ReferrersCacheType,
+		RecordsCacheType,
+		OciSpecCacheType,
+		TagsCacheType,
 	}
 	// OciCacheTypes specifies the OCI cache types.
 	OciCacheTypes = []string{
 		OciBlobCacheType,
 	}
+	// DirCacheTypes specifies the cache types holding directories.
+	DirCacheTypes = []string{
+		OciRootfsCacheType,
+	}
 )
 
 // Config describes the requested configuration requested when a new handle is created,
@@ -105,6 +129,13 @@ func (h *Handle) GetOciCacheDir(cacheType string) (cacheDir string, err error) {
 	return h.getCacheTypeDir(cacheType), nil
 }
 
+func (h *Handle) GetDirCacheDir(cacheType string) (cacheDir string, err error) {
+	if !stringInSlice(cacheType, DirCacheTypes) {
+		return "", errInvalidCacheType
+	}
+	return h.getCacheTypeDir(cacheType), nil
+}
+
 // GetEntry returns a cache Entry for a specified file cache type and hash
 func (h *Handle) GetEntry(cacheType string, hash string) (e *Entry, err error) {
 	if h.disabled {
@@ -189,8 +220,9 @@ func (h *Handle) CleanCache(cacheType string, dryRun bool, days int) (err error)
 
 		sylog.Infof("Removing %s cache entry: %s", cacheType, f.Name())
 		if !dryRun {
-			// We RemoveAll in case the entry is a directory from Singularity (prior to 3.6)
-			err := os.RemoveAll(path.Join(dir, f.Name()))
+			// We remove all in case the entry is a directory from Singularity (prior to 3.6),
+			// or an unpacked root filesystem with restrictive permissions
+			err := fs.ForceRemoveAll(path.Join(dir, f.Name()))
 			if err != nil {
 				sylog.Errorf("Could not remove cache entry '%s': %v", f.Name(), err)
 				errCount = errCount + 1
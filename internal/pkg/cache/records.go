@@ -0,0 +1,121 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImageRecord records the digest an image reference resolved to when it was
+// last pulled, into the cache when Path is empty, or to the file at Path.
+type ImageRecord struct {
+	URI    string    `json:"uri"`
+	Path   string    `json:"path,omitempty"`
+	Arch   string    `json:"arch,omitempty"`
+	Digest string    `json:"digest"`
+	Pulled time.Time `json:"pulled"`
+}
+
+func (r *ImageRecord) hash() string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(r.URI+"\x00"+r.Path+"\x00"+r.Arch)))
+}
+
+// RecordImage records the digest an image was pulled at, replacing any
+// previous record of the same image.
+func (h *Handle) RecordImage(r ImageRecord) error {
+	if h == nil || h.disabled {
+		return nil
+	}
+	r.Pulled = time.Now().UTC()
+	e, err := h.GetEntry(RecordsCacheType, r.hash())
+	if err != nil {
+		return err
+	}
+	if e.Exists {
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+		if e, err = h.GetEntry(RecordsCacheType, r.hash()); err != nil {
+			return err
+		}
+	}
+	defer e.CleanTmp()
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.TmpPath, b, 0o600); err != nil {
+		return err
+	}
+	return e.Finalize()
+}
+
+// GetImageRecord returns the record of the image at uri pulled for arch into
+// the cache, or to the file at path, or nil when there is none.
+func (h *Handle) GetImageRecord(uri, path, arch string) (*ImageRecord, error) {
+	if h == nil || h.disabled {
+		return nil, nil
+	}
+	r := &ImageRecord{URI: uri, Path: path, Arch: arch}
+	return readImageRecord(filepath.Join(h.getCacheTypeDir(RecordsCacheType), r.hash()))
+}
+
+// ImageRecords returns all the image records of the cache, sorted by URI.
+func (h *Handle) ImageRecords() ([]*ImageRecord, error) {
+	if h == nil || h.disabled {
+		return nil, nil
+	}
+	dir := h.getCacheTypeDir(RecordsCacheType)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var records []*ImageRecord
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), "tmp_") {
+			continue
+		}
+		r, err := readImageRecord(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].URI != records[j].URI {
+			return records[i].URI < records[j].URI
+		}
+		return records[i].Path < records[j].Path
+	})
+	return records, nil
+}
+
+func readImageRecord(path string) (*ImageRecord, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	r := new(ImageRecord)
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("while parsing image record %s: %v", path, err)
+	}
+	return r, nil
+}
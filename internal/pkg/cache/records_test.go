@@ -0,0 +1,60 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestImageRecords(t *testing.T) {
+	h := newTestHandle(t)
+
+	records := []ImageRecord{
+		{URI: "oras://registry.example.com/lolcow:latest", Digest: "sha256:aaaa"},
+		{URI: "docker://alpine:3.20", Arch: "arm64", Digest: "1111"},
+		{URI: "docker://alpine:3.20", Path: "/data/alpine.sif", Digest: "2222"},
+		{URI: "docker://alpine:3.20", Arch: "arm64", Digest: "3333"},
+	}
+	for _, r := range records {
+		if err := h.RecordImage(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	r, err := h.GetImageRecord("docker://alpine:3.20", "", "arm64")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r == nil || r.Digest != "3333" || r.Pulled.IsZero() {
+		t.Errorf("unexpected record %+v", r)
+	}
+
+	r, err = h.GetImageRecord("docker://alpine:3.20", "", "")
+	if err != nil || r != nil {
+		t.Errorf("got record %+v, %v for image never pulled", r, err)
+	}
+
+	all, err := h.ImageRecords()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d records, expected 3", len(all))
+	}
+	if all[0].URI != "docker://alpine:3.20" || all[0].Path != "" || all[1].Path != "/data/alpine.sif" || all[2].URI != records[0].URI {
+		t.Errorf("unexpected records order: %+v %+v %+v", all[0], all[1], all[2])
+	}
+}
+
+func TestImageRecordsDisabled(t *testing.T) {
+	h := &Handle{disabled: true}
+	if err := h.RecordImage(ImageRecord{URI: "docker://alpine", Digest: "1111"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if r, err := h.GetImageRecord("docker://alpine", "", ""); r != nil || err != nil {
+		t.Errorf("got record %+v, %v with disabled cache", r, err)
+	}
+}
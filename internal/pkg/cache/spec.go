@@ -0,0 +1,56 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// GetSpec returns the OCI runtime spec cached for key, or nil when there is
+// none.
+func (h *Handle) GetSpec(key string) ([]byte, error) {
+	if h == nil || h.disabled {
+		return nil, nil
+	}
+	b, err := os.ReadFile(filepath.Join(h.getCacheTypeDir(OciSpecCacheType), key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// PutSpec caches the OCI runtime spec for key, replacing any spec
+// previously cached for it.
+func (h *Handle) PutSpec(key string, spec []byte) error {
+	if h == nil || h.disabled {
+		return nil
+	}
+	return h.putEntry(OciSpecCacheType, key, spec)
+}
+
+// putEntry writes data to the entry key of cacheType, replacing any entry
+// previously cached for it.
+func (h *Handle) putEntry(cacheType, key string, data []byte) error {
+	e, err := h.GetEntry(cacheType, key)
+	if err != nil {
+		return err
+	}
+	if e.Exists {
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+		if e, err = h.GetEntry(cacheType, key); err != nil {
+			return err
+		}
+	}
+	defer e.CleanTmp()
+
+	if err := os.WriteFile(e.TmpPath, data, 0o600); err != nil {
+		return err
+	}
+	return e.Finalize()
+}
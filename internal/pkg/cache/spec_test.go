@@ -0,0 +1,38 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestSpec(t *testing.T) {
+	h := newTestHandle(t)
+
+	if b, err := h.GetSpec("key"); b != nil || err != nil {
+		t.Errorf("got spec %q, %v never cached", b, err)
+	}
+	for _, spec := range []string{`{"ociVersion":"1.0.0"}`, `{"ociVersion":"1.0.2"}`} {
+		if err := h.PutSpec("key", []byte(spec)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := h.GetSpec("key")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(b) != spec {
+			t.Errorf("got spec %q, expected %q", b, spec)
+		}
+	}
+
+	h = &Handle{disabled: true}
+	if err := h.PutSpec("key", []byte("{}")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if b, err := h.GetSpec("key"); b != nil || err != nil {
+		t.Errorf("got spec %q, %v with disabled cache", b, err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// GetTags returns the tags cached for the repository repo, and the time
+// they were cached at, or nil when there are none.
+func (h *Handle) GetTags(repo string) ([]string, time.Time, error) {
+	if h == nil || h.disabled {
+		return nil, time.Time{}, nil
+	}
+	path := filepath.Join(h.getCacheTypeDir(TagsCacheType), tagsKey(repo))
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, time.Time{}, nil
+	} else if err != nil {
+		return nil, time.Time{}, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var tags []string
+	if err := json.Unmarshal(b, &tags); err != nil {
+		return nil, time.Time{}, err
+	}
+	return tags, fi.ModTime(), nil
+}
+
+// PutTags caches the tags of the repository repo, replacing the tags
+// previously cached for it.
+func (h *Handle) PutTags(repo string, tags []string) error {
+	if h == nil || h.disabled {
+		return nil
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	b, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return h.putEntry(TagsCacheType, tagsKey(repo), b)
+}
+
+func tagsKey(repo string) string {
+	sum := sha256.Sum256([]byte(repo))
+	return hex.EncodeToString(sum[:])
+}
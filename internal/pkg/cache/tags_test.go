@@ -0,0 +1,46 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	h := newTestHandle(t)
+
+	if tags, _, err := h.GetTags("index.docker.io/library/alpine"); tags != nil || err != nil {
+		t.Errorf("got tags %q, %v never cached", tags, err)
+	}
+	for _, tags := range [][]string{{"3.19", "latest"}, {"3.20"}, {}} {
+		if err := h.PutTags("index.docker.io/library/alpine", tags); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, cachedAt, err := h.GetTags("index.docker.io/library/alpine")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, tags) {
+			t.Errorf("got tags %q, expected %q", got, tags)
+		}
+		if time.Since(cachedAt) > time.Minute {
+			t.Errorf("unexpected cache time %v", cachedAt)
+		}
+	}
+	if tags, _, _ := h.GetTags("index.docker.io/library/busybox"); tags != nil {
+		t.Errorf("got tags %q for another repository", tags)
+	}
+
+	h = &Handle{disabled: true}
+	if err := h.PutTags("alpine", []string{"latest"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if tags, _, err := h.GetTags("alpine"); tags != nil || err != nil {
+		t.Errorf("got tags %q, %v with disabled cache", tags, err)
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// ociLayoutFile and ociIndexFile are the metadata files of the OCI
+	// blob cache layout.
+	ociLayoutFile = "oci-layout"
+	ociIndexFile  = "index.json"
+)
+
+// blobPath matches the path of a blob in the OCI blob cache layout. Blobs are
+// content addressed, and verified when they are imported.
+var blobPath = regexp.MustCompile(`^blobs/sha256/[a-f0-9]{64}$`)
+
+// TransferTypes returns the cache types matching the list of types, where
+// "all" stands for all the file and OCI cache types.
+func TransferTypes(cacheTypes []string) ([]string, error) {
+	all := append(append([]string{}, OciCacheTypes...), FileCacheTypes...)
+	if len(cacheTypes) == 0 || stringInSlice("all", cacheTypes) {
+		return all, nil
+	}
+	for _, t := range cacheTypes {
+		if !stringInSlice(t, all) {
+			return nil, fmt.Errorf("%w: %s", errInvalidCacheType, t)
+		}
+	}
+	return cacheTypes, nil
+}
+
+// walkEntries calls fn for each entry of the cache types with its path,
+// relative to the cache root, and its absolute path. Temporary files of
+// pulls in progress are skipped, and the index of the OCI blob cache is
+// visited after its blobs.
+func (h *Handle) walkEntries(cacheTypes []string, fn func(rel, abs string) error) error {
+	for _, t := range cacheTypes {
+		dir := h.getCacheTypeDir(t)
+		if !stringInSlice(t, OciCacheTypes) {
+			files, err := os.ReadDir(dir)
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			for _, f := range files {
+				if !f.Type().IsRegular() || strings.HasPrefix(f.Name(), "tmp_") {
+					continue
+				}
+				if err := fn(path.Join(t, f.Name()), filepath.Join(dir, f.Name())); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		blobs, err := filepath.Glob(filepath.Join(dir, "blobs", "sha256", "*"))
+		if err != nil {
+			return err
+		}
+		for _, b := range blobs {
+			rel := path.Join("blobs", "sha256", filepath.Base(b))
+			if !blobPath.MatchString(rel) || !fs.IsFile(b) {
+				continue
+			}
+			if err := fn(path.Join(t, rel), b); err != nil {
+				return err
+			}
+		}
+		for _, name := range []string{ociLayoutFile, ociIndexFile} {
+			if fs.IsFile(filepath.Join(dir, name)) {
+				if err := fn(path.Join(t, name), filepath.Join(dir, name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ExportTar writes the entries of the cache types to w as a tar archive with
+// the layout of the cache, returning the number of entries written.
+func (h *Handle) ExportTar(w io.Writer, cacheTypes []string) (int, error) {
+	if h.disabled {
+		return 0, errors.New("cache is disabled")
+	}
+
+	tw := tar.NewWriter(w)
+	n := 0
+	err := h.walkEntries(cacheTypes, func(rel, abs string) error {
+		f, err := os.Open(abs)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     rel,
+			Size:     fi.Size(),
+			Mode:     0o600,
+			ModTime:  fi.ModTime(),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, f); err != nil {
+			return fmt.Errorf("while exporting %s: %w", rel, err)
+		}
+		sylog.Debugf("Exported %s", rel)
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, tw.Close()
+}
+
+// ExportDir copies the entries of the cache types to dir with the layout of
+// the cache, returning the number of entries copied. Entries already present
+// in dir are skipped, so that it can be updated incrementally and
+// synchronized to other nodes with tools like rsync.
+func (h *Handle) ExportDir(dir string, cacheTypes []string) (int, error) {
+	if h.disabled {
+		return 0, errors.New("cache is disabled")
+	}
+
+	n := 0
+	err := h.walkEntries(cacheTypes, func(rel, abs string) error {
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		// The index of the OCI blob cache changes as images are added.
+		if path.Base(rel) != ociIndexFile && fs.IsFile(dst) {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err := fs.CopyFileAtomic(abs, dst, 0o644); err != nil {
+			return fmt.Errorf("while exporting %s: %w", rel, err)
+		}
+		sylog.Debugf("Exported %s", rel)
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// importer adds entries to the cache. The index of the OCI blob cache is
+// merged with the current index once all blobs are imported.
+type importer struct {
+	h     *Handle
+	n     int
+	index *ispec.Index
+}
+
+// importEntry imports the content of the cache entry at path rel, relative
+// to the cache root. Existing entries are kept.
+func (im *importer) importEntry(rel string, r io.Reader) error {
+	rel = path.Clean(rel)
+	cacheType, name, ok := strings.Cut(rel, "/")
+	if !ok || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("invalid cache entry path %s", rel)
+	}
+
+	switch {
+	case stringInSlice(cacheType, FileCacheTypes):
+		if strings.Contains(name, "/") || strings.HasPrefix(name, "tmp_") {
+			return fmt.Errorf("invalid %s cache entry %s", cacheType, name)
+		}
+	case stringInSlice(cacheType, OciCacheTypes):
+		switch {
+		case name == ociIndexFile:
+			index := new(ispec.Index)
+			if err := json.NewDecoder(r).Decode(index); err != nil {
+				return fmt.Errorf("invalid %s: %v", rel, err)
+			}
+			im.index = index
+			return nil
+		case name == ociLayoutFile:
+		case !blobPath.MatchString(name):
+			return fmt.Errorf("invalid %s cache entry %s", cacheType, name)
+		}
+	default:
+		return fmt.Errorf("%w: %s", errInvalidCacheType, cacheType)
+	}
+
+	dst := filepath.Join(im.h.rootDir, filepath.FromSlash(rel))
+	if fs.IsFile(dst) {
+		sylog.Debugf("Skipping %s, already in cache", rel)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+
+	f, err := fs.MakeTmpFile(filepath.Dir(dst), "tmp_", 0o700)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("while importing %s: %v", rel, err)
+	}
+	if blobPath.MatchString(name) && hex.EncodeToString(hash.Sum(nil)) != path.Base(name) {
+		return fmt.Errorf("while importing %s: content doesn't match its digest", rel)
+	}
+	if err := os.Rename(f.Name(), dst); err != nil {
+		return err
+	}
+	sylog.Debugf("Imported %s", rel)
+	im.n++
+	return nil
+}
+
+// mergeIndex adds the manifests of the imported OCI blob cache index to the
+// current index.
+func (im *importer) mergeIndex() error {
+	if im.index == nil {
+		return nil
+	}
+
+	indexPath := filepath.Join(im.h.getCacheTypeDir(OciBlobCacheType), ociIndexFile)
+	current := &ispec.Index{MediaType: ispec.MediaTypeImageIndex}
+	current.SchemaVersion = 2
+	if b, err := os.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(b, current); err != nil {
+			return fmt.Errorf("invalid %s: %v", indexPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	added := 0
+	for _, m := range im.index.Manifests {
+		found := false
+		for _, c := range current.Manifests {
+			if c.Digest == m.Digest {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		blob := filepath.Join(im.h.getCacheTypeDir(OciBlobCacheType), "blobs", m.Digest.Algorithm().String(), m.Digest.Encoded())
+		if !fs.IsFile(blob) {
+			sylog.Warningf("Skipping image %s missing from the OCI blob cache", m.Digest)
+			continue
+		}
+		current.Manifests = append(current.Manifests, m)
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	f, err := fs.MakeTmpFile(filepath.Dir(indexPath), "tmp_", 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), indexPath); err != nil {
+		return err
+	}
+	im.n += added
+	return nil
+}
+
+// ImportTar adds the entries of a tar archive created by ExportTar to the
+// cache, returning the number of entries added. Entries already in the cache
+// are kept, and OCI blobs are verified against their digest.
+func (h *Handle) ImportTar(r io.Reader) (int, error) {
+	if h.disabled {
+		return 0, errors.New("cache is disabled")
+	}
+
+	im := &importer{h: h}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return im.n, err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return im.n, fmt.Errorf("unsupported entry type for %s", hdr.Name)
+		}
+		if err := im.importEntry(hdr.Name, tr); err != nil {
+			return im.n, err
+		}
+	}
+	return im.n, im.mergeIndex()
+}
+
+// ImportDir adds the entries of a directory created by ExportDir, or of
+// another cache directory, to the cache, returning the number of entries
+// added. Entries already in the cache are kept, and OCI blobs are verified
+// against their digest.
+func (h *Handle) ImportDir(dir string) (int, error) {
+	if h.disabled {
+		return 0, errors.New("cache is disabled")
+	}
+
+	src := &Handle{rootDir: dir}
+	types := []string{}
+	for _, t := range append(append([]string{}, OciCacheTypes...), FileCacheTypes...) {
+		if fs.IsDir(src.getCacheTypeDir(t)) {
+			types = append(types, t)
+		}
+	}
+
+	im := &importer{h: h}
+	err := src.walkEntries(types, func(rel, abs string) error {
+		f, err := os.Open(abs)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return im.importEntry(rel, f)
+	})
+	if err != nil {
+		return im.n, err
+	}
+	return im.n, im.mergeIndex()
+}
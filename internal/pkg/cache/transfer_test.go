@@ -0,0 +1,202 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newTestHandle(t *testing.T) *Handle {
+	t.Helper()
+	t.Setenv(DisableEnv, "")
+	h, err := New(Config{ParentDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	return h
+}
+
+func writeEntry(t *testing.T, h *Handle, rel string, content []byte) {
+	t.Helper()
+	p := filepath.Join(h.rootDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addImage adds a blob to the OCI blob cache of h, referenced by its index,
+// and returns its digest.
+func addImage(t *testing.T, h *Handle, content []byte) digest.Digest {
+	t.Helper()
+	sum := sha256.Sum256(content)
+	d := digest.NewDigestFromEncoded(digest.SHA256, hex.EncodeToString(sum[:]))
+	writeEntry(t, h, "blob/blobs/sha256/"+d.Encoded(), content)
+
+	index := ispec.Index{MediaType: ispec.MediaTypeImageIndex}
+	index.SchemaVersion = 2
+	if b, err := os.ReadFile(filepath.Join(h.rootDir, "blob", ociIndexFile)); err == nil {
+		if err := json.Unmarshal(b, &index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index.Manifests = append(index.Manifests, ispec.Descriptor{
+		MediaType: ispec.MediaTypeImageManifest,
+		Digest:    d,
+		Size:      int64(len(content)),
+	})
+	b, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeEntry(t, h, "blob/"+ociIndexFile, b)
+	writeEntry(t, h, "blob/"+ociLayoutFile, []byte(`{"imageLayoutVersion":"1.0.0"}`))
+	return d
+}
+
+func readIndex(t *testing.T, h *Handle) []digest.Digest {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(h.rootDir, "blob", ociIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index ispec.Index
+	if err := json.Unmarshal(b, &index); err != nil {
+		t.Fatal(err)
+	}
+	var digests []digest.Digest
+	for _, m := range index.Manifests {
+		digests = append(digests, m.Digest)
+	}
+	return digests
+}
+
+func TestTransfer(t *testing.T) {
+	src := newTestHandle(t)
+	writeEntry(t, src, "library/abc", []byte("library image"))
+	writeEntry(t, src, "oci-tmp/def", []byte("oci image"))
+	writeEntry(t, src, "oci-tmp/tmp_123", []byte("pull in progress"))
+	srcImage := addImage(t, src, []byte("manifest 1"))
+
+	types, err := TransferTypes([]string{"all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(t *testing.T, dst *Handle, dstImage digest.Digest) {
+		for _, rel := range []string{"library/abc", "oci-tmp/def", "blob/blobs/sha256/" + srcImage.Encoded()} {
+			if _, err := os.Stat(filepath.Join(dst.rootDir, rel)); err != nil {
+				t.Errorf("entry %s not imported: %v", rel, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dst.rootDir, "oci-tmp/tmp_123")); !os.IsNotExist(err) {
+			t.Errorf("temporary file was imported")
+		}
+		index := readIndex(t, dst)
+		if len(index) != 2 || index[0] != dstImage || index[1] != srcImage {
+			t.Errorf("got index %v, expected %v and %v", index, dstImage, srcImage)
+		}
+	}
+
+	t.Run("tar", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		n, err := src.ExportTar(buf, types)
+		if err != nil {
+			t.Fatalf("unexpected export error: %v", err)
+		}
+		if n != 5 {
+			t.Errorf("exported %d entries, expected 5", n)
+		}
+
+		dst := newTestHandle(t)
+		dstImage := addImage(t, dst, []byte("manifest 2"))
+		if _, err := dst.ImportTar(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("unexpected import error: %v", err)
+		}
+		check(t, dst, dstImage)
+
+		// Importing again doesn't add anything.
+		n, err = dst.ImportTar(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("unexpected import error: %v", err)
+		}
+		if n != 0 {
+			t.Errorf("imported %d entries again", n)
+		}
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		dir := t.TempDir()
+		if _, err := src.ExportDir(dir, types); err != nil {
+			t.Fatalf("unexpected export error: %v", err)
+		}
+
+		dst := newTestHandle(t)
+		dstImage := addImage(t, dst, []byte("manifest 2"))
+		if _, err := dst.ImportDir(dir); err != nil {
+			t.Fatalf("unexpected import error: %v", err)
+		}
+		check(t, dst, dstImage)
+	})
+}
+
+func TestImportTarInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+	}{
+		{"traversal", "../../etc/passwd", "x"},
+		{"unknown type", "unknown/abc", "x"},
+		{"nested entry", "library/a/b", "x"},
+		{"temporary file", "library/tmp_abc", "x"},
+		{"blob digest mismatch", "blob/blobs/sha256/" + hex.EncodeToString(make([]byte, 32)), "x"},
+		{"invalid blob", "blob/blobs/sha512/abc", "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			tw := tar.NewWriter(buf)
+			tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: tt.path, Size: int64(len(tt.content)), Mode: 0o600})
+			tw.Write([]byte(tt.content))
+			tw.Close()
+
+			h := newTestHandle(t)
+			if _, err := h.ImportTar(buf); err == nil {
+				t.Errorf("expected error importing %s", tt.path)
+			}
+			if _, err := os.Stat(filepath.Join(h.rootDir, filepath.FromSlash(tt.path))); err == nil {
+				t.Errorf("invalid entry %s was imported", tt.path)
+			}
+		})
+	}
+}
+
+func TestTransferTypes(t *testing.T) {
+	types, err := TransferTypes([]string{"library", "blob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(types) != 2 {
+		t.Errorf("got types %v", types)
+	}
+	if _, err := TransferTypes([]string{"library", "images"}); err == nil {
+		t.Errorf("expected error for invalid cache type")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/apptainer/sif/v2/pkg/sif"
+)
+
+// sifMagic is the magic of SIF images, following their launch script.
+var sifMagic = []byte("SIF_MAGIC\x00")
+
+const sifMagicOffset = 32
+
+// digestName matches the names of entries named after the sha256 digest of
+// their content, as SIF images pulled from the library or from Oras sources.
+var digestName = regexp.MustCompile(`^sha256[:.]([a-f0-9]{64})$`)
+
+// CorruptEntry describes a cache entry failing verification.
+type CorruptEntry struct {
+	// Type is the cache type of the entry.
+	Type string
+	// Name is the name of the entry in the directory of its type, the
+	// digest of OCI blobs.
+	Name string
+	// Path is the absolute path of the entry.
+	Path string
+	// Reason describes why verification failed.
+	Reason string
+}
+
+// Verify checks the entries of the cache types, returning the number of
+// entries checked and those found corrupted. The content of OCI blobs and
+// of SIF images named after their digest is checked against it, the
+// descriptors of other SIF images are checked to lie within the image, and
+// JSON entries are checked to parse.
+func (h *Handle) Verify(cacheTypes []string) (int, []CorruptEntry, error) {
+	if h.disabled {
+		return 0, nil, errors.New("cache is disabled")
+	}
+
+	n := 0
+	var corrupt []CorruptEntry
+	err := h.walkEntries(cacheTypes, func(rel, abs string) error {
+		t, name, _ := strings.Cut(rel, "/")
+		if t == OciBlobCacheType {
+			name = path.Base(name)
+		}
+		reason, err := verifyEntry(t, name, abs)
+		if err != nil {
+			return fmt.Errorf("while verifying %s: %v", rel, err)
+		}
+		n++
+		if reason != "" {
+			corrupt = append(corrupt, CorruptEntry{Type: t, Name: name, Path: abs, Reason: reason})
+		}
+		return nil
+	})
+	return n, corrupt, err
+}
+
+// RemoveEntry removes the corrupted entry c from the cache, to be fetched
+// again when next used.
+func (h *Handle) RemoveEntry(c CorruptEntry) error {
+	if err := os.Remove(c.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// verifyEntry returns why the entry name of cache type t at path is
+// corrupted, or an empty string if it's not. Errors are only returned when
+// the entry can't be read.
+func verifyEntry(t, name, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	switch {
+	case t == OciBlobCacheType && (name == ociLayoutFile || name == ociIndexFile),
+		t == RecordsCacheType, t == ReferrersCacheType, t == OciSpecCacheType, t == TagsCacheType:
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		if !json.Valid(b) {
+			return "invalid JSON content", nil
+		}
+		return "", nil
+	case t == OciBlobCacheType:
+		return verifyDigest(f, name)
+	case digestName.MatchString(name):
+		return verifyDigest(f, digestName.FindStringSubmatch(name)[1])
+	default:
+		return verifySIF(f, t == OciTempCacheType)
+	}
+}
+
+// verifyDigest returns whether the sha256 digest of the content of f
+// doesn't match the hex encoded digest.
+func verifyDigest(f *os.File, digest string) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != digest {
+		return fmt.Sprintf("content digest sha256:%s doesn't match", sum), nil
+	}
+	return "", nil
+}
+
+// verifySIF returns whether the SIF image f is invalid or truncated. Images
+// in other formats are only reported when required is true.
+func verifySIF(f *os.File, required bool) (string, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if fi.Size() == 0 {
+		return "empty file", nil
+	}
+	magic := make([]byte, len(sifMagic))
+	if _, err := f.ReadAt(magic, sifMagicOffset); err != nil && err != io.EOF {
+		return "", err
+	}
+	if !bytes.Equal(magic, sifMagic) {
+		if required {
+			return "not a SIF image", nil
+		}
+		return "", nil
+	}
+
+	fimg, err := sif.LoadContainer(f, sif.OptLoadWithCloseOnUnload(false))
+	if err != nil {
+		return fmt.Sprintf("invalid SIF image: %v", err), nil
+	}
+	defer fimg.UnloadContainer()
+
+	reason := ""
+	fimg.WithDescriptors(func(d sif.Descriptor) bool {
+		if d.Offset()+d.Size() > fi.Size() {
+			reason = fmt.Sprintf("object %d extends beyond the end of the image", d.ID())
+			return true
+		}
+		return false
+	})
+	return reason, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/apptainer/sif/v2/pkg/sif"
+)
+
+// testSIF returns the content of a SIF image holding a data object.
+func testSIF(t *testing.T) []byte {
+	t.Helper()
+	di, err := sif.NewDescriptorInput(sif.DataGeneric, bytes.NewReader(bytes.Repeat([]byte("data"), 1024)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "image.sif")
+	f, err := sif.CreateContainerAtPath(path, sif.OptCreateWithDescriptors(di))
+	if err != nil {
+		t.Fatalf("failed to create SIF: %v", err)
+	}
+	f.UnloadContainer()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestVerify(t *testing.T) {
+	h := newTestHandle(t)
+
+	blob := []byte("blob")
+	good := addImage(t, h, blob)
+	writeEntry(t, h, fmt.Sprintf("blob/blobs/sha256/%x", sha256.Sum256([]byte("other"))), blob)
+
+	image := testSIF(t)
+	writeEntry(t, h, "oci-tmp/good", image)
+	writeEntry(t, h, "oci-tmp/truncated", image[:len(image)-100])
+	writeEntry(t, h, "oci-tmp/empty", nil)
+	writeEntry(t, h, fmt.Sprintf("oras/sha256:%x", sha256.Sum256(image)), image)
+	writeEntry(t, h, fmt.Sprintf("library/sha256.%x", sha256.Sum256([]byte("other"))), image)
+	writeEntry(t, h, "net/squashfs", []byte("hsqs"))
+	writeEntry(t, h, "records/good", []byte(`{"uri":"docker://alpine"}`))
+	writeEntry(t, h, "records/bad", []byte(`{"uri":"docker://alp`))
+	writeEntry(t, h, "oci-tmp/tmp_1234", nil)
+
+	types, err := TransferTypes([]string{"all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, corrupt, err := h.Verify(types)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the index and layout of the blob cache are verified too
+	if n != 12 {
+		t.Errorf("got %d entries verified, expected 12", n)
+	}
+
+	var got []string
+	for _, c := range corrupt {
+		if c.Reason == "" {
+			t.Errorf("no reason given for %s", c.Path)
+		}
+		got = append(got, c.Type+"/"+c.Name)
+	}
+	sort.Strings(got)
+	expected := []string{
+		fmt.Sprintf("blob/%x", sha256.Sum256([]byte("other"))),
+		fmt.Sprintf("library/sha256.%x", sha256.Sum256([]byte("other"))),
+		"oci-tmp/empty",
+		"oci-tmp/truncated",
+		"records/bad",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("got corrupted entries %q, expected %q", got, expected)
+	}
+
+	for _, c := range corrupt {
+		if err := h.RemoveEntry(c); err != nil {
+			t.Fatalf("unexpected error removing %s: %v", c.Path, err)
+		}
+	}
+	n, corrupt, err = h.Verify(types)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 7 || len(corrupt) != 0 {
+		t.Errorf("got %d entries verified and %d corrupted after removal, expected 7 and none", n, len(corrupt))
+	}
+	if _, err := os.Stat(filepath.Join(h.rootDir, "blob", "blobs", "sha256", good.Encoded())); err != nil {
+		t.Errorf("valid blob removed: %v", err)
+	}
+}
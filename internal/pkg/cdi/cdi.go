@@ -0,0 +1,378 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package cdi discovers the devices described by Container Device Interface
+// (CDI) spec files, and validates them. See
+// https://github.com/cncf-tags/container-device-interface/blob/main/SPEC.md
+package cdi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDirs are the default directories of the CDI spec files, by
+// increasing priority.
+var DefaultDirs = []string{"/etc/cdi", "/var/run/cdi"}
+
+// DirsOrDefault returns dirs, or DefaultDirs when empty, as for the
+// configuration files written before the cdi dirs directive was introduced.
+func DirsOrDefault(dirs []string) []string {
+	if len(dirs) == 0 {
+		return DefaultDirs
+	}
+	return dirs
+}
+
+var (
+	vendorRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?$`)
+	classRegexp  = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?$`)
+	nameRegexp   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.:-]*[a-zA-Z0-9])?$`)
+)
+
+// Spec is a CDI spec file, describing the devices of a kind.
+type Spec struct {
+	Version        string         `yaml:"cdiVersion"`
+	Kind           string         `yaml:"kind"`
+	Devices        []Device       `yaml:"devices"`
+	ContainerEdits ContainerEdits `yaml:"containerEdits,omitempty"`
+
+	// Path is the path of the spec file.
+	Path string `yaml:"-"`
+}
+
+// Device is a device of a spec.
+type Device struct {
+	Name           string         `yaml:"name"`
+	ContainerEdits ContainerEdits `yaml:"containerEdits"`
+}
+
+// ContainerEdits are the edits of the container required to use a device.
+type ContainerEdits struct {
+	Env         []string     `yaml:"env,omitempty"`
+	DeviceNodes []DeviceNode `yaml:"deviceNodes,omitempty"`
+	Mounts      []Mount      `yaml:"mounts,omitempty"`
+	Hooks       []Hook       `yaml:"hooks,omitempty"`
+}
+
+// DeviceNode is a device node added to the container.
+type DeviceNode struct {
+	Path        string `yaml:"path"`
+	HostPath    string `yaml:"hostPath,omitempty"`
+	Permissions string `yaml:"permissions,omitempty"`
+}
+
+// Mount is a mount added to the container.
+type Mount struct {
+	HostPath      string   `yaml:"hostPath"`
+	ContainerPath string   `yaml:"containerPath"`
+	Type          string   `yaml:"type,omitempty"`
+	Options       []string `yaml:"options,omitempty"`
+}
+
+// Hook is an OCI hook run for the container.
+type Hook struct {
+	HookName string   `yaml:"hookName"`
+	Path     string   `yaml:"path"`
+	Args     []string `yaml:"args,omitempty"`
+}
+
+// empty returns whether there are no edits.
+func (e *ContainerEdits) empty() bool {
+	return len(e.Env) == 0 && len(e.DeviceNodes) == 0 && len(e.Mounts) == 0 && len(e.Hooks) == 0
+}
+
+// validate returns an error if the edits are invalid.
+func (e *ContainerEdits) validate() error {
+	for _, env := range e.Env {
+		if k, _, ok := strings.Cut(env, "="); !ok || k == "" {
+			return fmt.Errorf("invalid environment variable %q", env)
+		}
+	}
+	for _, d := range e.DeviceNodes {
+		if !filepath.IsAbs(d.Path) {
+			return fmt.Errorf("device node path %q is not absolute", d.Path)
+		}
+		if d.HostPath != "" && !filepath.IsAbs(d.HostPath) {
+			return fmt.Errorf("device node host path %q is not absolute", d.HostPath)
+		}
+		if strings.Trim(d.Permissions, "rwm") != "" {
+			return fmt.Errorf("invalid device node permissions %q", d.Permissions)
+		}
+	}
+	for _, m := range e.Mounts {
+		if m.HostPath == "" {
+			return fmt.Errorf("mount of %q has no host path", m.ContainerPath)
+		}
+		if !filepath.IsAbs(m.ContainerPath) {
+			return fmt.Errorf("mount container path %q is not absolute", m.ContainerPath)
+		}
+	}
+	for _, h := range e.Hooks {
+		if h.HookName == "" || !filepath.IsAbs(h.Path) {
+			return fmt.Errorf("invalid hook %q with path %q", h.HookName, h.Path)
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the spec is invalid.
+func (s *Spec) Validate() error {
+	if s.Version == "" {
+		return fmt.Errorf("no cdiVersion")
+	}
+	vendor, class, ok := strings.Cut(s.Kind, "/")
+	if !ok || !vendorRegexp.MatchString(vendor) || !classRegexp.MatchString(class) {
+		return fmt.Errorf("invalid kind %q, must be vendor/class", s.Kind)
+	}
+	if err := s.ContainerEdits.validate(); err != nil {
+		return err
+	}
+	if len(s.Devices) == 0 {
+		return fmt.Errorf("no devices")
+	}
+	names := make(map[string]bool)
+	for _, d := range s.Devices {
+		if !nameRegexp.MatchString(d.Name) {
+			return fmt.Errorf("invalid device name %q", d.Name)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("device %q defined more than once", d.Name)
+		}
+		names[d.Name] = true
+		if d.ContainerEdits.empty() {
+			return fmt.Errorf("device %q has no container edits", d.Name)
+		}
+		if err := d.ContainerEdits.validate(); err != nil {
+			return fmt.Errorf("device %q: %w", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// LoadSpec loads and validates the JSON or YAML spec file at path.
+func LoadSpec(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{Path: path}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// SpecFiles returns the spec files in dir, in lexical order.
+func SpecFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".json", ".yaml", ".yml":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// ResolvedDevice is a device discovered in the spec files.
+type ResolvedDevice struct {
+	// Name is the fully qualified name of the device, as vendor/class=name.
+	Name string
+	// Spec is the spec of the device.
+	Spec *Spec
+	// Device is the device in the spec.
+	Device *Device
+}
+
+// Edits returns the container edits of the device, the ones of its spec
+// followed by its own.
+func (d *ResolvedDevice) Edits() ContainerEdits {
+	s, o := d.Spec.ContainerEdits, d.Device.ContainerEdits
+	return ContainerEdits{
+		Env:         append(append([]string{}, s.Env...), o.Env...),
+		DeviceNodes: append(append([]DeviceNode{}, s.DeviceNodes...), o.DeviceNodes...),
+		Mounts:      append(append([]Mount{}, s.Mounts...), o.Mounts...),
+		Hooks:       append(append([]Hook{}, s.Hooks...), o.Hooks...),
+	}
+}
+
+// Registry holds the devices discovered in the spec files.
+type Registry struct {
+	devices map[string]*ResolvedDevice
+	// Errors are the errors of the invalid or conflicting spec files, which
+	// are ignored.
+	Errors []error
+}
+
+// Scan discovers the devices of the spec files in dirs, by increasing
+// priority: a device of a spec file in a directory overrides the one of a
+// spec file in a previous directory. Devices defined in more than one spec
+// file of the same directory are ignored.
+func Scan(dirs []string) *Registry {
+	r := &Registry{devices: make(map[string]*ResolvedDevice)}
+	for _, dir := range dirs {
+		files, err := SpecFiles(dir)
+		if err != nil {
+			r.Errors = append(r.Errors, err)
+			continue
+		}
+		found := make(map[string]*ResolvedDevice)
+		conflicts := make(map[string]bool)
+		for _, f := range files {
+			s, err := LoadSpec(f)
+			if err != nil {
+				r.Errors = append(r.Errors, err)
+				continue
+			}
+			for i := range s.Devices {
+				name := s.Kind + "=" + s.Devices[i].Name
+				if prev, ok := found[name]; ok {
+					r.Errors = append(r.Errors, fmt.Errorf("device %s defined in %s and %s", name, prev.Spec.Path, f))
+					conflicts[name] = true
+					continue
+				}
+				found[name] = &ResolvedDevice{Name: name, Spec: s, Device: &s.Devices[i]}
+			}
+		}
+		for name, d := range found {
+			if !conflicts[name] {
+				r.devices[name] = d
+			}
+		}
+	}
+	return r
+}
+
+// Names returns the fully qualified names of the devices, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.devices))
+	for name := range r.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Device returns the device with the fully qualified name.
+func (r *Registry) Device(name string) (*ResolvedDevice, error) {
+	kind, _, ok := strings.Cut(name, "=")
+	if !ok || !strings.Contains(kind, "/") {
+		return nil, fmt.Errorf("invalid CDI device %q, must be vendor/class=name", name)
+	}
+	d, ok := r.devices[name]
+	if !ok {
+		return nil, fmt.Errorf("CDI device %s not found, use 'apptainer cdi list' to list the available devices", name)
+	}
+	return d, nil
+}
+
+// HostDevice returns the OCI device for the host device node at path, at
+// containerPath in the container.
+func HostDevice(path, containerPath string) (specs.LinuxDevice, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return specs.LinuxDevice{}, fmt.Errorf("while getting device %s: %w", path, err)
+	}
+	var devType string
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFCHR:
+		devType = "c"
+	case unix.S_IFBLK:
+		devType = "b"
+	default:
+		return specs.LinuxDevice{}, fmt.Errorf("%s is not a device", path)
+	}
+	mode := os.FileMode(st.Mode &^ unix.S_IFMT)
+	return specs.LinuxDevice{
+		Path:     containerPath,
+		Type:     devType,
+		Major:    int64(unix.Major(st.Rdev)),
+		Minor:    int64(unix.Minor(st.Rdev)),
+		FileMode: &mode,
+	}, nil
+}
+
+// AddDevice adds the device to the OCI specification of g, allowing its
+// access in the devices cgroup with permissions, rwm when empty.
+func AddDevice(g *generate.Generator, d specs.LinuxDevice, permissions string) {
+	if permissions == "" {
+		permissions = "rwm"
+	}
+	if g.Config.Linux == nil {
+		g.Config.Linux = &specs.Linux{}
+	}
+	g.Config.Linux.Devices = append(g.Config.Linux.Devices, d)
+	if g.Config.Linux.Resources == nil {
+		g.Config.Linux.Resources = &specs.LinuxResources{}
+	}
+	g.Config.Linux.Resources.Devices = append(g.Config.Linux.Resources.Devices, specs.LinuxDeviceCgroup{
+		Allow:  true,
+		Type:   d.Type,
+		Major:  &d.Major,
+		Minor:  &d.Minor,
+		Access: permissions,
+	})
+}
+
+// ApplyToSpec applies the container edits to the OCI specification of g:
+// environment variables, device nodes and mounts. Hooks are not supported.
+func (e *ContainerEdits) ApplyToSpec(g *generate.Generator) error {
+	for _, env := range e.Env {
+		k, v, _ := strings.Cut(env, "=")
+		g.SetProcessEnv(k, v)
+	}
+	for _, n := range e.DeviceNodes {
+		hostPath := n.HostPath
+		if hostPath == "" {
+			hostPath = n.Path
+		}
+		d, err := HostDevice(hostPath, n.Path)
+		if err != nil {
+			return err
+		}
+		AddDevice(g, d, n.Permissions)
+	}
+	for _, m := range e.Mounts {
+		options := m.Options
+		if len(options) == 0 {
+			options = []string{"rbind", "nosuid", "nodev"}
+		}
+		mountType := m.Type
+		if mountType == "" {
+			mountType = "bind"
+		}
+		g.AddMount(specs.Mount{
+			Destination: m.ContainerPath,
+			Type:        mountType,
+			Source:      m.HostPath,
+			Options:     options,
+		})
+	}
+	if len(e.Hooks) > 0 {
+		return fmt.Errorf("CDI hooks are not supported")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cdi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const vendorSpec = `
+cdiVersion: "0.6.0"
+kind: vendor.com/device
+containerEdits:
+  env:
+    - VENDOR=1
+devices:
+  - name: dev0
+    containerEdits:
+      deviceNodes:
+        - path: /dev/vendor0
+  - name: dev1
+    containerEdits:
+      mounts:
+        - hostPath: /opt/vendor
+          containerPath: /opt/vendor
+          options: [ro]
+`
+
+func writeSpec(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{name: "YAML", file: "vendor.yaml", content: vendorSpec},
+		{name: "JSON", file: "vendor.json", content: `{"cdiVersion": "0.6.0", "kind": "vendor.com/device", "devices": [{"name": "dev0", "containerEdits": {"env": ["A=1"]}}]}`},
+		{name: "NoVersion", file: "noversion.json", content: `{"kind": "vendor.com/device", "devices": [{"name": "dev0", "containerEdits": {"env": ["A=1"]}}]}`, wantErr: true},
+		{name: "BadKind", file: "badkind.json", content: `{"cdiVersion": "0.6.0", "kind": "device", "devices": [{"name": "dev0", "containerEdits": {"env": ["A=1"]}}]}`, wantErr: true},
+		{name: "NoDevices", file: "nodevices.json", content: `{"cdiVersion": "0.6.0", "kind": "vendor.com/device"}`, wantErr: true},
+		{name: "NoEdits", file: "noedits.json", content: `{"cdiVersion": "0.6.0", "kind": "vendor.com/device", "devices": [{"name": "dev0"}]}`, wantErr: true},
+		{name: "Duplicate", file: "duplicate.json", content: `{"cdiVersion": "0.6.0", "kind": "vendor.com/device", "devices": [{"name": "dev0", "containerEdits": {"env": ["A=1"]}}, {"name": "dev0", "containerEdits": {"env": ["A=1"]}}]}`, wantErr: true},
+		{name: "RelativeNode", file: "relative.json", content: `{"cdiVersion": "0.6.0", "kind": "vendor.com/device", "devices": [{"name": "dev0", "containerEdits": {"deviceNodes": [{"path": "dev/vendor0"}]}}]}`, wantErr: true},
+		{name: "Malformed", file: "malformed.json", content: `{"cdiVersion": `, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSpec(t, dir, tt.file, tt.content)
+			_, err := LoadSpec(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	low := filepath.Join(root, "etc")
+	high := filepath.Join(root, "run")
+
+	writeSpec(t, low, "vendor.yaml", vendorSpec)
+	writeSpec(t, low, "invalid.json", `{}`)
+	writeSpec(t, low, "README", "not a spec")
+	// dev0 is overridden by the higher priority directory
+	override := writeSpec(t, high, "vendor.json", `{"cdiVersion": "0.6.0", "kind": "vendor.com/device", "devices": [{"name": "dev0", "containerEdits": {"env": ["OVERRIDE=1"]}}]}`)
+	// other is defined twice in the same directory, and ignored
+	writeSpec(t, high, "other1.json", `{"cdiVersion": "0.6.0", "kind": "other.com/device", "devices": [{"name": "x", "containerEdits": {"env": ["A=1"]}}]}`)
+	writeSpec(t, high, "other2.json", `{"cdiVersion": "0.6.0", "kind": "other.com/device", "devices": [{"name": "x", "containerEdits": {"env": ["A=2"]}}]}`)
+
+	r := Scan([]string{low, high, filepath.Join(root, "missing")})
+	expected := []string{"vendor.com/device=dev0", "vendor.com/device=dev1"}
+	if got := r.Names(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got devices %v, expected %v", got, expected)
+	}
+	if len(r.Errors) != 2 {
+		t.Errorf("got errors %v, expected 2", r.Errors)
+	}
+
+	d, err := r.Device("vendor.com/device=dev0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Spec.Path != override {
+		t.Errorf("got device from %s, expected %s", d.Spec.Path, override)
+	}
+
+	d, err = r.Device("vendor.com/device=dev1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	edits := d.Edits()
+	if !reflect.DeepEqual(edits.Env, []string{"VENDOR=1"}) || len(edits.Mounts) != 1 {
+		t.Errorf("unexpected edits %+v", edits)
+	}
+
+	if _, err := r.Device("vendor.com/device=dev2"); err == nil {
+		t.Errorf("unexpected success getting unknown device")
+	}
+	if _, err := r.Device("dev0"); err == nil {
+		t.Errorf("unexpected success getting unqualified device")
+	}
+}
+
+func TestApplyToSpec(t *testing.T) {
+	g := generate.New(&specs.Spec{})
+	edits := &ContainerEdits{
+		Env:         []string{"VENDOR=1"},
+		DeviceNodes: []DeviceNode{{Path: "/dev/vendor0", HostPath: "/dev/null", Permissions: "rw"}},
+		Mounts:      []Mount{{HostPath: "/opt/vendor", ContainerPath: "/opt/vendor", Options: []string{"ro"}}},
+	}
+	if err := edits.ApplyToSpec(g); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(g.Config.Process.Env, []string{"VENDOR=1"}) {
+		t.Errorf("got environment %v", g.Config.Process.Env)
+	}
+	devs := g.Config.Linux.Devices
+	if len(devs) != 1 || devs[0].Path != "/dev/vendor0" || devs[0].Type != "c" || devs[0].Major != 1 || devs[0].Minor != 3 {
+		t.Errorf("got devices %+v", devs)
+	}
+	allowed := g.Config.Linux.Resources.Devices
+	if len(allowed) != 1 || !allowed[0].Allow || allowed[0].Access != "rw" {
+		t.Errorf("got allowed devices %+v", allowed)
+	}
+	if len(g.Config.Mounts) != 1 || g.Config.Mounts[0].Type != "bind" || g.Config.Mounts[0].Source != "/opt/vendor" {
+		t.Errorf("got mounts %+v", g.Config.Mounts)
+	}
+
+	edits = &ContainerEdits{DeviceNodes: []DeviceNode{{Path: "/etc/passwd"}}}
+	if err := edits.ApplyToSpec(g); err == nil {
+		t.Errorf("unexpected success adding a regular file as a device")
+	}
+}
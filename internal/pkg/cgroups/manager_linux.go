@@ -14,7 +14,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/util/env"
@@ -25,6 +24,7 @@ import (
 	lcconfigs "github.com/opencontainers/runc/libcontainer/configs"
 	lcspecconv "github.com/opencontainers/runc/libcontainer/specconv"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
 )
 
 var ErrUninitialized = errors.New("cgroups manager is not initialized")
@@ -226,6 +226,18 @@ func (m *Manager) Thaw() (err error) {
 	return m.cgroup.Freeze(lcconfigs.Thawed)
 }
 
+// IsFrozen returns whether processes in the managed cgroup are frozen.
+func (m *Manager) IsFrozen() (bool, error) {
+	if m.group == "" || m.cgroup == nil {
+		return false, ErrUninitialized
+	}
+	state, err := m.cgroup.GetFreezerState()
+	if err != nil {
+		return false, err
+	}
+	return state == lcconfigs.Frozen, nil
+}
+
 // Destroy deletes the managed cgroup.
 func (m *Manager) Destroy() (err error) {
 	if m.group == "" || m.cgroup == nil {
@@ -234,12 +246,34 @@ func (m *Manager) Destroy() (err error) {
 	return m.cgroup.Destroy()
 }
 
+// TagPrefix is the prefix of the extended attributes tagging a cgroup.
+const TagPrefix = "user.apptainer."
+
+// Tag sets tags on the managed cgroup, as extended attributes prefixed by
+// TagPrefix, so that the events of the processes in the cgroup can be
+// attributed by monitoring tools. Only cgroups v2 support tags.
+func (m *Manager) Tag(tags map[string]string) error {
+	if m.group == "" || m.cgroup == nil {
+		return ErrUninitialized
+	}
+	if !lccgroups.IsCgroup2UnifiedMode() {
+		return fmt.Errorf("cgroup tags require cgroups v2")
+	}
+	path := m.cgroup.Path("")
+	for k, v := range tags {
+		if err := unix.Setxattr(path, TagPrefix+k, []byte(v), 0); err != nil {
+			return fmt.Errorf("while tagging cgroup %s with %s: %w", path, k, err)
+		}
+	}
+	return nil
+}
+
 // useRootless identifies whether rootless cgroups are required, and verifies the requested cgroup name is valid.
 func useRootless(group string, systemd bool) (rootless bool, err error) {
 	if os.Geteuid() == 0 {
 		if systemd {
-			if !strings.HasPrefix(group, "system.slice:") {
-				return false, fmt.Errorf("systemd cgroups require a cgroups path beginning with 'system.slice:'")
+			if slice, _, _ := strings.Cut(group, ":"); !strings.HasSuffix(slice, ".slice") {
+				return false, fmt.Errorf("systemd cgroups require a cgroups path beginning with a slice like 'system.slice:'")
 			}
 		}
 		return false, nil
@@ -351,15 +385,8 @@ func NewManagerWithSpec(spec *specs.LinuxResources, pid int, group string, syste
 	if pid == 0 {
 		return nil, fmt.Errorf("a pid is required to create a new cgroup")
 	}
-	if group == "" && !systemd {
-		group = filepath.Join("/apptainer", strconv.Itoa(pid))
-	}
-	if group == "" && systemd {
-		if os.Getuid() == 0 {
-			group = "system.slice:apptainer:" + strconv.Itoa(pid)
-		} else {
-			group = "user.slice:apptainer:" + strconv.Itoa(pid)
-		}
+	if group == "" {
+		group = DefaultGroup("", pid, systemd)
 	}
 
 	sylog.Debugf("Creating cgroups manager for %s", group)
@@ -223,3 +223,68 @@ func testManager(t *testing.T, systemd bool) (pid int, manager *Manager, cleanup
 
 	return pid, manager, cleanup
 }
+
+func TestDefaultGroup(t *testing.T) {
+	test.DropPrivilege(t)
+	defer test.ResetPrivilege(t)
+
+	tests := []struct {
+		parent  string
+		systemd bool
+		want    string
+	}{
+		{parent: "", want: "/apptainer/42"},
+		{parent: "/slurm/uid_1000/job_1", want: "/slurm/uid_1000/job_1/apptainer/42"},
+		{parent: "", systemd: true, want: "user.slice:apptainer:42"},
+		{parent: "job.slice", systemd: true, want: "job.slice:apptainer:42"},
+	}
+	for _, tt := range tests {
+		if got := DefaultGroup(tt.parent, 42, tt.systemd); got != tt.want {
+			t.Errorf("got group %q for parent %q, expected %q", got, tt.parent, tt.want)
+		}
+	}
+}
+
+func TestCheckParent(t *testing.T) {
+	tests := []struct {
+		parent  string
+		systemd bool
+		success bool
+	}{
+		{parent: "/slurm/job_1", success: true},
+		{parent: "slurm/job_1"},
+		{parent: "/slurm/../job_1"},
+		{parent: "job.slice", systemd: true, success: true},
+		{parent: "/job.slice", systemd: true},
+		{parent: "job.scope", systemd: true},
+	}
+	for _, tt := range tests {
+		err := CheckParent(tt.parent, tt.systemd)
+		if err != nil && tt.success {
+			t.Errorf("unexpected failure for %q: %s", tt.parent, err)
+		} else if err == nil && !tt.success {
+			t.Errorf("unexpected success for %q", tt.parent)
+		}
+	}
+}
+
+func TestInParent(t *testing.T) {
+	path, err := pidToPath(os.Getpid())
+	if err != nil {
+		t.Skipf("could not get cgroup of current process: %s", err)
+	}
+	for parent, want := range map[string]bool{
+		"/":                          true,
+		path:                         true,
+		filepath.Dir(path):           true,
+		filepath.Join(path, "child"): false,
+		"/apptainer-nonexistent":     path == "/apptainer-nonexistent",
+	} {
+		got, err := InParent(parent, os.Getpid())
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", parent, err)
+		} else if got != want {
+			t.Errorf("got %v for %q in %q, expected %v", got, path, parent, want)
+		}
+	}
+}
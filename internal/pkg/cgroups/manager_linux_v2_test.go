@@ -19,6 +19,7 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/test"
 	"github.com/apptainer/apptainer/internal/pkg/test/tool/require"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
 )
 
 // This file contains tests that will run under cgroups v2 only.
@@ -51,6 +52,10 @@ func TestCgroupsV2(t *testing.T) {
 			name:     "FreezeThaw",
 			testFunc: testFreezeThawV2,
 		},
+		{
+			name:     "Tag",
+			testFunc: testTagV2,
+		},
 	}
 	runCgroupfsTests(t, tests)
 	runSystemdTests(t, tests)
@@ -202,3 +207,25 @@ func testFreezeThawV2(t *testing.T, systemd bool) {
 	ensureStateBecomes(t, pid, "RS")
 	ensureInt(t, freezePath, 0)
 }
+
+func testTagV2(t *testing.T, systemd bool) {
+	manager := &Manager{}
+	if err := manager.Tag(map[string]string{"instance": "test"}); err == nil {
+		t.Errorf("unexpected success tagging uninitialized cgroup")
+	}
+
+	_, manager, cleanup := testManager(t, systemd)
+	defer cleanup()
+
+	if err := manager.Tag(map[string]string{"instance": "test"}); err != nil {
+		t.Fatalf("While tagging cgroup: %v", err)
+	}
+	value := make([]byte, 64)
+	n, err := unix.Getxattr(manager.cgroup.Path(""), TagPrefix+"instance", value)
+	if err != nil {
+		t.Fatalf("While reading cgroup tag: %v", err)
+	}
+	if got := string(value[:n]); got != "test" {
+		t.Errorf("Expected tag test, got %s", got)
+	}
+}
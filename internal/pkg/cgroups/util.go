@@ -12,6 +12,8 @@ package cgroups
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -56,6 +58,54 @@ func pidToPath(pid int) (path string, err error) {
 	return path, nil
 }
 
+// DefaultGroup returns the cgroup name/path of the container process pid
+// under parent. With cgroupfs, parent is a cgroup path, "/" by default. With
+// systemd, parent is a slice, system.slice or user.slice by default.
+func DefaultGroup(parent string, pid int, systemd bool) string {
+	if !systemd {
+		if parent == "" {
+			parent = "/"
+		}
+		return filepath.Join(parent, "apptainer", strconv.Itoa(pid))
+	}
+	if parent == "" {
+		parent = "system.slice"
+		if os.Getuid() != 0 {
+			parent = "user.slice"
+		}
+	}
+	return parent + ":apptainer:" + strconv.Itoa(pid)
+}
+
+// CheckParent checks that parent is a valid cgroup parent: an absolute
+// cgroup path with cgroupfs, or a slice name with systemd.
+func CheckParent(parent string, systemd bool) error {
+	if systemd {
+		if !strings.HasSuffix(parent, ".slice") || strings.ContainsAny(parent, "/:") {
+			return fmt.Errorf("cgroup parent %q must be a systemd slice name with systemd cgroups", parent)
+		}
+		return nil
+	}
+	if !filepath.IsAbs(parent) || filepath.Clean(parent) != parent {
+		return fmt.Errorf("cgroup parent %q must be an absolute and clean cgroup path", parent)
+	}
+	return nil
+}
+
+// InParent returns whether the process pid is in the cgroup parent, or in
+// one of its descendants.
+func InParent(parent string, pid int) (bool, error) {
+	path, err := pidToPath(pid)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false, nil
+	}
+	return rel != ".." && !strings.HasPrefix(rel, "../"), nil
+}
+
 // HasDbus checks if DBUS_SESSION_BUS_ADDRESS is set, and sane.
 // Logs unset var / non-existent target at DEBUG level.
 func HasDbus() (bool, error) {
@@ -0,0 +1,75 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package dmtcp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackUnpack(t *testing.T) {
+	src := &Entry{path: t.TempDir()}
+	if err := os.WriteFile(filepath.Join(src.path, portFile), []byte("7779\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src.path, "dmtcp_restart_script_1.sh"), []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("dmtcp_restart_script_1.sh", filepath.Join(src.path, "dmtcp_restart_script.sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Pack(&buf); err != nil {
+		t.Fatalf("while packing checkpoint: %v", err)
+	}
+
+	dst := &Entry{path: t.TempDir()}
+	if err := dst.Unpack(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("while unpacking checkpoint: %v", err)
+	}
+	port, err := dst.CoordinatorPort()
+	if err != nil || port != "7779" {
+		t.Errorf("got port %q, error %v", port, err)
+	}
+	link, err := os.Readlink(filepath.Join(dst.path, "dmtcp_restart_script.sh"))
+	if err != nil || link != "dmtcp_restart_script_1.sh" {
+		t.Errorf("got link %q, error %v", link, err)
+	}
+}
+
+func TestUnpackEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  tar.Header
+	}{
+		{name: "Path", hdr: tar.Header{Name: "../escape", Typeflag: tar.TypeReg}},
+		{name: "Link", hdr: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}},
+		{name: "AbsoluteLink", hdr: tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			if err := tw.WriteHeader(&tt.hdr); err != nil {
+				t.Fatal(err)
+			}
+			tw.Close()
+			gw.Close()
+
+			e := &Entry{path: filepath.Join(t.TempDir(), "checkpoint")}
+			if err := e.Unpack(&buf); err == nil {
+				t.Errorf("unexpected success")
+			}
+		})
+	}
+}
@@ -55,3 +55,14 @@ func CheckpointArgs(coordinatorPort string) []string {
 		"-c",
 	}
 }
+
+// BlockingCheckpointArgs returns the command checkpointing the processes
+// attached to the coordinator, and waiting for the checkpoint to complete.
+func BlockingCheckpointArgs(coordinatorPort string) []string {
+	return []string{
+		"dmtcp_command",
+		"--coord-port",
+		coordinatorPort,
+		"-bc",
+	}
+}
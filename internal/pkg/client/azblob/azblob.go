@@ -0,0 +1,287 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package azblob implements an object storage backend for Azure Blob
+// Storage. Containers of the storage account are accessed as buckets.
+package azblob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	// apiVersion is the Blob service REST API version used by requests.
+	apiVersion = "2021-08-06"
+	// maxPutSize is the maximum size of a blob uploaded by a single Put Blob.
+	maxPutSize = 5000 * 1024 * 1024
+	// Timeout for an object transfer in seconds - could be a large image...
+	transferTimeout = 7200
+)
+
+// Config holds the configuration of the Azure Blob Storage client.
+type Config struct {
+	// Account is the storage account name.
+	Account string
+	// Key is the base64 encoded storage account key used for Shared Key
+	// authorization.
+	Key string
+	// SASToken is a shared access signature query string, used when Key is
+	// not set. Anonymous requests are made when both are unset.
+	SASToken string
+	// Endpoint overrides the blob service endpoint of the account, e.g.
+	// for Azurite.
+	Endpoint string
+}
+
+// DefaultConfig returns a configuration resolved from the
+// AZURE_STORAGE_CONNECTION_STRING environment variable, or from the
+// AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY and AZURE_STORAGE_SAS_TOKEN
+// environment variables.
+func DefaultConfig() (*Config, error) {
+	cfg := &Config{}
+
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		for _, field := range strings.Split(cs, ";") {
+			k, v, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "AccountName":
+				cfg.Account = v
+			case "AccountKey":
+				cfg.Key = v
+			case "SharedAccessSignature":
+				cfg.SASToken = v
+			case "BlobEndpoint":
+				cfg.Endpoint = v
+			}
+		}
+	} else {
+		cfg.Account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+		cfg.Key = os.Getenv("AZURE_STORAGE_KEY")
+		cfg.SASToken = os.Getenv("AZURE_STORAGE_SAS_TOKEN")
+	}
+
+	cfg.SASToken = strings.TrimPrefix(cfg.SASToken, "?")
+	if cfg.Account == "" && cfg.Endpoint == "" {
+		return nil, fmt.Errorf("no Azure storage account configured, set AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING")
+	}
+	if cfg.Key != "" && cfg.Account == "" {
+		return nil, fmt.Errorf("an Azure storage account name is required with an account key")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://" + cfg.Account + ".blob.core.windows.net"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	if cfg.Key == "" && cfg.SASToken == "" {
+		sylog.Debugf("No Azure storage credentials found, using anonymous requests")
+	}
+	return cfg, nil
+}
+
+// Client is a minimal Azure Blob Storage client implementing
+// objstore.Backend.
+type Client struct {
+	cfg        *Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient returns a client using the given configuration.
+func NewClient(cfg *Config) *Client {
+	return &Client{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: transferTimeout * time.Second,
+		},
+		now: time.Now,
+	}
+}
+
+// newRequest returns an authorized request for the referenced blob.
+func (c *Client) newRequest(ctx context.Context, method string, ref objstore.Ref, body io.Reader, size int64, headers map[string]string) (*http.Request, error) {
+	u, err := url.Parse(c.cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure blob endpoint %q: %v", c.cfg.Endpoint, err)
+	}
+	u.Path += "/" + ref.Bucket + "/" + ref.Key
+	if c.cfg.Key == "" && c.cfg.SASToken != "" {
+		u.RawQuery = c.cfg.SASToken
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	req.Header.Set("User-Agent", useragent.Value())
+	req.Header.Set("X-Ms-Date", c.now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", apiVersion)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	if c.cfg.Key != "" {
+		if err := c.sign(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// sign adds a Shared Key Authorization header to req.
+func (c *Client) sign(req *http.Request) error {
+	key, err := base64.StdEncoding.DecodeString(c.cfg.Key)
+	if err != nil {
+		return fmt.Errorf("invalid Azure storage account key: %v", err)
+	}
+
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+
+	var msHeaders []string
+	for name := range req.Header {
+		if lname := strings.ToLower(name); strings.HasPrefix(lname, "x-ms-") {
+			msHeaders = append(msHeaders, lname+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + c.cfg.Account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(msHeaders, "\n"),
+		resource,
+	}, "\n")
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", "SharedKey "+c.cfg.Account+":"+base64.StdEncoding.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// do sends the request and checks the response status.
+func (c *Client) do(req *http.Request, ref objstore.Ref) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("object %s was not found", ref)
+	}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	msg := string(b)
+	if start, end := strings.Index(msg, "<Message>"), strings.Index(msg, "</Message>"); start >= 0 && end > start {
+		msg = msg[start+len("<Message>") : end]
+	}
+	if msg == "" {
+		msg = res.Header.Get("X-Ms-Error-Code")
+	}
+	return nil, fmt.Errorf("request for %s failed: %s %s", ref, res.Status, strings.TrimSpace(msg))
+}
+
+// Stat returns the metadata of the referenced object.
+func (c *Client) Stat(ctx context.Context, ref objstore.Ref) (objstore.ObjectInfo, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, ref, nil, 0, nil)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	res, err := c.do(req, ref)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	defer res.Body.Close()
+	return objstore.ObjectInfo{Size: res.ContentLength, ETag: res.Header.Get("ETag")}, nil
+}
+
+// GetRange returns length bytes of the referenced object starting at offset,
+// or the whole object when length is negative.
+func (c *Client) GetRange(ctx context.Context, ref objstore.Ref, offset, length int64) (io.ReadCloser, error) {
+	var headers map[string]string
+	if length >= 0 {
+		headers = map[string]string{"X-Ms-Range": fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)}
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, ref, nil, 0, headers)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req, ref)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Put uploads size bytes read from r to the referenced object as a block
+// blob.
+func (c *Client) Put(ctx context.Context, ref objstore.Ref, r io.Reader, size int64) error {
+	if c.cfg.Key == "" && c.cfg.SASToken == "" {
+		return fmt.Errorf("no Azure storage credentials found to push %s", ref)
+	}
+	if size > maxPutSize {
+		return fmt.Errorf("image size %d exceeds the maximum size of %d bytes for an Azure blob upload", size, int64(maxPutSize))
+	}
+	req, err := c.newRequest(ctx, http.MethodPut, ref, r, size, map[string]string{
+		"Content-Type":   "application/octet-stream",
+		"X-Ms-Blob-Type": "BlockBlob",
+	})
+	if err != nil {
+		return err
+	}
+	res, err := c.do(req, ref)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
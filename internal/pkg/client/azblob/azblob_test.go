@@ -0,0 +1,146 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package azblob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func TestDefaultConfig(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	t.Setenv("AZURE_STORAGE_KEY", "")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "")
+
+	if _, err := DefaultConfig(); err == nil {
+		t.Errorf("expected error without storage account")
+	}
+
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "account")
+	t.Setenv("AZURE_STORAGE_SAS_TOKEN", "?sv=2021&sig=abc")
+	cfg, err := DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Endpoint != "https://account.blob.core.windows.net" || cfg.SASToken != "sv=2021&sig=abc" {
+		t.Errorf("unexpected configuration %+v", cfg)
+	}
+
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;AccountKey=a2V5;BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1/;")
+	cfg, err = DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := Config{Account: "devstoreaccount1", Key: "a2V5", Endpoint: "http://127.0.0.1:10000/devstoreaccount1"}
+	if *cfg != expected {
+		t.Errorf("got configuration %+v, expected %+v", *cfg, expected)
+	}
+}
+
+func TestClient(t *testing.T) {
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "SharedKey account:") && r.URL.Query().Get("sig") != "abc" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "<Error><Code>AuthenticationFailed</Code><Message>Server failed to authenticate the request.</Message></Error>")
+			return
+		}
+		if r.Header.Get("X-Ms-Version") != apiVersion || r.Header.Get("X-Ms-Date") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("X-Ms-Blob-Type") != "BlockBlob" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = b
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodHead, http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("X-Ms-Range"), "bytes=%d-%d", &start, &end); err == nil {
+				b = b[start : end+1]
+			}
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Content-Length", fmt.Sprint(len(b)))
+			if r.Method == http.MethodGet {
+				w.Write(b)
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ref := objstore.Ref{Transport: objstore.AzBlob, Bucket: "container", Key: "image.sif"}
+
+	for _, cfg := range []Config{
+		{Account: "account", Key: "c2VjcmV0", Endpoint: srv.URL},
+		{Account: "account", SASToken: "sv=2021&sig=abc", Endpoint: srv.URL},
+	} {
+		c := NewClient(&cfg)
+
+		content := "image content"
+		if err := c.Put(ctx, ref, strings.NewReader(content), int64(len(content))); err != nil {
+			t.Fatalf("unexpected put error: %v", err)
+		}
+
+		info, err := c.Stat(ctx, ref)
+		if err != nil {
+			t.Fatalf("unexpected stat error: %v", err)
+		}
+		if info.Size != int64(len(content)) || info.ETag != `"etag"` {
+			t.Errorf("got %+v, expected size %d and etag %s", info, len(content), `"etag"`)
+		}
+
+		body, err := c.GetRange(ctx, ref, 0, 5)
+		if err != nil {
+			t.Fatalf("unexpected get error: %v", err)
+		}
+		b, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "image" {
+			t.Errorf("got content %q, expected %q", b, "image")
+		}
+	}
+
+	anonymous := NewClient(&Config{Account: "account", Endpoint: srv.URL})
+	if _, err := anonymous.Stat(ctx, objstore.Ref{Transport: objstore.AzBlob, Bucket: "container", Key: "missing"}); err == nil {
+		t.Errorf("expected error for missing object")
+	}
+	if _, err := anonymous.GetRange(ctx, ref, 0, -1); err == nil || !strings.Contains(err.Error(), "failed to authenticate") {
+		t.Errorf("expected authentication error, got %v", err)
+	}
+	if err := anonymous.Put(ctx, ref, strings.NewReader("x"), 1); err == nil {
+		t.Errorf("expected error pushing without credentials")
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package gcs implements an object storage backend for Google Cloud Storage.
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	defaultEndpoint   = "https://storage.googleapis.com"
+	defaultTokenURI   = "https://oauth2.googleapis.com/token"
+	readWriteScope    = "https://www.googleapis.com/auth/devstorage.read_write"
+	serviceAccountKey = "service_account"
+	authorizedUserKey = "authorized_user"
+	// Timeout for an object transfer in seconds - could be a large image...
+	transferTimeout = 7200
+)
+
+// credentialsFile holds the fields of the application default credentials
+// and service account key files used to obtain access tokens.
+type credentialsFile struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Config holds the configuration of the Google Cloud Storage client.
+type Config struct {
+	// AccessToken is used as is to authorize requests when set.
+	AccessToken string
+	// Credentials are used to obtain access tokens, anonymous requests are
+	// made when both AccessToken and Credentials are unset.
+	Credentials *credentialsFile
+	// Endpoint overrides the Google Cloud Storage endpoint, e.g. for an
+	// emulator.
+	Endpoint string
+}
+
+// DefaultConfig returns a configuration resolved from the
+// GOOGLE_OAUTH_ACCESS_TOKEN and GOOGLE_APPLICATION_CREDENTIALS environment
+// variables, then from the gcloud application default credentials. The
+// STORAGE_EMULATOR_HOST environment variable overrides the endpoint.
+func DefaultConfig() (*Config, error) {
+	cfg := &Config{
+		AccessToken: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		Endpoint:    defaultEndpoint,
+	}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		cfg.Endpoint = strings.TrimSuffix(host, "/")
+	}
+	if cfg.AccessToken != "" {
+		return cfg, nil
+	}
+
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+			if _, err := os.Stat(path); err != nil {
+				path = ""
+			}
+		}
+	}
+	if path == "" {
+		sylog.Debugf("No Google Cloud credentials found, using anonymous requests")
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("while reading Google Cloud credentials: %v", err)
+	}
+	creds := &credentialsFile{}
+	if err := json.Unmarshal(b, creds); err != nil {
+		return nil, fmt.Errorf("while parsing Google Cloud credentials %s: %v", path, err)
+	}
+	if creds.Type != serviceAccountKey && creds.Type != authorizedUserKey {
+		return nil, fmt.Errorf("unsupported Google Cloud credentials type %q in %s", creds.Type, path)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = defaultTokenURI
+	}
+	cfg.Credentials = creds
+	return cfg, nil
+}
+
+// Client is a minimal Google Cloud Storage client implementing
+// objstore.Backend with the JSON API.
+type Client struct {
+	cfg        *Config
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// NewClient returns a client using the given configuration.
+func NewClient(cfg *Config) *Client {
+	return &Client{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: transferTimeout * time.Second,
+		},
+		now: time.Now,
+	}
+}
+
+// accessToken returns the access token authorizing requests, fetching a new
+// one from the token endpoint when needed. An empty token means anonymous
+// requests.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	if c.cfg.AccessToken != "" || c.cfg.Credentials == nil {
+		return c.cfg.AccessToken, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Add(time.Minute).Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	creds := c.cfg.Credentials
+	form := url.Values{}
+	if creds.Type == serviceAccountKey {
+		assertion, err := c.jwtAssertion(creds)
+		if err != nil {
+			return "", err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	} else {
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		form.Set("refresh_token", creds.RefreshToken)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, creds.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("while requesting Google Cloud access token: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return "", fmt.Errorf("while requesting Google Cloud access token: %s %s", res.Status, strings.TrimSpace(string(b)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+		return "", fmt.Errorf("while decoding Google Cloud access token: %v", err)
+	}
+	c.token = tok.AccessToken
+	c.tokenExpiry = c.now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// jwtAssertion returns a signed JWT exchanged for an access token with a
+// service account key.
+func (c *Client) jwtAssertion(creds *credentialsFile) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("invalid service account private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("invalid service account private key: %v", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("service account private key is not a RSA key")
+	}
+
+	now := c.now().Unix()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   creds.ClientEmail,
+		"scope": readWriteScope,
+		"aud":   creds.TokenURI,
+		"iat":   now,
+		"exp":   now + 3600,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("while signing service account assertion: %v", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// do sends an authorized request and checks the response status.
+func (c *Client) do(req *http.Request, ref objstore.Ref) (*http.Response, error) {
+	token, err := c.accessToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("User-Agent", useragent.Value())
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("object %s was not found", ref)
+	}
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	msg := strings.TrimSpace(string(b))
+	if json.Unmarshal(b, &apiErr) == nil && apiErr.Error.Message != "" {
+		msg = apiErr.Error.Message
+	}
+	return nil, fmt.Errorf("request for %s failed: %s %s", ref, res.Status, msg)
+}
+
+// objectURL returns the JSON API URL of the referenced object.
+func (c *Client) objectURL(ref objstore.Ref) string {
+	return c.cfg.Endpoint + "/storage/v1/b/" + url.PathEscape(ref.Bucket) + "/o/" + url.PathEscape(ref.Key)
+}
+
+// Stat returns the metadata of the referenced object.
+func (c *Client) Stat(ctx context.Context, ref objstore.Ref) (objstore.ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(ref), nil)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	res, err := c.do(req, ref)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	defer res.Body.Close()
+
+	var obj struct {
+		Size string `json:"size"`
+		ETag string `json:"etag"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&obj); err != nil {
+		return objstore.ObjectInfo{}, fmt.Errorf("while decoding %s metadata: %v", ref, err)
+	}
+	size, err := strconv.ParseInt(obj.Size, 10, 64)
+	if err != nil {
+		return objstore.ObjectInfo{}, fmt.Errorf("invalid size %q for %s: %v", obj.Size, ref, err)
+	}
+	return objstore.ObjectInfo{Size: size, ETag: obj.ETag}, nil
+}
+
+// GetRange returns length bytes of the referenced object starting at offset,
+// or the whole object when length is negative.
+func (c *Client) GetRange(ctx context.Context, ref objstore.Ref, offset, length int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL(ref)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+	res, err := c.do(req, ref)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Put uploads size bytes read from r to the referenced object.
+func (c *Client) Put(ctx context.Context, ref objstore.Ref, r io.Reader, size int64) error {
+	if c.cfg.AccessToken == "" && c.cfg.Credentials == nil {
+		return fmt.Errorf("no Google Cloud credentials found to push %s", ref)
+	}
+	u := c.cfg.Endpoint + "/upload/storage/v1/b/" + url.PathEscape(ref.Bucket) + "/o?" + url.Values{
+		"uploadType": {"media"},
+		"name":       {ref.Key},
+	}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := c.do(req, ref)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, err = io.Copy(io.Discard, res.Body)
+	return err
+}
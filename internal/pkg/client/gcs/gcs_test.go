@@ -0,0 +1,207 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package gcs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func TestDefaultConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("STORAGE_EMULATOR_HOST", "localhost:4443")
+
+	cfg, err := DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Endpoint != "http://localhost:4443" {
+		t.Errorf("got endpoint %q, expected %q", cfg.Endpoint, "http://localhost:4443")
+	}
+	if cfg.AccessToken != "" || cfg.Credentials != nil {
+		t.Errorf("expected anonymous configuration, got %+v", cfg)
+	}
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(`{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "refresh"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+	cfg, err = DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Credentials == nil || cfg.Credentials.RefreshToken != "refresh" || cfg.Credentials.TokenURI != defaultTokenURI {
+		t.Errorf("unexpected credentials %+v", cfg.Credentials)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"type": "external_account"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DefaultConfig(); err == nil {
+		t.Errorf("expected error for unsupported credentials type")
+	}
+}
+
+func TestServiceAccountToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("while parsing token request: %v", err)
+		}
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("invalid assertion %q", r.PostForm.Get("assertion"))
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+			t.Errorf("invalid assertion signature: %v", err)
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if !strings.Contains(string(claims), `"iss":"sa@project.iam.gserviceaccount.com"`) {
+			t.Errorf("unexpected claims %s", claims)
+		}
+		fmt.Fprint(w, `{"access_token": "token", "expires_in": 3600}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(&Config{
+		Credentials: &credentialsFile{
+			Type:        serviceAccountKey,
+			ClientEmail: "sa@project.iam.gserviceaccount.com",
+			PrivateKey:  pemKey,
+			TokenURI:    srv.URL,
+		},
+	})
+	for i := 0; i < 2; i++ {
+		token, err := c.accessToken(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token != "token" {
+			t.Errorf("got token %q, expected %q", token, "token")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %d token requests, expected 1", requests)
+	}
+}
+
+func TestClient(t *testing.T) {
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": {"message": "Anonymous caller does not have access"}}`)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Query().Get("name")] = b
+			fmt.Fprint(w, `{}`)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"):
+			name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bucket/o/")
+			b, ok := objects[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.URL.Query().Get("alt") != "media" {
+				json.NewEncoder(w).Encode(map[string]string{"size": fmt.Sprint(len(b)), "etag": "etag"})
+				return
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+				b = b[start : end+1]
+			}
+			w.Write(b)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ref := objstore.Ref{Transport: objstore.GS, Bucket: "bucket", Key: "path/image.sif"}
+	c := NewClient(&Config{AccessToken: "token", Endpoint: srv.URL})
+
+	if _, err := c.Stat(ctx, ref); err == nil {
+		t.Fatalf("expected error for missing object")
+	}
+
+	content := "image content"
+	if err := c.Put(ctx, ref, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+
+	info, err := c.Stat(ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected stat error: %v", err)
+	}
+	if info.Size != int64(len(content)) || info.ETag != "etag" {
+		t.Errorf("got %+v, expected size %d and etag %s", info, len(content), "etag")
+	}
+
+	body, err := c.GetRange(ctx, ref, 6, 7)
+	if err != nil {
+		t.Fatalf("unexpected get error: %v", err)
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "content" {
+		t.Errorf("got content %q, expected %q", b, "content")
+	}
+
+	anonymous := NewClient(&Config{Endpoint: srv.URL})
+	if _, err := anonymous.Stat(ctx, ref); err == nil || !strings.Contains(err.Error(), "does not have access") {
+		t.Errorf("expected access error, got %v", err)
+	}
+	if err := anonymous.Put(ctx, ref, strings.NewReader(content), int64(len(content))); err == nil {
+		t.Errorf("expected error pushing without credentials")
+	}
+}
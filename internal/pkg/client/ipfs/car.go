@@ -0,0 +1,245 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package ipfs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// maxBlockSize bounds the size of a CAR section, IPFS blocks are
+	// limited to a few MiB.
+	maxBlockSize = 4 << 20
+
+	// UnixFS node types of file content.
+	unixfsRaw  = 0
+	unixfsFile = 2
+)
+
+// blockLocation is the location of the data of a block in a CAR file.
+type blockLocation struct {
+	codec  uint64
+	offset int64
+	size   int
+}
+
+// carIndex gives access to the verified blocks of a CARv1 file.
+type carIndex struct {
+	f      *os.File
+	blocks map[string]blockLocation
+}
+
+// indexCAR reads the CARv1 file f, verifying every block against its CID.
+func indexCAR(f *os.File) (*carIndex, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	cr := &countingReader{r: bufio.NewReader(f)}
+
+	// The dag-cbor header holding the version and roots is not needed,
+	// the requested CID is looked up in the index.
+	headerSize, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CAR header: %v", err)
+	}
+	if headerSize > maxBlockSize {
+		return nil, fmt.Errorf("invalid CAR header size %d", headerSize)
+	}
+	if _, err := io.CopyN(io.Discard, cr, int64(headerSize)); err != nil {
+		return nil, fmt.Errorf("invalid CAR header: %v", err)
+	}
+
+	idx := &carIndex{f: f, blocks: make(map[string]blockLocation)}
+	section := make([]byte, 0, 1<<20)
+	for {
+		size, err := binary.ReadUvarint(cr)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CAR section: %v", err)
+		}
+		if size > maxBlockSize {
+			return nil, fmt.Errorf("CAR section of %d bytes exceeds the maximum block size", size)
+		}
+		start := cr.n
+		if cap(section) < int(size) {
+			section = make([]byte, size)
+		}
+		section = section[:size]
+		if _, err := io.ReadFull(cr, section); err != nil {
+			return nil, fmt.Errorf("truncated CAR section: %v", err)
+		}
+		c, n, err := readCID(section)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block CID: %v", err)
+		}
+		if err := c.verify(section[n:]); err != nil {
+			return nil, err
+		}
+		idx.blocks[c.key()] = blockLocation{
+			codec:  c.codec,
+			offset: start + int64(n),
+			size:   len(section) - n,
+		}
+	}
+	return idx, nil
+}
+
+// block returns the data of the block identified by c.
+func (idx *carIndex) block(c cid) ([]byte, error) {
+	loc, ok := idx.blocks[c.key()]
+	if !ok {
+		return nil, fmt.Errorf("block %s is missing from the CAR", c.key())
+	}
+	data := make([]byte, loc.size)
+	if _, err := idx.f.ReadAt(data, loc.offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeFile writes the content of the UnixFS file rooted at c to w.
+func (idx *carIndex) writeFile(c cid, w io.Writer) error {
+	data, err := idx.block(c)
+	if err != nil {
+		return err
+	}
+	if c.codec == codecRaw {
+		_, err := w.Write(data)
+		return err
+	}
+
+	links, nodeData, err := decodePBNode(data)
+	if err != nil {
+		return err
+	}
+	fileType, content, err := decodeUnixFS(nodeData)
+	if err != nil {
+		return err
+	}
+	if fileType != unixfsFile && fileType != unixfsRaw {
+		return fmt.Errorf("unsupported UnixFS node type %d, only files can be pulled", fileType)
+	}
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+	for _, link := range links {
+		if err := idx.writeFile(link, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countingReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
+
+// protoFields calls fn for each field of the protobuf message b. Only the
+// varint and length-delimited wire types used by dag-pb and UnixFS are
+// supported.
+func protoFields(b []byte, fn func(num uint64, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("invalid protobuf tag")
+		}
+		b = b[n:]
+		num, wireType := tag>>3, tag&7
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return fmt.Errorf("invalid protobuf varint")
+			}
+			b = b[n:]
+			if err := fn(num, v, nil); err != nil {
+				return err
+			}
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return fmt.Errorf("invalid protobuf length")
+			}
+			data := b[n : n+int(l)]
+			b = b[n+int(l):]
+			if err := fn(num, 0, data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+	}
+	return nil
+}
+
+// decodePBNode returns the links and data of a dag-pb node.
+func decodePBNode(b []byte) (links []cid, data []byte, err error) {
+	err = protoFields(b, func(num uint64, _ uint64, field []byte) error {
+		switch num {
+		case 1:
+			data = field
+		case 2:
+			return protoFields(field, func(num uint64, _ uint64, field []byte) error {
+				if num != 1 {
+					return nil
+				}
+				c, n, err := readCID(field)
+				if err != nil {
+					return fmt.Errorf("invalid link: %v", err)
+				}
+				if n != len(field) {
+					return fmt.Errorf("invalid link: trailing data")
+				}
+				links = append(links, c)
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid dag-pb node: %v", err)
+	}
+	return links, data, nil
+}
+
+// decodeUnixFS returns the type and inline content of a UnixFS node.
+func decodeUnixFS(b []byte) (fileType uint64, content []byte, err error) {
+	err = protoFields(b, func(num uint64, v uint64, field []byte) error {
+		switch num {
+		case 1:
+			fileType = v
+		case 2:
+			content = field
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid UnixFS node: %v", err)
+	}
+	return fileType, content, nil
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package ipfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// Multicodec codes of the supported block formats.
+	codecRaw    = 0x55
+	codecDagPB  = 0x70
+	codecSHA256 = 0x12
+)
+
+// cid is a parsed content identifier. Only sha2-256 multihashes are
+// supported, as used by default to add content to IPFS.
+type cid struct {
+	codec  uint64
+	digest []byte
+}
+
+// key returns a comparable representation of the CID.
+func (c cid) key() string {
+	return fmt.Sprintf("%x-%x", c.codec, c.digest)
+}
+
+// verify checks that data hashes to the CID digest.
+func (c cid) verify(data []byte) error {
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], c.digest) {
+		return fmt.Errorf("block content doesn't match its CID")
+	}
+	return nil
+}
+
+// parseCID parses the string representation of a CIDv0, or of a CIDv1 in
+// base32 or base58btc encoding.
+func parseCID(s string) (cid, error) {
+	var b []byte
+	var err error
+	switch {
+	case len(s) == 46 && strings.HasPrefix(s, "Qm"):
+		b, err = decodeBase58(s)
+	case strings.HasPrefix(s, "b"):
+		b, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(s[1:]))
+	case strings.HasPrefix(s, "z"):
+		b, err = decodeBase58(s[1:])
+	default:
+		return cid{}, fmt.Errorf("unsupported CID encoding: %s", s)
+	}
+	if err != nil {
+		return cid{}, fmt.Errorf("invalid CID %s: %v", s, err)
+	}
+	c, n, err := readCID(b)
+	if err != nil {
+		return cid{}, fmt.Errorf("invalid CID %s: %v", s, err)
+	}
+	if n != len(b) {
+		return cid{}, fmt.Errorf("invalid CID %s: trailing data", s)
+	}
+	return c, nil
+}
+
+// readCID parses the binary CID at the start of b, returning the number of
+// bytes read.
+func readCID(b []byte) (cid, int, error) {
+	// CIDv0 is a bare sha2-256 multihash of a dag-pb block
+	if len(b) >= 2 && b[0] == codecSHA256 && b[1] == sha256.Size {
+		if len(b) < 2+sha256.Size {
+			return cid{}, 0, fmt.Errorf("truncated multihash")
+		}
+		return cid{codec: codecDagPB, digest: b[2 : 2+sha256.Size]}, 2 + sha256.Size, nil
+	}
+
+	var fields [4]uint64
+	n := 0
+	for i := range fields {
+		v, l := binary.Uvarint(b[n:])
+		if l <= 0 {
+			return cid{}, 0, fmt.Errorf("truncated CID")
+		}
+		fields[i] = v
+		n += l
+	}
+	version, codec, hash, size := fields[0], fields[1], fields[2], fields[3]
+	if version != 1 {
+		return cid{}, 0, fmt.Errorf("unsupported CID version %d", version)
+	}
+	if codec != codecRaw && codec != codecDagPB {
+		return cid{}, 0, fmt.Errorf("unsupported codec 0x%x", codec)
+	}
+	if hash != codecSHA256 || size != sha256.Size {
+		return cid{}, 0, fmt.Errorf("unsupported multihash 0x%x, only sha2-256 is supported", hash)
+	}
+	if len(b) < n+sha256.Size {
+		return cid{}, 0, fmt.Errorf("truncated multihash")
+	}
+	return cid{codec: codec, digest: b[n : n+sha256.Size]}, n + sha256.Size, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBase58 decodes a base58btc string.
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	zeros := 0
+	for i, r := range s {
+		idx := strings.IndexRune(base58Alphabet, r)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		if idx == 0 && i == zeros {
+			zeros++
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -0,0 +1,227 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package ipfs implements pulling content-addressed images from IPFS through
+// a trustless gateway. Blocks are fetched as a CAR archive and verified
+// against their CID, so that the gateway doesn't need to be trusted.
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	// defaultGateway is the gateway of a local IPFS node.
+	defaultGateway = "http://127.0.0.1:8080"
+	// Timeout for an image pull in seconds - could be a large download...
+	pullTimeout = 7200
+)
+
+// ParseRef returns the CID of an ipfs://CID reference.
+func ParseRef(ref string) (string, error) {
+	c, ok := strings.CutPrefix(ref, "ipfs://")
+	if !ok {
+		return "", fmt.Errorf("not an ipfs reference: %s", ref)
+	}
+	if c == "" || strings.Contains(c, "/") {
+		return "", fmt.Errorf("invalid ipfs reference %s: expected ipfs://CID", ref)
+	}
+	if _, err := parseCID(c); err != nil {
+		return "", err
+	}
+	return c, nil
+}
+
+// Gateway returns the URL of the IPFS gateway, from the IPFS_GATEWAY
+// environment variable, the gateway file of the IPFS_PATH repository, or the
+// gateway of a local node by default.
+func Gateway() string {
+	if gw := os.Getenv("IPFS_GATEWAY"); gw != "" {
+		return strings.TrimSuffix(gw, "/")
+	}
+	repo := os.Getenv("IPFS_PATH")
+	if repo == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			repo = filepath.Join(home, ".ipfs")
+		}
+	}
+	if repo != "" {
+		if b, err := os.ReadFile(filepath.Join(repo, "gateway")); err == nil {
+			if gw := strings.TrimSpace(string(b)); gw != "" {
+				return strings.TrimSuffix(gw, "/")
+			}
+		}
+	}
+	return defaultGateway
+}
+
+// DownloadImage retrieves the file identified by the CID from the gateway,
+// saving it into the specified file once all of its blocks are verified.
+func DownloadImage(ctx context.Context, gateway, filePath, cidStr string) error {
+	c, err := parseCID(cidStr)
+	if err != nil {
+		return err
+	}
+
+	url := gateway + "/ipfs/" + cidStr
+	sylog.Debugf("Pulling CAR from URL: %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.ipld.car; version=1")
+	req.Header.Set("User-Agent", useragent.Value())
+
+	httpClient := &http.Client{
+		Timeout: pullTimeout * time.Second,
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		io.Copy(buf, io.LimitReader(res.Body, 4096))
+		return fmt.Errorf("download did not succeed: %s %s", res.Status, strings.TrimSpace(buf.String()))
+	}
+
+	car, err := os.CreateTemp(filepath.Dir(filePath), ".ipfs-car-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		car.Close()
+		os.Remove(car.Name())
+	}()
+
+	pb := client.ProgressBarCallback(ctx)
+	if err := pb(res.ContentLength, res.Body, car); err != nil {
+		return err
+	}
+
+	idx, err := indexCAR(car)
+	if err != nil {
+		return fmt.Errorf("while verifying %s: %v", cidStr, err)
+	}
+
+	// Perms are 777 *prior* to umask
+	out, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o777)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := idx.writeFile(c, out); err != nil {
+		out.Close()
+		if err := os.Remove(filePath); err != nil {
+			sylog.Errorf("Error while removing incomplete image: %v", err)
+		}
+		return fmt.Errorf("while assembling %s: %v", cidStr, err)
+	}
+
+	sylog.Debugf("Download complete")
+	return nil
+}
+
+// pull will pull an ipfs image into the cache if directTo="", or a specific file if directTo is set.
+func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string) (imagePath string, err error) {
+	cidStr, err := ParseRef(pullFrom)
+	if err != nil {
+		return "", err
+	}
+	gateway := Gateway()
+
+	if directTo != "" {
+		sylog.Infof("Downloading ipfs image")
+		if err := DownloadImage(ctx, gateway, directTo, cidStr); err != nil {
+			return "", fmt.Errorf("unable to download image: %v", err)
+		}
+		return directTo, nil
+	}
+
+	// Content is immutable, the CID is the cache key.
+	cacheEntry, err := imgCache.GetEntry(cache.IpfsCacheType, cidStr)
+	if err != nil {
+		return "", fmt.Errorf("unable to check if %v exists in cache: %v", cidStr, err)
+	}
+	defer cacheEntry.CleanTmp()
+
+	if !cacheEntry.Exists {
+		sylog.Infof("Downloading ipfs image")
+		if err := DownloadImage(ctx, gateway, cacheEntry.TmpPath, cidStr); err != nil {
+			return "", fmt.Errorf("unable to download image: %v", err)
+		}
+		if err := cacheEntry.Finalize(); err != nil {
+			return "", err
+		}
+	} else {
+		sylog.Verbosef("Using image from cache")
+	}
+
+	return cacheEntry.Path, nil
+}
+
+// Pull will pull an ipfs image to the cache or direct to a temporary file if cache is disabled
+func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom, tmpDir string) (imagePath string, err error) {
+	directTo := ""
+
+	if imgCache.IsDisabled() {
+		file, err := os.CreateTemp(tmpDir, "sbuild-tmp-cache-")
+		if err != nil {
+			return "", fmt.Errorf("unable to create tmp file: %v", err)
+		}
+		directTo = file.Name()
+		sylog.Infof("Downloading ipfs image to tmp cache: %s", directTo)
+	}
+
+	return pull(ctx, imgCache, directTo, pullFrom)
+}
+
+// PullToFile will pull an ipfs image to the specified location, through the cache, or directly if cache is disabled
+func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom string, sandbox bool) (imagePath string, err error) {
+	directTo := ""
+	if imgCache.IsDisabled() {
+		directTo = pullTo
+		sylog.Debugf("Cache disabled, pulling directly to: %s", directTo)
+	}
+
+	src, err := pull(ctx, imgCache, directTo, pullFrom)
+	if err != nil {
+		return "", fmt.Errorf("error fetching image to cache: %v", err)
+	}
+
+	if directTo == "" && !sandbox {
+		// mode is before umask if pullTo doesn't exist
+		err = fs.CopyFileAtomic(src, pullTo, 0o777)
+		if err != nil {
+			return "", fmt.Errorf("error copying image out of cache: %v", err)
+		}
+	}
+
+	if sandbox {
+		if err := client.ConvertSifToSandbox(directTo, src, pullTo); err != nil {
+			return "", err
+		}
+	}
+
+	return pullTo, nil
+}
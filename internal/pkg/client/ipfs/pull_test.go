@@ -0,0 +1,221 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package ipfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+// cidV1 returns the binary CIDv1 of data with the given codec.
+func cidV1(codec uint64, data []byte) []byte {
+	sum := sha256.Sum256(data)
+	b := binary.AppendUvarint(nil, 1)
+	b = binary.AppendUvarint(b, codec)
+	b = append(b, codecSHA256, sha256.Size)
+	return append(b, sum[:]...)
+}
+
+// cidString returns the base32 string representation of a binary CIDv1.
+func cidString(b []byte) string {
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+}
+
+func protoBytes(b []byte, num uint64, data []byte) []byte {
+	b = binary.AppendUvarint(b, num<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func protoVarint(b []byte, num, v uint64) []byte {
+	b = binary.AppendUvarint(b, num<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+// buildCAR returns a CARv1 holding content as a UnixFS file made of raw
+// leaves of chunkSize bytes, and the CID of the file root.
+func buildCAR(content []byte, chunkSize int) ([]byte, string) {
+	var blocks [][2][]byte
+	root := protoVarint(nil, 1, unixfsFile)
+	root = protoVarint(root, 3, uint64(len(content)))
+	var node []byte
+	for off := 0; off < len(content); off += chunkSize {
+		chunk := content[off:min(off+chunkSize, len(content))]
+		c := cidV1(codecRaw, chunk)
+		blocks = append(blocks, [2][]byte{c, chunk})
+		node = protoBytes(node, 2, protoBytes(nil, 1, c))
+	}
+	node = protoBytes(node, 1, root)
+	rootCID := cidV1(codecDagPB, node)
+	blocks = append([][2][]byte{{rootCID, node}}, blocks...)
+
+	header := []byte{0xa2, 0x65, 'r', 'o', 'o', 't', 's', 0x80, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x01}
+	car := binary.AppendUvarint(nil, uint64(len(header)))
+	car = append(car, header...)
+	for _, b := range blocks {
+		car = binary.AppendUvarint(car, uint64(len(b[0])+len(b[1])))
+		car = append(car, b[0]...)
+		car = append(car, b[1]...)
+	}
+	return car, cidString(rootCID)
+}
+
+func TestParseRef(t *testing.T) {
+	_, rootCID := buildCAR([]byte("content"), 4)
+
+	tests := []struct {
+		name      string
+		ref       string
+		expectErr bool
+	}{
+		{"cidv0", "ipfs://QmUNLLsPACCz1vLxQVkXqqLX5R1X345qqfHbsf67hvA3Nn", false},
+		{"cidv1", "ipfs://" + rootCID, false},
+		{"path", "ipfs://" + rootCID + "/image.sif", true},
+		{"empty", "ipfs://", true},
+		{"invalid", "ipfs://Qm123", true},
+		{"wrong transport", "https://" + rootCID, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseRef(tt.ref)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if "ipfs://"+c != tt.ref {
+				t.Errorf("got CID %q from %q", c, tt.ref)
+			}
+		})
+	}
+}
+
+func TestDownloadImage(t *testing.T) {
+	content := bytes.Repeat([]byte("apptainer"), 1000)
+	car, rootCID := buildCAR(content, 1024)
+
+	corrupted := bytes.Clone(car)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	tests := []struct {
+		name      string
+		car       []byte
+		expectErr bool
+	}{
+		{"valid", car, false},
+		{"corrupted block", corrupted, true},
+		{"truncated", car[:len(car)-100], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/ipfs/"+rootCID || !strings.HasPrefix(r.Header.Get("Accept"), "application/vnd.ipld.car") {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write(tt.car)
+			}))
+			defer srv.Close()
+
+			path := filepath.Join(t.TempDir(), "image.sif")
+			err := DownloadImage(context.Background(), srv.URL, path, rootCID)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("unverified image %s was written", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("downloaded content doesn't match")
+			}
+		})
+	}
+}
+
+func TestGateway(t *testing.T) {
+	repo := t.TempDir()
+	t.Setenv("IPFS_GATEWAY", "")
+	t.Setenv("IPFS_PATH", repo)
+
+	if gw := Gateway(); gw != defaultGateway {
+		t.Errorf("got gateway %q, expected %q", gw, defaultGateway)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "gateway"), []byte("http://127.0.0.1:8081/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if gw := Gateway(); gw != "http://127.0.0.1:8081" {
+		t.Errorf("got gateway %q, expected %q", gw, "http://127.0.0.1:8081")
+	}
+	t.Setenv("IPFS_GATEWAY", "https://ipfs.example.com")
+	if gw := Gateway(); gw != "https://ipfs.example.com" {
+		t.Errorf("got gateway %q, expected %q", gw, "https://ipfs.example.com")
+	}
+}
+
+func TestIndexCARLargeBlock(t *testing.T) {
+	// IPFS blocks of up to 2 MiB are valid
+	content := bytes.Repeat([]byte("apptainer"), (2<<20)/9)
+	car, rootCID := buildCAR(content, len(content))
+
+	path := filepath.Join(t.TempDir(), "image.car")
+	if err := os.WriteFile(path, car, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	idx, err := indexCAR(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := parseCID(rootCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := idx.writeFile(c, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), content) {
+		t.Errorf("content of the 2 MiB block doesn't match")
+	}
+}
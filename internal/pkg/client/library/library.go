@@ -14,7 +14,6 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
@@ -59,15 +58,36 @@ func NormalizeLibraryRef(ref string) (*libClient.Ref, error) {
 	return &libClient.Ref{Host: host, Path: elem[0], Tags: tags}, nil
 }
 
-func getEnvInt(key string, defval int64) int64 {
-	envKey := env.TrimApptainerKey(key)
-	if env := env.GetenvLegacy(envKey, envKey); env != "" {
-		if n, err := strconv.ParseInt(env, 10, 0); err == nil {
-			return n
-		}
-		sylog.Warningf("Error parsing %s; using default (%d)", key, defval)
-	}
-	return defval
+// OrasURI returns the oras:// URI addressing the library reference ref
+// in the OCI registry backing a library endpoint. The registry may be
+// given as a bare hostname with an optional namespace path, or with an
+// http(s) scheme which is discarded.
+func OrasURI(registry string, ref *libClient.Ref) (string, error) {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry = strings.Trim(registry, "/")
+	if registry == "" {
+		return "", fmt.Errorf("no library registry specified")
+	}
+	if len(ref.Tags) != 1 {
+		return "", fmt.Errorf("library registry references require exactly one tag, got %d", len(ref.Tags))
+	}
+	path := strings.Trim(ref.Path, "/")
+	if path == "" {
+		return "", fmt.Errorf("empty library image path")
+	}
+	return fmt.Sprintf("oras://%s/%s:%s", registry, path, ref.Tags[0]), nil
+}
+
+// downloadConcurrency overrides the configured download concurrency when
+// set to a non-zero value.
+var downloadConcurrency int64
+
+// SetDownloadConcurrency sets the number of concurrent ranged requests used
+// to download library images, taking precedence over apptainer.conf and the
+// APPTAINER_DOWNLOAD_CONCURRENCY environment variable.
+func SetDownloadConcurrency(n int) {
+	downloadConcurrency = int64(n)
 }
 
 func getDownloadConfig() (libClient.Downloader, error) {
@@ -86,9 +106,12 @@ func getDownloadConfig() (libClient.Downloader, error) {
 		}
 	}
 
-	concurrency := int64(getEnvInt("APPTAINER_DOWNLOAD_CONCURRENCY", int64(conf.DownloadConcurrency)))
-	partSize := int64(getEnvInt("APPTAINER_DOWNLOAD_PART_SIZE", int64(conf.DownloadPartSize)))
-	bufferSize := int64(getEnvInt("APPTAINER_DOWNLOAD_BUFFER_SIZE", int64(conf.DownloadBufferSize)))
+	concurrency := int64(env.GetenvInt("APPTAINER_DOWNLOAD_CONCURRENCY", int64(conf.DownloadConcurrency)))
+	if downloadConcurrency != 0 {
+		concurrency = downloadConcurrency
+	}
+	partSize := int64(env.GetenvInt("APPTAINER_DOWNLOAD_PART_SIZE", int64(conf.DownloadPartSize)))
+	bufferSize := int64(env.GetenvInt("APPTAINER_DOWNLOAD_BUFFER_SIZE", int64(conf.DownloadBufferSize)))
 
 	if concurrency < 1 {
 		return libClient.Downloader{}, fmt.Errorf("invalid download concurrency value (%v)", concurrency)
@@ -12,6 +12,8 @@ package library
 import (
 	"reflect"
 	"testing"
+
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 )
 
 func TestNormalizeLibraryRef(t *testing.T) {
@@ -54,3 +56,72 @@ func TestNormalizeLibraryRef(t *testing.T) {
 		})
 	}
 }
+
+func TestOrasURI(t *testing.T) {
+	tests := []struct {
+		name       string
+		registry   string
+		libraryRef string
+		expected   string
+		wantErr    bool
+	}{
+		{"simple", "registry.example.com", "library://user/project/image:1.0", "oras://registry.example.com/user/project/image:1.0", false},
+		{"default tag", "registry.example.com", "library://user/project/image", "oras://registry.example.com/user/project/image:latest", false},
+		{"with scheme", "https://registry.example.com/", "library://image:tag", "oras://registry.example.com/image:tag", false},
+		{"with namespace", "registry.example.com/library", "library://user/project/image:tag", "oras://registry.example.com/library/user/project/image:tag", false},
+		{"multiple tags", "registry.example.com", "library://user/project/image:tag1,tag2", "", true},
+		{"empty registry", "https://", "library://user/project/image:tag", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := NormalizeLibraryRef(tt.libraryRef)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result, err := OrasURI(tt.registry, ref)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("got %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetDownloadConfig(t *testing.T) {
+	conf, err := apptainerconf.GetConfig(nil)
+	if err != nil {
+		t.Fatalf("unable to get default configuration: %v", err)
+	}
+	apptainerconf.SetCurrentConfig(conf)
+	t.Cleanup(func() {
+		apptainerconf.SetCurrentConfig(nil)
+		SetDownloadConcurrency(0)
+	})
+
+	spec, err := getDownloadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Concurrency != conf.DownloadConcurrency {
+		t.Errorf("got concurrency %d, expected %d", spec.Concurrency, conf.DownloadConcurrency)
+	}
+
+	t.Setenv("APPTAINER_DOWNLOAD_CONCURRENCY", "2")
+	SetDownloadConcurrency(8)
+	spec, err = getDownloadConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Concurrency != 8 {
+		t.Errorf("got concurrency %d, expected 8", spec.Concurrency)
+	}
+}
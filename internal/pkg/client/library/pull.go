@@ -57,6 +57,12 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo string, imageRef
 		if err := downloadWrapper(ctx, c, directTo, arch, imageRef, progressBar); err != nil {
 			return "", fmt.Errorf("unable to download image: %v", err)
 		}
+		if err := checkImageHash(directTo, libraryImage.Hash); err != nil {
+			if err := os.Remove(directTo); err != nil {
+				sylog.Errorf("Error while removing corrupted download: %v", err)
+			}
+			return "", err
+		}
 		return directTo, nil
 	}
 
@@ -71,10 +77,8 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo string, imageRef
 			return "", fmt.Errorf("unable to download image: %v", err)
 		}
 
-		if cacheFileHash, err := libClient.ImageHash(cacheEntry.TmpPath); err != nil {
-			return "", fmt.Errorf("error getting image hash: %v", err)
-		} else if cacheFileHash != libraryImage.Hash {
-			return "", fmt.Errorf("cached file hash(%s) and expected hash(%s) does not match", cacheFileHash, libraryImage.Hash)
+		if err := checkImageHash(cacheEntry.TmpPath, libraryImage.Hash); err != nil {
+			return "", err
 		}
 
 		if err := cacheEntry.Finalize(); err != nil {
@@ -87,6 +91,20 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo string, imageRef
 	return cacheEntry.Path, nil
 }
 
+// checkImageHash verifies that the downloaded image at path has the hash
+// advertised by the library, as concurrent downloads are reassembled from
+// independently fetched parts.
+func checkImageHash(path, expected string) error {
+	fileHash, err := libClient.ImageHash(path)
+	if err != nil {
+		return fmt.Errorf("error getting image hash: %v", err)
+	}
+	if fileHash != expected {
+		return fmt.Errorf("downloaded file hash(%s) and expected hash(%s) does not match", fileHash, expected)
+	}
+	return nil
+}
+
 // downloadWrapper calls DownloadImage() and outputs download summary if progressBar not specified.
 func downloadWrapper(ctx context.Context, c *libClient.Client, imagePath, arch string, libraryRef *libClient.Ref, pb libClient.ProgressBar) error {
 	sylog.Infof("Downloading library image")
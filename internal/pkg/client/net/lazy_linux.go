@@ -0,0 +1,205 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/util/fuse"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// lazyReady is written by the lazy mount process once the image is mounted.
+const lazyReady = "ready"
+
+// LazyOptions are the options of MountLazy.
+type LazyOptions struct {
+	// Headers are added to the requests made to the server.
+	Headers http.Header
+	// TmpDir is the directory holding the mount point and the block cache.
+	TmpDir string
+	// BlockSize is the size of the blocks fetched from the server, 4MiB when
+	// 0.
+	BlockSize int64
+}
+
+// lazyConfig is the configuration of the lazy mount process, read from its
+// standard input.
+type lazyConfig struct {
+	URL       string      `json:"url"`
+	Name      string      `json:"name"`
+	Headers   http.Header `json:"headers"`
+	BlockSize int64       `json:"blockSize"`
+	Dir       string      `json:"dir"`
+	ParentPID int         `json:"parentPid"`
+}
+
+// MountLazy mounts the image at the http(s) URL netURL with a read-only FUSE
+// filesystem, fetching its content with range requests when it is read, and
+// returns the path of the mounted image. The filesystem is served by a
+// detached apptainer process, which unmounts it once the calling process, or
+// the starter process replacing it, exits.
+func MountLazy(netURL string, opts LazyOptions) (string, error) {
+	if !IsNetPullRef(netURL) {
+		return "", fmt.Errorf("not a valid url reference: %s", netURL)
+	}
+	url, digest, err := ParseDigest(netURL)
+	if err != nil {
+		return "", err
+	}
+	if digest != "" {
+		return "", fmt.Errorf("the digest of a lazily mounted image can't be verified")
+	}
+
+	dir, err := os.MkdirTemp(opts.TmpDir, "lazy-")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	cfg := lazyConfig{
+		URL:       url,
+		Name:      lazyImageName(url),
+		Headers:   opts.Headers,
+		BlockSize: opts.BlockSize,
+		Dir:       dir,
+		ParentPID: os.Getpid(),
+	}
+	if err := os.Mkdir(filepath.Join(dir, "mnt"), 0o700); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	cmd := exec.Command(filepath.Join(buildcfg.BINDIR, "apptainer"), "lazy-mount")
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stderr = os.Stderr
+	// detached from the terminal signals sent to the container
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	sylog.Debugf("Mounting %s lazily in %s", url, dir)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("while starting lazy mount process: %w", err)
+	}
+
+	line, _ := bufio.NewReader(stdout).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line != lazyReady {
+		cmd.Wait()
+		os.RemoveAll(dir)
+		if line == "" {
+			line = "lazy mount process exited"
+		}
+		return "", fmt.Errorf("%s", line)
+	}
+	stdout.Close()
+	cmd.Process.Release()
+	return filepath.Join(dir, "mnt", cfg.Name), nil
+}
+
+// ServeLazy serves the FUSE filesystem of a lazily mounted image configured
+// by MountLazy from r, reporting the mount readiness, or the error preventing
+// it, to w.
+func ServeLazy(r io.Reader, w io.Writer) error {
+	var cfg lazyConfig
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return fmt.Errorf("while decoding lazy mount configuration: %w", err)
+	}
+	defer os.RemoveAll(cfg.Dir)
+
+	mnt := filepath.Join(cfg.Dir, "mnt")
+	dev, rr, err := mountLazy(cfg, mnt)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return err
+	}
+	defer dev.Close()
+	defer rr.cache.Close()
+	fmt.Fprintln(w, lazyReady)
+
+	// unmount once the parent process exits or on termination, the
+	// filesystem being served until then
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for os.Getppid() == cfg.ParentPID {
+			select {
+			case <-ticker.C:
+			case <-sigCh:
+				sylog.Debugf("Lazy mount process terminated")
+				fuse.Unmount(mnt)
+				return
+			}
+		}
+		sylog.Debugf("Parent process %d exited, unmounting %s", cfg.ParentPID, mnt)
+		if err := fuse.Unmount(mnt); err != nil {
+			sylog.Warningf("Unable to unmount %s: %s", mnt, err)
+		}
+	}()
+	return fuse.Serve(dev, cfg.Name, rr)
+}
+
+// mountLazy mounts the FUSE filesystem of the lazily mounted image of cfg on
+// mnt, and returns the FUSE device and the range reader of the image.
+func mountLazy(cfg lazyConfig, mnt string) (*os.File, *rangeReader, error) {
+	cache, err := os.CreateTemp(cfg.Dir, "blocks-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("while creating block cache: %w", err)
+	}
+	// unlinked, the cached blocks being freed once closed
+	os.Remove(cache.Name())
+
+	rr, err := newRangeReader(context.Background(), cfg.URL, cfg.Headers, cfg.BlockSize, cache)
+	if err != nil {
+		cache.Close()
+		return nil, nil, err
+	}
+	dev, err := fuse.Mount(mnt, "apptainer-lazy")
+	if err != nil {
+		cache.Close()
+		return nil, nil, err
+	}
+	return dev, rr, nil
+}
+
+// lazyImageName returns the name of the file of a lazily mounted image from
+// its URL.
+func lazyImageName(url string) string {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return "image.sif"
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || strings.HasPrefix(name, ".") {
+		return "image.sif"
+	}
+	return name
+}
@@ -15,6 +15,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"regexp"
@@ -31,6 +32,126 @@ import (
 // Timeout for an image pull in seconds - could be a large download...
 const pullTimeout = 1800
 
+// PullOptions holds the optional settings of an http(s) image pull.
+type PullOptions struct {
+	// Digest is the expected sha256 digest of the image, which may also be
+	// given as a "#sha256=<hex>" URL fragment.
+	Digest string
+	// Headers are added to the requests made to the server, e.g. to
+	// authenticate against an artifact repository.
+	Headers http.Header
+}
+
+// ParseHeaders builds request headers from a list of "Name: value" strings,
+// and adds an "Authorization: Bearer" header when token is not empty.
+func ParseHeaders(headers []string, token string) (http.Header, error) {
+	h := make(http.Header)
+	for _, header := range headers {
+		name, value, found := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid http header %q, expected 'Name: value'", header)
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	if token != "" {
+		if h.Get("Authorization") != "" {
+			return nil, fmt.Errorf("an http token can't be used with an Authorization header")
+		}
+		h.Set("Authorization", "Bearer "+token)
+	}
+	return h, nil
+}
+
+// ParseDigest splits an optional expected digest, given as a
+// "#sha256=<hex>" URL fragment, from a http(s) image reference. It returns the
+// URL without the fragment and the lower-cased hex digest, or an empty digest
+// if none was specified.
+func ParseDigest(netRef string) (string, string, error) {
+	url, fragment, found := strings.Cut(netRef, "#")
+	if !found {
+		return netRef, "", nil
+	}
+	algo, digest, found := strings.Cut(fragment, "=")
+	if !found || algo != "sha256" {
+		return "", "", fmt.Errorf("unsupported digest %q, expected sha256=<hex>", fragment)
+	}
+	if err := validateDigest(digest); err != nil {
+		return "", "", err
+	}
+	return url, strings.ToLower(digest), nil
+}
+
+// validateDigest checks that digest is a hex encoded sha256 sum.
+func validateDigest(digest string) error {
+	if len(digest) != hex.EncodedLen(sha256.Size) {
+		return fmt.Errorf("invalid sha256 digest %q: expected %d hex characters", digest, hex.EncodedLen(sha256.Size))
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return fmt.Errorf("invalid sha256 digest %q: %v", digest, err)
+	}
+	return nil
+}
+
+// resolveDigest strips any digest fragment from pullFrom and reconciles it
+// with the digest passed explicitly, which may be prefixed by "sha256:".
+func resolveDigest(pullFrom, digest string) (string, string, error) {
+	url, refDigest, err := ParseDigest(pullFrom)
+	if err != nil {
+		return "", "", err
+	}
+	if digest == "" {
+		return url, refDigest, nil
+	}
+	digest = strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+	if err := validateDigest(digest); err != nil {
+		return "", "", err
+	}
+	if refDigest != "" && refDigest != digest {
+		return "", "", fmt.Errorf("conflicting digests sha256:%s and sha256:%s", refDigest, digest)
+	}
+	return url, digest, nil
+}
+
+// checkDigest verifies that the file at path has the expected sha256 digest.
+func checkDigest(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("error computing image digest: %v", err)
+	}
+	if digest := hex.EncodeToString(h.Sum(nil)); digest != expected {
+		return fmt.Errorf("downloaded image digest sha256:%s does not match expected sha256:%s", digest, expected)
+	}
+	return nil
+}
+
+// maxRedirects is the number of redirects followed by a request, as by
+// the default http client.
+const maxRedirects = 10
+
+// headersRedirect returns the redirect policy of a client sending the user
+// headers, which drops them from the requests redirected to another host so
+// that credentials are only sent to the host they were given for.
+func headersRedirect(headers http.Header) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if req.URL.Host != via[0].URL.Host {
+			for name := range headers {
+				req.Header.Del(name)
+			}
+		}
+		return nil
+	}
+}
+
 // IsNetPullRef returns true if the provided string is a valid url
 // reference for a pull operation.
 func IsNetPullRef(netRef string) bool {
@@ -39,8 +160,8 @@ func IsNetPullRef(netRef string) bool {
 }
 
 // DownloadImage will retrieve an image from an http(s) URI,
-// saving it into the specified file
-func DownloadImage(ctx context.Context, filePath string, netURL string) error {
+// saving it into the specified file. The optional headers are added to the request.
+func DownloadImage(ctx context.Context, filePath string, netURL string, headers http.Header) error {
 	if !IsNetPullRef(netURL) {
 		return fmt.Errorf("not a valid url reference: %s", netURL)
 	}
@@ -54,7 +175,8 @@ func DownloadImage(ctx context.Context, filePath string, netURL string) error {
 	sylog.Debugf("Pulling from URL: %s\n", url)
 
 	httpClient := &http.Client{
-		Timeout: pullTimeout * time.Second,
+		Timeout:       pullTimeout * time.Second,
+		CheckRedirect: headersRedirect(headers),
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -62,6 +184,9 @@ func DownloadImage(ctx context.Context, filePath string, netURL string) error {
 		return err
 	}
 
+	for name, values := range headers {
+		req.Header[name] = values
+	}
 	req.Header.Set("User-Agent", useragent.Value())
 
 	res, err := httpClient.Do(req)
@@ -112,7 +237,13 @@ func DownloadImage(ctx context.Context, filePath string, netURL string) error {
 }
 
 // pull will pull a http(s) image into the cache if directTo="", or a specific file if directTo is set.
-func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string) (imagePath string, err error) {
+// When a digest is set, the image content is verified against it before being cached.
+func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string, opts PullOptions) (imagePath string, err error) {
+	pullFrom, digest, err := resolveDigest(pullFrom, opts.Digest)
+	if err != nil {
+		return "", err
+	}
+
 	// We will cache using a sha256 over the URL and the date of the file that
 	// is to be fetched, as returned by an HTTP HEAD call and the Last-Modified
 	// header. If no date is available, use the current date-time, which will
@@ -123,7 +254,11 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 	if err != nil {
 		sylog.Fatalf("Error constructing http request: %v\n", err)
 	}
-	res, err := http.DefaultClient.Do(req)
+	for name, values := range opts.Headers {
+		req.Header[name] = values
+	}
+	httpClient := &http.Client{CheckRedirect: headersRedirect(opts.Headers)}
+	res, err := httpClient.Do(req)
 	if err != nil {
 		sylog.Fatalf("Error making http request: %v\n", err)
 	}
@@ -142,9 +277,17 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 
 	if directTo != "" {
 		sylog.Infof("Downloading network image")
-		if err := DownloadImage(ctx, directTo, pullFrom); err != nil {
+		if err := DownloadImage(ctx, directTo, pullFrom, opts.Headers); err != nil {
 			return "", fmt.Errorf("unable to Download Image: %v", err)
 		}
+		if digest != "" {
+			if err := checkDigest(directTo, digest); err != nil {
+				if err := os.Remove(directTo); err != nil {
+					sylog.Errorf("Error while removing corrupted download: %v", err)
+				}
+				return "", err
+			}
+		}
 		imagePath = directTo
 
 	} else {
@@ -156,11 +299,17 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 
 		if !cacheEntry.Exists {
 			sylog.Infof("Downloading network image")
-			err := DownloadImage(ctx, cacheEntry.TmpPath, pullFrom)
+			err := DownloadImage(ctx, cacheEntry.TmpPath, pullFrom, opts.Headers)
 			if err != nil {
 				sylog.Fatalf("%v\n", err)
 			}
 
+			if digest != "" {
+				if err := checkDigest(cacheEntry.TmpPath, digest); err != nil {
+					return "", err
+				}
+			}
+
 			err = cacheEntry.Finalize()
 			if err != nil {
 				return "", err
@@ -168,6 +317,11 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 
 		} else {
 			sylog.Verbosef("Using image from cache")
+			if digest != "" {
+				if err := checkDigest(cacheEntry.Path, digest); err != nil {
+					return "", fmt.Errorf("cached image: %v", err)
+				}
+			}
 		}
 
 		imagePath = cacheEntry.Path
@@ -177,7 +331,7 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 }
 
 // Pull will pull a http(s) image to the cache or direct to a temporary file if cache is disabled
-func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom string, tmpDir string) (imagePath string, err error) {
+func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom, tmpDir string, opts PullOptions) (imagePath string, err error) {
 	directTo := ""
 
 	if imgCache.IsDisabled() {
@@ -189,18 +343,18 @@ func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom string, tmpDir s
 		sylog.Infof("Downloading library image to tmp cache: %s", directTo)
 	}
 
-	return pull(ctx, imgCache, directTo, pullFrom)
+	return pull(ctx, imgCache, directTo, pullFrom, opts)
 }
 
 // PullToFile will pull an http(s) image to the specified location, through the cache, or directly if cache is disabled
-func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom string, sandbox bool) (imagePath string, err error) {
+func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom string, sandbox bool, opts PullOptions) (imagePath string, err error) {
 	directTo := ""
 	if imgCache.IsDisabled() {
 		directTo = pullTo
 		sylog.Debugf("Cache disabled, pulling directly to: %s", directTo)
 	}
 
-	src, err := pull(ctx, imgCache, directTo, pullFrom)
+	src, err := pull(ctx, imgCache, directTo, pullFrom, opts)
 	if err != nil {
 		return "", fmt.Errorf("error fetching image to cache: %v", err)
 	}
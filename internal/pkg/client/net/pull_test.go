@@ -0,0 +1,150 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package net
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sha256 of "hello world\n"
+const helloDigest = "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"
+
+func TestResolveDigest(t *testing.T) {
+	tests := []struct {
+		name       string
+		pullFrom   string
+		digest     string
+		expectURL  string
+		expectHash string
+		expectErr  bool
+	}{
+		{"no digest", "https://example.com/img.sif", "", "https://example.com/img.sif", "", false},
+		{"fragment", "https://example.com/img.sif#sha256=" + helloDigest, "", "https://example.com/img.sif", helloDigest, false},
+		{"flag", "https://example.com/img.sif", "sha256:" + helloDigest, "https://example.com/img.sif", helloDigest, false},
+		{"fragment and flag", "https://example.com/img.sif#sha256=" + helloDigest, helloDigest, "https://example.com/img.sif", helloDigest, false},
+		{"conflicting", "https://example.com/img.sif#sha256=" + helloDigest, "0" + helloDigest[1:], "", "", true},
+		{"bad algorithm", "https://example.com/img.sif#md5=abc", "", "", "", true},
+		{"bad length", "https://example.com/img.sif#sha256=abc", "", "", "", true},
+		{"not hex", "https://example.com/img.sif", "z" + helloDigest[1:], "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, digest, err := resolveDigest(tt.pullFrom, tt.digest)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if url != tt.expectURL || digest != tt.expectHash {
+				t.Errorf("got %q %q, expected %q %q", url, digest, tt.expectURL, tt.expectHash)
+			}
+		})
+	}
+}
+
+func TestCheckDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "img")
+	if err := os.WriteFile(path, []byte("hello world\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDigest(path, helloDigest); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := checkDigest(path, "0"+helloDigest[1:]); err == nil {
+		t.Errorf("expected digest mismatch error")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h, err := ParseHeaders([]string{"X-JFrog-Art-Api: secret", "Private-Token:abc"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := h.Get("X-Jfrog-Art-Api"); v != "secret" {
+		t.Errorf("got X-JFrog-Art-Api %q, expected %q", v, "secret")
+	}
+	if v := h.Get("Private-Token"); v != "abc" {
+		t.Errorf("got Private-Token %q, expected %q", v, "abc")
+	}
+
+	h, err = ParseHeaders(nil, "token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := h.Get("Authorization"); v != "Bearer token" {
+		t.Errorf("got Authorization %q, expected %q", v, "Bearer token")
+	}
+
+	if _, err := ParseHeaders([]string{"no-separator"}, ""); err == nil {
+		t.Errorf("expected error for header without separator")
+	}
+	if _, err := ParseHeaders([]string{"Authorization: Basic abc"}, "token"); err == nil {
+		t.Errorf("expected error for token with Authorization header")
+	}
+}
+
+func TestDownloadImageRedirect(t *testing.T) {
+	// the headers seen by the server serving the image
+	var got http.Header
+	image := func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte("hello world\n"))
+	}
+	other := httptest.NewServer(http.HandlerFunc(image))
+	defer other.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/image.sif", image)
+	mux.HandleFunc("/same", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/image.sif", http.StatusFound)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/image.sif", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	headers, err := ParseHeaders([]string{"Private-Token: secret"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		path   string
+		header string
+	}{
+		{name: "SameHost", path: "/same", header: "secret"},
+		{name: "OtherHost", path: "/other", header: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			path := filepath.Join(t.TempDir(), "image.sif")
+			if err := DownloadImage(context.Background(), path, srv.URL+tt.path, headers); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil {
+				t.Fatalf("image not requested")
+			}
+			if v := got.Get("Private-Token"); v != tt.header {
+				t.Errorf("got Private-Token %q, expected %q", v, tt.header)
+			}
+			if err := checkDigest(path, helloDigest); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	// defaultBlockSize is the size of the blocks fetched by a range reader.
+	defaultBlockSize = 4 << 20
+	// rangeTimeout is the timeout of a block request, in seconds.
+	rangeTimeout = 300
+	// rangeAttempts is the number of attempts to fetch a block.
+	rangeAttempts = 3
+)
+
+// errNoRange is returned when the server doesn't support range requests.
+var errNoRange = errors.New("server doesn't support range requests")
+
+// rangeReader reads a remote file with http range requests, fetching it by
+// blocks cached in a sparse file.
+type rangeReader struct {
+	ctx       context.Context
+	client    *http.Client
+	url       string
+	headers   http.Header
+	validator string
+	size      int64
+	blockSize int64
+	cache     *os.File
+
+	mu       sync.Mutex
+	fetched  []bool
+	inflight map[int64]chan struct{}
+}
+
+// newRangeReader returns a range reader of the file at url, requested with
+// the optional headers, caching its blocks of blockSize bytes in cache. It
+// returns errNoRange if the server doesn't support range requests.
+func newRangeReader(ctx context.Context, url string, headers http.Header, blockSize int64, cache *os.File) (*rangeReader, error) {
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+	r := &rangeReader{
+		ctx:       ctx,
+		client:    &http.Client{Timeout: rangeTimeout * time.Second, CheckRedirect: headersRedirect(headers)},
+		url:       url,
+		headers:   headers,
+		blockSize: blockSize,
+		cache:     cache,
+		inflight:  make(map[int64]chan struct{}),
+	}
+
+	res, err := r.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, errNoRange
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("the requested image was not found")
+	default:
+		return nil, fmt.Errorf("range request failed: %s", res.Status)
+	}
+
+	_, size, err := parseContentRange(res.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	r.size = size
+	r.fetched = make([]bool, (size+blockSize-1)/blockSize)
+	// a modified file is sent whole instead of the requested range
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		r.validator = etag
+	} else {
+		r.validator = res.Header.Get("Last-Modified")
+	}
+	if err := cache.Truncate(size); err != nil {
+		return nil, fmt.Errorf("while allocating block cache: %w", err)
+	}
+	sylog.Debugf("Reading %s of %d bytes by blocks of %d bytes", url, size, blockSize)
+	return r, nil
+}
+
+// Size returns the size of the remote file.
+func (r *rangeReader) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(p) bytes of the remote file at offset off, fetching the
+// blocks not cached yet.
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	n := int64(len(p))
+	if off+n > r.size {
+		n = r.size - off
+	}
+	for b := off / r.blockSize; b <= (off+n-1)/r.blockSize; b++ {
+		if err := r.ensure(b); err != nil {
+			return 0, err
+		}
+	}
+	m, err := r.cache.ReadAt(p[:n], off)
+	if err == nil && n < int64(len(p)) {
+		err = io.EOF
+	}
+	return m, err
+}
+
+// ensure fetches the block b if it isn't cached, waiting for a concurrent
+// fetch of the same block.
+func (r *rangeReader) ensure(b int64) error {
+	for {
+		r.mu.Lock()
+		if r.fetched[b] {
+			r.mu.Unlock()
+			return nil
+		}
+		if ch, ok := r.inflight[b]; ok {
+			r.mu.Unlock()
+			<-ch
+			continue
+		}
+		ch := make(chan struct{})
+		r.inflight[b] = ch
+		r.mu.Unlock()
+
+		err := r.fetch(b)
+
+		r.mu.Lock()
+		delete(r.inflight, b)
+		if err == nil {
+			r.fetched[b] = true
+		}
+		r.mu.Unlock()
+		close(ch)
+		return err
+	}
+}
+
+// fetch fetches the block b into the cache.
+func (r *rangeReader) fetch(b int64) (err error) {
+	start := b * r.blockSize
+	end := min(start+r.blockSize, r.size) - 1
+	for i := 0; i < rangeAttempts; i++ {
+		if err = r.fetchRange(start, end); err == nil || r.ctx.Err() != nil {
+			return err
+		}
+		sylog.Debugf("Attempt %d to fetch bytes %d-%d failed: %s", i+1, start, end, err)
+	}
+	return err
+}
+
+func (r *rangeReader) fetchRange(start, end int64) error {
+	res, err := r.get(start, end)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return fmt.Errorf("remote image %s has been modified", r.url)
+	}
+	if res.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("range request failed: %s", res.Status)
+	}
+	if s, _, err := parseContentRange(res.Header.Get("Content-Range")); err != nil {
+		return err
+	} else if s != start {
+		return fmt.Errorf("unexpected range starting at %d, requested %d", s, start)
+	}
+
+	buf := make([]byte, end-start+1)
+	if _, err := io.ReadFull(res.Body, buf); err != nil {
+		return fmt.Errorf("while reading bytes %d-%d: %w", start, end, err)
+	}
+	_, err = r.cache.WriteAt(buf, start)
+	return err
+}
+
+// get requests the bytes start to end, inclusive, of the remote file.
+func (r *rangeReader) get(start, end int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("User-Agent", useragent.Value())
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if r.validator != "" {
+		req.Header.Set("If-Range", r.validator)
+	}
+	return r.client.Do(req)
+}
+
+// parseContentRange returns the start offset and the complete length of a
+// "bytes <start>-<end>/<length>" Content-Range header.
+func parseContentRange(h string) (int64, int64, error) {
+	rng, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	rng, length, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	start, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	s, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range header %q", h)
+	}
+	l, err := strconv.ParseInt(length, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("image size unknown from Content-Range header %q", h)
+	}
+	return s, l, nil
+}
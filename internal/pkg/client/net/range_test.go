@@ -0,0 +1,164 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package net
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func TestRangeReader(t *testing.T) {
+	content := make([]byte, 10000)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	var requests atomic.Int32
+	modTime := time.Now()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "image.sif", modTime, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	cache, err := os.CreateTemp(t.TempDir(), "blocks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	rr, err := newRangeReader(context.Background(), srv.URL, nil, 1024, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rr.Size() != int64(len(content)) {
+		t.Fatalf("got size %d, expected %d", rr.Size(), len(content))
+	}
+
+	tests := []struct {
+		name   string
+		off    int64
+		len    int
+		expect int
+		eof    bool
+	}{
+		{"within block", 10, 100, 100, false},
+		{"across blocks", 1000, 3000, 3000, false},
+		{"last block", 9990, 10, 10, false},
+		{"past end", 9990, 100, 10, true},
+		{"at end", 10000, 10, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := make([]byte, tt.len)
+			n, err := rr.ReadAt(p, tt.off)
+			if tt.eof && err != io.EOF {
+				t.Fatalf("expected EOF, got %v", err)
+			} else if !tt.eof && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if n != tt.expect {
+				t.Fatalf("read %d bytes, expected %d", n, tt.expect)
+			}
+			if !bytes.Equal(p[:n], content[tt.off:tt.off+int64(n)]) {
+				t.Errorf("unexpected content at offset %d", tt.off)
+			}
+		})
+	}
+
+	// blocks 0 to 3 and 9 fetched once, plus the initial probe
+	if n := requests.Load(); n != 6 {
+		t.Errorf("got %d requests, expected 6", n)
+	}
+}
+
+func TestRangeReaderNoRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world\n"))
+	}))
+	defer srv.Close()
+
+	cache, err := os.CreateTemp(t.TempDir(), "blocks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	_, err = newRangeReader(context.Background(), srv.URL, nil, 0, cache)
+	if !errors.Is(err, errNoRange) {
+		t.Errorf("expected %v, got %v", errNoRange, err)
+	}
+}
+
+func TestRangeReaderModified(t *testing.T) {
+	etag := `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "image.sif", time.Time{}, bytes.NewReader(make([]byte, 100)))
+	}))
+	defer srv.Close()
+
+	cache, err := os.CreateTemp(t.TempDir(), "blocks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	rr, err := newRangeReader(context.Background(), srv.URL, nil, 0, cache)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	etag = `"v2"`
+	if _, err := rr.ReadAt(make([]byte, 10), 0); err == nil {
+		t.Errorf("expected error reading a modified file, got nil")
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header      string
+		expectStart int64
+		expectSize  int64
+		expectErr   bool
+	}{
+		{"bytes 0-0/1234", 0, 1234, false},
+		{"bytes 1024-2047/4096", 1024, 4096, false},
+		{"bytes 0-0/*", 0, 0, true},
+		{"items 0-0/1234", 0, 0, true},
+		{"bytes 0-0", 0, 0, true},
+		{"bytes x-0/1234", 0, 0, true},
+	}
+	for _, tt := range tests {
+		start, size, err := parseContentRange(tt.header)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("%q: expected error, got nil", tt.header)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.header, err)
+		} else if start != tt.expectStart || size != tt.expectSize {
+			t.Errorf("%q: got %d %d, expected %d %d", tt.header, start, size, tt.expectStart, tt.expectSize)
+		}
+	}
+}
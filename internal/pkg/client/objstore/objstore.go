@@ -0,0 +1,105 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package objstore implements pulling and pushing images stored as objects
+// in cloud object storage. The storage services are accessed through a
+// Backend, implemented for each supported transport.
+package objstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/env"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+const (
+	// S3 is the transport of Amazon S3 and compatible object storage.
+	S3 = "s3"
+	// GS is the transport of Google Cloud Storage.
+	GS = "gs"
+	// AzBlob is the transport of Azure Blob Storage.
+	AzBlob = "azblob"
+)
+
+const (
+	// Defaults used when apptainer.conf hasn't been loaded.
+	defaultConcurrency = 3
+	defaultPartSize    = 5 * 1024 * 1024
+)
+
+// IsObjectRef returns true if ref is an object storage reference.
+func IsObjectRef(ref string) bool {
+	transport, _, _ := strings.Cut(ref, "://")
+	return transport == S3 || transport == GS || transport == AzBlob
+}
+
+// Ref is a reference to an object, parsed from a transport://bucket/key URI.
+// Azure Blob Storage containers are mapped to buckets.
+type Ref struct {
+	Transport string
+	Bucket    string
+	Key       string
+}
+
+// String returns the URI of the object.
+func (r Ref) String() string {
+	return r.Transport + "://" + r.Bucket + "/" + r.Key
+}
+
+// ParseRef parses a transport://bucket/key URI.
+func ParseRef(ref string) (Ref, error) {
+	transport, path, ok := strings.Cut(ref, "://")
+	if !ok || !IsObjectRef(ref) {
+		return Ref{}, fmt.Errorf("not an object storage reference: %s", ref)
+	}
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket == "" || key == "" {
+		return Ref{}, fmt.Errorf("invalid %s reference %s: expected %s://bucket/key", transport, ref, transport)
+	}
+	return Ref{Transport: transport, Bucket: bucket, Key: key}, nil
+}
+
+// ObjectInfo holds the metadata of an object.
+type ObjectInfo struct {
+	// Size is the object size in bytes.
+	Size int64
+	// ETag changes whenever the object content is replaced.
+	ETag string
+}
+
+// Backend gives access to the objects of an object storage service.
+type Backend interface {
+	// Stat returns the metadata of the referenced object.
+	Stat(ctx context.Context, ref Ref) (ObjectInfo, error)
+	// GetRange returns length bytes of the referenced object starting at
+	// offset, or the whole object when length is negative. The caller must
+	// close the returned reader.
+	GetRange(ctx context.Context, ref Ref, offset, length int64) (io.ReadCloser, error)
+	// Put uploads size bytes read from r to the referenced object.
+	Put(ctx context.Context, ref Ref, r io.Reader, size int64) error
+}
+
+// downloadConfig returns the number of concurrent ranged requests and the
+// part size used to download objects, shared with library downloads.
+func downloadConfig() (concurrency, partSize int64, err error) {
+	concurrency, partSize = defaultConcurrency, defaultPartSize
+	if conf := apptainerconf.GetCurrentConfig(); conf != nil {
+		concurrency, partSize = int64(conf.DownloadConcurrency), int64(conf.DownloadPartSize)
+	}
+	concurrency = env.GetenvInt("APPTAINER_DOWNLOAD_CONCURRENCY", concurrency)
+	partSize = env.GetenvInt("APPTAINER_DOWNLOAD_PART_SIZE", partSize)
+
+	if concurrency < 1 {
+		return 0, 0, fmt.Errorf("invalid download concurrency value (%v)", concurrency)
+	}
+	if partSize < 1 {
+		return 0, 0, fmt.Errorf("invalid concurrent download part size (%v)", partSize)
+	}
+	return concurrency, partSize, nil
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// memBackend is an in memory Backend recording the ranges requested.
+type memBackend struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	ranges  [][2]int64
+	failAt  int64
+}
+
+func (m *memBackend) Stat(_ context.Context, ref Ref) (ObjectInfo, error) {
+	b, ok := m.objects[ref.String()]
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("object %s was not found", ref)
+	}
+	return ObjectInfo{Size: int64(len(b)), ETag: "etag"}, nil
+}
+
+func (m *memBackend) GetRange(_ context.Context, ref Ref, offset, length int64) (io.ReadCloser, error) {
+	b, ok := m.objects[ref.String()]
+	if !ok {
+		return nil, fmt.Errorf("object %s was not found", ref)
+	}
+	m.mu.Lock()
+	m.ranges = append(m.ranges, [2]int64{offset, length})
+	m.mu.Unlock()
+	if m.failAt > 0 && offset == m.failAt {
+		return nil, fmt.Errorf("part failure")
+	}
+	if length < 0 {
+		length = int64(len(b)) - offset
+	}
+	return io.NopCloser(bytes.NewReader(b[offset : offset+length])), nil
+}
+
+func (m *memBackend) Put(_ context.Context, ref Ref, r io.Reader, _ int64) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.objects[ref.String()] = b
+	return nil
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		expected  Ref
+		expectErr bool
+	}{
+		{"s3", "s3://bucket/image.sif", Ref{S3, "bucket", "image.sif"}, false},
+		{"gs nested key", "gs://bucket/path/to/image.sif", Ref{GS, "bucket", "path/to/image.sif"}, false},
+		{"azblob", "azblob://container/image.sif", Ref{AzBlob, "container", "image.sif"}, false},
+		{"no key", "s3://bucket", Ref{}, true},
+		{"empty key", "gs://bucket/", Ref{}, true},
+		{"unsupported transport", "https://bucket/image.sif", Ref{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseRef(tt.ref)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ref != tt.expected {
+				t.Errorf("got %+v, expected %+v", ref, tt.expected)
+			}
+			if ref.String() != tt.ref {
+				t.Errorf("got string %q, expected %q", ref.String(), tt.ref)
+			}
+		})
+	}
+}
+
+func TestDownloadImage(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	ref := Ref{S3, "bucket", "image.sif"}
+
+	tests := []struct {
+		name        string
+		concurrency string
+		partSize    string
+		failAt      int64
+		parts       int
+		expectErr   bool
+	}{
+		{"single request", "3", "4096", 0, 1, false},
+		{"sequential parts", "1", "100", 0, 1, false},
+		{"concurrent parts", "3", "128", 0, 8, false},
+		{"part failure", "2", "100", 500, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APPTAINER_DOWNLOAD_CONCURRENCY", tt.concurrency)
+			t.Setenv("APPTAINER_DOWNLOAD_PART_SIZE", tt.partSize)
+
+			b := &memBackend{objects: map[string][]byte{ref.String(): content}, failAt: tt.failAt}
+			path := filepath.Join(t.TempDir(), "image.sif")
+
+			err := DownloadImage(context.Background(), b, path, ref, int64(len(content)))
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("incomplete download %s was not removed", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("downloaded content doesn't match")
+			}
+			if len(b.ranges) != tt.parts {
+				t.Errorf("got %d requests, expected %d", len(b.ranges), tt.parts)
+			}
+		})
+	}
+}
+
+func TestPush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.sif")
+	if err := os.WriteFile(path, []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b := &memBackend{objects: map[string][]byte{}}
+	if err := Push(context.Background(), b, path, "gs://bucket/image.sif"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(b.objects["gs://bucket/image.sif"]); got != "image" {
+		t.Errorf("got pushed content %q, expected %q", got, "image")
+	}
+	if err := Push(context.Background(), b, filepath.Dir(path), "gs://bucket/image.sif"); err == nil {
+		t.Errorf("expected error pushing a directory")
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package objstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/client"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// progressWriter reports the bytes written to a file part to a progress bar.
+type progressWriter struct {
+	w  io.Writer
+	pb *client.DownloadProgressBar
+}
+
+func (pw progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.pb.IncrBy(n)
+	return n, err
+}
+
+// DownloadImage will retrieve the referenced object of size bytes, saving it
+// into the specified file. Objects larger than the configured part size are
+// fetched with concurrent ranged requests.
+func DownloadImage(ctx context.Context, b Backend, filePath string, ref Ref, size int64) error {
+	sylog.Debugf("Pulling from object storage: %s", ref)
+
+	concurrency, partSize, err := downloadConfig()
+	if err != nil {
+		return err
+	}
+
+	// Perms are 777 *prior* to umask
+	out, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o777)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	pb := &client.DownloadProgressBar{}
+	pb.Init(size)
+
+	if concurrency == 1 || size <= partSize {
+		err = downloadPart(ctx, b, ref, out, 0, -1, pb)
+	} else {
+		err = downloadParts(ctx, b, ref, out, size, concurrency, partSize, pb)
+	}
+	if err != nil {
+		// Delete incomplete image file in the event of failure
+		// we get here e.g. if the context is canceled by Ctrl-C
+		pb.Abort(true)
+		pb.Wait()
+		out.Close()
+		sylog.Infof("Cleaning up incomplete download: %s", filePath)
+		if err := os.Remove(filePath); err != nil {
+			sylog.Errorf("Error while removing incomplete download: %v", err)
+		}
+		return err
+	}
+	pb.Wait()
+
+	sylog.Debugf("Download complete")
+	return nil
+}
+
+// downloadPart copies length bytes of the object starting at offset into out
+// at the same offset.
+func downloadPart(ctx context.Context, b Backend, ref Ref, out *os.File, offset, length int64, pb *client.DownloadProgressBar) error {
+	body, err := b.GetRange(ctx, ref, offset, length)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	w := progressWriter{w: io.NewOffsetWriter(out, offset), pb: pb}
+	n, err := client.CopyWithContext(ctx, w, body)
+	if err != nil {
+		return err
+	}
+	if length >= 0 && n != length {
+		return fmt.Errorf("short read for part at offset %d: got %d bytes, expected %d", offset, n, length)
+	}
+	return nil
+}
+
+// downloadParts downloads the object with concurrent ranged requests of
+// partSize bytes.
+func downloadParts(ctx context.Context, b Backend, ref Ref, out *os.File, size, concurrency, partSize int64, pb *client.DownloadProgressBar) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	offsets := make(chan int64)
+	errs := make(chan error, concurrency)
+
+	var wg sync.WaitGroup
+	for i := int64(0); i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				length := min(partSize, size-offset)
+				if err := downloadPart(ctx, b, ref, out, offset, length, pb); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for offset := int64(0); offset < size; offset += partSize {
+		select {
+		case offsets <- offset:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(offsets)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// pull will pull an object storage image into the cache if directTo="", or a specific file if directTo is set.
+func pull(ctx context.Context, b Backend, imgCache *cache.Handle, directTo, pullFrom string) (imagePath string, err error) {
+	ref, err := ParseRef(pullFrom)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := b.Stat(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	if directTo != "" {
+		sylog.Infof("Downloading %s image", ref.Transport)
+		if err := DownloadImage(ctx, b, directTo, ref, info.Size); err != nil {
+			return "", fmt.Errorf("unable to download image: %v", err)
+		}
+		return directTo, nil
+	}
+
+	// Cache using a sha256 over the URI and the object ETag, which changes
+	// whenever the object content is replaced.
+	h := sha256.New()
+	h.Write([]byte(ref.String() + info.ETag))
+	hash := hex.EncodeToString(h.Sum(nil))
+	sylog.Debugf("Image hash for cache is: %s", hash)
+
+	cacheEntry, err := imgCache.GetEntry(cache.ObjectCacheType, hash)
+	if err != nil {
+		return "", fmt.Errorf("unable to check if %v exists in cache: %v", hash, err)
+	}
+	defer cacheEntry.CleanTmp()
+
+	if !cacheEntry.Exists {
+		sylog.Infof("Downloading %s image", ref.Transport)
+		if err := DownloadImage(ctx, b, cacheEntry.TmpPath, ref, info.Size); err != nil {
+			return "", fmt.Errorf("unable to download image: %v", err)
+		}
+		if err := cacheEntry.Finalize(); err != nil {
+			return "", err
+		}
+	} else {
+		sylog.Verbosef("Using image from cache")
+	}
+
+	return cacheEntry.Path, nil
+}
+
+// Pull will pull an object storage image to the cache or direct to a temporary file if cache is disabled
+func Pull(ctx context.Context, b Backend, imgCache *cache.Handle, pullFrom, tmpDir string) (imagePath string, err error) {
+	directTo := ""
+
+	if imgCache.IsDisabled() {
+		file, err := os.CreateTemp(tmpDir, "sbuild-tmp-cache-")
+		if err != nil {
+			return "", fmt.Errorf("unable to create tmp file: %v", err)
+		}
+		directTo = file.Name()
+		sylog.Infof("Downloading object storage image to tmp cache: %s", directTo)
+	}
+
+	return pull(ctx, b, imgCache, directTo, pullFrom)
+}
+
+// PullToFile will pull an object storage image to the specified location, through the cache, or directly if cache is disabled
+func PullToFile(ctx context.Context, b Backend, imgCache *cache.Handle, pullTo, pullFrom string, sandbox bool) (imagePath string, err error) {
+	directTo := ""
+	if imgCache.IsDisabled() {
+		directTo = pullTo
+		sylog.Debugf("Cache disabled, pulling directly to: %s", directTo)
+	}
+
+	src, err := pull(ctx, b, imgCache, directTo, pullFrom)
+	if err != nil {
+		return "", fmt.Errorf("error fetching image to cache: %v", err)
+	}
+
+	if directTo == "" && !sandbox {
+		// mode is before umask if pullTo doesn't exist
+		err = fs.CopyFileAtomic(src, pullTo, 0o777)
+		if err != nil {
+			return "", fmt.Errorf("error copying image out of cache: %v", err)
+		}
+	}
+
+	if sandbox {
+		if err := client.ConvertSifToSandbox(directTo, src, pullTo); err != nil {
+			return "", err
+		}
+	}
+
+	return pullTo, nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package objstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/apptainer/apptainer/internal/pkg/client"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// Push uploads the image file at path to the object storage destination.
+func Push(ctx context.Context, b Backend, path, dest string) error {
+	ref, err := ParseRef(dest)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open image: %v", err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat image: %v", err)
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not an image file", path)
+	}
+
+	sylog.Infof("Uploading image to %s", ref)
+	pb := &client.UploadProgressBar{}
+	pb.InitUpload(fi.Size(), f)
+
+	if err := b.Put(ctx, ref, pb.GetReader(), fi.Size()); err != nil {
+		pb.Terminate()
+		return err
+	}
+	pb.Finish()
+	return nil
+}
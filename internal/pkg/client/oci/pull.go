@@ -14,6 +14,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/ociimage"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/internal/pkg/util/ociauth"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	buildtypes "github.com/apptainer/apptainer/pkg/build/types"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
@@ -54,8 +56,9 @@ func transportOptions(opts PullOptions) *ociimage.TransportOptions {
 	}
 }
 
-// pull will build a SIF image into the cache if directTo="", or a specific file if directTo is set.
-func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string, opts PullOptions) (imagePath string, err error) {
+// RemoteDigest returns the digest identifying the image at pullFrom for the
+// platform requested by opts, which is the key of the image in the cache.
+func RemoteDigest(ctx context.Context, pullFrom string, opts PullOptions) (string, error) {
 	// DockerInsecureSkipTLSVerify is set only if --no-https is specified to honor
 	// configuration from /etc/containers/registries.conf because DockerInsecureSkipTLSVerify
 	// can have three possible values true/false and undefined, so we left it as undefined instead
@@ -73,43 +76,68 @@ func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string
 			return "", fmt.Errorf("failed to parse the arch value: %s, should be one of %v", opts.Pullarch, keys)
 		}
 	}
-	hash, err := oci.ImageDigest(ctx, pullFrom, to)
+	return oci.ImageDigest(ctx, pullFrom, to)
+}
+
+// pull will build a SIF image into the cache if directTo="", or a specific file if directTo is set.
+// It also returns the digest of the image.
+func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string, opts PullOptions) (imagePath, hash string, err error) {
+	endDigest := profile.Begin("digest resolution")
+	hash, err = RemoteDigest(ctx, pullFrom, opts)
+	endDigest()
 	if err != nil {
-		return "", fmt.Errorf("failed to get checksum for %s: %s", pullFrom, err)
+		return "", "", fmt.Errorf("failed to get checksum for %s: %s", pullFrom, err)
 	}
 
 	if directTo != "" {
 		sylog.Infof("Converting OCI blobs to SIF format")
-		if err := convertOciToSIF(ctx, imgCache, pullFrom, directTo, opts); err != nil {
-			return "", fmt.Errorf("while building SIF from layers: %v", err)
+		endConvert := profile.Begin("conversion")
+		err = convertOciToSIF(ctx, imgCache, pullFrom, directTo, opts)
+		endConvert()
+		if err != nil {
+			return "", "", fmt.Errorf("while building SIF from layers: %v", err)
 		}
 		imagePath = directTo
 	} else {
 
+		endCheck := profile.Begin("cache check")
 		cacheEntry, err := imgCache.GetEntry(cache.OciTempCacheType, hash)
+		endCheck()
 		if err != nil {
-			return "", fmt.Errorf("unable to check if %v exists in cache: %v", hash, err)
+			return "", "", fmt.Errorf("unable to check if %v exists in cache: %v", hash, err)
 		}
 		defer cacheEntry.CleanTmp()
 		if !cacheEntry.Exists {
 			sylog.Infof("Converting OCI blobs to SIF format")
 
-			if err := convertOciToSIF(ctx, imgCache, pullFrom, cacheEntry.TmpPath, opts); err != nil {
-				return "", fmt.Errorf("while building SIF from layers: %v", err)
+			endConvert := profile.Begin("conversion")
+			err = convertOciToSIF(ctx, imgCache, pullFrom, cacheEntry.TmpPath, opts)
+			endConvert()
+			if err != nil {
+				return "", "", fmt.Errorf("while building SIF from layers: %v", err)
 			}
 
 			err = cacheEntry.Finalize()
 			if err != nil {
-				return "", err
+				return "", "", err
 			}
 
 		} else {
 			sylog.Infof("Using cached SIF image")
 		}
 		imagePath = cacheEntry.Path
+
+		recordImage(imgCache, cache.ImageRecord{URI: pullFrom, Arch: opts.Pullarch, Digest: hash})
 	}
 
-	return imagePath, nil
+	return imagePath, hash, nil
+}
+
+// recordImage records the digest an image was pulled at in the cache.
+func recordImage(imgCache *cache.Handle, r cache.ImageRecord) {
+	if err := imgCache.RecordImage(r); err != nil {
+		sylog.Warningf("Unable to record digest of %s in cache: %v", r.URI, err)
+	}
 }
 
 // convertOciToSIF will convert an OCI source into a SIF using the build routines
@@ -157,7 +185,8 @@ func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom string, opts Pul
 		sylog.Infof("Downloading library image to tmp cache: %s", directTo)
 	}
 
-	return pull(ctx, imgCache, directTo, pullFrom, opts)
+	imagePath, _, err = pull(ctx, imgCache, directTo, pullFrom, opts)
+	return imagePath, err
 }
 
 // PullToFile will build a SIF image from the specified oci URI and place it at the specified dest
@@ -168,7 +197,7 @@ func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom st
 		sylog.Debugf("Cache disabled, pulling directly to: %s", directTo)
 	}
 
-	src, err := pull(ctx, imgCache, directTo, pullFrom, opts)
+	src, hash, err := pull(ctx, imgCache, directTo, pullFrom, opts)
 	if err != nil {
 		if strings.Contains(err.Error(), "unsupported image-specific operation on artifact with type \"application/vnd.unknown.config.v1+json\"") {
 			return "", fmt.Errorf("%v; try changing the protocol to oras://", err)
@@ -190,5 +219,9 @@ func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom st
 		}
 	}
 
+	if path, err := filepath.Abs(pullTo); err == nil {
+		recordImage(imgCache, cache.ImageRecord{URI: pullFrom, Path: path, Arch: opts.Pullarch, Digest: hash})
+	}
+
 	return pullTo, nil
 }
@@ -0,0 +1,172 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/internal/pkg/util/ociauth"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// trimRef removes the transport and leading slashes of a docker:// or
+// oras:// URI.
+func trimRef(ref string) string {
+	if _, r, ok := strings.Cut(ref, "://"); ok {
+		ref = r
+	}
+	return strings.TrimPrefix(ref, "//")
+}
+
+func nameOptions(noHTTPS bool) []name.Option {
+	opts := []name.Option{name.WithDefaultRegistry(name.DefaultRegistry)}
+	if noHTTPS {
+		opts = append(opts, name.Insecure)
+	}
+	return opts
+}
+
+func remoteOptions(ctx context.Context, ociAuth *authn.AuthConfig, reqAuthFile string) []remote.Option {
+	return []remote.Option{
+		ociauth.AuthOptn(ociAuth, reqAuthFile),
+		remote.WithUserAgent(useragent.Value()),
+		remote.WithContext(ctx),
+	}
+}
+
+// ListTags returns the tags of the repository of ref, a docker:// or oras://
+// URI, sorted alphabetically. A tag or digest in ref is ignored.
+func ListTags(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) ([]string, error) {
+	r, err := name.ParseReference(trimRef(ref), nameOptions(noHTTPS)...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	tags, err := remote.List(r.Context(), remoteOptions(ctx, ociAuth, reqAuthFile)...)
+	if err != nil {
+		return nil, fmt.Errorf("while listing tags of %s: %w", r.Context(), err)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// ListTagsCached returns the tags of the repository of ref cached in imgCache
+// when they were listed less than maxAge ago, or else lists them with ListTags
+// and caches them. With a non-zero maxAge, older cached tags are returned
+// when the tags can't be listed.
+func ListTagsCached(ctx context.Context, imgCache *cache.Handle, ref string, maxAge time.Duration, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) ([]string, error) {
+	r, err := name.ParseReference(trimRef(ref), nameOptions(noHTTPS)...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	repo := r.Context().Name()
+
+	cached, cachedAt, err := imgCache.GetTags(repo)
+	if err != nil {
+		sylog.Debugf("While reading cached tags of %s: %v", repo, err)
+	}
+	if cached != nil && time.Since(cachedAt) < maxAge {
+		return cached, nil
+	}
+
+	tags, err := ListTags(ctx, ref, ociAuth, noHTTPS, reqAuthFile)
+	if err != nil {
+		if cached != nil && maxAge > 0 {
+			sylog.Debugf("Using tags of %s cached at %s: %v", repo, cachedAt, err)
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := imgCache.PutTags(repo, tags); err != nil {
+		sylog.Debugf("While caching tags of %s: %v", repo, err)
+	}
+	return tags, nil
+}
+
+// splitQuery splits a search query into the registry it targets and the
+// repository name to search for. The first path component is the registry
+// when it is a host name, otherwise the default registry is searched.
+func splitQuery(query string) (registry, repository string) {
+	host, path, ok := strings.Cut(query, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		return host, path
+	}
+	return "", query
+}
+
+// SearchRegistry returns the repositories listed in the catalog of the
+// registry targeted by query, a docker:// or oras:// URI, whose names contain
+// the repository part of query. It also returns the registry searched. Many
+// public registries don't allow listing their catalog.
+func SearchRegistry(ctx context.Context, query string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (string, []string, error) {
+	host, repository := splitQuery(trimRef(query))
+	if host == "" {
+		host = name.DefaultRegistry
+	}
+	reg, err := name.NewRegistry(host, nameOptions(noHTTPS)...)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid registry %q: %w", host, err)
+	}
+
+	repos, err := remote.Catalog(ctx, reg, remoteOptions(ctx, ociAuth, reqAuthFile)...)
+	if err != nil {
+		return "", nil, fmt.Errorf("while listing catalog of %s: %w", reg, err)
+	}
+
+	repository = strings.ToLower(strings.Trim(repository, "/"))
+	var matches []string
+	for _, r := range repos {
+		if strings.Contains(r, repository) {
+			matches = append(matches, r)
+		}
+	}
+	sort.Strings(matches)
+	return reg.RegistryStr(), matches, nil
+}
+
+// ResolveDigest returns the normalized form of ref, a docker:// or oras://
+// URI without its transport, and the digest of the manifest or image index
+// it points to. The registry is only contacted for tag-based references.
+func ResolveDigest(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (string, string, error) {
+	r, err := name.ParseReference(trimRef(ref), nameOptions(noHTTPS)...)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	if d, ok := r.(name.Digest); ok {
+		return d.Name(), d.DigestStr(), nil
+	}
+
+	opts := remoteOptions(ctx, ociAuth, reqAuthFile)
+	desc, err := remote.Head(r, opts...)
+	if err != nil {
+		// some registries don't support HEAD requests on manifests
+		gd, gerr := remote.Get(r, opts...)
+		if gerr != nil {
+			return "", "", fmt.Errorf("while resolving %s: %w", r, err)
+		}
+		desc = &gd.Descriptor
+	}
+	return r.Name(), desc.Digest.String(), nil
+}
+
+// PinDigest returns the docker:// or oras:// URI ref with its tag replaced
+// by digest.
+func PinDigest(ref, digest string, noHTTPS bool) (string, error) {
+	r, err := name.ParseReference(trimRef(ref), nameOptions(noHTTPS)...)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	transport, _, _ := strings.Cut(ref, "://")
+	return transport + "://" + r.Context().Name() + "@" + digest, nil
+}
@@ -0,0 +1,207 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oci
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func testRegistry(t *testing.T, refs ...string) string {
+	host, _ := testRegistryServer(t, refs...)
+	return host
+}
+
+// testRegistryServer is like testRegistry, and also returns the server.
+func testRegistryServer(t *testing.T, refs ...string) (string, *httptest.Server) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(s.Close)
+	host := strings.TrimPrefix(s.URL, "http://")
+
+	testPush(t, host, refs...)
+	return host, s
+}
+
+func testPush(t *testing.T, host string, refs ...string) {
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range refs {
+		tag, err := name.NewTag(host+"/"+r, name.Insecure)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Write(tag, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListTags(t *testing.T) {
+	host := testRegistry(t, "biocontainers/samtools:1.9", "biocontainers/samtools:1.10", "biocontainers/bwa:0.7")
+
+	tags, err := ListTags(context.Background(), "docker://"+host+"/biocontainers/samtools:1.9", nil, true, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"1.10", "1.9"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	if _, err := ListTags(context.Background(), "docker://"+host+"/biocontainers/missing", nil, true, ""); err == nil {
+		t.Errorf("expected error for missing repository")
+	}
+}
+
+func TestListTagsCached(t *testing.T) {
+	host, server := testRegistryServer(t, "biocontainers/samtools:1.9")
+	ref := "docker://" + host + "/biocontainers/samtools"
+	t.Setenv(cache.DisableEnv, "")
+	imgCache, err := cache.New(cache.Config{ParentDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := ListTagsCached(context.Background(), imgCache, ref, time.Hour, nil, true, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"1.9"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	// tags pushed after the listing are only seen once the cached tags expire
+	testPush(t, host, "biocontainers/samtools:1.10")
+	if tags, _ := ListTagsCached(context.Background(), imgCache, ref, time.Hour, nil, true, ""); !reflect.DeepEqual(tags, []string{"1.9"}) {
+		t.Errorf("got tags %v, expected the cached tags", tags)
+	}
+	if tags, _ := ListTagsCached(context.Background(), imgCache, ref, 0, nil, true, ""); !reflect.DeepEqual(tags, []string{"1.10", "1.9"}) {
+		t.Errorf("got tags %v, expected the listed tags", tags)
+	}
+
+	// the cached tags are used when the registry can't be reached, unless
+	// the tags must be listed
+	server.Close()
+	if tags, _ := ListTagsCached(context.Background(), imgCache, ref, time.Nanosecond, nil, true, ""); !reflect.DeepEqual(tags, []string{"1.10", "1.9"}) {
+		t.Errorf("got tags %v, expected the cached tags", tags)
+	}
+	if _, err := ListTagsCached(context.Background(), imgCache, ref, 0, nil, true, ""); err == nil {
+		t.Errorf("unexpected success listing tags of an unreachable registry")
+	}
+}
+
+func TestSearchRegistry(t *testing.T) {
+	host := testRegistry(t, "biocontainers/samtools:1.9", "biocontainers/bwa:0.7", "other/samtools-extra:1")
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "Repository", query: "docker://" + host + "/biocontainers/samtools", want: []string{"biocontainers/samtools"}},
+		{name: "Partial", query: "oras://" + host + "/samtools", want: []string{"biocontainers/samtools", "other/samtools-extra"}},
+		{name: "All", query: "docker://" + host + "/", want: []string{"biocontainers/bwa", "biocontainers/samtools", "other/samtools-extra"}},
+		{name: "None", query: "docker://" + host + "/blast", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg, repos, err := SearchRegistry(context.Background(), tt.query, nil, true, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reg != host {
+				t.Errorf("searched registry %s, want %s", reg, host)
+			}
+			if !reflect.DeepEqual(repos, tt.want) {
+				t.Errorf("got repositories %v, want %v", repos, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitQuery(t *testing.T) {
+	tests := []struct {
+		query, registry, repository string
+	}{
+		{"quay.io/biocontainers/samtools", "quay.io", "biocontainers/samtools"},
+		{"localhost:5000/samtools", "localhost:5000", "samtools"},
+		{"localhost/samtools", "localhost", "samtools"},
+		{"biocontainers/samtools", "", "biocontainers/samtools"},
+		{"samtools", "", "samtools"},
+	}
+	for _, tt := range tests {
+		registry, repository := splitQuery(tt.query)
+		if registry != tt.registry || repository != tt.repository {
+			t.Errorf("splitQuery(%q) = %q, %q, want %q, %q", tt.query, registry, repository, tt.registry, tt.repository)
+		}
+	}
+}
+
+func TestResolveDigest(t *testing.T) {
+	host := testRegistry(t, "lolcow:latest")
+
+	ref, err := name.ParseReference(host+"/lolcow:latest", name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := remote.Head(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := desc.Digest.String()
+
+	n, digest, err := ResolveDigest(context.Background(), "docker://"+host+"/lolcow", nil, true, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != host+"/lolcow:latest" || digest != want {
+		t.Errorf("got %s %s, want %s %s", n, digest, host+"/lolcow:latest", want)
+	}
+
+	// digest references are resolved without contacting the registry
+	d := "sha256:" + strings.Repeat("0", 64)
+	n, digest, err = ResolveDigest(context.Background(), "oras://unreachable.invalid/lolcow@"+d, nil, false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != "unreachable.invalid/lolcow@"+d || digest != d {
+		t.Errorf("got %s %s for digest reference", n, digest)
+	}
+
+	if _, _, err := ResolveDigest(context.Background(), "docker://"+host+"/missing:1", nil, true, ""); err == nil {
+		t.Errorf("expected error for missing image")
+	}
+
+	pinned, err := PinDigest("docker://alpine:3.19", want, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pinned != "docker://index.docker.io/library/alpine@"+want {
+		t.Errorf("got pinned reference %s", pinned)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// CheckpointArtifactType is the artifact type of a container checkpoint.
+	CheckpointArtifactType = "application/vnd.apptainer.checkpoint.v1"
+	// CheckpointLayerMediaType is the mediaType of the layer holding the
+	// checkpoint state, archived as a gzip compressed tar file.
+	CheckpointLayerMediaType = "application/vnd.apptainer.checkpoint.layer.v1.tar+gzip"
+)
+
+// UploadCheckpoint pushes the checkpoint archive at path to the provided oci reference, as an
+// artifact of CheckpointArtifactType with a single layer. It will use credentials if supplied.
+func UploadCheckpoint(ctx context.Context, path, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) error {
+	ir, remoteOpts, err := remoteRef(ctx, ref, ociAuth, noHTTPS, nil, reqAuthFile)
+	if err != nil {
+		return err
+	}
+	im, err := NewImageFromSIF(path, CheckpointLayerMediaType,
+		OptImageArtifactType(CheckpointArtifactType),
+		OptImageConfigMediaType(CheckpointArtifactType),
+	)
+	if err != nil {
+		return err
+	}
+	defer im.layer.rc.Close()
+
+	remoteOpts = append(remoteOpts, remote.WithUserAgent(useragent.Value()))
+	return remote.Write(ir, im, remoteOpts...)
+}
+
+// DownloadCheckpoint downloads the checkpoint archive of the artifact specified by an oci
+// reference to a file, using the included credentials.
+func DownloadCheckpoint(ctx context.Context, path, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) error {
+	im, err := remoteImage(ctx, ref, ociAuth, noHTTPS, nil, reqAuthFile)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := im.Manifest()
+	if err != nil {
+		return err
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != CheckpointLayerMediaType {
+		return fmt.Errorf("%s is not a checkpoint artifact", ref)
+	}
+
+	l, err := im.LayerByDigest(manifest.Layers[0].Digest)
+	if err != nil {
+		return err
+	}
+	// the digest of the layer is verified when read to the end
+	rc, err := l.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestCheckpoint(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	host := strings.TrimPrefix(s.URL, "http://")
+
+	archive := filepath.Join(t.TempDir(), "checkpoint.tar.gz")
+	if err := os.WriteFile(archive, []byte("checkpoint state"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ref := "oras://" + host + "/test/checkpoint:latest"
+	if err := UploadCheckpoint(context.Background(), archive, ref, nil, true, ""); err != nil {
+		t.Fatalf("while uploading checkpoint: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "pulled.tar.gz")
+	if err := DownloadCheckpoint(context.Background(), dest, ref, nil, true, ""); err != nil {
+		t.Fatalf("while downloading checkpoint: %v", err)
+	}
+	b, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "checkpoint state" {
+		t.Errorf("unexpected checkpoint content %q", b)
+	}
+
+	// a SIF image isn't a checkpoint
+	image := "oras://" + host + "/test/image:latest"
+	if err := UploadImage(context.Background(), createSIF(t), image, nil, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := DownloadCheckpoint(context.Background(), dest, image, nil, true, ""); err == nil {
+		t.Errorf("unexpected success downloading checkpoint from image")
+	}
+}
@@ -6,9 +6,18 @@
 package oras
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 
+	"github.com/apptainer/apptainer/internal/pkg/ociimage"
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/inspect"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/sif/v2/pkg/sif"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/partial"
 	"github.com/google/go-containerregistry/pkg/v1/types"
@@ -26,10 +35,69 @@ const (
 	SifConfigMediaTypeV1 = "application/vnd.sylabs.sif.config.v1+json"
 )
 
+// mediaTypeRegexp matches a media type as defined by RFC 6838.
+var mediaTypeRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]{0,126}/[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]{0,126}$`)
+
 // SifImage implements a go-containerregistry v1.Image representing an ORAS / OCI artifact of a single SIF image.
 type SifImage struct {
-	manifest v1.Manifest
-	layer    *SifLayer
+	manifest     v1.Manifest
+	artifactType string
+	layer        *SifLayer
+	referrers    []Referrer
+}
+
+// sifManifest is the serialized manifest of a SifImage. The artifactType
+// field of OCI 1.1 manifests isn't part of v1.Manifest.
+type sifManifest struct {
+	v1.Manifest
+	ArtifactType string `json:"artifactType,omitempty"`
+}
+
+// ImageOpt sets an option on a SifImage.
+type ImageOpt func(*SifImage) error
+
+// OptImageArtifactType sets the artifactType of the manifest, used by
+// registries to identify the kind of artifact.
+func OptImageArtifactType(t string) ImageOpt {
+	return func(si *SifImage) error {
+		if t != "" && !mediaTypeRegexp.MatchString(t) {
+			return fmt.Errorf("invalid artifact type %q", t)
+		}
+		si.artifactType = t
+		return nil
+	}
+}
+
+// OptImageConfigMediaType sets the media type of the config descriptor of
+// the manifest, in place of SifConfigMediaTypeV1.
+func OptImageConfigMediaType(t string) ImageOpt {
+	return func(si *SifImage) error {
+		if t == "" {
+			return nil
+		}
+		if !mediaTypeRegexp.MatchString(t) {
+			return fmt.Errorf("invalid config media type %q", t)
+		}
+		si.manifest.Config.MediaType = types.MediaType(t)
+		return nil
+	}
+}
+
+// OptImageAnnotations adds annotations to the manifest, such as
+// org.opencontainers.image.source or org.opencontainers.image.version.
+func OptImageAnnotations(annotations map[string]string) ImageOpt {
+	return func(si *SifImage) error {
+		for k, v := range annotations {
+			if k == "" {
+				return fmt.Errorf("empty annotation key")
+			}
+			if si.manifest.Annotations == nil {
+				si.manifest.Annotations = make(map[string]string)
+			}
+			si.manifest.Annotations[k] = v
+		}
+		return nil
+	}
 }
 
 var _ = v1.Image(&SifImage{})
@@ -47,7 +115,7 @@ func (si *SifImage) MediaType() (types.MediaType, error) {
 
 // Size returns the size of the manifest.
 func (si *SifImage) Size() (int64, error) {
-	return 0, nil
+	return partial.Size(si)
 }
 
 // ConfigName returns the hash of the image's config file, also known as
@@ -77,9 +145,13 @@ func (si *SifImage) Manifest() (*v1.Manifest, error) {
 	return &si.manifest, nil
 }
 
-// RawManifest returns the serialized bytes of Manifest()
+// RawManifest returns the serialized bytes of Manifest(), with the
+// artifactType of the image.
 func (si *SifImage) RawManifest() ([]byte, error) {
-	return partial.RawManifest(si)
+	if si.artifactType == "" {
+		return partial.RawManifest(si)
+	}
+	return json.Marshal(sifManifest{Manifest: si.manifest, ArtifactType: si.artifactType})
 }
 
 // LayerByDigest returns a Layer for interacting with a particular layer of
@@ -97,7 +169,45 @@ func (si *SifImage) LayerByDiffID(hash v1.Hash) (v1.Layer, error) {
 	return si.LayerByDigest(hash)
 }
 
-func NewImageFromSIF(file string, layerMediaType types.MediaType) (*SifImage, error) {
+// OptImageSubject sets the subject of the manifest, making the image a
+// referrer of the manifest described by d.
+func OptImageSubject(d v1.Descriptor) ImageOpt {
+	return func(si *SifImage) error {
+		si.manifest.Subject = &d
+		return nil
+	}
+}
+
+// OptImageReferrers sets the artifacts attached to the image as referrers
+// when it is uploaded.
+func OptImageReferrers(referrers ...Referrer) ImageOpt {
+	return func(si *SifImage) error {
+		si.referrers = append(si.referrers, referrers...)
+		return nil
+	}
+}
+
+// descriptor returns the descriptor of the manifest of the image.
+func (si *SifImage) descriptor() (v1.Descriptor, error) {
+	b, err := si.RawManifest()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	h, size, err := v1.SHA256(bytes.NewReader(b))
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return v1.Descriptor{
+		MediaType:    si.manifest.MediaType,
+		Size:         size,
+		Digest:       h,
+		ArtifactType: si.artifactType,
+	}, nil
+}
+
+// NewImageFromSIF returns an image with a single layer of layerMediaType
+// holding the SIF file, and its manifest set by opts.
+func NewImageFromSIF(file string, layerMediaType types.MediaType, opts ...ImageOpt) (*SifImage, error) {
 	si := SifImage{}
 
 	sl, err := NewLayerFromSIF(file, layerMediaType)
@@ -165,5 +275,49 @@ func NewImageFromSIF(file string, layerMediaType types.MediaType) (*SifImage, er
 		},
 	}
 
+	// the provenance labels of the image are annotations of the manifest,
+	// unless overridden by opts
+	if annotations := ociimage.ImageAnnotations(sifLabels(file)); len(annotations) > 0 {
+		si.manifest.Annotations = annotations
+	}
+
+	for _, opt := range opts {
+		if err := opt(&si); err != nil {
+			return nil, err
+		}
+	}
+
 	return &si, nil
 }
+
+// sifLabels returns the labels held by the metadata of the SIF image file,
+// or nil if there are none.
+func sifLabels(file string) map[string]string {
+	f, err := sif.LoadContainerFromPath(file, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		return nil
+	}
+	defer f.UnloadContainer()
+
+	ds, err := f.GetDescriptors(sif.WithDataType(sif.DataGenericJSON))
+	if err != nil {
+		return nil
+	}
+	for _, d := range ds {
+		if d.Name() != image.SIFDescInspectMetadataJSON {
+			continue
+		}
+		b, err := d.GetData()
+		if err != nil {
+			sylog.Debugf("Unable to read metadata of %s: %v", file, err)
+			return nil
+		}
+		var m inspect.Metadata
+		if err := json.Unmarshal(b, &m); err != nil {
+			sylog.Debugf("Unable to decode metadata of %s: %v", file, err)
+			return nil
+		}
+		return m.Attributes.Labels
+	}
+	return nil
+}
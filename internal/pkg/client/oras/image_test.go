@@ -0,0 +1,154 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/inspect"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+func TestNewImageFromSIFOpts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "image.sif")
+	if err := os.WriteFile(file, []byte("not really a SIF"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		opts             []ImageOpt
+		wantArtifactType string
+		wantConfigType   string
+		wantAnnotations  map[string]string
+		wantErr          bool
+	}{
+		{
+			name:           "Default",
+			wantConfigType: SifConfigMediaTypeV1,
+		},
+		{
+			name: "ArtifactTypeAnnotations",
+			opts: []ImageOpt{
+				OptImageArtifactType("application/vnd.example.sif"),
+				OptImageConfigMediaType("application/vnd.oci.empty.v1+json"),
+				OptImageAnnotations(map[string]string{"org.opencontainers.image.version": "1.0"}),
+			},
+			wantArtifactType: "application/vnd.example.sif",
+			wantConfigType:   "application/vnd.oci.empty.v1+json",
+			wantAnnotations:  map[string]string{"org.opencontainers.image.version": "1.0"},
+		},
+		{
+			name:    "InvalidArtifactType",
+			opts:    []ImageOpt{OptImageArtifactType("sif")},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidConfigMediaType",
+			opts:    []ImageOpt{OptImageConfigMediaType("application/")},
+			wantErr: true,
+		},
+		{
+			name:    "EmptyAnnotationKey",
+			opts:    []ImageOpt{OptImageAnnotations(map[string]string{"": "x"})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, err := NewImageFromSIF(file, SifLayerMediaTypeV1, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer im.layer.rc.Close()
+
+			b, err := im.RawManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var m struct {
+				v1.Manifest
+				ArtifactType string `json:"artifactType"`
+			}
+			if err := json.Unmarshal(b, &m); err != nil {
+				t.Fatal(err)
+			}
+			if m.ArtifactType != tt.wantArtifactType {
+				t.Errorf("got artifactType %q, want %q", m.ArtifactType, tt.wantArtifactType)
+			}
+			if string(m.Config.MediaType) != tt.wantConfigType {
+				t.Errorf("got config media type %q, want %q", m.Config.MediaType, tt.wantConfigType)
+			}
+			if len(m.Annotations) != len(tt.wantAnnotations) {
+				t.Errorf("got annotations %v, want %v", m.Annotations, tt.wantAnnotations)
+			}
+			for k, v := range tt.wantAnnotations {
+				if m.Annotations[k] != v {
+					t.Errorf("got annotation %s=%q, want %q", k, m.Annotations[k], v)
+				}
+			}
+
+			// the digest is computed from the serialized manifest
+			d, err := im.Digest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, _, _ := v1.SHA256(bytes.NewReader(b)); d != want {
+				t.Errorf("got digest %v, want %v", d, want)
+			}
+		})
+	}
+}
+
+func TestNewImageFromSIFLabels(t *testing.T) {
+	m := inspect.NewMetadata()
+	m.Attributes.Labels["org.opencontainers.image.source"] = "https://example.com/src"
+	m.Attributes.Labels["org.opencontainers.image.version"] = "1.0"
+	m.Attributes.Labels["maintainer"] = "someone"
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	di, err := sif.NewDescriptorInput(sif.DataGenericJSON, bytes.NewReader(b), sif.OptObjectName(image.SIFDescInspectMetadataJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "image.sif")
+	f, err := sif.CreateContainerAtPath(file, sif.OptCreateWithDescriptors(di))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.UnloadContainer()
+
+	im, err := NewImageFromSIF(file, SifLayerMediaTypeV1, OptImageAnnotations(map[string]string{"org.opencontainers.image.version": "2.0"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer im.layer.rc.Close()
+
+	mf, err := im.Manifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"org.opencontainers.image.source":  "https://example.com/src",
+		"org.opencontainers.image.version": "2.0",
+	}
+	if !reflect.DeepEqual(mf.Annotations, want) {
+		t.Errorf("got annotations %v, want %v", mf.Annotations, want)
+	}
+}
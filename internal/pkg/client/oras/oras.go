@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/client"
+	"github.com/apptainer/apptainer/internal/pkg/client/p2p"
 	"github.com/apptainer/apptainer/internal/pkg/util/ociauth"
 	"github.com/apptainer/apptainer/pkg/image"
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -35,7 +36,11 @@ import (
 
 // DownloadImage downloads a SIF image specified by an oci reference to a file using the included credentials
 func DownloadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) error {
-	rt := client.NewRoundTripper(ctx, nil)
+	p2pCfg, err := p2p.CurrentConfig()
+	if err != nil {
+		return err
+	}
+	rt := client.NewRoundTripper(ctx, p2p.NewTransport(p2pCfg, nil))
 	im, err := remoteImage(ctx, ref, ociAuth, noHTTPS, rt, reqAuthFile)
 	if err != nil {
 		rt.ProgressShutdown()
@@ -114,8 +119,9 @@ func DownloadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthCon
 }
 
 // UploadImage uploads the image specified by path and pushes it to the provided oci reference,
-// it will use credentials if supplied
-func UploadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) error {
+// it will use credentials if supplied. The manifest of the image is set by imageOpts, and
+// the referrers set with OptImageReferrers are attached to it once pushed.
+func UploadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string, imageOpts ...ImageOpt) error {
 	// ensure that are uploading a SIF
 	if err := ensureSIF(path); err != nil {
 		return err
@@ -134,7 +140,7 @@ func UploadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfi
 		return err
 	}
 
-	im, err := NewImageFromSIF(path, SifLayerMediaTypeV1)
+	im, err := NewImageFromSIF(path, SifLayerMediaTypeV1, imageOpts...)
 	if err != nil {
 		return err
 	}
@@ -144,6 +150,8 @@ func UploadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfi
 		remote.WithUserAgent(useragent.Value()),
 		remote.WithContext(ctx),
 	}
+	// referrers are small, push them without progress bar
+	referrerOpts := remoteOpts
 	if term.IsTerminal(2) {
 		pb := &client.DownloadProgressBar{}
 		progChan := make(chan v1.Update, 1)
@@ -172,7 +180,40 @@ func UploadImage(ctx context.Context, path, ref string, ociAuth *authn.AuthConfi
 		}()
 		remoteOpts = append(remoteOpts, remote.WithProgress(progChan))
 	}
-	return remote.Write(ir, im, remoteOpts...)
+	if err := remote.Write(ir, im, remoteOpts...); err != nil {
+		return err
+	}
+	return pushReferrers(ir.Context(), im, referrerOpts)
+}
+
+// pushReferrers pushes the referrers of the image to the repository of the
+// image. Registries that don't support the referrers API are handled by
+// remote.Write, which updates the index tagged with the fallback tag of the
+// image.
+func pushReferrers(repo name.Repository, im *SifImage, opts []remote.Option) error {
+	subject, err := im.descriptor()
+	if err != nil {
+		return err
+	}
+	for _, r := range im.referrers {
+		ri, err := r.image(subject)
+		if err != nil {
+			return fmt.Errorf("while creating referrer for %s: %w", r.Path, err)
+		}
+		d, err := ri.Digest()
+		if err != nil {
+			ri.layer.rc.Close()
+			return err
+		}
+		sylog.Debugf("Attaching %s (%s) as referrer %s of %s", r.Path, r.ArtifactType, d, subject.Digest)
+		err = remote.Write(repo.Digest(d.String()), ri, opts...)
+		ri.layer.rc.Close()
+		if err != nil {
+			return fmt.Errorf("while attaching %s: %w", r.Path, err)
+		}
+		sylog.Infof("Attached %s as %s", r.Path, r.ArtifactType)
+	}
+	return nil
 }
 
 // ensureSIF checks for a SIF image at filepath and returns an error if it is not, or an error is encountered
@@ -192,27 +233,37 @@ func ensureSIF(filepath string) error {
 
 // RefHash returns the digest of the SIF layer of the OCI manifest for supplied ref
 func RefHash(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (v1.Hash, error) {
+	_, hash, err := refHashes(ctx, ref, ociAuth, noHTTPS, reqAuthFile)
+	return hash, err
+}
+
+// refHashes returns the digest of the OCI manifest for supplied ref, and the
+// digest of its SIF layer.
+func refHashes(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (manifestHash, layerHash v1.Hash, err error) {
 	im, err := remoteImage(ctx, ref, ociAuth, noHTTPS, nil, reqAuthFile)
 	if err != nil {
-		return v1.Hash{}, err
+		return v1.Hash{}, v1.Hash{}, err
 	}
 
 	// Check manifest to ensure we have a SIF as single layer
 	manifest, err := im.Manifest()
 	if err != nil {
-		return v1.Hash{}, err
+		return v1.Hash{}, v1.Hash{}, err
 	}
 	if len(manifest.Layers) != 1 {
-		return v1.Hash{}, fmt.Errorf("ORAS SIF image should have a single layer, found %d", len(manifest.Layers))
+		return v1.Hash{}, v1.Hash{}, fmt.Errorf("ORAS SIF image should have a single layer, found %d", len(manifest.Layers))
 	}
 	layer := manifest.Layers[0]
 	if layer.MediaType != SifLayerMediaTypeV1 &&
 		layer.MediaType != SifLayerMediaTypeProto {
-		return v1.Hash{}, fmt.Errorf("invalid layer mediatype: %s", layer.MediaType)
+		return v1.Hash{}, v1.Hash{}, fmt.Errorf("invalid layer mediatype: %s", layer.MediaType)
 	}
 
-	hash := layer.Digest
-	return hash, nil
+	manifestHash, err = im.Digest()
+	if err != nil {
+		return v1.Hash{}, v1.Hash{}, err
+	}
+	return manifestHash, layer.Digest, nil
 }
 
 // ImageDigest returns the digest for a file
@@ -251,6 +302,20 @@ func sha256sum(r io.Reader) (result string, nBytes int64, err error) {
 
 // remoteImage returns a v1.Image for the provided remote ref.
 func remoteImage(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, rt *client.RoundTripper, reqAuthFile string) (v1.Image, error) {
+	ir, remoteOpts, err := remoteRef(ctx, ref, ociAuth, noHTTPS, rt, reqAuthFile)
+	if err != nil {
+		return nil, err
+	}
+	im, err := remote.Image(ir, remoteOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return im, nil
+}
+
+// remoteRef returns the reference of the provided remote ref, with the
+// options to access it.
+func remoteRef(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noHTTPS bool, rt *client.RoundTripper, reqAuthFile string) (name.Reference, []remote.Option, error) {
 	ref = strings.TrimPrefix(ref, "oras://")
 	ref = strings.TrimPrefix(ref, "//")
 
@@ -261,7 +326,7 @@ func remoteImage(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noH
 	}
 	ir, err := name.ParseReference(ref, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+		return nil, nil, fmt.Errorf("invalid reference %q: %w", ref, err)
 	}
 	remoteOpts := []remote.Option{
 		ociauth.AuthOptn(ociAuth, reqAuthFile),
@@ -270,9 +335,5 @@ func remoteImage(ctx context.Context, ref string, ociAuth *authn.AuthConfig, noH
 	if rt != nil {
 		remoteOpts = append(remoteOpts, remote.WithTransport(rt))
 	}
-	im, err := remote.Image(ir, remoteOpts...)
-	if err != nil {
-		return nil, err
-	}
-	return im, nil
+	return ir, remoteOpts, nil
 }
@@ -0,0 +1,86 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/signature"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+// ReferrerPolicy is the policy enforced on the referrers of pulled images.
+type ReferrerPolicy struct {
+	// RequiredTypes are the artifact types which must be attached to the
+	// image.
+	RequiredTypes []string
+	// Keyless, when set, requires the image to be verified by one of the
+	// sigstore bundles attached to it, with this keyless trust.
+	Keyless *signature.KeylessTrust
+}
+
+// currentReferrerPolicy returns the referrer policy set in apptainer.conf.
+func currentReferrerPolicy() (*ReferrerPolicy, error) {
+	p := new(ReferrerPolicy)
+	cfg := apptainerconf.GetCurrentConfig()
+	if cfg == nil {
+		return p, nil
+	}
+	for _, t := range cfg.OrasRequiredReferrers {
+		p.RequiredTypes = append(p.RequiredTypes, ResolveArtifactType(t))
+	}
+	if cfg.OrasVerifyReferrers {
+		t, err := signature.LoadConfiguredKeylessTrust(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("while loading keyless trust configuration: %w", err)
+		}
+		p.Keyless = t
+	}
+	return p, nil
+}
+
+// Enforced returns whether the policy puts any requirement on the referrers.
+func (p *ReferrerPolicy) Enforced() bool {
+	return len(p.RequiredTypes) > 0 || p.Keyless != nil
+}
+
+// Check checks that the referrers of the SIF image at path satisfy the
+// policy.
+func (p *ReferrerPolicy) Check(ctx context.Context, path string, s *ReferrerSet) error {
+	var missing []string
+	for _, t := range p.RequiredTypes {
+		if !s.Has(t) {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("image %s has no required referrer of type %s", s.Subject, strings.Join(missing, ", "))
+	}
+
+	if p.Keyless == nil {
+		return nil
+	}
+	var errs []error
+	for _, r := range s.Referrers {
+		if r.ArtifactType != SigstoreBundleArtifactType {
+			continue
+		}
+		err := signature.Verify(ctx, path, signature.OptVerifyWithKeylessBundle(r.Data, p.Keyless))
+		if err == nil {
+			sylog.Infof("Image verified by sigstore bundle referrer %s", r.Digest)
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("referrer %s: %w", r.Digest, err))
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("image %s has no sigstore bundle referrer", s.Subject)
+	}
+	return fmt.Errorf("image %s not verified by its sigstore bundle referrers: %w", s.Subject, errors.Join(errs...))
+}
@@ -11,60 +11,172 @@ package oras
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/apptainer/apptainer/internal/pkg/cache"
 	"github.com/apptainer/apptainer/internal/pkg/client"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 // pull will pull an oras image into the cache if directTo="", or a specific file if directTo is set.
-func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (imagePath string, err error) {
-	hash, err := RefHash(ctx, pullFrom, ociAuth, noHTTPS, reqAuthFile)
+// It also returns the digest of the SIF layer of the image.
+func pull(ctx context.Context, imgCache *cache.Handle, directTo, pullFrom string, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (imagePath string, hash v1.Hash, err error) {
+	manifestHash, hash, err := refHashes(ctx, pullFrom, ociAuth, noHTTPS, reqAuthFile)
 	if err != nil {
-		return "", fmt.Errorf("failed to get checksum for %s: %s", pullFrom, err)
+		return "", hash, fmt.Errorf("failed to get checksum for %s: %s", pullFrom, err)
 	}
 
 	if directTo != "" {
 		sylog.Infof("Downloading oras image")
 		if err := DownloadImage(ctx, directTo, pullFrom, ociAuth, noHTTPS, reqAuthFile); err != nil {
-			return "", fmt.Errorf("unable to Download Image: %v", err)
+			return "", hash, fmt.Errorf("unable to Download Image: %v", err)
 		}
 		imagePath = directTo
 
+		if err := checkReferrers(ctx, imgCache, imagePath, pullFrom, manifestHash, ociAuth, noHTTPS, reqAuthFile); err != nil {
+			os.Remove(imagePath)
+			return "", hash, err
+		}
 	} else {
 		cacheEntry, err := imgCache.GetEntry(cache.OrasCacheType, hash.String())
 		if err != nil {
-			return "", fmt.Errorf("unable to check if %v exists in cache: %v", hash, err)
+			return "", hash, fmt.Errorf("unable to check if %v exists in cache: %v", hash, err)
 		}
 		defer cacheEntry.CleanTmp()
 		if !cacheEntry.Exists {
 			sylog.Infof("Downloading oras image")
 
 			if err := DownloadImage(ctx, cacheEntry.TmpPath, pullFrom, ociAuth, noHTTPS, reqAuthFile); err != nil {
-				return "", fmt.Errorf("unable to Download Image: %v", err)
+				return "", hash, fmt.Errorf("unable to Download Image: %v", err)
 			}
 			if cacheFileHash, err := ImageHash(cacheEntry.TmpPath); err != nil {
-				return "", fmt.Errorf("error getting ImageHash: %v", err)
+				return "", hash, fmt.Errorf("error getting ImageHash: %v", err)
 			} else if cacheFileHash != hash {
-				return "", fmt.Errorf("cached file hash(%s) and expected hash(%s) does not match", cacheFileHash, hash)
+				return "", hash, fmt.Errorf("cached file hash(%s) and expected hash(%s) does not match", cacheFileHash, hash)
 			}
 
 			err = cacheEntry.Finalize()
 			if err != nil {
-				return "", err
+				return "", hash, err
 			}
 
 		} else {
 			sylog.Infof("Using cached SIF image")
 		}
 		imagePath = cacheEntry.Path
+
+		if err := checkReferrers(ctx, imgCache, imagePath, pullFrom, manifestHash, ociAuth, noHTTPS, reqAuthFile); err != nil {
+			return "", hash, err
+		}
+
+		recordImage(imgCache, cache.ImageRecord{URI: pullFrom, Digest: hash.String()})
+	}
+
+	return imagePath, hash, nil
+}
+
+// recordImage records the digest an image was pulled at in the cache.
+func recordImage(imgCache *cache.Handle, r cache.ImageRecord) {
+	if err := imgCache.RecordImage(r); err != nil {
+		sylog.Warningf("Unable to record digest of %s in cache: %v", r.URI, err)
+	}
+}
+
+// checkReferrers fetches the referrers of the manifest of pullFrom with the
+// digest subject, records them in the cache, and checks them against the
+// referrer policy. The recorded referrers are used when they can't be
+// fetched.
+func checkReferrers(ctx context.Context, imgCache *cache.Handle, imagePath, pullFrom string, subject v1.Hash, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) error {
+	policy, err := currentReferrerPolicy()
+	if err != nil {
+		return err
+	}
+
+	set, err := fetchReferrers(ctx, pullFrom, subject, ociAuth, noHTTPS, reqAuthFile)
+	if err != nil {
+		cached, cerr := cachedReferrers(imgCache, subject)
+		if cerr != nil || cached == nil {
+			if policy.Enforced() {
+				return fmt.Errorf("unable to get referrers of %s: %v", pullFrom, err)
+			}
+			sylog.Debugf("Unable to get referrers of %s: %v", pullFrom, err)
+			return nil
+		}
+		sylog.Warningf("Unable to get referrers of %s, using cached referrers: %v", pullFrom, err)
+		set = cached
+	} else if err := recordReferrers(imgCache, set); err != nil {
+		sylog.Warningf("Unable to record referrers of %s in cache: %v", pullFrom, err)
 	}
 
-	return imagePath, nil
+	if !policy.Enforced() {
+		return nil
+	}
+	if err := policy.Check(ctx, imagePath, set); err != nil {
+		return fmt.Errorf("referrer policy check failed: %w", err)
+	}
+	return nil
+}
+
+// cachedReferrers returns the referrers of the manifest with the digest
+// subject recorded in the cache, or nil if there are none.
+func cachedReferrers(imgCache *cache.Handle, subject v1.Hash) (*ReferrerSet, error) {
+	if imgCache == nil || imgCache.IsDisabled() {
+		return nil, nil
+	}
+	e, err := imgCache.GetEntry(cache.ReferrersCacheType, subject.String())
+	if err != nil {
+		return nil, err
+	}
+	defer e.CleanTmp()
+	if !e.Exists {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(e.Path)
+	if err != nil {
+		return nil, err
+	}
+	set := new(ReferrerSet)
+	if err := json.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("while parsing %s: %v", e.Path, err)
+	}
+	return set, nil
+}
+
+// recordReferrers records the referrers of a manifest in the cache,
+// replacing those previously recorded.
+func recordReferrers(imgCache *cache.Handle, set *ReferrerSet) error {
+	if imgCache == nil || imgCache.IsDisabled() || len(set.Referrers) == 0 {
+		return nil
+	}
+	e, err := imgCache.GetEntry(cache.ReferrersCacheType, set.Subject)
+	if err != nil {
+		return err
+	}
+	if e.Exists {
+		if err := os.Remove(e.Path); err != nil {
+			return err
+		}
+		if e, err = imgCache.GetEntry(cache.ReferrersCacheType, set.Subject); err != nil {
+			return err
+		}
+	}
+	defer e.CleanTmp()
+
+	b, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.TmpPath, b, 0o644); err != nil {
+		return err
+	}
+	return e.Finalize()
 }
 
 // Pull will pull an oras image to the cache or direct to a temporary file if cache is disabled
@@ -80,7 +192,8 @@ func Pull(ctx context.Context, imgCache *cache.Handle, pullFrom, tmpDir string,
 		sylog.Infof("Downloading oras image to tmp cache: %s", directTo)
 	}
 
-	return pull(ctx, imgCache, directTo, pullFrom, ociAuth, noHTTPS, reqAuthFile)
+	imagePath, _, err = pull(ctx, imgCache, directTo, pullFrom, ociAuth, noHTTPS, reqAuthFile)
+	return imagePath, err
 }
 
 // PullToFile will pull an oras image to the specified location, through the cache, or directly if cache is disabled
@@ -91,7 +204,7 @@ func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom st
 		sylog.Debugf("Cache disabled, pulling directly to: %s", directTo)
 	}
 
-	src, err := pull(ctx, imgCache, directTo, pullFrom, ociAuth, noHTTPS, reqAuthFile)
+	src, hash, err := pull(ctx, imgCache, directTo, pullFrom, ociAuth, noHTTPS, reqAuthFile)
 	if err != nil {
 		return "", fmt.Errorf("error fetching image to cache: %v", err)
 	}
@@ -110,5 +223,9 @@ func PullToFile(ctx context.Context, imgCache *cache.Handle, pullTo, pullFrom st
 		}
 	}
 
+	if path, err := filepath.Abs(pullTo); err == nil {
+		recordImage(imgCache, cache.ImageRecord{URI: pullFrom, Path: path, Digest: hash.String()})
+	}
+
 	return pullTo, nil
 }
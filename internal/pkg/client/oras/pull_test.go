@@ -0,0 +1,51 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestPullRecords(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	ref := "oras://" + strings.TrimPrefix(s.URL, "http://") + "/test/image:latest"
+	if err := UploadImage(context.Background(), createSIF(t), ref, nil, true, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imgCache, err := cache.New(cache.Config{ParentDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pullTo := filepath.Join(t.TempDir(), "image.sif")
+	if _, err := PullToFile(context.Background(), imgCache, pullTo, ref, nil, true, "", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hash, err := RefHash(context.Background(), ref, nil, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"", pullTo} {
+		r, err := imgCache.GetImageRecord(ref, path, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r == nil || r.Digest != hash.String() {
+			t.Errorf("got record %+v for path %q, expected digest %s", r, path, hash)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// maxReferrerSize is the maximum size of the artifact fetched from a
+// referrer, referrers being signatures, attestations and SBOMs rather than
+// images.
+const maxReferrerSize = 16 << 20
+
+// Artifact types of the well known artifacts attached to images.
+const (
+	// SPDXArtifactType is the artifact type of an SPDX SBOM.
+	SPDXArtifactType = "application/spdx+json"
+	// CycloneDXArtifactType is the artifact type of a CycloneDX SBOM.
+	CycloneDXArtifactType = "application/vnd.cyclonedx+json"
+	// SigstoreBundleArtifactType is the artifact type of a sigstore bundle,
+	// as attached by cosign.
+	SigstoreBundleArtifactType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+	// InTotoArtifactType is the artifact type of an in-toto attestation,
+	// such as a SLSA provenance.
+	InTotoArtifactType = "application/vnd.in-toto+json"
+)
+
+// artifactTypeAliases are the short names of the well known artifact types.
+var artifactTypeAliases = map[string]string{
+	"sbom":       SPDXArtifactType,
+	"spdx":       SPDXArtifactType,
+	"cyclonedx":  CycloneDXArtifactType,
+	"signature":  SigstoreBundleArtifactType,
+	"provenance": InTotoArtifactType,
+}
+
+// Referrer is an artifact attached to an image, whose manifest refers to the
+// manifest of the image with its subject field.
+type Referrer struct {
+	// ArtifactType is the artifact type of the referrer manifest, also used
+	// as the media type of its single layer.
+	ArtifactType string
+	// Path is the path of the file holding the artifact.
+	Path string
+}
+
+// ParseReferrer parses an artifact to attach, in the form type=path, where
+// type is an artifact type or one of sbom, spdx, cyclonedx, signature and
+// provenance.
+func ParseReferrer(s string) (Referrer, error) {
+	t, path, ok := strings.Cut(s, "=")
+	if !ok || t == "" || path == "" {
+		return Referrer{}, fmt.Errorf("invalid artifact %q, expected type=path", s)
+	}
+	t = ResolveArtifactType(t)
+	if !mediaTypeRegexp.MatchString(t) {
+		return Referrer{}, fmt.Errorf("invalid artifact type %q", t)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Referrer{}, err
+	}
+	if !fi.Mode().IsRegular() {
+		return Referrer{}, fmt.Errorf("artifact %s is not a regular file", path)
+	}
+	return Referrer{ArtifactType: t, Path: path}, nil
+}
+
+// image returns the referrer manifest of the artifact, referring to subject.
+// As the fallback tag scheme uses the config media type for the artifact
+// type of the referrers, it is set to the artifact type as well.
+func (r Referrer) image(subject v1.Descriptor) (*SifImage, error) {
+	return NewImageFromSIF(r.Path, types.MediaType(r.ArtifactType),
+		OptImageArtifactType(r.ArtifactType),
+		OptImageConfigMediaType(r.ArtifactType),
+		OptImageSubject(subject),
+	)
+}
+
+// ResolveArtifactType returns the artifact type named by one of the short
+// names sbom, spdx, cyclonedx, signature and provenance, or t itself.
+func ResolveArtifactType(t string) string {
+	if at, ok := artifactTypeAliases[t]; ok {
+		return at
+	}
+	return t
+}
+
+// FetchedReferrer is an artifact attached to a pulled image.
+type FetchedReferrer struct {
+	ArtifactType string            `json:"artifactType"`
+	Digest       string            `json:"digest"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Data         []byte            `json:"data"`
+}
+
+// ReferrerSet records the artifacts attached to the manifest whose digest is
+// Subject.
+type ReferrerSet struct {
+	Subject   string            `json:"subject"`
+	Referrers []FetchedReferrer `json:"referrers"`
+}
+
+// Has returns whether an artifact of artifactType is attached.
+func (s *ReferrerSet) Has(artifactType string) bool {
+	for _, r := range s.Referrers {
+		if r.ArtifactType == artifactType {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchReferrers returns the artifacts attached to the manifest of ref with
+// the digest subject. Referrers which aren't made of a single layer, or are
+// too large, are skipped.
+func fetchReferrers(ctx context.Context, ref string, subject v1.Hash, ociAuth *authn.AuthConfig, noHTTPS bool, reqAuthFile string) (*ReferrerSet, error) {
+	ir, remoteOpts, err := remoteRef(ctx, ref, ociAuth, noHTTPS, nil, reqAuthFile)
+	if err != nil {
+		return nil, err
+	}
+	repo := ir.Context()
+
+	idx, err := remote.Referrers(repo.Digest(subject.String()), remoteOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("while listing referrers: %w", err)
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("while listing referrers: %w", err)
+	}
+
+	set := &ReferrerSet{Subject: subject.String()}
+	for _, desc := range im.Manifests {
+		r, err := fetchReferrer(repo.Digest(desc.Digest.String()), desc, remoteOpts)
+		if err != nil {
+			return nil, fmt.Errorf("while fetching referrer %s: %w", desc.Digest, err)
+		}
+		if r == nil {
+			sylog.Debugf("Skipping referrer %s of %s (%s)", desc.Digest, subject, desc.ArtifactType)
+			continue
+		}
+		set.Referrers = append(set.Referrers, *r)
+	}
+	return set, nil
+}
+
+// fetchReferrer returns the artifact of the referrer manifest described by
+// desc, or nil if it isn't made of a single layer of at most maxReferrerSize.
+func fetchReferrer(ref name.Reference, desc v1.Descriptor, remoteOpts []remote.Option) (*FetchedReferrer, error) {
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return nil, err
+	}
+	m, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Layers) != 1 || m.Layers[0].Size > maxReferrerSize {
+		return nil, nil
+	}
+	artifactType := desc.ArtifactType
+	if artifactType == "" {
+		artifactType = string(m.Config.MediaType)
+	}
+
+	l, err := img.LayerByDigest(m.Layers[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+	// the digest of the layer is verified when read to the end
+	rc, err := l.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxReferrerSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReferrerSize {
+		return nil, nil
+	}
+
+	return &FetchedReferrer{
+		ArtifactType: artifactType,
+		Digest:       desc.Digest.String(),
+		Annotations:  m.Annotations,
+		Data:         data,
+	}, nil
+}
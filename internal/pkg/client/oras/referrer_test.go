@@ -0,0 +1,218 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oras
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func TestParseReferrer(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sbom.spdx.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		s                string
+		wantArtifactType string
+		wantErr          bool
+	}{
+		{name: "Alias", s: "sbom=" + file, wantArtifactType: SPDXArtifactType},
+		{name: "Signature", s: "signature=" + file, wantArtifactType: SigstoreBundleArtifactType},
+		{name: "MediaType", s: "application/vnd.example+json=" + file, wantArtifactType: "application/vnd.example+json"},
+		{name: "NoType", s: file, wantErr: true},
+		{name: "InvalidType", s: "example=" + file, wantErr: true},
+		{name: "Missing", s: "sbom=" + file + ".missing", wantErr: true},
+		{name: "Directory", s: "sbom=" + filepath.Dir(file), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseReferrer(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (r.ArtifactType != tt.wantArtifactType || r.Path != file) {
+				t.Errorf("got %+v", r)
+			}
+		})
+	}
+}
+
+func createSIF(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "image.sif")
+	di, err := sif.NewDescriptorInput(sif.DataGeneric, strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := sif.CreateContainerAtPath(path, sif.OptCreateWithDescriptors(di))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.UnloadContainer(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadImageReferrers(t *testing.T) {
+	sbom := filepath.Join(t.TempDir(), "sbom.spdx.json")
+	if err := os.WriteFile(sbom, []byte(`{"spdxVersion":"SPDX-2.3"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseReferrer("sbom=" + sbom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := createSIF(t)
+
+	tests := []struct {
+		name      string
+		referrers bool
+	}{
+		{name: "ReferrersAPI", referrers: true},
+		{name: "FallbackTag", referrers: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(registry.New(registry.WithReferrersSupport(tt.referrers), registry.Logger(log.New(io.Discard, "", 0))))
+			defer s.Close()
+			ref := strings.TrimPrefix(s.URL, "http://") + "/test/image:latest"
+
+			err := UploadImage(context.Background(), path, "oras://"+ref, nil, true, "", OptImageReferrers(r))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tag, err := name.ParseReference(ref, name.Insecure)
+			if err != nil {
+				t.Fatal(err)
+			}
+			desc, err := remote.Head(tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			idx, err := remote.Referrers(tag.Context().Digest(desc.Digest.String()))
+			if err != nil {
+				t.Fatalf("while getting referrers: %v", err)
+			}
+			m, err := idx.IndexManifest()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.Manifests) != 1 {
+				t.Fatalf("got %d referrers, expected 1", len(m.Manifests))
+			}
+			if m.Manifests[0].ArtifactType != SPDXArtifactType {
+				t.Errorf("got artifact type %q, expected %q", m.Manifests[0].ArtifactType, SPDXArtifactType)
+			}
+
+			img, err := remote.Image(tag.Context().Digest(m.Manifests[0].Digest.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			layers, err := img.Layers()
+			if err != nil || len(layers) != 1 {
+				t.Fatalf("got %d layers, error %v", len(layers), err)
+			}
+			rc, err := layers[0].Compressed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			b, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != `{"spdxVersion":"SPDX-2.3"}` {
+				t.Errorf("unexpected referrer content %s", b)
+			}
+		})
+	}
+}
+
+func TestPullReferrers(t *testing.T) {
+	sbom := filepath.Join(t.TempDir(), "sbom.spdx.json")
+	if err := os.WriteFile(sbom, []byte(`{"spdxVersion":"SPDX-2.3"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseReferrer("sbom=" + sbom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	defer s.Close()
+	ref := "oras://" + strings.TrimPrefix(s.URL, "http://") + "/test/image:latest"
+	if err := UploadImage(context.Background(), createSIF(t), ref, nil, true, "", OptImageReferrers(r)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imgCache, err := cache.New(cache.Config{ParentDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer apptainerconf.SetCurrentConfig(apptainerconf.GetCurrentConfig())
+
+	tests := []struct {
+		name     string
+		required []string
+		wantErr  bool
+	}{
+		{name: "NoPolicy"},
+		{name: "RequiredPresent", required: []string{"sbom"}},
+		{name: "RequiredMissing", required: []string{"sbom", "provenance"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apptainerconf.SetCurrentConfig(&apptainerconf.File{OrasRequiredReferrers: tt.required})
+
+			_, err := Pull(context.Background(), imgCache, ref, t.TempDir(), nil, true, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	manifestHash, _, err := refHashes(context.Background(), ref, nil, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := cachedReferrers(imgCache, manifestHash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set == nil || !set.Has(SPDXArtifactType) || len(set.Referrers) != 1 {
+		t.Fatalf("unexpected cached referrers %+v", set)
+	}
+	if string(set.Referrers[0].Data) != `{"spdxVersion":"SPDX-2.3"}` {
+		t.Errorf("unexpected cached referrer content %s", set.Referrers[0].Data)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package p2p routes the layer requests of OCI image pulls through the local
+// agent of a peer-to-peer distribution system, such as Dragonfly or Kraken,
+// as configured by the administrator in apptainer.conf.
+package p2p
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+const (
+	// None disables peer-to-peer distribution.
+	None = "none"
+	// Proxy uses the agent as an HTTP proxy.
+	Proxy = "proxy"
+	// Mirror uses the agent as a registry mirror.
+	Mirror = "mirror"
+)
+
+// blobPath matches the path of a registry blob request. Blobs are content
+// addressed, so they can be safely shared between peers.
+var blobPath = regexp.MustCompile(`^/v2/.+/blobs/sha256:[a-f0-9]{64}$`)
+
+// Config holds the peer-to-peer agent configuration.
+type Config struct {
+	// Mode is one of None, Proxy or Mirror.
+	Mode string
+	// URL is the URL of the agent.
+	URL *url.URL
+	// Registries is a list of registry hosts, or shell patterns, whose
+	// blobs are fetched through the agent. When empty, the agent is used
+	// for all registries.
+	Registries []string
+}
+
+// ConfigFromFile returns the peer-to-peer configuration set in cfg, a nil
+// configuration is returned when peer-to-peer distribution is disabled.
+func ConfigFromFile(cfg *apptainerconf.File) (*Config, error) {
+	if cfg == nil || cfg.P2PProxy == "" || cfg.P2PProxy == None {
+		return nil, nil
+	}
+	if cfg.P2PProxy != Proxy && cfg.P2PProxy != Mirror {
+		return nil, fmt.Errorf("invalid p2p proxy %q: must be one of %s, %s or %s", cfg.P2PProxy, None, Proxy, Mirror)
+	}
+	if cfg.P2PProxyURL == "" {
+		return nil, fmt.Errorf("p2p proxy url is required when p2p proxy is %s", cfg.P2PProxy)
+	}
+	u, err := url.Parse(cfg.P2PProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p2p proxy url %q: %v", cfg.P2PProxyURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid p2p proxy url %q: expected http(s)://host[:port]", cfg.P2PProxyURL)
+	}
+	return &Config{
+		Mode:       cfg.P2PProxy,
+		URL:        u,
+		Registries: cfg.P2PProxyRegistries,
+	}, nil
+}
+
+// CurrentConfig returns the peer-to-peer configuration set in the current
+// configuration.
+func CurrentConfig() (*Config, error) {
+	return ConfigFromFile(apptainerconf.GetCurrentConfig())
+}
+
+// matchRegistry returns whether the registry host is configured to be
+// fetched through the agent.
+func (c *Config) matchRegistry(host string) bool {
+	if len(c.Registries) == 0 {
+		return true
+	}
+	hostname := host
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		hostname = h
+	}
+	for _, pattern := range c.Registries {
+		pattern = strings.TrimSpace(pattern)
+		// Docker Hub is referred to as docker.io, but served by
+		// registry-1.docker.io.
+		if pattern == "docker.io" && hostname == "registry-1.docker.io" {
+			return true
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, hostname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport is an http.RoundTripper sending registry blob requests through
+// the peer-to-peer agent, falling back to a direct request when the agent
+// fails. Other requests are sent directly.
+type Transport struct {
+	cfg   *Config
+	inner http.RoundTripper
+	agent http.RoundTripper
+}
+
+// NewTransport wraps inner (or http.DefaultTransport if inner is nil) to fetch
+// blobs through the agent configured by cfg. inner is returned unmodified if
+// cfg is nil.
+func NewTransport(cfg *Config, inner http.RoundTripper) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	if cfg == nil {
+		return inner
+	}
+
+	agent := http.DefaultTransport.(*http.Transport).Clone()
+	if it, ok := inner.(*http.Transport); ok {
+		agent = it.Clone()
+	}
+	if cfg.Mode == Proxy {
+		agent.Proxy = http.ProxyURL(cfg.URL)
+	} else {
+		agent.Proxy = nil
+	}
+
+	return &Transport{
+		cfg:   cfg,
+		inner: inner,
+		agent: agent,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || !blobPath.MatchString(req.URL.Path) || !t.cfg.matchRegistry(req.URL.Host) {
+		return t.inner.RoundTrip(req)
+	}
+
+	agentReq := req.Clone(req.Context())
+	if t.cfg.Mode == Mirror {
+		// Dragonfly needs the origin registry, Kraken ignores it.
+		agentReq.Header.Set("X-Dragonfly-Registry", req.URL.Scheme+"://"+req.URL.Host)
+		agentReq.URL.Scheme = t.cfg.URL.Scheme
+		agentReq.URL.Host = t.cfg.URL.Host
+		agentReq.Host = ""
+	}
+
+	sylog.Debugf("Fetching %s through p2p %s %s", req.URL, t.cfg.Mode, t.cfg.URL)
+	res, err := t.agent.RoundTrip(agentReq)
+	if err == nil && res.StatusCode < http.StatusInternalServerError && res.StatusCode != http.StatusNotFound {
+		return res, nil
+	}
+	if err == nil {
+		res.Body.Close()
+		err = fmt.Errorf("%s", res.Status)
+	}
+	sylog.Warningf("p2p %s failed, fetching %s from registry: %v", t.cfg.Mode, path.Base(req.URL.Path), err)
+	return t.inner.RoundTrip(req)
+}
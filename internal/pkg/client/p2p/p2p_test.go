@@ -0,0 +1,156 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package p2p
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+const blob = "/v2/library/alpine/blobs/sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestConfigFromFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *apptainerconf.File
+		expectCfg bool
+		expectErr bool
+	}{
+		{"nil", nil, false, false},
+		{"none", &apptainerconf.File{P2PProxy: None, P2PProxyURL: "http://127.0.0.1:65001"}, false, false},
+		{"proxy", &apptainerconf.File{P2PProxy: Proxy, P2PProxyURL: "http://127.0.0.1:65001"}, true, false},
+		{"mirror", &apptainerconf.File{P2PProxy: Mirror, P2PProxyURL: "http://127.0.0.1:16000"}, true, false},
+		{"missing url", &apptainerconf.File{P2PProxy: Proxy}, false, true},
+		{"invalid url", &apptainerconf.File{P2PProxy: Mirror, P2PProxyURL: "127.0.0.1:16000"}, false, true},
+		{"invalid mode", &apptainerconf.File{P2PProxy: "bittorrent", P2PProxyURL: "http://127.0.0.1:65001"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ConfigFromFile(tt.cfg)
+			if tt.expectErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			} else if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectCfg != (cfg != nil) {
+				t.Errorf("got configuration %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestMatchRegistry(t *testing.T) {
+	cfg := &Config{Registries: []string{"docker.io", "*.example.org", "localhost:5000"}}
+
+	tests := []struct {
+		host  string
+		match bool
+	}{
+		{"registry-1.docker.io", true},
+		{"registry.example.org", true},
+		{"registry.example.org:443", true},
+		{"localhost:5000", true},
+		{"localhost:5001", false},
+		{"quay.io", false},
+	}
+	for _, tt := range tests {
+		if m := cfg.matchRegistry(tt.host); m != tt.match {
+			t.Errorf("matchRegistry(%q) = %v, expected %v", tt.host, m, tt.match)
+		}
+	}
+
+	if !(&Config{}).matchRegistry("quay.io") {
+		t.Errorf("expected any registry to match an empty list")
+	}
+}
+
+func get(t *testing.T, rt http.RoundTripper, u string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestTransport(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "registry")
+	}))
+	defer registry.Close()
+
+	var agentReq *http.Request
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agentReq = r
+		io.WriteString(w, "agent")
+	}))
+	defer agent.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	agentURL, _ := url.Parse(agent.URL)
+	brokenURL, _ := url.Parse(broken.URL)
+	registryHost := strings.TrimPrefix(registry.URL, "http://")
+
+	tests := []struct {
+		name       string
+		cfg        *Config
+		path       string
+		expectBody string
+	}{
+		{"disabled", nil, blob, "registry"},
+		{"proxy blob", &Config{Mode: Proxy, URL: agentURL}, blob, "agent"},
+		{"proxy manifest", &Config{Mode: Proxy, URL: agentURL}, "/v2/library/alpine/manifests/latest", "registry"},
+		{"mirror blob", &Config{Mode: Mirror, URL: agentURL}, blob, "agent"},
+		{"unmatched registry", &Config{Mode: Mirror, URL: agentURL, Registries: []string{"quay.io"}}, blob, "registry"},
+		{"agent failure", &Config{Mode: Mirror, URL: brokenURL}, blob, "registry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agentReq = nil
+			rt := NewTransport(tt.cfg, nil)
+			if body := get(t, rt, registry.URL+tt.path); body != tt.expectBody {
+				t.Fatalf("got response from %s, expected %s", body, tt.expectBody)
+			}
+			if agentReq == nil {
+				return
+			}
+			if agentReq.URL.Path != tt.path {
+				t.Errorf("agent got path %s, expected %s", agentReq.URL.Path, tt.path)
+			}
+			switch tt.cfg.Mode {
+			case Proxy:
+				if agentReq.URL.Host != registryHost {
+					t.Errorf("proxy got request for host %s, expected %s", agentReq.URL.Host, registryHost)
+				}
+			case Mirror:
+				if r := agentReq.Header.Get("X-Dragonfly-Registry"); r != registry.URL {
+					t.Errorf("mirror got registry %s, expected %s", r, registry.URL)
+				}
+			}
+		})
+	}
+}
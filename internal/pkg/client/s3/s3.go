@@ -0,0 +1,411 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package s3 implements an object storage backend for Amazon S3, and S3
+// compatible object storage such as MinIO and Ceph.
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	// defaultRegion is used when no region is configured.
+	defaultRegion = "us-east-1"
+	// unsignedPayload is used as payload hash so that objects don't have to
+	// be read twice to be uploaded.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// maxPutSize is the maximum size of an object uploaded by a single PUT.
+	maxPutSize = 5 << 30
+	// Timeout for an object transfer in seconds - could be a large image...
+	transferTimeout = 7200
+)
+
+// Credentials holds the AWS credentials used to sign requests.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Config holds the configuration of the S3 client.
+type Config struct {
+	// Credentials are used to sign requests, anonymous requests are made
+	// when nil.
+	Credentials *Credentials
+	// Region is the region of the buckets.
+	Region string
+	// Endpoint overrides the AWS S3 endpoint, e.g. for MinIO or Ceph. Path
+	// style requests are used with a custom endpoint.
+	Endpoint string
+}
+
+// DefaultConfig returns a configuration resolved like the AWS SDKs do, from
+// the AWS_ environment variables then from the shared credentials and
+// config files for the selected AWS_PROFILE.
+func DefaultConfig() (*Config, error) {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+
+	cfg := &Config{
+		Region:   os.Getenv("AWS_REGION"),
+		Endpoint: os.Getenv("AWS_ENDPOINT_URL_S3"),
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		cfg.Credentials = &Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+
+	awsDir := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		awsDir = filepath.Join(home, ".aws")
+	}
+
+	if cfg.Credentials == nil {
+		credFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+		if credFile == "" && awsDir != "" {
+			credFile = filepath.Join(awsDir, "credentials")
+		}
+		section, err := readProfile(credFile, profile)
+		if err != nil {
+			return nil, err
+		}
+		if section["aws_access_key_id"] != "" && section["aws_secret_access_key"] != "" {
+			cfg.Credentials = &Credentials{
+				AccessKeyID:     section["aws_access_key_id"],
+				SecretAccessKey: section["aws_secret_access_key"],
+				SessionToken:    section["aws_session_token"],
+			}
+		}
+	}
+
+	if cfg.Region == "" || cfg.Endpoint == "" {
+		configFile := os.Getenv("AWS_CONFIG_FILE")
+		if configFile == "" && awsDir != "" {
+			configFile = filepath.Join(awsDir, "config")
+		}
+		name := profile
+		if profile != "default" {
+			name = "profile " + profile
+		}
+		section, err := readProfile(configFile, name)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Region == "" {
+			cfg.Region = section["region"]
+		}
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = section["endpoint_url"]
+		}
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	if cfg.Credentials == nil {
+		sylog.Debugf("No AWS credentials found, using anonymous S3 requests")
+	}
+	return cfg, nil
+}
+
+// readProfile returns the key / value pairs of the named section of an AWS
+// shared configuration file. A missing file is not an error.
+func readProfile(path, name string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while reading %s: %v", path, err)
+	}
+	defer f.Close()
+
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == name
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("while reading %s: %v", path, err)
+	}
+	return values, nil
+}
+
+// Client is a minimal S3 client implementing objstore.Backend.
+type Client struct {
+	cfg        *Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewClient returns a client using the given configuration.
+func NewClient(cfg *Config) *Client {
+	return &Client{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: transferTimeout * time.Second,
+		},
+		now: time.Now,
+	}
+}
+
+// objectURL returns the URL of the referenced object, using virtual hosted
+// style on AWS and path style on custom endpoints.
+func (c *Client) objectURL(ref objstore.Ref) (*url.URL, error) {
+	if c.cfg.Endpoint != "" {
+		u, err := url.Parse(c.cfg.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %q: %v", c.cfg.Endpoint, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q: expected scheme://host[:port]", c.cfg.Endpoint)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + ref.Bucket + "/" + ref.Key
+		u.RawPath = escapePath(u.Path)
+		return u, nil
+	}
+	host := ref.Bucket + ".s3." + c.cfg.Region + ".amazonaws.com"
+	path := "/" + ref.Key
+	// Bucket names containing dots don't match the wildcard certificate
+	if strings.Contains(ref.Bucket, ".") {
+		host = "s3." + c.cfg.Region + ".amazonaws.com"
+		path = "/" + ref.Bucket + path
+	}
+	return &url.URL{Scheme: "https", Host: host, Path: path, RawPath: escapePath(path)}, nil
+}
+
+// escapePath URI-encodes every byte of path except unreserved characters
+// and slashes, as required by the signature canonical request.
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// newRequest returns a signed request for the referenced object.
+func (c *Client) newRequest(ctx context.Context, method string, ref objstore.Ref, body io.Reader) (*http.Request, error) {
+	u, err := c.objectURL(ref)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", useragent.Value())
+	if c.cfg.Credentials != nil {
+		c.sign(req)
+	}
+	return req, nil
+}
+
+// Stat returns the metadata of the referenced object.
+func (c *Client) Stat(ctx context.Context, ref objstore.Ref) (objstore.ObjectInfo, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, ref, nil)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res, ref); err != nil {
+		return objstore.ObjectInfo{}, err
+	}
+	return objstore.ObjectInfo{Size: res.ContentLength, ETag: res.Header.Get("ETag")}, nil
+}
+
+// GetRange returns length bytes of the referenced object starting at offset,
+// or the whole object when length is negative.
+func (c *Client) GetRange(ctx context.Context, ref objstore.Ref, offset, length int64) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(res, ref); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Put uploads size bytes read from r to the referenced object.
+func (c *Client) Put(ctx context.Context, ref objstore.Ref, r io.Reader, size int64) error {
+	if c.cfg.Credentials == nil {
+		return fmt.Errorf("no AWS credentials found to push %s", ref)
+	}
+	if size > maxPutSize {
+		return fmt.Errorf("image size %d exceeds the maximum size of %d bytes for a S3 upload", size, int64(maxPutSize))
+	}
+	req, err := c.newRequest(ctx, http.MethodPut, ref, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkResponse(res, ref)
+}
+
+// checkResponse returns an error for unsuccessful responses, including the
+// S3 error message when available.
+func checkResponse(res *http.Response, ref objstore.Ref) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("object %s was not found", ref)
+	}
+	buf := new(bytes.Buffer)
+	io.Copy(buf, io.LimitReader(res.Body, 4096))
+	msg := buf.String()
+	if start, end := strings.Index(msg, "<Message>"), strings.Index(msg, "</Message>"); start >= 0 && end > start {
+		msg = msg[start+len("<Message>") : end]
+	}
+	return fmt.Errorf("request for %s failed: %s %s", ref, res.Status, strings.TrimSpace(msg))
+}
+
+// sign adds an AWS signature version 4 Authorization header to req.
+func (c *Client) sign(req *http.Request) {
+	creds := c.cfg.Credentials
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lname := strings.ToLower(name)
+		if lname == "x-amz-date" || lname == "x-amz-content-sha256" || lname == "x-amz-security-token" {
+			headers[lname] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := signingKey(creds.SecretAccessKey, date, c.cfg.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// signingKey derives the signature version 4 signing key.
+func signingKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	k = hmacSHA256(k, []byte(region))
+	k = hmacSHA256(k, []byte(service))
+	return hmacSHA256(k, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
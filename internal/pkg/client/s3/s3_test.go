@@ -0,0 +1,172 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package s3
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/client/objstore"
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS signature version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	expected := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != expected {
+		t.Errorf("got signing key %s, expected %s", got, expected)
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		ref      objstore.Ref
+		expected string
+	}{
+		{"aws", Config{Region: "eu-west-1"}, objstore.Ref{Transport: objstore.S3, Bucket: "bucket", Key: "a/b c.sif"}, "https://bucket.s3.eu-west-1.amazonaws.com/a/b%20c.sif"},
+		{"aws dotted bucket", Config{Region: "us-east-1"}, objstore.Ref{Transport: objstore.S3, Bucket: "my.bucket", Key: "img.sif"}, "https://s3.us-east-1.amazonaws.com/my.bucket/img.sif"},
+		{"custom endpoint", Config{Region: "us-east-1", Endpoint: "http://minio:9000"}, objstore.Ref{Transport: objstore.S3, Bucket: "bucket", Key: "img+1.sif"}, "http://minio:9000/bucket/img%2B1.sif"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewClient(&tt.cfg).objectURL(tt.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if u.String() != tt.expected {
+				t.Errorf("got %s, expected %s", u, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	config := filepath.Join(dir, "config")
+	if err := os.WriteFile(credentials, []byte("[default]\naws_access_key_id = default\naws_secret_access_key = secret\n\n[test]\naws_access_key_id = test\naws_secret_access_key = testsecret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config, []byte("[default]\nregion = eu-west-1\n\n[profile test]\nregion = eu-central-1\nendpoint_url = http://minio:9000\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_S3"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", config)
+	t.Setenv("AWS_PROFILE", "test")
+
+	cfg, err := DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Credentials == nil || cfg.Credentials.AccessKeyID != "test" || cfg.Credentials.SecretAccessKey != "testsecret" {
+		t.Errorf("unexpected credentials %+v", cfg.Credentials)
+	}
+	if cfg.Region != "eu-central-1" || cfg.Endpoint != "http://minio:9000" {
+		t.Errorf("unexpected region %q and endpoint %q", cfg.Region, cfg.Endpoint)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "env")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+	t.Setenv("AWS_REGION", "us-west-2")
+	cfg, err = DefaultConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Credentials == nil || cfg.Credentials.AccessKeyID != "env" {
+		t.Errorf("unexpected credentials %+v", cfg.Credentials)
+	}
+	if cfg.Region != "us-west-2" {
+		t.Errorf("unexpected region %q", cfg.Region)
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, "<Error><Message>Access Denied</Message></Error>")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = b
+		case http.MethodHead, http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", `"etag"`)
+			w.Write(b)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	ref := objstore.Ref{Transport: objstore.S3, Bucket: "bucket", Key: "image.sif"}
+	c := NewClient(&Config{
+		Credentials: &Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+		Region:      defaultRegion,
+		Endpoint:    srv.URL,
+	})
+
+	if _, err := c.Stat(ctx, ref); err == nil {
+		t.Fatalf("expected error for missing object")
+	}
+
+	content := "image content"
+	if err := c.Put(ctx, ref, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+
+	info, err := c.Stat(ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected stat error: %v", err)
+	}
+	if info.ETag != `"etag"` || info.Size != int64(len(content)) {
+		t.Errorf("got %+v, expected size %d and etag %s", info, len(content), `"etag"`)
+	}
+
+	body, err := c.GetRange(ctx, ref, 0, -1)
+	if err != nil {
+		t.Fatalf("unexpected get error: %v", err)
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != content {
+		t.Errorf("got content %q, expected %q", b, content)
+	}
+
+	anonymous := NewClient(&Config{Region: defaultRegion, Endpoint: srv.URL})
+	if _, err := anonymous.GetRange(ctx, ref, 0, -1); err == nil || !strings.Contains(err.Error(), "Access Denied") {
+		t.Errorf("expected access denied error, got %v", err)
+	}
+}
@@ -123,8 +123,11 @@ func InitImageDrivers(register, unprivileged bool, fileconf *apptainerconf.File,
 		}
 	}
 	// Always initialize the OverlayFeature because the kernel overlay
-	// doesn't like using FUSE for lower or upper layers.
-	if overlayFeature.init("fuse-overlayfs", "use FUSE overlay", desiredFeatures&image.OverlayFeature) {
+	// doesn't like using FUSE or network filesystems for lower or upper
+	// layers, unless the kernel overlay is enforced by configuration.
+	if fileconf.EnableOverlay == "kernel" {
+		sylog.Debugf("fuse-overlayfs mounting not enabled because of 'enable overlay = kernel'")
+	} else if overlayFeature.init("fuse-overlayfs", "use FUSE overlay", desiredFeatures&image.OverlayFeature) {
 		features |= image.OverlayFeature
 	}
 	// gocryptfs is always available
@@ -92,7 +92,7 @@ func (g *Gocryptfs) init(tmpDir string) (cryptInfo *cryptInfo, err error) {
 	}
 
 	switch g.keyInfo.Format {
-	case cryptkey.PEM, cryptkey.ENV:
+	case cryptkey.PEM, cryptkey.ENV, cryptkey.Age, cryptkey.PKCS11:
 		// #nosec G401
 		hash := md5.Sum(buf)
 		cryptInfo.pass = hex.EncodeToString(hash[:])
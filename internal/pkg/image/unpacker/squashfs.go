@@ -60,6 +60,9 @@ func unsquashfsCmd(unsquashfs string, dest string, filename string, filter strin
 // Squashfs represents a squashfs unpacker.
 type Squashfs struct {
 	UnsquashfsPath string
+	// Processors is the number of processors used by unsquashfs, all of
+	// them when 0.
+	Processors uint
 }
 
 // NewSquashfs initializes and returns a Squahfs unpacker instance
@@ -133,6 +136,10 @@ func (s *Squashfs) extract(files []string, reader io.Reader, dest string) (err e
 		opts = append(opts, "-no-xattrs")
 	}
 
+	if s.Processors != 0 {
+		opts = append(opts, "-processors", fmt.Sprint(s.Processors))
+	}
+
 	// non real root users could not create pseudo devices so we compare
 	// the host UID (to include fake root user) and apply a filter at extraction (#5690)
 	filter := ""
@@ -10,8 +10,12 @@
 package instance
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -48,14 +52,18 @@ type File struct {
 	Name        string `json:"name"`
 	User        string `json:"user"`
 	Image       string `json:"image"`
+	ImageDigest string `json:"imageDigest,omitempty"`
 	Config      []byte `json:"config"`
 	UserNs      bool   `json:"userns"`
 	Cgroup      bool   `json:"cgroup"`
 	IP          string `json:"ip"`
+	MAC         string `json:"mac,omitempty"`
 	LogErrPath  string `json:"logErrPath"`
 	LogOutPath  string `json:"logOutPath"`
+	LogDriver   string `json:"logDriver,omitempty"`
 	Checkpoint  string `json:"checkpoint"`
 	ShareNSMode bool   `json:"sharensMode"`
+	CreatedAt   int64  `json:"createdAt,omitempty"`
 }
 
 // ProcName returns process name based on instance name
@@ -201,6 +209,27 @@ func List(username string, name string, subDir string, all bool) ([]*File, error
 	return list, nil
 }
 
+// CheckAddress returns an error if the IP or MAC address is already
+// assigned to a running instance of the current user
+func CheckAddress(ip string, mac string, subDir string) error {
+	list, err := List("", "*", subDir, true)
+	if err != nil {
+		return fmt.Errorf("could not list instances: %s", err)
+	}
+	for _, i := range list {
+		if i.isExited() {
+			continue
+		}
+		if ip != "" && i.IP != "" && net.ParseIP(ip).Equal(net.ParseIP(i.IP)) {
+			return fmt.Errorf("IP address %s is already assigned to instance %s", ip, i.Name)
+		}
+		if mac != "" && i.MAC != "" && strings.EqualFold(mac, i.MAC) {
+			return fmt.Errorf("MAC address %s is already assigned to instance %s", mac, i.Name)
+		}
+	}
+	return nil
+}
+
 // Delete deletes instance file
 func (i *File) Delete() error {
 	dir := filepath.Dir(i.Path)
@@ -271,6 +300,81 @@ func (i *File) Update() error {
 	return file.Sync()
 }
 
+// imageDigestDir is the directory in the user configuration directory
+// caching the digests of image files.
+const imageDigestDir = "image-digests"
+
+// imageDigestEntry is the cached digest of an image file, valid as long as
+// the file keeps its size and modification time.
+type imageDigestEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTime"`
+	Digest  string `json:"digest"`
+}
+
+// ImageDigest returns the sha256 digest of an image file, or an empty
+// digest for a sandbox image. The digest is cached until the image file
+// changes, so that the file is not read on each launch.
+func ImageDigest(path string) (string, error) {
+	return imageDigest(path, filepath.Join(syfs.ConfigDir(), imageDigestDir))
+}
+
+// imageDigest returns the sha256 digest of an image file, cached in the
+// directory cacheDir.
+func imageDigest(path, cacheDir string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", nil
+	}
+
+	sum := sha256.Sum256([]byte(path))
+	cacheFile := filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".json")
+	entry := imageDigestEntry{Path: path, Size: fi.Size(), ModTime: fi.ModTime().UnixNano()}
+	if b, err := os.ReadFile(cacheFile); err == nil {
+		var cached imageDigestEntry
+		if err := json.Unmarshal(b, &cached); err == nil && cached.Digest != "" &&
+			cached.Path == entry.Path && cached.Size == entry.Size && cached.ModTime == entry.ModTime {
+			return cached.Digest, nil
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("while computing digest of %s: %s", path, err)
+	}
+	entry.Digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	if err := saveImageDigest(cacheFile, &entry); err != nil {
+		sylog.Debugf("Could not cache digest of %s: %s", path, err)
+	}
+	return entry.Digest, nil
+}
+
+// saveImageDigest writes the cached digest entry to the file path.
+func saveImageDigest(path string, entry *imageDigestEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
 // GetLogFilePaths returns the paths of log files containing
 // .err, .out streams, respectively
 func GetLogFilePaths(name string, subDir string) (string, string, error) {
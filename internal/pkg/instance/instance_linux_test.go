@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/apptainer/apptainer/internal/pkg/test"
 )
@@ -255,6 +256,97 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestCheckAddress(t *testing.T) {
+	test.EnsurePrivilege(t)
+
+	file, err := Add("address", testSubDir)
+	if err != nil {
+		t.Fatalf("unexpected failure while adding instance: %s", err)
+	}
+	file.User = "root"
+	file.PPid = fakeInstancePid
+	file.Pid = os.Getpid()
+	file.IP = "10.22.0.10"
+	file.MAC = "02:42:ac:11:00:02"
+	if err := file.Update(); err != nil {
+		t.Fatalf("error while creating instance: %s", err)
+	}
+	defer file.Delete()
+
+	tests := []struct {
+		desc          string
+		ip            string
+		mac           string
+		expectFailure bool
+	}{
+		{desc: "free addresses", ip: "10.22.0.11", mac: "02:42:ac:11:00:03"},
+		{desc: "no address"},
+		{desc: "used IP", ip: "10.22.0.10", expectFailure: true},
+		{desc: "used MAC", mac: "02:42:AC:11:00:02", expectFailure: true},
+	}
+	for _, e := range tests {
+		err := CheckAddress(e.ip, e.mac, testSubDir)
+		if err != nil && !e.expectFailure {
+			t.Errorf("unexpected failure for %s: %s", e.desc, err)
+		} else if err == nil && e.expectFailure {
+			t.Errorf("unexpected success for %s", e.desc)
+		}
+	}
+}
+
+func TestImageDigest(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	image := filepath.Join(dir, "image.sif")
+	if err := os.WriteFile(image, []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1000, 0)
+	if err := os.Chtimes(image, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	// sha256 of "image"
+	const digest = "sha256:6105d6cc76af400325e94d588ce511be5bfdbb73b437dc51eca43917d7a43e3d"
+	got, err := imageDigest(image, cacheDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != digest {
+		t.Errorf("got digest %s, expected %s", got, digest)
+	}
+
+	// the cached digest is returned while the size and modification
+	// time are unchanged
+	if err := os.WriteFile(image, []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(image, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = imageDigest(image, cacheDir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got != digest {
+		t.Errorf("got digest %s, expected cached %s", got, digest)
+	}
+
+	// and computed again once the image changes
+	mtime = mtime.Add(time.Second)
+	if err := os.Chtimes(image, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = imageDigest(image, cacheDir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if got == digest {
+		t.Errorf("got stale digest %s", got)
+	}
+
+	// sandbox images have no digest
+	if got, err = imageDigest(dir, cacheDir); err != nil || got != "" {
+		t.Errorf("got digest %q and error %v for a directory, expected none", got, err)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// spawn a fake instance process
 	cmd := exec.Command("cat")
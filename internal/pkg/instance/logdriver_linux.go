@@ -0,0 +1,138 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package instance
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	// FileLogDriver writes instance output streams to log files.
+	FileLogDriver = "file"
+	// SyslogLogDriver sends instance output streams to syslog.
+	SyslogLogDriver = "syslog"
+	// JournaldLogDriver sends instance output streams to the systemd journal.
+	JournaldLogDriver = "journald"
+	// NoneLogDriver discards instance output streams.
+	NoneLogDriver = "none"
+)
+
+// journalStreamSocket is the socket of the journald stream protocol.
+const journalStreamSocket = "/run/systemd/journal/stdout"
+
+// syslog priorities of the instance output streams.
+const (
+	stdoutPriority = 6 // info
+	stderrPriority = 3 // err
+)
+
+// CheckLogDriver returns an error if the log driver is not supported.
+func CheckLogDriver(driver string) error {
+	switch driver {
+	case FileLogDriver, SyslogLogDriver, JournaldLogDriver, NoneLogDriver:
+		return nil
+	}
+	return fmt.Errorf("unknown instance log driver %q, must be one of %s, %s, %s or %s",
+		driver, FileLogDriver, SyslogLogDriver, JournaldLogDriver, NoneLogDriver)
+}
+
+// LogIdentifier returns the syslog identifier of the output streams of an
+// instance.
+func LogIdentifier(name string) string {
+	return "apptainer-" + name
+}
+
+// SetLogDriver returns the stdout/stderr streams of an instance for a log
+// driver: log files for the file driver, or streams sent to syslog or to
+// the systemd journal with the instance log identifier.
+func SetLogDriver(driver, name string, userNs bool, uid int, subDir string) (*os.File, *os.File, error) {
+	var stdout, stderr *os.File
+	var err error
+
+	switch driver {
+	case FileLogDriver:
+		return SetLogFile(name, userNs, uid, subDir)
+	case NoneLogDriver:
+		if stdout, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0); err != nil {
+			return nil, nil, err
+		}
+		if stderr, err = os.OpenFile(os.DevNull, os.O_WRONLY, 0); err != nil {
+			return nil, nil, err
+		}
+	case JournaldLogDriver:
+		if stdout, err = journalStream(LogIdentifier(name), stdoutPriority); err != nil {
+			return nil, nil, err
+		}
+		if stderr, err = journalStream(LogIdentifier(name), stderrPriority); err != nil {
+			return nil, nil, err
+		}
+	case SyslogLogDriver:
+		if stdout, err = syslogStream(LogIdentifier(name), "user.info"); err != nil {
+			return nil, nil, err
+		}
+		if stderr, err = syslogStream(LogIdentifier(name), "user.err"); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, CheckLogDriver(driver)
+	}
+	return stdout, stderr, nil
+}
+
+// journalStream returns a stream connected to journald, each line written
+// to it being logged with the identifier and priority, like systemd-cat.
+func journalStream(identifier string, priority int) (*os.File, error) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: journalStreamSocket, Net: "unix"})
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to journald: %s", err)
+	}
+	defer conn.Close()
+
+	if err := conn.CloseRead(); err != nil {
+		return nil, fmt.Errorf("could not connect to journald: %s", err)
+	}
+	// identifier, unit ID, priority, level prefix, forward to syslog,
+	// forward to kmsg and forward to console
+	header := fmt.Sprintf("%s\n\n%d\n0\n0\n0\n0\n", identifier, priority)
+	if _, err := conn.Write([]byte(header)); err != nil {
+		return nil, fmt.Errorf("could not initialize journald stream: %s", err)
+	}
+	return conn.File()
+}
+
+// syslogStream returns a stream whose lines are sent to syslog with the
+// identifier and priority by a logger process, which exits once the
+// instance closes the stream.
+func syslogStream(identifier, priority string) (*os.File, error) {
+	logger, err := exec.LookPath("logger")
+	if err != nil {
+		return nil, fmt.Errorf("logger command required by the syslog log driver not found: %s", err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	cmd := exec.Command(logger, "-t", identifier, "-p", priority)
+	cmd.Stdin = r
+	// detach from the terminal session, the logger process outlives the
+	// instance start command
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("could not start logger: %s", err)
+	}
+	// the logger process is reparented once the instance start command exits
+	go cmd.Wait()
+
+	return w, nil
+}
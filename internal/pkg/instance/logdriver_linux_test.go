@@ -0,0 +1,35 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package instance
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSetLogDriver(t *testing.T) {
+	for _, driver := range []string{FileLogDriver, SyslogLogDriver, JournaldLogDriver, NoneLogDriver} {
+		if err := CheckLogDriver(driver); err != nil {
+			t.Errorf("unexpected error for log driver %s: %s", driver, err)
+		}
+	}
+	if err := CheckLogDriver("fluentd"); err == nil {
+		t.Errorf("unexpected success for unknown log driver")
+	}
+
+	stdout, stderr, err := SetLogDriver(NoneLogDriver, "test", false, os.Getuid(), LogSubDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer stdout.Close()
+	defer stderr.Close()
+	if stdout.Name() != os.DevNull || stderr.Name() != os.DevNull {
+		t.Errorf("unexpected streams %s and %s for none log driver", stdout.Name(), stderr.Name())
+	}
+	if _, err := stdout.WriteString("discarded\n"); err != nil {
+		t.Errorf("unexpected error writing to discarded stream: %s", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// logCheckInterval is the interval between two checks of the instance log
+// files limits.
+const logCheckInterval = 10 * time.Second
+
+// LogRotation defines the limits of the instance log files: a log file is
+// rotated once larger than MaxSize bytes or once its content is older than
+// MaxAge, keeping the MaxFiles last rotated files. A zero MaxSize or MaxAge
+// disables the corresponding limit.
+type LogRotation struct {
+	MaxSize  int64
+	MaxAge   time.Duration
+	MaxFiles int
+}
+
+// rotatedLogPath returns the path of the nth rotated file of a log file.
+func rotatedLogPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// RotateLogFile rotates a log file still written by an instance: previous
+// rotated files are shifted, the oldest beyond maxFiles being removed, the
+// log file content is copied to path.1 and the log file is truncated. As the
+// instance streams are opened in append mode, the instance keeps writing at
+// the beginning of the truncated file, lines written during the copy are
+// lost.
+func RotateLogFile(path string, maxFiles int) error {
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return fmt.Errorf("while opening log file %s: %s", path, err)
+	}
+	defer f.Close()
+
+	if maxFiles > 0 {
+		fi, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("while getting information for %s: %s", path, err)
+		}
+		if err := os.Remove(rotatedLogPath(path, maxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("while removing rotated log file: %s", err)
+		}
+		for i := maxFiles - 1; i > 0; i-- {
+			err := os.Rename(rotatedLogPath(path, i), rotatedLogPath(path, i+1))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("while shifting rotated log file: %s", err)
+			}
+		}
+
+		rotated, err := os.OpenFile(rotatedLogPath(path, 1), os.O_WRONLY|os.O_CREATE|os.O_EXCL|syscall.O_NOFOLLOW, fi.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("while creating rotated log file: %s", err)
+		}
+		defer rotated.Close()
+
+		// keep the log file owner when running as root
+		if st, ok := fi.Sys().(*syscall.Stat_t); ok && os.Geteuid() == 0 {
+			if err := rotated.Chown(int(st.Uid), int(st.Gid)); err != nil {
+				return fmt.Errorf("while changing rotated log file owner: %s", err)
+			}
+		}
+		if _, err := io.Copy(rotated, f); err != nil {
+			return fmt.Errorf("while copying log file %s: %s", path, err)
+		}
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("while truncating log file %s: %s", path, err)
+	}
+	return nil
+}
+
+// WatchLogFiles periodically checks the log files of an instance and rotates
+// them when they exceed the limits, until the context is done.
+func WatchLogFiles(ctx context.Context, r LogRotation, paths ...string) {
+	if r.MaxSize <= 0 && r.MaxAge <= 0 {
+		return
+	}
+
+	// the content age is counted from the watch start, or from the last
+	// time the log file was rotated or found empty
+	since := make([]time.Time, len(paths))
+	for i := range since {
+		since[i] = time.Now()
+	}
+
+	ticker := time.NewTicker(logCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for i, path := range paths {
+				fi, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				if fi.Size() == 0 {
+					since[i] = now
+					continue
+				}
+				if (r.MaxSize > 0 && fi.Size() >= r.MaxSize) || (r.MaxAge > 0 && now.Sub(since[i]) >= r.MaxAge) {
+					if err := RotateLogFile(path, r.MaxFiles); err != nil {
+						sylog.Warningf("Could not rotate instance log file: %s", err)
+					}
+					since[i] = now
+				}
+			}
+		}
+	}
+}
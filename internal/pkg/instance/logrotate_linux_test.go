@@ -0,0 +1,63 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package instance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.out")
+
+	// the instance stream, opened in append mode
+	stream, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := stream.WriteString(line); err != nil {
+			t.Fatal(err)
+		}
+		if err := RotateLogFile(path, 2); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if _, err := stream.WriteString("fourth\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		path:                    "fourth\n",
+		rotatedLogPath(path, 1): "third\n",
+		rotatedLogPath(path, 2): "second\n",
+	}
+	for p, content := range expected {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Errorf("unexpected error reading %s: %s", p, err)
+		} else if string(b) != content {
+			t.Errorf("unexpected content %q for %s, expected %q", b, p, content)
+		}
+	}
+	if _, err := os.Stat(rotatedLogPath(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("unexpected rotated log file beyond the maximum")
+	}
+
+	// no rotated file kept
+	if err := RotateLogFile(path, 0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 0 {
+		t.Errorf("log file not truncated")
+	}
+	if b, _ := os.ReadFile(rotatedLogPath(path, 1)); string(b) != "third\n" {
+		t.Errorf("unexpected rotation without rotated files kept")
+	}
+}
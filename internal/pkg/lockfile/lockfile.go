@@ -0,0 +1,103 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package lockfile implements the apptainer.lock file, which records the
+// digests that tag-based docker:// and oras:// references resolved to, so
+// that the same images can be pulled again later.
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/util/fs/lock"
+)
+
+const (
+	// DefaultName is the name of the lockfile used when none is specified.
+	DefaultName = "apptainer.lock"
+	// Version is the version of the lockfile format.
+	Version = 1
+)
+
+// Lockfile maps normalized image references to their locked digests.
+type Lockfile struct {
+	Version int               `json:"version"`
+	Images  map[string]string `json:"images"`
+}
+
+// New returns an empty lockfile.
+func New() *Lockfile {
+	return &Lockfile{Version: Version, Images: make(map[string]string)}
+}
+
+// Load reads the lockfile at path.
+func Load(path string) (*Lockfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := New()
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, fmt.Errorf("while parsing lockfile %s: %v", path, err)
+	}
+	if l.Version != Version {
+		return nil, fmt.Errorf("lockfile %s has unsupported version %d", path, l.Version)
+	}
+	if l.Images == nil {
+		l.Images = make(map[string]string)
+	}
+	return l, nil
+}
+
+// Digest returns the digest locked for ref, and whether there is one.
+func (l *Lockfile) Digest(ref string) (string, bool) {
+	d, ok := l.Images[ref]
+	return d, ok
+}
+
+// Record sets the digest locked for ref in the lockfile at path, creating
+// the lockfile if needed. The directory of the lockfile is locked while it
+// is updated, so that concurrent pulls don't lose each other's records.
+func Record(path, ref, digest string) error {
+	dir := filepath.Dir(path)
+	fd, err := lock.Exclusive(dir)
+	if err != nil {
+		return fmt.Errorf("while locking %s: %v", dir, err)
+	}
+	defer lock.Release(fd)
+
+	l, err := Load(path)
+	if os.IsNotExist(err) {
+		l = New()
+	} else if err != nil {
+		return err
+	}
+	if l.Images[ref] == digest {
+		return nil
+	}
+	l.Images[ref] = digest
+
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := fs.MakeTmpFile(dir, ".apptainer-lock-", 0o644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultName)
+
+	if _, err := Load(path); !os.IsNotExist(err) {
+		t.Fatalf("got error %v for missing lockfile", err)
+	}
+
+	records := []struct {
+		ref, digest string
+	}{
+		{"docker://index.docker.io/library/alpine:3.19", "sha256:aaaa"},
+		{"oras://ghcr.io/apptainer/lolcow:latest", "sha256:bbbb"},
+		{"docker://index.docker.io/library/alpine:3.19", "sha256:cccc"},
+	}
+	for _, r := range records {
+		if err := Record(path, r.ref, r.digest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(l.Images) != 2 {
+		t.Errorf("got %d images, expected 2", len(l.Images))
+	}
+	if d, ok := l.Digest("docker://index.docker.io/library/alpine:3.19"); !ok || d != "sha256:cccc" {
+		t.Errorf("got digest %q, %v", d, ok)
+	}
+	if _, ok := l.Digest("docker://index.docker.io/library/busybox:latest"); ok {
+		t.Errorf("unexpected digest for unlocked image")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "Malformed", content: "images:"},
+		{name: "Version", content: `{"version": 2, "images": {}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package ociimage
+
+import (
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// annotationPrefix is the prefix of the keys of the pre-defined OCI image
+// annotations, describing the provenance of an image.
+const annotationPrefix = "org.opencontainers.image."
+
+// ImageAnnotations returns the pre-defined OCI image annotations, such as
+// org.opencontainers.image.source or org.opencontainers.image.revision, held
+// by the annotations or labels m. The reference name annotation is left out,
+// it only names an image in an index.
+func ImageAnnotations(m map[string]string) map[string]string {
+	annotations := make(map[string]string)
+	for k, v := range m {
+		if strings.HasPrefix(k, annotationPrefix) && k != ocispec.AnnotationRefName {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,31 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package ociimage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImageAnnotations(t *testing.T) {
+	m := map[string]string{
+		"org.opencontainers.image.source":   "https://github.com/apptainer/apptainer",
+		"org.opencontainers.image.revision": "abcdef",
+		"org.opencontainers.image.ref.name": "latest",
+		"org.label-schema.version":          "1.0",
+		"maintainer":                        "someone",
+	}
+	expected := map[string]string{
+		"org.opencontainers.image.source":   "https://github.com/apptainer/apptainer",
+		"org.opencontainers.image.revision": "abcdef",
+	}
+	if got := ImageAnnotations(m); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+	if got := ImageAnnotations(nil); len(got) != 0 {
+		t.Errorf("got %v, expected no annotations", got)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/cache"
 	progressClient "github.com/apptainer/apptainer/internal/pkg/client"
+	"github.com/apptainer/apptainer/internal/pkg/client/p2p"
 	"github.com/apptainer/apptainer/pkg/sylog"
 	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -89,7 +90,11 @@ func FetchToLayout(ctx context.Context, tOpts *TransportOptions, imgCache *cache
 		return nil, err
 	}
 
-	rt := progressClient.NewRoundTripper(ctx, nil)
+	p2pCfg, err := p2p.CurrentConfig()
+	if err != nil {
+		return nil, err
+	}
+	rt := progressClient.NewRoundTripper(ctx, p2p.NewTransport(p2pCfg, nil))
 
 	srcImg, err := srcType.Image(ctx, srcRef, tOpts, rt)
 	if err != nil {
@@ -97,6 +102,28 @@ func FetchToLayout(ctx context.Context, tOpts *TransportOptions, imgCache *cache
 		return nil, err
 	}
 
+	limits, err := CurrentLimits()
+	if err != nil {
+		rt.ProgressShutdown()
+		return nil, err
+	}
+	if err := limits.CheckImage(srcImg); err != nil {
+		rt.ProgressShutdown()
+		return nil, err
+	}
+
+	layoutDir := ""
+	if imgCache != nil && !imgCache.IsDisabled() {
+		if layoutDir, err = imgCache.GetOciCacheDir(cache.OciBlobCacheType); err != nil {
+			rt.ProgressShutdown()
+			return nil, err
+		}
+	}
+	if err := CurrentSpaceCheck(tOpts.TmpDir, layoutDir).CheckImage(srcImg); err != nil {
+		rt.ProgressShutdown()
+		return nil, err
+	}
+
 	if imgCache != nil && !imgCache.IsDisabled() {
 		// Ensure the image is cached, and return reference to the cached image.
 		cachedImg, err := cachedImage(ctx, imgCache, srcImg)
@@ -0,0 +1,120 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	units "github.com/docker/go-units"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// ErrImageLimit is returned when an image exceeds one of the limits set
+// by the administrator.
+var ErrImageLimit = errors.New("image exceeds the limit set by the administrator")
+
+// ImageLimits holds the maximum size and number of layers allowed for
+// OCI images being fetched or unpacked. A zero value means unlimited.
+type ImageLimits struct {
+	// MaxCompressedSize is the maximum sum of the compressed layer sizes.
+	MaxCompressedSize int64
+	// MaxUncompressedSize is the maximum size of the flattened root
+	// filesystem tar stream.
+	MaxUncompressedSize int64
+	// MaxLayers is the maximum number of layers.
+	MaxLayers int
+}
+
+// LimitsFromConfig returns the image limits set in the configuration,
+// no limits are returned for a nil configuration.
+func LimitsFromConfig(cfg *apptainerconf.File) (ImageLimits, error) {
+	var (
+		l   ImageLimits
+		err error
+	)
+	if cfg == nil {
+		return l, nil
+	}
+	if cfg.MaxImageCompressedSize != "" {
+		l.MaxCompressedSize, err = units.RAMInBytes(cfg.MaxImageCompressedSize)
+		if err != nil {
+			return l, fmt.Errorf("invalid max image compressed size %q: %s", cfg.MaxImageCompressedSize, err)
+		}
+	}
+	if cfg.MaxImageUncompressedSize != "" {
+		l.MaxUncompressedSize, err = units.RAMInBytes(cfg.MaxImageUncompressedSize)
+		if err != nil {
+			return l, fmt.Errorf("invalid max image uncompressed size %q: %s", cfg.MaxImageUncompressedSize, err)
+		}
+	}
+	l.MaxLayers = int(cfg.MaxImageLayers)
+	return l, nil
+}
+
+// CurrentLimits returns the image limits set in the current configuration.
+func CurrentLimits() (ImageLimits, error) {
+	return LimitsFromConfig(apptainerconf.GetCurrentConfig())
+}
+
+// CheckImage verifies that the layer count and the compressed size of
+// the image, as reported by its manifest, are within the limits.
+func (l ImageLimits) CheckImage(img v1.Image) error {
+	if l.MaxLayers <= 0 && l.MaxCompressedSize <= 0 {
+		return nil
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("while getting image manifest: %w", err)
+	}
+
+	if l.MaxLayers > 0 && len(manifest.Layers) > l.MaxLayers {
+		return fmt.Errorf("%w: %d layers, maximum is %d", ErrImageLimit, len(manifest.Layers), l.MaxLayers)
+	}
+
+	if l.MaxCompressedSize > 0 {
+		var size int64
+		for _, layer := range manifest.Layers {
+			size += layer.Size
+		}
+		if size > l.MaxCompressedSize {
+			return fmt.Errorf("%w: compressed size is %s, maximum is %s",
+				ErrImageLimit, units.BytesSize(float64(size)), units.BytesSize(float64(l.MaxCompressedSize)))
+		}
+	}
+	return nil
+}
+
+// LimitReader returns a reader failing with ErrImageLimit once more than
+// MaxUncompressedSize bytes have been read from r.
+func (l ImageLimits) LimitReader(r io.Reader) io.Reader {
+	if l.MaxUncompressedSize <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, max: l.MaxUncompressedSize}
+}
+
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.max {
+		return n, fmt.Errorf("%w: uncompressed size is greater than %s",
+			ErrImageLimit, units.BytesSize(float64(lr.max)))
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestLimitsFromConfig(t *testing.T) {
+	l, err := LimitsFromConfig(&apptainerconf.File{
+		MaxImageCompressedSize:   "1k",
+		MaxImageUncompressedSize: "2M",
+		MaxImageLayers:           3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if l.MaxCompressedSize != 1024 || l.MaxUncompressedSize != 2*1024*1024 || l.MaxLayers != 3 {
+		t.Errorf("unexpected limits: %+v", l)
+	}
+
+	if _, err := LimitsFromConfig(&apptainerconf.File{MaxImageCompressedSize: "lots"}); err == nil {
+		t.Errorf("unexpected success with an invalid size")
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	img := empty.Image
+	for i := 0; i < 2; i++ {
+		var err error
+		layer := static.NewLayer(bytes.Repeat([]byte{'a'}, 100), types.DockerLayer)
+		img, err = mutate.AppendLayers(img, layer)
+		if err != nil {
+			t.Fatalf("while appending layer: %s", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		limits  ImageLimits
+		wantErr bool
+	}{
+		{
+			name: "unlimited",
+		},
+		{
+			name:   "within limits",
+			limits: ImageLimits{MaxLayers: 2, MaxCompressedSize: 200},
+		},
+		{
+			name:    "too many layers",
+			limits:  ImageLimits{MaxLayers: 1},
+			wantErr: true,
+		},
+		{
+			name:    "too large",
+			limits:  ImageLimits{MaxCompressedSize: 199},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.CheckImage(img)
+			if tt.wantErr && !errors.Is(err, ErrImageLimit) {
+				t.Errorf("expected ErrImageLimit, got %v", err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLimitReader(t *testing.T) {
+	data := bytes.Repeat([]byte{'a'}, 1000)
+
+	l := ImageLimits{MaxUncompressedSize: 1000}
+	if _, err := io.Copy(io.Discard, l.LimitReader(bytes.NewReader(data))); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	l.MaxUncompressedSize = 999
+	if _, err := io.Copy(io.Discard, l.LimitReader(bytes.NewReader(data))); !errors.Is(err, ErrImageLimit) {
+		t.Errorf("expected ErrImageLimit, got %v", err)
+	}
+}
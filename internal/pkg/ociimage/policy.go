@@ -0,0 +1,106 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/uri"
+	"github.com/containers/image/v5/docker/reference"
+)
+
+// ErrRegistryNotAllowed is returned when an image reference doesn't
+// satisfy the registry allow / deny lists.
+var ErrRegistryNotAllowed = errors.New("registry not allowed by configuration")
+
+// ErrDigestRequired is returned when an image reference has no digest while
+// one is required.
+var ErrDigestRequired = errors.New("image reference must include a digest")
+
+// CheckRegistryPolicy checks that the image URI, in the docker:// or
+// oras:// form, refers to a registry repository matching one of the
+// allowed patterns, if any, and none of the denied patterns. A pattern
+// is either a registry host, a registry host followed by a namespace,
+// or a shell pattern like quay.io/biocontainers/*, which matches the
+// repository itself or any of its parent namespaces. Other transports
+// are not subject to the policy.
+func CheckRegistryPolicy(imageURI string, allowed, denied []string) error {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil
+	}
+
+	transport, ref := uri.Split(imageURI)
+	if transport != "docker" && transport != uri.Oras {
+		return nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(ref, "//"))
+	if err != nil {
+		return fmt.Errorf("while parsing image reference %s: %w", ref, err)
+	}
+	repository := named.Name()
+
+	for _, pattern := range denied {
+		if matchRepository(pattern, repository) {
+			return fmt.Errorf("%s: %w (denied by %q)", repository, ErrRegistryNotAllowed, pattern)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, pattern := range allowed {
+		if matchRepository(pattern, repository) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", repository, ErrRegistryNotAllowed)
+}
+
+// matchRepository returns whether the repository or one of its parent
+// namespaces matches pattern.
+func matchRepository(pattern, repository string) bool {
+	pattern = strings.TrimSuffix(strings.TrimSpace(pattern), "/")
+	if pattern == "" {
+		return false
+	}
+	for name := repository; name != ""; {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		idx := strings.LastIndex(name, "/")
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	return false
+}
+
+// CheckImageDigest checks that the image URI, in the docker:// or oras://
+// form, pins the image with an explicit digest rather than only a mutable
+// tag. Other transports are not checked.
+func CheckImageDigest(imageURI string) error {
+	transport, ref := uri.Split(imageURI)
+	if transport != "docker" && transport != uri.Oras {
+		return nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(strings.TrimPrefix(ref, "//"))
+	if err != nil {
+		return fmt.Errorf("while parsing image reference %s: %w", ref, err)
+	}
+	if _, ok := named.(reference.Digested); !ok {
+		return fmt.Errorf("%s: %w, e.g. %s@sha256:<digest>", imageURI, ErrDigestRequired, transport+"://"+named.Name())
+	}
+	return nil
+}
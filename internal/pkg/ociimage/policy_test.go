@@ -0,0 +1,125 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckRegistryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		allowed []string
+		denied  []string
+		wantErr bool
+	}{
+		{
+			name: "no policy",
+			uri:  "docker://alpine",
+		},
+		{
+			name:    "allowed registry",
+			uri:     "docker://registry.site.org/project/image:latest",
+			allowed: []string{"registry.site.org"},
+		},
+		{
+			name:    "docker hub normalization",
+			uri:     "docker://alpine:3.20",
+			allowed: []string{"docker.io/library/*"},
+		},
+		{
+			name:    "allowed namespace",
+			uri:     "docker://quay.io/biocontainers/samtools:1.9",
+			allowed: []string{"registry.site.org", "quay.io/biocontainers/*"},
+		},
+		{
+			name:    "allowed nested namespace",
+			uri:     "docker://quay.io/biocontainers/tools/samtools",
+			allowed: []string{"quay.io/biocontainers/*"},
+		},
+		{
+			name:    "other namespace",
+			uri:     "docker://quay.io/other/samtools",
+			allowed: []string{"quay.io/biocontainers/*"},
+			wantErr: true,
+		},
+		{
+			name:    "registry prefix only",
+			uri:     "docker://registry.site.org.evil.com/image",
+			allowed: []string{"registry.site.org"},
+			wantErr: true,
+		},
+		{
+			name:    "oras allowed",
+			uri:     "oras://registry.site.org/sif/image:1.0",
+			allowed: []string{"registry.site.org"},
+		},
+		{
+			name:    "denied over allowed",
+			uri:     "docker://quay.io/biocontainers/bad",
+			allowed: []string{"quay.io/*"},
+			denied:  []string{"quay.io/biocontainers/bad"},
+			wantErr: true,
+		},
+		{
+			name:    "denied registry",
+			uri:     "docker://docker.io/library/alpine",
+			denied:  []string{"docker.io"},
+			wantErr: true,
+		},
+		{
+			name:    "not a registry transport",
+			uri:     "docker-archive:/tmp/image.tar",
+			allowed: []string{"registry.site.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckRegistryPolicy(tt.uri, tt.allowed, tt.denied)
+			if tt.wantErr && !errors.Is(err, ErrRegistryNotAllowed) {
+				t.Errorf("expected ErrRegistryNotAllowed, got %v", err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckImageDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		name    string
+		uri     string
+		wantErr bool
+	}{
+		{name: "implicit tag", uri: "docker://alpine", wantErr: true},
+		{name: "tag", uri: "docker://quay.io/biocontainers/samtools:1.9", wantErr: true},
+		{name: "oras tag", uri: "oras://registry.site.org/sif/image:1.0", wantErr: true},
+		{name: "digest", uri: "docker://alpine@" + digest},
+		{name: "tag and digest", uri: "docker://alpine:3.20@" + digest},
+		{name: "oras digest", uri: "oras://registry.site.org/sif/image@" + digest},
+		{name: "not a registry transport", uri: "docker-archive:/tmp/image.tar"},
+		{name: "library", uri: "library://alpine:latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckImageDigest(tt.uri)
+			if tt.wantErr && !errors.Is(err, ErrDigestRequired) {
+				t.Errorf("expected ErrDigestRequired, got %v", err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	progressClient "github.com/apptainer/apptainer/internal/pkg/client"
 	"github.com/apptainer/apptainer/internal/pkg/util/ociauth"
@@ -29,6 +30,9 @@ import (
 
 type SourceSink int
 
+// layoutMu serializes writes to OCI layouts.
+var layoutMu sync.Mutex
+
 const (
 	UnknownSourceSink SourceSink = iota
 	RegistrySourceSink
@@ -170,6 +174,12 @@ func (ss SourceSink) Image(ctx context.Context, ref string, tOpts *TransportOpti
 func (ss SourceSink) WriteImage(img v1.Image, dstName string, tOpts *TransportOptions) error {
 	switch ss {
 	case OCISourceSink:
+		// Blobs and index.json are not written atomically, so that
+		// concurrent writes to the same layout, e.g. the OCI blob cache,
+		// are serialized.
+		layoutMu.Lock()
+		defer layoutMu.Unlock()
+
 		lp, err := layout.FromPath(dstName)
 		if err != nil {
 			lp, err = layout.Write(dstName, empty.Index)
@@ -0,0 +1,187 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	units "github.com/docker/go-units"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"golang.org/x/sys/unix"
+)
+
+// ErrNoSpace is returned when a filesystem doesn't have the free space
+// estimated to be needed by an image.
+var ErrNoSpace = errors.New("not enough free space")
+
+// SpaceCheck estimates the space needed by an OCI image from its manifest,
+// and verifies it's available before the image is pulled or converted.
+type SpaceCheck struct {
+	// Factor is the multiple of the compressed size of the layers needed
+	// in the temporary directory. A zero value disables the check.
+	Factor uint
+	// TmpDir is the temporary directory, the default one when empty.
+	TmpDir string
+	// LayoutDir is the OCI layout of the blob cache, the layers being
+	// stored in TmpDir when empty.
+	LayoutDir string
+}
+
+// spaceNeed is the space needed in a directory.
+type spaceNeed struct {
+	dir  string
+	what string
+	hint string
+	size int64
+}
+
+// CurrentSpaceCheck returns the space check of the current configuration
+// for tmpDir and the blob cache layoutDir. The check is disabled without
+// configuration.
+func CurrentSpaceCheck(tmpDir, layoutDir string) SpaceCheck {
+	c := SpaceCheck{TmpDir: tmpDir, LayoutDir: layoutDir}
+	if cfg := apptainerconf.GetCurrentConfig(); cfg != nil {
+		c.Factor = cfg.TmpSpaceFactor
+	}
+	return c
+}
+
+// CheckImage verifies that the temporary and cache directories have the
+// space needed by img, the layers already in the blob cache being ignored.
+// It returns an error wrapping ErrNoSpace when a filesystem is too small.
+func (c SpaceCheck) CheckImage(img v1.Image) error {
+	if c.Factor == 0 {
+		return nil
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("while getting image manifest: %w", err)
+	}
+
+	var size, missing int64
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+		if c.LayoutDir == "" || !c.cached(layer.Digest) {
+			missing += layer.Size
+		}
+	}
+
+	tmpDir := c.TmpDir
+	if tmpDir == "" {
+		tmpDir = os.TempDir()
+	}
+	needs := []spaceNeed{{
+		dir:  tmpDir,
+		what: "temporary directory",
+		hint: "set a temporary directory with more space with --tmpdir or APPTAINER_TMPDIR",
+		size: size * int64(c.Factor),
+	}}
+	if c.LayoutDir == "" {
+		needs[0].size += missing
+	} else {
+		needs = append(needs, spaceNeed{
+			dir:  c.LayoutDir,
+			what: "cache directory",
+			hint: "set a cache directory with more space with APPTAINER_CACHEDIR, or use --disable-cache",
+			size: missing,
+		})
+	}
+	return checkSpace(needs)
+}
+
+// cached returns whether the blob d is in the blob cache.
+func (c SpaceCheck) cached(d v1.Hash) bool {
+	_, err := os.Stat(filepath.Join(c.LayoutDir, "blobs", d.Algorithm, d.Hex))
+	return err == nil
+}
+
+// checkSpace verifies the space needs, the needs of directories located on
+// the same filesystem being added.
+func checkSpace(needs []spaceNeed) error {
+	type fsNeed struct {
+		dirs  []string
+		whats []string
+		hints []string
+		size  int64
+		avail int64
+	}
+	var (
+		devices []uint64
+		byDev   = make(map[uint64]*fsNeed)
+	)
+	for _, n := range needs {
+		dir, err := existingParent(n.dir)
+		if err != nil {
+			sylog.Debugf("Skipping free space check of %s: %s", n.dir, err)
+			continue
+		}
+		fi, err := os.Stat(dir)
+		if err != nil {
+			sylog.Debugf("Skipping free space check of %s: %s", n.dir, err)
+			continue
+		}
+		dev := uint64(fi.Sys().(*syscall.Stat_t).Dev) //nolint:unconvert
+		fn, ok := byDev[dev]
+		if !ok {
+			var st unix.Statfs_t
+			if err := unix.Statfs(dir, &st); err != nil {
+				sylog.Debugf("Skipping free space check of %s: %s", n.dir, err)
+				continue
+			}
+			fn = &fsNeed{avail: int64(st.Bavail) * int64(st.Bsize)} //nolint:unconvert
+			byDev[dev] = fn
+			devices = append(devices, dev)
+		}
+		fn.dirs = append(fn.dirs, n.dir)
+		fn.whats = append(fn.whats, n.what)
+		fn.hints = append(fn.hints, n.hint)
+		fn.size += n.size
+	}
+
+	for _, dev := range devices {
+		fn := byDev[dev]
+		sylog.Debugf("Estimated %s needed in %s, %s available",
+			units.BytesSize(float64(fn.size)), strings.Join(fn.dirs, " and "), units.BytesSize(float64(fn.avail)))
+		if fn.size <= fn.avail {
+			continue
+		}
+		return fmt.Errorf("%w in %s (%s): about %s needed, %s available; %s",
+			ErrNoSpace, strings.Join(fn.dirs, " and "), strings.Join(fn.whats, " and "),
+			units.BytesSize(float64(fn.size)), units.BytesSize(float64(fn.avail)), strings.Join(fn.hints, "; "))
+	}
+	return nil
+}
+
+// existingParent returns the closest existing parent of dir, or dir itself,
+// as directories are created by the pull.
+func existingParent(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no existing parent directory")
+		}
+		dir = parent
+	}
+}
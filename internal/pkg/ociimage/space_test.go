@@ -0,0 +1,87 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package ociimage
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestSpaceCheck(t *testing.T) {
+	layer := static.NewLayer(bytes.Repeat([]byte{'a'}, 100), types.DockerLayer)
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatalf("while appending layer: %s", err)
+	}
+	digest, err := layer.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	layoutDir := filepath.Join(t.TempDir(), "blob")
+
+	tests := []struct {
+		name    string
+		check   SpaceCheck
+		wantErr bool
+	}{
+		{
+			name:  "disabled",
+			check: SpaceCheck{TmpDir: tmpDir},
+		},
+		{
+			name:  "enough space",
+			check: SpaceCheck{Factor: 3, TmpDir: tmpDir, LayoutDir: layoutDir},
+		},
+		{
+			name:  "missing tmp dir",
+			check: SpaceCheck{Factor: 3, TmpDir: filepath.Join(tmpDir, "missing")},
+		},
+		{
+			name:    "not enough space",
+			check:   SpaceCheck{Factor: math.MaxUint32, TmpDir: tmpDir, LayoutDir: layoutDir},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check.CheckImage(img)
+			if tt.wantErr && !errors.Is(err, ErrNoSpace) {
+				t.Errorf("expected %v, got %v", ErrNoSpace, err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+
+	c := SpaceCheck{LayoutDir: layoutDir}
+	if c.cached(digest) {
+		t.Errorf("layer %s reported cached", digest)
+	}
+	blob := filepath.Join(layoutDir, "blobs", digest.Algorithm, digest.Hex)
+	if err := os.MkdirAll(filepath.Dir(blob), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blob, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !c.cached(digest) {
+		t.Errorf("layer %s not reported cached", digest)
+	}
+}
@@ -41,6 +41,8 @@ type Config struct {
 	Exclusive  bool             `yaml:"Exclusive"`          // true if the endpoint must be used exclusively
 	Insecure   bool             `yaml:"Insecure,omitempty"` // Allow use of http for service discovery
 	Keyservers []*ServiceConfig `yaml:"Keyservers,omitempty"`
+	// OCI registry serving library:// references for this endpoint
+	LibraryRegistry string `yaml:"LibraryRegistry,omitempty"`
 
 	// for internal purpose
 	credentials []*credential.Config
@@ -148,7 +148,7 @@ func (config *Config) UpdateKeyserversConfig() error {
 			// attempt to find credentials in the credential store
 			for _, cred := range config.credentials {
 				if remoteutil.SameKeyserver(cred.URI, kc.URI) {
-					kc.credential = cred
+					kc.credential = oauthCredential(cred)
 					break
 				}
 			}
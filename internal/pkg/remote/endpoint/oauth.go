@@ -0,0 +1,73 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package endpoint
+
+import (
+	"context"
+	"errors"
+
+	"github.com/apptainer/apptainer/internal/pkg/remote/credential"
+	"github.com/apptainer/apptainer/internal/pkg/remote/oauth"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+// oauthStore is the store of the tokens obtained with the device
+// authorization grant.
+var oauthStore = oauth.DefaultStore
+
+// OIDCProvider returns the OIDC issuer and client ID advertised by the token
+// service of the endpoint, used to log in with the device authorization
+// grant.
+func (config *Config) OIDCProvider() (issuer, clientID string, err error) {
+	issuer, err = config.getServiceConfigVal(Token, OIDCIssuerConfigKey)
+	if err != nil {
+		return "", "", err
+	}
+	clientID, err = config.getServiceConfigVal(Token, OIDCClientIDConfigKey)
+	if err != nil {
+		return "", "", err
+	}
+	return issuer, clientID, nil
+}
+
+// LoadOAuthToken sets the endpoint token from the token obtained with the
+// device authorization grant, if the endpoint was logged in that way. The
+// token is refreshed when it has expired. It must be called before the
+// services of the endpoint are used.
+func (config *Config) LoadOAuthToken(ctx context.Context) error {
+	if config.URI == "" || config.Token != "" {
+		return nil
+	}
+	token, err := oauthStore().AccessToken(ctx, config.URI)
+	if errors.Is(err, oauth.ErrNotLoggedIn) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	config.Token = token
+	return nil
+}
+
+// oauthCredential returns the credential of an external keyserver with the
+// token obtained with the device authorization grant, if the keyserver was
+// logged in that way and has no static credential.
+func oauthCredential(cred *credential.Config) *credential.Config {
+	if cred.Auth != "" {
+		return cred
+	}
+	token, err := oauthStore().AccessToken(context.Background(), cred.URI)
+	if errors.Is(err, oauth.ErrNotLoggedIn) {
+		return cred
+	} else if err != nil {
+		sylog.Warningf("Unable to get access token for %s: %v", cred.URI, err)
+		return cred
+	}
+	return &credential.Config{
+		URI:      cred.URI,
+		Auth:     credential.TokenPrefix + token,
+		Insecure: cred.Insecure,
+	}
+}
@@ -51,6 +51,13 @@ const (
 // RegistryURIConfigKey is the config key for the library OCI registry URI
 const RegistryURIConfigKey = "registryUri"
 
+// Config keys for the OIDC provider used to log in with the device
+// authorization grant, advertised by the token service.
+const (
+	OIDCIssuerConfigKey   = "oidcIssuer"
+	OIDCClientIDConfigKey = "oidcClientId"
+)
+
 var errorCodeMap = map[int]string{
 	404: "Invalid Credentials",
 	500: "Internal Server Error",
@@ -214,6 +221,15 @@ func (config *Config) GetAllServices() (map[string][]Service, error) {
 			}
 		}
 
+		// Store the OIDC provider for the token service (if any).
+		if s == Token {
+			for _, key := range []string{OIDCIssuerConfigKey, OIDCClientIDConfigKey} {
+				if val, ok := v[key].(string); ok {
+					sConfigMap[key] = val
+				}
+			}
+		}
+
 		config.services[s] = []Service{
 			&service{
 				cfg:       sConfig,
@@ -0,0 +1,295 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package oauth implements the OAuth2 device authorization grant (RFC 8628)
+// used to log in to remote endpoints and keyservers with single sign-on, and
+// the transparent refresh of the access tokens it obtains.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+const (
+	// discoveryPath is the path of the OpenID Connect discovery document,
+	// relative to the issuer.
+	discoveryPath = "/.well-known/openid-configuration"
+	// deviceCodeGrant is the grant type of the device authorization grant.
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+	// defaultInterval is the polling interval used when the authorization
+	// server doesn't specify one.
+	defaultInterval = 5 * time.Second
+	// expiryDelta is subtracted from the expiry of access tokens, so that
+	// they are refreshed before they expire during a request.
+	expiryDelta = 30 * time.Second
+)
+
+// DefaultScopes are the scopes requested when none are specified, offline
+// access being required to obtain a refresh token.
+var DefaultScopes = []string{"openid", "offline_access"}
+
+// ErrAccessDenied is returned when the user denies the authorization request.
+var ErrAccessDenied = errors.New("authorization request denied")
+
+// ErrExpired is returned when the device code expires before the user
+// completes the authorization.
+var ErrExpired = errors.New("device code expired, please log in again")
+
+// Provider describes an OAuth2 authorization server and the client
+// registered with it.
+type Provider struct {
+	Issuer                      string   `json:"issuer"`
+	ClientID                    string   `json:"clientId"`
+	Scopes                      []string `json:"scopes,omitempty"`
+	DeviceAuthorizationEndpoint string   `json:"deviceAuthorizationEndpoint,omitempty"`
+	TokenEndpoint               string   `json:"tokenEndpoint"`
+
+	// for internal purpose
+	client *http.Client
+}
+
+// SetHTTPClient sets the HTTP client used to reach the authorization server.
+func (p *Provider) SetHTTPClient(c *http.Client) {
+	p.client = c
+}
+
+func (p *Provider) httpClient() *http.Client {
+	if p.client != nil {
+		return p.client
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// Discover returns the provider of the issuer for the client, with its
+// endpoints read from the OpenID Connect discovery document.
+func Discover(ctx context.Context, issuer, clientID string, scopes []string) (*Provider, error) {
+	if issuer == "" || clientID == "" {
+		return nil, fmt.Errorf("an OIDC issuer and client ID are required")
+	}
+	p := &Provider{
+		Issuer:   strings.TrimSuffix(issuer, "/"),
+		ClientID: clientID,
+		Scopes:   scopes,
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = DefaultScopes
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", useragent.Value())
+
+	res, err := p.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("while fetching OIDC discovery document: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("while fetching OIDC discovery document: %s", res.Status)
+	}
+
+	var doc struct {
+		Issuer                      string `json:"issuer"`
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint               string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid OIDC discovery document: %v", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("OIDC discovery document issuer %q doesn't match %q", doc.Issuer, p.Issuer)
+	}
+	if doc.DeviceAuthorizationEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s doesn't support the device authorization grant", p.Issuer)
+	}
+	if doc.TokenEndpoint == "" {
+		return nil, fmt.Errorf("issuer %s has no token endpoint", p.Issuer)
+	}
+	p.DeviceAuthorizationEndpoint = doc.DeviceAuthorizationEndpoint
+	p.TokenEndpoint = doc.TokenEndpoint
+	return p, nil
+}
+
+// DeviceAuthorization holds the codes of a device authorization request.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// Token holds an access token and the refresh token used to renew it.
+type Token struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid returns whether the access token is set and not about to expire.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+// tokenError is the error response of the token endpoint.
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *tokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// post sends a form to an endpoint of the provider and decodes the JSON
+// response into v. Error responses are returned as a *tokenError when
+// possible.
+func (p *Provider) post(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	form.Set("client_id", p.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", useragent.Value())
+
+	res, err := p.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error making request to server: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		te := new(tokenError)
+		if err := json.NewDecoder(res.Body).Decode(te); err == nil && te.Code != "" {
+			return te
+		}
+		return fmt.Errorf("error response from server: %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// tokenResponse is the successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (r *tokenResponse) token() (*Token, error) {
+	if r.AccessToken == "" {
+		return nil, fmt.Errorf("no access token returned by the server")
+	}
+	if r.TokenType != "" && !strings.EqualFold(r.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", r.TokenType)
+	}
+	t := &Token{AccessToken: r.AccessToken, RefreshToken: r.RefreshToken}
+	if r.ExpiresIn > 0 {
+		t.Expiry = time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return t, nil
+}
+
+// DeviceLogin runs the device authorization grant: prompt is called with the
+// verification URI and the code the user must enter there, then the token
+// endpoint is polled until the user completes or denies the authorization.
+func (p *Provider) DeviceLogin(ctx context.Context, prompt func(*DeviceAuthorization)) (*Token, error) {
+	form := url.Values{}
+	form.Set("scope", strings.Join(p.Scopes, " "))
+	da := new(DeviceAuthorization)
+	if err := p.post(ctx, p.DeviceAuthorizationEndpoint, form, da); err != nil {
+		return nil, fmt.Errorf("while requesting device authorization: %w", err)
+	}
+	if da.DeviceCode == "" || da.UserCode == "" || da.VerificationURI == "" {
+		return nil, fmt.Errorf("invalid device authorization response")
+	}
+	prompt(da)
+
+	interval := time.Duration(da.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	var deadline <-chan time.Time
+	if da.ExpiresIn > 0 {
+		timer := time.NewTimer(time.Duration(da.ExpiresIn) * time.Second)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	form = url.Values{}
+	form.Set("grant_type", deviceCodeGrant)
+	form.Set("device_code", da.DeviceCode)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline:
+			return nil, ErrExpired
+		case <-time.After(interval):
+		}
+
+		tr := new(tokenResponse)
+		err := p.post(ctx, p.TokenEndpoint, form, tr)
+		var te *tokenError
+		switch {
+		case err == nil:
+			return tr.token()
+		case !errors.As(err, &te):
+			return nil, err
+		case te.Code == "authorization_pending":
+		case te.Code == "slow_down":
+			interval += 5 * time.Second
+		case te.Code == "access_denied":
+			return nil, ErrAccessDenied
+		case te.Code == "expired_token":
+			return nil, ErrExpired
+		default:
+			return nil, err
+		}
+	}
+}
+
+// Refresh returns a new access token obtained with the refresh token of t.
+// The refresh token of t is kept if the server doesn't rotate it.
+func (p *Provider) Refresh(ctx context.Context, t *Token) (*Token, error) {
+	if t == nil || t.RefreshToken == "" {
+		return nil, fmt.Errorf("no refresh token, please log in again")
+	}
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", t.RefreshToken)
+	tr := new(tokenResponse)
+	if err := p.post(ctx, p.TokenEndpoint, form, tr); err != nil {
+		return nil, fmt.Errorf("while refreshing access token: %w", err)
+	}
+	nt, err := tr.token()
+	if err != nil {
+		return nil, err
+	}
+	if nt.RefreshToken == "" {
+		nt.RefreshToken = t.RefreshToken
+	}
+	return nt, nil
+}
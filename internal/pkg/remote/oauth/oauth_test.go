@@ -0,0 +1,261 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	useragent "github.com/apptainer/apptainer/pkg/util/user-agent"
+)
+
+func TestMain(m *testing.M) {
+	useragent.InitValue("apptainer", "v0.1.0-30-g67692d50f-dirty")
+
+	os.Exit(m.Run())
+}
+
+// testServer is an authorization server supporting the device
+// authorization grant and refresh tokens.
+type testServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	polls    int
+	pending  int
+	deny     bool
+	refresh  int
+	issuerOK bool
+}
+
+func newTestServer(t *testing.T) *testServer {
+	s := &testServer{issuerOK: true}
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, _ *http.Request) {
+		issuer := s.URL
+		if !s.issuerOK {
+			issuer = "https://evil.example.com"
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        issuer,
+			"device_authorization_endpoint": s.URL + "/device",
+			"token_endpoint":                s.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_id") != "apptainer" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(tokenError{Code: "invalid_client"})
+			return
+		}
+		json.NewEncoder(w).Encode(DeviceAuthorization{
+			DeviceCode:      "device-code",
+			UserCode:        "ABCD-EFGH",
+			VerificationURI: s.URL + "/activate",
+			ExpiresIn:       60,
+			Interval:        1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		fail := func(code string) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenError{Code: code})
+		}
+		switch r.FormValue("grant_type") {
+		case deviceCodeGrant:
+			s.polls++
+			if r.FormValue("device_code") != "device-code" {
+				fail("invalid_grant")
+			} else if s.deny {
+				fail("access_denied")
+			} else if s.polls <= s.pending {
+				fail("authorization_pending")
+			} else {
+				json.NewEncoder(w).Encode(tokenResponse{
+					AccessToken:  "access-0",
+					TokenType:    "Bearer",
+					RefreshToken: "refresh-0",
+					ExpiresIn:    3600,
+				})
+			}
+		case "refresh_token":
+			if r.FormValue("refresh_token") != "refresh-"+string(rune('0'+s.refresh)) {
+				fail("invalid_grant")
+				return
+			}
+			s.refresh++
+			json.NewEncoder(w).Encode(tokenResponse{
+				AccessToken:  "access-" + string(rune('0'+s.refresh)),
+				TokenType:    "Bearer",
+				RefreshToken: "refresh-" + string(rune('0'+s.refresh)),
+				ExpiresIn:    3600,
+			})
+		default:
+			fail("unsupported_grant_type")
+		}
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestDiscover(t *testing.T) {
+	s := newTestServer(t)
+
+	p, err := Discover(context.Background(), s.URL+"/", "apptainer", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.TokenEndpoint != s.URL+"/token" || p.DeviceAuthorizationEndpoint != s.URL+"/device" {
+		t.Errorf("unexpected endpoints: %+v", p)
+	}
+	if len(p.Scopes) != len(DefaultScopes) {
+		t.Errorf("got scopes %v, expected %v", p.Scopes, DefaultScopes)
+	}
+
+	s.issuerOK = false
+	if _, err := Discover(context.Background(), s.URL, "apptainer", nil); err == nil {
+		t.Errorf("expected error for mismatched issuer")
+	}
+	if _, err := Discover(context.Background(), s.URL, "", nil); err == nil {
+		t.Errorf("expected error without client ID")
+	}
+}
+
+func TestDeviceLogin(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientID    string
+		pending     int
+		deny        bool
+		expectError error
+	}{
+		{name: "Authorized", clientID: "apptainer", pending: 1},
+		{name: "Denied", clientID: "apptainer", deny: true, expectError: ErrAccessDenied},
+		{name: "InvalidClient", clientID: "other", expectError: errors.New("invalid_client")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.pending = tt.pending
+			s.deny = tt.deny
+
+			p, err := Discover(context.Background(), s.URL, tt.clientID, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var userCode string
+			tok, err := p.DeviceLogin(context.Background(), func(da *DeviceAuthorization) {
+				userCode = da.UserCode
+			})
+			if tt.expectError != nil {
+				if err == nil {
+					t.Fatalf("expected error %v, got nil", tt.expectError)
+				}
+				if errors.Is(tt.expectError, ErrAccessDenied) && !errors.Is(err, ErrAccessDenied) {
+					t.Errorf("got error %v, expected %v", err, tt.expectError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if userCode != "ABCD-EFGH" {
+				t.Errorf("prompt got user code %q", userCode)
+			}
+			if tok.AccessToken != "access-0" || tok.RefreshToken != "refresh-0" || !tok.Valid() {
+				t.Errorf("unexpected token %+v", tok)
+			}
+			if s.polls != tt.pending+1 {
+				t.Errorf("token endpoint polled %d times, expected %d", s.polls, tt.pending+1)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := newTestServer(t)
+	p, err := Discover(context.Background(), s.URL, "apptainer", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "oauth-tokens.json")
+	store := NewStore(path)
+	uri := "https://keys.example.org"
+
+	if _, err := store.AccessToken(context.Background(), uri); !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("got error %v, expected %v", err, ErrNotLoggedIn)
+	}
+
+	if err := store.Set(uri, p, &Token{AccessToken: "access-0", RefreshToken: "refresh-0", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("store has permissions %o, expected 0600", fi.Mode().Perm())
+	}
+
+	// A valid token is returned without refresh.
+	tok, err := store.AccessToken(context.Background(), uri+"/pks")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok != "access-0" || s.refresh != 0 {
+		t.Errorf("got token %s after %d refreshes", tok, s.refresh)
+	}
+
+	// An expired token is refreshed, and the rotated refresh token stored.
+	if err := store.Set(uri, p, &Token{AccessToken: "access-0", RefreshToken: "refresh-0", Expiry: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		tok, err = store.AccessToken(context.Background(), uri)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok != "access-1" {
+			t.Errorf("got token %s, expected access-1", tok)
+		}
+	}
+	if s.refresh != 1 {
+		t.Errorf("token refreshed %d times, expected 1", s.refresh)
+	}
+
+	// A store readable by other users is refused.
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AccessToken(context.Background(), uri); err == nil {
+		t.Errorf("expected error for store readable by other users")
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := store.Delete(uri); err != nil || !found {
+		t.Fatalf("delete returned %v, %v", found, err)
+	}
+	if store.Has(uri) {
+		t.Errorf("token still stored after delete")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	remoteutil "github.com/apptainer/apptainer/internal/pkg/remote/util"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fs/lock"
+)
+
+// ErrNotLoggedIn is returned when no token is stored for a service.
+var ErrNotLoggedIn = errors.New("not logged in with OIDC")
+
+// Entry is the token stored for a service, with the provider used to
+// refresh it.
+type Entry struct {
+	URI      string   `json:"uri"`
+	Provider Provider `json:"provider"`
+	Token    Token    `json:"token"`
+}
+
+// Store holds the tokens of the services logged in with the device
+// authorization grant. Refresh tokens are long lived credentials, so the
+// store is only readable by its owner, and kept out of remote.yaml.
+type Store struct {
+	path string
+}
+
+// DefaultStore returns the token store of the current user.
+func DefaultStore() *Store {
+	return NewStore(syfs.OAuthTokens())
+}
+
+// NewStore returns the token store at path.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// update applies fn to the entries of the store while holding a lock on the
+// directory of the store, so that concurrent refreshes don't lose a rotated
+// refresh token. The entries are written back when fn returns true.
+func (s *Store) update(fn func(entries []*Entry) ([]*Entry, bool, error)) error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	fd, err := lock.Exclusive(dir)
+	if err != nil {
+		return fmt.Errorf("while locking %s: %v", dir, err)
+	}
+	defer lock.Release(fd)
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	entries, write, err := fn(entries)
+	if err != nil || !write {
+		return err
+	}
+
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := fs.MakeTmpFile(dir, ".oauth-tokens-", 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// read returns the entries of the store, refusing to use a store readable
+// by other users.
+func (s *Store) read() ([]*Entry, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode().Perm()&0o077 != 0 {
+		return nil, fmt.Errorf("%s is accessible by other users, please restrict its permissions to 0600", s.path)
+	}
+
+	var entries []*Entry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("while parsing %s: %v", s.path, err)
+	}
+	return entries, nil
+}
+
+func find(entries []*Entry, uri string) int {
+	for i, e := range entries {
+		if e.URI == uri || remoteutil.SameURI(e.URI, uri) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Set stores the token obtained from the provider for the service at uri.
+func (s *Store) Set(uri string, p *Provider, t *Token) error {
+	return s.update(func(entries []*Entry) ([]*Entry, bool, error) {
+		e := &Entry{URI: uri, Provider: *p, Token: *t}
+		e.Provider.client = nil
+		if i := find(entries, uri); i >= 0 {
+			entries[i] = e
+		} else {
+			entries = append(entries, e)
+		}
+		return entries, true, nil
+	})
+}
+
+// Delete removes the token of the service at uri, returning whether there
+// was one.
+func (s *Store) Delete(uri string) (bool, error) {
+	found := false
+	err := s.update(func(entries []*Entry) ([]*Entry, bool, error) {
+		i := find(entries, uri)
+		if i < 0 {
+			return entries, false, nil
+		}
+		found = true
+		return append(entries[:i], entries[i+1:]...), true, nil
+	})
+	return found, err
+}
+
+// Has returns whether a token is stored for the service at uri.
+func (s *Store) Has(uri string) bool {
+	entries, err := s.read()
+	return err == nil && find(entries, uri) >= 0
+}
+
+// AccessToken returns a valid access token for the service at uri,
+// refreshing it transparently when it has expired. ErrNotLoggedIn is
+// returned when no token is stored for the service.
+func (s *Store) AccessToken(ctx context.Context, uri string) (string, error) {
+	// avoid locking the store when the service isn't logged in
+	entries, err := s.read()
+	if err != nil {
+		return "", err
+	} else if find(entries, uri) < 0 {
+		return "", ErrNotLoggedIn
+	}
+
+	var token string
+	err = s.update(func(entries []*Entry) ([]*Entry, bool, error) {
+		i := find(entries, uri)
+		if i < 0 {
+			return entries, false, ErrNotLoggedIn
+		}
+		e := entries[i]
+		if e.Token.Valid() {
+			token = e.Token.AccessToken
+			return entries, false, nil
+		}
+
+		sylog.Debugf("Refreshing access token for %s", uri)
+		t, err := e.Provider.Refresh(ctx, &e.Token)
+		if err != nil {
+			return entries, false, err
+		}
+		e.Token = *t
+		token = t.AccessToken
+		return entries, true, nil
+	})
+	return token, err
+}
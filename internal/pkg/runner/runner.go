@@ -0,0 +1,254 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package runner proxies action commands to a remote Linux runner, so that
+// containers can be run from hosts where Apptainer can't run them natively,
+// with their standard input and outputs streamed back.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/shell"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+const (
+	// DefaultCommand is the Apptainer command run on the runner.
+	DefaultCommand = "apptainer"
+	// imageDir is the directory, relative to the home directory on the
+	// runner, where local images are uploaded.
+	imageDir = ".apptainer/runner"
+)
+
+// ErrUnsupportedRunner is returned for runners not reachable over SSH.
+var ErrUnsupportedRunner = errors.New("unsupported runner, only ssh runners are supported")
+
+// Runner is a remote Linux host running the action commands.
+type Runner struct {
+	// Host is the SSH destination, as user@host.
+	Host string
+	// Port is the SSH port, or empty for the default one.
+	Port string
+	// Command is the Apptainer command run on the runner.
+	Command string
+	// SSH is the ssh command used to reach the runner.
+	SSH string
+	// EnvFile is the file on the runner exporting the environment of the
+	// Apptainer command, or empty for none.
+	EnvFile string
+}
+
+// Parse parses a runner specification, an SSH destination as user@host or
+// an ssh://[user@]host[:port] URI.
+func Parse(spec string) (*Runner, error) {
+	r := &Runner{Command: DefaultCommand, SSH: "ssh"}
+	if !strings.Contains(spec, "://") {
+		// a host starting with a dash would be taken as an ssh option
+		if spec == "" || strings.HasPrefix(spec, "-") || strings.ContainsAny(spec, " \t") {
+			return nil, fmt.Errorf("invalid runner %q", spec)
+		}
+		r.Host = spec
+		return r, nil
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid runner %q: %v", spec, err)
+	}
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedRunner, spec)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid runner %q: no host", spec)
+	}
+	r.Host = u.Hostname()
+	if u.User != nil {
+		r.Host = u.User.Username() + "@" + r.Host
+	}
+	if strings.HasPrefix(r.Host, "-") {
+		return nil, fmt.Errorf("invalid runner %q", spec)
+	}
+	r.Port = u.Port()
+	return r, nil
+}
+
+// sshArgs returns the arguments of the ssh command running the shell
+// command on the runner, with a terminal allocated if tty is true.
+func (r *Runner) sshArgs(tty bool, command string) []string {
+	args := []string{}
+	if tty {
+		args = append(args, "-t")
+	} else {
+		args = append(args, "-T")
+	}
+	if r.Port != "" {
+		args = append(args, "-p", r.Port)
+	}
+	return append(args, "--", r.Host, command)
+}
+
+// quote quotes args to be passed to the shell run by the SSH server.
+func quote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + shell.EscapeSingleQuotes(a) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Run runs Apptainer with args on the runner, streaming stdin, stdout and
+// stderr, and returns its exit code.
+func (r *Runner) Run(ctx context.Context, args []string, tty bool, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	command := r.Command + " " + quote(args)
+	sylog.Debugf("Running %s on %s", command, r.Host)
+	if r.EnvFile != "" {
+		command = fmt.Sprintf("set -a && . %s && set +a && %s", quote([]string{r.EnvFile}), command)
+	}
+
+	cmd := exec.CommandContext(ctx, r.SSH, r.sshArgs(tty, command)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// ssh exits with 255 when the connection fails
+		if exitErr.ExitCode() == 255 {
+			return 255, fmt.Errorf("while running on %s: %v", r.Host, err)
+		}
+		return exitErr.ExitCode(), nil
+	} else if err != nil {
+		return 0, fmt.Errorf("while running on %s: %v", r.Host, err)
+	}
+	return 0, nil
+}
+
+// Upload uploads the local image or definition file to the runner, unless
+// already uploaded, and returns its path on the runner, relative to the home
+// directory. Files are named after their digest.
+func (r *Runner) Upload(ctx context.Context, image string) (string, error) {
+	f, err := os.Open(image)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("while hashing %s: %v", image, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	dest := path.Join(imageDir, fmt.Sprintf("%x%s", hash.Sum(nil), path.Ext(image)))
+
+	// the image is written to a temporary file first, so that an
+	// interrupted upload is not used
+	script := fmt.Sprintf("test -f %[1]s && exit 0; mkdir -p %[2]s && cat > %[1]s.tmp.$$ && mv %[1]s.tmp.$$ %[1]s",
+		quote([]string{dest}), quote([]string{imageDir}))
+	sylog.Infof("Uploading %s to %s", image, r.Host)
+
+	if err := r.script(ctx, script, f, nil); err != nil {
+		return "", fmt.Errorf("while uploading %s to %s: %v", image, r.Host, err)
+	}
+	return dest, nil
+}
+
+// script runs the shell script on the runner, with stdin and stdout.
+func (r *Runner) script(ctx context.Context, script string, stdin io.Reader, stdout io.Writer) error {
+	cmd := exec.CommandContext(ctx, r.SSH, r.sshArgs(false, script)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// TempDir creates a temporary directory on the runner and returns its path.
+func (r *Runner) TempDir(ctx context.Context) (string, error) {
+	var out strings.Builder
+	if err := r.script(ctx, "mktemp -d", nil, &out); err != nil {
+		return "", fmt.Errorf("while creating temporary directory on %s: %v", r.Host, err)
+	}
+	dir := strings.TrimSpace(out.String())
+	if dir == "" {
+		return "", fmt.Errorf("no temporary directory created on %s", r.Host)
+	}
+	return dir, nil
+}
+
+// Remove removes the path on the runner, and its content.
+func (r *Runner) Remove(ctx context.Context, path string) error {
+	if err := r.script(ctx, "rm -rf "+quote([]string{path}), nil, nil); err != nil {
+		return fmt.Errorf("while removing %s on %s: %v", path, r.Host, err)
+	}
+	return nil
+}
+
+// WriteFile writes content to the file dest on the runner, readable by the
+// user only. Content is sent on the standard input of ssh, so that secrets
+// don't appear in the command line of any process.
+func (r *Runner) WriteFile(ctx context.Context, dest string, content []byte) error {
+	script := "umask 077 && cat > " + quote([]string{dest})
+	if err := r.script(ctx, script, bytes.NewReader(content), nil); err != nil {
+		return fmt.Errorf("while writing %s on %s: %v", dest, r.Host, err)
+	}
+	return nil
+}
+
+// SetEnv writes env to a file in the directory dir on the runner, and sets
+// it as the environment file of the runner.
+func (r *Runner) SetEnv(ctx context.Context, dir string, env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, quote([]string{env[k]}))
+	}
+	envFile := path.Join(dir, "env")
+	if err := r.WriteFile(ctx, envFile, []byte(b.String())); err != nil {
+		return err
+	}
+	r.EnvFile = envFile
+	return nil
+}
+
+// Download downloads the file src on the runner to the local file dest.
+func (r *Runner) Download(ctx context.Context, src, dest string) error {
+	f, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	sylog.Infof("Downloading %s from %s", dest, r.Host)
+	if err := r.script(ctx, "cat "+quote([]string{src}), nil, f); err != nil {
+		return fmt.Errorf("while downloading %s from %s: %v", src, r.Host, err)
+	}
+	if err := f.Chmod(0o755); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), dest)
+}
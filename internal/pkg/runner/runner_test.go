@@ -0,0 +1,211 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		host    string
+		port    string
+		wantErr bool
+	}{
+		{name: "Host", spec: "runner", host: "runner"},
+		{name: "UserHost", spec: "user@runner", host: "user@runner"},
+		{name: "URI", spec: "ssh://runner", host: "runner"},
+		{name: "URIUserPort", spec: "ssh://user@runner:2222", host: "user@runner", port: "2222"},
+		{name: "Empty", spec: "", wantErr: true},
+		{name: "Space", spec: "user@run ner", wantErr: true},
+		{name: "NoHost", spec: "ssh://", wantErr: true},
+		{name: "Option", spec: "-oProxyCommand=true", wantErr: true},
+		{name: "URIOption", spec: "ssh://-oProxyCommand=true@runner", wantErr: true},
+		{name: "Unsupported", spec: "tcp://runner:2375", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, expected error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if r.Host != tt.host || r.Port != tt.port {
+				t.Errorf("got host %q port %q, expected %q %q", r.Host, r.Port, tt.host, tt.port)
+			}
+			if r.Command != DefaultCommand {
+				t.Errorf("got command %q, expected %q", r.Command, DefaultCommand)
+			}
+		})
+	}
+
+	if _, err := Parse("tcp://runner"); !errors.Is(err, ErrUnsupportedRunner) {
+		t.Errorf("got error %v, expected %v", err, ErrUnsupportedRunner)
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	r := &Runner{Host: "user@runner", Port: "2222"}
+	got := r.sshArgs(true, "apptainer 'run'")
+	expected := []string{"-t", "-p", "2222", "--", "user@runner", "apptainer 'run'"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	r.Port = ""
+	got = r.sshArgs(false, "true")
+	expected = []string{"-T", "--", "user@runner", "true"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	got := quote([]string{"exec", "--bind=/a b", "it's", "$HOME"})
+	expected := `'exec' '--bind=/a b' 'it'"'"'s' '$HOME'`
+	if got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
+
+// fakeRunner returns a runner whose ssh command runs the remote command
+// locally, in a temporary home directory.
+func fakeRunner(t *testing.T) *Runner {
+	t.Helper()
+	dir := t.TempDir()
+	ssh := filepath.Join(dir, "ssh")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift 2\ncd " + dir + " && exec /bin/sh -c \"$1\"\n"
+	if err := os.WriteFile(ssh, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &Runner{Host: "runner", Command: "echo", SSH: ssh}
+}
+
+func TestRun(t *testing.T) {
+	r := fakeRunner(t)
+
+	var stdout bytes.Buffer
+	code, err := r.Run(context.Background(), []string{"exec", "it's", "$HOME"}, false, nil, &stdout, os.Stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 0 {
+		t.Errorf("got exit code %d, expected 0", code)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "exec it's $HOME" {
+		t.Errorf("got output %q, expected %q", got, "exec it's $HOME")
+	}
+
+	r.Command = "exit"
+	code, err = r.Run(context.Background(), []string{"3"}, false, nil, &stdout, os.Stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 3 {
+		t.Errorf("got exit code %d, expected 3", code)
+	}
+
+	code, err = r.Run(context.Background(), []string{"255"}, false, nil, &stdout, os.Stderr)
+	if err == nil || code != 255 {
+		t.Errorf("got exit code %d and error %v, expected a connection error", code, err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	r := fakeRunner(t)
+
+	image := filepath.Join(t.TempDir(), "image.sif")
+	content := []byte("image content")
+	if err := os.WriteFile(image, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := r.Upload(context.Background(), image)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(dest, imageDir+"/") || !strings.HasSuffix(dest, ".sif") {
+		t.Errorf("unexpected destination %s", dest)
+	}
+
+	home := filepath.Dir(r.SSH)
+	b, err := os.ReadFile(filepath.Join(home, dest))
+	if err != nil {
+		t.Fatalf("image not uploaded: %v", err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Errorf("got uploaded content %q, expected %q", b, content)
+	}
+
+	// an image already uploaded is not uploaded again
+	again, err := r.Upload(context.Background(), image)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again != dest {
+		t.Errorf("got destination %s, expected %s", again, dest)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	r := fakeRunner(t)
+	ctx := context.Background()
+
+	dir, err := r.TempDir(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filepath.IsAbs(dir) {
+		t.Fatalf("got temporary directory %q, expected an absolute path", dir)
+	}
+
+	if err := r.SetEnv(ctx, dir, map[string]string{"SECRET": "it's a secret"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fi, err := os.Stat(r.EnvFile)
+	if err != nil {
+		t.Fatalf("environment file not written: %v", err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("got environment file mode %o, expected 600", fi.Mode().Perm())
+	}
+
+	// the environment file is sourced before running the command
+	r.Command = "/bin/sh"
+	var stdout bytes.Buffer
+	if _, err := r.Run(ctx, []string{"-c", "echo $SECRET"}, false, nil, &stdout, os.Stderr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "it's a secret" {
+		t.Errorf("got %q, expected %q", got, "it's a secret")
+	}
+
+	dest := filepath.Join(t.TempDir(), "file")
+	if err := r.Download(ctx, r.EnvFile, dest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b, err := os.ReadFile(dest); err != nil || !strings.Contains(string(b), "SECRET=") {
+		t.Errorf("got downloaded content %q (%v)", b, err)
+	}
+
+	if err := r.Remove(ctx, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary directory not removed: %v", err)
+	}
+}
@@ -20,9 +20,12 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/instance"
 	fakerootConfig "github.com/apptainer/apptainer/internal/pkg/runtime/engine/fakeroot/config"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/launchhooks"
 	"github.com/apptainer/apptainer/internal/pkg/util/bin"
 	"github.com/apptainer/apptainer/internal/pkg/util/crypt"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/teardown"
 	"github.com/apptainer/apptainer/internal/pkg/util/priv"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	"github.com/apptainer/apptainer/internal/pkg/util/starter"
 	"github.com/apptainer/apptainer/pkg/build/types"
 	"github.com/apptainer/apptainer/pkg/runtime/engine/config"
@@ -43,8 +46,16 @@ import (
 // For better understanding of runtime flow in general refer to
 // https://github.com/opencontainers/runtime-spec/blob/master/runtime.md#lifecycle.
 // CleanupContainer is performing step 8/9 here.
-func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, _ syscall.WaitStatus) error {
+func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, status syscall.WaitStatus) error {
 	sylog.Debugf("Cleanup container")
+	if !e.EngineConfig.GetInstance() {
+		profile.End("container run")
+		profile.End("starter")
+		if err := profile.Report(os.Stderr); err != nil {
+			sylog.Warningf("Could not report startup profile: %s", err)
+		}
+	}
+
 	if fd := e.EngineConfig.GetShareNSFd(); fd != -1 && e.EngineConfig.GetShareNSMode() {
 		br := lock.NewByteRange(fd, 0, 0)
 		// wait all other processes first
@@ -88,6 +99,7 @@ func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, _ sysc
 		sylog.Infof("Cleaning up image...")
 
 		var err error
+		fakerootFailed := false
 
 		if e.EngineConfig.GetFakeroot() && os.Getuid() != 0 {
 			// this is required when we are using SUID workflow
@@ -96,14 +108,25 @@ func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, _ sysc
 			// image removal, so we execute "rm -rf /tmp/image" via
 			// the fakeroot engine
 			err = fakerootCleanup(tempDir)
+			if err != nil {
+				sylog.Errorf("failed to delete container image tempDir %s: %s", tempDir, err)
+				fakerootFailed = true
+			}
 		} else {
 			err = types.FixPerms(tempDir)
 			if err != nil {
 				sylog.Debugf("FixPerms had a problem: %v", err)
 			}
-			err = os.RemoveAll(tempDir)
 		}
-		if err != nil {
+		// this also completes the teardown registered at launch, or
+		// defers it to a later invocation when the removal fails,
+		// except after a fakeroot failure which a later invocation
+		// outside of the fakeroot context can't complete either
+		if fakerootFailed {
+			if err := teardown.Forget(tempDir); err != nil {
+				sylog.Warningf("Could not remove teardown of %s: %s", tempDir, err)
+			}
+		} else if err = teardown.Teardown(tempDir); err != nil {
 			sylog.Errorf("failed to delete container image tempDir %s: %s", tempDir, err)
 		}
 	}
@@ -138,6 +161,10 @@ func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, _ sysc
 		}
 	}
 
+	if ev := e.EngineConfig.GetLaunchEvent(); ev != nil {
+		e.runPostExitHooks(ctx, ev, status)
+	}
+
 	if e.EngineConfig.GetInstance() {
 		file, err := instance.Get(e.CommonConfig.ContainerID, instance.AppSubDir)
 		if err != nil {
@@ -149,6 +176,20 @@ func (e *EngineOperations) CleanupContainer(ctx context.Context, _ error, _ sysc
 	return nil
 }
 
+// runPostExitHooks runs the post-exit hooks of the site, with the
+// description of the container recorded at launch and its exit code.
+func (e *EngineOperations) runPostExitHooks(ctx context.Context, ev *launchhooks.Event, status syscall.WaitStatus) {
+	code := status.ExitStatus()
+	if status.Signaled() {
+		code = 128 + int(status.Signal())
+	}
+	ev.Hook = launchhooks.PostExit
+	ev.ExitCode = &code
+	if err := launchhooks.Run(ctx, e.EngineConfig.File.LaunchHooksDir, ev); err != nil {
+		sylog.Warningf("While running post-exit hooks: %s", err)
+	}
+}
+
 func umount() (err error) {
 	var errs []string
 	var oldEffective uint64
@@ -13,6 +13,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	osuser "os/user"
 	"path/filepath"
@@ -95,6 +96,9 @@ type container struct {
 	suidFlag      uintptr
 	devSourcePath string
 	skipCwd       bool
+	// staged network files updated after the CNI networks setup
+	hostsStaged      bool
+	resolvConfStaged bool
 }
 
 //nolint:maintidx
@@ -383,12 +387,23 @@ func create(ctx context.Context, engine *EngineOperations, rpcOps *client.RPC, p
 			os.Setenv("DBUS_SESSION_BUS_ADDRESS", engine.EngineConfig.GetDbusSessionBusAddress())
 		}
 
-		cgroupsManager, err = cgroups.NewManagerWithJSON(cgJSON, pid, "", engine.EngineConfig.File.SystemdCgroups)
+		systemd := engine.EngineConfig.File.SystemdCgroups
+		group := ""
+		if parent := engine.EngineConfig.GetCgroupParent(); parent != "" {
+			group = cgroups.DefaultGroup(parent, pid, systemd)
+		}
+		cgroupsManager, err = cgroups.NewManagerWithJSON(cgJSON, pid, group, systemd)
 		if err != nil {
 			return fmt.Errorf("while applying cgroups config: %v", err)
 		}
 		os.Unsetenv("XDG_RUNTIME_DIR")
 		os.Unsetenv("DBUS_SESSION_BUS_ADDRESS")
+
+		if engine.EngineConfig.File.TagContainerCgroups {
+			// the image digest is computed once the container is
+			// started, to not delay it with large images
+			go engine.tagCgroup()
+		}
 	}
 
 	sylog.Debugf("Chdir into / to avoid errors\n")
@@ -600,16 +615,10 @@ func (c *container) setupImageDriver(system *mount.System, containerPid int) err
 // won't see mount done by RPC server anymore. Typically
 // called after SharedTag mounts
 func (c *container) setPropagationMount(_ *mount.System) error {
-	pflags := uintptr(syscall.MS_REC)
-
-	if c.engine.EngineConfig.File.MountSlave {
-		sylog.Debugf("Set RPC mount propagation flag to SLAVE")
-		pflags |= syscall.MS_SLAVE
-	} else {
-		sylog.Debugf("Set RPC mount propagation flag to PRIVATE")
-		pflags |= syscall.MS_PRIVATE
-	}
+	mode := c.engine.rootfsPropagation()
+	pflags, _ := mount.ConvertOptions([]string{mode})
 
+	sylog.Debugf("Set RPC mount propagation flag to %s", mode)
 	return c.rpcOps.Mount("", "/", "", pflags, "")
 }
 
@@ -770,15 +779,34 @@ mount:
 			err = fmt.Errorf("overlay image driver selected by configuration")
 		} else {
 			lowerdirs := ""
+			upperdir := ""
 			hasUpper := false
 			for _, opt := range opts {
 				if strings.HasPrefix(opt, "lowerdir=") {
 					lowerdirs = opt[len("lowerdir="):]
 				} else if strings.HasPrefix(opt, "upperdir=") {
+					upperdir = opt[len("upperdir="):]
 					hasUpper = true
 				}
 			}
-			if hasUpper {
+			// The kernel overlayfs refuses network and cluster
+			//  filesystems like NFS or Lustre as upper directory,
+			//  and some of them as lower directories, so skip it
+			//  for them in favor of the overlay image driver.
+			for _, ldir := range strings.Split(lowerdirs, ":") {
+				if lerr := fsoverlay.CheckLower(ldir); fsoverlay.IsIncompatible(lerr) {
+					err = lerr
+					break
+				}
+			}
+			if err == nil && hasUpper {
+				if uerr := fsoverlay.CheckUpper(upperdir); fsoverlay.IsIncompatible(uerr) {
+					err = uerr
+				}
+			}
+			if err != nil {
+				sylog.Verbosef("Using overlay image driver: %s", err)
+			} else if hasUpper {
 				// This is an overlay and there is an overlay image
 				//  driver.  When a lower layer is of type FUSE
 				//  we want to skip trying the kernel overlayfs. That's
@@ -1074,11 +1102,8 @@ func (c *container) addRootfsMount(system *mount.System) error {
 		}
 		// re-apply mount propagation flag, on EL6 a kernel bug reset propagation flag
 		// and may lead to crash (see https://github.com/apptainer/singularity/issues/4851)
-		flags = syscall.MS_SLAVE
-		if !c.engine.EngineConfig.File.MountSlave {
-			flags = syscall.MS_PRIVATE
-		}
-		return system.Points.AddPropagation(mount.RootfsTag, c.session.RootFsPath(), flags)
+		flags, _ = mount.ConvertOptions([]string{c.engine.rootfsPropagation()})
+		return system.Points.AddPropagation(mount.RootfsTag, c.session.RootFsPath(), flags&^syscall.MS_REC)
 	}
 
 	sylog.Debugf("Mounting block [%v] image: %v\n", mountType, rootfs)
@@ -1792,6 +1817,7 @@ func (c *container) addBindsMount(system *mount.System) error {
 			if err := system.Points.AddBind(mount.BindsTag, hosts, hostsPath, flags, "skip-on-error"); err != nil {
 				return fmt.Errorf("unable to add %s to mount list: %s", hosts, err)
 			}
+			c.hostsStaged = hosts != hostsPath
 			if err := system.Points.AddRemount(mount.BindsTag, hostsPath, flags); err != nil {
 				return fmt.Errorf("unable to add %s for remount: %s", hostsPath, err)
 			}
@@ -2092,6 +2118,12 @@ func (c *container) addUserbindsMount(system *mount.System) error {
 				c.session.OverrideDir(dst, src)
 			}
 			system.Points.AddRemount(mount.UserbindsTag, dst, flags)
+			if p := b.Propagation(); p != "" {
+				pflags, _ := mount.ConvertOptions([]string{p})
+				if err := system.Points.AddPropagation(mount.UserbindsTag, dst, pflags); err != nil {
+					return fmt.Errorf("unable to set %s propagation on %s: %s", p, dst, err)
+				}
+			}
 		}
 	}
 
@@ -2633,6 +2665,8 @@ func (c *container) addResolvConfMount(system *mount.System) error {
 		}
 		if err := c.session.AddFile(resolvConf, content); err != nil {
 			sylog.Warningf("failed to add resolv.conf session file: %s", err)
+		} else {
+			c.resolvConfStaged = dns == ""
 		}
 		sessionFile, _ := c.session.GetPath(resolvConf)
 
@@ -2805,10 +2839,52 @@ func (c *container) prepareNetworkSetup(system *mount.System, pid int) (func(con
 		if err := networkSetup.AddNetworks(ctx); err != nil {
 			return fmt.Errorf("%s", err)
 		}
-		return nil
+		return c.updateNetworkFiles(networks)
 	}, nil
 }
 
+// updateNetworkFiles adds the IPv4 and IPv6 addresses assigned by the CNI
+// networks to the staged /etc/hosts, and replaces the staged /etc/resolv.conf
+// with the DNS configuration returned by the networks, if any.
+func (c *container) updateNetworkFiles(networks []string) error {
+	var ips []net.IP
+	var nameservers, search []string
+
+	for _, n := range networks {
+		nips, err := networkSetup.GetNetworkIPs(n)
+		if err != nil {
+			return err
+		}
+		ips = append(ips, nips...)
+
+		dns, err := networkSetup.GetNetworkDNS(n)
+		if err != nil {
+			return err
+		}
+		nameservers = append(nameservers, dns.Nameservers...)
+		search = append(search, dns.Search...)
+	}
+
+	hostname := c.engine.EngineConfig.GetHostname()
+	if c.hostsStaged && hostname != "" && len(ips) > 0 {
+		sylog.Debugf("Adding %s to /etc/hosts", hostname)
+		if err := c.rpcOps.UpdateFile("/etc/hosts", files.Hosts(hostname, ips)); err != nil {
+			return fmt.Errorf("while updating /etc/hosts: %s", err)
+		}
+	}
+	if c.resolvConfStaged && len(nameservers) > 0 {
+		content, err := files.ResolvConf(nameservers, search...)
+		if err != nil {
+			return fmt.Errorf("invalid DNS configuration returned by CNI networks: %s", err)
+		}
+		sylog.Debugf("Setting /etc/resolv.conf nameservers to %s", strings.Join(nameservers, ","))
+		if err := c.rpcOps.UpdateFile("/etc/resolv.conf", content); err != nil {
+			return fmt.Errorf("while updating /etc/resolv.conf: %s", err)
+		}
+	}
+	return nil
+}
+
 // getFuseFdFromRPC returns fuse file descriptors from RPC server based on
 // the file descriptor list provided in argument, it also returns an
 // additional file descriptor corresponding to /proc/self/ns/user.
@@ -16,7 +16,9 @@ import (
 	"net/rpc"
 
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/apptainer/rpc/client"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	apptainerConfig "github.com/apptainer/apptainer/pkg/runtime/engine/apptainer/config"
+	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
 // CreateContainer is called from master process to prepare container
@@ -36,6 +38,17 @@ func (e *EngineOperations) CreateContainer(ctx context.Context, pid int, rpcConn
 		return fmt.Errorf("engineName configuration doesn't match runtime name")
 	}
 
+	// the startup phases recorded by the launcher are completed by the
+	// master process
+	if err := profile.Import(e.EngineConfig.GetProfile()); err != nil {
+		sylog.Debugf("Could not import startup profile: %s", err)
+	}
+	endCreate := profile.Begin("container create")
+	defer func() {
+		endCreate()
+		profile.Begin("container start")
+	}()
+
 	if e.EngineConfig.GetInstanceJoin() {
 		return nil
 	}
@@ -10,6 +10,8 @@
 package apptainer
 
 import (
+	"os"
+
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/apptainer/rpc/server"
@@ -33,7 +35,7 @@ func (e *EngineOperations) InitConfig(cfg *config.Common, privStageOne bool) {
 	if privStageOne {
 		// override the contents of File for security reasons
 		var err error
-		e.EngineConfig.File, err = apptainerconf.Parse(buildcfg.APPTAINER_CONF_FILE)
+		e.EngineConfig.File, err = apptainerconf.ParseWithOverrides(buildcfg.APPTAINER_CONF_FILE, os.Getuid())
 		if err != nil {
 			sylog.Fatalf("unable to parse apptainer.conf file: %s", err)
 		}
@@ -22,7 +22,6 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
 	"github.com/apptainer/apptainer/internal/pkg/cgroups"
 	fakerootutil "github.com/apptainer/apptainer/internal/pkg/fakeroot"
@@ -31,9 +30,9 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/plugin"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/starter"
 	"github.com/apptainer/apptainer/internal/pkg/security"
+	"github.com/apptainer/apptainer/internal/pkg/security/landlock"
 	"github.com/apptainer/apptainer/internal/pkg/security/seccomp"
 	"github.com/apptainer/apptainer/internal/pkg/syecl"
-	"github.com/apptainer/apptainer/internal/pkg/sypgp"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs/overlay"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs/squashfs"
@@ -165,6 +164,19 @@ func (e *EngineOperations) PrepareConfig(starterConfig *starter.Config) error {
 		}
 	}
 
+	// no new privileges is always set for unprivileged users, root can
+	// override the default set by apptainer.conf
+	if os.Getuid() == 0 {
+		switch {
+		case e.EngineConfig.GetNoNewPrivs():
+			e.EngineConfig.OciConfig.SetProcessNoNewPrivileges(true)
+		case e.EngineConfig.GetAllowNewPrivs():
+			e.EngineConfig.OciConfig.SetProcessNoNewPrivileges(false)
+		case e.EngineConfig.File.RootNoNewPrivs && !e.EngineConfig.GetInstanceJoin():
+			e.EngineConfig.OciConfig.SetProcessNoNewPrivileges(true)
+		}
+	}
+
 	starterConfig.SetMasterPropagateMount(true)
 	starterConfig.SetNoNewPrivs(e.EngineConfig.OciConfig.Process.NoNewPrivileges)
 
@@ -324,6 +336,22 @@ func (e *EngineOperations) prepareUserCaps(enforced bool) error {
 	e.EngineConfig.OciConfig.Process.Capabilities.Bounding = commonCaps
 	e.EngineConfig.OciConfig.Process.Capabilities.Ambient = commonCaps
 
+	// the administrator controls whether the granted capabilities are kept
+	// by the container processes across exec with the ambient set
+	if enforced && len(commonCaps) > 0 {
+		switch e.EngineConfig.File.UserAmbientCapabilities {
+		case "request":
+			if !e.EngineConfig.GetAmbientCaps() {
+				e.EngineConfig.OciConfig.Process.Capabilities.Ambient = []string{}
+			}
+		case "never":
+			if e.EngineConfig.GetAmbientCaps() {
+				sylog.Warningf("ambient capabilities disallowed by administrator, only binaries with file capabilities can use %s", strings.Join(commonCaps, ","))
+			}
+			e.EngineConfig.OciConfig.Process.Capabilities.Ambient = []string{}
+		}
+	}
+
 	return nil
 }
 
@@ -607,6 +635,70 @@ func (e *EngineOperations) joinNetns(starterConfig *starter.Config) error {
 	return starterConfig.SetNsPath(specs.NetworkNamespace, netnsPath)
 }
 
+// rootfsPropagation returns the recursive mount propagation mode of the
+// container root filesystem, requested with --rootfs-propagation or set by
+// the 'mount slave' directive.
+func (e *EngineOperations) rootfsPropagation() string {
+	if mode := e.EngineConfig.GetRootfsPropagation(); mode != "" {
+		return "r" + strings.TrimPrefix(mode, "r")
+	}
+	if e.EngineConfig.File.MountSlave {
+		return "rslave"
+	}
+	return "rprivate"
+}
+
+// checkPropagation ensures the mount propagation modes requested for the
+// root filesystem and bind mounts are valid. Shared modes would propagate
+// mounts done in the container back to the host, so they are restricted to
+// the root user.
+func (e *EngineOperations) checkPropagation() error {
+	modes := []string{e.EngineConfig.GetRootfsPropagation()}
+	for _, b := range e.EngineConfig.GetBindPath() {
+		modes = append(modes, b.Propagation())
+	}
+	for _, mode := range modes {
+		if mode == "" {
+			continue
+		}
+		if err := apptainerConfig.CheckPropagation(mode); err != nil {
+			return err
+		}
+		if strings.HasSuffix(mode, "shared") && os.Getuid() != 0 {
+			return fmt.Errorf("%s mount propagation is only allowed for the root user", mode)
+		}
+	}
+	return nil
+}
+
+// checkCgroupParent checks that the user is allowed to create the container
+// cgroup under the requested parent. Other users than root can only select
+// a cgroup containing their current process, like the cgroup of their batch
+// job, so that the container doesn't escape their resource limits.
+func (e *EngineOperations) checkCgroupParent() error {
+	parent := e.EngineConfig.GetCgroupParent()
+	if parent == "" {
+		return nil
+	}
+	systemd := e.EngineConfig.File.SystemdCgroups
+	if err := cgroups.CheckParent(parent, systemd); err != nil {
+		return err
+	}
+	if os.Getuid() == 0 {
+		return nil
+	}
+	if systemd {
+		return fmt.Errorf("cgroup parent is only allowed for the root user with systemd cgroups")
+	}
+	ok, err := cgroups.InParent(parent, os.Getpid())
+	if err != nil {
+		return fmt.Errorf("while checking cgroup parent: %s", err)
+	} else if !ok {
+		return fmt.Errorf("cgroup parent %s doesn't contain the current cgroup, only the root user can select another cgroup", parent)
+	}
+	return nil
+}
+
 // prepareContainerConfig is responsible for getting and applying
 // user supplied configuration for container creation.
 func (e *EngineOperations) prepareContainerConfig(starterConfig *starter.Config) error {
@@ -655,11 +747,13 @@ func (e *EngineOperations) prepareContainerConfig(starterConfig *starter.Config)
 		}
 	}
 
-	if e.EngineConfig.File.MountSlave {
-		starterConfig.SetMountPropagation("rslave")
-	} else {
-		starterConfig.SetMountPropagation("rprivate")
+	if err := e.checkPropagation(); err != nil {
+		return err
+	}
+	if err := e.checkCgroupParent(); err != nil {
+		return err
 	}
+	starterConfig.SetMountPropagation(e.rootfsPropagation())
 
 	if e.EngineConfig.GetFakeroot() {
 		uid := uint32(os.Getuid())
@@ -758,6 +852,13 @@ func (e *EngineOperations) prepareContainerConfig(starterConfig *starter.Config)
 			return err
 		}
 	}
+	param = security.GetParam(e.EngineConfig.GetSecurity(), "landlock")
+	if param != "" {
+		sylog.Debugf("Applying landlock ruleset from %s", param)
+		if err := e.setLandlockRuleset(param); err != nil {
+			return err
+		}
+	}
 
 	// open file descriptors (autofs bug path)
 	return e.prepareAutofs(starterConfig)
@@ -1028,6 +1129,17 @@ func (e *EngineOperations) prepareInstanceJoinConfig(starterConfig *starter.Conf
 		e.EngineConfig.OciConfig.Linux.Seccomp = instanceEngineConfig.OciConfig.Linux.Seccomp
 	}
 
+	// restore landlock ruleset or apply a new one if provided
+	param = security.GetParam(e.EngineConfig.GetSecurity(), "landlock")
+	if param != "" {
+		sylog.Debugf("Applying landlock ruleset from %s", param)
+		if err := e.setLandlockRuleset(param); err != nil {
+			return err
+		}
+	} else if ruleset, ok := instanceEngineConfig.OciConfig.Annotations[landlock.Annotation]; ok {
+		e.EngineConfig.OciConfig.AddAnnotation(landlock.Annotation, ruleset)
+	}
+
 	// Note - in non-root flow without userns the CLI process joined the cgroup
 	// early in execStarter because we don't have permission to move a parent
 	// process into the cgroup here. In that case, this code is a no-op that
@@ -1127,8 +1239,8 @@ func (e *EngineOperations) setSessionLayer(img *image.Image) error {
 		return fmt.Errorf("cannot use --overlay in conjunction with --writable")
 	}
 
-	// a SIF image may contain one or more overlay partition
-	// check there is at least one ext3 overlay partition
+	// a SIF image may contain one or more overlay partition,
+	// writable ext3 partitions or squashfs layers added by commit
 	hasSIFOverlay := false
 	if img.Type == image.SIF {
 		overlays, err := img.GetOverlayPartitions()
@@ -1136,7 +1248,7 @@ func (e *EngineOperations) setSessionLayer(img *image.Image) error {
 			return fmt.Errorf("while getting overlay partition in SIF image %s: %s", img.Path, err)
 		}
 		for _, o := range overlays {
-			if o.Type == image.EXT3 {
+			if o.Type == image.EXT3 || o.Type == image.SQUASHFS {
 				hasSIFOverlay = true
 				break
 			}
@@ -1277,30 +1389,8 @@ func (e *EngineOperations) loadImages(starterConfig *starter.Config, userNS bool
 		}
 	} else if img.Type == image.SIF {
 		// query the ECL module, proceed if an ecl config file is found
-		ecl, err := syecl.LoadConfig(buildcfg.ECL_FILE)
-		if err == nil {
-			if err = ecl.ValidateConfig(); err != nil {
-				return fmt.Errorf("while validating ECL configuration: %s", err)
-			}
-
-			// Only try to load the global keyring here if the ECL is active.
-			// Otherwise pass through an empty keyring rather than avoiding calling
-			// the ECL functions as this keeps the logic for applying / ignoring ECL in a
-			// single location.
-			var kr openpgp.KeyRing = openpgp.EntityList{}
-			if ecl.Activated {
-				keyring := sypgp.NewHandle(buildcfg.APPTAINER_CONFDIR, sypgp.GlobalHandleOpt())
-				kr, err = keyring.LoadPubKeyring()
-				if err != nil {
-					return fmt.Errorf("while obtaining keyring for ECL: %s", err)
-				}
-			}
-
-			if ok, err := ecl.ShouldRunFp(context.TODO(), img.File, kr); err != nil {
-				return fmt.Errorf("while checking container image with ECL: %s", err)
-			} else if !ok {
-				return errors.New("image prohibited by ECL")
-			}
+		if err := syecl.Enforce(context.TODO(), buildcfg.ECL_FILE, buildcfg.APPTAINER_CONFDIR, img.File); err != nil {
+			return err
 		}
 
 		// look for potential overlay partition in SIF image
@@ -1449,6 +1539,10 @@ func (e *EngineOperations) loadImage(path string, writable bool, userNS bool, el
 	const delSuffix = " (deleted)"
 
 	imgObject, imgErr := image.Init(path, writable)
+	if image.IsArchMismatch(imgErr) && e.EngineConfig.GetAllowArchMismatch() {
+		sylog.Debugf("Ignoring architecture mismatch of %s: %s", path, imgErr)
+		imgErr = nil
+	}
 	// pass imgObject if not nil for overlay and read-only filesystem error.
 	// Do not remove this line
 	if imgObject == nil {
@@ -1603,3 +1697,17 @@ func (e *EngineOperations) setUserInfo(useTargetIDs bool) {
 		}
 	}
 }
+
+// setLandlockRuleset validates the Landlock ruleset file and stores it in
+// the container annotations, to be applied to the container process.
+func (e *EngineOperations) setLandlockRuleset(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("while reading landlock ruleset: %s", err)
+	}
+	if _, err := landlock.ParseRuleset(data); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	e.EngineConfig.OciConfig.AddAnnotation(landlock.Annotation, string(data))
+	return nil
+}
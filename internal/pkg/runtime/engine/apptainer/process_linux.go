@@ -35,9 +35,11 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/instance"
 	"github.com/apptainer/apptainer/internal/pkg/plugin"
 	"github.com/apptainer/apptainer/internal/pkg/security"
+	"github.com/apptainer/apptainer/internal/pkg/security/seccomp"
 	"github.com/apptainer/apptainer/internal/pkg/util/env"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs/files"
 	"github.com/apptainer/apptainer/internal/pkg/util/machine"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	"github.com/apptainer/apptainer/internal/pkg/util/shell"
 	"github.com/apptainer/apptainer/internal/pkg/util/shell/interpreter"
 	"github.com/apptainer/apptainer/internal/pkg/util/user"
@@ -129,10 +131,12 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 		}
 	}
 
+	pidNamespace := false
 	if e.EngineConfig.OciConfig.Linux != nil {
 		namespaces := e.EngineConfig.OciConfig.Linux.Namespaces
 		for _, ns := range namespaces {
 			if ns.Type == specs.PIDNamespace {
+				pidNamespace = true
 				if !e.EngineConfig.GetNoInit() {
 					shimProcess = true
 				}
@@ -141,6 +145,22 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 		}
 	}
 
+	// Without PID namespace, the processes orphaned by the container process
+	// are reparented to the shim process instead of leaking as zombies
+	// in the parent session, like a batch job.
+	if !pidNamespace && !shimProcess && e.EngineConfig.GetSubreaper() {
+		if err := unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("while setting child subreaper: %s", err)
+		}
+		shimProcess = true
+	}
+
+	// The seccomp audit notifications are received by the shim process.
+	auditSyscalls := e.EngineConfig.GetSeccompAudit()
+	if len(auditSyscalls) > 0 {
+		shimProcess = true
+	}
+
 	for _, img := range e.EngineConfig.GetImageList() {
 		// bad file descriptor error is ignored because
 		// the file descriptor has been previously closed
@@ -160,13 +180,25 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 		}
 	}
 
-	// restore the stack size limit for setuid workflow
+	// restore the stack size limit for setuid workflow, and set the
+	// requested core dump size limit
 	for _, limit := range e.EngineConfig.OciConfig.Process.Rlimits {
-		if limit.Type == "RLIMIT_STACK" {
+		switch limit.Type {
+		case "RLIMIT_STACK":
 			if err := rlimit.Set(limit.Type, limit.Soft, limit.Hard); err != nil {
 				return fmt.Errorf("while restoring stack size limit: %s", err)
 			}
-			break
+		case "RLIMIT_CORE":
+			if err := rlimit.Set(limit.Type, limit.Soft, limit.Hard); err != nil {
+				return fmt.Errorf("while setting core dump size limit: %s", err)
+			}
+		}
+	}
+
+	// set the execution domain, inherited by the container processes
+	if e.EngineConfig.OciConfig.Linux != nil && e.EngineConfig.OciConfig.Linux.Personality != nil {
+		if err := setPersonality(e.EngineConfig.OciConfig.Linux.Personality); err != nil {
+			return fmt.Errorf("while setting personality: %s", err)
 		}
 	}
 
@@ -218,7 +250,12 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 		cmd.SysProcAttr = &syscall.SysProcAttr{
 			Setpgid: isInstance,
 		}
-		if err := cmd.Start(); err != nil {
+		if len(auditSyscalls) > 0 {
+			err = startAuditedProcess(cmd, auditSyscalls, &e.EngineConfig.OciConfig.Spec)
+		} else {
+			err = cmd.Start()
+		}
+		if err != nil {
 			if e, ok := err.(*os.PathError); ok {
 				if e.Err.(syscall.Errno) == syscall.ENOEXEC && args[0] != defaultShell {
 					args = append([]string{defaultShell}, args...)
@@ -341,8 +378,10 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 // and thus no additional privileges can be gained.
 //
 // Here, however, apptainer engine does not escalate privileges.
-func (e *EngineOperations) PostStartProcess(_ context.Context, pid int) error {
+func (e *EngineOperations) PostStartProcess(ctx context.Context, pid int) error {
 	sylog.Debugf("Post start process")
+	profile.End("container start")
+	profile.Begin("container run")
 
 	callbackType := (apptainercallback.PostStartProcess)(nil)
 	callbacks, err := plugin.LoadCallbacks(callbackType)
@@ -377,9 +416,22 @@ func (e *EngineOperations) PostStartProcess(_ context.Context, pid int) error {
 			return err
 		}
 
-		logErrPath, logOutPath, err := instance.GetLogFilePaths(name, instance.LogSubDir)
-		if err != nil {
-			return fmt.Errorf("could not find log paths: %s", err)
+		// log paths are only set when logging to files
+		var logErrPath, logOutPath string
+		logDriver := e.EngineConfig.GetInstanceLogDriver()
+		if logDriver == "" || logDriver == instance.FileLogDriver {
+			logErrPath, logOutPath, err = instance.GetLogFilePaths(name, instance.LogSubDir)
+			if err != nil {
+				return fmt.Errorf("could not find log paths: %s", err)
+			}
+			// the master process rotates the log files for the
+			// instance lifetime
+			rotation := instance.LogRotation{
+				MaxSize:  int64(e.EngineConfig.File.InstanceLogMaxSize) * 1024 * 1024,
+				MaxAge:   time.Duration(e.EngineConfig.File.InstanceLogMaxAge) * time.Hour,
+				MaxFiles: int(e.EngineConfig.File.InstanceLogMaxFiles),
+			}
+			go instance.WatchLogFiles(ctx, rotation, logOutPath, logErrPath)
 		}
 
 		file.User = pw.Name
@@ -388,13 +440,16 @@ func (e *EngineOperations) PostStartProcess(_ context.Context, pid int) error {
 		file.Image = e.EngineConfig.GetImage()
 		file.LogErrPath = logErrPath
 		file.LogOutPath = logOutPath
+		file.LogDriver = logDriver
 		file.Checkpoint = e.EngineConfig.GetDMTCPConfig().Checkpoint
+		file.CreatedAt = time.Now().UnixNano()
 
 		ip, err := e.getIP()
 		if err != nil {
 			sylog.Warningf("Could not get ip for %s: %s", pw.Name, err)
 		}
 		file.IP = ip
+		file.MAC = e.getMAC()
 
 		// by default we add all namespaces except the user namespace which
 		// is added conditionally. This delegates checks to the C starter code
@@ -442,6 +497,10 @@ func (e *EngineOperations) PostStartProcess(_ context.Context, pid int) error {
 			return err
 		}
 
+		// the image digest is computed by the master process once the
+		// instance is started, to not delay it with large images
+		go updateImageDigest(file)
+
 		if !e.EngineConfig.GetShareNSMode() {
 			// send SIGUSR1 to the parent process in order to tell it
 			// to detach container process and run as instance.
@@ -470,6 +529,47 @@ func (e *EngineOperations) PostStartProcess(_ context.Context, pid int) error {
 	return nil
 }
 
+// updateImageDigest stores the digest of the instance image in the
+// instance file.
+func updateImageDigest(file *instance.File) {
+	digest, err := instance.ImageDigest(file.Image)
+	if err != nil {
+		sylog.Debugf("Could not compute image digest: %s", err)
+		return
+	} else if digest == "" {
+		return
+	}
+	// the instance file is removed when the instance exits
+	if _, err := os.Stat(file.Path); err != nil {
+		return
+	}
+	file.ImageDigest = digest
+	if err := file.Update(); err != nil {
+		sylog.Debugf("Could not store image digest: %s", err)
+	}
+}
+
+// tagCgroup tags the cgroup of the container with the image it runs, the
+// image digest and the instance name.
+func (e *EngineOperations) tagCgroup() {
+	image := e.EngineConfig.GetImageArg()
+	if image == "" {
+		image = e.EngineConfig.GetImage()
+	}
+	tags := map[string]string{"image": image}
+	if digest, err := instance.ImageDigest(e.EngineConfig.GetImage()); err != nil {
+		sylog.Debugf("Could not compute image digest: %s", err)
+	} else if digest != "" {
+		tags["image.digest"] = digest
+	}
+	if e.EngineConfig.GetInstance() {
+		tags["instance"] = e.CommonConfig.ContainerID
+	}
+	if err := cgroupsManager.Tag(tags); err != nil {
+		sylog.Warningf("Could not tag container cgroup: %s", err)
+	}
+}
+
 func (e *EngineOperations) setPathEnv() {
 	env := e.EngineConfig.OciConfig.Process.Env
 	for _, keyval := range env {
@@ -621,6 +721,43 @@ func (e *EngineOperations) getIP() (string, error) {
 	return "", errors.New("could not get ip")
 }
 
+func (e *EngineOperations) getMAC() string {
+	if networkSetup == nil {
+		return ""
+	}
+
+	net := strings.Split(e.EngineConfig.GetNetwork(), ",")
+
+	mac, err := networkSetup.GetNetworkMAC(net[0])
+	if err != nil {
+		sylog.Debugf("Could not get MAC address: %s", err)
+		return ""
+	}
+	return mac.String()
+}
+
+// personalities maps the personality domains to their value, as defined in
+// linux/personality.h.
+var personalities = map[specs.LinuxPersonalityDomain]uintptr{
+	specs.PerLinux:   0x0000,
+	specs.PerLinux32: 0x0008,
+}
+
+// setPersonality sets the execution domain of the current process, kept
+// across exec. The OS thread executing the container process is locked by
+// the starter.
+func setPersonality(p *specs.LinuxPersonality) error {
+	persona, ok := personalities[p.Domain]
+	if !ok {
+		return fmt.Errorf("unsupported personality domain %s", p.Domain)
+	}
+	if _, _, errno := unix.RawSyscall(unix.SYS_PERSONALITY, persona, 0, 0); errno != 0 {
+		return errno
+	}
+	sylog.Debugf("Personality set to %s", p.Domain)
+	return nil
+}
+
 func getExecError(err error, args []string, shell string) error {
 	// We know the shell exists at this point, so let's inspect its architecture
 	if shell == "" {
@@ -1002,3 +1139,44 @@ func getDockerRunscript(path string) (io.Reader, error) {
 
 	return nil, nil
 }
+
+// startAuditedProcess starts the container process with a seccomp filter
+// notifying the audited syscalls, which are logged and allowed until the
+// container process and its children exit. The filter is loaded in a
+// dedicated thread terminated once the process started, so that the shim
+// process itself is not audited. As Landlock restricts only the thread
+// enforcing it, the ruleset of the container is enforced again there.
+func startAuditedProcess(cmd *exec.Cmd, syscalls []string, spec *specs.Spec) error {
+	errChan := make(chan error, 1)
+
+	go func() {
+		// the thread is not unlocked and so destroyed with the goroutine
+		runtime.LockOSThread()
+
+		if err := security.ConfigureLandlock(spec); err != nil {
+			errChan <- err
+			return
+		}
+		fd, err := seccomp.LoadAuditFilter(syscalls)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			syscall.Close(fd)
+			errChan <- err
+			return
+		}
+		go func() {
+			defer syscall.Close(fd)
+			err := seccomp.Audit(fd, func(pid int, name string, args []uint64) {
+				comm, _ := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+				sylog.Infof("seccomp audit: process %d (%s) called %s with arguments %#x", pid, strings.TrimSpace(string(comm)), name, args)
+			})
+			sylog.Debugf("Seccomp audit stopped: %s", err)
+		}()
+		errChan <- nil
+	}()
+
+	return <-errChan
+}
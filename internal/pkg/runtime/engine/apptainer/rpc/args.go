@@ -152,6 +152,12 @@ type WriteFileArgs struct {
 	Perm     os.FileMode
 }
 
+// UpdateFileArgs defines the arguments to updatefile.
+type UpdateFileArgs struct {
+	Filename string
+	Data     []byte
+}
+
 // NvCCLIArgs defines the arguments to NvCCLI.
 type NvCCLIArgs struct {
 	Flags      []string
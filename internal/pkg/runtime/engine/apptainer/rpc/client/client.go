@@ -273,6 +273,15 @@ func (t *RPC) WriteFile(filename string, data []byte, perm os.FileMode) error {
 	return t.Client.Call(t.Name+".WriteFile", arguments, nil)
 }
 
+// UpdateFile calls the updatefile RPC using the supplied arguments.
+func (t *RPC) UpdateFile(filename string, data []byte) error {
+	arguments := &args.UpdateFileArgs{
+		Filename: filename,
+		Data:     data,
+	}
+	return t.Client.Call(t.Name+".UpdateFile", arguments, nil)
+}
+
 // NvCCLI will call nvidia-container-cli to configure GPU(s) for the container.
 func (t *RPC) NvCCLI(flags []string, rootFsPath string, userNS bool) error {
 	arguments := &args.NvCCLIArgs{
@@ -446,6 +446,30 @@ func (t *Methods) WriteFile(arguments *args.WriteFileArgs, _ *int) error {
 	return err
 }
 
+// UpdateFile replaces the content of an existing regular file, without
+// following a symlink.
+func (t *Methods) UpdateFile(arguments *args.UpdateFileArgs, _ *int) error {
+	f, err := os.OpenFile(arguments.Filename, os.O_WRONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %s", arguments.Filename, err)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %s", arguments.Filename, err)
+	} else if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", arguments.Filename)
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate file %s: %s", arguments.Filename, err)
+	}
+	if _, err := f.Write(arguments.Data); err != nil {
+		return fmt.Errorf("failed to write file %s: %s", arguments.Filename, err)
+	}
+	return f.Close()
+}
+
 // NvCCLI will call nvidia-container-cli to configure GPU(s) for the container.
 func (t *Methods) NvCCLI(arguments *args.NvCCLIArgs, _ *int) (err error) {
 	runtime.LockOSThread()
@@ -30,6 +30,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/apptainer/apptainer/pkg/util/capabilities"
 	"github.com/opencontainers/runtime-spec/specs-go"
@@ -166,6 +167,20 @@ func (g *Generator) AddOrReplaceLinuxNamespace(ns specs.LinuxNamespaceType, path
 	g.Config.Linux.Namespaces = append(g.Config.Linux.Namespaces, namespace)
 }
 
+// RemoveLinuxNamespace removes a namespace.
+func (g *Generator) RemoveLinuxNamespace(ns specs.LinuxNamespaceType) {
+	if g.Config.Linux == nil {
+		return
+	}
+	namespaces := g.Config.Linux.Namespaces[:0]
+	for _, n := range g.Config.Linux.Namespaces {
+		if n.Type != ns {
+			namespaces = append(namespaces, n)
+		}
+	}
+	g.Config.Linux.Namespaces = namespaces
+}
+
 // SetProcessArgs sets container process arguments.
 func (g *Generator) SetProcessArgs(args []string) {
 	g.initProcess()
@@ -190,11 +205,46 @@ func (g *Generator) SetRootPath(path string) {
 	g.Config.Root.Path = path
 }
 
+// SetRootReadonly sets if container root filesystem is read-only or not.
+func (g *Generator) SetRootReadonly(b bool) {
+	g.initRoot()
+	g.Config.Root.Readonly = b
+}
+
 // AddMount adds a mount for container environment setup.
 func (g *Generator) AddMount(mnt specs.Mount) {
 	g.Config.Mounts = append(g.Config.Mounts, mnt)
 }
 
+// AddTmpfsMount adds a tmpfs mount on dest with the mount options, replacing
+// any mount with the same destination.
+func (g *Generator) AddTmpfsMount(dest string, options []string) {
+	mnt := specs.Mount{
+		Destination: dest,
+		Type:        "tmpfs",
+		Source:      "tmpfs",
+		Options:     options,
+	}
+	for i, m := range g.Config.Mounts {
+		if m.Destination == dest {
+			g.Config.Mounts[i] = mnt
+			return
+		}
+	}
+	g.AddMount(mnt)
+}
+
+// RemoveMounts removes the mounts on dest and below it.
+func (g *Generator) RemoveMounts(dest string) {
+	mounts := g.Config.Mounts[:0]
+	for _, m := range g.Config.Mounts {
+		if m.Destination != dest && !strings.HasPrefix(m.Destination, dest+"/") {
+			mounts = append(mounts, m)
+		}
+	}
+	g.Config.Mounts = mounts
+}
+
 // AddLinuxUIDMapping adds a UID mapping.
 func (g *Generator) AddLinuxUIDMapping(host, container, size uint32) {
 	g.initLinux()
@@ -286,6 +336,14 @@ func (g *Generator) SetProcessApparmorProfile(prof string) {
 	g.Config.Process.ApparmorProfile = prof
 }
 
+// AddAnnotation adds or replaces an annotation of the container.
+func (g *Generator) AddAnnotation(key, value string) {
+	if g.Config.Annotations == nil {
+		g.Config.Annotations = make(map[string]string)
+	}
+	g.Config.Annotations[key] = value
+}
+
 // Save writes the configuration into w.
 func (g *Generator) Save(w io.Writer) (err error) {
 	var data []byte
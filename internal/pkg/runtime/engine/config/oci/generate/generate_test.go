@@ -165,6 +165,11 @@ func TestGenerate(t *testing.T) {
 	if len(config.Linux.Namespaces) != 2 {
 		t.Fatalf("wrong OCI process namespace size: %d instead of 2", len(config.Linux.Namespaces))
 	}
+	g.AddOrReplaceLinuxNamespace(specs.NetworkNamespace, "")
+	g.RemoveLinuxNamespace(specs.NetworkNamespace)
+	if len(config.Linux.Namespaces) != 2 {
+		t.Fatalf("wrong OCI process namespace size: %d instead of 2", len(config.Linux.Namespaces))
+	}
 
 	g.AddProcessRlimits("A_LIMIT", 1024, 128)
 	if len(config.Process.Rlimits) != 1 {
@@ -178,6 +183,34 @@ func TestGenerate(t *testing.T) {
 	if rlimit.Type != "A_SEC_LIMIT" || rlimit.Hard != 2048 || rlimit.Soft != 1024 {
 		t.Fatalf("wrong OCI process rlimit entry: %v", rlimit)
 	}
+
+	g.SetRootReadonly(true)
+	if !config.Root.Readonly {
+		t.Fatalf("OCI root is not read-only")
+	}
+
+	n := len(config.Mounts)
+	g.AddMount(specs.Mount{Destination: "/tmp", Type: "bind", Source: "/tmp"})
+	g.AddTmpfsMount("/run", []string{"nosuid"})
+	g.AddTmpfsMount("/tmp", []string{"nosuid", "size=64m"})
+	if len(config.Mounts) != n+2 {
+		t.Fatalf("wrong OCI mounts size: %d instead of %d", len(config.Mounts), n+2)
+	}
+	if m := config.Mounts[n]; m.Destination != "/tmp" || m.Type != "tmpfs" || !reflect.DeepEqual(m.Options, []string{"nosuid", "size=64m"}) {
+		t.Fatalf("wrong OCI tmpfs mount entry: %v", m)
+	}
+
+	g.AddMount(specs.Mount{Destination: "/run/lock", Type: "tmpfs", Source: "tmpfs"})
+	g.AddMount(specs.Mount{Destination: "/runtime", Type: "tmpfs", Source: "tmpfs"})
+	g.RemoveMounts("/run")
+	if len(config.Mounts) != n+2 {
+		t.Fatalf("wrong OCI mounts size: %d instead of %d", len(config.Mounts), n+2)
+	}
+	for _, m := range config.Mounts {
+		if m.Destination == "/run" || m.Destination == "/run/lock" {
+			t.Fatalf("OCI mount %s not removed", m.Destination)
+		}
+	}
 }
 
 var ociJSON = `{
@@ -35,7 +35,14 @@ type EngineConfig struct {
 	SyncSocket     string           `json:"syncSocket"`
 	EmptyProcess   bool             `json:"emptyProcess"`
 	Exec           bool             `json:"exec"`
+	Init           bool             `json:"init,omitempty"`
+	AllowNewPrivs  bool             `json:"allowNewPrivs,omitempty"`
 	SystemdCgroups bool             `json:"systemdCgroups"`
+	CgroupParent   string           `json:"cgroupParent,omitempty"`
+	Network        string           `json:"network,omitempty"`
+	CNIConfPath    string           `json:"cniConfPath,omitempty"`
+	CNIPluginPath  string           `json:"cniPluginPath,omitempty"`
+	ScratchDirs    []string         `json:"scratchDirs,omitempty"`
 	Cgroups        *cgroups.Manager `json:"-"`
 
 	sync.Mutex `json:"-"`
@@ -101,6 +108,17 @@ func (e *EngineConfig) GetPidFile() string {
 	return e.PidFile
 }
 
+// SetInit sets whether to run an init process reaping zombie processes and
+// forwarding signals to the container process.
+func (e *EngineConfig) SetInit(init bool) {
+	e.Init = init
+}
+
+// GetInit returns whether to run an init process in the container.
+func (e *EngineConfig) GetInit() bool {
+	return e.Init
+}
+
 // SetSystemdCgroups sets whether to manage cgroups with systemd.
 func (e *EngineConfig) SetSystemdCgroups(systemd bool) {
 	e.SystemdCgroups = systemd
@@ -110,3 +128,37 @@ func (e *EngineConfig) SetSystemdCgroups(systemd bool) {
 func (e *EngineConfig) GetSystemdCgroups() bool {
 	return e.SystemdCgroups
 }
+
+// SetAllowNewPrivs sets whether the container process is allowed to gain
+// new privileges whatever the default of apptainer.conf.
+func (e *EngineConfig) SetAllowNewPrivs(allow bool) {
+	e.AllowNewPrivs = allow
+}
+
+// GetAllowNewPrivs returns whether the container process is allowed to gain
+// new privileges.
+func (e *EngineConfig) GetAllowNewPrivs() bool {
+	return e.AllowNewPrivs
+}
+
+// SetCgroupParent sets the parent cgroup, or systemd slice, under which
+// the cgroup of the container is created.
+func (e *EngineConfig) SetCgroupParent(parent string) {
+	e.CgroupParent = parent
+}
+
+// GetCgroupParent returns the parent cgroup of the container.
+func (e *EngineConfig) GetCgroupParent() string {
+	return e.CgroupParent
+}
+
+// SetNetwork sets the network requested for the container: none, host or
+// a comma separated list of CNI network configurations.
+func (e *EngineConfig) SetNetwork(network string) {
+	e.Network = network
+}
+
+// GetNetwork returns the network requested for the container.
+func (e *EngineConfig) GetNetwork() string {
+	return e.Network
+}
@@ -162,7 +162,7 @@ var statusChan = make(chan string, 1)
 // command set requires privileged execution.
 //
 //nolint:maintidx
-func (e *EngineOperations) CreateContainer(_ context.Context, pid int, rpcConn net.Conn) error {
+func (e *EngineOperations) CreateContainer(ctx context.Context, pid int, rpcConn net.Conn) error {
 	var err error
 
 	if e.CommonConfig.EngineName != Name {
@@ -333,6 +333,10 @@ func (e *EngineOperations) CreateContainer(_ context.Context, pid int, rpcConn n
 		}
 	}
 
+	if err := e.setupNetwork(ctx, pid); err != nil {
+		return err
+	}
+
 	method := "pivot"
 	if !c.mntNS {
 		method = "chroot"
@@ -492,6 +496,14 @@ func (c *container) addCgroups(pid int, system *mount.System) error {
 	systemd := c.engine.EngineConfig.GetSystemdCgroups()
 	cgroupsPath := c.engine.EngineConfig.OciConfig.Linux.CgroupsPath
 
+	if parent := c.engine.EngineConfig.GetCgroupParent(); parent != "" && cgroupsPath == "" {
+		if systemd {
+			cgroupsPath = parent + ":apptainer-oci:" + name
+		} else {
+			cgroupsPath = filepath.Join(parent, "apptainer-oci", name)
+		}
+	}
+
 	if !systemd && !filepath.IsAbs(cgroupsPath) {
 		if cgroupsPath == "" {
 			cgroupsPath = filepath.Join("/apptainer-oci", name)
@@ -0,0 +1,109 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/pkg/network"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	hostNet = "host"
+	noneNet = "none"
+)
+
+var (
+	// defaultCNIConfPath is the default directory to CNI network configuration files.
+	defaultCNIConfPath = filepath.Join(buildcfg.SYSCONFDIR, "apptainer", "network")
+	// defaultCNIPluginPath is the default directory to CNI plugins executables.
+	defaultCNIPluginPath = filepath.Join(buildcfg.LIBEXECDIR, "apptainer", "cni")
+)
+
+// networkSetup holds the CNI networks added to the container, deleted
+// during cleanup. netNs holds a reference to the network namespace of the
+// container, so that they can be deleted after the container process exits.
+var (
+	networkSetup *network.Setup
+	netNs        *os.File
+)
+
+// checkNetwork ensures that the container doesn't share the network
+// namespace of the host when disallowed by the administrator.
+func (e *EngineOperations) checkNetwork(sConf *apptainerconf.File) error {
+	if sConf.AllowOciHostNetwork {
+		return nil
+	}
+	for _, ns := range e.EngineConfig.OciConfig.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("host network disallowed by administrator, use --net %s or a CNI network", noneNet)
+}
+
+// setupNetwork adds the CNI networks requested with --net to the network
+// namespace of the container process.
+func (e *EngineOperations) setupNetwork(ctx context.Context, pid int) error {
+	net := e.EngineConfig.GetNetwork()
+	if net == "" || net == hostNet || net == noneNet {
+		return nil
+	}
+
+	f, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return fmt.Errorf("could not hold network namespace reference: %s", err)
+	}
+	netNs = f
+
+	cniPath := &network.CNIPath{
+		Conf:   e.EngineConfig.CNIConfPath,
+		Plugin: e.EngineConfig.CNIPluginPath,
+	}
+	if cniPath.Conf == "" {
+		cniPath.Conf = defaultCNIConfPath
+	}
+	if cniPath.Plugin == "" {
+		cniPath.Plugin = defaultCNIPluginPath
+	}
+
+	nspath := fmt.Sprintf("/proc/%d/fd/%d", os.Getpid(), netNs.Fd())
+	setup, err := network.NewSetup(strings.Split(net, ","), e.CommonConfig.ContainerID, nspath, cniPath)
+	if err != nil {
+		return fmt.Errorf("network setup failed: %s", err)
+	}
+	setup.SetEnvPath("/bin:/sbin:/usr/bin:/usr/sbin")
+
+	sylog.Debugf("Adding CNI networks %s", net)
+	if err := setup.AddNetworks(ctx); err != nil {
+		return fmt.Errorf("%s", err)
+	}
+	networkSetup = setup
+	return nil
+}
+
+// cleanupNetwork deletes the CNI networks added to the container.
+func (e *EngineOperations) cleanupNetwork(ctx context.Context) {
+	if networkSetup != nil {
+		sylog.Debugf("Cleaning up CNI network config %s", e.EngineConfig.GetNetwork())
+		if err := networkSetup.DelNetworks(ctx); err != nil {
+			sylog.Errorf("could not delete networks: %v", err)
+		}
+		networkSetup = nil
+	}
+	if netNs != nil {
+		netNs.Close()
+		netNs = nil
+	}
+}
@@ -67,11 +67,19 @@ func (e *EngineOperations) PrepareConfig(starterConfig *starter.Config) error {
 	if !fs.IsOwner(buildcfg.APPTAINER_CONF_FILE, 0) {
 		return fmt.Errorf("%s must be owned by root", buildcfg.APPTAINER_CONF_FILE)
 	}
-	sConf, err := apptainerconf.Parse(buildcfg.APPTAINER_CONF_FILE)
+	sConf, err := apptainerconf.ParseWithOverrides(buildcfg.APPTAINER_CONF_FILE, os.Getuid())
 	if err != nil {
 		return fmt.Errorf("unable to parse apptainer.conf file: %s", err)
 	}
 	e.EngineConfig.SystemdCgroups = sConf.SystemdCgroups
+	e.EngineConfig.CNIConfPath = sConf.CniConfPath
+	e.EngineConfig.CNIPluginPath = sConf.CniPluginPath
+
+	if parent := e.EngineConfig.GetCgroupParent(); parent != "" {
+		if err := cgroups.CheckParent(parent, e.EngineConfig.SystemdCgroups); err != nil {
+			return err
+		}
+	}
 
 	// reset state config that could be passed to engine
 	e.EngineConfig.State = ociruntime.State{}
@@ -102,6 +110,13 @@ func (e *EngineOperations) PrepareConfig(starterConfig *starter.Config) error {
 		}
 	}
 
+	if err := e.checkNetwork(sConf); err != nil {
+		return err
+	}
+	if net := e.EngineConfig.GetNetwork(); net != "" && net != "host" {
+		starterConfig.SetBringLoopbackInterface(true)
+	}
+
 	starterConfig.SetNsFlagsFromSpec(e.EngineConfig.OciConfig.Linux.Namespaces)
 	if err := starterConfig.SetNsPathFromSpec(e.EngineConfig.OciConfig.Linux.Namespaces); err != nil {
 		return err
@@ -128,6 +143,9 @@ func (e *EngineOperations) PrepareConfig(starterConfig *starter.Config) error {
 		starterConfig.SetMountPropagation("private")
 	}
 
+	if sConf.RootNoNewPrivs && !e.EngineConfig.GetAllowNewPrivs() {
+		e.EngineConfig.OciConfig.SetProcessNoNewPrivileges(true)
+	}
 	starterConfig.SetNoNewPrivs(e.EngineConfig.OciConfig.Process.NoNewPrivileges)
 
 	if e.EngineConfig.OciConfig.Process.Capabilities != nil {
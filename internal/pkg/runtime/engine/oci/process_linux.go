@@ -33,6 +33,7 @@ import (
 	"github.com/apptainer/apptainer/pkg/util/unix"
 	"github.com/creack/pty"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
+	sysunix "golang.org/x/sys/unix"
 )
 
 // StartProcess is called during stage2 after RPC server finished
@@ -157,10 +158,82 @@ func (e *EngineOperations) StartProcess(masterConnFd int) error {
 		return fmt.Errorf("failed to apply security configuration: %s", err)
 	}
 
+	if e.EngineConfig.GetInit() && !e.EngineConfig.Exec {
+		return e.initProcess(masterConn, args, env)
+	}
+
 	err = syscall.Exec(args[0], args, env)
 	return fmt.Errorf("exec %s failed: %s", args[0], err)
 }
 
+// initProcess runs the container process as a child of an init process,
+// which reaps the zombie processes of the container, forwards the signals
+// it receives to the container process and exits with its exit status.
+func (e *EngineOperations) initProcess(masterConn net.Conn, args, env []string) error {
+	// handle signals before starting the container process to not miss
+	// its exit, use a channel size of two as we may receive SIGURG used
+	// by the Go runtime for goroutine preemption
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals)
+
+	// also reap orphans when the container doesn't have its own PID
+	// namespace, where this process isn't PID 1
+	if err := sysunix.Prctl(sysunix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set child subreaper: %s", err)
+	}
+
+	cmd := osexec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// with a terminal, the container process is the foreground process
+	// group, so that it receives the terminal signals directly
+	if e.EngineConfig.MasterPts != -1 {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid:    true,
+			Foreground: true,
+			Ctty:       int(os.Stdin.Fd()),
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("exec %s failed: %s", args[0], err)
+	}
+	cmdPid := cmd.Process.Pid
+
+	// notify the master that the container process is started
+	masterConn.Close()
+
+	for s := range signals {
+		switch s {
+		case syscall.SIGCHLD:
+			for {
+				var status syscall.WaitStatus
+
+				wpid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+				if wpid <= 0 || err != nil {
+					break
+				}
+				if wpid != cmdPid {
+					sylog.Debugf("Reaped process %d", wpid)
+					continue
+				}
+				if status.Signaled() {
+					os.Exit(128 + int(status.Signal()))
+				}
+				os.Exit(status.ExitStatus())
+			}
+		case syscall.SIGURG:
+			// ignore SIGURG used for goroutine preemption
+		default:
+			// the container process may have exited and be reaped with
+			// the next SIGCHLD
+			_ = syscall.Kill(cmdPid, s.(syscall.Signal))
+		}
+	}
+	return nil
+}
+
 // PreStartProcess is called from master after before container startup.
 //
 // Additional privileges may be gained when running
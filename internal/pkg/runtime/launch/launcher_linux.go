@@ -14,14 +14,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/cdi"
 	"github.com/apptainer/apptainer/internal/pkg/cgroups"
 	"github.com/apptainer/apptainer/internal/pkg/checkpoint/dmtcp"
 	"github.com/apptainer/apptainer/internal/pkg/fakeroot"
@@ -31,16 +35,24 @@ import (
 	"github.com/apptainer/apptainer/internal/pkg/plugin"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci"
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/launchhooks"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/warm"
 	"github.com/apptainer/apptainer/internal/pkg/security"
+	"github.com/apptainer/apptainer/internal/pkg/security/seccomp"
+	"github.com/apptainer/apptainer/internal/pkg/syecl"
 	"github.com/apptainer/apptainer/internal/pkg/util/bin"
 	"github.com/apptainer/apptainer/internal/pkg/util/env"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs/squashfs"
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/teardown"
 	"github.com/apptainer/apptainer/internal/pkg/util/gpu"
+	"github.com/apptainer/apptainer/internal/pkg/util/machine"
+	"github.com/apptainer/apptainer/internal/pkg/util/profile"
 	"github.com/apptainer/apptainer/internal/pkg/util/starter"
 	"github.com/apptainer/apptainer/internal/pkg/util/user"
 	"github.com/apptainer/apptainer/pkg/build/types"
 	imgutil "github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/network"
 	clicallback "github.com/apptainer/apptainer/pkg/plugin/callback/cli"
 	apptainercallback "github.com/apptainer/apptainer/pkg/plugin/callback/runtime/engine/apptainer"
 	apptainerConfig "github.com/apptainer/apptainer/pkg/runtime/engine/apptainer/config"
@@ -50,9 +62,12 @@ import (
 	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 	"github.com/apptainer/apptainer/pkg/util/capabilities"
 	"github.com/apptainer/apptainer/pkg/util/cryptkey"
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/apptainer/apptainer/pkg/util/fs/proc"
 	"github.com/apptainer/apptainer/pkg/util/namespaces"
 	"github.com/apptainer/apptainer/pkg/util/rlimit"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	units "github.com/docker/go-units"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 )
@@ -65,6 +80,13 @@ func NewLauncher(opts ...Option) (*Launcher, error) {
 		}
 	}
 
+	// A network namespace to join can be given by name.
+	netnsPath, err := network.NamespacePath(lo.NetnsPath)
+	if err != nil {
+		return nil, err
+	}
+	lo.NetnsPath = netnsPath
+
 	// Initialize empty default Apptainer Engine and OCI configuration
 	engineConfig := apptainerConfig.NewConfig()
 	imageArg := os.Getenv("IMAGE_ARG")
@@ -96,6 +118,16 @@ func NewLauncher(opts ...Option) (*Launcher, error) {
 func (l *Launcher) Exec(ctx context.Context, image string, args []string, instanceName string) error {
 	var err error
 
+	endConfig := profile.Begin("engine configuration")
+
+	// complete the teardowns left by previous containers which crashed,
+	// or had busy mounts at exit
+	if _, pending, err := teardown.Process(); err != nil {
+		sylog.Debugf("While processing deferred teardowns: %s", err)
+	} else if pending > 0 {
+		sylog.Debugf("%d deferred teardowns still pending", pending)
+	}
+
 	var fakerootPath string
 	if l.cfg.Fakeroot {
 		if (l.uid == 0) && namespaces.IsUnprivileged() {
@@ -200,6 +232,15 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 	// Prefer underlay for bind
 	l.engineConfig.SetUnderlay(l.cfg.Underlay)
 
+	// Check the image targets an architecture the host can run, before
+	// opening it.
+	if !l.engineConfig.GetInstanceJoin() {
+		if err := l.checkArch(); err != nil {
+			sylog.Fatalf("%s", err)
+		}
+	}
+	l.engineConfig.SetAllowArchMismatch(l.cfg.AllowArchMismatch)
+
 	// Check key is available for encrypted image, if applicable.
 	// If we are joining an instance, then any encrypted image is already mounted.
 	if !l.engineConfig.GetInstanceJoin() {
@@ -219,6 +260,16 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 		l.generator.AddProcessRlimits("RLIMIT_STACK", hard, soft)
 	}
 
+	// Core dump limit and directory, which adds a bind path.
+	if err := l.setCoreDump(); err != nil {
+		sylog.Fatalf("While setting core dump configuration: %s", err)
+	}
+
+	// CDI devices, which add bind paths and environment variables.
+	if err := l.setDevices(); err != nil {
+		sylog.Fatalf("While setting CDI devices: %s", err)
+	}
+
 	// Handle requested binds, fuse mounts.
 	if err := l.setBinds(fakerootPath); err != nil {
 		sylog.Fatalf("While setting bind mount configuration: %s", err)
@@ -226,6 +277,10 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 	if err := l.setFuseMounts(); err != nil {
 		sylog.Fatalf("While setting FUSE mount configuration: %s", err)
 	}
+	l.engineConfig.SetRootfsPropagation(l.cfg.RootfsPropagation)
+	if err := l.setPersonality(); err != nil {
+		sylog.Fatalf("While setting personality: %s", err)
+	}
 
 	// Set the home directory that should be effective in the container.
 	if err := l.setHome(); err != nil {
@@ -276,6 +331,7 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 	// Set requested capabilities (effective for root, or if sysadmin has permitted to another user).
 	l.engineConfig.SetAddCaps(l.cfg.AddCaps)
 	l.engineConfig.SetDropCaps(l.cfg.DropCaps)
+	l.engineConfig.SetAmbientCaps(l.cfg.AmbientCaps)
 
 	// Custom --config file (only effective in non-setuid or as root).
 	l.engineConfig.SetConfigurationFile(l.cfg.ConfigFile)
@@ -303,9 +359,36 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 	// User can optionally force dropping all privs from root in the container.
 	l.engineConfig.SetNoPrivs(l.cfg.NoPrivs)
 
+	// No new privileges is always set for unprivileged users, root can
+	// override the default of apptainer.conf.
+	if l.cfg.NoNewPrivs && l.cfg.AllowNewPrivs {
+		sylog.Fatalf("--no-new-privs and --allow-new-privs are mutually exclusive")
+	}
+	l.engineConfig.SetNoNewPrivs(l.cfg.NoNewPrivs)
+	err = withPrivilege(l.uid, l.cfg.AllowNewPrivs, "--allow-new-privs", func() error {
+		l.engineConfig.SetAllowNewPrivs(l.cfg.AllowNewPrivs)
+		return nil
+	})
+	if err != nil {
+		sylog.Fatalf("Could not configure --allow-new-privs: %s", err)
+	}
+
 	// Set engine --security options (selinux, apparmor, seccomp functionality).
 	l.engineConfig.SetSecurity(l.cfg.SecurityOpts)
 
+	// Log the interesting syscalls of the container processes with
+	// --seccomp-audit.
+	if l.cfg.SeccompAudit {
+		if !seccomp.Enabled() {
+			sylog.Fatalf("--seccomp-audit requires Apptainer built with seccomp support")
+		}
+		syscalls := l.cfg.SeccompAuditSyscalls
+		if len(syscalls) == 0 {
+			syscalls = seccomp.DefaultAuditSyscalls
+		}
+		l.engineConfig.SetSeccompAudit(syscalls)
+	}
+
 	// User can override shell used when entering container.
 	l.engineConfig.SetShell(l.cfg.ShellPath)
 	if l.cfg.ShellPath != "" {
@@ -362,6 +445,10 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 			return fmt.Errorf("instance %s already exists", instanceName)
 		}
 
+		if err := l.setStaticAddress(); err != nil {
+			return err
+		}
+
 		if l.cfg.Boot {
 			l.cfg.Namespaces.UTS = true
 			l.cfg.Namespaces.Net = true
@@ -377,6 +464,8 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 		// Set sharens mode
 		l.engineConfig.SetShareNSMode(l.cfg.ShareNSMode)
 		l.engineConfig.SetShareNSFd(l.cfg.ShareNSFd)
+	} else if l.cfg.StaticIP != "" || l.cfg.StaticMAC != "" {
+		return fmt.Errorf("--ip and --mac are only supported when starting an instance")
 	}
 
 	// Set runscript timeout
@@ -397,10 +486,14 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 		l.generator.SetProcessEnvWithPrefixes(env.ApptainerPrefixes, "SHARENS_MASTER", "1")
 	}
 
+	endConfig()
+
 	// Get image ready to run, if needed, via FUSE mount / extraction / image driver handling.
+	endPrepare := profile.Begin("image preparation")
 	if err := l.prepareImage(ctx, insideUserNs, image); err != nil {
 		return fmt.Errorf("while preparing image: %s", err)
 	}
+	endPrepare()
 
 	loadOverlay := false
 	if !l.cfg.Namespaces.User && (buildcfg.APPTAINER_SUID_INSTALL == 1 || os.Getuid() == 0) {
@@ -422,9 +515,29 @@ func (l *Launcher) Exec(ctx context.Context, image string, args []string, instan
 	// Allow any plugins with callbacks to modify the assembled Config
 	runPluginCallbacks(cfg)
 
+	// Allow the site launch hooks to veto or annotate the launch
+	if err := l.runPreLaunchHooks(ctx, image, args, instanceName); err != nil {
+		return err
+	}
+
+	// the starter phases are recorded by the engine, which reports them
+	// when the container exits
+	profile.Begin("starter")
+	if p, err := profile.Export(); err != nil {
+		sylog.Debugf("Could not export startup profile: %s", err)
+	} else {
+		l.engineConfig.SetProfile(p)
+	}
+
 	// Call the starter binary using our prepared config.
 	if l.engineConfig.GetInstance() && !l.cfg.ShareNSMode {
 		err = l.starterInstance(loadOverlay, insideUserNs, instanceName, useSuid, cfg)
+		if err == nil {
+			profile.End("starter")
+			if err := profile.Report(os.Stderr); err != nil {
+				sylog.Warningf("Could not report startup profile: %s", err)
+			}
+		}
 	} else {
 		var imageFilename string
 		var fileInfoErr error
@@ -580,7 +693,7 @@ func (l *Launcher) setImageOrInstance(image string, name string) error {
 func (l *Launcher) checkEncryptionKey() error {
 	sylog.Debugf("Checking for encrypted system partition")
 	img, err := imgutil.Init(l.engineConfig.GetImage(), false)
-	if err != nil {
+	if err != nil && !imgutil.IsArchMismatch(err) {
 		return fmt.Errorf("could not open image %s: %w", l.engineConfig.GetImage(), err)
 	}
 
@@ -592,8 +705,14 @@ func (l *Launcher) checkEncryptionKey() error {
 	if part.Type == imgutil.ENCRYPTSQUASHFS || part.Type == imgutil.GOCRYPTFSSQUASHFS {
 		sylog.Debugf("Encrypted container filesystem detected")
 
+		if part.Type == imgutil.GOCRYPTFSSQUASHFS {
+			if err := fips.Forbid("gocryptfs encryption"); err != nil {
+				return fmt.Errorf("cannot decrypt %s: %w", l.engineConfig.GetImage(), err)
+			}
+		}
+
 		if l.cfg.KeyInfo == nil {
-			return fmt.Errorf("required option --passphrase or --pem-path missing")
+			return fmt.Errorf("required option --passphrase, --pem-path, --age-path or --pkcs11-uri missing")
 		}
 
 		plaintextKey, err := cryptkey.PlaintextKey(*l.cfg.KeyInfo, l.engineConfig.GetImage())
@@ -653,6 +772,149 @@ func (l *Launcher) useSuid(insideUserNs bool) (useSuid bool) {
 	return useSuid
 }
 
+// corePatternFile is the kernel file holding the pattern of core dump files.
+const corePatternFile = "/proc/sys/kernel/core_pattern"
+
+// setPersonality sets the execution domain of the container process, so
+// that 32-bit userlands report a 32-bit architecture with linux32.
+func (l *Launcher) setPersonality() error {
+	var domain specs.LinuxPersonalityDomain
+	switch strings.ToLower(l.cfg.Personality) {
+	case "":
+		return nil
+	case "linux":
+		domain = specs.PerLinux
+	case "linux32":
+		domain = specs.PerLinux32
+	default:
+		return fmt.Errorf("invalid personality %q, must be linux or linux32", l.cfg.Personality)
+	}
+	if l.generator.Config.Linux == nil {
+		l.generator.Config.Linux = &specs.Linux{}
+	}
+	l.generator.Config.Linux.Personality = &specs.LinuxPersonality{Domain: domain}
+	return nil
+}
+
+// setCoreDump sets the RLIMIT_CORE limit of the container process, and binds
+// the requested core dump directory where the kernel writes core dumps in the
+// container. Without explicit limit, a core dump directory raises the limit
+// to the hard limit.
+func (l *Launcher) setCoreDump() error {
+	if l.cfg.CoreLimit == "" && l.cfg.CoreDir == "" {
+		return nil
+	}
+
+	_, hard, err := rlimit.Get("RLIMIT_CORE")
+	if err != nil {
+		return fmt.Errorf("can't retrieve core size limit: %s", err)
+	}
+	soft := hard
+	switch l.cfg.CoreLimit {
+	case "":
+	case "unlimited":
+		soft = unix.RLIM_INFINITY
+	default:
+		size, err := units.RAMInBytes(l.cfg.CoreLimit)
+		if err != nil || size < 0 {
+			return fmt.Errorf("invalid core size limit %q, must be 'unlimited' or a size like 512M", l.cfg.CoreLimit)
+		}
+		soft = uint64(size)
+	}
+	if soft > hard {
+		if l.uid != 0 {
+			return fmt.Errorf("core size limit %s exceeds the hard limit of %d bytes", l.cfg.CoreLimit, hard)
+		}
+		hard = soft
+	}
+	l.generator.AddProcessRlimits("RLIMIT_CORE", hard, soft)
+
+	if l.cfg.CoreDir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(l.cfg.CoreDir)
+	if err != nil {
+		return fmt.Errorf("while resolving core dump directory: %s", err)
+	}
+	if fi, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("while checking core dump directory: %s", err)
+	} else if !fi.IsDir() {
+		return fmt.Errorf("core dump directory %s is not a directory", dir)
+	}
+
+	b, err := os.ReadFile(corePatternFile)
+	if err != nil {
+		return fmt.Errorf("while reading %s: %s", corePatternFile, err)
+	}
+	pattern := strings.TrimSpace(string(b))
+	dest := dir
+	switch {
+	case strings.HasPrefix(pattern, "|"):
+		// cores are piped to a helper running on the host, like
+		// systemd-coredump, and never written in the container
+		sylog.Warningf("Core dumps are handled by the host with core_pattern %q, ignoring --core-dir", pattern)
+		return nil
+	case filepath.IsAbs(pattern):
+		// cores are written at the path of the pattern, resolved in the
+		// mount namespace of the crashing process
+		dest = filepath.Dir(pattern)
+	default:
+		sylog.Infof("Core dumps are written in the working directory of the crashing process with core_pattern %q, use --pwd %s to write them in the core dump directory", pattern, dir)
+	}
+	sylog.Debugf("Binding core dump directory %s to %s", dir, dest)
+	l.cfg.BindPaths = append(l.cfg.BindPaths, dir+":"+dest)
+	return nil
+}
+
+// setDevices applies the container edits of the requested CDI devices, found
+// in the CDI spec files of the directories set in apptainer.conf: their
+// environment variables, not overriding the ones set by the user, and binds
+// of their device nodes and mounts. Their hooks are not supported.
+func (l *Launcher) setDevices() error {
+	if len(l.cfg.Devices) == 0 {
+		return nil
+	}
+	registry := cdi.Scan(cdi.DirsOrDefault(l.engineConfig.File.CDIDirs))
+	for _, err := range registry.Errors {
+		sylog.Debugf("Ignoring CDI spec: %s", err)
+	}
+	for _, name := range l.cfg.Devices {
+		d, err := registry.Device(name)
+		if err != nil {
+			return err
+		}
+		edits := d.Edits()
+		for _, env := range edits.Env {
+			k, v, _ := strings.Cut(env, "=")
+			if l.cfg.Env == nil {
+				l.cfg.Env = make(map[string]string)
+			}
+			if _, ok := l.cfg.Env[k]; !ok {
+				l.cfg.Env[k] = v
+			}
+		}
+		for _, n := range edits.DeviceNodes {
+			src := n.HostPath
+			if src == "" {
+				src = n.Path
+			}
+			l.cfg.BindPaths = append(l.cfg.BindPaths, src+":"+n.Path)
+		}
+		for _, m := range edits.Mounts {
+			bind := m.HostPath + ":" + m.ContainerPath
+			if slices.Contains(m.Options, "ro") {
+				bind += ":ro"
+			}
+			l.cfg.BindPaths = append(l.cfg.BindPaths, bind)
+		}
+		if len(edits.Hooks) > 0 {
+			sylog.Warningf("Ignoring the hooks of CDI device %s, which are not supported", name)
+		}
+		sylog.Debugf("Added CDI device %s from %s", name, d.Spec.Path)
+	}
+	return nil
+}
+
 // setBinds sets engine configuration for requested bind mounts.
 func (l *Launcher) setBinds(fakerootPath string) error {
 	// First get binds from -B/--bind and env var
@@ -684,6 +946,16 @@ func (l *Launcher) setBinds(fakerootPath string) error {
 		binds = append(binds, fakebinds...)
 	}
 
+	if l.emulator != "" {
+		binds = append(binds, apptainerConfig.BindPath{
+			Source:      l.emulator,
+			Destination: l.emulator,
+			Options: map[string]*apptainerConfig.BindOption{
+				"ro": {},
+			},
+		})
+	}
+
 	l.engineConfig.SetBindPath(binds)
 
 	// Pass only the destinations to nested binds
@@ -695,6 +967,56 @@ func (l *Launcher) setBinds(fakerootPath string) error {
 	return nil
 }
 
+// imageArch returns the architecture targeted by the SIF or sandbox image,
+// or an empty string when it's unknown.
+func imageArch(image string) string {
+	if fs.IsDir(image) {
+		shell := filepath.Join(image, fs.EvalRelative("/bin/sh", image))
+		arch, err := machine.ArchFromElf(shell)
+		if err != nil {
+			return ""
+		}
+		return arch
+	}
+	fimg, err := sif.LoadContainerFromPath(image, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		return ""
+	}
+	defer fimg.UnloadContainer()
+	desc, err := fimg.GetDescriptor(sif.WithPartitionType(sif.PartPrimSys))
+	if err != nil {
+		return ""
+	}
+	_, _, arch, err := desc.PartitionMetadata()
+	if err != nil || arch == "unknown" {
+		return ""
+	}
+	return arch
+}
+
+// checkArch checks that the image targets an architecture the host can run,
+// natively or through an emulator registered in binfmt_misc. An emulator
+// registered without the persistent flag, like qemu-user-static, is bound in
+// the container at its registered path.
+func (l *Launcher) checkArch() error {
+	image := l.engineConfig.GetImage()
+	arch := imageArch(image)
+	if arch == "" || machine.CompatibleWith(arch) {
+		return nil
+	}
+	if l.emulator = machine.Emulator(arch); l.emulator != "" {
+		sylog.Warningf("Image architecture %s doesn't match the host architecture %s, running through the emulator %s", arch, runtime.GOARCH, l.emulator)
+		return nil
+	}
+	if l.cfg.AllowArchMismatch {
+		sylog.Warningf("Image architecture %s doesn't match the host architecture %s, running it as --allow-arch-mismatch is set", arch, runtime.GOARCH)
+		return nil
+	}
+	return fmt.Errorf("image %s targets the %s architecture and can't run on this %s host: no emulator for %s is registered in /proc/sys/fs/binfmt_misc, "+
+		"register one like qemu-user-static, or use --allow-arch-mismatch if an emulator is known to be available in the container",
+		image, arch, runtime.GOARCH, arch)
+}
+
 // setFuseMounts sets engine configuration for requested FUSE mounts.
 func (l *Launcher) setFuseMounts() error {
 	if len(l.cfg.FuseMount) > 0 {
@@ -946,6 +1268,35 @@ func (l *Launcher) addGPUBinds(libs, bins, ipcs, regularFiles []string, gpuPlatf
 	}
 }
 
+// setStaticAddress requests the IP and MAC addresses set for an instance
+// from the plugins of its first network, after checking that they are not
+// assigned to another instance.
+func (l *Launcher) setStaticAddress() error {
+	ip, mac := l.cfg.StaticIP, l.cfg.StaticMAC
+	if ip == "" && mac == "" {
+		return nil
+	}
+	if ip != "" {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid IP address %q", ip)
+		}
+		l.cfg.NetworkArgs = append(l.cfg.NetworkArgs, "IP="+ip)
+	}
+	if mac != "" {
+		hw, err := net.ParseMAC(mac)
+		if err != nil {
+			return fmt.Errorf("invalid MAC address %q: %s", mac, err)
+		}
+		mac = hw.String()
+		l.cfg.NetworkArgs = append(l.cfg.NetworkArgs, "MAC="+mac)
+	}
+	if err := instance.CheckAddress(ip, mac, instance.AppSubDir); err != nil {
+		return err
+	}
+	l.engineConfig.SetNetworkArgs(l.cfg.NetworkArgs)
+	return nil
+}
+
 // setNamespaces sets namespace configuration for the engine.
 func (l *Launcher) setNamespaces() {
 	if !l.cfg.Namespaces.Net && l.cfg.Network != "" {
@@ -992,6 +1343,8 @@ func (l *Launcher) setNamespaces() {
 	if l.cfg.Namespaces.PID {
 		l.generator.AddOrReplaceLinuxNamespace("pid", "")
 		l.engineConfig.SetNoInit(l.cfg.NoInit)
+	} else {
+		l.engineConfig.SetSubreaper(l.cfg.Subreaper)
 	}
 	if l.cfg.Namespaces.IPC {
 		l.generator.AddOrReplaceLinuxNamespace("ipc", "")
@@ -1005,19 +1358,42 @@ func (l *Launcher) setNamespaces() {
 	}
 }
 
-// setEnvVars sets the environment for the container, from the host environment, glads, env-file.
+// setEnvVars sets the environment for the container, from the host environment, glads, env-file, env-json.
 func (l *Launcher) setEnvVars(ctx context.Context, args []string) error {
-	if len(l.cfg.EnvFiles) > 0 {
+	if len(l.cfg.EnvFiles) > 0 || l.cfg.EnvJSON != "" {
 		currentEnv := append(
 			os.Environ(),
 			"APPTAINER_IMAGE="+l.engineConfig.GetImage(),
 		)
 
 		// Read all environment files and put the variables into envFilesMap,
-		// environment variables in later files will take precedence.
+		// environment variables in later files will take precedence. The
+		// variables set by previous files take precedence over the host
+		// environment when referenced in a file.
 		envFilesMap := map[string]string{}
+		lookup := func(key string) (string, bool) {
+			if v, ok := envFilesMap[key]; ok {
+				return v, true
+			}
+			for i := len(currentEnv) - 1; i >= 0; i-- {
+				if v, ok := strings.CutPrefix(currentEnv[i], key+"="); ok {
+					return v, true
+				}
+			}
+			return "", false
+		}
 		for _, envFile := range l.cfg.EnvFiles {
-			tempEnvMap, err := env.FileMap(ctx, envFile, args, currentEnv)
+			var tempEnvMap map[string]string
+			var err error
+			if l.cfg.EnvFileLiteral {
+				tempEnvMap, err = env.LiteralFileMap(envFile, lookup)
+			} else {
+				fileEnv := append([]string{}, currentEnv...)
+				for k, v := range envFilesMap {
+					fileEnv = append(fileEnv, k+"="+v)
+				}
+				tempEnvMap, err = env.FileMap(ctx, envFile, args, fileEnv)
+			}
 			if err != nil {
 				return fmt.Errorf("while processing %s: %w", envFile, err)
 			}
@@ -1025,6 +1401,16 @@ func (l *Launcher) setEnvVars(ctx context.Context, args []string) error {
 			envFilesMap = env.MergeMap(envFilesMap, tempEnvMap)
 		}
 
+		// --env-json variables take precedence over the environment files
+		if l.cfg.EnvJSON != "" {
+			jsonEnvMap, err := env.JSONFileMap(l.cfg.EnvJSON)
+			if err != nil {
+				return err
+			}
+			sylog.Debugf("Setting environment variables from JSON %s", l.cfg.EnvJSON)
+			envFilesMap = env.MergeMap(envFilesMap, jsonEnvMap)
+		}
+
 		// --env variables will take precedence over variables defined by the environment files
 		// Update Env with those from file
 		for k, v := range envFilesMap {
@@ -1087,13 +1473,31 @@ func (l *Launcher) setCgroups(instanceName string) error {
 		l.engineConfig.SetDbusSessionBusAddress(os.Getenv("DBUS_SESSION_BUS_ADDRESS"))
 	}
 
+	if l.cfg.CgroupParent != "" {
+		if err := cgroups.CheckParent(l.cfg.CgroupParent, l.engineConfig.File.SystemdCgroups); err != nil {
+			return err
+		}
+		l.engineConfig.SetCgroupParent(l.cfg.CgroupParent)
+		// A cgroup is always created under the requested parent, even
+		// without resource limits.
+		if l.cfg.CGroupsJSON == "" {
+			cg := cgroups.Config{}
+			cgJSON, err := cg.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			l.cfg.CGroupsJSON = cgJSON
+		}
+	}
+
 	if l.cfg.CGroupsJSON != "" {
 		// Handle cgroups configuration (parsed from file or flags in CLI).
 		l.engineConfig.SetCgroupsJSON(l.cfg.CGroupsJSON)
 		return nil
 	}
 
-	if instanceName == "" {
+	// Containers are put in a cgroup, if possible, to be tagged.
+	if instanceName == "" && !l.engineConfig.File.TagContainerCgroups {
 		return nil
 	}
 
@@ -1114,6 +1518,11 @@ func (l *Launcher) setCgroups(instanceName string) error {
 		return nil
 	}
 
+	if instanceName == "" {
+		sylog.Debugf("Container cgroup will not be tagged - system configuration does not support cgroup management.")
+		return nil
+	}
+
 	if l.cfg.Fakeroot {
 		sylog.Debugf("Instance stats will not be available because of fakeroot mode")
 		return nil
@@ -1132,10 +1541,48 @@ func (l *Launcher) setCgroups(instanceName string) error {
 	return nil
 }
 
+// useWarmRootfs sets the warm root filesystem rootfs of the SIF image as
+// the container image, and returns whether it did. The engine handles it
+// as a sandbox, so the execution control list is enforced on the SIF image
+// here, and the image is run from the SIF image when sandboxes are
+// disallowed.
+func (l *Launcher) useWarmRootfs(ctx context.Context, image, rootfs string) (bool, error) {
+	fileconf := l.engineConfig.File
+	if !fileconf.AllowContainerDir || !fileconf.AllowContainerSIF {
+		sylog.Debugf("Not using warm root filesystem %s of %s: disallowed by configuration", rootfs, image)
+		return false, nil
+	}
+	f, err := os.Open(image)
+	if err != nil {
+		return false, fmt.Errorf("while opening image %s: %w", image, err)
+	}
+	defer f.Close()
+	if err := syecl.Enforce(ctx, buildcfg.ECL_FILE, buildcfg.APPTAINER_CONFDIR, f); err != nil {
+		return false, err
+	}
+	sylog.Debugf("Using warm root filesystem %s of %s", rootfs, image)
+	l.engineConfig.SetImage(rootfs)
+	return true, nil
+}
+
 // PrepareImage performs any image preparation required before execution.
 // This is currently limited to extraction or FUSE mount when using the user namespace,
 // and activating any image driver plugins that might handle the image mount.
-func (l *Launcher) prepareImage(_ context.Context, insideUserNs bool, image string) error {
+func (l *Launcher) prepareImage(ctx context.Context, insideUserNs bool, image string) error {
+	// run the root filesystem of a warm image directly, kept mounted by
+	// a helper process of the user, only accessible in a user namespace
+	if fs.IsFile(image) && (l.cfg.Namespaces.User || insideUserNs) && !l.cfg.Unsquash && !l.cfg.Writable {
+		if rootfs, ok := warm.Lookup(image); ok {
+			used, err := l.useWarmRootfs(ctx, image, rootfs)
+			if err != nil {
+				return err
+			}
+			if used {
+				return nil
+			}
+		}
+	}
+
 	// initialize internal image drivers
 	var desiredFeatures imgutil.DriverFeature
 	if fs.IsFile(image) {
@@ -1182,12 +1629,18 @@ func (l *Launcher) prepareImage(_ context.Context, insideUserNs bool, image stri
 				sylog.Fatalf("while extracting %s: %s", image, err)
 			}
 			sylog.Infof("Converting SIF file to temporary sandbox...")
+			endExtract := profile.Begin("extraction")
 			rootfsDir, imageDir, err := convertImage(image, unsquashfsPath, l.cfg.TmpDir)
+			endExtract()
 			if err != nil {
 				sylog.Fatalf("while extracting %s: %s", image, err)
 			}
 			l.engineConfig.SetImage(imageDir)
 			l.engineConfig.SetDeleteTempDir(rootfsDir)
+			// removed by a later invocation if the container crashes
+			if err := teardown.Register(os.Getpid(), rootfsDir); err != nil {
+				sylog.Debugf("Could not register teardown of %s: %s", rootfsDir, err)
+			}
 			l.generator.SetProcessEnvWithPrefixes(env.ApptainerPrefixes, "CONTAINER", imageDir)
 			// if '--disable-cache' flag, then remove original SIF after converting to sandbox
 			if l.cfg.CacheDisabled {
@@ -1202,6 +1655,30 @@ func (l *Launcher) prepareImage(_ context.Context, insideUserNs bool, image stri
 	return nil
 }
 
+// runPreLaunchHooks runs the pre-launch hooks of the site, and records the
+// description of the container for the post-exit hooks run by the engine.
+func (l *Launcher) runPreLaunchHooks(ctx context.Context, image string, args []string, instanceName string) error {
+	dir := l.engineConfig.File.LaunchHooksDir
+	if dir == "" {
+		return nil
+	}
+	ev := &launchhooks.Event{
+		Hook:     launchhooks.PreLaunch,
+		Image:    image,
+		Args:     args,
+		Instance: instanceName,
+		UID:      l.uid,
+		GID:      l.gid,
+		Binds:    l.cfg.BindPaths,
+		Fakeroot: l.cfg.Fakeroot,
+	}
+	if err := launchhooks.Run(ctx, dir, ev); err != nil {
+		return err
+	}
+	l.engineConfig.SetLaunchEvent(ev)
+	return nil
+}
+
 // starterInteractive executes the starter binary to run an image interactively, given the supplied engineConfig
 func (l *Launcher) starterInteractive(loadOverlay bool, useSuid bool, cfg *config.Common, imageFilename string) error {
 	err := starter.Exec(
@@ -1226,13 +1703,25 @@ func (l *Launcher) starterInstance(loadOverlay bool, insideUserNs bool, name str
 
 	var start int64
 
-	stdout, stderr, err := instance.SetLogFile(name, l.cfg.Namespaces.User || insideUserNs, int(l.uid), instance.LogSubDir)
-	if err != nil {
-		return fmt.Errorf("failed to create instance log files: %w", err)
+	driver := l.cfg.LogDriver
+	if driver == "" {
+		driver = l.engineConfig.File.InstanceLogDriver
+	}
+	if err := instance.CheckLogDriver(driver); err != nil {
+		return err
 	}
-	start, err = stderr.Seek(0, io.SeekEnd)
+	l.engineConfig.SetInstanceLogDriver(driver)
+	logFile := driver == instance.FileLogDriver
+
+	stdout, stderr, err := instance.SetLogDriver(driver, name, l.cfg.Namespaces.User || insideUserNs, int(l.uid), instance.LogSubDir)
 	if err != nil {
-		sylog.Warningf("failed to get standard error stream offset: %s", err)
+		return fmt.Errorf("failed to create instance log streams: %w", err)
+	}
+	if logFile {
+		start, err = stderr.Seek(0, io.SeekEnd)
+		if err != nil {
+			sylog.Warningf("failed to get standard error stream offset: %s", err)
+		}
 	}
 
 	cmdErr := starter.Run(
@@ -1244,7 +1733,7 @@ func (l *Launcher) starterInstance(loadOverlay bool, insideUserNs bool, name str
 		starter.LoadOverlayModule(loadOverlay),
 	)
 
-	if sylog.GetLevel() != 0 {
+	if logFile && sylog.GetLevel() != 0 {
 		// starter can exit a bit before all errors has been reported
 		// by instance process, wait a bit to catch all errors
 		time.Sleep(100 * time.Millisecond)
@@ -1263,8 +1752,12 @@ func (l *Launcher) starterInstance(loadOverlay bool, insideUserNs bool, name str
 	if cmdErr != nil {
 		return fmt.Errorf("failed to start instance: %w", cmdErr)
 	}
-	sylog.Verbosef("you will find instance output here: %s", stdout.Name())
-	sylog.Verbosef("you will find instance error here: %s", stderr.Name())
+	if logFile {
+		sylog.Verbosef("you will find instance output here: %s", stdout.Name())
+		sylog.Verbosef("you will find instance error here: %s", stderr.Name())
+	} else if driver != instance.NoneLogDriver {
+		sylog.Verbosef("instance output sent to %s with identifier %s", driver, instance.LogIdentifier(name))
+	}
 	sylog.Infof("instance started successfully")
 
 	return nil
@@ -1320,7 +1813,7 @@ func hidepidProc() bool {
 // the caller's responsibility to remove rootfsDir when no longer needed.
 func convertImage(filename string, unsquashfsPath string, tmpDir string) (rootfsDir string, imageDir string, err error) {
 	img, err := imgutil.Init(filename, false)
-	if err != nil {
+	if err != nil && !imgutil.IsArchMismatch(err) {
 		return "", "", fmt.Errorf("could not open image %s: %s", filename, err)
 	}
 	defer img.File.Close()
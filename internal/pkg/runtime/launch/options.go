@@ -75,6 +75,11 @@ type launchOptions struct {
 	Env map[string]string
 	// EnvFiles contains filenames to read container env vars from.
 	EnvFiles []string
+	// EnvFileLiteral reads the EnvFiles without shell evaluation.
+	EnvFileLiteral bool
+	// EnvJSON is a JSON file, or - for stdin, holding an object of container
+	// env vars.
+	EnvJSON string
 	// CleanEnv starts the container with a clean environment, excluding host env vars.
 	CleanEnv bool
 	// NoEval instructs Apptainer not to shell evaluate args and env vars.
@@ -83,8 +88,9 @@ type launchOptions struct {
 	// Namespaces is the list of optional Namespaces requested for the container.
 	Namespaces Namespaces
 
-	// NetnsPath is the path to a network namespace to join, rather than
-	// creating one / applying a CNI config.
+	// NetnsPath is the path to a network namespace to join, or the name of
+	// a network namespace created with 'ip netns add', rather than creating
+	// one / applying a CNI config.
 	NetnsPath string
 
 	// Network is the name of an optional CNI networking configuration to apply.
@@ -95,24 +101,62 @@ type launchOptions struct {
 	Hostname string
 	// DNS is the comma separated list of DNS servers to be set in the container's resolv.conf.
 	DNS string
+	// StaticIP is the IP address requested for an instance on its first network.
+	StaticIP string
+	// StaticMAC is the MAC address requested for an instance on its first network.
+	StaticMAC string
+	// LogDriver is the log driver of the instance output streams, the
+	// default of apptainer.conf is used when empty.
+	LogDriver string
 
 	// AddCaps is the list of capabilities to Add to the container process.
 	AddCaps string
 	// DropCaps is the list of capabilities to drop from the container process.
 	DropCaps string
+	// AmbientCaps requests the capabilities granted to a user in the ambient
+	// set, as allowed by apptainer.conf.
+	AmbientCaps bool
 	// AllowSUID permits setuid executables inside a container started by the root user.
 	AllowSUID bool
 	// KeepPrivs keeps all privileges inside a container started by the root user.
 	KeepPrivs bool
 	// NoPrivs drops all privileges inside a container.
 	NoPrivs bool
-	// SecurityOpts is the list of security options (selinux, apparmor, seccomp) to apply.
+	// NoNewPrivs sets no new privileges for the container processes.
+	NoNewPrivs bool
+	// AllowNewPrivs allows the container processes started by the root user
+	// to gain new privileges.
+	AllowNewPrivs bool
+	// SecurityOpts is the list of security options (selinux, apparmor, seccomp, landlock) to apply.
 	SecurityOpts []string
+	// SeccompAudit logs the syscalls of the container processes listed in
+	// SeccompAuditSyscalls, or the default ones, without blocking them.
+	SeccompAudit         bool
+	SeccompAuditSyscalls []string
 	// NoUmask disables propagation of the host umask into the container, using a default 0022.
 	NoUmask bool
+	// RootfsPropagation is the mount propagation mode of the container root filesystem.
+	RootfsPropagation string
+	// Personality is the execution domain of the container process, linux
+	// or linux32.
+	Personality string
+
+	// CoreLimit is the core dump size limit of the container process, a
+	// size like 512M or unlimited.
+	CoreLimit string
+	// CoreDir is a host directory where the core dumps of the container
+	// processes are written.
+	CoreDir string
+
+	// Devices are the fully qualified names of the CDI devices added to
+	// the container.
+	Devices []string
 
 	// CGroupsJSON is a JSON format cgroups resource limit specification to apply.
 	CGroupsJSON string
+	// CgroupParent is the cgroup path, or systemd slice, under which the
+	// container cgroup is created.
+	CgroupParent string
 
 	// ConfigFile is an alternate apptainer.conf that will be used by unprivileged installations only.
 	ConfigFile string
@@ -128,6 +172,12 @@ type launchOptions struct {
 	Boot bool
 	// NoInit disables shim process when PID namespace is used.
 	NoInit bool
+	// Subreaper starts a shim process reaping orphaned processes when PID
+	// namespace is not used.
+	Subreaper bool
+	// AllowArchMismatch runs images targeting another architecture than the
+	// host's without a usable emulator.
+	AllowArchMismatch bool
 	// Contain starts the container with minimal /dev and empty home/tmp mounts.
 	Contain bool
 	// ContainAll infers Contain, and adds PID, IPC namespaces, and CleanEnv.
@@ -166,6 +216,9 @@ type Launcher struct {
 	cfg          launchOptions
 	engineConfig *apptainerConfig.EngineConfig
 	generator    *generate.Generator
+	// emulator is the path of the emulator bound in the container to run
+	// an image targeting a foreign architecture.
+	emulator string
 }
 
 // Namespaces holds flags for the optional (non-mount) namespaces that can be
@@ -315,6 +368,24 @@ func OptEnv(env map[string]string, envFiles []string, clean bool) Option {
 	}
 }
 
+// OptEnvFileLiteral reads environment files as literal KEY=VALUE lines,
+// rather than shell evaluating them.
+func OptEnvFileLiteral(b bool) Option {
+	return func(lo *launchOptions) error {
+		lo.EnvFileLiteral = b
+		return nil
+	}
+}
+
+// OptEnvJSON sets container environment variables from a JSON object read
+// from file path, or from standard input if path is "-".
+func OptEnvJSON(path string) Option {
+	return func(lo *launchOptions) error {
+		lo.EnvJSON = path
+		return nil
+	}
+}
+
 // OptNoEval disables shell evaluation of args and env vars.
 func OptNoEval(b bool) Option {
 	return func(lo *launchOptions) error {
@@ -331,7 +402,7 @@ func OptNamespaces(n Namespaces) Option {
 	}
 }
 
-// OptJoinNetNamespace sets the network namespace to join, if permitted.
+// OptNetnsPath sets the network namespace to join, if permitted.
 func OptNetnsPath(n string) Option {
 	return func(lo *launchOptions) error {
 		lo.NetnsPath = n
@@ -351,6 +422,24 @@ func OptNetwork(network string, args []string) Option {
 	}
 }
 
+// OptStaticAddress sets the IP and MAC addresses requested for an instance
+// on its first network.
+func OptStaticAddress(ip, mac string) Option {
+	return func(lo *launchOptions) error {
+		lo.StaticIP = ip
+		lo.StaticMAC = mac
+		return nil
+	}
+}
+
+// OptLogDriver sets the log driver of the instance output streams.
+func OptLogDriver(driver string) Option {
+	return func(lo *launchOptions) error {
+		lo.LogDriver = driver
+		return nil
+	}
+}
+
 // OptHostname sets a hostname for the container (infers/requires UTS namespace).
 func OptHostname(h string) Option {
 	return func(lo *launchOptions) error {
@@ -376,6 +465,15 @@ func OptCaps(add, drop string) Option {
 	}
 }
 
+// OptAmbientCaps requests the capabilities granted to a user in the ambient
+// set, so that they are kept by non-root container processes across exec.
+func OptAmbientCaps(b bool) Option {
+	return func(lo *launchOptions) error {
+		lo.AmbientCaps = b
+		return nil
+	}
+}
+
 // OptAllowSUID permits setuid executables inside a container started by the root user.
 func OptAllowSUID(b bool) Option {
 	return func(lo *launchOptions) error {
@@ -400,7 +498,27 @@ func OptNoPrivs(b bool) Option {
 	}
 }
 
-// OptSecurity supplies a list of security options (selinux, apparmor, seccomp) to apply.
+// OptSeccompAudit enables the logging of the listed syscalls, or of the
+// default ones when empty, made by the container processes.
+func OptSeccompAudit(b bool, syscalls []string) Option {
+	return func(lo *launchOptions) error {
+		lo.SeccompAudit = b
+		lo.SeccompAuditSyscalls = syscalls
+		return nil
+	}
+}
+
+// OptNewPrivs sets no new privileges for the container processes, or
+// allows those started by the root user to gain new privileges.
+func OptNewPrivs(noNewPrivs, allowNewPrivs bool) Option {
+	return func(lo *launchOptions) error {
+		lo.NoNewPrivs = noNewPrivs
+		lo.AllowNewPrivs = allowNewPrivs
+		return nil
+	}
+}
+
+// OptSecurity supplies a list of security options (selinux, apparmor, seccomp, landlock) to apply.
 func OptSecurity(s []string) Option {
 	return func(lo *launchOptions) error {
 		lo.SecurityOpts = s
@@ -416,6 +534,41 @@ func OptNoUmask(b bool) Option {
 	}
 }
 
+// OptRootfsPropagation sets the mount propagation mode of the container root filesystem.
+func OptRootfsPropagation(mode string) Option {
+	return func(lo *launchOptions) error {
+		lo.RootfsPropagation = mode
+		return nil
+	}
+}
+
+// OptPersonality sets the execution domain of the container process.
+func OptPersonality(domain string) Option {
+	return func(lo *launchOptions) error {
+		lo.Personality = domain
+		return nil
+	}
+}
+
+// OptCoreDump sets the core dump size limit of the container process, and a
+// host directory bound in the container where core dumps are written.
+func OptCoreDump(limit, dir string) Option {
+	return func(lo *launchOptions) error {
+		lo.CoreLimit = limit
+		lo.CoreDir = dir
+		return nil
+	}
+}
+
+// OptDevices sets the fully qualified names, as vendor/class=name, of the
+// CDI devices added to the container.
+func OptDevices(devices []string) Option {
+	return func(lo *launchOptions) error {
+		lo.Devices = devices
+		return nil
+	}
+}
+
 // OptCgroupsJSON sets a Cgroups resource limit configuration to apply to the container.
 func OptCgroupsJSON(cj string) Option {
 	return func(lo *launchOptions) error {
@@ -424,6 +577,15 @@ func OptCgroupsJSON(cj string) Option {
 	}
 }
 
+// OptCgroupParent sets the cgroup path, or systemd slice, under which the
+// container cgroup is created.
+func OptCgroupParent(parent string) Option {
+	return func(lo *launchOptions) error {
+		lo.CgroupParent = parent
+		return nil
+	}
+}
+
 // OptConfigFile specifies an alternate apptainer.conf that will be used by unprivileged installations only.
 func OptConfigFile(c string) Option {
 	return func(lo *launchOptions) error {
@@ -472,6 +634,24 @@ func OptNoInit(b bool) Option {
 	}
 }
 
+// OptSubreaper starts a shim process reaping orphaned processes when PID
+// namespace is not used.
+func OptSubreaper(b bool) Option {
+	return func(lo *launchOptions) error {
+		lo.Subreaper = b
+		return nil
+	}
+}
+
+// OptAllowArchMismatch runs images targeting another architecture than the
+// host's, when an emulator not detected is known to be registered.
+func OptAllowArchMismatch(b bool) Option {
+	return func(lo *launchOptions) error {
+		lo.AllowArchMismatch = b
+		return nil
+	}
+}
+
 // OptContain starts the container with minimal /dev and empty home/tmp mounts.
 func OptContain(b bool) Option {
 	return func(lo *launchOptions) error {
@@ -0,0 +1,183 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package launchhooks runs the executable hooks provided by the site
+// administrator in the launch hooks directory, before a container is
+// launched and after it exits. Hooks are a lighter-weight alternative to
+// plugins: they receive a JSON description of the container on their
+// standard input, and a pre-launch hook can veto the launch by exiting with
+// a non-zero status, or annotate it by writing a JSON response on its
+// standard output.
+package launchhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+const (
+	// PreLaunch is the hook run before a container is launched.
+	PreLaunch = "pre-launch"
+	// PostExit is the hook run after a container exits.
+	PostExit = "post-exit"
+)
+
+// Timeout is the maximum time a hook is allowed to run.
+const Timeout = 30 * time.Second
+
+// hookOwner is the owner required for the hooks, so that they can't be
+// replaced by users.
+var hookOwner = uint32(0)
+
+// ErrVetoed is returned when a pre-launch hook vetoes the launch.
+var ErrVetoed = errors.New("launch vetoed")
+
+// Event is the description of a container passed to the hooks.
+type Event struct {
+	// Hook is the hook run, PreLaunch or PostExit.
+	Hook string `json:"hook"`
+	// Image is the image of the container.
+	Image string `json:"image"`
+	// Args are the arguments of the container process.
+	Args []string `json:"args,omitempty"`
+	// Instance is the instance name, or empty when not an instance.
+	Instance string `json:"instance,omitempty"`
+	// UID and GID are the user and group running the container.
+	UID uint32 `json:"uid"`
+	GID uint32 `json:"gid"`
+	// Binds are the bind paths requested for the container.
+	Binds []string `json:"binds,omitempty"`
+	// Fakeroot is whether the container runs in fakeroot mode.
+	Fakeroot bool `json:"fakeroot,omitempty"`
+	// Annotations are the annotations set by the pre-launch hooks.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ExitCode is the exit code of the container, set for PostExit.
+	ExitCode *int `json:"exitCode,omitempty"`
+}
+
+// Response is the optional JSON response written by a hook on its
+// standard output.
+type Response struct {
+	// Message is a message displayed to the user.
+	Message string `json:"message,omitempty"`
+	// Annotations are added to the annotations of the launch, and passed
+	// to the following hooks.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// List returns the hooks of type hook in the directory dir, the executable
+// files in its <hook>.d subdirectory, in lexical order. Hooks not owned by
+// root, or writable by group or others, are skipped.
+func List(dir, hook string) ([]string, error) {
+	hookDir := filepath.Join(dir, hook+".d")
+	entries, err := os.ReadDir(hookDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while reading %s: %w", hookDir, err)
+	}
+
+	var hooks []string
+	for _, e := range entries {
+		path := filepath.Join(hookDir, e.Name())
+		fi, err := os.Stat(path)
+		if err != nil || !fi.Mode().IsRegular() || fi.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok || st.Uid != hookOwner || fi.Mode().Perm()&0o022 != 0 {
+			sylog.Warningf("Ignoring launch hook %s: not owned by root, or writable by group or others", path)
+			continue
+		}
+		hooks = append(hooks, path)
+	}
+	sort.Strings(hooks)
+	return hooks, nil
+}
+
+// Run runs the hooks of type ev.Hook in the directory dir, in lexical
+// order, with ev on their standard input. The annotations returned by each
+// hook are added to ev, so that they are passed to the following hooks. A
+// pre-launch hook exiting with a non-zero status vetoes the launch, and
+// ErrVetoed is returned with the reason it reported on its standard error.
+// The failures of other hooks are only reported.
+func Run(ctx context.Context, dir string, ev *Event) error {
+	if dir == "" {
+		return nil
+	}
+	hooks, err := List(dir, ev.Hook)
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		if err := run(ctx, hook, ev); err != nil {
+			if ev.Hook == PreLaunch {
+				return err
+			}
+			sylog.Warningf("%s", err)
+		}
+	}
+	return nil
+}
+
+// run runs a single hook with ev on its standard input.
+func run(ctx context.Context, hook string, ev *Event) error {
+	input, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	sylog.Debugf("Running %s hook %s", ev.Hook, hook)
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		if reason == "" {
+			reason = err.Error()
+		}
+		var exitErr *exec.ExitError
+		if ev.Hook == PreLaunch && errors.As(err, &exitErr) && ctx.Err() == nil {
+			return fmt.Errorf("%w by %s: %s", ErrVetoed, filepath.Base(hook), reason)
+		}
+		return fmt.Errorf("%s hook %s failed: %s", ev.Hook, hook, reason)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("%s hook %s returned an invalid response: %v", ev.Hook, hook, err)
+	}
+	if resp.Message != "" {
+		sylog.Infof("%s", resp.Message)
+	}
+	if len(resp.Annotations) > 0 && ev.Annotations == nil {
+		ev.Annotations = make(map[string]string, len(resp.Annotations))
+	}
+	for k, v := range resp.Annotations {
+		ev.Annotations[k] = v
+	}
+	return nil
+}
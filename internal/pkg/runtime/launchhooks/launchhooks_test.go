@@ -0,0 +1,83 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package launchhooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeHook writes a hook script of type hook named name in dir.
+func writeHook(t *testing.T, dir, hook, name, script string, mode os.FileMode) {
+	t.Helper()
+	hookDir := filepath.Join(dir, hook+".d")
+	if err := os.MkdirAll(hookDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(hookDir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	hookOwner = uint32(os.Geteuid())
+	defer func() { hookOwner = 0 }()
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "input")
+	writeHook(t, dir, PreLaunch, "10-annotate", `echo '{"message": "checked", "annotations": {"site": "a"}}'`, 0o755)
+	writeHook(t, dir, PreLaunch, "20-record", "cat > "+out, 0o755)
+	writeHook(t, dir, PreLaunch, "30-not-executable", "exit 1", 0o644)
+	writeHook(t, dir, PreLaunch, "40-writable", "exit 1", 0o777)
+
+	ev := &Event{Hook: PreLaunch, Image: "image.sif", Args: []string{"true"}, UID: 1000, GID: 1000}
+	if err := Run(context.Background(), dir, ev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"site": "a"}
+	if !reflect.DeepEqual(ev.Annotations, expected) {
+		t.Errorf("got annotations %v, expected %v", ev.Annotations, expected)
+	}
+	// annotations are passed to the following hooks
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("hook not run: %v", err)
+	}
+	if !strings.Contains(string(b), `"annotations":{"site":"a"}`) {
+		t.Errorf("unexpected hook input %s", b)
+	}
+
+	// a failing pre-launch hook vetoes the launch
+	writeHook(t, dir, PreLaunch, "50-veto", "echo 'not allowed' >&2; exit 1", 0o755)
+	err = Run(context.Background(), dir, ev)
+	if !errors.Is(err, ErrVetoed) || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("got error %v, expected launch vetoed", err)
+	}
+
+	// failing post-exit hooks are only reported
+	writeHook(t, dir, PostExit, "10-fail", "exit 1", 0o755)
+	code := 3
+	ev.Hook = PostExit
+	ev.ExitCode = &code
+	if err := Run(context.Background(), dir, ev); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// no hooks are run without a directory
+	ev.Hook = PreLaunch
+	if err := Run(context.Background(), "", ev); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package warm keeps SIF images mounted by per-user helper processes, so
+// that the containers launched from a warm image run its mounted root
+// filesystem directly, only setting up their namespaces and starting their
+// process, instead of mounting or extracting the image each time.
+package warm
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/internal/pkg/util/bin"
+	"github.com/apptainer/apptainer/internal/pkg/util/fuse"
+	imgutil "github.com/apptainer/apptainer/pkg/image"
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+)
+
+const (
+	// stateDir is the directory of the warm image states in the user
+	// configuration directory.
+	stateDir = "warm"
+	// ready is written by the helper process once the image is mounted.
+	ready = "ready"
+	// pollInterval is the interval the helper checks its image and idle
+	// timeout at.
+	pollInterval = 5 * time.Second
+	// mountTimeout is the time the helper waits for the image to be
+	// mounted.
+	mountTimeout = 10 * time.Second
+	// stopTimeout is the time Stop waits for the helper to exit.
+	stopTimeout = 10 * time.Second
+)
+
+// ErrNotWarm is returned when an image is not kept mounted.
+var ErrNotWarm = errors.New("image is not warm")
+
+// State is the state of a warm image, recorded by its helper process.
+type State struct {
+	// Image is the absolute path of the image.
+	Image string `json:"image"`
+	// Size and ModTime identify the content of the image, which is not
+	// used once modified.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Rootfs is the mount point of the root filesystem of the image.
+	Rootfs string `json:"rootfs"`
+	// PID is the helper process keeping the image mounted.
+	PID int `json:"pid"`
+	// StartTime is the start time of PID, distinguishing it from a later
+	// process reusing its ID.
+	StartTime time.Duration `json:"startTime"`
+	// IdleTimeout is the time the image is kept mounted without being
+	// used, forever when zero.
+	IdleTimeout time.Duration `json:"idleTimeout,omitempty"`
+	// LastUsed is the last time a container was launched from the image.
+	LastUsed time.Time `json:"lastUsed,omitempty"`
+}
+
+// serveConfig is the configuration of the helper process, read from its
+// standard input.
+type serveConfig struct {
+	Image       string        `json:"image"`
+	IdleTimeout time.Duration `json:"idleTimeout"`
+}
+
+// StateDir returns the directory of the warm image states of the current
+// user.
+func StateDir() string {
+	return filepath.Join(syfs.ConfigDir(), stateDir)
+}
+
+// Start starts a helper process keeping the image mounted, until it's
+// stopped or unused for idleTimeout, and returns the state of the warm
+// image.
+func Start(image string, idleTimeout time.Duration) (*State, error) {
+	image, err := filepath.Abs(image)
+	if err != nil {
+		return nil, err
+	}
+	if s, err := load(image); err == nil && s.running() {
+		return nil, fmt.Errorf("%s is already warm, kept mounted by process %d", image, s.PID)
+	}
+	b, err := json.Marshal(serveConfig{Image: image, IdleTimeout: idleTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(filepath.Join(buildcfg.BINDIR, "apptainer"), "warm", "serve")
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stderr = os.Stderr
+	// detached from the terminal signals
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("while starting helper process: %w", err)
+	}
+	line, _ := bufio.NewReader(stdout).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line != ready {
+		cmd.Wait()
+		if line == "" {
+			line = "helper process exited"
+		}
+		return nil, fmt.Errorf("%s", line)
+	}
+	stdout.Close()
+	cmd.Process.Release()
+	return load(image)
+}
+
+// Stop stops the helper process keeping the image mounted.
+func Stop(image string) error {
+	image, err := filepath.Abs(image)
+	if err != nil {
+		return err
+	}
+	s, err := load(image)
+	if os.IsNotExist(err) {
+		return ErrNotWarm
+	} else if err != nil {
+		return err
+	}
+	if !s.running() {
+		// the helper was killed, the state is stale
+		return removeState(image)
+	}
+	if err := syscall.Kill(s.PID, syscall.SIGTERM); err != nil {
+		return fmt.Errorf("while stopping helper process %d: %w", s.PID, err)
+	}
+	for deadline := time.Now().Add(stopTimeout); time.Now().Before(deadline); {
+		if !s.running() {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("helper process %d didn't exit", s.PID)
+}
+
+// List returns the states of the warm images of the current user, the
+// stale states of killed helper processes being removed.
+func List() ([]*State, error) {
+	files, err := os.ReadDir(StateDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var states []*State
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		s, err := loadFile(filepath.Join(StateDir(), f.Name()))
+		if err != nil {
+			sylog.Warningf("Ignoring warm image state %s: %s", f.Name(), err)
+			continue
+		}
+		if !s.running() {
+			removeState(s.Image)
+			continue
+		}
+		states = append(states, s)
+	}
+	return states, nil
+}
+
+// Lookup returns the mounted root filesystem of image when it's warm and
+// unmodified, and records its use.
+func Lookup(image string) (string, bool) {
+	image, err := filepath.Abs(image)
+	if err != nil {
+		return "", false
+	}
+	s, err := load(image)
+	if err != nil || !s.running() {
+		return "", false
+	}
+	fi, err := os.Stat(image)
+	if err != nil || fi.Size() != s.Size || !fi.ModTime().Equal(s.ModTime) {
+		sylog.Debugf("Not using warm %s, modified since it was mounted", image)
+		return "", false
+	}
+	// the modification time of the state is the last use of the image,
+	// checked by the helper against its idle timeout
+	now := time.Now()
+	if err := os.Chtimes(stateFile(image), now, now); err != nil {
+		sylog.Debugf("Could not record use of warm %s: %s", image, err)
+	}
+	return s.Rootfs, true
+}
+
+// Serve keeps the image configured by Start from r mounted, reporting the
+// mount readiness, or the error preventing it, to w. It returns once the
+// helper is terminated, the image is unused for its idle timeout, or it's
+// modified.
+func Serve(r io.Reader, w io.Writer) error {
+	var cfg serveConfig
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return fmt.Errorf("while decoding warm image configuration: %w", err)
+	}
+	s, cmd, err := mount(cfg)
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return err
+	}
+	// the directories are removed once empty, never along with a mounted
+	// image
+	defer os.Remove(filepath.Dir(s.Rootfs))
+	defer os.Remove(s.Rootfs)
+	defer removeState(s.Image)
+	fmt.Fprintln(w, ready)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	if err := s.wait(exited); err != nil {
+		return err
+	}
+	// squashfuse exits once its filesystem is unmounted
+	if err := fuse.Unmount(s.Rootfs); err != nil {
+		sylog.Warningf("Unable to unmount %s: %s", s.Rootfs, err)
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+	<-exited
+	return nil
+}
+
+// wait waits for the termination of the helper, or until the image is
+// unused for its idle timeout or modified. It returns an error if the
+// squashfuse process exits first.
+func (s *State) wait(exited <-chan error) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigCh:
+			sylog.Debugf("Warm image helper terminated")
+			return nil
+		case err := <-exited:
+			return fmt.Errorf("squashfuse exited: %v", err)
+		case <-ticker.C:
+		}
+		if fi, err := os.Stat(s.Image); err != nil || fi.Size() != s.Size || !fi.ModTime().Equal(s.ModTime) {
+			sylog.Debugf("%s modified, unmounting it", s.Image)
+			return nil
+		}
+		if s.IdleTimeout > 0 {
+			if fi, err := os.Stat(stateFile(s.Image)); err == nil && time.Since(fi.ModTime()) > s.IdleTimeout {
+				sylog.Debugf("%s unused for %s, unmounting it", s.Image, s.IdleTimeout)
+				return nil
+			}
+		}
+	}
+}
+
+// mount mounts the root filesystem of the image of cfg with squashfuse, and
+// returns its state and the squashfuse process.
+func mount(cfg serveConfig) (*State, *exec.Cmd, error) {
+	fi, err := os.Stat(cfg.Image)
+	if err != nil {
+		return nil, nil, err
+	}
+	offset, err := rootfsOffset(cfg.Image)
+	if err != nil {
+		return nil, nil, err
+	}
+	squashfuse, err := bin.FindBin("squashfuse_ll")
+	if err != nil {
+		if squashfuse, err = bin.FindBin("squashfuse"); err != nil {
+			return nil, nil, fmt.Errorf("warm images require squashfuse: %w", err)
+		}
+	}
+	p, err := proc.GetProcess(os.Getpid())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "warm-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create temporary directory: %w", err)
+	}
+	s := &State{
+		Image:       cfg.Image,
+		Size:        fi.Size(),
+		ModTime:     fi.ModTime(),
+		Rootfs:      filepath.Join(dir, "rootfs"),
+		PID:         os.Getpid(),
+		StartTime:   p.StartTime,
+		IdleTimeout: cfg.IdleTimeout,
+	}
+	if err := os.Mkdir(s.Rootfs, 0o700); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	opts := "ro,offset=" + strconv.FormatUint(offset, 10)
+	cmd := exec.Command(squashfuse, "-f", "-o", opts, s.Image, s.Rootfs)
+	cmd.Stderr = os.Stderr
+	sylog.Debugf("Mounting %s on %s with %s", s.Image, s.Rootfs, squashfuse)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("while starting %s: %w", squashfuse, err)
+	}
+	if err := waitMount(s.Rootfs); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	if err := s.save(); err != nil {
+		fuse.Unmount(s.Rootfs)
+		cmd.Wait()
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return s, cmd, nil
+}
+
+// rootfsOffset returns the offset of the squashfs root filesystem of the
+// image, refusing images which can't be run from it alone.
+func rootfsOffset(image string) (uint64, error) {
+	img, err := imgutil.Init(image, false)
+	if err != nil {
+		return 0, fmt.Errorf("could not open image %s: %w", image, err)
+	}
+	defer img.File.Close()
+
+	part, err := img.GetRootFsPartition()
+	if err != nil {
+		return 0, fmt.Errorf("while getting root filesystem in %s: %w", image, err)
+	}
+	if part.Type != imgutil.SQUASHFS {
+		return 0, fmt.Errorf("only images with a squashfs root filesystem can be kept warm")
+	}
+	if enc, err := img.EncryptedRootFs(); err != nil {
+		return 0, err
+	} else if enc != "" {
+		return 0, fmt.Errorf("encrypted images can't be kept warm")
+	}
+	if overlays, err := img.GetOverlayPartitions(); err != nil {
+		return 0, err
+	} else if len(overlays) > 0 {
+		return 0, fmt.Errorf("images with an overlay partition can't be kept warm")
+	}
+	return part.Offset, nil
+}
+
+// waitMount waits for the mount of dir.
+func waitMount(dir string) error {
+	for deadline := time.Now().Add(mountTimeout); time.Now().Before(deadline); {
+		entries, err := proc.GetMountInfoEntry("/proc/self/mountinfo")
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(entries, func(e proc.MountInfoEntry) bool { return e.Point == dir }) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("timed out waiting for the mount of %s", dir)
+}
+
+// running returns whether the helper process of the warm image runs.
+func (s *State) running() bool {
+	p, err := proc.GetProcess(s.PID)
+	return err == nil && p.StartTime == s.StartTime
+}
+
+// stateFile returns the path of the state file of image, derived from its
+// path so that it's unique.
+func stateFile(image string) string {
+	sum := sha256.Sum256([]byte(image))
+	return filepath.Join(StateDir(), hex.EncodeToString(sum[:8])+".json")
+}
+
+func (s *State) save() error {
+	if err := os.MkdirAll(StateDir(), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := stateFile(s.Image) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, stateFile(s.Image))
+}
+
+func load(image string) (*State, error) {
+	return loadFile(stateFile(image))
+}
+
+func loadFile(path string) (*State, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := new(State)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(s.Image) || !filepath.IsAbs(s.Rootfs) {
+		return nil, fmt.Errorf("invalid warm image state")
+	}
+	if fi, err := os.Stat(path); err == nil {
+		s.LastUsed = fi.ModTime()
+	}
+	return s, nil
+}
+
+func removeState(image string) error {
+	if err := os.Remove(stateFile(image)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
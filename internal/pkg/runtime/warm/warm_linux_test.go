@@ -0,0 +1,115 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package warm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "warm-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("APPTAINER_CONFIGDIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeState records the state of image as kept mounted by pid.
+func fakeState(t *testing.T, image string, pid int) *State {
+	fi, err := os.Stat(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := proc.GetProcess(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &State{
+		Image:     image,
+		Size:      fi.Size(),
+		ModTime:   fi.ModTime(),
+		Rootfs:    filepath.Join(t.TempDir(), "rootfs"),
+		PID:       pid,
+		StartTime: p.StartTime,
+	}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLookup(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "image.sif")
+	if err := os.WriteFile(image, []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Lookup(image); ok {
+		t.Fatalf("unexpected warm image %s", image)
+	}
+
+	s := fakeState(t, image, os.Getpid())
+	defer removeState(image)
+	rootfs, ok := Lookup(image)
+	if !ok || rootfs != s.Rootfs {
+		t.Fatalf("got %q and %v, expected %q", rootfs, ok, s.Rootfs)
+	}
+
+	states, err := List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(states) != 1 || states[0].Image != image {
+		t.Errorf("got %d warm images, expected %s", len(states), image)
+	}
+
+	// a modified image is not used
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(image, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Lookup(image); ok {
+		t.Errorf("modified image %s used", image)
+	}
+}
+
+func TestStaleState(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "image.sif")
+	if err := os.WriteFile(image, []byte("image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := fakeState(t, image, os.Getpid())
+	// another process reusing the PID of the helper
+	s.StartTime++
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Lookup(image); ok {
+		t.Errorf("image %s of a stale state used", image)
+	}
+	states, err := List()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("got %d warm images, expected none", len(states))
+	}
+	if _, err := os.Stat(stateFile(image)); !os.IsNotExist(err) {
+		t.Errorf("stale state of %s not removed", image)
+	}
+	if err := Stop(image); !errors.Is(err, ErrNotWarm) {
+		t.Errorf("got %v stopping %s, expected %v", err, image, ErrNotWarm)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package landlock restricts the filesystem accesses of the container process
+// with Landlock rulesets, an unprivileged path-based sandboxing mechanism
+// available since Linux 5.13.
+package landlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"golang.org/x/sys/unix"
+)
+
+// Annotation is the OCI annotation holding the Landlock ruleset applied to
+// the container process.
+const Annotation = "io.apptainer.landlock.ruleset"
+
+// accessRights are the filesystem access rights by name, with the Landlock
+// ABI version introducing them.
+var accessRights = map[string]struct {
+	right uint64
+	abi   int
+}{
+	"execute":     {unix.LANDLOCK_ACCESS_FS_EXECUTE, 1},
+	"write_file":  {unix.LANDLOCK_ACCESS_FS_WRITE_FILE, 1},
+	"read_file":   {unix.LANDLOCK_ACCESS_FS_READ_FILE, 1},
+	"read_dir":    {unix.LANDLOCK_ACCESS_FS_READ_DIR, 1},
+	"remove_dir":  {unix.LANDLOCK_ACCESS_FS_REMOVE_DIR, 1},
+	"remove_file": {unix.LANDLOCK_ACCESS_FS_REMOVE_FILE, 1},
+	"make_char":   {unix.LANDLOCK_ACCESS_FS_MAKE_CHAR, 1},
+	"make_dir":    {unix.LANDLOCK_ACCESS_FS_MAKE_DIR, 1},
+	"make_reg":    {unix.LANDLOCK_ACCESS_FS_MAKE_REG, 1},
+	"make_sock":   {unix.LANDLOCK_ACCESS_FS_MAKE_SOCK, 1},
+	"make_fifo":   {unix.LANDLOCK_ACCESS_FS_MAKE_FIFO, 1},
+	"make_block":  {unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK, 1},
+	"make_sym":    {unix.LANDLOCK_ACCESS_FS_MAKE_SYM, 1},
+	"refer":       {unix.LANDLOCK_ACCESS_FS_REFER, 2},
+	"truncate":    {unix.LANDLOCK_ACCESS_FS_TRUNCATE, 3},
+	"ioctl_dev":   {unix.LANDLOCK_ACCESS_FS_IOCTL_DEV, 5},
+}
+
+// fileAccess are the access rights applying to files, the only ones
+// allowed by rules on a path which is not a directory.
+const fileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+	unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+	unix.LANDLOCK_ACCESS_FS_READ_FILE |
+	unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+	unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+
+// Rule allows access rights beneath paths of the container.
+type Rule struct {
+	Paths  []string `json:"paths"`
+	Access []string `json:"access"`
+}
+
+// Ruleset is a Landlock ruleset: the handled access rights are denied
+// except beneath the paths of the rules allowing them. All access rights
+// are handled when none are specified.
+type Ruleset struct {
+	HandledAccess []string `json:"handledAccess,omitempty"`
+	Rules         []Rule   `json:"rules"`
+}
+
+// accessMask returns the mask of the named access rights.
+func accessMask(names []string) (uint64, error) {
+	var mask uint64
+	for _, name := range names {
+		a, ok := accessRights[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown landlock access right %q", name)
+		}
+		mask |= a.right
+	}
+	return mask, nil
+}
+
+// supportedAccess returns the mask of the access rights supported by a
+// Landlock ABI version.
+func supportedAccess(abi int) uint64 {
+	var mask uint64
+	for _, a := range accessRights {
+		if a.abi <= abi {
+			mask |= a.right
+		}
+	}
+	return mask
+}
+
+// ParseRuleset parses and validates a JSON Landlock ruleset.
+func ParseRuleset(data []byte) (*Ruleset, error) {
+	r := new(Ruleset)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(r); err != nil {
+		return nil, fmt.Errorf("invalid landlock ruleset: %s", err)
+	}
+	if _, err := accessMask(r.HandledAccess); err != nil {
+		return nil, err
+	}
+	for _, rule := range r.Rules {
+		if _, err := accessMask(rule.Access); err != nil {
+			return nil, err
+		}
+		for _, path := range rule.Paths {
+			if !filepath.IsAbs(path) {
+				return nil, fmt.Errorf("landlock rule path %s is not absolute", path)
+			}
+		}
+	}
+	return r, nil
+}
+
+// ABI returns the Landlock ABI version supported by the kernel, or 0 if
+// Landlock is not supported or disabled.
+func ABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// Apply restricts the current thread and its future children with the
+// ruleset. The access rights unknown to the kernel are ignored, paths
+// missing in the container are skipped. No new privileges bit is set.
+func (r *Ruleset) Apply() error {
+	abi := ABI()
+	if abi < 1 {
+		return fmt.Errorf("landlock is not supported or disabled by the kernel, Linux 5.13 or later is required")
+	}
+	supported := supportedAccess(abi)
+
+	handled := supported
+	if len(r.HandledAccess) > 0 {
+		mask, err := accessMask(r.HandledAccess)
+		if err != nil {
+			return err
+		}
+		if mask&^supported != 0 {
+			sylog.Debugf("Ignoring landlock access rights unsupported by landlock ABI %d", abi)
+		}
+		handled = mask & supported
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %s", errno)
+	}
+	defer unix.Close(int(fd))
+
+	for _, rule := range r.Rules {
+		access, err := accessMask(rule.Access)
+		if err != nil {
+			return err
+		}
+		for _, path := range rule.Paths {
+			if err := addPathRule(int(fd), path, access&handled); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no new privs flag: %s", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, fd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %s", errno)
+	}
+	return nil
+}
+
+// addPathRule adds a rule allowing access beneath path to the ruleset.
+func addPathRule(rulesetFd int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		sylog.Debugf("Skipping landlock rule for missing path %s", path)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("while opening landlock rule path %s: %s", path, err)
+	}
+	defer unix.Close(fd)
+
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return fmt.Errorf("while getting information for %s: %s", path, err)
+	}
+	if st.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= fileAccess
+	}
+	if access == 0 {
+		return nil
+	}
+
+	attr := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to add landlock rule for %s: %s", path, errno)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package landlock
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseRuleset(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectError bool
+	}{
+		{
+			name: "Valid",
+			data: `{"handledAccess": ["read_file", "write_file"], "rules": [{"paths": ["/usr"], "access": ["read_file"]}]}`,
+		},
+		{
+			name: "DefaultHandledAccess",
+			data: `{"rules": [{"paths": ["/"], "access": ["execute", "read_dir"]}]}`,
+		},
+		{
+			name:        "UnknownAccess",
+			data:        `{"rules": [{"paths": ["/usr"], "access": ["read"]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "UnknownHandledAccess",
+			data:        `{"handledAccess": ["write"], "rules": []}`,
+			expectError: true,
+		},
+		{
+			name:        "RelativePath",
+			data:        `{"rules": [{"paths": ["usr"], "access": ["read_file"]}]}`,
+			expectError: true,
+		},
+		{
+			name:        "UnknownField",
+			data:        `{"rule": []}`,
+			expectError: true,
+		},
+		{
+			name:        "BadJSON",
+			data:        `{"rules": [`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRuleset([]byte(tt.data))
+			if err != nil && !tt.expectError {
+				t.Errorf("unexpected error: %s", err)
+			} else if err == nil && tt.expectError {
+				t.Errorf("unexpected success")
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	if ABI() < 1 {
+		t.Skip("landlock not supported")
+	}
+
+	dir := t.TempDir()
+	allowed := filepath.Join(dir, "allowed")
+	denied := filepath.Join(dir, "denied")
+	for _, d := range []string{allowed, denied} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &Ruleset{
+		HandledAccess: []string{"write_file", "make_reg"},
+		Rules: []Rule{
+			{Paths: []string{allowed, filepath.Join(dir, "missing")}, Access: []string{"write_file", "make_reg"}},
+		},
+	}
+
+	errChan := make(chan error, 3)
+	go func() {
+		// the thread is not unlocked and so destroyed with the goroutine,
+		// the ruleset restricting only this thread
+		runtime.LockOSThread()
+
+		if err := r.Apply(); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			close(errChan)
+			return
+		}
+		errChan <- os.WriteFile(filepath.Join(allowed, "file"), nil, 0o644)
+		errChan <- os.WriteFile(filepath.Join(denied, "file"), nil, 0o644)
+		close(errChan)
+	}()
+
+	if err, ok := <-errChan; ok && err != nil {
+		t.Errorf("unexpected error writing in allowed directory: %s", err)
+	}
+	if err, ok := <-errChan; ok && err == nil {
+		t.Errorf("unexpected success writing in denied directory")
+	}
+}
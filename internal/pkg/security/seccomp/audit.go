@@ -0,0 +1,31 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package seccomp
+
+// DefaultAuditSyscalls are the syscalls logged by the seccomp audit mode when
+// none are specified, those commonly restricted by enforcement profiles.
+var DefaultAuditSyscalls = []string{
+	"add_key",
+	"bpf",
+	"chroot",
+	"delete_module",
+	"finit_module",
+	"init_module",
+	"kexec_file_load",
+	"kexec_load",
+	"keyctl",
+	"mount",
+	"open_by_handle_at",
+	"perf_event_open",
+	"personality",
+	"pivot_root",
+	"ptrace",
+	"request_key",
+	"setns",
+	"umount2",
+	"unshare",
+	"userfaultfd",
+}
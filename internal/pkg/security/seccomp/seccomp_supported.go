@@ -20,9 +20,11 @@ import (
 
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci/generate"
 	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/capabilities"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	cseccomp "github.com/seccomp/containers-golang"
 	lseccomp "github.com/seccomp/libseccomp-golang"
+	"golang.org/x/sys/unix"
 )
 
 var scmpArchMap = map[specs.Arch]lseccomp.ScmpArch{
@@ -269,3 +271,80 @@ func LoadProfileFromFile(profile string, generator *generate.Generator) error {
 
 	return nil
 }
+
+// notifyAPILevel is the libseccomp API level required for userspace
+// notifications.
+const notifyAPILevel = 6
+
+// LoadAuditFilter loads a seccomp filter for the current thread notifying the
+// listed syscalls to userspace, and returns the notification file descriptor
+// to pass to Audit. Without the effective capability CAP_SYS_ADMIN, the
+// kernel only allows the filter with the no new privileges bit set.
+func LoadAuditFilter(syscalls []string) (int, error) {
+	if api, err := lseccomp.GetAPI(); err != nil || api < notifyAPILevel {
+		return -1, fmt.Errorf("seccomp audit requires libseccomp 2.5.0 or later and kernel 5.5 or later")
+	}
+
+	filter, err := lseccomp.NewFilter(lseccomp.ActAllow)
+	if err != nil {
+		return -1, fmt.Errorf("error creating new filter: %s", err)
+	}
+	caps, err := capabilities.GetProcessEffective()
+	if err != nil {
+		return -1, fmt.Errorf("could not get effective capabilities: %s", err)
+	}
+	noNewPrivs := caps&uint64(1<<unix.CAP_SYS_ADMIN) == 0
+	if err := filter.SetNoNewPrivsBit(noNewPrivs); err != nil {
+		return -1, fmt.Errorf("failed to set no new priv flag: %s", err)
+	}
+	if noNewPrivs {
+		sylog.Debugf("No CAP_SYS_ADMIN capability, setting no new privileges for seccomp audit")
+	}
+
+	for _, name := range syscalls {
+		sysNr, err := lseccomp.GetSyscallFromName(name)
+		if err != nil {
+			sylog.Debugf("Ignoring unknown syscall %s for seccomp audit", name)
+			continue
+		}
+		if err := filter.AddRule(sysNr, lseccomp.ActNotify); err != nil {
+			return -1, fmt.Errorf("failed adding seccomp audit rule for syscall %s: %s", name, err)
+		}
+	}
+
+	if err := filter.Load(); err != nil {
+		return -1, fmt.Errorf("failed loading seccomp audit filter: %s", err)
+	}
+	fd, err := filter.GetNotifFd()
+	if err != nil {
+		return -1, fmt.Errorf("failed to get seccomp notification file descriptor: %s", err)
+	}
+	return int(fd), nil
+}
+
+// Audit receives the notifications of the seccomp filter loaded by
+// LoadAuditFilter, calls log for each notified syscall and lets the syscall
+// continue. It returns when notifications can't be received anymore.
+func Audit(fd int, log func(pid int, syscall string, args []uint64)) error {
+	for {
+		req, err := lseccomp.NotifReceive(lseccomp.ScmpFd(fd))
+		if err != nil {
+			return fmt.Errorf("failed to receive seccomp notification: %s", err)
+		}
+
+		name, err := req.Data.Syscall.GetNameByArch(req.Data.Arch)
+		if err != nil {
+			name = fmt.Sprintf("syscall_%d", req.Data.Syscall)
+		}
+		log(int(req.Pid), name, req.Data.Args)
+
+		resp := &lseccomp.ScmpNotifResp{
+			ID:    req.ID,
+			Flags: lseccomp.NotifRespFlagContinue,
+		}
+		// the process may have been interrupted or killed meanwhile
+		if err := lseccomp.NotifRespond(lseccomp.ScmpFd(fd), resp); err != nil {
+			sylog.Debugf("Failed to respond to seccomp notification: %s", err)
+		}
+	}
+}
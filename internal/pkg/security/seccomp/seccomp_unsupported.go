@@ -38,3 +38,13 @@ func LoadProfileFromFile(_ string, generator *generate.Generator) error {
 	}
 	return nil
 }
+
+// LoadAuditFilter loads a seccomp filter notifying the listed syscalls.
+func LoadAuditFilter(_ []string) (int, error) {
+	return -1, fmt.Errorf("can't load seccomp audit filter: not enabled at compilation time")
+}
+
+// Audit receives the notifications of the seccomp audit filter.
+func Audit(_ int, _ func(int, string, []uint64)) error {
+	return fmt.Errorf("can't audit syscalls: seccomp not enabled at compilation time")
+}
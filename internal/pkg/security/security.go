@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/security/apparmor"
+	"github.com/apptainer/apptainer/internal/pkg/security/landlock"
 	"github.com/apptainer/apptainer/internal/pkg/security/seccomp"
 	"github.com/apptainer/apptainer/internal/pkg/security/selinux"
 	"github.com/apptainer/apptainer/pkg/sylog"
@@ -44,6 +45,10 @@ func Configure(config *specs.Spec) error {
 			}
 		}
 	}
+	// landlock syscalls may be denied by the seccomp filter
+	if err := ConfigureLandlock(config); err != nil {
+		return err
+	}
 	if config.Linux != nil && config.Linux.Seccomp != nil {
 		if seccomp.Enabled() {
 			if err := seccomp.LoadSeccompConfig(config.Linux.Seccomp, config.Process.NoNewPrivileges, 1); err != nil {
@@ -56,6 +61,21 @@ func Configure(config *specs.Spec) error {
 	return nil
 }
 
+// ConfigureLandlock restricts the current thread with the Landlock ruleset
+// set in the annotations of the configuration, if any.
+func ConfigureLandlock(config *specs.Spec) error {
+	data, ok := config.Annotations[landlock.Annotation]
+	if !ok {
+		return nil
+	}
+	ruleset, err := landlock.ParseRuleset([]byte(data))
+	if err != nil {
+		return err
+	}
+	sylog.Debugf("Applying landlock ruleset")
+	return ruleset.Apply()
+}
+
 // GetParam iterates over security argument and returns parameters
 // for the security feature
 func GetParam(security []string, feature string) string {
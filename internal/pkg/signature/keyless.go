@@ -0,0 +1,456 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/internal/pkg/trust"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Fulcio certificate extensions holding the OIDC issuer of the identity
+// token used to obtain the certificate.
+var (
+	oidFulcioIssuer   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidFulcioIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// KeylessIdentity is an identity trusted to sign images keylessly: the OIDC
+// issuer of its certificate, and a regular expression matching its subject,
+// an email address or URI.
+type KeylessIdentity struct {
+	Issuer  string
+	Subject *regexp.Regexp
+}
+
+// ParseKeylessIdentity parses an identity in the "<issuer> <subject regex>"
+// form. The regular expression must match the whole subject.
+func ParseKeylessIdentity(s string) (KeylessIdentity, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return KeylessIdentity{}, fmt.Errorf("invalid keyless identity %q: expected '<issuer> <subject regex>'", s)
+	}
+	re, err := regexp.Compile("^(?:" + fields[1] + ")$")
+	if err != nil {
+		return KeylessIdentity{}, fmt.Errorf("invalid keyless identity subject %q: %v", fields[1], err)
+	}
+	return KeylessIdentity{Issuer: fields[0], Subject: re}, nil
+}
+
+// KeylessTrust holds the Fulcio certificate authority, the Rekor transparency
+// log and the identities trusted to verify keyless signatures.
+type KeylessTrust struct {
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+	// RekorKeys are the verifiers of the Rekor logs, indexed by log ID.
+	RekorKeys  map[string]signature.Verifier
+	Identities []KeylessIdentity
+}
+
+// LoadKeylessTrust returns the keyless trust from the PEM files of the Fulcio
+// certificates and of the Rekor public key, and the list of identities.
+func LoadKeylessTrust(fulcioPaths []string, rekorPath string, identities []string) (*KeylessTrust, error) {
+	if len(fulcioPaths) == 0 || rekorPath == "" {
+		return nil, fmt.Errorf("keyless verification requires the Fulcio roots and the Rekor public key to be configured")
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("keyless verification requires trusted identities to be configured")
+	}
+
+	t := &KeylessTrust{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		RekorKeys:     make(map[string]signature.Verifier),
+	}
+
+	for _, fulcioPath := range fulcioPaths {
+		b, err := os.ReadFile(fulcioPath)
+		if err != nil {
+			return nil, err
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(b)
+		if err != nil {
+			return nil, fmt.Errorf("while loading Fulcio certificates: %v", err)
+		}
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("no certificate found in %s", fulcioPath)
+		}
+		for _, c := range certs {
+			if bytes.Equal(c.RawIssuer, c.RawSubject) {
+				t.Roots.AddCert(c)
+			} else {
+				t.Intermediates.AddCert(c)
+			}
+		}
+	}
+
+	b, err := os.ReadFile(rekorPath)
+	if err != nil {
+		return nil, err
+	}
+	for len(b) > 0 {
+		var p *pem.Block
+		p, b = pem.Decode(b)
+		if p == nil {
+			break
+		}
+		pub, err := x509.ParsePKIXPublicKey(p.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("while loading Rekor public key: %v", err)
+		}
+		sv, err := signature.LoadVerifier(pub, crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("while loading Rekor public key: %v", err)
+		}
+		id := sha256.Sum256(p.Bytes)
+		t.RekorKeys[hex.EncodeToString(id[:])] = sv
+	}
+	if len(t.RekorKeys) == 0 {
+		return nil, fmt.Errorf("no public key found in %s", rekorPath)
+	}
+
+	for _, s := range identities {
+		id, err := ParseKeylessIdentity(s)
+		if err != nil {
+			return nil, err
+		}
+		t.Identities = append(t.Identities, id)
+	}
+	return t, nil
+}
+
+// LoadConfiguredKeylessTrust returns the keyless trust set by the keyless
+// directives of the apptainer configuration, the Fulcio certificates of the
+// site and user trust stores being trusted in addition to the configured
+// ones.
+func LoadConfiguredKeylessTrust(cfg *apptainerconf.File) (*KeylessTrust, error) {
+	var fulcioPaths []string
+	if cfg.KeylessFulcioRoots != "" {
+		fulcioPaths = append(fulcioPaths, cfg.KeylessFulcioRoots)
+	}
+	roots, err := trust.FulcioRoots()
+	if err != nil {
+		return nil, fmt.Errorf("while reading trust stores: %w", err)
+	}
+	fulcioPaths = append(fulcioPaths, roots...)
+	return LoadKeylessTrust(fulcioPaths, cfg.KeylessRekorPublicKey, cfg.KeylessTrustedIdentities)
+}
+
+// jsonInt64 is an int64 encoded as a string or a number, as protobuf encodes
+// 64-bit integers as strings in JSON.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = jsonInt64(n)
+	return nil
+}
+
+// sigstoreBundle holds the verification material of a sigstore bundle.
+type sigstoreBundle struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		X509CertificateChain struct {
+			Certificates []struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificates"`
+		} `json:"x509CertificateChain"`
+		Certificate struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificate"`
+		TlogEntries []struct {
+			LogIndex jsonInt64 `json:"logIndex"`
+			LogID    struct {
+				KeyID []byte `json:"keyId"`
+			} `json:"logId"`
+			IntegratedTime   jsonInt64 `json:"integratedTime"`
+			InclusionPromise struct {
+				SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+			} `json:"inclusionPromise"`
+			CanonicalizedBody []byte `json:"canonicalizedBody"`
+		} `json:"tlogEntries"`
+	} `json:"verificationMaterial"`
+	MessageSignature *struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+	DSSEEnvelope json.RawMessage `json:"dsseEnvelope"`
+}
+
+// hashedRekord is the body of a hashedrekord Rekor entry, recording a
+// signature, the certificate of its key and the digest of the signed
+// artifact.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+	} `json:"spec"`
+}
+
+// rekorPromise is the payload signed by Rekor in a signed entry timestamp.
+// Fields are in the order of the canonical JSON encoding.
+type rekorPromise struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// certificateIssuer returns the OIDC issuer recorded in a Fulcio certificate.
+func certificateIssuer(c *x509.Certificate) string {
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oidFulcioIssuerV2) {
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		}
+	}
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oidFulcioIssuer) {
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// certificateSubjects returns the email addresses and URIs of a certificate.
+func certificateSubjects(c *x509.Certificate) []string {
+	subjects := append([]string{}, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		subjects = append(subjects, u.String())
+	}
+	return subjects
+}
+
+// checkIdentity checks that the certificate was issued to a trusted identity.
+func (t *KeylessTrust) checkIdentity(c *x509.Certificate) error {
+	issuer := certificateIssuer(c)
+	subjects := certificateSubjects(c)
+	for _, id := range t.Identities {
+		if id.Issuer != issuer {
+			continue
+		}
+		for _, s := range subjects {
+			if id.Subject.MatchString(s) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("certificate identity %v from issuer %q is not trusted", subjects, issuer)
+}
+
+// parseHashedRekord returns the signature and the sha256 digest of the
+// signed artifact recorded by the hashedrekord Rekor entry body, checking
+// that it records the signature made with the key of the certificate c.
+func parseHashedRekord(body []byte, c *x509.Certificate) (sig, digest []byte, err error) {
+	var e hashedRekord
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, nil, fmt.Errorf("invalid entry body: %v", err)
+	}
+	if e.Kind != "hashedrekord" {
+		return nil, nil, fmt.Errorf("unsupported entry kind %q, expected hashedrekord", e.Kind)
+	}
+	p, _ := pem.Decode(e.Spec.Signature.PublicKey.Content)
+	if p == nil || p.Type != "CERTIFICATE" || !bytes.Equal(p.Bytes, c.Raw) {
+		return nil, nil, fmt.Errorf("entry doesn't record the bundle certificate")
+	}
+	if len(e.Spec.Signature.Content) == 0 {
+		return nil, nil, fmt.Errorf("entry records no signature")
+	}
+	if e.Spec.Data.Hash.Algorithm != "sha256" {
+		return nil, nil, fmt.Errorf("unsupported entry digest algorithm %q", e.Spec.Data.Hash.Algorithm)
+	}
+	digest, err = hex.DecodeString(e.Spec.Data.Hash.Value)
+	if err != nil || len(digest) != sha256.Size {
+		return nil, nil, fmt.Errorf("invalid entry digest %q", e.Spec.Data.Hash.Value)
+	}
+	return e.Spec.Signature.Content, digest, nil
+}
+
+// keylessSignature is the signature verified by a sigstore bundle: the
+// certificate of its key, the signature and the sha256 digest of the signed
+// message, as recorded in the transparency log.
+type keylessSignature struct {
+	cert   *x509.Certificate
+	sig    []byte
+	digest []byte
+}
+
+// verifier returns a verifier with the key of the certificate which only
+// accepts the recorded signature of the recorded message, so that messages
+// signed with the key but not recorded in the transparency log, for other
+// artifacts or after the certificate expired, are rejected.
+func (s *keylessSignature) verifier() (signature.Verifier, error) {
+	sv, err := signature.LoadVerifier(s.cert.PublicKey, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedVerifier{Verifier: sv, sig: s.sig, digest: s.digest}, nil
+}
+
+// loggedVerifier is a verifier only accepting the signature sig of the
+// message with the sha256 digest.
+type loggedVerifier struct {
+	signature.Verifier
+	sig    []byte
+	digest []byte
+}
+
+func (v *loggedVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	s, err := io.ReadAll(sig)
+	if err != nil {
+		return err
+	}
+	m, err := io.ReadAll(message)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(m)
+	if !bytes.Equal(s, v.sig) || !bytes.Equal(digest[:], v.digest) {
+		return fmt.Errorf("signature not recorded in the transparency log entry of the sigstore bundle")
+	}
+	return v.Verifier.VerifySignature(bytes.NewReader(s), bytes.NewReader(m), opts...)
+}
+
+// verifyKeylessBundle verifies that the certificate of a sigstore bundle was
+// issued by Fulcio to a trusted identity, and that the message signature of
+// the bundle was recorded with it in the Rekor transparency log while it was
+// valid. The certificate and the recorded signature are returned.
+func verifyKeylessBundle(data []byte, t *KeylessTrust) (*keylessSignature, error) {
+	var b sigstoreBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid sigstore bundle: %v", err)
+	}
+	if !strings.HasPrefix(b.MediaType, "application/vnd.dev.sigstore.bundle") {
+		return nil, fmt.Errorf("invalid sigstore bundle media type %q", b.MediaType)
+	}
+	ms := b.MessageSignature
+	if ms == nil {
+		if len(b.DSSEEnvelope) > 0 {
+			return nil, fmt.Errorf("sigstore bundles with a DSSE envelope are not supported, a message signature is required")
+		}
+		return nil, fmt.Errorf("no message signature found in sigstore bundle")
+	}
+	if ms.MessageDigest.Algorithm != "SHA2_256" || len(ms.MessageDigest.Digest) != sha256.Size {
+		return nil, fmt.Errorf("unsupported message digest %q in sigstore bundle, expected SHA2_256", ms.MessageDigest.Algorithm)
+	}
+
+	var raw [][]byte
+	if len(b.VerificationMaterial.Certificate.RawBytes) > 0 {
+		raw = append(raw, b.VerificationMaterial.Certificate.RawBytes)
+	}
+	for _, c := range b.VerificationMaterial.X509CertificateChain.Certificates {
+		raw = append(raw, c.RawBytes)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no certificate found in sigstore bundle, keyless verification requires a Fulcio certificate")
+	}
+	leaf, err := x509.ParseCertificate(raw[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate in sigstore bundle: %v", err)
+	}
+	intermediates := t.Intermediates.Clone()
+	for _, r := range raw[1:] {
+		c, err := x509.ParseCertificate(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in sigstore bundle: %v", err)
+		}
+		intermediates.AddCert(c)
+	}
+
+	if len(b.VerificationMaterial.TlogEntries) == 0 {
+		return nil, fmt.Errorf("no transparency log entry found in sigstore bundle")
+	}
+
+	var lastErr error
+	for _, e := range b.VerificationMaterial.TlogEntries {
+		logID := hex.EncodeToString(e.LogID.KeyID)
+		sv, ok := t.RekorKeys[logID]
+		if !ok {
+			lastErr = fmt.Errorf("transparency log entry from unknown log %s", logID)
+			continue
+		}
+		payload, err := json.Marshal(rekorPromise{
+			Body:           base64.StdEncoding.EncodeToString(e.CanonicalizedBody),
+			IntegratedTime: int64(e.IntegratedTime),
+			LogID:          logID,
+			LogIndex:       int64(e.LogIndex),
+		})
+		if err != nil {
+			return nil, err
+		}
+		set := e.InclusionPromise.SignedEntryTimestamp
+		if err := sv.VerifySignature(bytes.NewReader(set), bytes.NewReader(payload)); err != nil {
+			lastErr = fmt.Errorf("invalid signed entry timestamp of transparency log entry %d: %v", e.LogIndex, err)
+			continue
+		}
+
+		sig, digest, err := parseHashedRekord(e.CanonicalizedBody, leaf)
+		if err != nil {
+			lastErr = fmt.Errorf("transparency log entry %d: %v", e.LogIndex, err)
+			continue
+		}
+		if !bytes.Equal(sig, ms.Signature) || !bytes.Equal(digest, ms.MessageDigest.Digest) {
+			lastErr = fmt.Errorf("transparency log entry %d doesn't record the message signature of the bundle", e.LogIndex)
+			continue
+		}
+
+		// Fulcio certificates are short lived, they are verified at the
+		// time they were recorded in the transparency log.
+		integrated := time.Unix(int64(e.IntegratedTime), 0)
+		_, err = leaf.Verify(x509.VerifyOptions{
+			Intermediates: intermediates,
+			Roots:         t.Roots,
+			CurrentTime:   integrated,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("while verifying certificate at %s: %v", integrated.UTC().Format(time.RFC3339), err)
+		}
+
+		if err := t.checkIdentity(leaf); err != nil {
+			return nil, err
+		}
+		return &keylessSignature{cert: leaf, sig: sig, digest: digest}, nil
+	}
+	return nil, lastErr
+}
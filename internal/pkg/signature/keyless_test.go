@@ -0,0 +1,461 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apptainer/sif/v2/pkg/sif"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// keylessFixture holds a test Fulcio certificate authority and Rekor log.
+type keylessFixture struct {
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	rekorKey *ecdsa.PrivateKey
+	logID    []byte
+	trust    *KeylessTrust
+}
+
+func newKeylessFixture(t *testing.T) *keylessFixture {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&rekorKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(pub)
+
+	dir := t.TempDir()
+	fulcioPath := filepath.Join(dir, "fulcio.pem")
+	rekorPath := filepath.Join(dir, "rekor.pub")
+	if err := os.WriteFile(fulcioPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rekorPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trust, err := LoadKeylessTrust([]string{fulcioPath}, rekorPath, []string{`https://issuer.example.org .*@example\.org`})
+	if err != nil {
+		t.Fatalf("failed to load keyless trust: %v", err)
+	}
+
+	return &keylessFixture{ca: ca, caKey: caKey, rekorKey: rekorKey, logID: logID[:], trust: trust}
+}
+
+// issue returns a Fulcio-like certificate issued to email by issuer, valid
+// from notBefore for ten minutes.
+func (f *keylessFixture) issue(t *testing.T, issuer, email string, notBefore time.Time) *x509.Certificate {
+	t.Helper()
+	c, _ := f.issueKey(t, issuer, email, notBefore)
+	return c
+}
+
+// issueKey returns a certificate like issue, and its private key.
+func (f *keylessFixture) issueKey(t *testing.T, issuer, email string, notBefore time.Time) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerExt, err := asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      notBefore,
+		NotAfter:       notBefore.Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{email},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidFulcioIssuerV2, Value: issuerExt},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, f.ca, &key.PublicKey, f.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, key
+}
+
+// bundle returns a sigstore bundle for c with a placeholder message
+// signature, with a transparency log entry integrated at the given time and
+// signed by the Rekor key.
+func (f *keylessFixture) bundle(t *testing.T, c *x509.Certificate, integrated time.Time) map[string]interface{} {
+	t.Helper()
+	digest := sha256.Sum256([]byte("message"))
+	return f.signedBundle(t, c, integrated, []byte("signature"), digest[:])
+}
+
+// signedBundle returns a sigstore bundle for c with the message signature
+// sig of the message with the sha256 digest, recorded in a transparency log
+// entry integrated at the given time and signed by the Rekor key.
+func (f *keylessFixture) signedBundle(t *testing.T, c *x509.Certificate, integrated time.Time, sig, digest []byte) map[string]interface{} {
+	t.Helper()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"signature": map[string]interface{}{
+				"content": sig,
+				"publicKey": map[string]interface{}{
+					"content": base64.StdEncoding.EncodeToString(certPEM),
+				},
+			},
+			"data": map[string]interface{}{
+				"hash": map[string]interface{}{
+					"algorithm": "sha256",
+					"value":     hex.EncodeToString(digest),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(rekorPromise{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integrated.Unix(),
+		LogID:          hex.EncodeToString(f.logID),
+		LogIndex:       42,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.LoadSigner(f.rekorKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := signer.SignMessage(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]interface{}{
+		"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2",
+		"verificationMaterial": map[string]interface{}{
+			"x509CertificateChain": map[string]interface{}{
+				"certificates": []interface{}{
+					map[string]interface{}{"rawBytes": c.Raw},
+				},
+			},
+			"tlogEntries": []interface{}{
+				map[string]interface{}{
+					"logIndex":          "42",
+					"logId":             map[string]interface{}{"keyId": f.logID},
+					"integratedTime":    strconv.FormatInt(integrated.Unix(), 10),
+					"inclusionPromise":  map[string]interface{}{"signedEntryTimestamp": set},
+					"canonicalizedBody": body,
+				},
+			},
+		},
+		"messageSignature": map[string]interface{}{
+			"messageDigest": map[string]interface{}{
+				"algorithm": "SHA2_256",
+				"digest":    digest,
+			},
+			"signature": sig,
+		},
+	}
+}
+
+func tlogEntry(b map[string]interface{}) map[string]interface{} {
+	vm := b["verificationMaterial"].(map[string]interface{})
+	return vm["tlogEntries"].([]interface{})[0].(map[string]interface{})
+}
+
+func TestVerifyKeylessBundle(t *testing.T) {
+	f := newKeylessFixture(t)
+	now := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		bundle    func() map[string]interface{}
+		expectErr bool
+	}{
+		{
+			name: "Valid",
+			bundle: func() map[string]interface{} {
+				return f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+			},
+		},
+		{
+			name: "UntrustedSubject",
+			bundle: func() map[string]interface{} {
+				return f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org.evil", now), now.Add(time.Minute))
+			},
+			expectErr: true,
+		},
+		{
+			name: "UntrustedIssuer",
+			bundle: func() map[string]interface{} {
+				return f.bundle(t, f.issue(t, "https://evil.example.com", "user@example.org", now), now.Add(time.Minute))
+			},
+			expectErr: true,
+		},
+		{
+			name: "IntegratedAfterExpiry",
+			bundle: func() map[string]interface{} {
+				return f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Hour))
+			},
+			expectErr: true,
+		},
+		{
+			name: "TamperedIntegratedTime",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Hour))
+				tlogEntry(b)["integratedTime"] = strconv.FormatInt(now.Add(time.Minute).Unix(), 10)
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "CertificateNotInLog",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				other := f.issue(t, "https://issuer.example.org", "user@example.org", now)
+				vm := b["verificationMaterial"].(map[string]interface{})
+				vm["x509CertificateChain"] = map[string]interface{}{
+					"certificates": []interface{}{map[string]interface{}{"rawBytes": other.Raw}},
+				}
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "MessageSignatureNotInLog",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				b["messageSignature"].(map[string]interface{})["signature"] = []byte("other signature")
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "MessageDigestNotInLog",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				digest := sha256.Sum256([]byte("other message"))
+				b["messageSignature"].(map[string]interface{})["messageDigest"] = map[string]interface{}{
+					"algorithm": "SHA2_256",
+					"digest":    digest[:],
+				}
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "NoMessageSignature",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				delete(b, "messageSignature")
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "UnknownLog",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				tlogEntry(b)["logId"] = map[string]interface{}{"keyId": make([]byte, 32)}
+				return b
+			},
+			expectErr: true,
+		},
+		{
+			name: "NoLogEntry",
+			bundle: func() map[string]interface{} {
+				b := f.bundle(t, f.issue(t, "https://issuer.example.org", "user@example.org", now), now.Add(time.Minute))
+				b["verificationMaterial"].(map[string]interface{})["tlogEntries"] = []interface{}{}
+				return b
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.bundle())
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := verifyKeylessBundle(data, f.trust)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.cert.EmailAddresses[0] != "user@example.org" {
+				t.Errorf("got certificate for %v", s.cert.EmailAddresses)
+			}
+		})
+	}
+}
+
+// signKeyless signs a copy of the SIF image at path with key, and returns
+// the path of the signed image, the DSSE signature and the sha256 digest of
+// the signed message, as recorded in the transparency log by keyless signing.
+func signKeyless(t *testing.T, path string, key *ecdsa.PrivateKey) (string, []byte, []byte) {
+	t.Helper()
+	signed, err := tempFileFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(signed) })
+	ss, err := signature.LoadSigner(key, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Sign(context.Background(), signed, OptSignWithSigner(ss)); err != nil {
+		t.Fatalf("failed to sign image: %v", err)
+	}
+
+	fimg, err := sif.LoadContainerFromPath(signed, sif.OptLoadWithFlag(os.O_RDONLY))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fimg.UnloadContainer()
+	d, err := fimg.GetDescriptor(sif.WithDataType(sif.DataSignature))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := d.GetData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e struct {
+		PayloadType string `json:"payloadType"`
+		Payload     []byte `json:"payload"`
+		Signatures  []struct {
+			Sig []byte `json:"sig"`
+		} `json:"signatures"`
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Signatures) != 1 {
+		t.Fatalf("got %d signatures, expected 1", len(e.Signatures))
+	}
+	// the DSSE signature is made over the pre-authentication encoding of
+	// the payload
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(e.PayloadType), e.PayloadType, len(e.Payload), e.Payload)
+	digest := sha256.Sum256([]byte(pae))
+	return signed, e.Signatures[0].Sig, digest[:]
+}
+
+func TestVerifyKeyless(t *testing.T) {
+	f := newKeylessFixture(t)
+	now := time.Now().Add(-time.Hour)
+	image := filepath.Join("..", "..", "..", "test", "images", "one-group.sif")
+
+	c, key := f.issueKey(t, "https://issuer.example.org", "user@example.org", now)
+	logged, sig, digest := signKeyless(t, image, key)
+	data, err := json.Marshal(f.signedBundle(t, c, now.Add(time.Minute), sig, digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify(context.Background(), logged, OptVerifyWithKeylessBundle(data, f.trust)); err != nil {
+		t.Errorf("unexpected error verifying logged signature: %v", err)
+	}
+
+	// an image signed with the same key, but whose signature was not
+	// recorded in the transparency log, is rejected
+	notLogged, _, _ := signKeyless(t, image, key)
+	if err := Verify(context.Background(), notLogged, OptVerifyWithKeylessBundle(data, f.trust)); err == nil {
+		t.Errorf("unexpected success verifying signature not recorded in the transparency log")
+	}
+}
+
+func TestParseKeylessIdentity(t *testing.T) {
+	tests := []struct {
+		identity  string
+		subject   string
+		match     bool
+		expectErr bool
+	}{
+		{`https://issuer.example.org .*@example\.org`, "user@example.org", true, false},
+		{`https://issuer.example.org .*@example\.org`, "user@example.org.evil", false, false},
+		{`https://issuer.example.org user@example\.org`, "evil-user@example.org", false, false},
+		{`https://issuer.example.org`, "", false, true},
+		{`https://issuer.example.org (`, "", false, true},
+	}
+
+	for _, tt := range tests {
+		id, err := ParseKeylessIdentity(tt.identity)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("expected error parsing %q", tt.identity)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tt.identity, err)
+			continue
+		}
+		if m := id.Subject.MatchString(tt.subject); m != tt.match {
+			t.Errorf("identity %q match %q = %v, expected %v", tt.identity, tt.subject, m, tt.match)
+		}
+	}
+}
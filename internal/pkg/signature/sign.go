@@ -11,8 +11,10 @@ package signature
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/apptainer/apptainer/internal/pkg/sypgp"
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/apptainer/sif/v2/pkg/integrity"
 	"github.com/apptainer/sif/v2/pkg/sif"
 	"github.com/sigstore/sigstore/pkg/signature"
@@ -28,6 +30,16 @@ type SignOpt func(s *signer) error
 // OptSignWithSigner specifies ss be used to generate signature(s).
 func OptSignWithSigner(ss signature.Signer) SignOpt {
 	return func(s *signer) error {
+		if fips.Enabled() {
+			pub, err := ss.PublicKey()
+			if err != nil {
+				return err
+			}
+			if err := fips.CheckPublicKey(pub); err != nil {
+				return fmt.Errorf("signing with a %w", err)
+			}
+		}
+
 		s.opts = append(s.opts, integrity.OptSignWithSigner(ss))
 		return nil
 	}
@@ -42,6 +54,12 @@ func OptSignEntitySelector(f sypgp.EntitySelector) SignOpt {
 			return err
 		}
 
+		if fips.Enabled() {
+			if err := fips.CheckPGPKey(e.PrimaryKey); err != nil {
+				return fmt.Errorf("signing with a %w", err)
+			}
+		}
+
 		s.opts = append(s.opts, integrity.OptSignWithEntity(e))
 
 		return nil
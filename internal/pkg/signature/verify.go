@@ -22,7 +22,9 @@ import (
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
 	"github.com/apptainer/apptainer/internal/pkg/sypgp"
+	"github.com/apptainer/apptainer/internal/pkg/trust"
 	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/apptainer/container-key-client/client"
 	"github.com/apptainer/sif/v2/pkg/integrity"
 	"github.com/apptainer/sif/v2/pkg/sif"
@@ -41,6 +43,8 @@ type verifier struct {
 	roots         *x509.CertPool
 	ocsp          bool
 	svs           []signature.Verifier
+	bundles       [][]byte
+	keyless       *KeylessTrust
 	pgp           bool
 	pgpOpts       []client.Option
 	groupIDs      []uint32
@@ -48,6 +52,7 @@ type verifier struct {
 	all           bool
 	legacy        bool
 	cb            VerifyCallback
+	fipsErr       *error
 }
 
 // VerifyOpt are used to configure v.
@@ -87,6 +92,18 @@ func OptVerifyWithVerifier(sv signature.Verifier) VerifyOpt {
 	}
 }
 
+// OptVerifyWithKeylessBundle appends the certificate of the sigstore bundle b as a source of key
+// material to verify signatures. The certificate must be issued by the Fulcio certificate authority
+// of t to one of its identities, and be recorded in its Rekor transparency log along with the
+// message signature of the bundle, the only signature accepted with its key.
+func OptVerifyWithKeylessBundle(b []byte, t *KeylessTrust) VerifyOpt {
+	return func(v *verifier) error {
+		v.bundles = append(v.bundles, b)
+		v.keyless = t
+		return nil
+	}
+}
+
 // OptVerifyWithPGP adds the local public keyring as a source of key material to verify signatures.
 // If supplied, opts specify a keyserver to use in addition to the local public keyring.
 func OptVerifyWithPGP(opts ...client.Option) VerifyOpt {
@@ -97,6 +114,19 @@ func OptVerifyWithPGP(opts ...client.Option) VerifyOpt {
 	}
 }
 
+// OptVerifyWithTrustedKeys appends the public keys of the site and user trust stores as sources of
+// key material to verify signatures.
+func OptVerifyWithTrustedKeys() VerifyOpt {
+	return func(v *verifier) error {
+		svs, err := trust.Verifiers()
+		if err != nil {
+			return err
+		}
+		v.svs = append(v.svs, svs...)
+		return nil
+	}
+}
+
 // OptVerifyWithOCSP subjects the x509 certificate chains to online revocation checks,
 // before the leaf certificate is deemed as trusted for validating the signature.
 func OptVerifyWithOCSP() VerifyOpt {
@@ -162,6 +192,22 @@ func newVerifier(opts []VerifyOpt) (verifier, error) {
 	return v, nil
 }
 
+// checkFIPSSigner returns an error if the signature of r was made with a key of an algorithm not
+// approved in FIPS mode.
+func checkFIPSSigner(r integrity.VerifyResult) error {
+	if e := r.Entity(); e != nil {
+		if err := fips.CheckPGPKey(e.PrimaryKey); err != nil {
+			return fmt.Errorf("image signed with a %w", err)
+		}
+	}
+	for _, pub := range r.Keys() {
+		if err := fips.CheckPublicKey(pub); err != nil {
+			return fmt.Errorf("image signed with a %w", err)
+		}
+	}
+	return nil
+}
+
 // verifyCertificate attempts to verify c is a valid code signing certificate by building one or
 // more chains from c to a certificate in roots, using certificates in intermediates if needed.
 // This function does not do any revocation checking.
@@ -215,6 +261,23 @@ func (v verifier) getOpts(ctx context.Context, f *sif.FileImage) ([]integrity.Ve
 		iopts = append(iopts, integrity.OptVerifyWithVerifier(sv))
 	}
 
+	// Add key material from keyless sigstore bundle(s).
+	for _, b := range v.bundles {
+		s, err := verifyKeylessBundle(b, v.keyless)
+		if err != nil {
+			return nil, err
+		}
+		sylog.Debugf("Keyless certificate issued to %v verified", certificateSubjects(s.cert))
+
+		// only the signature recorded in the transparency log is accepted
+		sv, err := s.verifier()
+		if err != nil {
+			return nil, err
+		}
+
+		iopts = append(iopts, integrity.OptVerifyWithVerifier(sv))
+	}
+
 	// Add explicitly provided key material source(s).
 	for _, sv := range v.svs {
 		iopts = append(iopts, integrity.OptVerifyWithVerifier(sv))
@@ -243,7 +306,12 @@ func (v verifier) getOpts(ctx context.Context, f *sif.FileImage) ([]integrity.Ve
 		if err != nil {
 			return nil, err
 		}
-		kr = sypgp.NewMultiKeyRing(gkr, kr)
+		// and the PGP keys of the trust stores
+		tkr, err := trust.PGPKeyRing()
+		if err != nil {
+			return nil, err
+		}
+		kr = sypgp.NewMultiKeyRing(gkr, tkr, kr)
 
 		iopts = append(iopts, integrity.OptVerifyWithKeyRing(kr))
 	}
@@ -295,6 +363,10 @@ func (v verifier) getOpts(ctx context.Context, f *sif.FileImage) ([]integrity.Ve
 //
 // To use raw key material, use OptVerifyWithVerifier.
 //
+// To use key material from a keyless sigstore bundle, use OptVerifyWithKeylessBundle.
+//
+// To use the public keys of the trust stores, use OptVerifyWithTrustedKeys.
+//
 // To use PGP key material, use OptVerifyWithPGP.
 //
 // By default, non-legacy signatures for all object groups are verified. To override the default
@@ -312,7 +384,9 @@ func Verify(ctx context.Context, path string, opts ...VerifyOpt) error {
 	}
 	defer f.UnloadContainer()
 
-	// Get options to validate f.
+	// Get options to validate f, recording signatures made with non-approved keys in FIPS mode.
+	var fipsErr error
+	v.fipsErr = &fipsErr
 	vopts, err := v.getOpts(ctx, f)
 	if err != nil {
 		return err
@@ -324,7 +398,10 @@ func Verify(ctx context.Context, path string, opts ...VerifyOpt) error {
 		return err
 	}
 
-	return iv.Verify()
+	if err := iv.Verify(); err != nil {
+		return err
+	}
+	return fipsErr
 }
 
 // VerifyFingerprints verifies an image and checks it was signed by *all* of the provided
@@ -336,6 +413,10 @@ func Verify(ctx context.Context, path string, opts ...VerifyOpt) error {
 //
 // To use raw key material, use OptVerifyWithVerifier.
 //
+// To use key material from a keyless sigstore bundle, use OptVerifyWithKeylessBundle.
+//
+// To use the public keys of the trust stores, use OptVerifyWithTrustedKeys.
+//
 // To use PGP key material, use OptVerifyWithPGP.
 //
 // By default, non-legacy signatures for all object groups are verified. To override the default
@@ -353,7 +434,9 @@ func VerifyFingerprints(ctx context.Context, path string, fingerprints []string,
 	}
 	defer f.UnloadContainer()
 
-	// Get options to validate f.
+	// Get options to validate f, recording signatures made with non-approved keys in FIPS mode.
+	var fipsErr error
+	v.fipsErr = &fipsErr
 	vopts, err := v.getOpts(ctx, f)
 	if err != nil {
 		return err
@@ -368,6 +451,9 @@ func VerifyFingerprints(ctx context.Context, path string, fingerprints []string,
 	if err != nil {
 		return err
 	}
+	if fipsErr != nil {
+		return fipsErr
+	}
 
 	// get signing entities fingerprints that have signed all selected objects
 	keyfps, err := iv.AllSignedBy()
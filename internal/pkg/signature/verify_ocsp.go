@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/ocsp"
 )
@@ -181,6 +182,9 @@ func checkOCSPResponse(cert, issuer *x509.Certificate) (needsValidation *x509.Ce
 	// Hash contains the hash function that should be used when
 	// constructing the OCSP request. If zero, SHA-1 will be used.
 	opts := &ocsp.RequestOptions{Hash: crypto.SHA1}
+	if fips.Enabled() {
+		opts.Hash = crypto.SHA256
+	}
 
 	buffer, err := ocsp.CreateRequest(cert, issuer, opts)
 	if err != nil {
@@ -0,0 +1,58 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package syecl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/apptainer/apptainer/internal/pkg/sypgp"
+)
+
+// ErrProhibited is returned by Enforce when an image is not allowed
+// to run by the execution control list.
+var ErrProhibited = errors.New("image prohibited by ECL")
+
+// Enforce loads the ECL configuration file located at confPath and
+// checks whether the SIF image opened as fp is allowed to run. If the
+// configuration file can't be read, any image is allowed to run. The
+// global public keyring located in keyringDir is only loaded when the
+// ECL is activated.
+func Enforce(ctx context.Context, confPath, keyringDir string, fp *os.File) error {
+	ecl, err := LoadConfig(confPath)
+	if err != nil {
+		return nil
+	}
+	if err := ecl.ValidateConfig(); err != nil {
+		return fmt.Errorf("while validating ECL configuration: %s", err)
+	}
+
+	// Only try to load the global keyring here if the ECL is active.
+	// Otherwise pass through an empty keyring rather than avoiding calling
+	// the ECL functions as this keeps the logic for applying / ignoring ECL in a
+	// single location.
+	var kr openpgp.KeyRing = openpgp.EntityList{}
+	if ecl.Activated {
+		keyring := sypgp.NewHandle(keyringDir, sypgp.GlobalHandleOpt())
+		kr, err = keyring.LoadPubKeyring()
+		if err != nil {
+			return fmt.Errorf("while obtaining keyring for ECL: %s", err)
+		}
+	}
+
+	if ok, err := ecl.ShouldRunFp(ctx, fp, kr); err != nil {
+		return fmt.Errorf("while checking container image with ECL: %s", err)
+	} else if !ok {
+		return ErrProhibited
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package trust manages the trust anchors used to verify images: PGP public
+// keys, PEM public keys like cosign keys, and the certificates of the Fulcio
+// certificate authorities issuing keyless signing certificates. Anchors are
+// stored as files in the site trust store, managed by root, and in the user
+// trust store.
+package trust
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// Kind is the kind of a trust anchor.
+type Kind string
+
+const (
+	// PGP anchors are PGP public keys verifying PGP signatures.
+	PGP Kind = "pgp"
+	// Key anchors are PEM public keys, like cosign keys, verifying DSSE
+	// signatures.
+	Key Kind = "key"
+	// Root anchors are PEM certificates of Fulcio certificate authorities
+	// issuing keyless signing certificates.
+	Root Kind = "root"
+)
+
+// Kinds are the kinds of trust anchors.
+var Kinds = []Kind{PGP, Key, Root}
+
+// ParseKind returns the kind of trust anchor named s.
+func ParseKind(s string) (Kind, error) {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown trust anchor kind %q, must be one of pgp, key or root", s)
+}
+
+// ext returns the file extension of the anchors of kind k.
+func (k Kind) ext() string {
+	if k == PGP {
+		return ".pgp"
+	}
+	return ".pem"
+}
+
+// ErrAnchorNotFound is returned when removing a trust anchor which doesn't
+// exist.
+var ErrAnchorNotFound = errors.New("trust anchor not found")
+
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// Store is a trust store.
+type Store struct {
+	// Name is site or user.
+	Name string
+	// Dir is the directory holding the trust anchors.
+	Dir string
+}
+
+// SiteStore returns the site trust store, in the apptainer configuration
+// directory.
+func SiteStore() *Store {
+	return &Store{Name: "site", Dir: filepath.Join(buildcfg.APPTAINER_CONFDIR, syfs.TrustDirName)}
+}
+
+// UserStore returns the trust store of the current user.
+func UserStore() *Store {
+	return &Store{Name: "user", Dir: syfs.TrustDir()}
+}
+
+// Stores returns the site and user trust stores.
+func Stores() []*Store {
+	return []*Store{SiteStore(), UserStore()}
+}
+
+// Anchor is a trust anchor of a store.
+type Anchor struct {
+	Kind  Kind   `json:"kind"`
+	Name  string `json:"name"`
+	Store string `json:"store"`
+	Path  string `json:"path"`
+	// ID identifies the anchor: the PGP key fingerprints, the sha256
+	// digest of the public key or the certificate subjects.
+	ID string `json:"id"`
+}
+
+// path returns the path of the anchor of kind k named name.
+func (s *Store) path(k Kind, name string) string {
+	return filepath.Join(s.Dir, string(k), name+k.ext())
+}
+
+// Add adds the trust anchor of kind k read from the file src to the store,
+// named name or after the file name if empty.
+func (s *Store) Add(k Kind, name, src string) (*Anchor, error) {
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	}
+	if !nameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid trust anchor name %q, only alphanumeric characters, '.', '_' and '-' are allowed", name)
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	id, err := anchorID(k, b)
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s: %w", src, err)
+	}
+
+	path := s.path(k, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%s trust anchor %s already exists in the %s trust store", k, name, s.Name)
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &Anchor{Kind: k, Name: name, Store: s.Name, Path: path, ID: id}, nil
+}
+
+// Remove removes the trust anchor of kind k named name from the store.
+func (s *Store) Remove(k Kind, name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("%s %s: %w", k, name, ErrAnchorNotFound)
+	}
+	err := os.Remove(s.path(k, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s %s: %w", k, name, ErrAnchorNotFound)
+	}
+	return err
+}
+
+// List returns the trust anchors of kind k in the store, or all anchors if
+// k is empty.
+func (s *Store) List(k Kind) ([]Anchor, error) {
+	var anchors []Anchor
+	for _, kind := range Kinds {
+		if k != "" && kind != k {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(s.Dir, string(kind), "*"+kind.ext()))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			id, err := anchorID(kind, b)
+			if err != nil {
+				return nil, fmt.Errorf("while reading trust anchor %s: %w", path, err)
+			}
+			anchors = append(anchors, Anchor{
+				Kind:  kind,
+				Name:  strings.TrimSuffix(filepath.Base(path), kind.ext()),
+				Store: s.Name,
+				Path:  path,
+				ID:    id,
+			})
+		}
+	}
+	return anchors, nil
+}
+
+// anchorID validates the content of a trust anchor of kind k, and returns
+// its identifier.
+func anchorID(k Kind, b []byte) (string, error) {
+	switch k {
+	case PGP:
+		el, err := readPGPKeys(b)
+		if err != nil {
+			return "", err
+		}
+		fps := make([]string, 0, len(el))
+		for _, e := range el {
+			if e.PrivateKey != nil {
+				return "", fmt.Errorf("private PGP keys can't be trust anchors")
+			}
+			fps = append(fps, fmt.Sprintf("%X", e.PrimaryKey.Fingerprint))
+		}
+		return strings.Join(fps, ","), nil
+	case Key:
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey(b)
+		if err != nil {
+			return "", fmt.Errorf("invalid PEM public key: %w", err)
+		}
+		der, err := cryptoutils.MarshalPublicKeyToDER(pub)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(der)
+		return "sha256:" + hex.EncodeToString(sum[:]), nil
+	case Root:
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(b)
+		if err != nil {
+			return "", fmt.Errorf("invalid PEM certificates: %w", err)
+		}
+		if len(certs) == 0 {
+			return "", fmt.Errorf("no PEM certificate found")
+		}
+		subjects := make([]string, 0, len(certs))
+		for _, c := range certs {
+			subjects = append(subjects, c.Subject.String())
+		}
+		return strings.Join(subjects, ","), nil
+	}
+	return "", fmt.Errorf("unknown trust anchor kind %q", k)
+}
+
+// readPGPKeys reads PGP keys in binary or armored format.
+func readPGPKeys(b []byte) (openpgp.EntityList, error) {
+	el, err := openpgp.ReadKeyRing(bytes.NewReader(b))
+	if err != nil {
+		el, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid PGP public keys: %w", err)
+	}
+	return el, nil
+}
+
+// anchorPaths returns the paths of the trust anchors of kind k of the site
+// and user trust stores.
+func anchorPaths(k Kind) ([]string, error) {
+	var paths []string
+	for _, s := range Stores() {
+		anchors, err := s.List(k)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range anchors {
+			paths = append(paths, a.Path)
+		}
+	}
+	return paths, nil
+}
+
+// PGPKeyRing returns the PGP public keys of the site and user trust stores.
+func PGPKeyRing() (openpgp.EntityList, error) {
+	paths, err := anchorPaths(PGP)
+	if err != nil {
+		return nil, err
+	}
+	var el openpgp.EntityList
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := readPGPKeys(b)
+		if err != nil {
+			return nil, err
+		}
+		el = append(el, keys...)
+	}
+	return el, nil
+}
+
+// Verifiers returns the verifiers of the PEM public keys of the site and
+// user trust stores.
+func Verifiers() ([]signature.Verifier, error) {
+	paths, err := anchorPaths(Key)
+	if err != nil {
+		return nil, err
+	}
+	svs := make([]signature.Verifier, 0, len(paths))
+	for _, path := range paths {
+		sv, err := signature.LoadVerifierFromPEMFile(path, crypto.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("while loading trusted key %s: %w", path, err)
+		}
+		svs = append(svs, sv)
+	}
+	return svs, nil
+}
+
+// FulcioRoots returns the paths of the Fulcio certificates of the site and
+// user trust stores.
+func FulcioRoots() ([]string, error) {
+	return anchorPaths(Root)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package trust
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// writeAnchors writes a PGP public key, a PEM public key and a PEM
+// certificate in dir, returning their paths.
+func writeAnchors(t *testing.T, dir string) (string, string, string) {
+	e, err := openpgp.NewEntity("test", "", "test@example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgpPath := filepath.Join(dir, "test.asc")
+	f, err := os.Create(pgpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := armor.Encode(f, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	f.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "cosign.pub")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootPath := filepath.Join(dir, "fulcio.pem")
+	if err := os.WriteFile(rootPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return pgpPath, keyPath, rootPath
+}
+
+func TestStore(t *testing.T) {
+	pgpPath, keyPath, rootPath := writeAnchors(t, t.TempDir())
+	s := &Store{Name: "user", Dir: t.TempDir()}
+
+	if _, err := s.Add(PGP, "", pgpPath); err != nil {
+		t.Errorf("unexpected error adding PGP key: %s", err)
+	}
+	if _, err := s.Add(Key, "", keyPath); err != nil {
+		t.Errorf("unexpected error adding public key: %s", err)
+	}
+	if _, err := s.Add(Root, "sigstore", rootPath); err != nil {
+		t.Errorf("unexpected error adding root certificate: %s", err)
+	}
+
+	if _, err := s.Add(Key, "cosign", keyPath); err == nil {
+		t.Errorf("unexpected success adding an existing trust anchor")
+	}
+	if _, err := s.Add(Key, "bad", rootPath); err == nil {
+		t.Errorf("unexpected success adding a certificate as public key")
+	}
+	if _, err := s.Add(Root, "../bad", rootPath); err == nil {
+		t.Errorf("unexpected success adding a trust anchor with an invalid name")
+	}
+
+	anchors, err := s.List("")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []struct {
+		kind Kind
+		name string
+	}{{PGP, "test"}, {Key, "cosign"}, {Root, "sigstore"}}
+	if len(anchors) != len(expected) {
+		t.Fatalf("unexpected trust anchors %v", anchors)
+	}
+	for i, a := range anchors {
+		if a.Kind != expected[i].kind || a.Name != expected[i].name || a.ID == "" {
+			t.Errorf("unexpected trust anchor %v, expected %s %s", a, expected[i].kind, expected[i].name)
+		}
+	}
+	if anchors[2].Store != "user" {
+		t.Errorf("unexpected store %s", anchors[2].Store)
+	}
+
+	if err := s.Remove(Key, "cosign"); err != nil {
+		t.Errorf("unexpected error removing public key: %s", err)
+	}
+	if err := s.Remove(Key, "cosign"); !errors.Is(err, ErrAnchorNotFound) {
+		t.Errorf("unexpected error removing missing public key: %v", err)
+	}
+	if anchors, err := s.List(Key); err != nil || len(anchors) != 0 {
+		t.Errorf("unexpected public keys after removal: %v (%v)", anchors, err)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, k := range Kinds {
+		if kind, err := ParseKind(string(k)); err != nil || kind != k {
+			t.Errorf("unexpected result for kind %s: %s (%v)", k, kind, err)
+		}
+	}
+	if _, err := ParseKind("cert"); err == nil {
+		t.Errorf("unexpected success for unknown kind")
+	}
+}
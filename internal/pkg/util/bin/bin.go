@@ -55,6 +55,8 @@ func FindBin(name string) (path string, err error) {
 		"fakeroot-sysv",
 		"fuse-overlayfs",
 		"fuse2fs",
+		"fusermount",
+		"fusermount3",
 		"go",
 		"mksquashfs",
 		"newgidmap",
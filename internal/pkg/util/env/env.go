@@ -10,9 +10,13 @@
 package env
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/util/shell/interpreter"
@@ -93,15 +97,29 @@ func TrimApptainerKey(key string) string {
 	return strings.TrimPrefix(key, ApptainerPrefixes[0])
 }
 
+// GetenvInt returns the integer value of the environment variable key,
+// retrieved with GetenvLegacy, or defval when it's unset or invalid.
+func GetenvInt(key string, defval int64) int64 {
+	envKey := TrimApptainerKey(key)
+	if env := GetenvLegacy(envKey, envKey); env != "" {
+		if n, err := strconv.ParseInt(env, 10, 0); err == nil {
+			return n
+		}
+		sylog.Warningf("Error parsing %s; using default (%d)", key, defval)
+	}
+	return defval
+}
+
 // FileMap returns a map of KEY=VAL env vars from an environment file f. The env
 // file is shell evaluated using mvdan/sh with arguments and environment set
-// from args and hostEnv.
+// from args and hostEnv. A UTF-8 byte order mark and CRLF line endings are
+// accepted.
 func FileMap(ctx context.Context, f string, args []string, hostEnv []string) (map[string]string, error) {
 	envMap := map[string]string{}
 
-	content, err := os.ReadFile(f)
+	content, err := readEnvFile(f)
 	if err != nil {
-		return envMap, fmt.Errorf("could not read environment file %q: %w", f, err)
+		return envMap, err
 	}
 
 	// Use the embedded shell interpreter to evaluate the env file, with an empty starting environment.
@@ -129,6 +147,165 @@ func FileMap(ctx context.Context, f string, args []string, hostEnv []string) (ma
 	return envMap, nil
 }
 
+// LiteralFileMap returns a map of KEY=VAL env vars from an environment file f,
+// without shell evaluation, in the format of docker --env-file. Each line
+// holds KEY=VALUE, with VALUE taken as is, or KEY alone to pass the value
+// returned by lookup when it is found. Empty lines and lines starting with #
+// are ignored, and a UTF-8 byte order mark and CRLF line endings are accepted.
+//
+// ${KEY} references in values are expanded to the value of KEY set by a
+// previous line of f, or else returned by lookup, or else to an empty string.
+// $${ stands for a literal ${.
+func LiteralFileMap(f string, lookup func(string) (string, bool)) (map[string]string, error) {
+	envMap := map[string]string{}
+
+	content, err := readEnvFile(f)
+	if err != nil {
+		return envMap, err
+	}
+
+	get := func(key string) (string, bool) {
+		if v, ok := envMap[key]; ok {
+			return v, true
+		}
+		if lookup != nil {
+			return lookup(key)
+		}
+		return "", false
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, hasValue := strings.Cut(line, "=")
+		if !validEnvName(key) {
+			return envMap, fmt.Errorf("while processing %s: invalid environment variable name %q on line %d", f, key, i+1)
+		}
+		if !hasValue {
+			if v, ok := get(key); ok {
+				envMap[key] = v
+			}
+			continue
+		}
+		envMap[key] = expandEnvRefs(value, get)
+	}
+	return envMap, nil
+}
+
+// readEnvFile returns the content of the environment file f, with a
+// leading UTF-8 byte order mark removed and CRLF line endings converted,
+// as written by some Windows editors.
+func readEnvFile(f string) ([]byte, error) {
+	content, err := os.ReadFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read environment file %q: %w", f, err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), nil
+}
+
+// validEnvName returns whether name is a valid shell variable name.
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// expandEnvRefs replaces the ${KEY} references in s with the value returned
+// by get, or an empty string, and $${ with ${. Other $ are left unchanged.
+func expandEnvRefs(s string, get func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i])
+			b.WriteString("{")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.Index(s[i:], "}")
+		if end < 0 || !validEnvName(s[i+2:i+end]) {
+			b.WriteString(s[:i+2])
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+		v, _ := get(s[i+2 : i+end])
+		b.WriteString(v)
+		s = s[i+end+1:]
+	}
+}
+
+// JSONMap returns a map of KEY=VAL env vars from a JSON object read from r.
+// String values are used as is, numbers and booleans are converted to their
+// JSON representation.
+func JSONMap(r io.Reader) (map[string]string, error) {
+	var object map[string]interface{}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return nil, fmt.Errorf("environment must be a JSON object: %w", err)
+	}
+	if object == nil || dec.More() {
+		return nil, fmt.Errorf("environment must be a single JSON object")
+	}
+
+	envMap := make(map[string]string, len(object))
+	for k, value := range object {
+		if k == "" || strings.Contains(k, "=") {
+			return nil, fmt.Errorf("invalid environment variable name %q", k)
+		}
+		switch v := value.(type) {
+		case string:
+			envMap[k] = v
+		case json.Number:
+			envMap[k] = v.String()
+		case bool:
+			envMap[k] = fmt.Sprintf("%t", v)
+		default:
+			return nil, fmt.Errorf("value of environment variable %s must be a string, a number or a boolean", k)
+		}
+	}
+	return envMap, nil
+}
+
+// JSONFileMap returns a map of KEY=VAL env vars from a JSON file f, or from
+// standard input if f is "-".
+func JSONFileMap(f string) (map[string]string, error) {
+	if f == "-" {
+		envMap, err := JSONMap(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("while reading environment from standard input: %w", err)
+		}
+		return envMap, nil
+	}
+
+	r, err := os.Open(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read environment file %q: %w", f, err)
+	}
+	defer r.Close()
+
+	envMap, err := JSONMap(r)
+	if err != nil {
+		return nil, fmt.Errorf("while processing %s: %w", f, err)
+	}
+	return envMap, nil
+}
+
 // MergeMap merges two maps of environment variables, with values in b replacing
 // values also set in a.
 func MergeMap(a map[string]string, b map[string]string) map[string]string {
@@ -158,6 +158,28 @@ func TestGetenvLegacy(t *testing.T) {
 	}
 }
 
+func TestGetenvInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int64
+	}{
+		{name: "unset", expected: 4},
+		{name: "set", value: "16", expected: 16},
+		{name: "invalid", value: "sixteen", expected: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != "" {
+				t.Setenv("APPTAINER_TEST_INT", tt.value)
+			}
+			if got := GetenvInt("APPTAINER_TEST_INT", 4); got != tt.expected {
+				t.Errorf("got %d, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEnvFileMap(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -220,6 +242,15 @@ func TestEnvFileMap(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "BOMAndCRLF",
+			envFile: "\xef\xbb\xbfFOO=BAR\r\nABC=123\r\n",
+			want: map[string]string{
+				"FOO": "BAR",
+				"ABC": "123",
+			},
+			wantErr: false,
+		},
 		{
 			name:    "HostEnvSet",
 			envFile: "HELLO=$YOU",
@@ -251,3 +282,155 @@ func TestEnvFileMap(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "EmptyObject",
+			json: `{}`,
+			want: map[string]string{},
+		},
+		{
+			name: "Values",
+			json: `{"FOO": "BAR", "MULTI": "FOO\nBAR", "EMPTY": "", "INT": 12, "FLOAT": 1.5e3, "BOOL": true}`,
+			want: map[string]string{
+				"FOO":   "BAR",
+				"MULTI": "FOO\nBAR",
+				"EMPTY": "",
+				"INT":   "12",
+				"FLOAT": "1.5e3",
+				"BOOL":  "true",
+			},
+		},
+		{
+			name:    "Null",
+			json:    `null`,
+			wantErr: true,
+		},
+		{
+			name:    "Array",
+			json:    `["FOO=BAR"]`,
+			wantErr: true,
+		},
+		{
+			name:    "NullValue",
+			json:    `{"FOO": null}`,
+			wantErr: true,
+		},
+		{
+			name:    "ObjectValue",
+			json:    `{"FOO": {"BAR": "BAZ"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "EmptyName",
+			json:    `{"": "BAR"}`,
+			wantErr: true,
+		},
+		{
+			name:    "InvalidName",
+			json:    `{"FOO=BAR": "BAZ"}`,
+			wantErr: true,
+		},
+		{
+			name:    "TrailingData",
+			json:    `{"FOO": "BAR"} {}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONMap(strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JSONMap() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("JSONMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiteralFileMap(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		v, ok := map[string]string{"HOST": "host", "EMPTY": ""}[key]
+		return v, ok
+	}
+	tests := []struct {
+		name    string
+		envFile string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "EmptyFile",
+			envFile: "",
+			want:    map[string]string{},
+		},
+		{
+			name:    "Literal",
+			envFile: "# comment\n\nFOO=\"FOO BAR\" $HOST `id` 'x'\n  ABC=1=2",
+			want: map[string]string{
+				"FOO": "\"FOO BAR\" $HOST `id` 'x'",
+				"ABC": "1=2",
+			},
+		},
+		{
+			name:    "BOMAndCRLF",
+			envFile: "\xef\xbb\xbfFOO=BAR\r\nABC=123\r\n",
+			want: map[string]string{
+				"FOO": "BAR",
+				"ABC": "123",
+			},
+		},
+		{
+			name:    "Expansion",
+			envFile: "FOO=bar\nHOST=file\nA=${FOO}/${HOST}/${MISSING}/$${FOO}/${1}/${FOO",
+			want: map[string]string{
+				"FOO":  "bar",
+				"HOST": "file",
+				"A":    "bar/file//${FOO}/${1}/${FOO",
+			},
+		},
+		{
+			name:    "LookupKey",
+			envFile: "HOST\nEMPTY\nMISSING",
+			want: map[string]string{
+				"HOST":  "host",
+				"EMPTY": "",
+			},
+		},
+		{
+			name:    "InvalidName",
+			envFile: "export FOO=BAR",
+			want:    map[string]string{},
+			wantErr: true,
+		},
+	}
+
+	envFile := filepath.Join(t.TempDir(), "env-file")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(envFile, []byte(tt.envFile), 0o644); err != nil {
+				t.Fatalf("Could not write test env-file: %v", err)
+			}
+
+			got, err := LiteralFileMap(envFile, lookup)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LiteralFileMap() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LiteralFileMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
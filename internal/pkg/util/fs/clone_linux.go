@@ -0,0 +1,222 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package fs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// CloneMode is the way the regular files of a tree are cloned.
+type CloneMode int
+
+const (
+	// CloneCopy copies the content of the files.
+	CloneCopy CloneMode = iota
+	// CloneReflink shares the content of the files with copy-on-write
+	// reflinks, on filesystems like XFS or Btrfs.
+	CloneReflink
+	// CloneHardlink hardlinks the files, modifying a cloned file in place
+	// modifies the source file.
+	CloneHardlink
+)
+
+func (m CloneMode) String() string {
+	switch m {
+	case CloneReflink:
+		return "reflink"
+	case CloneHardlink:
+		return "hardlink"
+	}
+	return "copy"
+}
+
+// CloneOptions are the options of CloneTree.
+type CloneOptions struct {
+	// Hardlink allows to hardlink the files when reflinks are not supported.
+	Hardlink bool
+	// CopyPaths are the paths, relative to the source tree, of the files
+	// and directories whose files are copied instead of being hardlinked.
+	CopyPaths []string
+}
+
+// Reflinkable returns whether files can be reflinked from the directory src
+// to the directory dst.
+func Reflinkable(src, dst string) bool {
+	sf, err := os.CreateTemp(src, ".reflink-")
+	if err != nil {
+		return false
+	}
+	defer os.Remove(sf.Name())
+	defer sf.Close()
+	if _, err := sf.WriteString("reflink"); err != nil {
+		return false
+	}
+	df, err := os.CreateTemp(dst, ".reflink-")
+	if err != nil {
+		return false
+	}
+	defer os.Remove(df.Name())
+	defer df.Close()
+	return unix.IoctlFileClone(int(df.Fd()), int(sf.Fd())) == nil
+}
+
+// CloneTree recreates the tree src at dst, which must not exist or be an empty
+// directory, preserving the permissions, the modification times and, when
+// running as root, the ownership of its entries. The regular files are reflinked when supported,
+// or hardlinked when allowed by opts, and copied otherwise. It returns the
+// way the files were cloned.
+func CloneTree(src, dst string, opts CloneOptions) (CloneMode, error) {
+	mode := CloneCopy
+	if Reflinkable(filepath.Dir(src), existingDir(dst)) {
+		mode = CloneReflink
+	} else if opts.Hardlink {
+		mode = CloneHardlink
+	}
+
+	type dirEntry struct {
+		path string
+		info fs.FileInfo
+	}
+	// directories permissions and times are set once their content is
+	// cloned, as restrictive permissions could prevent it
+	var dirs []dirEntry
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch t := info.Mode().Type(); {
+		case t == fs.ModeDir:
+			err := os.Mkdir(target, 0o700)
+			if rel == "." && os.IsExist(err) && IsDir(target) {
+				err = nil
+			}
+			if err != nil {
+				return err
+			}
+			dirs = append(dirs, dirEntry{target, info})
+			return nil
+		case t == fs.ModeSymlink:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case t.IsRegular():
+			m := mode
+			if m == CloneHardlink && isCopyPath(rel, opts.CopyPaths) {
+				m = CloneCopy
+			}
+			if err := cloneFile(path, target, info, m); err != nil {
+				return fmt.Errorf("while cloning %s: %w", path, err)
+			}
+			if m == CloneHardlink {
+				return nil
+			}
+		default:
+			st := info.Sys().(*syscall.Stat_t)
+			if err := unix.Mknod(target, st.Mode, int(st.Rdev)); err != nil {
+				return fmt.Errorf("while creating %s: %w", target, err)
+			}
+		}
+		return setAttrs(target, info)
+	})
+	if err != nil {
+		return mode, err
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := setAttrs(dirs[i].path, dirs[i].info); err != nil {
+			return mode, err
+		}
+	}
+	return mode, nil
+}
+
+// existingDir returns dst when it is a directory, or its parent directory.
+func existingDir(dst string) string {
+	if IsDir(dst) {
+		return dst
+	}
+	return filepath.Dir(dst)
+}
+
+// isCopyPath returns whether the relative path rel is, or is under, one of
+// the paths copyPaths.
+func isCopyPath(rel string, copyPaths []string) bool {
+	return slices.ContainsFunc(copyPaths, func(p string) bool {
+		r, err := filepath.Rel(filepath.Clean(p), rel)
+		return err == nil && filepath.IsLocal(r)
+	})
+}
+
+// cloneFile clones the regular file src to dst with mode m.
+func cloneFile(src, dst string, info fs.FileInfo, m CloneMode) error {
+	if m == CloneHardlink {
+		return os.Link(src, dst)
+	}
+
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+	df, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm()|0o200)
+	if err != nil {
+		return err
+	}
+	if m == CloneReflink {
+		err = unix.IoctlFileClone(int(df.Fd()), int(sf.Fd()))
+	} else {
+		_, err = io.Copy(df, sf)
+	}
+	if err != nil {
+		df.Close()
+		return err
+	}
+	return df.Close()
+}
+
+// setAttrs sets the ownership, permissions and modification time of info
+// to path.
+func setAttrs(path string, info fs.FileInfo) error {
+	st := info.Sys().(*syscall.Stat_t)
+	if os.Geteuid() == 0 {
+		if err := os.Lchown(path, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+	if info.Mode().Type() != fs.ModeSymlink {
+		if err := os.Chmod(path, info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			return err
+		}
+	}
+	ts := []unix.Timespec{
+		{Sec: st.Atim.Sec, Nsec: st.Atim.Nsec},
+		{Sec: st.Mtim.Sec, Nsec: st.Mtim.Nsec},
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, path, ts, unix.AT_SYMLINK_NOFOLLOW)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloneTree(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	for _, d := range []string{"bin", "etc", ".singularity.d/env"} {
+		if err := os.MkdirAll(filepath.Join(src, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]os.FileMode{
+		"bin/app":                      0o755,
+		"etc/hosts":                    0o644,
+		"etc/passwd":                   0o644,
+		".singularity.d/env/90-env.sh": 0o755,
+		".singularity.d/runscript":     0o755,
+		"bin/readonly":                 0o400,
+	}
+	for f, mode := range files {
+		if err := os.WriteFile(filepath.Join(src, f), []byte(f), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("app", filepath.Join(src, "bin", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(src, "etc"), 0o555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(src, "etc"), 0o755)
+
+	tests := []struct {
+		name      string
+		opts      CloneOptions
+		existing  bool
+		hardlinks []string
+	}{
+		{
+			name: "Copy",
+		},
+		{
+			name:     "ExistingDestination",
+			existing: true,
+		},
+		{
+			name: "Hardlink",
+			opts: CloneOptions{
+				Hardlink:  true,
+				CopyPaths: []string{".singularity.d", "etc/hosts"},
+			},
+			hardlinks: []string{"bin/app", "etc/passwd", "bin/readonly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "dst")
+			if tt.existing {
+				if err := os.Mkdir(dst, 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mode, err := CloneTree(src, dst, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Chmod(filepath.Join(dst, "etc"), 0o755)
+			if mode == CloneReflink {
+				// reflinks share no inode, like copies
+				tt.hardlinks = nil
+			}
+
+			for f, want := range files {
+				fi, err := os.Stat(filepath.Join(dst, f))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if fi.Mode().Perm() != want {
+					t.Errorf("%s: got mode %o, want %o", f, fi.Mode().Perm(), want)
+				}
+				sfi, err := os.Stat(filepath.Join(src, f))
+				if err != nil {
+					t.Fatal(err)
+				}
+				linked := os.SameFile(fi, sfi)
+				wantLinked := false
+				for _, h := range tt.hardlinks {
+					wantLinked = wantLinked || h == f
+				}
+				if linked != wantLinked {
+					t.Errorf("%s: hardlinked %v, want %v", f, linked, wantLinked)
+				}
+				if !fi.ModTime().Equal(sfi.ModTime()) {
+					t.Errorf("%s: modification time not preserved", f)
+				}
+			}
+			if link, err := os.Readlink(filepath.Join(dst, "bin", "link")); err != nil || link != "app" {
+				t.Errorf("got symlink %q (%v), want %q", link, err, "app")
+			}
+			fi, err := os.Stat(filepath.Join(dst, "etc"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != 0o555 {
+				t.Errorf("etc: got mode %o, want %o", fi.Mode().Perm(), 0o555)
+			}
+		})
+	}
+}
+
+func TestCloneTreeExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "dst")
+	if err := os.WriteFile(dst, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CloneTree(t.TempDir(), dst, CloneOptions{}); err == nil {
+		t.Errorf("unexpected success cloning to an existing file")
+	}
+}
@@ -9,6 +9,11 @@
 
 package files
 
+import (
+	"fmt"
+	"net"
+)
+
 var defaultContent = `127.0.0.1   localhost
 ::1         localhost ip6-localhost ip6-loopback
 ff02::1     ip6-allnodes
@@ -19,3 +24,13 @@ ff02::2     ip6-allrouters
 func DefaultHosts() []byte {
 	return []byte(defaultContent)
 }
+
+// Hosts creates the default hosts file with entries resolving the hostname
+// to the IPv4 and IPv6 addresses of the container.
+func Hosts(hostname string, ips []net.IP) []byte {
+	content := DefaultHosts()
+	for _, ip := range ips {
+		content = append(content, fmt.Sprintf("%-11s %s\n", ip, hostname)...)
+	}
+	return content
+}
@@ -11,6 +11,7 @@ package files
 
 import (
 	"bytes"
+	"net"
 	"os"
 	"testing"
 
@@ -87,4 +88,19 @@ func TestResolvConf(t *testing.T) {
 	if !bytes.Equal(content, []byte("nameserver 8.8.8.8\n")) {
 		t.Errorf("ResolvConf returns a bad content")
 	}
+	content, err = ResolvConf([]string{"2001:4860:4860::8888", "fe80::1%eth0"}, "example.org")
+	if err != nil {
+		t.Errorf("should have passed with valid IPv6 dns: %s", err)
+	}
+	if !bytes.Equal(content, []byte("nameserver 2001:4860:4860::8888\nnameserver fe80::1%eth0\nsearch example.org\n")) {
+		t.Errorf("ResolvConf returns a bad content: %s", content)
+	}
+}
+
+func TestHosts(t *testing.T) {
+	content := Hosts("mycontainer", []net.IP{net.ParseIP("10.22.0.2"), net.ParseIP("fd00:22::2")})
+	expected := defaultContent + "10.22.0.2   mycontainer\nfd00:22::2  mycontainer\n"
+	if string(content) != expected {
+		t.Errorf("Hosts returns a bad content: %s", content)
+	}
 }
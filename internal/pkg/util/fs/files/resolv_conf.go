@@ -12,22 +12,30 @@ package files
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
-// ResolvConf creates a resolv.conf content with provided dns list and returns it
-func ResolvConf(dns []string) (content []byte, err error) {
+// ResolvConf creates a resolv.conf content with provided dns list and optional
+// search domains and returns it. IPv6 link-local servers may include a zone,
+// e.g. fe80::1%eth0.
+func ResolvConf(dns []string, search ...string) (content []byte, err error) {
 	sylog.Verbosef("Creating resolv.conf content\n")
 	if len(dns) == 0 {
 		return content, fmt.Errorf("no dns ip provided")
 	}
 	for _, ip := range dns {
-		if net.ParseIP(ip) == nil {
+		addr, _, _ := strings.Cut(ip, "%")
+		if net.ParseIP(addr) == nil {
 			return content, fmt.Errorf("dns ip %s is not a valid IP address", ip)
 		}
 		line := fmt.Sprintf("nameserver %s\n", ip)
 		content = append(content, line...)
 	}
+	if len(search) > 0 {
+		line := fmt.Sprintf("search %s\n", strings.Join(search, " "))
+		content = append(content, line...)
+	}
 	return content, nil
 }
@@ -10,6 +10,11 @@
 package squashfs
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/apptainer/apptainer/internal/pkg/buildcfg"
 	"github.com/apptainer/apptainer/internal/pkg/syecl"
 	"github.com/apptainer/apptainer/internal/pkg/util/bin"
@@ -56,6 +61,40 @@ func GetMem() (string, error) {
 	return mem, err
 }
 
+// Compressors are the squashfs compressors supported by the kernel squashfs
+// driver and squashfuse.
+var Compressors = []string{"gzip", "lz4", "lzo", "xz", "zstd"}
+
+// CheckComp returns an error if comp isn't one of Compressors.
+func CheckComp(comp string) error {
+	if !slices.Contains(Compressors, comp) {
+		return fmt.Errorf("unsupported squashfs compressor %q, must be one of %s", comp, strings.Join(Compressors, ", "))
+	}
+	return nil
+}
+
+// ParseBlockSize returns the size in bytes of the squashfs block size s, a
+// number of bytes optionally suffixed with K or M. The block size must be a
+// power of two between 4K and 1M.
+func ParseBlockSize(s string) (uint, error) {
+	n, mult := s, uint64(1)
+	switch {
+	case strings.HasSuffix(strings.ToUpper(s), "K"):
+		n, mult = s[:len(s)-1], 1<<10
+	case strings.HasSuffix(strings.ToUpper(s), "M"):
+		n, mult = s[:len(s)-1], 1<<20
+	}
+	v, err := strconv.ParseUint(n, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid squashfs block size %q", s)
+	}
+	v *= mult
+	if v < 4<<10 || v > 1<<20 || v&(v-1) != 0 {
+		return 0, fmt.Errorf("invalid squashfs block size %q, must be a power of two between 4K and 1M", s)
+	}
+	return uint(v), nil
+}
+
 var (
 	setuidMountKnown   bool
 	setuidMountAllowed bool
@@ -0,0 +1,48 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package squashfs
+
+import "testing"
+
+func TestParseBlockSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint
+		wantErr bool
+	}{
+		{in: "131072", want: 131072},
+		{in: "4K", want: 4096},
+		{in: "256k", want: 262144},
+		{in: "1M", want: 1048576},
+		{in: "2K", wantErr: true},
+		{in: "2M", wantErr: true},
+		{in: "100000", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "M", wantErr: true},
+		{in: "-4K", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBlockSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBlockSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBlockSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckComp(t *testing.T) {
+	for _, c := range Compressors {
+		if err := CheckComp(c); err != nil {
+			t.Errorf("CheckComp(%q) unexpected error: %s", c, err)
+		}
+	}
+	if err := CheckComp("lzma"); err == nil {
+		t.Errorf("CheckComp(\"lzma\") unexpected success")
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package teardown unmounts container mount points and removes their
+// directories, queuing the teardowns which can't be completed, because of
+// busy mounts or of a crashed process, so that they are processed by a later
+// invocation.
+package teardown
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/syfs"
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// queueDir is the directory of the teardown queue in the user
+	// configuration directory.
+	queueDir = "teardown"
+	// busyRetries is the number of unmount attempts of a busy mount
+	// point before it's lazily unmounted.
+	busyRetries = 10
+	// busyDelay is the delay between two unmount attempts.
+	busyDelay = 100 * time.Millisecond
+	// maxAttempts is the number of failed attempts after which a queued
+	// teardown is given up.
+	maxAttempts = 5
+)
+
+// Entry is the teardown of a directory and of the mount points located
+// under it.
+type Entry struct {
+	// Path is the directory removed once its mount points are unmounted.
+	Path string `json:"path"`
+	// Mounts are the mount points unmounted, in order.
+	Mounts []string `json:"mounts,omitempty"`
+	// PID is the process which completes the teardown when it exits, the
+	// queued teardown being processed once this process is gone.
+	PID int `json:"pid,omitempty"`
+	// StartTime is the start time of PID, distinguishing it from a later
+	// process reusing its ID.
+	StartTime time.Duration `json:"startTime,omitempty"`
+	// Attempts is the number of failed teardown attempts.
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// QueueDir returns the directory of the teardown queue of the current user.
+func QueueDir() string {
+	return filepath.Join(syfs.ConfigDir(), queueDir)
+}
+
+// Register queues the teardown of path and mounts on behalf of the
+// process pid, which completes it with Teardown. The teardown is processed
+// by a later invocation if pid exits without completing it, when it
+// crashes or is killed.
+func Register(pid int, path string, mounts ...string) error {
+	p, err := proc.GetProcess(pid)
+	if err != nil {
+		return err
+	}
+	e := &Entry{Path: path, Mounts: mounts, PID: pid, StartTime: p.StartTime}
+	return e.save()
+}
+
+// Teardown unmounts mounts, in order, and removes the directory path
+// once no mount point is left under it. Busy mount points are retried,
+// then lazily unmounted. When the teardown fails, it's queued to be
+// processed by a later invocation and an error is returned.
+func Teardown(path string, mounts ...string) error {
+	e := &Entry{Path: path, Mounts: mounts}
+	if err := e.run(); err != nil {
+		e.Attempts++
+		if qerr := e.save(); qerr != nil {
+			return fmt.Errorf("%w, and could not be deferred: %s", err, qerr)
+		}
+		return fmt.Errorf("%w, deferred to a later invocation", err)
+	}
+	return e.remove()
+}
+
+// Forget removes the queued teardown of path, if any, when it's completed
+// by other means or can't be completed by a later invocation.
+func Forget(path string) error {
+	e := &Entry{Path: path}
+	return e.remove()
+}
+
+// Process processes the queued teardowns whose process exited, and returns
+// the numbers of completed and pending teardowns. A teardown failing
+// maxAttempts times is given up with a warning.
+func Process() (int, int, error) {
+	files, err := os.ReadDir(QueueDir())
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	done, pending := 0, 0
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		e, err := load(filepath.Join(QueueDir(), f.Name()))
+		if err != nil {
+			sylog.Warningf("Ignoring teardown %s: %s", f.Name(), err)
+			continue
+		}
+		if e.running() {
+			pending++
+			continue
+		}
+		if err := e.run(); err != nil {
+			sylog.Debugf("Teardown of %s failed: %s", e.Path, err)
+			e.PID = 0
+			e.Attempts++
+			if e.Attempts >= maxAttempts {
+				sylog.Warningf("Giving up teardown of %s after %d attempts, remove it manually: %s", e.Path, e.Attempts, err)
+				if err := e.remove(); err != nil {
+					sylog.Warningf("Could not remove teardown of %s: %s", e.Path, err)
+				}
+				continue
+			}
+			if err := e.save(); err != nil {
+				sylog.Warningf("Could not update teardown of %s: %s", e.Path, err)
+			}
+			pending++
+			continue
+		}
+		sylog.Debugf("Teardown of %s completed", e.Path)
+		if err := e.remove(); err != nil {
+			sylog.Warningf("Could not remove teardown of %s: %s", e.Path, err)
+		}
+		done++
+	}
+	return done, pending, nil
+}
+
+// run unmounts the mount points of the teardown and removes its directory.
+func (e *Entry) run() error {
+	for _, m := range e.Mounts {
+		if err := unmount(m); err != nil {
+			return fmt.Errorf("while unmounting %s: %w", m, err)
+		}
+	}
+	if _, err := os.Lstat(e.Path); os.IsNotExist(err) {
+		return nil
+	}
+
+	// a mount point left under the directory would have its content
+	// removed along with it
+	points, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	path := resolve(e.Path)
+	for _, p := range points {
+		if p == path || strings.HasPrefix(p, path+"/") {
+			return fmt.Errorf("%s is still mounted", p)
+		}
+	}
+	if err := os.RemoveAll(e.Path); err != nil {
+		return fmt.Errorf("while removing %s: %w", e.Path, err)
+	}
+	return nil
+}
+
+// running returns whether the process completing the teardown is running.
+func (e *Entry) running() bool {
+	if e.PID == 0 {
+		return false
+	}
+	p, err := proc.GetProcess(e.PID)
+	return err == nil && p.StartTime == e.StartTime
+}
+
+// file returns the path of the queue file of the teardown, derived from
+// its directory so that it's unique.
+func (e *Entry) file() string {
+	sum := sha256.Sum256([]byte(e.Path))
+	return filepath.Join(QueueDir(), hex.EncodeToString(sum[:8])+".json")
+}
+
+func (e *Entry) save() error {
+	if err := os.MkdirAll(QueueDir(), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp := e.file() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.file())
+}
+
+func (e *Entry) remove() error {
+	if err := os.Remove(e.file()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func load(path string) (*Entry, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := new(Entry)
+	if err := json.Unmarshal(b, e); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(e.Path) {
+		return nil, fmt.Errorf("invalid teardown path %q", e.Path)
+	}
+	return e, nil
+}
+
+// unmount unmounts the mount point path, and the mount points stacked
+// under it, retrying when it's busy before lazily unmounting it. A path
+// which isn't a mount point, or doesn't exist, is ignored.
+func unmount(path string) error {
+	path = resolve(path)
+	retries := 0
+	for {
+		points, err := mountPoints()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(points, path) {
+			return nil
+		}
+
+		err = unix.Unmount(path, 0)
+		if errors.Is(err, unix.EBUSY) {
+			if retries < busyRetries {
+				retries++
+				time.Sleep(busyDelay)
+				continue
+			}
+			sylog.Debugf("Busy, doing lazy umount %s", path)
+			err = unix.Unmount(path, unix.MNT_DETACH)
+		}
+		if err != nil {
+			return err
+		}
+		retries = 0
+	}
+}
+
+// mountPoints returns the mount points of the current mount namespace.
+func mountPoints() ([]string, error) {
+	entries, err := proc.GetMountInfoEntry("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	points := make([]string, len(entries))
+	for i, e := range entries {
+		points[i] = e.Point
+	}
+	return points, nil
+}
+
+// resolve returns path with its symbolic links resolved, as the mount points
+// of /proc/self/mountinfo.
+func resolve(path string) string {
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		return p
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package teardown
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/test"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "teardown-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("APPTAINER_CONFIGDIR", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func makeDir(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "rootfs")
+	if err := os.MkdirAll(filepath.Join(dir, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "etc", "hosts"), []byte("hosts"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func queued(t *testing.T, path string) bool {
+	e := &Entry{Path: path}
+	_, err := os.Stat(e.file())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestTeardown(t *testing.T) {
+	dir := makeDir(t)
+
+	if err := Teardown(dir, filepath.Join(dir, "etc")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed", dir)
+	}
+	if queued(t, dir) {
+		t.Errorf("completed teardown of %s queued", dir)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	dir := makeDir(t)
+
+	// the teardown is pending while the registering process runs
+	if err := Register(os.Getpid(), dir); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	done, pending, err := Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if done != 0 || pending != 1 {
+		t.Errorf("got %d done and %d pending, expected 0 and 1", done, pending)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("%s removed while its process runs", dir)
+	}
+
+	// a different start time stands for a process which exited and
+	// had its ID reused
+	e := &Entry{Path: dir, PID: os.Getpid(), StartTime: -1}
+	if err := e.save(); err != nil {
+		t.Fatal(err)
+	}
+	done, pending, err = Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if done != 1 || pending != 0 {
+		t.Errorf("got %d done and %d pending, expected 1 and 0", done, pending)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed", dir)
+	}
+	if queued(t, dir) {
+		t.Errorf("completed teardown of %s still queued", dir)
+	}
+}
+
+func TestProcessAttempts(t *testing.T) {
+	// a mount point is never removed, its teardown always fails
+	e := &Entry{Path: "/proc", Attempts: maxAttempts - 2}
+	if err := e.save(); err != nil {
+		t.Fatal(err)
+	}
+	defer e.remove()
+
+	done, pending, err := Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if done != 0 || pending != 1 {
+		t.Errorf("got %d done and %d pending, expected 0 and 1", done, pending)
+	}
+
+	// the last attempt gives the teardown up
+	done, pending, err = Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if done != 0 || pending != 0 {
+		t.Errorf("got %d done and %d pending, expected 0 and 0", done, pending)
+	}
+	if queued(t, e.Path) {
+		t.Errorf("teardown of %s still queued after %d attempts", e.Path, maxAttempts)
+	}
+}
+
+func TestTeardownMounted(t *testing.T) {
+	test.EnsurePrivilege(t)
+
+	dir := makeDir(t)
+	mnt := filepath.Join(dir, "etc")
+	if err := syscall.Mount("tmpfs", mnt, "tmpfs", 0, ""); err != nil {
+		t.Fatalf("while mounting tmpfs: %s", err)
+	}
+	defer syscall.Unmount(mnt, syscall.MNT_DETACH)
+
+	// a mount point not listed is left in place, deferring the teardown
+	if err := Teardown(dir); err == nil {
+		t.Fatalf("unexpected success removing %s with a mount point", dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "etc")); err != nil {
+		t.Fatalf("%s removed with a mount point", dir)
+	}
+	if !queued(t, dir) {
+		t.Fatalf("failed teardown of %s not queued", dir)
+	}
+
+	if err := syscall.Unmount(mnt, 0); err != nil {
+		t.Fatal(err)
+	}
+	done, pending, err := Process()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if done != 1 || pending != 0 {
+		t.Errorf("got %d done and %d pending, expected 1 and 0", done, pending)
+	}
+
+	// listed mount points are unmounted, stacked ones included
+	dir = makeDir(t)
+	mnt = filepath.Join(dir, "etc")
+	for i := 0; i < 2; i++ {
+		if err := syscall.Mount("tmpfs", mnt, "tmpfs", 0, ""); err != nil {
+			t.Fatalf("while mounting tmpfs: %s", err)
+		}
+	}
+	if err := Teardown(dir, mnt); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed", dir)
+	}
+}
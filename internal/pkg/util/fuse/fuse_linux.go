@@ -0,0 +1,454 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package fuse implements a minimal FUSE server exposing a single read-only
+// file, speaking the kernel FUSE protocol directly over /dev/fuse.
+package fuse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"golang.org/x/sys/unix"
+)
+
+// File is the content of the file served by a filesystem.
+type File interface {
+	io.ReaderAt
+	// Size returns the size of the file.
+	Size() int64
+}
+
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opOpen        = 14
+	opRead        = 15
+	opStatfs      = 17
+	opRelease     = 18
+	opGetxattr    = 22
+	opListxattr   = 23
+	opFlush       = 25
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opAccess      = 34
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+)
+
+const (
+	rootID = 1
+	fileID = 2
+
+	// maxRead is the maximum size of the read requests.
+	maxRead = 128 << 10
+	// bufSize is the size of the request buffer, which must hold a request
+	// header and a write request payload.
+	bufSize = maxRead + 4096
+
+	// attrValid is how long the kernel caches the attributes and entries.
+	attrValid = time.Hour
+
+	// maxBackground is the maximum number of concurrent background
+	// requests, like readahead requests.
+	maxBackground = 16
+
+	// fopenKeepCache keeps the page cache of the file across opens.
+	fopenKeepCache = 1 << 1
+)
+
+type inHeader struct {
+	Len         uint32
+	Opcode      uint32
+	Unique      uint64
+	NodeID      uint64
+	UID         uint32
+	GID         uint32
+	PID         uint32
+	TotalExtlen uint16
+	Padding     uint16
+}
+
+type outHeader struct {
+	Len    uint32
+	Error  int32
+	Unique uint64
+}
+
+type initIn struct {
+	Major        uint32
+	Minor        uint32
+	MaxReadahead uint32
+	Flags        uint32
+}
+
+type initOut struct {
+	Major               uint32
+	Minor               uint32
+	MaxReadahead        uint32
+	Flags               uint32
+	MaxBackground       uint16
+	CongestionThreshold uint16
+	MaxWrite            uint32
+	TimeGran            uint32
+	MaxPages            uint16
+	MapAlignment        uint16
+	Flags2              uint32
+	MaxStackDepth       uint32
+	Unused              [6]uint32
+}
+
+type attr struct {
+	Ino       uint64
+	Size      uint64
+	Blocks    uint64
+	Atime     uint64
+	Mtime     uint64
+	Ctime     uint64
+	Atimensec uint32
+	Mtimensec uint32
+	Ctimensec uint32
+	Mode      uint32
+	Nlink     uint32
+	UID       uint32
+	GID       uint32
+	Rdev      uint32
+	Blksize   uint32
+	Flags     uint32
+}
+
+type entryOut struct {
+	NodeID         uint64
+	Generation     uint64
+	EntryValid     uint64
+	AttrValid      uint64
+	EntryValidNsec uint32
+	AttrValidNsec  uint32
+	Attr           attr
+}
+
+type attrOut struct {
+	AttrValid     uint64
+	AttrValidNsec uint32
+	Dummy         uint32
+	Attr          attr
+}
+
+type openOut struct {
+	Fh        uint64
+	OpenFlags uint32
+	Padding   uint32
+}
+
+type readIn struct {
+	Fh        uint64
+	Offset    uint64
+	Size      uint32
+	ReadFlags uint32
+	LockOwner uint64
+	Flags     uint32
+	Padding   uint32
+}
+
+type kstatfs struct {
+	Blocks  uint64
+	Bfree   uint64
+	Bavail  uint64
+	Files   uint64
+	Ffree   uint64
+	Bsize   uint32
+	Namelen uint32
+	Frsize  uint32
+	Padding uint32
+	Spare   [6]uint32
+}
+
+type dirent struct {
+	Ino     uint64
+	Off     uint64
+	Namelen uint32
+	Type    uint32
+}
+
+// server serves the file named name on the FUSE device dev.
+type server struct {
+	dev   *os.File
+	name  string
+	file  File
+	mtime time.Time
+	uid   uint32
+	gid   uint32
+	wg    sync.WaitGroup
+}
+
+// Serve serves a read-only filesystem holding the single file name, whose
+// content is read from file, on the FUSE device dev until the filesystem is
+// unmounted. Read requests are served concurrently. The files of the
+// filesystem must not be opened with the os package by the serving process,
+// as the registration of the file in the Go poller blocks the runtime until
+// the poll request it triggers is served.
+func Serve(dev *os.File, name string, file File) error {
+	s := &server{
+		dev:   dev,
+		name:  name,
+		file:  file,
+		mtime: time.Now(),
+		uid:   uint32(os.Getuid()),
+		gid:   uint32(os.Getgid()),
+	}
+	defer s.wg.Wait()
+
+	for {
+		buf := make([]byte, bufSize)
+		n, err := syscall.Read(int(dev.Fd()), buf)
+		if errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.ENOENT) {
+			continue
+		} else if errors.Is(err, syscall.ENODEV) {
+			// filesystem unmounted
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("while reading FUSE request: %w", err)
+		}
+
+		var h inHeader
+		hSize := binary.Size(h)
+		if n < hSize {
+			return fmt.Errorf("short FUSE request of %d bytes", n)
+		}
+		if err := binary.Read(bytes.NewReader(buf[:hSize]), binary.NativeEndian, &h); err != nil {
+			return err
+		}
+		payload := buf[hSize:n]
+
+		if h.Opcode == opRead {
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.read(h, payload)
+			}()
+			continue
+		}
+		if done := s.handle(h, payload); done {
+			return nil
+		}
+	}
+}
+
+// handle handles the request h, returning true once the filesystem is
+// destroyed.
+func (s *server) handle(h inHeader, payload []byte) bool {
+	switch h.Opcode {
+	case opInit:
+		var in initIn
+		if err := decode(payload, &in); err != nil {
+			s.reply(h, syscall.EIO, nil)
+			return false
+		}
+		if in.Major != 7 {
+			sylog.Debugf("Unsupported FUSE protocol version %d.%d", in.Major, in.Minor)
+			s.reply(h, syscall.EPROTO, nil)
+			return false
+		}
+		out := initOut{
+			Major:        7,
+			Minor:        31,
+			MaxReadahead: in.MaxReadahead,
+			// readahead requests are background requests, never sent
+			// when the background limit is 0
+			MaxBackground:       maxBackground,
+			CongestionThreshold: maxBackground * 3 / 4,
+			MaxWrite:            maxRead,
+			TimeGran:            1,
+			MaxPages:            maxRead / 4096,
+		}
+		s.reply(h, 0, out)
+	case opLookup:
+		name := string(bytes.TrimRight(payload, "\x00"))
+		if h.NodeID != rootID || name != s.name {
+			s.reply(h, syscall.ENOENT, nil)
+			return false
+		}
+		s.reply(h, 0, entryOut{
+			NodeID:     fileID,
+			EntryValid: uint64(attrValid.Seconds()),
+			AttrValid:  uint64(attrValid.Seconds()),
+			Attr:       s.attr(fileID),
+		})
+	case opGetattr:
+		if h.NodeID != rootID && h.NodeID != fileID {
+			s.reply(h, syscall.ENOENT, nil)
+			return false
+		}
+		s.reply(h, 0, attrOut{
+			AttrValid: uint64(attrValid.Seconds()),
+			Attr:      s.attr(h.NodeID),
+		})
+	case opOpen:
+		if h.NodeID != fileID {
+			s.reply(h, syscall.EISDIR, nil)
+			return false
+		}
+		s.reply(h, 0, openOut{OpenFlags: fopenKeepCache})
+	case opOpendir:
+		if h.NodeID != rootID {
+			s.reply(h, syscall.ENOTDIR, nil)
+			return false
+		}
+		s.reply(h, 0, openOut{})
+	case opReaddir:
+		var in readIn
+		if err := decode(payload, &in); err != nil {
+			s.reply(h, syscall.EIO, nil)
+			return false
+		}
+		s.replyData(h, s.readdir(in.Offset, int(in.Size)))
+	case opStatfs:
+		size := uint64(s.file.Size())
+		s.reply(h, 0, kstatfs{
+			Blocks:  (size + 511) / 512,
+			Files:   2,
+			Bsize:   512,
+			Namelen: 255,
+			Frsize:  512,
+		})
+	case opRelease, opReleasedir, opFlush:
+		s.reply(h, 0, nil)
+	case opAccess:
+		s.reply(h, 0, nil)
+	case opForget, opBatchForget, opInterrupt:
+		// no reply expected
+	case opGetxattr, opListxattr:
+		s.reply(h, syscall.ENOSYS, nil)
+	case opDestroy:
+		s.reply(h, 0, nil)
+		return true
+	default:
+		s.reply(h, syscall.ENOSYS, nil)
+	}
+	return false
+}
+
+// read replies to the read request h with the content of the file.
+func (s *server) read(h inHeader, payload []byte) {
+	var in readIn
+	if err := decode(payload, &in); err != nil {
+		s.reply(h, syscall.EIO, nil)
+		return
+	}
+	size := int64(in.Size)
+	if rem := s.file.Size() - int64(in.Offset); rem < size {
+		size = max(rem, 0)
+	}
+	data := make([]byte, size)
+	n, err := s.file.ReadAt(data, int64(in.Offset))
+	if err != nil && !errors.Is(err, io.EOF) {
+		sylog.Debugf("While reading %d bytes at offset %d: %s", size, in.Offset, err)
+		s.reply(h, syscall.EIO, nil)
+		return
+	}
+	s.replyData(h, data[:n])
+}
+
+// readdir returns the directory entries of the root directory starting at
+// offset off, up to size bytes.
+func (s *server) readdir(off uint64, size int) []byte {
+	entries := []struct {
+		ino  uint64
+		name string
+		typ  uint32
+	}{
+		{rootID, ".", unix.DT_DIR},
+		{rootID, "..", unix.DT_DIR},
+		{fileID, s.name, unix.DT_REG},
+	}
+	var buf bytes.Buffer
+	for i := off; i < uint64(len(entries)); i++ {
+		e := entries[i]
+		d := dirent{Ino: e.ino, Off: i + 1, Namelen: uint32(len(e.name)), Type: e.typ}
+		entLen := binary.Size(d) + len(e.name)
+		padded := (entLen + 7) &^ 7
+		if buf.Len()+padded > size {
+			break
+		}
+		binary.Write(&buf, binary.NativeEndian, d)
+		buf.WriteString(e.name)
+		buf.Write(make([]byte, padded-entLen))
+	}
+	return buf.Bytes()
+}
+
+// attr returns the attributes of the node id.
+func (s *server) attr(id uint64) attr {
+	a := attr{
+		Ino:       id,
+		Atime:     uint64(s.mtime.Unix()),
+		Mtime:     uint64(s.mtime.Unix()),
+		Ctime:     uint64(s.mtime.Unix()),
+		Atimensec: uint32(s.mtime.Nanosecond()),
+		Mtimensec: uint32(s.mtime.Nanosecond()),
+		Ctimensec: uint32(s.mtime.Nanosecond()),
+		UID:       s.uid,
+		GID:       s.gid,
+		Blksize:   maxRead,
+	}
+	if id == rootID {
+		a.Mode = unix.S_IFDIR | 0o555
+		a.Nlink = 2
+	} else {
+		a.Size = uint64(s.file.Size())
+		a.Blocks = (a.Size + 511) / 512
+		a.Mode = unix.S_IFREG | 0o444
+		a.Nlink = 1
+	}
+	return a
+}
+
+// reply writes the reply to the request h with the error errno, or the
+// structure out when errno is 0.
+func (s *server) reply(h inHeader, errno syscall.Errno, out any) {
+	var buf bytes.Buffer
+	if errno == 0 && out != nil {
+		binary.Write(&buf, binary.NativeEndian, out)
+	}
+	s.write(h, errno, buf.Bytes())
+}
+
+// replyData writes the reply to the request h with the raw data.
+func (s *server) replyData(h inHeader, data []byte) {
+	s.write(h, 0, data)
+}
+
+func (s *server) write(h inHeader, errno syscall.Errno, data []byte) {
+	oh := outHeader{Error: -int32(errno), Unique: h.Unique}
+	oh.Len = uint32(binary.Size(oh) + len(data))
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.NativeEndian, oh)
+	buf.Write(data)
+	// a request may have been interrupted, the kernel then rejects its reply
+	if _, err := syscall.Write(int(s.dev.Fd()), buf.Bytes()); err != nil && !errors.Is(err, syscall.ENOENT) {
+		sylog.Debugf("While replying to FUSE request %d: %s", h.Opcode, err)
+	}
+}
+
+// decode decodes the request payload b into v, ignoring the fields of newer
+// protocol versions.
+func decode(b []byte, v any) error {
+	if len(b) < binary.Size(v) {
+		return fmt.Errorf("short FUSE request payload")
+	}
+	return binary.Read(bytes.NewReader(b), binary.NativeEndian, v)
+}
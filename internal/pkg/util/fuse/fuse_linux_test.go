@@ -0,0 +1,97 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package fuse
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/test"
+	"golang.org/x/sys/unix"
+)
+
+func TestServe(t *testing.T) {
+	test.EnsurePrivilege(t)
+
+	content := make([]byte, 3*maxRead+123)
+	if _, err := rand.Read(content); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	dev, err := Mount(dir, "fuse-test")
+	if err != nil {
+		t.Skipf("FUSE mount not available: %s", err)
+	}
+	defer dev.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Serve(dev, "image.sif", bytes.NewReader(content))
+	}()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Errorf("unexpected error while reading mount point: %s", err)
+	} else if len(entries) != 1 || entries[0].Name() != "image.sif" {
+		t.Errorf("got entries %v, want image.sif", entries)
+	}
+
+	path := filepath.Join(dir, "image.sif")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if fi.Size() != int64(len(content)) || fi.Mode().Perm() != 0o444 {
+		t.Errorf("got size %d and mode %s, want %d and -r--r--r--", fi.Size(), fi.Mode(), len(content))
+	}
+
+	b, err := readFile(path)
+	if err != nil {
+		t.Errorf("unexpected error while reading file: %s", err)
+	} else if !bytes.Equal(b, content) {
+		t.Errorf("file content differs")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "other")); !os.IsNotExist(err) {
+		t.Errorf("got %v for a missing file, want a not exist error", err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err == nil {
+		t.Errorf("unexpected success while writing the file")
+	}
+
+	if err := Unmount(dir); err != nil {
+		t.Fatalf("unexpected error while unmounting: %s", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Errorf("unexpected serve error: %s", err)
+	}
+}
+
+// readFile reads the file at path without the os package, which would
+// deadlock with the in-process server.
+func readFile(path string) ([]byte, error) {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+
+	var b []byte
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := unix.Read(fd, buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return b, nil
+		}
+		b = append(b, buf[:n]...)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package fuse
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/bin"
+	"golang.org/x/sys/unix"
+)
+
+// mountOptions are the options of the read-only mounts, the kernel checking
+// the permissions of the file.
+const mountOptions = "ro,nosuid,nodev,default_permissions"
+
+// Mount mounts a FUSE filesystem named fsname on the directory dir, and
+// returns the FUSE device serving it. The filesystem is mounted directly when
+// running as root, and with the fusermount3 or fusermount setuid helper
+// otherwise.
+func Mount(dir, fsname string) (*os.File, error) {
+	if os.Geteuid() == 0 {
+		return mountDirect(dir, fsname)
+	}
+	return mountHelper(dir, fsname)
+}
+
+// Unmount unmounts the FUSE filesystem mounted on the directory dir.
+func Unmount(dir string) error {
+	if os.Geteuid() == 0 {
+		return unix.Unmount(dir, unix.MNT_DETACH)
+	}
+	path, err := fusermountPath()
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(path, "-u", "-z", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("while unmounting %s: %s: %w", dir, strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+func mountDirect(dir, fsname string) (*os.File, error) {
+	// the device is opened in blocking mode, out of the Go poller
+	fd, err := unix.Open("/dev/fuse", unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("while opening /dev/fuse: %w", err)
+	}
+	dev := os.NewFile(uintptr(fd), "/dev/fuse")
+	data := fmt.Sprintf("fd=%d,rootmode=40000,user_id=%d,group_id=%d,max_read=%d,default_permissions",
+		dev.Fd(), os.Getuid(), os.Getgid(), maxRead)
+	flags := uintptr(unix.MS_RDONLY | unix.MS_NOSUID | unix.MS_NODEV)
+	if err := unix.Mount(fsname, dir, "fuse", flags, data); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("while mounting %s: %w", dir, err)
+	}
+	return dev, nil
+}
+
+// mountHelper mounts the filesystem with the fusermount helper, which sends
+// back the opened FUSE device over a unix socket.
+func mountHelper(dir, fsname string) (*os.File, error) {
+	path, err := fusermountPath()
+	if err != nil {
+		return nil, err
+	}
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "fusermount-local")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount-remote")
+	defer local.Close()
+	defer remote.Close()
+
+	cmd := exec.Command(path, "-o", mountOptions+",fsname="+fsname, "--", dir)
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("while mounting %s: %s: %w", dir, strings.TrimSpace(string(out)), err)
+	}
+
+	buf := make([]byte, 1)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, err := unix.Recvmsg(fds[0], buf, oob, 0)
+	if err != nil {
+		return nil, fmt.Errorf("while receiving FUSE device: %w", err)
+	}
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		return nil, fmt.Errorf("while receiving FUSE device: invalid control message")
+	}
+	devFds, err := unix.ParseUnixRights(&msgs[0])
+	if err != nil || len(devFds) != 1 {
+		return nil, fmt.Errorf("while receiving FUSE device: invalid file descriptor")
+	}
+	return os.NewFile(uintptr(devFds[0]), "/dev/fuse"), nil
+}
+
+func fusermountPath() (string, error) {
+	path, err := bin.FindBin("fusermount3")
+	if err != nil {
+		path, err = bin.FindBin("fusermount")
+	}
+	return path, err
+}
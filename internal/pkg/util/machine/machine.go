@@ -221,12 +221,15 @@ func ArchFromContainer(container string) string {
 const binfmtMisc = "/proc/sys/fs/binfmt_misc"
 
 type binfmtEntry struct {
-	magic      string
-	enabled    bool
-	persistent bool
+	magic       string
+	interpreter string
+	enabled     bool
+	persistent  bool
 }
 
-func canEmulate(arch string) bool {
+// binfmtEntries returns the enabled binfmt_misc entries registered for the
+// binaries of arch.
+func binfmtEntries(arch string) []*binfmtEntry {
 	var format format
 
 	for _, f := range formats {
@@ -238,22 +241,23 @@ func canEmulate(arch string) bool {
 
 	// no architecture format found
 	if format.Arch == "" {
-		return false
+		return nil
 	}
 
 	// look at /proc/sys/fs/binfmt_misc
 	content, _ := os.ReadFile(filepath.Join(binfmtMisc, "status"))
 	if string(content) != "enabled\n" {
-		return false
+		return nil
 	}
 
 	entries, err := os.ReadDir(binfmtMisc)
 	if err != nil {
-		return false
+		return nil
 	}
 
 	archMagic := hex.EncodeToString(format.ElfMagic)
 
+	var archEntries []*binfmtEntry
 	for _, entry := range entries {
 		f := filepath.Join(binfmtMisc, entry.Name())
 		b, err := os.ReadFile(f)
@@ -269,6 +273,11 @@ func canEmulate(arch string) bool {
 
 			if t == "enabled" {
 				entry.enabled = true
+			} else if strings.HasPrefix(t, "interpreter") {
+				splitted := strings.Split(t, " ")
+				if len(splitted) > 1 {
+					entry.interpreter = splitted[1]
+				}
 			} else if strings.HasPrefix(t, "magic") {
 				splitted := strings.Split(t, " ")
 				if len(splitted) > 1 {
@@ -282,14 +291,40 @@ func canEmulate(arch string) bool {
 			}
 		}
 
-		if entry.enabled && entry.persistent && entry.magic == archMagic {
-			return true
+		if entry.enabled && entry.magic == archMagic {
+			archEntries = append(archEntries, entry)
 		}
 	}
 
+	return archEntries
+}
+
+// canEmulate returns whether the binaries of arch are run by an emulator
+// registered with the persistent flag, opened by the kernel at registration
+// so that it's also available in containers.
+func canEmulate(arch string) bool {
+	for _, entry := range binfmtEntries(arch) {
+		if entry.persistent {
+			return true
+		}
+	}
 	return false
 }
 
+// Emulator returns the path of the emulator registered in binfmt_misc to
+// run the binaries of arch, like qemu-user-static, when it's registered
+// without the persistent flag and must be present at the same path in the
+// container. An empty string is returned if there is no such emulator on
+// the host.
+func Emulator(arch string) string {
+	for _, entry := range binfmtEntries(arch) {
+		if !entry.persistent && entry.interpreter != "" && fs.IsFile(entry.interpreter) {
+			return entry.interpreter
+		}
+	}
+	return ""
+}
+
 // CompatibleWith returns if the current machine architecture is
 // compatible or can run via emulation the architecture passed in
 // argument.
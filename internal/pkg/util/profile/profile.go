@@ -0,0 +1,189 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package profile records the duration of the startup phases of a
+// container, requested with the --profile option, and reports them once the
+// container exits. The recorded phases are passed from the launching process
+// to the starter with Export and Import.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	// FormatText reports the phases as a table.
+	FormatText = "text"
+	// FormatJSON reports the phases as a JSON document.
+	FormatJSON = "json"
+)
+
+// Phase is a startup phase.
+type Phase struct {
+	// Name is the name of the phase.
+	Name string `json:"name"`
+	// Depth is the number of phases the phase is nested in.
+	Depth int `json:"depth"`
+	// Start is the time the phase started.
+	Start time.Time `json:"start"`
+	// End is the time the phase ended, zero while it runs.
+	End time.Time `json:"end"`
+}
+
+// profile is the set of phases recorded by the current process.
+type profile struct {
+	Format string    `json:"format"`
+	Origin time.Time `json:"origin"`
+	Phases []*Phase  `json:"phases"`
+}
+
+var (
+	mu      sync.Mutex
+	current *profile
+)
+
+// Enable starts recording the startup phases, to be reported in format.
+func Enable(format string) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("unknown profile format %q, must be %s or %s", format, FormatText, FormatJSON)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = &profile{Format: format, Origin: time.Now()}
+	return nil
+}
+
+// Enabled returns whether the startup phases are recorded.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return current != nil
+}
+
+// Begin starts the phase name, nested in the phases running, and returns
+// a function ending it. It does nothing when the phases aren't recorded.
+func Begin(name string) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return func() {}
+	}
+	depth := 0
+	for _, p := range current.Phases {
+		if p.End.IsZero() {
+			depth++
+		}
+	}
+	current.Phases = append(current.Phases, &Phase{Name: name, Depth: depth, Start: time.Now()})
+	return func() { End(name) }
+}
+
+// End ends the last running phase name, which may have been started by
+// another process.
+func End(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return
+	}
+	for i := len(current.Phases) - 1; i >= 0; i-- {
+		if p := current.Phases[i]; p.Name == name && p.End.IsZero() {
+			p.End = time.Now()
+			return
+		}
+	}
+}
+
+// Export returns the recorded phases, to be imported by another process,
+// or an empty string when the phases aren't recorded.
+func Export() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(current)
+	return string(b), err
+}
+
+// Import continues recording the phases exported by another process. It
+// does nothing for an empty string.
+func Import(s string) error {
+	if s == "" {
+		return nil
+	}
+	p := new(profile)
+	if err := json.Unmarshal([]byte(s), p); err != nil {
+		return fmt.Errorf("while decoding profile: %w", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = p
+	return nil
+}
+
+// jsonPhase is a phase reported in JSON, with times in seconds relative to
+// the start of the profile.
+type jsonPhase struct {
+	Name     string  `json:"name"`
+	Depth    int     `json:"depth"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+}
+
+// Report writes the recorded phases to w, the phases still running being
+// reported up to now. It does nothing when the phases aren't recorded.
+func Report(w io.Writer) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		return nil
+	}
+	now := time.Now()
+	duration := func(p *Phase) time.Duration {
+		if p.End.IsZero() {
+			return now.Sub(p.Start)
+		}
+		return p.End.Sub(p.Start)
+	}
+
+	if current.Format == FormatJSON {
+		r := struct {
+			Total  float64     `json:"total"`
+			Phases []jsonPhase `json:"phases"`
+		}{
+			Total:  now.Sub(current.Origin).Seconds(),
+			Phases: make([]jsonPhase, 0, len(current.Phases)),
+		}
+		for _, p := range current.Phases {
+			r.Phases = append(r.Phases, jsonPhase{
+				Name:     p.Name,
+				Depth:    p.Depth,
+				Start:    p.Start.Sub(current.Origin).Seconds(),
+				Duration: duration(p).Seconds(),
+			})
+		}
+		return json.NewEncoder(w).Encode(r)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tSTART\tDURATION")
+	for _, p := range current.Phases {
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\n",
+			strings.Repeat("  ", p.Depth), p.Name, seconds(p.Start.Sub(current.Origin)), seconds(duration(p)))
+	}
+	fmt.Fprintf(tw, "total\t\t%s\n", seconds(now.Sub(current.Origin)))
+	return tw.Flush()
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDisabled(t *testing.T) {
+	current = nil
+
+	Begin("phase")()
+	if Enabled() {
+		t.Errorf("profile enabled")
+	}
+	if s, err := Export(); err != nil || s != "" {
+		t.Errorf("got %q and %v exporting a disabled profile", s, err)
+	}
+	var b bytes.Buffer
+	if err := Report(&b); err != nil || b.Len() != 0 {
+		t.Errorf("got %q and %v reporting a disabled profile", b.String(), err)
+	}
+}
+
+func TestPhases(t *testing.T) {
+	defer func() { current = nil }()
+
+	if err := Enable("yaml"); err == nil {
+		t.Errorf("unexpected success with an unknown format")
+	}
+	if err := Enable(FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	endOuter := Begin("outer")
+	Begin("inner")()
+	Begin("starter")
+	s, err := Export()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the starter phase is ended by another process
+	current = nil
+	if err := Import(s); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	End("starter")
+	endOuter()
+
+	var b bytes.Buffer
+	if err := Report(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var r struct {
+		Total  float64     `json:"total"`
+		Phases []jsonPhase `json:"phases"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &r); err != nil {
+		t.Fatalf("while decoding report %q: %s", b.String(), err)
+	}
+	expected := []struct {
+		name  string
+		depth int
+	}{
+		{"outer", 0},
+		{"inner", 1},
+		{"starter", 1},
+	}
+	if len(r.Phases) != len(expected) {
+		t.Fatalf("got %d phases, expected %d", len(r.Phases), len(expected))
+	}
+	for i, e := range expected {
+		if p := r.Phases[i]; p.Name != e.name || p.Depth != e.depth {
+			t.Errorf("got phase %q at depth %d, expected %q at depth %d", p.Name, p.Depth, e.name, e.depth)
+		}
+	}
+
+	current.Format = FormatText
+	b.Reset()
+	if err := Report(&b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, line := range []string{"PHASE", "outer", "  inner", "total"} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("report %q doesn't contain %q", b.String(), line)
+		}
+	}
+}
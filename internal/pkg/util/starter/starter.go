@@ -223,6 +223,7 @@ func (c *Command) init(config *config.Common, ops ...CommandOp) error {
 	}
 
 	c.env = append(c.env, sylog.GetEnvVar())
+	c.env = append(c.env, sylog.GetFormatEnvVar())
 	c.env = append(c.env, envConfig...)
 
 	return nil
@@ -25,6 +25,14 @@ const (
 	HTTPS = "https"
 	// Oras is the keyword for an oras ref
 	Oras = "oras"
+	// S3 is the keyword for a s3 object storage ref
+	S3 = "s3"
+	// GS is the keyword for a Google Cloud Storage ref
+	GS = "gs"
+	// AzBlob is the keyword for an Azure Blob Storage ref
+	AzBlob = "azblob"
+	// IPFS is the keyword for a content-addressed IPFS ref
+	IPFS = "ipfs"
 )
 
 // validURIs contains a list of known uris
@@ -39,6 +47,10 @@ var validURIs = map[string]bool{
 	"http":           true,
 	"https":          true,
 	"oras":           true,
+	"s3":             true,
+	"gs":             true,
+	"azblob":         true,
+	"ipfs":           true,
 }
 
 // IsValid returns whether or not the given source is valid
@@ -69,11 +81,16 @@ func GetName(uri string) string {
 	ref = strings.TrimLeft(ref, "/")    // Trim leading "/" characters
 	refSplit := strings.Split(ref, "/") // Split ref into parts
 
-	if transport == HTTP || transport == HTTPS {
-		imageName := refSplit[len(refSplit)-1]
+	if transport == HTTP || transport == HTTPS || transport == S3 || transport == GS || transport == AzBlob {
+		// Drop any URL fragment, e.g. an expected #sha256=<hex> digest
+		imageName, _, _ := strings.Cut(refSplit[len(refSplit)-1], "#")
 		return imageName
 	}
 
+	if transport == IPFS {
+		return refSplit[0] + ".sif"
+	}
+
 	// Default tag is latest
 	tags := []string{"latest"}
 	container := refSplit[len(refSplit)-1]
@@ -23,6 +23,9 @@ func Test_GetName(t *testing.T) {
 		{"docker scoped", "docker://user/image", "image_latest.sif"},
 		{"dave's magical lolcow", "docker://sylabs.io/lolcow", "lolcow_latest.sif"},
 		{"docker w/ tags", "docker://sylabs.io/lolcow:3.7", "lolcow_3.7.sif"},
+		{"https", "https://example.com/lolcow.sif", "lolcow.sif"},
+		{"ipfs", "ipfs://bafkreidvbhs33ighmljlvr7zbv2ywwzcmp5adtf4kqvlly67cy56bdtmve", "bafkreidvbhs33ighmljlvr7zbv2ywwzcmp5adtf4kqvlly67cy56bdtmve.sif"},
+		{"https w/ digest", "https://example.com/lolcow.sif#sha256=abc", "lolcow.sif"},
 	}
 
 	for _, tt := range tests {
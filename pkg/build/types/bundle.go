@@ -94,6 +94,17 @@ type Options struct {
 	Arch string
 	// Authentication file for registry credentials
 	ReqAuthFile string
+	// MksquashfsProcs overrides the number of processors used by mksquashfs
+	// and unsquashfs, and of OCI layers decompressed concurrently, when not 0.
+	MksquashfsProcs uint
+	// MksquashfsMem overrides the memory limit of mksquashfs when not empty.
+	MksquashfsMem string
+	// MksquashfsBlockSize is the block size of the squashfs filesystem of a
+	// SIF image, the mksquashfs default when empty.
+	MksquashfsBlockSize string
+	// MksquashfsComp is the compressor of the squashfs filesystem of a SIF
+	// image, gzip when empty.
+	MksquashfsComp string
 }
 
 // NewEncryptedBundle creates an Encrypted Bundle environment.
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 
@@ -96,6 +97,25 @@ func IsReadOnlyFilesytem(err error) bool {
 	return ok
 }
 
+// ArchMismatchError is returned with the initialized image by Init for a
+// SIF image targeting an architecture the host can't run, so that callers
+// knowing an emulator is available can use it anyway.
+type ArchMismatchError struct {
+	// Arch is the architecture targeted by the image.
+	Arch string
+}
+
+func (e *ArchMismatchError) Error() string {
+	return fmt.Sprintf("the image's architecture (%s) could not run on the host's (%s)", e.Arch, runtime.GOARCH)
+}
+
+// IsArchMismatch returns if the corresponding error
+// is an architecture mismatch error or not.
+func IsArchMismatch(err error) bool {
+	var e *ArchMismatchError
+	return errors.As(err, &e)
+}
+
 // ErrUnknownFormat represents an unknown image format error.
 var ErrUnknownFormat = errors.New("image format not recognized")
 
@@ -414,16 +434,17 @@ func Init(path string, writable bool) (*Image, error) {
 			return nil, err
 		}
 
-		// readOnlyFilesystemError is allowed here and passed back
-		// to the caller because there is basically no error with
-		// the image format just a mismatch with writable parameter,
-		// so the decision is delegated to the caller
+		// readOnlyFilesystemError and ArchMismatchError are allowed
+		// here and passed back to the caller because there is basically
+		// no error with the image format just a mismatch with writable
+		// parameter or the host, so the decision is delegated to the
+		// caller
 		initErr := rf.format.initializer(img, fileinfo)
 		if _, ok := initErr.(debugError); ok {
 			sylog.Debugf("%s format initializer returned: %v", rf.name, initErr)
 			_ = img.File.Close()
 			continue
-		} else if initErr != nil && !IsReadOnlyFilesytem(initErr) {
+		} else if initErr != nil && !IsReadOnlyFilesytem(initErr) && !IsArchMismatch(initErr) {
 			_ = img.File.Close()
 			return nil, initErr
 		}
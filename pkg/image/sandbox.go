@@ -11,6 +11,8 @@ package image
 
 import (
 	"os"
+
+	"golang.org/x/sys/unix"
 )
 
 type sandboxFormat struct{}
@@ -29,6 +31,20 @@ func (f *sandboxFormat) initializer(img *Image, fi os.FileInfo) error {
 			AllowedUsage: RootFsUsage | OverlayUsage | DataUsage,
 		},
 	}
+
+	// directory trees published on a read-only filesystem, like the
+	// unpacked images distributed with CVMFS, are immutable root
+	// filesystems which can only be written through an overlay
+	if img.Writable && img.File != nil {
+		var st unix.Statfs_t
+		if err := unix.Fstatfs(int(img.File.Fd()), &st); err == nil && st.Flags&unix.ST_RDONLY != 0 {
+			img.Writable = false
+
+			return &readOnlyFilesystemError{
+				"could not set " + img.Path + " image writable: directory is located on a read-only filesystem",
+			}
+		}
+	}
 	return nil
 }
 
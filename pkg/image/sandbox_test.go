@@ -11,7 +11,10 @@ package image
 
 import (
 	"os"
+	"syscall"
 	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/test"
 )
 
 // runSandboxInitializerTest does all the required calls to be able to invoke
@@ -87,3 +90,36 @@ func TestSBOpenMode(t *testing.T) {
 		t.Fatal("openMode(false) returned the wrong value")
 	}
 }
+
+func TestSandboxInitializerReadOnly(t *testing.T) {
+	test.EnsurePrivilege(t)
+
+	path := t.TempDir()
+
+	img := &Image{Name: "test", Writable: true}
+	if err := runSandboxInitializerTest(t, img, path); err != nil {
+		t.Fatalf("sandbox initializer failed: %s", err)
+	}
+	img.File.Close()
+	if !img.Writable {
+		t.Errorf("sandbox on a read-write filesystem not writable")
+	}
+
+	if err := syscall.Mount(path, path, "", syscall.MS_BIND, ""); err != nil {
+		t.Fatalf("while bind mounting %s: %s", path, err)
+	}
+	defer syscall.Unmount(path, syscall.MNT_DETACH)
+	if err := syscall.Mount("", path, "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY, ""); err != nil {
+		t.Fatalf("while remounting %s read-only: %s", path, err)
+	}
+
+	img = &Image{Name: "test", Writable: true}
+	err := runSandboxInitializerTest(t, img, path)
+	img.File.Close()
+	if !IsReadOnlyFilesytem(err) {
+		t.Errorf("expected read-only filesystem error, got %v", err)
+	}
+	if img.Writable {
+		t.Errorf("sandbox on a read-only filesystem writable")
+	}
+}
@@ -12,8 +12,8 @@ package image
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
 
 	"github.com/apptainer/apptainer/internal/pkg/util/machine"
 	"github.com/apptainer/sif/v2/pkg/sif"
@@ -28,10 +28,10 @@ const (
 
 type sifFormat struct{}
 
-func checkPartitionType(img *Image, fstype sif.FSType, offset int64) (uint32, error) {
+func checkPartitionType(r io.ReaderAt, fstype sif.FSType, offset int64) (uint32, error) {
 	header := make([]byte, bufferSize)
 
-	if _, err := img.File.ReadAt(header, offset); err != nil {
+	if _, err := r.ReadAt(header, offset); err != nil {
 		return 0, fmt.Errorf("failed to read SIF partition at offset %d: %s", offset, err)
 	}
 
@@ -69,22 +69,21 @@ func (f *sifFormat) initializer(img *Image, fi os.FileInfo) error {
 		return debugError("SIF magic not found")
 	}
 
-	flag := os.O_RDONLY
-	if img.Writable {
-		flag = os.O_RDWR
-	}
+	// the header and descriptors are read from a memory mapping, so that
+	// only the pages holding them are read whatever the image size, and
+	// only the used descriptors are decoded
+	r, release := sifReader(img.File, fi.Size())
+	defer release()
 
 	// Load the SIF file
-	fimg, err := sif.LoadContainer(img.File,
-		sif.OptLoadWithFlag(flag),
-		sif.OptLoadWithCloseOnUnload(false),
-	)
+	fimg, err := sif.LoadContainer(r, sif.OptLoadWithCloseOnUnload(false))
 	if err != nil {
 		return err
 	}
 	defer fimg.UnloadContainer()
 
 	var groupID uint32
+	var archErr error
 
 	// Get the default system partition image
 	desc, err := fimg.GetDescriptor(sif.WithPartitionType(sif.PartPrimSys))
@@ -94,7 +93,7 @@ func (f *sifFormat) initializer(img *Image, fi os.FileInfo) error {
 			return err
 		}
 
-		htype, err := checkPartitionType(img, fstype, desc.Offset())
+		htype, err := checkPartitionType(r, fstype, desc.Offset())
 		if err != nil {
 			return fmt.Errorf("while checking system partition header: %s", err)
 		}
@@ -102,9 +101,10 @@ func (f *sifFormat) initializer(img *Image, fi os.FileInfo) error {
 		// Check the compatibility of the image's target architecture, the
 		// CompatibleWith call will also check that the current machine
 		// has persistent emulation enabled in /proc/sys/fs/binfmt_misc to
-		// be able to execute container process correctly
-		if goArch != "unknown" && !machine.CompatibleWith(goArch) {
-			return fmt.Errorf("the image's architecture (%s) could not run on the host's (%s)", goArch, runtime.GOARCH)
+		// be able to execute container process correctly, otherwise an
+		// emulator is bound in the container by the launcher
+		if goArch != "unknown" && !machine.CompatibleWith(goArch) && machine.Emulator(goArch) == "" {
+			archErr = &ArchMismatchError{Arch: goArch}
 		}
 
 		groupID = desc.GroupID()
@@ -132,7 +132,7 @@ func (f *sifFormat) initializer(img *Image, fi os.FileInfo) error {
 				return false
 			}
 
-			htype, err := checkPartitionType(img, fstype, desc.Offset())
+			htype, err := checkPartitionType(r, fstype, desc.Offset())
 			if err != nil {
 				return false
 			}
@@ -171,7 +171,7 @@ func (f *sifFormat) initializer(img *Image, fi os.FileInfo) error {
 
 	img.Type = SIF
 
-	return nil
+	return archErr
 }
 
 func (f *sifFormat) openMode(writable bool) int {
@@ -0,0 +1,187 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package image
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/apptainer/apptainer/pkg/sylog"
+	"github.com/apptainer/sif/v2/pkg/sif"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sifMapSize is the size of the leading region of a SIF image mapped
+	// in memory, holding its global header and descriptors, and the headers
+	// of the objects stored first. Only the pages accessed are read, so that
+	// the cost of opening an image doesn't depend on its size.
+	sifMapSize = 1 << 20
+
+	// sifHeaderSize is the size of the SIF global header, and the other
+	// offsets locate the fields describing the descriptors table in it.
+	sifHeaderSize              = 128
+	sifDescriptorsTotalOffset  = 88
+	sifDescriptorsOffsetOffset = 96
+	sifDescriptorsSizeOffset   = 104
+
+	// sifDescriptorSize is the size of a SIF descriptor, and
+	// sifDescriptorUsedOffset the offset of its used flag.
+	sifDescriptorSize       = 585
+	sifDescriptorUsedOffset = 4
+)
+
+// errMappedReadOnly is returned by the write operations of mappedFile.
+var errMappedReadOnly = errors.New("mapped SIF image is read-only")
+
+// mappedFile reads a SIF image from the memory mapping of its leading
+// region, and from the file beyond it. It implements sif.ReadWriter for
+// loading the descriptors without writing them.
+//
+// The descriptors table is presented compacted to the used descriptors,
+// with a global header patched accordingly, so that the unused descriptors
+// of large tables are not decoded.
+type mappedFile struct {
+	file *os.File
+	data []byte
+	// header and table replace the global header and the beginning of the
+	// descriptors table at tableOffset when not nil.
+	header      []byte
+	table       []byte
+	tableOffset int64
+}
+
+// mapSIF maps the leading region of the SIF image file of size bytes in
+// memory, read-only, and compacts its descriptors table.
+func mapSIF(file *os.File, size int64) (*mappedFile, error) {
+	n := min(size, sifMapSize)
+	if n <= 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+	data, err := unix.Mmap(int(file.Fd()), 0, int(n), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("while mapping %s: %w", file.Name(), err)
+	}
+	m := &mappedFile{file: file, data: data}
+	if err := m.compact(); err != nil {
+		sylog.Debugf("Not compacting SIF descriptors of %s: %s", file.Name(), err)
+	}
+	return m, nil
+}
+
+// compact builds the global header and the descriptors table listing the
+// used descriptors only. The image is left as is when its header doesn't
+// have the expected layout, the SIF library reporting invalid images.
+func (m *mappedFile) compact() error {
+	header := make([]byte, sifHeaderSize)
+	if _, err := m.readAt(header, 0); err != nil {
+		return err
+	}
+	total := int64(binary.LittleEndian.Uint64(header[sifDescriptorsTotalOffset:]))
+	offset := int64(binary.LittleEndian.Uint64(header[sifDescriptorsOffsetOffset:]))
+	size := int64(binary.LittleEndian.Uint64(header[sifDescriptorsSizeOffset:]))
+	if total <= 0 || offset < sifHeaderSize || size != total*sifDescriptorSize {
+		return fmt.Errorf("unexpected descriptors table of %d bytes for %d descriptors", size, total)
+	}
+
+	raw := make([]byte, size)
+	if _, err := m.readAt(raw, offset); err != nil {
+		return err
+	}
+	table := make([]byte, 0, size)
+	for i := int64(0); i < total; i++ {
+		d := raw[i*sifDescriptorSize : (i+1)*sifDescriptorSize]
+		if d[sifDescriptorUsedOffset] != 0 {
+			table = append(table, d...)
+		}
+	}
+	used := int64(len(table) / sifDescriptorSize)
+	if used == 0 || used == total {
+		return nil
+	}
+	binary.LittleEndian.PutUint64(header[sifDescriptorsTotalOffset:], uint64(used))
+	binary.LittleEndian.PutUint64(header[sifDescriptorsSizeOffset:], uint64(len(table)))
+	m.header, m.table, m.tableOffset = header, table, offset
+	return nil
+}
+
+// ReadAt reads from the image, the compacted global header and descriptors
+// table replacing the original ones.
+func (m *mappedFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := m.readAt(p, off)
+	if m.header != nil {
+		overlay(p[:n], off, m.header, 0)
+		overlay(p[:n], off, m.table, m.tableOffset)
+	}
+	return n, err
+}
+
+// overlay copies the bytes of src located at srcOff in the image over the
+// bytes of p located at off.
+func overlay(p []byte, off int64, src []byte, srcOff int64) {
+	start := max(off, srcOff)
+	end := min(off+int64(len(p)), srcOff+int64(len(src)))
+	if start < end {
+		copy(p[start-off:end-off], src[start-srcOff:end-srcOff])
+	}
+}
+
+// readAt reads from the mapping when the whole range is mapped, from the
+// file otherwise. A fault raised by a file truncated while mapped is
+// returned as an error.
+func (m *mappedFile) readAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off+int64(len(p)) > int64(len(m.data)) {
+		return m.file.ReadAt(p, off)
+	}
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, fmt.Errorf("while reading %s at offset %d: %v", m.file.Name(), off, r)
+		}
+	}()
+	return copy(p, m.data[off:]), nil
+}
+
+func (m *mappedFile) Write([]byte) (int, error) {
+	return 0, errMappedReadOnly
+}
+
+func (m *mappedFile) Seek(int64, int) (int64, error) {
+	return 0, errMappedReadOnly
+}
+
+func (m *mappedFile) Truncate(int64) error {
+	return errMappedReadOnly
+}
+
+// Close unmaps the mapped region, leaving the file open.
+func (m *mappedFile) Close() error {
+	if m.data == nil {
+		return nil
+	}
+	err := unix.Munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// sifReader returns the reader of the SIF image file of size bytes used to
+// load its descriptors, mapped in memory when possible, and a function
+// releasing it.
+func sifReader(file *os.File, size int64) (sif.ReadWriter, func()) {
+	m, err := mapSIF(file, size)
+	if err != nil {
+		// some filesystems don't support shared mappings
+		sylog.Debugf("Reading SIF image without mapping it: %s", err)
+		return file, func() {}
+	}
+	return m, func() { m.Close() }
+}
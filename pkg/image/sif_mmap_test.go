@@ -0,0 +1,217 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package image
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/apptainer/sif/v2/pkg/sif"
+)
+
+func TestMappedFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), sifMapSize/8)
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := mapSIF(f, int64(len(content)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer m.Close()
+	if len(m.data) != sifMapSize {
+		t.Errorf("got %d bytes mapped, expected %d", len(m.data), sifMapSize)
+	}
+
+	for _, off := range []int64{0, 100, sifMapSize - 8, sifMapSize + 100} {
+		b := make([]byte, 16)
+		n, err := m.ReadAt(b, off)
+		if err != nil || n != len(b) {
+			t.Errorf("got %d bytes and %v reading at offset %d", n, err, off)
+		} else if !bytes.Equal(b, content[off:off+16]) {
+			t.Errorf("got %q at offset %d, expected %q", b, off, content[off:off+16])
+		}
+	}
+	if _, err := m.ReadAt(make([]byte, 16), int64(len(content))); err == nil {
+		t.Errorf("unexpected success reading past the end of the file")
+	}
+	if _, err := m.Write([]byte("a")); !errors.Is(err, errMappedReadOnly) {
+		t.Errorf("got %v writing, expected %v", err, errMappedReadOnly)
+	}
+}
+
+func TestMappedSIF(t *testing.T) {
+	b, err := os.ReadFile(testSquash)
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", testSquash, err)
+	}
+	primPart := func() (sif.DescriptorInput, error) {
+		return sif.NewDescriptorInput(sif.DataPartition, bytes.NewReader(b),
+			sif.OptPartitionMetadata(sif.FsSquash, sif.PartPrimSys, runtime.GOARCH),
+		)
+	}
+	section := func() (sif.DescriptorInput, error) {
+		return sif.NewDescriptorInput(sif.DataGeneric, bytes.NewReader([]byte("data")), sif.OptObjectName("section"))
+	}
+	path := createSIF(t, false, section, primPart, section)
+	defer os.Remove(path)
+
+	// leave a hole in the descriptors table
+	fimg, err := sif.LoadContainerFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fimg.DeleteObject(1); err != nil {
+		t.Fatal(err)
+	}
+	fimg.UnloadContainer()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := mapSIF(f, fi.Size())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer m.Close()
+	if m.table == nil {
+		t.Fatalf("descriptors table not compacted")
+	}
+
+	descriptors := func(r sif.ReadWriter) []string {
+		fimg, err := sif.LoadContainer(r, sif.OptLoadWithCloseOnUnload(false))
+		if err != nil {
+			t.Fatalf("while loading SIF: %s", err)
+		}
+		defer fimg.UnloadContainer()
+		var list []string
+		fimg.WithDescriptors(func(d sif.Descriptor) bool {
+			data, err := d.GetData()
+			if err != nil {
+				t.Fatalf("while reading object %d: %s", d.ID(), err)
+			}
+			list = append(list, fmt.Sprintf("%d %d %s %d %d %x", d.ID(), d.GroupID(), d.Name(), d.Offset(), d.Size(), sha256.Sum256(data)))
+			return false
+		})
+		return list
+	}
+	got, expected := descriptors(m), descriptors(f)
+	if len(expected) != 2 || !slices.Equal(got, expected) {
+		t.Errorf("got descriptors %q, expected %q", got, expected)
+	}
+}
+
+// benchmarkSIF returns the path of a SIF image with a root filesystem
+// partition and a few data objects, followed by a sparse region making it
+// as large as a multi-hundred-GB image.
+func benchmarkSIF(b *testing.B) string {
+	squash, err := os.ReadFile(testSquash)
+	if err != nil {
+		b.Fatalf("failed to read %s: %s", testSquash, err)
+	}
+	opts := []sif.CreateOpt{}
+	for i := 0; i < 8; i++ {
+		di, err := sif.NewDescriptorInput(sif.DataGeneric, bytes.NewReader([]byte("data")))
+		if err != nil {
+			b.Fatal(err)
+		}
+		opts = append(opts, sif.OptCreateWithDescriptors(di))
+	}
+	di, err := sif.NewDescriptorInput(sif.DataPartition, bytes.NewReader(squash),
+		sif.OptPartitionMetadata(sif.FsSquash, sif.PartPrimSys, runtime.GOARCH),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	opts = append(opts, sif.OptCreateWithDescriptors(di))
+
+	path := filepath.Join(b.TempDir(), "image.sif")
+	f, err := sif.CreateContainerAtPath(path, opts...)
+	if err != nil {
+		b.Fatalf("failed to create SIF: %v", err)
+	}
+	f.UnloadContainer()
+	if err := os.Truncate(path, 256<<30); err != nil {
+		b.Skipf("can't create sparse image: %s", err)
+	}
+	return path
+}
+
+// BenchmarkSIFLoad compares loading the descriptors and checking the
+// partition headers of a large SIF image through its memory mapping and
+// through file reads.
+func BenchmarkSIFLoad(b *testing.B) {
+	path := benchmarkSIF(b)
+	file, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	load := func(b *testing.B, r sif.ReadWriter) {
+		fimg, err := sif.LoadContainer(r, sif.OptLoadWithCloseOnUnload(false))
+		if err != nil {
+			b.Fatal(err)
+		}
+		desc, err := fimg.GetDescriptor(sif.WithPartitionType(sif.PartPrimSys))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := checkPartitionType(r, sif.FsSquash, desc.Offset()); err != nil {
+			b.Fatal(err)
+		}
+		fimg.UnloadContainer()
+	}
+
+	b.Run("mapped", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			r, release := sifReader(file, fi.Size())
+			load(b, r)
+			release()
+		}
+	})
+	b.Run("file", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			load(b, file)
+		}
+	})
+}
+
+// BenchmarkSIFInit measures opening a large SIF image.
+func BenchmarkSIFInit(b *testing.B) {
+	path := benchmarkSIF(b)
+	for i := 0; i < b.N; i++ {
+		img, err := Init(path, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		img.File.Close()
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"testing"
 
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/internal/pkg/util/machine"
 	"github.com/apptainer/sif/v2/pkg/sif"
 )
 
@@ -126,7 +127,7 @@ func TestSIFInitializer(t *testing.T) {
 			path:               createSIF(t, false, primPartOtherArch),
 			writable:           false,
 			expectedSuccess:    false,
-			expectedPartitions: 0,
+			expectedPartitions: 1,
 			expectedSections:   0,
 		},
 		{
@@ -219,3 +220,36 @@ func TestSIFOpenMode(t *testing.T) {
 		t.Fatal("openMode(false) returned the wrong value")
 	}
 }
+
+func TestSIFArchMismatch(t *testing.T) {
+	arch := "s390x"
+	if runtime.GOARCH == arch {
+		arch = "amd64"
+	}
+	if machine.CompatibleWith(arch) || machine.Emulator(arch) != "" {
+		t.Skipf("host can run %s images", arch)
+	}
+
+	b, err := os.ReadFile(testSquash)
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", testSquash, err)
+	}
+	path := createSIF(t, false, func() (sif.DescriptorInput, error) {
+		return sif.NewDescriptorInput(sif.DataPartition, bytes.NewReader(b),
+			sif.OptPartitionMetadata(sif.FsSquash, sif.PartPrimSys, arch),
+		)
+	})
+	defer os.Remove(path)
+
+	img, err := Init(path, false)
+	if !IsArchMismatch(err) {
+		t.Fatalf("got error %v, expected an architecture mismatch", err)
+	}
+	if img == nil {
+		t.Fatalf("no image returned with the architecture mismatch")
+	}
+	defer img.File.Close()
+	if _, err := img.GetRootFsPartition(); err != nil {
+		t.Errorf("unexpected error getting root filesystem partition: %s", err)
+	}
+}
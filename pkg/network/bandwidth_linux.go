@@ -0,0 +1,219 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package network
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/types"
+	cnitypes "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// bandwidthCapability is the capability of the CNI bandwidth plugin.
+	bandwidthCapability = "bandwidth"
+	// minBurst is the minimum burst in bits allowed above the rate limits,
+	// large enough to hold a few packets of a jumbo frames MTU.
+	minBurst = 64 * 1024 * 8
+	// shapingLatency is the maximum time in milliseconds a packet can wait
+	// in the token bucket filter before being dropped.
+	shapingLatency = 25
+)
+
+// rateUnits are the units of the rates accepted by the bandwidth argument,
+// as understood by tc, with their value in bits per second.
+var rateUnits = []struct {
+	suffix string
+	factor uint64
+}{
+	{"tbit", 1000 * 1000 * 1000 * 1000},
+	{"gbit", 1000 * 1000 * 1000},
+	{"mbit", 1000 * 1000},
+	{"kbit", 1000},
+	{"bit", 1},
+	{"tbps", 8 * 1000 * 1000 * 1000 * 1000},
+	{"gbps", 8 * 1000 * 1000 * 1000},
+	{"mbps", 8 * 1000 * 1000},
+	{"kbps", 8 * 1000},
+	{"bps", 8},
+}
+
+// parseRate returns the rate in bits per second of a rate like 10mbit or
+// 1gbps, a rate without unit is expressed in bits per second.
+func parseRate(rate string) (uint64, error) {
+	r := strings.ToLower(rate)
+	factor := uint64(1)
+	for _, u := range rateUnits {
+		if strings.HasSuffix(r, u.suffix) {
+			r = strings.TrimSuffix(r, u.suffix)
+			factor = u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(r, 64)
+	if err != nil || n < 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, fmt.Errorf("badly formatted rate '%s', must be of form <number>[bit|kbit|mbit|gbit|tbit|bps|kbps|mbps|gbps|tbps]", rate)
+	}
+	bits := n * float64(factor)
+	if bits >= math.MaxUint64 {
+		return 0, fmt.Errorf("rate '%s' is too high", rate)
+	}
+	return uint64(bits), nil
+}
+
+// burst returns the burst allowed for a rate, corresponding to 100ms of
+// traffic.
+func burst(rate uint64) uint64 {
+	if rate == 0 {
+		return 0
+	}
+	if b := rate / 10; b > minBurst {
+		return b
+	}
+	return minBurst
+}
+
+// parseBandwidth returns the bandwidth limits of a bandwidth argument of
+// the form <rate> applying to both directions, or <ingress rate>/<egress rate>
+// where a zero rate means no limit.
+func parseBandwidth(value string) (*BandwidthEntry, error) {
+	rates := strings.Split(value, "/")
+	if len(rates) > 2 {
+		return nil, fmt.Errorf("badly formatted bandwidth argument '%s', must be of form bandwidth=rate or bandwidth=ingressRate/egressRate", value)
+	}
+	ingress, err := parseRate(rates[0])
+	if err != nil {
+		return nil, err
+	}
+	egress := ingress
+	if len(rates) == 2 {
+		egress, err = parseRate(rates[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ingress == 0 && egress == 0 {
+		return nil, fmt.Errorf("bandwidth argument '%s' doesn't set any limit", value)
+	}
+	return &BandwidthEntry{
+		IngressRate:  ingress,
+		IngressBurst: burst(ingress),
+		EgressRate:   egress,
+		EgressBurst:  burst(egress),
+	}, nil
+}
+
+// setBandwidth sets the bandwidth limits of a configured network. They are
+// passed to the bandwidth plugin when the network provides it, or applied
+// with traffic control once the network is added otherwise.
+func (m *Setup) setBandwidth(network string, bw *BandwidthEntry) {
+	for i := range m.networks {
+		if m.networks[i] != network {
+			continue
+		}
+		hasCap := false
+		for _, plugin := range m.networkConfList[i].Plugins {
+			if plugin.Network.Capabilities[bandwidthCapability] {
+				hasCap = true
+				break
+			}
+		}
+		if hasCap {
+			m.runtimeConf[i].CapabilityArgs[bandwidthCapability] = *bw
+			m.bandwidth[i] = nil
+		} else {
+			m.bandwidth[i] = bw
+		}
+	}
+}
+
+// hostVeth returns the host side veth interface created by the plugins of
+// a network.
+func hostVeth(result types.Result) (netlink.Link, error) {
+	res, err := cnitypes.NewResultFromResult(result)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert result: %v", err)
+	}
+	for _, iface := range res.Interfaces {
+		if iface.Sandbox != "" {
+			continue
+		}
+		link, err := netlink.LinkByName(iface.Name)
+		if err != nil {
+			continue
+		}
+		if link.Type() == "veth" {
+			return link, nil
+		}
+	}
+	return nil, fmt.Errorf("no host veth interface found, only bridge and ptp networks support bandwidth limits without the bandwidth plugin")
+}
+
+// limitBandwidth applies bandwidth limits with traffic control on the host
+// side veth interface of a network: traffic sent to the container is shaped
+// by a token bucket filter, and traffic sent by the container is policed at
+// ingress of the interface. The rules are removed with the interface when
+// the network is deleted.
+func limitBandwidth(result types.Result, bw *BandwidthEntry) error {
+	link, err := hostVeth(result)
+	if err != nil {
+		return err
+	}
+	index := link.Attrs().Index
+
+	if bw.IngressRate > 0 {
+		rate := bw.IngressRate / 8
+		burst := uint32(bw.IngressBurst / 8)
+		tbf := &netlink.Tbf{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: index,
+				Handle:    netlink.MakeHandle(1, 0),
+				Parent:    netlink.HANDLE_ROOT,
+			},
+			Rate:   rate,
+			Limit:  uint32(rate*shapingLatency/1000) + burst,
+			Buffer: netlink.Xmittime(rate, burst),
+		}
+		if err := netlink.QdiscReplace(tbf); err != nil {
+			return fmt.Errorf("could not add ingress limit: %s", err)
+		}
+	}
+
+	if bw.EgressRate > 0 {
+		ingress := &netlink.Ingress{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: index,
+				Handle:    netlink.MakeHandle(0xffff, 0),
+				Parent:    netlink.HANDLE_INGRESS,
+			},
+		}
+		if err := netlink.QdiscReplace(ingress); err != nil {
+			return fmt.Errorf("could not add ingress qdisc: %s", err)
+		}
+		police := netlink.NewPoliceAction()
+		police.Rate = uint32(min(bw.EgressRate/8, math.MaxUint32))
+		police.Burst = uint32(bw.EgressBurst / 8)
+		police.ExceedAction = netlink.TC_POLICE_SHOT
+		filter := &netlink.MatchAll{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: index,
+				Parent:    ingress.Handle,
+				Priority:  1,
+				Protocol:  unix.ETH_P_ALL,
+			},
+			Actions: []netlink.Action{police},
+		}
+		if err := netlink.FilterAdd(filter); err != nil {
+			return fmt.Errorf("could not add egress limit: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package network
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NetnsRunDir is the directory where the named network namespaces created
+// with 'ip netns add' are mounted.
+const NetnsRunDir = "/run/netns"
+
+// NamespacePath returns the path of the network namespace to join, netns
+// being either an absolute path like /proc/<pid>/ns/net, or the name of a
+// network namespace created with 'ip netns add'.
+func NamespacePath(netns string) (string, error) {
+	if netns == "" {
+		return "", nil
+	}
+	if strings.Contains(netns, "/") {
+		if !filepath.IsAbs(netns) {
+			return "", fmt.Errorf("network namespace path %s must be absolute", netns)
+		}
+		return filepath.Clean(netns), nil
+	}
+	if netns == "." || netns == ".." {
+		return "", fmt.Errorf("invalid network namespace name %s", netns)
+	}
+	return filepath.Join(NetnsRunDir, netns), nil
+}
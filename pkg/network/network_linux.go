@@ -52,6 +52,7 @@ type Setup struct {
 	networkConfList []*libcni.NetworkConfigList
 	runtimeConf     []*libcni.RuntimeConf
 	result          []types.Result
+	bandwidth       []*BandwidthEntry
 	cniPath         *CNIPath
 	containerID     string
 	netNS           string
@@ -66,6 +67,16 @@ type PortMapEntry struct {
 	HostIP        string `json:"hostIP,omitempty"`
 }
 
+// BandwidthEntry describes the bandwidth limits of a container, rates are
+// expressed in bits per second and bursts in bits, a zero rate means no
+// limit
+type BandwidthEntry struct {
+	IngressRate  uint64 `json:"ingressRate"`
+	IngressBurst uint64 `json:"ingressBurst"`
+	EgressRate   uint64 `json:"egressRate"`
+	EgressBurst  uint64 `json:"egressBurst"`
+}
+
 // GetAllNetworkConfigList lists configured networks in configuration path directory
 // provided by cniPath
 func GetAllNetworkConfigList(cniPath *CNIPath) ([]*libcni.NetworkConfigList, error) {
@@ -174,6 +185,7 @@ func NewSetupFromConfig(networkConfList []*libcni.NetworkConfigList, containerID
 			networks:        networks,
 			networkConfList: networkConfList,
 			runtimeConf:     runtimeConf,
+			bandwidth:       make([]*BandwidthEntry, len(networkConfList)),
 			cniPath:         cniPath,
 			netNS:           netNS,
 			containerID:     id,
@@ -222,9 +234,25 @@ func (m *Setup) SetCapability(network string, capName string, args interface{})
 					args,
 				)
 			case []allocator.Range:
+				// each range set gives an address, so that ranges of
+				// both families request a dual-stack configuration
+				if m.runtimeConf[i].CapabilityArgs[capName] == nil {
+					m.runtimeConf[i].CapabilityArgs[capName] = make([]allocator.RangeSet, 0)
+				}
+				m.runtimeConf[i].CapabilityArgs[capName] = append(
+					m.runtimeConf[i].CapabilityArgs[capName].([]allocator.RangeSet),
+					args,
+				)
+			case BandwidthEntry:
+				m.runtimeConf[i].CapabilityArgs[capName] = args
+			case string:
 				if m.runtimeConf[i].CapabilityArgs[capName] == nil {
-					m.runtimeConf[i].CapabilityArgs[capName] = []allocator.RangeSet{args}
+					m.runtimeConf[i].CapabilityArgs[capName] = make([]string, 0)
 				}
+				m.runtimeConf[i].CapabilityArgs[capName] = append(
+					m.runtimeConf[i].CapabilityArgs[capName].([]string),
+					args,
+				)
 			}
 		}
 	}
@@ -328,6 +356,23 @@ func (m *Setup) SetArgs(args []string) error {
 				if err := m.SetCapability(networkName, "ipRanges", ipRange); err != nil {
 					return err
 				}
+			} else if key == "ips" {
+				addr := value
+				if ip, _, err := net.ParseCIDR(value); err == nil {
+					addr = ip.String()
+				}
+				if net.ParseIP(addr) == nil {
+					return fmt.Errorf("badly formatted ips argument '%s', must be an IPv4 or IPv6 address", value)
+				}
+				if err := m.SetCapability(networkName, "ips", value); err != nil {
+					return err
+				}
+			} else if key == "bandwidth" {
+				bw, err := parseBandwidth(value)
+				if err != nil {
+					return err
+				}
+				m.setBandwidth(networkName, bw)
 			} else {
 				for i := range m.networks {
 					if m.networks[i] == networkName {
@@ -340,9 +385,10 @@ func (m *Setup) SetArgs(args []string) error {
 	return nil
 }
 
-// GetNetworkIP returns IP associated with a configured network, if network
-// is empty, the function returns IP for the first configured network
-func (m *Setup) GetNetworkIP(network string, version string) (net.IP, error) {
+// networkResult returns the result of the plugins for a configured network,
+// if network is empty, the function returns the result for the first
+// configured network
+func (m *Setup) networkResult(network string) (*cnitypes.Result, error) {
 	n := network
 	if n == "" && len(m.networkConfList) > 0 {
 		n = m.networkConfList[0].Name
@@ -350,21 +396,84 @@ func (m *Setup) GetNetworkIP(network string, version string) (net.IP, error) {
 
 	for i := 0; i < len(m.networkConfList); i++ {
 		if m.networkConfList[i].Name == n {
+			if i >= len(m.result) || m.result[i] == nil {
+				break
+			}
 			res, err := cnitypes.NewResultFromResult(m.result[i])
 			if err != nil {
 				return nil, fmt.Errorf("could not convert result: %v", err)
 			}
-			for _, ipResult := range res.IPs {
-				is4 := ipResult.Address.IP.To4() != nil
-				if (is4 && version == "4") || version == "6" {
-					return ipResult.Address.IP, nil
-				}
-			}
-			break
+			return res, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no IP found for network %s", network)
+	return nil, fmt.Errorf("no result found for network %s", network)
+}
+
+// GetNetworkIP returns IP associated with a configured network, if network
+// is empty, the function returns IP for the first configured network
+func (m *Setup) GetNetworkIP(network string, version string) (net.IP, error) {
+	ips, err := m.GetNetworkIPs(network)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		is4 := ip.To4() != nil
+		if (is4 && version == "4") || (!is4 && version == "6") {
+			return ip, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no IPv%s found for network %s", version, network)
+}
+
+// GetNetworkIPs returns the IPv4 and IPv6 addresses associated with a
+// configured network, if network is empty, the function returns the
+// addresses for the first configured network
+func (m *Setup) GetNetworkIPs(network string) ([]net.IP, error) {
+	res, err := m.networkResult(network)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(res.IPs))
+	for _, ipResult := range res.IPs {
+		ips = append(ips, ipResult.Address.IP)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no IP found for network %s", network)
+	}
+	return ips, nil
+}
+
+// GetNetworkDNS returns the DNS configuration returned by the plugins of a
+// configured network, if network is empty, the function returns the DNS
+// configuration for the first configured network
+func (m *Setup) GetNetworkDNS(network string) (types.DNS, error) {
+	res, err := m.networkResult(network)
+	if err != nil {
+		return types.DNS{}, err
+	}
+	return res.DNS, nil
+}
+
+// GetNetworkMAC returns the MAC address of the container network interface
+// associated with a configured network, if network is empty, the function
+// returns the MAC address for the first configured network
+func (m *Setup) GetNetworkMAC(network string) (net.HardwareAddr, error) {
+	res, err := m.networkResult(network)
+	if err != nil {
+		return nil, err
+	}
+	ifName, err := m.GetNetworkInterface(network)
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range res.Interfaces {
+		if iface.Sandbox != "" && iface.Name == ifName && iface.Mac != "" {
+			return net.ParseMAC(iface.Mac)
+		}
+	}
+	return nil, fmt.Errorf("no MAC address found for network %s", network)
 }
 
 // GetNetworkInterface returns container network interface associated
@@ -472,7 +581,16 @@ func (m *Setup) command(ctx context.Context, command string) error {
 		m.result = make([]types.Result, len(m.networkConfList))
 		for i := 0; i < len(m.networkConfList); i++ {
 			var err error
-			if m.result[i], err = config.AddNetworkList(ctx, m.networkConfList[i], m.runtimeConf[i]); err != nil {
+			m.result[i], err = config.AddNetworkList(ctx, m.networkConfList[i], m.runtimeConf[i])
+			if err == nil && m.bandwidth[i] != nil {
+				if err = limitBandwidth(m.result[i], m.bandwidth[i]); err != nil {
+					err = fmt.Errorf("while limiting bandwidth of network %s: %s", m.networks[i], err)
+					if delErr := config.DelNetworkList(ctx, m.networkConfList[i], m.runtimeConf[i]); delErr != nil {
+						return delErr
+					}
+				}
+			}
+			if err != nil {
 				for j := i - 1; j >= 0; j-- {
 					if err := config.DelNetworkList(ctx, m.networkConfList[j], m.runtimeConf[j]); err != nil {
 						return err
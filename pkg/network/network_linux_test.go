@@ -263,11 +263,56 @@ func testSetArgs(setup *Setup, t *testing.T) {
 			args:    []string{"test-bridge-iprange:ipRange=10.1.1.0/16"},
 			success: true,
 		},
+		{
+			desc:    "good IPv6 ipRange arg",
+			args:    []string{"test-bridge-iprange:ipRange=fd00:111:113::/64"},
+			success: true,
+		},
+		{
+			desc:    "good dual-stack ipRange arg",
+			args:    []string{"test-bridge-iprange:ipRange=10.1.1.0/16;ipRange=fd00:111:113::/64"},
+			success: true,
+		},
+		{
+			desc:    "ips not supported arg",
+			args:    []string{"test-bridge:ips=fd00:111:113::10/64"},
+			success: false,
+		},
+		{
+			desc:    "bad ips arg",
+			args:    []string{"test-bridge:ips=fd00:111:113::zz"},
+			success: false,
+		},
 		{
 			desc:    "bad ipRange arg",
 			args:    []string{"test-bridge-iprange:ipRange=1024.1.1.0/16"},
 			success: false,
 		},
+		{
+			desc:    "good bandwidth arg",
+			args:    []string{"test-bridge:bandwidth=10mbit"},
+			success: true,
+		},
+		{
+			desc:    "good ingress/egress bandwidth arg",
+			args:    []string{"bandwidth=1.5gbit/0"},
+			success: true,
+		},
+		{
+			desc:    "bad bandwidth unit",
+			args:    []string{"test-bridge:bandwidth=10mb"},
+			success: false,
+		},
+		{
+			desc:    "bad bandwidth arg",
+			args:    []string{"test-bridge:bandwidth=10mbit/1mbit/1mbit"},
+			success: false,
+		},
+		{
+			desc:    "no bandwidth limit",
+			args:    []string{"test-bridge:bandwidth=0/0"},
+			success: false,
+		},
 		{
 			desc:    "IP arg",
 			args:    []string{"test-bridge:IP=10.1.1.1"},
@@ -597,6 +642,69 @@ func TestAddDelNetworks(t *testing.T) {
 	}
 }
 
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *BandwidthEntry
+		success bool
+	}{
+		{
+			value:   "10mbit",
+			want:    &BandwidthEntry{IngressRate: 10000000, IngressBurst: 1000000, EgressRate: 10000000, EgressBurst: 1000000},
+			success: true,
+		},
+		{
+			value:   "1MBps/0",
+			want:    &BandwidthEntry{IngressRate: 8000000, IngressBurst: 800000},
+			success: true,
+		},
+		{
+			value:   "0/100kbit",
+			want:    &BandwidthEntry{EgressRate: 100000, EgressBurst: minBurst},
+			success: true,
+		},
+		{value: "10mb"},
+		{value: "-1mbit"},
+		{value: "0"},
+		{value: "1mbit/"},
+	}
+	for _, tt := range tests {
+		bw, err := parseBandwidth(tt.value)
+		if err != nil && tt.success {
+			t.Errorf("unexpected failure for %q: %s", tt.value, err)
+		} else if err == nil && !tt.success {
+			t.Errorf("unexpected success for %q", tt.value)
+		} else if tt.success && !reflect.DeepEqual(bw, tt.want) {
+			t.Errorf("got %+v for %q, expected %+v", bw, tt.value, tt.want)
+		}
+	}
+}
+
+func TestNamespacePath(t *testing.T) {
+	tests := []struct {
+		netns   string
+		want    string
+		success bool
+	}{
+		{netns: "", want: "", success: true},
+		{netns: "/proc/1/ns/net", want: "/proc/1/ns/net", success: true},
+		{netns: "/run/netns/../netns/sdn", want: "/run/netns/sdn", success: true},
+		{netns: "sdn", want: "/run/netns/sdn", success: true},
+		{netns: "proc/1/ns/net"},
+		{netns: ".."},
+	}
+	for _, tt := range tests {
+		path, err := NamespacePath(tt.netns)
+		if err != nil && tt.success {
+			t.Errorf("unexpected failure for %q: %s", tt.netns, err)
+		} else if err == nil && !tt.success {
+			t.Errorf("unexpected success for %q", tt.netns)
+		} else if path != tt.want {
+			t.Errorf("got %q for %q, expected %q", path, tt.netns, tt.want)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	var err error
 
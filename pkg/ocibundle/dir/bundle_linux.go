@@ -0,0 +1,120 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package dirbundle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/apptainer/apptainer/pkg/ocibundle"
+	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+)
+
+type dirBundle struct {
+	dir        string
+	bundlePath string
+	writable   bool
+	configOut  io.Writer
+	ocibundle.Bundle
+}
+
+// BundleOpt is a functional option for FromDir.
+type BundleOpt func(d *dirBundle)
+
+// OptConfigOutput sets the writer of the OCI configuration of the bundle,
+// written to config.json in the bundle directory by default.
+func OptConfigOutput(w io.Writer) BundleOpt {
+	return func(d *dirBundle) {
+		d.configOut = w
+	}
+}
+
+// Create creates an OCI bundle from a directory tree, bind mounted
+// read-only as the bundle root filesystem without being copied, so
+// that trees unpacked on a read-only filesystem like CVMFS are used
+// in place.
+func (d *dirBundle) Create(ociConfig *specs.Spec) error {
+	if d.dir == "" {
+		return fmt.Errorf("directory wasn't set, need one to create bundle")
+	}
+
+	fi, err := os.Stat(d.dir)
+	if err != nil {
+		return fmt.Errorf("failed to load directory %s: %s", d.dir, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", d.dir)
+	}
+
+	// generate OCI bundle directory and config
+	g, err := tools.GenerateBundleConfig(d.bundlePath, ociConfig)
+	if err != nil {
+		return fmt.Errorf("failed to generate OCI bundle/config: %s", err)
+	}
+
+	rootFs := tools.RootFs(d.bundlePath).Path()
+	if err := syscall.Mount(d.dir, rootFs, "", syscall.MS_BIND, ""); err != nil {
+		tools.DeleteBundle(d.bundlePath)
+		return fmt.Errorf("failed to bind %s: %s", d.dir, err)
+	}
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_BIND | syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV)
+	if err := syscall.Mount("", rootFs, "", flags, ""); err != nil {
+		syscall.Unmount(rootFs, syscall.MNT_DETACH)
+		tools.DeleteBundle(d.bundlePath)
+		return fmt.Errorf("failed to remount %s read-only: %s", rootFs, err)
+	}
+
+	if err := tools.WriteBundleConfig(d.bundlePath, g, d.configOut); err != nil {
+		syscall.Unmount(rootFs, syscall.MNT_DETACH)
+		tools.DeleteBundle(d.bundlePath)
+		return fmt.Errorf("failed to write OCI configuration: %s", err)
+	}
+
+	if d.writable {
+		if err := tools.CreateOverlay(d.bundlePath); err != nil {
+			syscall.Unmount(rootFs, syscall.MNT_DETACH)
+			tools.DeleteBundle(d.bundlePath)
+			return fmt.Errorf("failed to create overlay: %s", err)
+		}
+	}
+	return nil
+}
+
+// Delete erases OCI bundle created from a directory, leaving the
+// directory untouched.
+func (d *dirBundle) Delete() error {
+	return tools.TeardownBundle(d.bundlePath)
+}
+
+// FromDir returns a bundle interface to create/delete OCI bundle from
+// a directory tree. When writable is true, a writable overlay is
+// mounted on top of the read-only root filesystem.
+func FromDir(dir, bundle string, writable bool, opts ...BundleOpt) (ocibundle.Bundle, error) {
+	var err error
+
+	d := &dirBundle{
+		writable: writable,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.bundlePath, err = filepath.Abs(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine bundle path: %s", err)
+	}
+	if dir != "" {
+		d.dir, err = filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine directory path: %s", err)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package dirbundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci"
+	"github.com/apptainer/apptainer/internal/pkg/test"
+	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+	"github.com/opencontainers/runtime-tools/validate"
+)
+
+func TestFromDir(t *testing.T) {
+	test.EnsurePrivilege(t)
+
+	bundlePath := t.TempDir()
+	dir := t.TempDir()
+	runScript := filepath.Join(dir, tools.RunScript)
+	if err := os.MkdirAll(filepath.Dir(runScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(runScript, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// test with a wrong directory path
+	bundle, err := FromDir("/blah", bundlePath, false)
+	if err != nil {
+		t.Errorf("unexpected error while opening non existent directory: %s", err)
+	}
+	if err := bundle.Create(nil); err == nil {
+		t.Errorf("unexpected success while creating OCI bundle")
+	}
+
+	tests := []struct {
+		name     string
+		writable bool
+	}{
+		{"FromDir", false},
+		{"FromDirWritable", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.writable {
+				requireFilesystem(t, "overlay")
+			}
+
+			bundle, err := FromDir(dir, bundlePath, tt.writable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// generate a default configuration
+			g, err := oci.DefaultConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// remove seccomp filter for CI
+			g.Config.Linux.Seccomp = nil
+			g.SetProcessArgs([]string{tools.RunScript, "id"})
+
+			if err := bundle.Create(g.Config); err != nil {
+				t.Fatal(err)
+			}
+
+			// the directory is only writable through the overlay
+			testFile := filepath.Join(tools.RootFs(bundlePath).Path(), "test")
+			err = os.WriteFile(testFile, []byte("test"), 0o644)
+			if tt.writable && err != nil {
+				t.Errorf("unexpected error while writing in bundle: %s", err)
+			} else if !tt.writable && err == nil {
+				t.Errorf("unexpected success while writing in read-only bundle")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "test")); err == nil {
+				t.Errorf("bundle write reached the directory")
+			}
+
+			// Validate the bundle using OCI runtime-tools
+			// Run in non-host-specific mode. Our bundle is for the "linux" platform
+			v, err := validate.NewValidatorFromPath(bundlePath, false, "linux")
+			if err != nil {
+				t.Errorf("Could not create bundle validator: %v", err)
+			}
+			if err := v.CheckAll(); err != nil {
+				t.Errorf("Bundle not valid: %v", err)
+			}
+
+			// Clean up
+			if err := bundle.Delete(); err != nil {
+				t.Error(err)
+			}
+			if _, err := os.Stat(runScript); err != nil {
+				t.Errorf("directory altered by the bundle deletion: %s", err)
+			}
+		})
+	}
+}
+
+// requireFilesystem checks that the current test could use the
+// corresponding filesystem, if the filesystem is not listed in
+// /proc/filesystems, the current test is skipped with a message.
+func requireFilesystem(t *testing.T, fs string) {
+	has, err := proc.HasFilesystem(fs)
+	if err != nil {
+		t.Fatalf("error while checking filesystem presence: %s", err)
+	}
+	if !has {
+		t.Skipf("%s filesystem seems not supported", fs)
+	}
+}
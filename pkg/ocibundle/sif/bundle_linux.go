@@ -10,8 +10,11 @@
 package sifbundle
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -24,27 +27,109 @@ import (
 	"github.com/apptainer/apptainer/pkg/image"
 	"github.com/apptainer/apptainer/pkg/ocibundle"
 	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+	"github.com/apptainer/apptainer/pkg/ociruntime"
+	"github.com/apptainer/apptainer/pkg/sylog"
 )
 
 type sifBundle struct {
 	image      string
 	bundlePath string
 	writable   bool
+	verify     func(*image.Image) error
+	specCache  SpecCache
+	configOut  io.Writer
 	ocibundle.Bundle
 }
 
+// SpecCache caches the OCI runtime specs generated for bundles, keyed by
+// a digest of the image configuration and of the provided OCI
+// configuration.
+type SpecCache interface {
+	// GetSpec returns the spec cached for key, or nil when there is none.
+	GetSpec(key string) ([]byte, error)
+	// PutSpec caches the spec for key.
+	PutSpec(key string, spec []byte) error
+}
+
+// specCacheVersion is part of the keys of the cached specs, to be
+// incremented when the generation of the specs changes.
+const specCacheVersion = "3"
+
+// BundleOpt is a functional option for FromSif.
+type BundleOpt func(s *sifBundle)
+
+// OptVerifyImage sets a function called with the opened SIF image
+// before its root filesystem is mounted, an error returned by fn
+// aborts the bundle creation. It is typically used to enforce the
+// execution control list on the image.
+func OptVerifyImage(fn func(*image.Image) error) BundleOpt {
+	return func(s *sifBundle) {
+		s.verify = fn
+	}
+}
+
+// OptSpecCache sets the cache of the generated OCI runtime specs, reused
+// while neither the image configuration nor the provided OCI configuration
+// change.
+func OptSpecCache(c SpecCache) BundleOpt {
+	return func(s *sifBundle) {
+		s.specCache = c
+	}
+}
+
+// OptConfigOutput sets the writer of the OCI configuration of the bundle,
+// written to config.json in the bundle directory by default.
+func OptConfigOutput(w io.Writer) BundleOpt {
+	return func(s *sifBundle) {
+		s.configOut = w
+	}
+}
+
 func (s *sifBundle) writeConfig(img *image.Image, g *generate.Generator) error {
 	// check if SIF file contain an OCI image configuration
+	var imgConfigJSON []byte
 	reader, err := image.NewSectionReader(img, image.SIFDescOCIConfigJSON, -1)
 	if err != nil && err != image.ErrNoSection {
 		return fmt.Errorf("failed to read %s section: %s", image.SIFDescOCIConfigJSON, err)
-	} else if err == image.ErrNoSection {
-		return tools.SaveBundleConfig(s.bundlePath, g)
+	} else if err == nil {
+		if imgConfigJSON, err = io.ReadAll(reader); err != nil {
+			return fmt.Errorf("failed to read %s section: %s", image.SIFDescOCIConfigJSON, err)
+		}
+	}
+
+	key := ""
+	if s.specCache != nil {
+		if key, err = s.specKey(g.Config, imgConfigJSON); err != nil {
+			return err
+		}
+		if spec, err := s.cachedSpec(key); err != nil {
+			sylog.Debugf("Not using cached OCI runtime spec: %s", err)
+		} else if spec != nil {
+			sylog.Debugf("Using cached OCI runtime spec %s", key)
+			return tools.WriteBundleConfig(s.bundlePath, generate.New(spec), s.configOut)
+		}
+	}
+
+	if imgConfigJSON != nil {
+		if err := s.applyImageConfig(imgConfigJSON, g); err != nil {
+			return err
+		}
 	}
 
+	if key != "" {
+		if err := s.cacheSpec(key, g.Config); err != nil {
+			sylog.Debugf("Could not cache OCI runtime spec: %s", err)
+		}
+	}
+	return tools.WriteBundleConfig(s.bundlePath, g, s.configOut)
+}
+
+// applyImageConfig applies the OCI image configuration of the SIF image to
+// the generated OCI configuration.
+func (s *sifBundle) applyImageConfig(imgConfigJSON []byte, g *generate.Generator) error {
 	var imgConfig imageSpecs.ImageConfig
 
-	if err := json.NewDecoder(reader).Decode(&imgConfig); err != nil {
+	if err := json.Unmarshal(imgConfigJSON, &imgConfig); err != nil {
 		return fmt.Errorf("failed to decode %s: %s", image.SIFDescOCIConfigJSON, err)
 	}
 
@@ -56,9 +141,14 @@ func (s *sifBundle) writeConfig(img *image.Image, g *generate.Generator) error {
 		}
 	}
 
-	if g.Config.Process.Cwd == "" && imgConfig.WorkingDir != "" {
+	// the default configuration starts in /, which the image working
+	// directory overrides
+	if (g.Config.Process.Cwd == "" || g.Config.Process.Cwd == "/") && imgConfig.WorkingDir != "" {
 		g.SetProcessCwd(imgConfig.WorkingDir)
 	}
+	if imgConfig.StopSignal != "" {
+		g.AddAnnotation(ociruntime.AnnotationStopSignal, imgConfig.StopSignal)
+	}
 	for _, e := range imgConfig.Env {
 		found := false
 		k := strings.SplitN(e, "=", 2)
@@ -88,7 +178,84 @@ func (s *sifBundle) writeConfig(img *image.Image, g *generate.Generator) error {
 		})
 	}
 
-	return tools.SaveBundleConfig(s.bundlePath, g)
+	return nil
+}
+
+// specKey returns the cache key of the OCI configuration generated from
+// spec and the OCI image configuration, the paths in the bundle being
+// relative so that the cached spec is reused by other bundles.
+func (s *sifBundle) specKey(spec *specs.Spec, imgConfigJSON []byte) (string, error) {
+	b, err := json.Marshal(s.relativeSpec(spec))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode OCI configuration: %s", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(specCacheVersion + "\x00"))
+	h.Write(b)
+	h.Write([]byte("\x00"))
+	h.Write(imgConfigJSON)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// relativeSpec returns a copy of spec with the paths in the bundle made
+// relative to it.
+func (s *sifBundle) relativeSpec(spec *specs.Spec) *specs.Spec {
+	c := *spec
+	rel := func(path string) string {
+		if r, err := filepath.Rel(s.bundlePath, path); err == nil && filepath.IsAbs(path) && !strings.HasPrefix(r, "..") {
+			return r
+		}
+		return path
+	}
+	if spec.Root != nil {
+		root := *spec.Root
+		root.Path = rel(root.Path)
+		c.Root = &root
+	}
+	c.Mounts = make([]specs.Mount, len(spec.Mounts))
+	for i, m := range spec.Mounts {
+		m.Source = rel(m.Source)
+		c.Mounts[i] = m
+	}
+	return &c
+}
+
+// cachedSpec returns the spec cached for key, with its paths relocated in
+// the bundle and its volume directories created, or nil when there is
+// none.
+func (s *sifBundle) cachedSpec(key string) (*specs.Spec, error) {
+	b, err := s.specCache.GetSpec(key)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	spec := new(specs.Spec)
+	if err := json.Unmarshal(b, spec); err != nil {
+		return nil, fmt.Errorf("failed to decode cached OCI configuration: %s", err)
+	}
+	if spec.Root != nil && !filepath.IsAbs(spec.Root.Path) {
+		spec.Root.Path = filepath.Join(s.bundlePath, spec.Root.Path)
+	}
+	volumes := tools.Volumes(s.bundlePath).Path()
+	for i, m := range spec.Mounts {
+		if filepath.IsAbs(m.Source) || !strings.HasPrefix(filepath.Join(s.bundlePath, m.Source), volumes+string(os.PathSeparator)) {
+			continue
+		}
+		src := filepath.Join(s.bundlePath, m.Source)
+		if err := os.MkdirAll(src, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create volume directory %s: %s", src, err)
+		}
+		spec.Mounts[i].Source = src
+	}
+	return spec, nil
+}
+
+// cacheSpec caches the generated spec for key.
+func (s *sifBundle) cacheSpec(key string, spec *specs.Spec) error {
+	b, err := json.Marshal(s.relativeSpec(spec))
+	if err != nil {
+		return err
+	}
+	return s.specCache.PutSpec(key, b)
 }
 
 // Create creates an OCI bundle from a SIF image
@@ -107,6 +274,12 @@ func (s *sifBundle) Create(ociConfig *specs.Spec) error {
 		return fmt.Errorf("%s is not a SIF image", s.image)
 	}
 
+	if s.verify != nil {
+		if err := s.verify(img); err != nil {
+			return err
+		}
+	}
+
 	part, err := img.GetRootFsPartition()
 	if err != nil {
 		return fmt.Errorf("while getting root filesystem in SIF %s: %s", s.image, err)
@@ -158,27 +331,19 @@ func (s *sifBundle) Create(ociConfig *specs.Spec) error {
 
 // Delete erases OCI bundle create from SIF image
 func (s *sifBundle) Delete() error {
-	if s.writable {
-		if err := tools.DeleteOverlay(s.bundlePath); err != nil {
-			return fmt.Errorf("delete error: %s", err)
-		}
-	}
-	// Umount rootfs
-	rootFsDir := tools.RootFs(s.bundlePath).Path()
-	if err := syscall.Unmount(rootFsDir, syscall.MNT_DETACH); err != nil {
-		return fmt.Errorf("failed to unmount %s: %s", rootFsDir, err)
-	}
-	// delete bundle directory
-	return tools.DeleteBundle(s.bundlePath)
+	return tools.TeardownBundle(s.bundlePath)
 }
 
 // FromSif returns a bundle interface to create/delete OCI bundle from SIF image
-func FromSif(image, bundle string, writable bool) (ocibundle.Bundle, error) {
+func FromSif(image, bundle string, writable bool, opts ...BundleOpt) (ocibundle.Bundle, error) {
 	var err error
 
 	s := &sifBundle{
 		writable: writable,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
 	s.bundlePath, err = filepath.Abs(bundle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine bundle path: %s", err)
@@ -10,15 +10,21 @@
 package sifbundle
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci"
 	"github.com/apptainer/apptainer/internal/pkg/test"
 	"github.com/apptainer/apptainer/internal/pkg/util/fs"
+	"github.com/apptainer/apptainer/pkg/image"
 	"github.com/apptainer/apptainer/pkg/ocibundle/tools"
+	"github.com/apptainer/apptainer/pkg/ociruntime"
 	"github.com/apptainer/apptainer/pkg/util/fs/proc"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/opencontainers/runtime-tools/validate"
 )
 
@@ -103,6 +109,149 @@ func TestFromSif(t *testing.T) {
 	}
 }
 
+// specCache is an in-memory SpecCache.
+type specCache map[string][]byte
+
+func (c specCache) GetSpec(key string) ([]byte, error) {
+	return c[key], nil
+}
+
+func (c specCache) PutSpec(key string, spec []byte) error {
+	c[key] = spec
+	return nil
+}
+
+func TestSpecCache(t *testing.T) {
+	img, err := image.Init(busyboxSIF, false)
+	if err != nil {
+		t.Fatalf("Could not open test image: %v", err)
+	}
+	defer img.File.Close()
+
+	c := specCache{}
+	for i := 0; i < 2; i++ {
+		bundlePath := t.TempDir()
+		s := &sifBundle{bundlePath: bundlePath, specCache: c}
+		g, err := tools.GenerateBundleConfig(bundlePath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		volume := filepath.Join(tools.Volumes(bundlePath).Path(), "_data")
+		g.AddMount(specs.Mount{Source: volume, Destination: "/data", Type: "none", Options: []string{"bind"}})
+		if err := s.writeConfig(img, g); err != nil {
+			t.Fatal(err)
+		}
+		// the spec of the first bundle is reused by the second one
+		if len(c) != 1 {
+			t.Fatalf("got %d cached specs, expected 1", len(c))
+		}
+
+		var key string
+		for k := range c {
+			key = k
+		}
+		spec, err := s.cachedSpec(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec.Root.Path != tools.RootFs(bundlePath).Path() {
+			t.Errorf("got root path %s, expected %s", spec.Root.Path, tools.RootFs(bundlePath).Path())
+		}
+		found := false
+		for _, m := range spec.Mounts {
+			found = found || m.Source == volume
+		}
+		if !found {
+			t.Errorf("volume %s not mounted", volume)
+		}
+		if !fs.IsDir(volume) {
+			t.Errorf("volume directory %s not created", volume)
+		}
+	}
+
+	// a different configuration doesn't use the cached spec
+	s := &sifBundle{bundlePath: t.TempDir(), specCache: c}
+	g, err := tools.GenerateBundleConfig(s.bundlePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetProcessArgs([]string{tools.RunScript, "id"})
+	if err := s.writeConfig(img, g); err != nil {
+		t.Fatal(err)
+	}
+	if len(c) != 2 {
+		t.Errorf("got %d cached specs, expected 2", len(c))
+	}
+}
+
+func TestConfigOutput(t *testing.T) {
+	img, err := image.Init(busyboxSIF, false)
+	if err != nil {
+		t.Fatalf("Could not open test image: %v", err)
+	}
+	defer img.File.Close()
+
+	var b bytes.Buffer
+	bundlePath := t.TempDir()
+	s := &sifBundle{bundlePath: bundlePath, configOut: &b}
+	g, err := tools.GenerateBundleConfig(bundlePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.writeConfig(img, g); err != nil {
+		t.Fatal(err)
+	}
+	var spec specs.Spec
+	if err := json.Unmarshal(b.Bytes(), &spec); err != nil {
+		t.Fatalf("while decoding OCI configuration: %v", err)
+	}
+	if spec.Root == nil || spec.Root.Path != tools.RootFs(bundlePath).Path() {
+		t.Errorf("unexpected root %+v", spec.Root)
+	}
+	if _, err := os.Stat(tools.Config(bundlePath).Path()); !os.IsNotExist(err) {
+		t.Errorf("configuration written in bundle")
+	}
+}
+
+func TestApplyImageConfigStopSignal(t *testing.T) {
+	bundlePath := t.TempDir()
+	s := &sifBundle{bundlePath: bundlePath}
+	g, err := tools.GenerateBundleConfig(bundlePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.applyImageConfig([]byte(`{"StopSignal":"SIGQUIT"}`), g); err != nil {
+		t.Fatal(err)
+	}
+	if sig := g.Config.Annotations[ociruntime.AnnotationStopSignal]; sig != "SIGQUIT" {
+		t.Errorf("got stop signal annotation %q, expected SIGQUIT", sig)
+	}
+}
+
+func TestApplyImageConfigWorkingDir(t *testing.T) {
+	bundlePath := t.TempDir()
+	s := &sifBundle{bundlePath: bundlePath}
+	g, err := tools.GenerateBundleConfig(bundlePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the default configuration starts in /
+	if err := s.applyImageConfig([]byte(`{"WorkingDir":"/app"}`), g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Config.Process.Cwd != "/app" {
+		t.Errorf("got working directory %q, expected /app", g.Config.Process.Cwd)
+	}
+
+	g.SetProcessCwd("/data")
+	if err := s.applyImageConfig([]byte(`{"WorkingDir":"/app"}`), g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Config.Process.Cwd != "/data" {
+		t.Errorf("got working directory %q, expected /data", g.Config.Process.Cwd)
+	}
+}
+
 // TODO: This is a duplicate from internal/pkg/test/tool/require
 // in order avoid needing buildcfg for this unit test, such that
 // it can be run directly from the source tree without compilation.
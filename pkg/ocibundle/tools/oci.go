@@ -11,6 +11,7 @@ package tools
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -91,6 +92,17 @@ func SaveBundleConfig(bundlePath string, g *generate.Generator) error {
 	return g.SaveToFile(Config(bundlePath).Path())
 }
 
+// WriteBundleConfig writes the OCI configuration of the bundle to w, or
+// to config.json in the bundle directory when w is nil. Writing it to a
+// pipe or a tmpfs file read by the runtime avoids the round trips to a
+// bundle on a slow shared filesystem.
+func WriteBundleConfig(bundlePath string, g *generate.Generator, w io.Writer) error {
+	if w != nil {
+		return g.Save(w)
+	}
+	return SaveBundleConfig(bundlePath, g)
+}
+
 // DeleteBundle deletes bundle directory
 func DeleteBundle(bundlePath string) error {
 	if err := os.RemoveAll(Volumes(bundlePath).Path()); err != nil {
@@ -99,7 +111,7 @@ func DeleteBundle(bundlePath string) error {
 	if err := os.Remove(RootFs(bundlePath).Path()); err != nil {
 		return fmt.Errorf("failed to delete rootfs directory: %s", err)
 	}
-	if err := os.Remove(Config(bundlePath).Path()); err != nil {
+	if err := os.Remove(Config(bundlePath).Path()); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete config.json file: %s", err)
 	}
 	if err := os.Remove(bundlePath); err != nil && !os.IsExist(err) {
@@ -14,6 +14,8 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/fs/teardown"
 )
 
 // CreateOverlay creates a writable overlay
@@ -82,3 +84,13 @@ func DeleteOverlay(bundlePath string) error {
 	}
 	return nil
 }
+
+// TeardownBundle unmounts the root filesystem of a bundle, with its
+// writable overlay if any, and deletes the bundle directory. Busy mounts
+// are lazily unmounted, and a bundle which can't be deleted is queued to
+// be deleted by a later invocation.
+func TeardownBundle(bundlePath string) error {
+	rootFsDir := RootFs(bundlePath).Path()
+	overlayDir := filepath.Join(bundlePath, "overlay")
+	return teardown.Teardown(bundlePath, rootFsDir, overlayDir)
+}
@@ -26,6 +26,10 @@ const (
 	Paused = "paused"
 )
 
+// AnnotationStopSignal is the annotation of the OCI configuration holding
+// the stop signal set in the configuration of the container image.
+const AnnotationStopSignal = "org.opencontainers.image.stopSignal"
+
 // State represents the state of the container
 type State struct {
 	specs.State
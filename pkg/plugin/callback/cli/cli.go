@@ -10,8 +10,12 @@
 package cli
 
 import (
+	"context"
+
+	"github.com/apptainer/apptainer/internal/pkg/cache"
 	"github.com/apptainer/apptainer/pkg/cmdline"
 	"github.com/apptainer/apptainer/pkg/runtime/engine/config"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // Command callback allows to add/modify commands and/or flags.
@@ -26,3 +30,21 @@ type Command func(*cmdline.CommandManager)
 // allows plugins to modify/alter runtime engine configuration. This
 // is the place to inject custom binds.
 type ApptainerEngineConfig func(*config.Common)
+
+// OCISpec callback allows to manipulate the OCI runtime specification
+// of a container created by the OCI runtime.
+// This callback is called in internal/app/apptainer/oci_create_linux.go,
+// before the container is created, and allows plugins to modify the
+// mounts, environment or devices of the container. An error returned by
+// the callback aborts the container creation.
+type OCISpec func(containerID string, spec *specs.Spec) error
+
+// ImageTransport callback allows to handle images referenced by URIs
+// of transports not supported by Apptainer, like lfs:// or internal
+// artifact stores.
+// This callback is called in cmd/internal/cli/actions.go and
+// cmd/internal/cli/pull.go with the transport of the URI, and returns
+// whether the plugin handles it. When handled, the plugin retrieves the
+// image, into the cache imgCache when enabled, and returns the path of
+// the local image.
+type ImageTransport func(ctx context.Context, imgCache *cache.Handle, transport, imageURI string) (image string, handled bool, err error)
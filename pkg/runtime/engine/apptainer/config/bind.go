@@ -25,10 +25,33 @@ const (
 // bindOptions is a map of option strings valid in bind specifications.
 // If true, the option is a flag. If false, the option takes a value.
 var bindOptions = map[string]bool{
-	"ro":        flagOption,
-	"rw":        flagOption,
-	"image-src": valueOption,
-	"id":        valueOption,
+	"ro":               flagOption,
+	"rw":               flagOption,
+	"image-src":        valueOption,
+	"id":               valueOption,
+	"bind-propagation": valueOption,
+}
+
+// propagationModes are the mount propagation modes accepted for the
+// container root filesystem and bind mounts.
+var propagationModes = []string{
+	"private",
+	"rprivate",
+	"slave",
+	"rslave",
+	"shared",
+	"rshared",
+}
+
+// CheckPropagation returns an error if mode isn't a supported mount
+// propagation mode.
+func CheckPropagation(mode string) error {
+	for _, m := range propagationModes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid mount propagation %q, must be one of %s", mode, strings.Join(propagationModes, ", "))
 }
 
 // BindPath stores a parsed bind path specification. Source and Destination
@@ -61,6 +84,15 @@ func (b *BindPath) ID() string {
 	return ""
 }
 
+// Propagation returns the value of the option bind-propagation for a
+// BindPath, or an empty string if the option wasn't set.
+func (b *BindPath) Propagation() string {
+	if b.Options != nil && b.Options["bind-propagation"] != nil {
+		return b.Options["bind-propagation"].Value
+	}
+	return ""
+}
+
 // Readonly returns true if the ro option was set for a BindPath.
 func (b *BindPath) Readonly() bool {
 	return b.Options != nil && b.Options["ro"] != nil
@@ -267,6 +299,11 @@ func newBindPath(bind string) (BindPath, error) {
 				return bp, fmt.Errorf("%s is not a valid bind option", value)
 			}
 		}
+		if p, ok := bp.Options["bind-propagation"]; ok {
+			if err := CheckPropagation(p.Value); err != nil {
+				return bp, err
+			}
+		}
 	}
 
 	return bp, nil
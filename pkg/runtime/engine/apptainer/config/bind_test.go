@@ -174,6 +174,26 @@ func TestParseBindPath(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "srcDstPropagation",
+			bindpaths: []string{"/opt:/other:ro,bind-propagation=rslave"},
+			want: []BindPath{
+				{
+					Source:      "/opt",
+					Destination: "/other",
+					Options: map[string]*BindOption{
+						"ro":               {},
+						"bind-propagation": {"rslave"},
+					},
+				},
+			},
+		},
+		{
+			name:      "srcDstPropagationInvalid",
+			bindpaths: []string{"/opt:/other:bind-propagation=potato"},
+			want:      []BindPath{},
+			wantErr:   true,
+		},
 		{
 			name:      "invalidOption",
 			bindpaths: []string{"/opt:/other:invalid"},
@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/apptainer/apptainer/internal/pkg/runtime/engine/config/oci"
+	"github.com/apptainer/apptainer/internal/pkg/runtime/launchhooks"
 	"github.com/apptainer/apptainer/pkg/image"
 	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
 )
@@ -81,82 +82,93 @@ type UserInfo struct {
 
 // JSONConfig stores engine specific configuration that is allowed to be set by the user.
 type JSONConfig struct {
-	ScratchDir            []string          `json:"scratchdir,omitempty"`
-	OverlayImage          []string          `json:"overlayImage,omitempty"`
-	NetworkArgs           []string          `json:"networkArgs,omitempty"`
-	Security              []string          `json:"security,omitempty"`
-	FilesPath             []string          `json:"filesPath,omitempty"`
-	LibrariesPath         []string          `json:"librariesPath,omitempty"`
-	FuseMount             []FuseMount       `json:"fuseMount,omitempty"`
-	ImageList             []image.Image     `json:"imageList,omitempty"`
-	BindPath              []BindPath        `json:"bindpath,omitempty"`
-	ApptainerEnv          map[string]string `json:"apptainerEnv,omitempty"`
-	UnixSocketPair        [2]int            `json:"unixSocketPair,omitempty"`
-	OpenFd                []int             `json:"openFd,omitempty"`
-	TargetGID             []int             `json:"targetGID,omitempty"`
-	Image                 string            `json:"image"`
-	ImageArg              string            `json:"imageArg"`
-	Workdir               string            `json:"workdir,omitempty"`
-	ConfigDir             string            `json:"configdir,omitempty"`
-	CgroupsJSON           string            `json:"cgroupsJSON,omitempty"`
-	HomeSource            string            `json:"homedir,omitempty"`
-	HomeDest              string            `json:"homeDest,omitempty"`
-	Command               string            `json:"command,omitempty"`
-	Shell                 string            `json:"shell,omitempty"`
-	FakerootPath          string            `json:"fakerootPath,omitempty"`
-	TmpDir                string            `json:"tmpdir,omitempty"`
-	AddCaps               string            `json:"addCaps,omitempty"`
-	DropCaps              string            `json:"dropCaps,omitempty"`
-	Hostname              string            `json:"hostname,omitempty"`
-	Network               string            `json:"network,omitempty"`
-	DNS                   string            `json:"dns,omitempty"`
-	Cwd                   string            `json:"cwd,omitempty"`
-	SessionLayer          string            `json:"sessionLayer,omitempty"`
-	ConfigurationFile     string            `json:"configurationFile,omitempty"`
-	UseBuildConfig        bool              `json:"useBuildConfig,omitempty"`
-	EncryptionKey         []byte            `json:"encryptionKey,omitempty"`
-	TargetUID             int               `json:"targetUID,omitempty"`
-	WritableImage         bool              `json:"writableImage,omitempty"`
-	WritableTmpfs         bool              `json:"writableTmpfs,omitempty"`
-	Contain               bool              `json:"container,omitempty"`
-	NvLegacy              bool              `json:"nvLegacy,omitempty"`
-	NvCCLI                bool              `json:"nvCCLI,omitempty"`
-	NvCCLIEnv             []string          `json:"NvCCLIEnv,omitempty"`
-	Rocm                  bool              `json:"rocm,omitempty"`
-	CustomHome            bool              `json:"customHome,omitempty"`
-	Instance              bool              `json:"instance,omitempty"`
-	InstanceJoin          bool              `json:"instanceJoin,omitempty"`
-	BootInstance          bool              `json:"bootInstance,omitempty"`
-	RunPrivileged         bool              `json:"runPrivileged,omitempty"`
-	AllowSUID             bool              `json:"allowSUID,omitempty"`
-	KeepPrivs             bool              `json:"keepPrivs,omitempty"`
-	NoPrivs               bool              `json:"noPrivs,omitempty"`
-	NoProc                bool              `json:"noProc,omitempty"`
-	NoSys                 bool              `json:"noSys,omitempty"`
-	NoDev                 bool              `json:"noDev,omitempty"`
-	NoDevPts              bool              `json:"noDevPts,omitempty"`
-	NoHome                bool              `json:"noHome,omitempty"`
-	NoTmp                 bool              `json:"noTmp,omitempty"`
-	NoHostfs              bool              `json:"noHostfs,omitempty"`
-	NoCwd                 bool              `json:"noCwd,omitempty"`
-	SkipBinds             []string          `json:"skipBinds,omitempty"`
-	NoInit                bool              `json:"noInit,omitempty"`
-	Fakeroot              bool              `json:"fakeroot,omitempty"`
-	SignalPropagation     bool              `json:"signalPropagation,omitempty"`
-	RestoreUmask          bool              `json:"restoreUmask,omitempty"`
-	DeleteTempDir         string            `json:"deleteTempDir,omitempty"`
-	Umask                 int               `json:"umask,omitempty"`
-	DMTCPConfig           DMTCPConfig       `json:"dmtcpConfig,omitempty"`
-	XdgRuntimeDir         string            `json:"xdgRuntimeDir,omitempty"`
-	DbusSessionBusAddress string            `json:"dbusSessionBusAddress,omitempty"`
-	NoEval                bool              `json:"noEval,omitempty"`
-	Underlay              bool              `json:"underlay,omitempty"`
-	UserInfo              UserInfo          `json:"userInfo,omitempty"`
-	WritableOverlay       bool              `json:"writableOverlay,omitempty"`
-	OverlayImplied        bool              `json:"overlayImplied,omitempty"`
-	ShareNSMode           bool              `json:"sharensMode,omitempty"`
-	ShareNSFd             int               `json:"sharensFd,omitempty"`
-	RunscriptTimeout      string            `json:"runscriptTimeout,omitempty"`
+	ScratchDir            []string           `json:"scratchdir,omitempty"`
+	OverlayImage          []string           `json:"overlayImage,omitempty"`
+	NetworkArgs           []string           `json:"networkArgs,omitempty"`
+	Security              []string           `json:"security,omitempty"`
+	FilesPath             []string           `json:"filesPath,omitempty"`
+	LibrariesPath         []string           `json:"librariesPath,omitempty"`
+	FuseMount             []FuseMount        `json:"fuseMount,omitempty"`
+	ImageList             []image.Image      `json:"imageList,omitempty"`
+	BindPath              []BindPath         `json:"bindpath,omitempty"`
+	ApptainerEnv          map[string]string  `json:"apptainerEnv,omitempty"`
+	UnixSocketPair        [2]int             `json:"unixSocketPair,omitempty"`
+	OpenFd                []int              `json:"openFd,omitempty"`
+	TargetGID             []int              `json:"targetGID,omitempty"`
+	Image                 string             `json:"image"`
+	ImageArg              string             `json:"imageArg"`
+	Workdir               string             `json:"workdir,omitempty"`
+	ConfigDir             string             `json:"configdir,omitempty"`
+	CgroupsJSON           string             `json:"cgroupsJSON,omitempty"`
+	CgroupParent          string             `json:"cgroupParent,omitempty"`
+	HomeSource            string             `json:"homedir,omitempty"`
+	HomeDest              string             `json:"homeDest,omitempty"`
+	Command               string             `json:"command,omitempty"`
+	Shell                 string             `json:"shell,omitempty"`
+	FakerootPath          string             `json:"fakerootPath,omitempty"`
+	TmpDir                string             `json:"tmpdir,omitempty"`
+	AddCaps               string             `json:"addCaps,omitempty"`
+	DropCaps              string             `json:"dropCaps,omitempty"`
+	AmbientCaps           bool               `json:"ambientCaps,omitempty"`
+	Hostname              string             `json:"hostname,omitempty"`
+	Network               string             `json:"network,omitempty"`
+	DNS                   string             `json:"dns,omitempty"`
+	Cwd                   string             `json:"cwd,omitempty"`
+	SessionLayer          string             `json:"sessionLayer,omitempty"`
+	ConfigurationFile     string             `json:"configurationFile,omitempty"`
+	UseBuildConfig        bool               `json:"useBuildConfig,omitempty"`
+	EncryptionKey         []byte             `json:"encryptionKey,omitempty"`
+	TargetUID             int                `json:"targetUID,omitempty"`
+	WritableImage         bool               `json:"writableImage,omitempty"`
+	WritableTmpfs         bool               `json:"writableTmpfs,omitempty"`
+	Contain               bool               `json:"container,omitempty"`
+	NvLegacy              bool               `json:"nvLegacy,omitempty"`
+	NvCCLI                bool               `json:"nvCCLI,omitempty"`
+	NvCCLIEnv             []string           `json:"NvCCLIEnv,omitempty"`
+	Rocm                  bool               `json:"rocm,omitempty"`
+	CustomHome            bool               `json:"customHome,omitempty"`
+	Instance              bool               `json:"instance,omitempty"`
+	InstanceJoin          bool               `json:"instanceJoin,omitempty"`
+	BootInstance          bool               `json:"bootInstance,omitempty"`
+	InstanceLogDriver     string             `json:"instanceLogDriver,omitempty"`
+	RunPrivileged         bool               `json:"runPrivileged,omitempty"`
+	AllowSUID             bool               `json:"allowSUID,omitempty"`
+	KeepPrivs             bool               `json:"keepPrivs,omitempty"`
+	NoPrivs               bool               `json:"noPrivs,omitempty"`
+	NoNewPrivs            bool               `json:"noNewPrivs,omitempty"`
+	AllowNewPrivs         bool               `json:"allowNewPrivs,omitempty"`
+	NoProc                bool               `json:"noProc,omitempty"`
+	NoSys                 bool               `json:"noSys,omitempty"`
+	NoDev                 bool               `json:"noDev,omitempty"`
+	NoDevPts              bool               `json:"noDevPts,omitempty"`
+	NoHome                bool               `json:"noHome,omitempty"`
+	NoTmp                 bool               `json:"noTmp,omitempty"`
+	NoHostfs              bool               `json:"noHostfs,omitempty"`
+	NoCwd                 bool               `json:"noCwd,omitempty"`
+	SkipBinds             []string           `json:"skipBinds,omitempty"`
+	NoInit                bool               `json:"noInit,omitempty"`
+	Subreaper             bool               `json:"subreaper,omitempty"`
+	AllowArchMismatch     bool               `json:"allowArchMismatch,omitempty"`
+	SeccompAudit          []string           `json:"seccompAudit,omitempty"`
+	Fakeroot              bool               `json:"fakeroot,omitempty"`
+	SignalPropagation     bool               `json:"signalPropagation,omitempty"`
+	RootfsPropagation     string             `json:"rootfsPropagation,omitempty"`
+	RestoreUmask          bool               `json:"restoreUmask,omitempty"`
+	DeleteTempDir         string             `json:"deleteTempDir,omitempty"`
+	Umask                 int                `json:"umask,omitempty"`
+	DMTCPConfig           DMTCPConfig        `json:"dmtcpConfig,omitempty"`
+	XdgRuntimeDir         string             `json:"xdgRuntimeDir,omitempty"`
+	DbusSessionBusAddress string             `json:"dbusSessionBusAddress,omitempty"`
+	NoEval                bool               `json:"noEval,omitempty"`
+	Underlay              bool               `json:"underlay,omitempty"`
+	UserInfo              UserInfo           `json:"userInfo,omitempty"`
+	WritableOverlay       bool               `json:"writableOverlay,omitempty"`
+	OverlayImplied        bool               `json:"overlayImplied,omitempty"`
+	ShareNSMode           bool               `json:"sharensMode,omitempty"`
+	ShareNSFd             int                `json:"sharensFd,omitempty"`
+	RunscriptTimeout      string             `json:"runscriptTimeout,omitempty"`
+	Profile               string             `json:"profile,omitempty"`
+	LaunchEvent           *launchhooks.Event `json:"launchEvent,omitempty"`
 }
 
 // SetImage sets the container image path to be used by EngineConfig.JSON.
@@ -400,6 +412,16 @@ func (e *EngineConfig) GetBootInstance() bool {
 	return e.JSON.BootInstance
 }
 
+// SetInstanceLogDriver sets the log driver of the instance output streams.
+func (e *EngineConfig) SetInstanceLogDriver(driver string) {
+	e.JSON.InstanceLogDriver = driver
+}
+
+// GetInstanceLogDriver returns the log driver of the instance output streams.
+func (e *EngineConfig) GetInstanceLogDriver() string {
+	return e.JSON.InstanceLogDriver
+}
+
 // SetAddCaps sets bounding/effective/permitted/inheritable/ambient capabilities to add.
 func (e *EngineConfig) SetAddCaps(caps string) {
 	e.JSON.AddCaps = caps
@@ -420,6 +442,17 @@ func (e *EngineConfig) GetDropCaps() string {
 	return e.JSON.DropCaps
 }
 
+// SetAmbientCaps sets ambient-caps flag to request the capabilities granted
+// to a user in the ambient set.
+func (e *EngineConfig) SetAmbientCaps(val bool) {
+	e.JSON.AmbientCaps = val
+}
+
+// GetAmbientCaps returns if ambient-caps flag is set or not.
+func (e *EngineConfig) GetAmbientCaps() bool {
+	return e.JSON.AmbientCaps
+}
+
 // SetHostname sets hostname to use in containee.JSON.
 func (e *EngineConfig) SetHostname(hostname string) {
 	e.JSON.Hostname = hostname
@@ -460,6 +493,28 @@ func (e *EngineConfig) GetNoPrivs() bool {
 	return e.JSON.NoPrivs
 }
 
+// SetNoNewPrivs sets no-new-privs flag to set no new privileges for the
+// container processes of the root user.
+func (e *EngineConfig) SetNoNewPrivs(val bool) {
+	e.JSON.NoNewPrivs = val
+}
+
+// GetNoNewPrivs returns if no-new-privs flag is set or not.
+func (e *EngineConfig) GetNoNewPrivs() bool {
+	return e.JSON.NoNewPrivs
+}
+
+// SetAllowNewPrivs sets allow-new-privs flag to allow the container
+// processes of the root user to gain new privileges.
+func (e *EngineConfig) SetAllowNewPrivs(val bool) {
+	e.JSON.AllowNewPrivs = val
+}
+
+// GetAllowNewPrivs returns if allow-new-privs flag is set or not.
+func (e *EngineConfig) GetAllowNewPrivs() bool {
+	return e.JSON.AllowNewPrivs
+}
+
 // SetNoProc set flag to not mount proc directory.
 func (e *EngineConfig) SetNoProc(val bool) {
 	e.JSON.NoProc = val
@@ -560,6 +615,40 @@ func (e *EngineConfig) GetNoInit() bool {
 	return e.JSON.NoInit
 }
 
+// SetSubreaper sets subreaper flag to start a shim process reaping the
+// orphaned processes of the container when PID namespace is not used.
+func (e *EngineConfig) SetSubreaper(val bool) {
+	e.JSON.Subreaper = val
+}
+
+// GetSubreaper returns if subreaper flag is set or not.
+func (e *EngineConfig) GetSubreaper() bool {
+	return e.JSON.Subreaper
+}
+
+// SetAllowArchMismatch sets whether images targeting another architecture
+// than the host's are run.
+func (e *EngineConfig) SetAllowArchMismatch(val bool) {
+	e.JSON.AllowArchMismatch = val
+}
+
+// GetAllowArchMismatch returns if images targeting another architecture
+// than the host's are run.
+func (e *EngineConfig) GetAllowArchMismatch() bool {
+	return e.JSON.AllowArchMismatch
+}
+
+// SetSeccompAudit sets the syscalls notified by a seccomp filter and logged
+// by a shim process for the container processes.
+func (e *EngineConfig) SetSeccompAudit(syscalls []string) {
+	e.JSON.SeccompAudit = syscalls
+}
+
+// GetSeccompAudit returns the syscalls logged by the seccomp audit mode.
+func (e *EngineConfig) GetSeccompAudit() []string {
+	return e.JSON.SeccompAudit
+}
+
 // SetNetwork sets a list of commas separated networks to configure inside container.
 func (e *EngineConfig) SetNetwork(network string) {
 	e.JSON.Network = network
@@ -656,6 +745,18 @@ func (e *EngineConfig) GetCgroupsJSON() string {
 	return e.JSON.CgroupsJSON
 }
 
+// SetCgroupParent sets the cgroup path, or systemd slice, under which the
+// container cgroup is created.
+func (e *EngineConfig) SetCgroupParent(parent string) {
+	e.JSON.CgroupParent = parent
+}
+
+// GetCgroupParent returns the cgroup path, or systemd slice, under which the
+// container cgroup is created.
+func (e *EngineConfig) GetCgroupParent() string {
+	return e.JSON.CgroupParent
+}
+
 // SetTargetUID sets target UID to execute the container process as user ID.
 func (e *EngineConfig) SetTargetUID(uid int) {
 	e.JSON.TargetUID = uid
@@ -865,6 +966,18 @@ func (e *EngineConfig) GetUseBuildConfig() bool {
 	return e.JSON.UseBuildConfig
 }
 
+// SetRootfsPropagation sets the mount propagation mode of the container
+// root filesystem, overriding the 'mount slave' directive.
+func (e *EngineConfig) SetRootfsPropagation(mode string) {
+	e.JSON.RootfsPropagation = mode
+}
+
+// GetRootfsPropagation returns the mount propagation mode of the container
+// root filesystem (see SetRootfsPropagation).
+func (e *EngineConfig) GetRootfsPropagation() string {
+	return e.JSON.RootfsPropagation
+}
+
 // SetRestoreUmask returns whether to restore Umask for the container launched process.
 func (e *EngineConfig) SetRestoreUmask(restoreUmask bool) {
 	e.JSON.RestoreUmask = restoreUmask
@@ -987,3 +1100,26 @@ func (e *EngineConfig) SetRunscriptTimout(timeout string) {
 func (e *EngineConfig) GetRunscriptTimeout() string {
 	return e.JSON.RunscriptTimeout
 }
+
+// SetProfile sets the startup phases recorded by the launcher, which are
+// completed by the engine and reported when the container exits.
+func (e *EngineConfig) SetProfile(profile string) {
+	e.JSON.Profile = profile
+}
+
+// GetProfile returns the startup phases recorded by the launcher.
+func (e *EngineConfig) GetProfile() string {
+	return e.JSON.Profile
+}
+
+// SetLaunchEvent sets the description of the container passed to the launch
+// hooks, with the annotations of the pre-launch hooks.
+func (e *EngineConfig) SetLaunchEvent(ev *launchhooks.Event) {
+	e.JSON.LaunchEvent = ev
+}
+
+// GetLaunchEvent returns the description of the container passed to the
+// launch hooks.
+func (e *EngineConfig) GetLaunchEvent() *launchhooks.Event {
+	return e.JSON.LaunchEvent
+}
@@ -81,7 +81,10 @@ func ParseMountString(mount string) (bindPaths []BindPath, err error) {
 				}
 				bp.Options["id"] = &BindOption{Value: val}
 			case "bind-propagation":
-				return []BindPath{}, fmt.Errorf("bind-propagation not supported for individual mounts, check apptainer.conf for global setting")
+				if err := CheckPropagation(val); err != nil {
+					return []BindPath{}, err
+				}
+				bp.Options["bind-propagation"] = &BindOption{Value: val}
 			default:
 				return []BindPath{}, fmt.Errorf("invalid key %q in mount specification", key)
 			}
@@ -196,7 +196,21 @@ func TestParseMountString(t *testing.T) {
 		},
 		{
 			name:        "bindpropagation",
-			mountString: "type=bind,source=/opt,destination=/opt,bind-propagation=shared",
+			mountString: "type=bind,source=/opt,destination=/opt,bind-propagation=rslave",
+			want: []BindPath{
+				{
+					Source:      "/opt",
+					Destination: "/opt",
+					Options: map[string]*BindOption{
+						"bind-propagation": {Value: "rslave"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:        "bindpropagationInvalid",
+			mountString: "type=bind,source=/opt,destination=/opt,bind-propagation=potato",
 			want:        []BindPath{},
 			wantErr:     true,
 		},
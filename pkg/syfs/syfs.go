@@ -32,6 +32,11 @@ const (
 	RemoteConfFile         = "remote.yaml"
 	RemoteCache            = "remote-cache"
 	DockerConfFile         = "docker-config.json"
+	OAuthTokensFile        = "oauth-tokens.json"
+	TrustDirName           = "trust"
+	ImageDirsFile          = "image-dirs"
+	PresetsFile            = "presets.conf"
+	SettingsFile           = "settings.conf"
 	apptainerDir           = ".apptainer"
 	legacyDir              = ".singularity"
 	defaultLocalKeyDirName = "keys" // defaultLocalKeyDirName represents the default local key storage folder name
@@ -102,6 +107,31 @@ func DockerConf() string {
 	return filepath.Join(ConfigDir(), DockerConfFile)
 }
 
+func OAuthTokens() string {
+	return filepath.Join(ConfigDir(), OAuthTokensFile)
+}
+
+func TrustDir() string {
+	return filepath.Join(ConfigDir(), TrustDirName)
+}
+
+// ImageDirs returns the file listing the directories registered by the
+// user to look for images in.
+func ImageDirs() string {
+	return filepath.Join(ConfigDir(), ImageDirsFile)
+}
+
+// Presets returns the file holding the flag presets defined by the user.
+func Presets() string {
+	return filepath.Join(ConfigDir(), PresetsFile)
+}
+
+// Settings returns the file holding the configuration directives set by the
+// user.
+func Settings() string {
+	return filepath.Join(ConfigDir(), SettingsFile)
+}
+
 func FallbackDockerConf() string {
 	return filepath.Join(configDir(".docker"), "config.json")
 }
@@ -12,12 +12,14 @@
 package sylog
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var messageColors = map[messageLevel]string{
@@ -34,11 +36,20 @@ var (
 
 var logWriter = (io.Writer)(os.Stderr)
 
+var logFormat = TextFormat
+
+// modulePrefix is stripped from package paths when reporting the
+// component emitting a JSON message.
+const modulePrefix = "github.com/apptainer/apptainer/"
+
 func init() {
 	l, err := strconv.Atoi(os.Getenv("APPTAINER_MESSAGELEVEL"))
 	if err == nil {
 		loggerLevel = messageLevel(l)
 	}
+	if os.Getenv(FormatEnv) == JSONFormat {
+		logFormat = JSONFormat
+	}
 }
 
 func prefix(logLevel, msgLevel messageLevel) string {
@@ -72,6 +83,61 @@ func prefix(logLevel, msgLevel messageLevel) string {
 	return fmt.Sprintf("%s%-8s%s%-19s%-30s", messageColor, msgLevel, colorReset, uidStr, funcName)
 }
 
+// jsonMessage is the structure of a message emitted with the JSON format.
+type jsonMessage struct {
+	Level     string `json:"level"`
+	Time      string `json:"timestamp"`
+	Component string `json:"component"`
+	Message   string `json:"message"`
+	Function  string `json:"function,omitempty"`
+	UID       *int   `json:"uid,omitempty"`
+	PID       *int   `json:"pid,omitempty"`
+}
+
+// jsonLine returns the JSON encoded representation of message, the
+// component is the package path of the function calling the logger.
+func jsonLine(logLevel, msgLevel messageLevel, message string) []byte {
+	m := jsonMessage{
+		Level:     msgLevel.String(),
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Component: "????",
+		Message:   message,
+	}
+
+	if pc, _, _, ok := runtime.Caller(3); ok {
+		if details := runtime.FuncForPC(pc); details != nil {
+			name := strings.TrimPrefix(details.Name(), modulePrefix)
+			// split package path from the function name, taking care
+			// of dots present in the last path element
+			pkg, fn := name, ""
+			if idx := strings.LastIndex(name, "/"); idx >= 0 {
+				if dot := strings.Index(name[idx:], "."); dot >= 0 {
+					pkg, fn = name[:idx+dot], name[idx+dot+1:]
+				}
+			} else if dot := strings.Index(name, "."); dot >= 0 {
+				pkg, fn = name[:dot], name[dot+1:]
+			}
+			m.Component = pkg
+			if logLevel >= DebugLevel {
+				m.Function = fn
+			}
+		}
+	}
+
+	if logLevel >= DebugLevel {
+		uid := os.Geteuid()
+		pid := os.Getpid()
+		m.UID = &uid
+		m.PID = &pid
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return []byte(fmt.Sprintf("{\"level\":%q,\"message\":%q}", msgLevel.String(), message))
+	}
+	return b
+}
+
 func writef(msgLevel messageLevel, format string, a ...interface{}) {
 	logLevel := getLoggerLevel()
 	if logLevel < msgLevel {
@@ -81,6 +147,11 @@ func writef(msgLevel messageLevel, format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
 	message = strings.TrimRight(message, "\n")
 
+	if logFormat == JSONFormat {
+		fmt.Fprintf(logWriter, "%s\n", jsonLine(logLevel, msgLevel, message))
+		return
+	}
+
 	fmt.Fprintf(logWriter, "%s%s\n", prefix(logLevel, msgLevel), message)
 }
 
@@ -151,6 +222,29 @@ func GetEnvVar() string {
 	return fmt.Sprintf("APPTAINER_MESSAGELEVEL=%d", loggerLevel)
 }
 
+// SetFormat sets the output format of subsequent log messages, format
+// must be either TextFormat or JSONFormat.
+func SetFormat(format string) error {
+	switch format {
+	case TextFormat, JSONFormat:
+		logFormat = format
+	default:
+		return fmt.Errorf("unknown log format %q, must be %s or %s", format, TextFormat, JSONFormat)
+	}
+	return nil
+}
+
+// GetFormat returns the current log output format.
+func GetFormat() string {
+	return logFormat
+}
+
+// GetFormatEnvVar returns a formatted environment variable string
+// propagating the log output format to a child proc
+func GetFormatEnvVar() string {
+	return fmt.Sprintf("%s=%s", FormatEnv, logFormat)
+}
+
 // Writer returns an io.Writer to pass to an external packages logging utility.
 // i.e when --quiet option is set, this function returns io.Discard writer to ignore output
 func Writer() io.Writer {
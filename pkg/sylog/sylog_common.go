@@ -46,3 +46,15 @@ var messageLabels = map[messageLevel]string{
 	Verbose3Level: "VERBOSE",
 	DebugLevel:    "DEBUG",
 }
+
+// Log output formats.
+const (
+	// TextFormat is the default human readable output format.
+	TextFormat = "text"
+	// JSONFormat emits each message as a single line JSON object.
+	JSONFormat = "json"
+)
+
+// FormatEnv is the environment variable used to propagate the log
+// output format to child processes.
+const FormatEnv = "APPTAINER_MESSAGEFORMAT"
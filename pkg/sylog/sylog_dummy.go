@@ -86,6 +86,22 @@ func GetEnvVar() string {
 	return "APPTAINER_MESSAGELEVEL=-1"
 }
 
+// SetFormat is a dummy function doing nothing.
+func SetFormat(format string) error {
+	return nil
+}
+
+// GetFormat is a dummy function returning the text format.
+func GetFormat() string {
+	return TextFormat
+}
+
+// GetFormatEnvVar is a dummy function returning environment variable
+// with the text format.
+func GetFormatEnvVar() string {
+	return FormatEnv + "=" + TextFormat
+}
+
 // Writer is a dummy function returning io.Discard writer.
 func Writer() io.Writer {
 	return io.Discard
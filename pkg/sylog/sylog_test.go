@@ -13,6 +13,7 @@ package sylog
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -217,6 +218,88 @@ func TestWritef(t *testing.T) {
 	}
 }
 
+func TestWritefJSON(t *testing.T) {
+	const str = "just a test"
+
+	var buf bytes.Buffer
+	logWriter = &buf
+
+	defer func() {
+		logWriter = defaultWriter
+		logFormat = TextFormat
+	}()
+
+	if err := SetFormat("xml"); err == nil {
+		t.Fatalf("unexpected success with an unknown log format")
+	}
+	if err := SetFormat(JSONFormat); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		lvl      messageLevel
+		logLvl   messageLevel
+		levelStr string
+		debug    bool
+	}{
+		{
+			name:     "info",
+			lvl:      InfoLevel,
+			logLvl:   InfoLevel,
+			levelStr: "INFO",
+		},
+		{
+			name:     "warning",
+			lvl:      WarnLevel,
+			logLvl:   InfoLevel,
+			levelStr: "WARNING",
+		},
+		{
+			name:     "debug",
+			lvl:      DebugLevel,
+			logLvl:   DebugLevel,
+			levelStr: "DEBUG",
+			debug:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLevel(int(tt.logLvl), true)
+			buf.Reset()
+
+			writef(tt.lvl, "%s\n", str)
+
+			if strings.Count(buf.String(), "\n") != 1 {
+				t.Fatalf("expected a single line, got %q", buf.String())
+			}
+
+			var m jsonMessage
+			if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+				t.Fatalf("failed to decode %q: %s", buf.String(), err)
+			}
+			if m.Level != tt.levelStr {
+				t.Errorf("got level %q instead of %q", m.Level, tt.levelStr)
+			}
+			if m.Message != str {
+				t.Errorf("got message %q instead of %q", m.Message, str)
+			}
+			if m.Time == "" {
+				t.Errorf("missing timestamp")
+			}
+			if m.Component == "" {
+				t.Errorf("missing component")
+			}
+			if tt.debug && (m.UID == nil || m.PID == nil || m.Function == "") {
+				t.Errorf("missing debug fields in %q", buf.String())
+			} else if !tt.debug && (m.UID != nil || m.PID != nil) {
+				t.Errorf("unexpected debug fields in %q", buf.String())
+			}
+		})
+	}
+}
+
 func TestGetLevel(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -102,6 +102,7 @@ type File struct {
 	AllowSetuidMountEncrypted bool     `default:"yes" authorized:"yes,no" directive:"allow setuid-mount encrypted"`
 	AllowSetuidMountSquashfs  string   `default:"iflimited" authorized:"yes,no,iflimited" directive:"allow setuid-mount squashfs"`
 	AllowSetuidMountExtfs     bool     `default:"no" authorized:"yes,no" directive:"allow setuid-mount extfs"`
+	RequireImageDigest        bool     `default:"no" authorized:"yes,no" directive:"require image digest"`
 	AlwaysUseNv               bool     `default:"no" authorized:"yes,no" directive:"always use nv"`
 	UseNvCCLI                 bool     `default:"no" authorized:"yes,no" directive:"use nvidia-container-cli"`
 	AlwaysUseRocm             bool     `default:"no" authorized:"yes,no" directive:"always use rocm"`
@@ -109,7 +110,7 @@ type File struct {
 	MaxLoopDevices            uint     `default:"256" directive:"max loop devices"`
 	SessiondirMaxSize         uint     `default:"64" directive:"sessiondir max size"`
 	MountDev                  string   `default:"yes" authorized:"yes,no,minimal" directive:"mount dev"`
-	EnableOverlay             string   `default:"yes" authorized:"yes,no,try,driver" directive:"enable overlay"`
+	EnableOverlay             string   `default:"yes" authorized:"yes,no,try,driver,kernel" directive:"enable overlay"`
 	BindPath                  []string `default:"/etc/localtime,/etc/hosts" directive:"bind path"`
 	LimitContainerOwners      []string `directive:"limit container owners"`
 	LimitContainerGroups      []string `directive:"limit container groups"`
@@ -118,8 +119,17 @@ type File struct {
 	AllowNetGroups            []string `directive:"allow net groups"`
 	AllowNetNetworks          []string `directive:"allow net networks"`
 	AllowNetnsPaths           []string `directive:"allow netns paths"`
+	AllowOciHostNetwork       bool     `default:"yes" authorized:"yes,no" directive:"allow oci host network"`
+	AllowedRegistries         []string `directive:"allowed registries"`
+	DeniedRegistries          []string `directive:"denied registries"`
 	RootDefaultCapabilities   string   `default:"full" authorized:"full,file,no" directive:"root default capabilities"`
+	RootNoNewPrivs            bool     `default:"no" authorized:"yes,no" directive:"root no new privs"`
+	UserAmbientCapabilities   string   `default:"always" authorized:"always,request,never" directive:"user ambient capabilities"`
 	MemoryFSType              string   `default:"tmpfs" authorized:"tmpfs,ramfs" directive:"memory fs type"`
+	InstanceLogDriver         string   `default:"file" authorized:"file,syslog,journald,none" directive:"instance log driver"`
+	InstanceLogMaxSize        uint     `default:"0" directive:"instance log max size"`
+	InstanceLogMaxAge         uint     `default:"0" directive:"instance log max age"`
+	InstanceLogMaxFiles       uint     `default:"5" directive:"instance log max files"`
 	CniConfPath               string   `directive:"cni configuration path"`
 	CniPluginPath             string   `directive:"cni plugin path"`
 	BinaryPath                string   `default:"$PATH:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin" directive:"binary path"`
@@ -131,11 +141,47 @@ type File struct {
 	DownloadConcurrency uint   `default:"3" directive:"download concurrency"`
 	DownloadPartSize    uint   `default:"5242880" directive:"download part size"`
 	DownloadBufferSize  uint   `default:"32768" directive:"download buffer size"`
-	SystemdCgroups      bool   `default:"yes" authorized:"yes,no" directive:"systemd cgroups"`
+	// Limits applied to OCI images when they are pulled or converted
+	MaxImageCompressedSize   string `directive:"max image compressed size"`
+	MaxImageUncompressedSize string `directive:"max image uncompressed size"`
+	MaxImageLayers           uint   `default:"0" directive:"max image layers"`
+	// Temporary directories of image pulls and conversions
+	TransportTmpDirs []string `directive:"transport tmp dirs"`
+	TmpSpaceFactor   uint     `default:"3" directive:"tmp space factor"`
+	// Peer-to-peer distribution of OCI image blobs
+	P2PProxy           string   `default:"none" authorized:"none,proxy,mirror" directive:"p2p proxy"`
+	P2PProxyURL        string   `directive:"p2p proxy url"`
+	P2PProxyRegistries []string `directive:"p2p proxy registries"`
+	// Keyless verification of sigstore signatures
+	KeylessFulcioRoots       string   `directive:"keyless fulcio roots"`
+	KeylessRekorPublicKey    string   `directive:"keyless rekor public key"`
+	KeylessTrustedIdentities []string `directive:"keyless trusted identities"`
+	// Policy on the referrers of images pulled from oras:// URIs
+	OrasRequiredReferrers []string `directive:"oras required referrers"`
+	OrasVerifyReferrers   bool     `default:"no" authorized:"yes,no" directive:"oras verify referrers"`
+	// Restrict cryptographic algorithms to FIPS approved ones
+	FIPSMode       bool `default:"no" authorized:"yes,no" directive:"fips mode"`
+	SystemdCgroups bool `default:"yes" authorized:"yes,no" directive:"systemd cgroups"`
 	// apptheus unix socket
 	ApptheusSocketPath string `default:"/run/apptheus/gateway.sock" directive:"apptheus communication socket path"`
 	// Allow monitoring by apptheus, default is `no` because it requires an additional tool, i.e. apptheus
 	AllowMonitoring bool `default:"no" authorized:"yes,no" directive:"allow monitoring"`
+	// Default format of log messages, may be overridden with --log-format
+	LogFormat string `default:"text" authorized:"text,json" directive:"log format"`
+
+	LaunchHooksDir string `directive:"launch hooks dir"`
+
+	TagContainerCgroups bool `default:"no" authorized:"yes,no" directive:"tag container cgroups"`
+
+	CDIDirs []string `default:"/etc/cdi,/var/run/cdi" directive:"cdi dirs"`
+
+	// Named sets of action flags applied with --preset, values are not
+	// split on commas
+	Presets []string `directive:"preset" split:"no"`
+
+	// Runtime running images with the action commands, may be overridden
+	// by users and with --oci and --no-oci
+	DefaultRuntime string `default:"auto" authorized:"auto,native,oci" directive:"default runtime"`
 }
 
 // NOTE: if you think that we may want to change the default for any
@@ -154,6 +200,13 @@ const TemplateAsset = `# APPTAINER.CONF
 # This is the global configuration file for Apptainer. This file controls
 # what the container is allowed to do on a particular host, and as a result
 # this file must be owned by root.
+#
+# Directives set in this file may be overridden by configuration fragments
+# (*.conf files) located in the conf.d directory next to this file, applied
+# in lexical order. A fragment containing "match users = <user>[,<user>...]"
+# and/or "match groups = <group>[,<group>...]" directives is only applied for
+# the listed users or the members of the listed groups. Fragments must have
+# the same owner as this file and must not be writable by group or others.
 
 # ALLOW SETUID: [BOOL]
 # DEFAULT: yes
@@ -292,13 +345,17 @@ user bind control = {{ if eq .UserBindControl true }}yes{{ else }}no{{ end }}
 # command line option.
 enable fusemount = {{ if eq .EnableFusemount true }}yes{{ else }}no{{ end }}
 
-# ENABLE OVERLAY: [yes/no/driver/try]
+# ENABLE OVERLAY: [yes/no/driver/kernel/try]
 # DEFAULT: yes
 # Enabling this option will make it possible to specify bind paths to locations
 # that do not currently exist within the container.  If 'yes', kernel overlayfs
 # will be tried but if it doesn't work, the image driver (i.e. fuse-overlayfs)
-# will be used instead.  'try' is obsolete and treated the same as 'yes'.
+# will be used instead.  The image driver is used directly for overlay
+# directories located on filesystems refused by kernel overlayfs, like NFS or
+# Lustre upper directories.  'try' is obsolete and treated the same as 'yes'.
 # If 'driver' is chosen, overlay will always be handled by the image driver.
+# If 'kernel' is chosen, overlay will always be handled by kernel overlayfs,
+# without falling back to the image driver.
 # If 'no' is chosen, then no overlay type will be used for missing bind paths
 # nor for any other purpose.
 # The ENABLE UNDERLAY 'preferred' option below overrides this option for
@@ -502,6 +559,43 @@ allow container dir = {{ if eq .AllowContainerDir true }}yes{{ else }}no{{ end }
 {{- if eq $index 0 }}allow netns paths = {{ else }}, {{ end }}{{$path}}
 {{- end }}
 
+# ALLOW OCI HOST NETWORK: [BOOL]
+# DEFAULT: yes
+# Whether containers created with 'apptainer oci create' and 'apptainer oci run'
+# may share the network namespace of the host, either with '--net host' or
+# because their bundle configuration has no network namespace. When set to no,
+# these containers must use '--net none' or CNI network configurations.
+allow oci host network = {{ if eq .AllowOciHostNetwork true }}yes{{ else }}no{{ end }}
+
+# ALLOWED REGISTRIES: [STRING]
+# DEFAULT: NULL
+# Comma separated list of OCI registries, registry namespaces or shell
+# patterns (e.g. quay.io/biocontainers/*) that users are allowed to pull
+# from or run with the docker:// and oras:// URIs. When empty, any
+# registry not listed in denied registries is allowed.
+#allowed registries = registry.site.org, quay.io/biocontainers/*
+{{ range $index, $registry := .AllowedRegistries }}
+{{- if eq $index 0 }}allowed registries = {{ else }}, {{ end }}{{$registry}}
+{{- end }}
+
+# DENIED REGISTRIES: [STRING]
+# DEFAULT: NULL
+# Comma separated list of OCI registries, registry namespaces or shell
+# patterns that users are not allowed to pull from or run with the docker://
+# and oras:// URIs. This list takes precedence over allowed registries.
+#denied registries = docker.io/untrusted
+{{ range $index, $registry := .DeniedRegistries }}
+{{- if eq $index 0 }}denied registries = {{ else }}, {{ end }}{{$registry}}
+{{- end }}
+
+# REQUIRE IMAGE DIGEST: [BOOL]
+# DEFAULT: no
+# When enabled, images can only be pulled from or run with the docker:// and
+# oras:// URIs when the reference includes an explicit digest, for example
+# docker://alpine@sha256:<digest>, rejecting mutable tag references. Users
+# can opt in with the --require-digest flag when this is disabled.
+require image digest = {{ if eq .RequireImageDigest true }}yes{{ else }}no{{ end }}
+
 # ALWAYS USE NV ${TYPE}: [BOOL]
 # DEFAULT: no
 # This feature allows an administrator to determine that every action command
@@ -533,6 +627,27 @@ always use rocm = {{ if eq .AlwaysUseRocm true }}yes{{ else }}no{{ end }}
 # - no: no capabilities (same as --no-privs)
 root default capabilities = {{ .RootDefaultCapabilities }}
 
+# ROOT NO NEW PRIVS: [BOOL]
+# DEFAULT: no
+# Set no new privileges by default for the container processes of the root
+# user, preventing them from gaining privileges with setuid binaries or file
+# capabilities. Container processes of other users always run with no new
+# privileges. Root can override this default with --no-new-privs and
+# --allow-new-privs, this also applies to the 'apptainer oci' commands.
+root no new privs = {{ if eq .RootNoNewPrivs true }}yes{{ else }}no{{ end }}
+
+# USER AMBIENT CAPABILITIES: [always/request/never]
+# DEFAULT: always
+# Define when the capabilities granted to unprivileged users in
+# ${prefix}/etc/apptainer/capability.json are placed in the ambient set of
+# setuid containers, so that non-root container processes keep them across
+# exec, e.g. to bind low ports or use raw sockets without setuid helpers
+# - always: the granted capabilities are always ambient
+# - request: the granted capabilities are ambient only with --ambient-caps
+# - never: the granted capabilities are never ambient, only binaries with
+#          inheritable file capabilities can use them
+user ambient capabilities = {{ .UserAmbientCapabilities }}
+
 # MEMORY FS TYPE: [tmpfs/ramfs]
 # DEFAULT: tmpfs
 # This feature allow to choose temporary filesystem type used by Apptainer.
@@ -541,6 +656,36 @@ root default capabilities = {{ .RootDefaultCapabilities }}
 # kernel panic
 memory fs type = {{ .MemoryFSType }}
 
+# INSTANCE LOG DRIVER: [file/syslog/journald/none]
+# DEFAULT: file
+# Define where the output streams of instances are sent by default, users
+# can choose another driver with the --log-driver option of instance start
+# - file: log files in the instances directory of the user home
+# - syslog: syslog, with the logger command, tagged apptainer-<instance name>
+# - journald: the systemd journal, with the apptainer-<instance name>
+#             syslog identifier
+# - none: output streams are discarded
+instance log driver = {{ .InstanceLogDriver }}
+
+# INSTANCE LOG MAX SIZE: [INT]
+# DEFAULT: 0
+# Size in MiB above which the log files of instances using the file log
+# driver are rotated, a value of 0 disables the size limit.
+instance log max size = {{ .InstanceLogMaxSize }}
+
+# INSTANCE LOG MAX AGE: [INT]
+# DEFAULT: 0
+# Age in hours after which the log files of instances using the file log
+# driver are rotated, a value of 0 disables the age limit.
+instance log max age = {{ .InstanceLogMaxAge }}
+
+# INSTANCE LOG MAX FILES: [INT]
+# DEFAULT: 5
+# Number of rotated log files kept for each instance log file, named
+# <instance>.out.1 for the most recent one. With a value of 0, log files
+# are truncated without keeping their content.
+instance log max files = {{ .InstanceLogMaxFiles }}
+
 # CNI CONFIGURATION PATH: [STRING]
 # DEFAULT: Undefined
 # Defines path where CNI configuration files are stored
@@ -566,6 +711,8 @@ memory fs type = {{ .MemoryFSType }}
 # DEFAULT: 0 (All CPUs)
 # This allows the administrator to specify the number of CPUs for mksquashfs 
 # to use when building an image.  The fewer processors the longer it takes.
+# It also limits the CPUs used by unsquashfs and the number of OCI layers
+# decompressed concurrently during a build.
 # To enable it to use all available CPU's set this to 0.
 # mksquashfs procs = 0
 mksquashfs procs = {{ .MksquashfsProcs }}
@@ -616,6 +763,135 @@ download part size = {{ .DownloadPartSize }}
 # are enabled.
 download buffer size = {{ .DownloadBufferSize }}
 
+# MAX IMAGE COMPRESSED SIZE: [STRING]
+# DEFAULT: Unlimited
+# Maximum total size of the compressed layers of an OCI image being pulled
+# or converted to SIF, e.g. 10G for 10 gigabytes. Images exceeding this size
+# are rejected before their layers are downloaded.
+# max image compressed size = 10G
+{{ if ne .MaxImageCompressedSize "" }}max image compressed size = {{ .MaxImageCompressedSize }}{{ end }}
+
+# MAX IMAGE UNCOMPRESSED SIZE: [STRING]
+# DEFAULT: Unlimited
+# Maximum size of the root filesystem extracted from an OCI image during its
+# conversion, e.g. 20G for 20 gigabytes. The extraction is aborted when this
+# size is exceeded.
+# max image uncompressed size = 20G
+{{ if ne .MaxImageUncompressedSize "" }}max image uncompressed size = {{ .MaxImageUncompressedSize }}{{ end }}
+
+# MAX IMAGE LAYERS: [UINT]
+# DEFAULT: 0 (Unlimited)
+# Maximum number of layers of an OCI image being pulled or converted to SIF.
+max image layers = {{ .MaxImageLayers }}
+
+# TRANSPORT TMP DIRS: [STRING]
+# DEFAULT: NULL
+# Comma separated list of <transport>:<directory> pairs setting the temporary
+# directory used to pull and convert the images of a transport, when no
+# temporary directory is set with --tmpdir or APPTAINER_TMPDIR, e.g. to
+# convert OCI images on a large scratch filesystem.
+#transport tmp dirs = docker:/scratch/tmp, oras:/scratch/tmp
+{{ range $index, $dir := .TransportTmpDirs }}
+{{- if eq $index 0 }}transport tmp dirs = {{ else }}, {{ end }}{{$dir}}
+{{- end }}
+
+# TMP SPACE FACTOR: [UINT]
+# DEFAULT: 3
+# Before an OCI image is pulled or converted, the free space of the temporary
+# directory is checked against the compressed size of its layers, as reported
+# by its manifest, multiplied by this factor, to account for the extraction
+# of its root filesystem and its conversion to SIF. The free space of the
+# cache directory is checked against the size of the layers not cached yet.
+# The pull fails early when the space is insufficient. 0 disables the check.
+tmp space factor = {{ .TmpSpaceFactor }}
+
+# P2P PROXY: [none/proxy/mirror]
+# DEFAULT: none
+# Fetch the layers of OCI images pulled from docker:// and oras:// URIs
+# through the agent of a peer-to-peer distribution system running on the
+# node, such as Dragonfly or Kraken, so that nodes pulling the same image
+# share its layers with each other instead of all downloading them from the
+# registry. Manifests are always fetched from the registry, and layers are
+# fetched from the registry directly if the agent fails.
+# - none: layers are fetched from the registry directly
+# - proxy: the agent is used as an HTTP proxy for layer requests (e.g. the
+#          Dragonfly dfdaemon proxy)
+# - mirror: layer requests are sent to the agent as a registry mirror (e.g.
+#           the Dragonfly dfdaemon registry mirror, or the Kraken agent)
+p2p proxy = {{ .P2PProxy }}
+
+# P2P PROXY URL: [STRING]
+# DEFAULT: Undefined
+# URL of the peer-to-peer agent, required when p2p proxy is not none.
+# p2p proxy url = http://127.0.0.1:65001
+{{ if ne .P2PProxyURL "" }}p2p proxy url = {{ .P2PProxyURL }}{{ end }}
+
+# P2P PROXY REGISTRIES: [STRING]
+# DEFAULT: NULL
+# Comma separated list of registry hosts, or shell patterns matching them,
+# whose layers are fetched through the peer-to-peer agent. When empty, the
+# agent is used for all registries.
+#p2p proxy registries = docker.io, *.example.org
+{{ range $index, $registry := .P2PProxyRegistries }}
+{{- if eq $index 0 }}p2p proxy registries = {{ else }}, {{ end }}{{$registry}}
+{{- end }}
+
+# KEYLESS FULCIO ROOTS: [STRING]
+# DEFAULT: Undefined
+# Path to a PEM file holding the root and intermediate certificates of the
+# Fulcio certificate authority, used by 'verify --bundle' and 'oras verify
+# referrers' to verify keyless signatures. The root trust anchors added with
+# 'apptainer trust add root' are trusted in addition.
+# keyless fulcio roots = /etc/apptainer/sigstore/fulcio.pem
+{{ if ne .KeylessFulcioRoots "" }}keyless fulcio roots = {{ .KeylessFulcioRoots }}{{ end }}
+
+# KEYLESS REKOR PUBLIC KEY: [STRING]
+# DEFAULT: Undefined
+# Path to a PEM file holding the public key of the Rekor transparency log
+# which must record keyless signing certificates.
+# keyless rekor public key = /etc/apptainer/sigstore/rekor.pub
+{{ if ne .KeylessRekorPublicKey "" }}keyless rekor public key = {{ .KeylessRekorPublicKey }}{{ end }}
+
+# KEYLESS TRUSTED IDENTITIES: [STRING]
+# DEFAULT: NULL
+# Comma separated list of identities trusted to sign images keylessly, each
+# as an OIDC issuer followed by a regular expression matching the whole
+# subject (email address or URI) of the signing certificate. Repeat the
+# directive to add more identities.
+#keyless trusted identities = https://accounts.google.com .*@example\.org
+{{ range $index, $identity := .KeylessTrustedIdentities }}
+{{- if eq $index 0 }}keyless trusted identities = {{ else }}, {{ end }}{{$identity}}
+{{- end }}
+
+# ORAS REQUIRED REFERRERS: [STRING]
+# DEFAULT: NULL
+# Comma separated list of the artifact types which must be attached as OCI
+# referrers to the images pulled from oras:// URIs, as artifact types or as
+# one of sbom, spdx, cyclonedx, signature and provenance. Images lacking one
+# of them are refused. The referrers of pulled images are recorded in the
+# referrers cache, and the recorded ones are used when the registry can't be
+# reached.
+#oras required referrers = sbom, signature
+{{ range $index, $type := .OrasRequiredReferrers }}
+{{- if eq $index 0 }}oras required referrers = {{ else }}, {{ end }}{{$type}}
+{{- end }}
+
+# ORAS VERIFY REFERRERS: [BOOL]
+# DEFAULT: no
+# Whether images pulled from oras:// URIs must be verified by one of the
+# sigstore bundles attached to them as referrers, with the keyless trust set
+# by the keyless directives above.
+oras verify referrers = {{ if eq .OrasVerifyReferrers true }}yes{{ else }}no{{ end }}
+
+# FIPS MODE: [BOOL]
+# DEFAULT: no
+# Whether to restrict the signing, encryption and digest algorithms to the
+# FIPS 140 approved ones. Images signed with a non-approved key, or encrypted
+# with age or gocryptfs, are refused. FIPS mode is always enabled when the
+# kernel runs in FIPS mode (/proc/sys/crypto/fips_enabled set to 1), or when
+# apptainer is built with the fips tag.
+fips mode = {{ if eq .FIPSMode true }}yes{{ else }}no{{ end }}
+
 # SYSTEMD CGROUPS: [BOOL]
 # DEFAULT: yes
 # Whether to use systemd to manage container cgroups. Required for rootless cgroups
@@ -632,4 +908,75 @@ systemd cgroups = {{ if eq .SystemdCgroups true }}yes{{ else }}no{{ end }}
 # Allow to monitor the system resource usage of apptainer. To enable this option
 # additional tool, i.e. apptheus, is required.
 allow monitoring = {{ if eq .AllowMonitoring true }}yes{{ else }}no{{ end }}
+
+# LOG FORMAT: [STRING]
+# DEFAULT: text
+# Defines the default format of messages printed by apptainer. With 'json'
+# every message is emitted as a single JSON object per line containing the
+# level, timestamp, component and message fields, which is suitable for
+# ingestion by log aggregation systems. Users may override it with the
+# --log-format option.
+log format = {{ .LogFormat }}
+
+# LAUNCH HOOKS DIR: [STRING]
+# DEFAULT: Undefined
+# Directory of the executable hooks run before a container is launched, in
+# its pre-launch.d subdirectory, and after it exits, in its post-exit.d
+# subdirectory. Hooks are run in lexical order as the user running the
+# container, with a JSON description of the container on their standard
+# input. A pre-launch hook exiting with a non-zero status vetoes the launch,
+# the reason written on its standard error being reported to the user. Hooks
+# may write a JSON object on their standard output, with a "message" displayed
+# to the user and "annotations" passed to the following hooks. Hooks must be
+# owned by root and not writable by group or others.
+#launch hooks dir = /usr/local/etc/apptainer/hooks
+{{ if ne .LaunchHooksDir "" }}launch hooks dir = {{ .LaunchHooksDir }}{{ end }}
+
+# TAG CONTAINER CGROUPS: [BOOL]
+# DEFAULT: no
+# Whether to run every container in its own cgroup when possible, tagged with
+# the image it runs, the image digest and the instance name, as extended
+# attributes of the cgroup prefixed by user.apptainer. (image, image.digest
+# and instance). Runtime security monitoring tools, like eBPF based ones,
+# identifying processes by their cgroup can then attribute their events to
+# images. Requires cgroups v2.
+tag container cgroups = {{ if eq .TagContainerCgroups true }}yes{{ else }}no{{ end }}
+
+# CDI DIRS: [STRING]
+# DEFAULT: /etc/cdi,/var/run/cdi
+# Comma separated list of the directories of the Container Device Interface
+# (CDI) spec files, describing the devices requested with --device, by
+# increasing priority. Use 'apptainer cdi list' to list the devices they
+# describe, and 'apptainer cdi validate' to validate them.
+{{ range $index, $dir := .CDIDirs }}
+{{- if eq $index 0 }}cdi dirs = {{ else }}, {{ end }}{{$dir}}
+{{- end }}
+
+# PRESET: [STRING]
+# DEFAULT: Undefined
+# Defines a named set of flags of the action and instance commands (run,
+# exec, shell, test and instance start), applied with --preset <name>. The
+# value is the preset name followed by the flags, which are split into words
+# and expanded like a shell command line, without command substitution. Flags
+# given on the command line or by environment variables take precedence,
+# except for flags accepting multiple values (like --bind), to which the
+# preset values are added. Users may define their own presets, overriding
+# those with the same name, with preset directives in
+# ~/.apptainer/presets.conf. This directive can be repeated.
+#preset = gpu --nv --bind /scratch
+{{ range $preset := .Presets }}
+{{- if ne $preset "" }}preset = {{$preset}}
+{{ end }}
+{{- end }}
+
+# DEFAULT RUNTIME: [STRING]
+# DEFAULT: auto
+# Runtime running images with the action commands (run, exec, shell and
+# test): 'native' runs all images with the native runtime, 'oci' with the OCI
+# runtime, through a temporary bundle like with 'apptainer oci mount' and
+# 'apptainer oci run', and 'auto' runs OCI-SIF images with the OCI runtime and
+# the other images with the native runtime. The OCI runtime can only be used
+# by root. Users may override this directive with a default runtime directive
+# in ~/.apptainer/settings.conf, and --oci and --no-oci override both.
+default runtime = {{ .DefaultRuntime }}
 `
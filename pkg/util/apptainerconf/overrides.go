@@ -0,0 +1,159 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package apptainerconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/apptainer/apptainer/internal/pkg/util/user"
+	"github.com/apptainer/apptainer/pkg/sylog"
+)
+
+const (
+	// MatchUsersDirective restricts a configuration fragment to the
+	// listed user names or UIDs.
+	MatchUsersDirective = "match users"
+	// MatchGroupsDirective restricts a configuration fragment to the
+	// members of the listed group names or GIDs.
+	MatchGroupsDirective = "match groups"
+)
+
+// OverridesDir returns the drop-in directory holding the configuration
+// fragments which override the configuration file located at confPath.
+func OverridesDir(confPath string) string {
+	return filepath.Join(filepath.Dir(confPath), "conf.d")
+}
+
+// ParseWithOverrides parses the configuration file located at confPath
+// and applies on top of it the configuration fragments (*.conf files)
+// found in its drop-in directory, in lexical order. A fragment may
+// contain "match users" and/or "match groups" directives, in which case
+// it is only applied if the user identified by uid is listed in, or a
+// member of one of the groups listed in, those directives. A directive
+// set in a matching fragment replaces the value set previously. To be
+// considered, fragments must be owned by the owner of the configuration
+// file and must not be writable by group or others.
+func ParseWithOverrides(confPath string, uid int) (*File, error) {
+	if confPath == "" {
+		return Parse("")
+	}
+
+	c, err := os.Open(confPath)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	directives, err := GetDirectives(c)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing data: %s", err)
+	}
+
+	fi, err := c.Stat()
+	if err != nil {
+		return nil, err
+	}
+	owner := fi.Sys().(*syscall.Stat_t).Uid
+
+	fragments, err := filepath.Glob(filepath.Join(OverridesDir(confPath), "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(fragments)
+
+	for _, fragment := range fragments {
+		if err := applyFragment(directives, fragment, owner, uid); err != nil {
+			return nil, fmt.Errorf("while applying configuration fragment %s: %s", fragment, err)
+		}
+	}
+
+	return GetConfig(directives)
+}
+
+// applyFragment overrides directives with those of fragment if its
+// match conditions are satisfied by uid.
+func applyFragment(directives Directives, fragment string, owner uint32, uid int) error {
+	f, err := os.Open(fragment)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Sys().(*syscall.Stat_t).Uid != owner || fi.Mode().Perm()&0o022 != 0 {
+		sylog.Warningf("Ignoring configuration fragment %s: wrong ownership or permissions", fragment)
+		return nil
+	}
+
+	fragmentDirectives, err := GetDirectives(f)
+	if err != nil {
+		return err
+	}
+
+	ok, err := fragmentMatch(fragmentDirectives, uid)
+	if err != nil || !ok {
+		return err
+	}
+
+	sylog.Debugf("Applying configuration fragment %s", fragment)
+	for key, values := range fragmentDirectives {
+		if key == MatchUsersDirective || key == MatchGroupsDirective {
+			continue
+		}
+		directives[key] = values
+	}
+	return nil
+}
+
+// fragmentMatch returns whether the match conditions of a fragment,
+// if any, are satisfied by uid.
+func fragmentMatch(directives Directives, uid int) (bool, error) {
+	users := splitValues(directives[MatchUsersDirective])
+	groups := splitValues(directives[MatchGroupsDirective])
+	if len(users) == 0 && len(groups) == 0 {
+		return true, nil
+	}
+
+	if len(users) > 0 {
+		ok, err := user.UIDInList(uid, users)
+		if err != nil {
+			return false, fmt.Errorf("while checking user match: %s", err)
+		} else if ok {
+			return true, nil
+		}
+	}
+	if len(groups) > 0 {
+		ok, err := user.UIDInAnyGroup(uid, groups)
+		if err != nil {
+			return false, fmt.Errorf("while checking group match: %s", err)
+		}
+		return ok, nil
+	}
+	return false, nil
+}
+
+func splitValues(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package apptainerconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "apptainer.conf")
+
+	write := func(path, content string, perm os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			t.Fatalf("while writing %s: %s", path, err)
+		}
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatalf("while setting permissions on %s: %s", path, err)
+		}
+	}
+
+	write(configFile, "allow pid ns = no\nmax loop devices = 128\n", 0o644)
+
+	if err := os.Mkdir(OverridesDir(configFile), 0o755); err != nil {
+		t.Fatalf("while creating drop-in directory: %s", err)
+	}
+
+	uid := os.Getuid()
+	// another existing user, root or daemon
+	other := 0
+	if uid == 0 {
+		other = 1
+	}
+	fragments := filepath.Join(dir, "conf.d")
+	// applied to everyone
+	write(filepath.Join(fragments, "00-all.conf"), "max loop devices = 64\n", 0o644)
+	// applied to the current user
+	write(filepath.Join(fragments, "10-user.conf"), fmt.Sprintf("match users = nobody-%d, %d\nallow pid ns = yes\n", uid, uid), 0o644)
+	// not applied, no matching user
+	write(filepath.Join(fragments, "20-other.conf"), fmt.Sprintf("match users = %d\nmax loop devices = 1\n", other), 0o644)
+	// ignored, writable by others
+	write(filepath.Join(fragments, "30-writable.conf"), "max loop devices = 2\n", 0o666)
+	// ignored, not a .conf file
+	write(filepath.Join(fragments, "40-all.conf.bak"), "max loop devices = 3\n", 0o644)
+
+	config, err := ParseWithOverrides(configFile, uid)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !config.AllowPidNs {
+		t.Errorf("allow pid ns was not overridden for the matching user")
+	}
+	if config.MaxLoopDevices != 64 {
+		t.Errorf("got max loop devices %d instead of 64", config.MaxLoopDevices)
+	}
+
+	config, err = ParseWithOverrides(configFile, other)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if config.AllowPidNs {
+		t.Errorf("allow pid ns was overridden for a non matching user")
+	}
+	if config.MaxLoopDevices != 1 {
+		t.Errorf("got max loop devices %d instead of 1", config.MaxLoopDevices)
+	}
+}
@@ -105,7 +105,12 @@ func GetConfig(directives Directives) (*File, error) {
 		value := []string{}
 		if len(directives[dir]) > 0 {
 			for _, dv := range directives[dir] {
-				if dv != "" {
+				if dv == "" {
+					continue
+				}
+				if typeField.Tag.Get("split") == "no" {
+					value = append(value, dv)
+				} else {
 					value = append(value, strings.Split(dv, ",")...)
 				}
 			}
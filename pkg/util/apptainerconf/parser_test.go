@@ -125,6 +125,7 @@ func TestGetConfig(t *testing.T) {
 	directives["download concurrency"] = []string{"42"}
 	directives["download part size"] = []string{"1234"}
 	directives["download buffer size"] = []string{"4567"}
+	directives["preset"] = []string{"gpu --nv --bind /a,/b", "tmp --writable-tmpfs"}
 
 	config, err := GetConfig(directives)
 	if err != nil {
@@ -151,6 +152,9 @@ func TestGetConfig(t *testing.T) {
 	if config.DownloadBufferSize != 4567 {
 		t.Errorf("bad value for DownloadBufferSize: %v", config.DownloadPartSize)
 	}
+	if !reflect.DeepEqual(config.Presets, directives["preset"]) {
+		t.Errorf("bad value for Presets: %v", config.Presets)
+	}
 }
 
 func TestHasDirective(t *testing.T) {
@@ -0,0 +1,88 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cryptkey
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// LoadAgeRecipientsFile returns the age recipients listed in the file fn,
+// one per line.
+func LoadAgeRecipientsFile(fn string) ([]age.Recipient, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read age recipients from %s: %v", fn, err)
+	}
+	return recipients, nil
+}
+
+// LoadAgeIdentitiesFile returns the age identities stored in the file fn.
+func LoadAgeIdentitiesFile(fn string) ([]age.Identity, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read age identities from %s: %v", fn, err)
+	}
+	return identities, nil
+}
+
+// ageEncrypt encrypts plaintext to the recipients listed in the file fn,
+// returning an armored age file.
+func ageEncrypt(fn string, plaintext []byte) ([]byte, error) {
+	recipients, err := LoadAgeRecipientsFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ageDecrypt decrypts the armored age file msg with one of the identities
+// stored in the file fn.
+func ageDecrypt(fn string, msg []byte) ([]byte, error) {
+	identities, err := LoadAgeIdentitiesFile(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(msg)), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cryptkey
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeEncryptKey(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	recipients := filepath.Join(dir, "recipients.txt")
+	identity := filepath.Join(dir, "key.txt")
+	otherIdentity := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(recipients, []byte("# site key\n"+id.Recipient().String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(identity, []byte(id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(otherIdentity, []byte(other.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k := KeyInfo{Format: Age, Path: recipients}
+	if MessageType(k) != MessageAge {
+		t.Errorf("unexpected message type %v", MessageType(k))
+	}
+
+	plaintext, err := NewPlaintextKey(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msg, err := EncryptKey(k, plaintext)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decrypted, err := ageDecrypt(identity, msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("decrypted key doesn't match the plaintext key")
+	}
+
+	if _, err := ageDecrypt(otherIdentity, msg); err == nil {
+		t.Errorf("expected error decrypting with another identity")
+	}
+	if _, err := EncryptKey(KeyInfo{Format: Age, Path: identity}, plaintext); err == nil {
+		t.Errorf("expected error encrypting to an identity file")
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/apptainer/apptainer/pkg/util/fips"
 	"github.com/apptainer/sif/v2/pkg/sif"
 )
 
@@ -46,10 +47,24 @@ const (
 	PEM
 	// ENV indicates PEM content saved in an environment variable.
 	ENV
+	// Age indicates the key material is an age recipients file when
+	// encrypting, or an age identity file when decrypting.
+	Age
+	// PKCS11 indicates the key material is a PKCS#11 URI designating an RSA
+	// key held by a hardware token.
+	PKCS11
 	// hash size for encryption (Bytes)
 	Hash = 32
 )
 
+// SIF crypto message types of the encrypted keys, not defined by the SIF
+// specification. The key encrypted with age is stored as an armored age
+// file, and the PKCS#11 blob as a PEM message.
+const (
+	MessageAge    sif.MessageType = 0x1000
+	MessagePKCS11 sif.MessageType = 0x1100
+)
+
 // KeyInfo contains information for passing around
 // or extracting a passphrase for an encrypted container
 type KeyInfo struct {
@@ -69,7 +84,7 @@ func getRandomBytes(size int) ([]byte, error) {
 
 func NewPlaintextKey(k KeyInfo) ([]byte, error) {
 	switch k.Format {
-	case PEM:
+	case PEM, Age, PKCS11:
 		// in this case we will generate a random secret and
 		// encrypt it using the PEM key.use the PEM key to
 		// encrypt a secret
@@ -91,6 +106,9 @@ func EncryptKey(k KeyInfo, plaintext []byte) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("loading public key for key encryption: %v", err)
 		}
+		if err := checkFIPSKey(pubKey); err != nil {
+			return nil, err
+		}
 
 		msglen := len(plaintext)
 		step := pubKey.Size() - 2*Hash - 2
@@ -119,6 +137,23 @@ func EncryptKey(k KeyInfo, plaintext []byte) ([]byte, error) {
 
 		return buf.Bytes(), nil
 
+	case Age:
+		if err := fips.Forbid("age encryption"); err != nil {
+			return nil, err
+		}
+		data, err := ageEncrypt(k.Path, plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting key with age: %v", err)
+		}
+		return data, nil
+
+	case PKCS11:
+		data, err := pkcs11Encrypt(k.Material, plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting key with PKCS#11 token: %v", err)
+		}
+		return data, nil
+
 	case Passphrase:
 		return nil, nil
 
@@ -134,8 +169,11 @@ func PlaintextKey(k KeyInfo, image string) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("could not load PEM private key: %v", err)
 		}
+		if err := checkFIPSKey(&privateKey.PublicKey); err != nil {
+			return nil, err
+		}
 
-		pemKey, err := getEncryptionKeyFromImage(image)
+		pemKey, err := getEncryptionKeyFromImage(image, sif.MessageRSAOAEP)
 		if err != nil {
 			return nil, fmt.Errorf("could not get encryption information from SIF: %v", err)
 		}
@@ -169,6 +207,31 @@ func PlaintextKey(k KeyInfo, image string) ([]byte, error) {
 
 		return plainText.Bytes(), nil
 
+	case Age:
+		if err := fips.Forbid("age decryption"); err != nil {
+			return nil, err
+		}
+		msg, err := getEncryptionKeyFromImage(image, MessageAge)
+		if err != nil {
+			return nil, fmt.Errorf("could not get encryption information from SIF: %v", err)
+		}
+		plaintext, err := ageDecrypt(k.Path, msg)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt LUKS key with age: %v", err)
+		}
+		return plaintext, nil
+
+	case PKCS11:
+		msg, err := getEncryptionKeyFromImage(image, MessagePKCS11)
+		if err != nil {
+			return nil, fmt.Errorf("could not get encryption information from SIF: %v", err)
+		}
+		plaintext, err := pkcs11Decrypt(k.Material, msg)
+		if err != nil {
+			return nil, fmt.Errorf("could not decrypt LUKS key with PKCS#11 token: %v", err)
+		}
+		return plaintext, nil
+
 	case Passphrase:
 		return []byte(k.Material), nil
 
@@ -177,6 +240,31 @@ func PlaintextKey(k KeyInfo, image string) ([]byte, error) {
 	}
 }
 
+// checkFIPSKey ensures the RSA key encrypting the image key is approved when
+// FIPS mode is enabled.
+func checkFIPSKey(pub *rsa.PublicKey) error {
+	if !fips.Enabled() {
+		return nil
+	}
+	if err := fips.CheckPublicKey(pub); err != nil {
+		return fmt.Errorf("image key encryption with a %w", err)
+	}
+	return nil
+}
+
+// MessageType returns the SIF crypto message type of the key encrypted with
+// the key material.
+func MessageType(k KeyInfo) sif.MessageType {
+	switch k.Format {
+	case Age:
+		return MessageAge
+	case PKCS11:
+		return MessagePKCS11
+	default:
+		return sif.MessageRSAOAEP
+	}
+}
+
 func LoadPEMPrivateKey(k KeyInfo) (*rsa.PrivateKey, error) {
 	switch k.Format {
 	case PEM:
@@ -312,7 +400,7 @@ func savePEMMessage(w io.Writer, msg []byte) error {
 	return pem.Encode(w, b)
 }
 
-func getEncryptionKeyFromImage(fn string) ([]byte, error) {
+func getEncryptionKeyFromImage(fn string, messageType sif.MessageType) ([]byte, error) {
 	img, err := sif.LoadContainerFromPath(fn, sif.OptLoadWithFlag(os.O_RDONLY))
 	if err != nil {
 		return nil, fmt.Errorf("could not load container: %w", err)
@@ -338,8 +426,7 @@ func getEncryptionKeyFromImage(fn string) ([]byte, error) {
 			return nil, fmt.Errorf("could not get crypto message metadata: %w", err)
 		}
 
-		// currently only support one type of message
-		if format != sif.FormatPEM || message != sif.MessageRSAOAEP {
+		if format != sif.FormatPEM || message != messageType {
 			continue
 		}
 
@@ -0,0 +1,69 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package cryptkey
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/containers/ocicrypt/crypto/pkcs11"
+)
+
+// ParsePKCS11URI parses a PKCS#11 URI (RFC 7512) designating an RSA key held
+// by a hardware token. The module-path attribute of the URI selects the
+// PKCS#11 module, which is otherwise searched by module-name in the default
+// module directories of the distribution.
+func ParsePKCS11URI(uri string) (*pkcs11.Pkcs11KeyFileObject, error) {
+	p11uri, err := pkcs11.ParsePkcs11Uri(uri)
+	if err != nil {
+		return nil, err
+	}
+	if err := p11uri.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid PKCS#11 URI: %v", err)
+	}
+	// the module is chosen by the user running the command, in their own
+	// process, as for any other PKCS#11 client
+	p11uri.SetModuleDirectories(pkcs11.GetDefaultModuleDirectories())
+	p11uri.SetAllowAnyModule(true)
+	if _, err := p11uri.GetModule(); err != nil {
+		return nil, fmt.Errorf("invalid PKCS#11 URI: %v", err)
+	}
+	return &pkcs11.Pkcs11KeyFileObject{Uri: p11uri}, nil
+}
+
+// pkcs11Encrypt encrypts plaintext with the public key designated by uri,
+// returning the PKCS#11 blob wrapped in a PEM message.
+func pkcs11Encrypt(uri string, plaintext []byte) ([]byte, error) {
+	key, err := ParsePKCS11URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := pkcs11.EncryptMultiple([]interface{}{key}, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := savePEMMessage(&buf, blob); err != nil {
+		return nil, fmt.Errorf("serializing encrypted key: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pkcs11Decrypt decrypts the PEM message msg with the private key designated
+// by uri. The PIN of the token is taken from the pin-value or pin-source
+// attribute of the URI.
+func pkcs11Decrypt(uri string, msg []byte) ([]byte, error) {
+	key, err := ParsePKCS11URI(uri)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := loadPEMMessage(bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	return pkcs11.Decrypt([]*pkcs11.Pkcs11KeyFileObject{key}, blob)
+}
@@ -0,0 +1,11 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+//go:build !goexperiment.boringcrypto
+
+package fips
+
+// buildEnabled is true when FIPS mode is enforced at build time.
+const buildEnabled = false
@@ -0,0 +1,15 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+//go:build goexperiment.boringcrypto
+
+package fips
+
+// restrict TLS connections to the FIPS approved settings
+import _ "crypto/tls/fipsonly"
+
+// buildEnabled is true when FIPS mode is enforced at build time, apptainer
+// being built with the validated BoringCrypto module.
+const buildEnabled = true
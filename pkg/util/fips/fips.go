@@ -0,0 +1,127 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+// Package fips restricts the signing, encryption and digest algorithms to
+// the FIPS 140 approved ones when FIPS mode is enabled. FIPS mode is enabled
+// by the fips mode directive of apptainer.conf, by a kernel running in FIPS
+// mode, or when apptainer is built with GOEXPERIMENT=boringcrypto so that
+// the validated BoringCrypto module is used.
+package fips
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+// ErrNotApproved is returned when an algorithm not approved by FIPS 140 is
+// required while FIPS mode is enabled.
+var ErrNotApproved = errors.New("not approved in FIPS mode")
+
+// MinRSABits is the minimum size of the RSA keys approved by FIPS 186-5.
+const MinRSABits = 2048
+
+const kernelFIPSPath = "/proc/sys/crypto/fips_enabled"
+
+var (
+	kernelOnce    sync.Once
+	kernelEnabled bool
+)
+
+// Enabled returns whether FIPS mode is enabled.
+func Enabled() bool {
+	if buildEnabled {
+		return true
+	}
+	if c := apptainerconf.GetCurrentConfig(); c != nil && c.FIPSMode {
+		return true
+	}
+	kernelOnce.Do(func() {
+		b, err := os.ReadFile(kernelFIPSPath)
+		kernelEnabled = err == nil && string(bytes.TrimSpace(b)) == "1"
+	})
+	return kernelEnabled
+}
+
+// Forbid returns an error wrapping ErrNotApproved if FIPS mode is enabled,
+// what describing the non-approved algorithm or feature about to be used.
+func Forbid(what string) error {
+	if !Enabled() {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", what, ErrNotApproved)
+}
+
+// CheckHash returns an error if h isn't an approved digest algorithm.
+func CheckHash(h crypto.Hash) error {
+	switch h {
+	case crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512,
+		crypto.SHA512_224, crypto.SHA512_256,
+		crypto.SHA3_224, crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512:
+		return nil
+	}
+	return fmt.Errorf("%s digest algorithm: %w", h, ErrNotApproved)
+}
+
+// CheckPublicKey returns an error if pub isn't a public key of an approved
+// signature algorithm: RSA of at least MinRSABits, ECDSA on a NIST curve or
+// Ed25519.
+func CheckPublicKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if bits := k.N.BitLen(); bits < MinRSABits {
+			return fmt.Errorf("%d bits RSA key: %w", bits, ErrNotApproved)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		}
+		return fmt.Errorf("ECDSA key on curve %s: %w", k.Curve.Params().Name, ErrNotApproved)
+	case ed25519.PublicKey:
+		return nil
+	}
+	return fmt.Errorf("%T public key: %w", pub, ErrNotApproved)
+}
+
+// CheckPGPKey returns an error if pk isn't a PGP public key of an approved
+// signature algorithm.
+func CheckPGPKey(pk *packet.PublicKey) error {
+	switch pk.PubKeyAlgo {
+	case packet.PubKeyAlgoRSA, packet.PubKeyAlgoRSASignOnly:
+		bits, err := pk.BitLength()
+		if err != nil {
+			return err
+		}
+		if bits < MinRSABits {
+			return fmt.Errorf("%d bits RSA PGP key %X: %w", bits, pk.Fingerprint, ErrNotApproved)
+		}
+		return nil
+	case packet.PubKeyAlgoECDSA:
+		curve, err := pk.Curve()
+		if err != nil {
+			return err
+		}
+		switch curve {
+		case packet.CurveNistP256, packet.CurveNistP384, packet.CurveNistP521:
+			return nil
+		}
+		return fmt.Errorf("ECDSA PGP key %X on curve %s: %w", pk.Fingerprint, curve, ErrNotApproved)
+	case packet.PubKeyAlgoEdDSA, packet.PubKeyAlgoEd25519:
+		return nil
+	}
+	return fmt.Errorf("PGP key %X with algorithm %d: %w", pk.Fingerprint, pk.PubKeyAlgo, ErrNotApproved)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Contributors to the Apptainer project, established as
+//   Apptainer a Series of LF Projects LLC.
+//   For website terms of use, trademark policy, privacy policy and other
+//   project policies see https://lfprojects.org/policies
+
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"github.com/apptainer/apptainer/pkg/util/apptainerconf"
+)
+
+func TestCheckPublicKey(t *testing.T) {
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsa2048, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pub      crypto.PublicKey
+		approved bool
+	}{
+		{name: "RSA1024", pub: &rsa1024.PublicKey},
+		{name: "RSA2048", pub: &rsa2048.PublicKey, approved: true},
+		{name: "ECDSAP256", pub: &p256.PublicKey, approved: true},
+		{name: "Ed25519", pub: ed, approved: true},
+		{name: "Unknown", pub: []byte("key")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPublicKey(tt.pub)
+			if tt.approved && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !tt.approved && !errors.Is(err, ErrNotApproved) {
+				t.Errorf("got error %v, want %v", err, ErrNotApproved)
+			}
+		})
+	}
+}
+
+func TestCheckHash(t *testing.T) {
+	if err := CheckHash(crypto.SHA256); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, h := range []crypto.Hash{crypto.MD5, crypto.SHA1} {
+		if err := CheckHash(h); !errors.Is(err, ErrNotApproved) {
+			t.Errorf("%s: got error %v, want %v", h, err, ErrNotApproved)
+		}
+	}
+}
+
+func TestForbid(t *testing.T) {
+	defer apptainerconf.SetCurrentConfig(apptainerconf.GetCurrentConfig())
+	apptainerconf.SetCurrentConfig(&apptainerconf.File{FIPSMode: true})
+
+	if !Enabled() {
+		t.Fatalf("FIPS mode not enabled by configuration")
+	}
+	if err := Forbid("age encryption"); !errors.Is(err, ErrNotApproved) {
+		t.Errorf("got error %v, want %v", err, ErrNotApproved)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
@@ -341,3 +342,123 @@ func Getppid(pid int) (int, error) {
 
 	return -1, fmt.Errorf("no parent process ID found")
 }
+
+// clockTicks is the number of clock ticks per second used by the CPU times
+// of /proc/<pid>/stat (USER_HZ), fixed to 100 by the kernel ABI.
+const clockTicks = 100
+
+// Process holds the information of a process read from /proc.
+type Process struct {
+	Pid  int
+	PPid int
+	// NSpid holds the process ID in each nested PID namespace the
+	// process belongs to, from the PID namespace of the caller.
+	NSpid []int
+	UID   int
+	// RSS is the resident set size of the process in bytes.
+	RSS uint64
+	// CPUTime is the CPU time spent by the process in user and system mode.
+	CPUTime time.Duration
+	// StartTime is the time the process started after system boot.
+	StartTime time.Duration
+	Command   string
+}
+
+// GetProcess returns the information of the process pid read from
+// /proc/<pid>/status, /proc/<pid>/stat and /proc/<pid>/cmdline.
+func GetProcess(pid int) (*Process, error) {
+	p := &Process{Pid: pid}
+	name := ""
+
+	status := fmt.Sprintf("/proc/%d/status", pid)
+	f, err := os.Open(status)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %s", status, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		switch key {
+		case "Name":
+			name = fields[0]
+		case "PPid":
+			p.PPid, _ = strconv.Atoi(fields[0])
+		case "Uid":
+			p.UID, _ = strconv.Atoi(fields[0])
+		case "NSpid":
+			for _, f := range fields {
+				id, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, fmt.Errorf("bad NSpid entry in %s: %s", status, value)
+				}
+				p.NSpid = append(p.NSpid, id)
+			}
+		case "VmRSS":
+			kb, _ := strconv.ParseUint(fields[0], 10, 64)
+			p.RSS = kb * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("while reading %s: %s", status, err)
+	}
+	if len(p.NSpid) == 0 {
+		p.NSpid = []int{pid}
+	}
+
+	stat := fmt.Sprintf("/proc/%d/stat", pid)
+	b, err := os.ReadFile(stat)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", stat, err)
+	}
+	// the command name may contain spaces and parenthesis, fields
+	// start after its closing parenthesis with the process state
+	i := strings.LastIndexByte(string(b), ')')
+	if i < 0 {
+		return nil, fmt.Errorf("bad format of %s", stat)
+	}
+	fields := strings.Fields(string(b[i+1:]))
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("bad format of %s", stat)
+	}
+	ticks := func(s string) time.Duration {
+		n, _ := strconv.ParseUint(s, 10, 64)
+		return time.Duration(n) * time.Second / clockTicks
+	}
+	p.CPUTime = ticks(fields[11]) + ticks(fields[12])
+	p.StartTime = ticks(fields[19])
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err == nil {
+		p.Command = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+	if p.Command == "" {
+		p.Command = "[" + name + "]"
+	}
+	return p, nil
+}
+
+// Uptime returns the time elapsed since system boot.
+func Uptime() (time.Duration, error) {
+	b, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("bad format of /proc/uptime")
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad format of /proc/uptime: %s", err)
+	}
+	return time.Duration(uptime * float64(time.Second)), nil
+}
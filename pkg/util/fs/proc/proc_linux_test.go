@@ -12,6 +12,7 @@ package proc
 import (
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -517,3 +518,40 @@ func TestGetppid(t *testing.T) {
 		}
 	}
 }
+
+func TestGetProcess(t *testing.T) {
+	test.DropPrivilege(t)
+	defer test.ResetPrivilege(t)
+
+	if _, err := GetProcess(0); err == nil {
+		t.Fatalf("unexpected success for process ID 0")
+	}
+
+	pid := os.Getpid()
+	p, err := GetProcess(pid)
+	if err != nil {
+		t.Fatalf("unexpected failure for current process: %s", err)
+	}
+	if p.Pid != pid || p.PPid != os.Getppid() {
+		t.Errorf("unexpected process IDs: got %d/%d instead of %d/%d", p.Pid, p.PPid, pid, os.Getppid())
+	}
+	if p.UID != os.Getuid() {
+		t.Errorf("unexpected user ID: got %d instead of %d", p.UID, os.Getuid())
+	}
+	if p.NSpid[len(p.NSpid)-1] != pid {
+		t.Errorf("unexpected namespace process IDs %v for process %d", p.NSpid, pid)
+	}
+	if p.RSS == 0 {
+		t.Errorf("unexpected zero resident set size")
+	}
+	if p.Command != strings.Join(os.Args, " ") {
+		t.Errorf("unexpected command %q", p.Command)
+	}
+	uptime, err := Uptime()
+	if err != nil {
+		t.Fatalf("unexpected failure reading uptime: %s", err)
+	}
+	if p.StartTime > uptime {
+		t.Errorf("process start time %s is after uptime %s", p.StartTime, uptime)
+	}
+}